@@ -16,6 +16,11 @@ type Error struct {
 	// `err.Error()`. This field is required.
 	Message string `json:"message"`
 
+	// Code identifies the error, such as "wallet.insufficient_funds". If it
+	// is not set, writeError sets it from the HTTP status.
+	// Clients should branch on Code rather than on Message.
+	Code string `json:"code,omitempty"`
+
 	// TODO: add a Param field with the (omitempty option in the json tag)
 	// to indicate that the error was caused by an invalid, missing, or
 	// incorrect parameter. This is not trivial as the API does not
@@ -89,7 +94,7 @@ func HttpPOSTAuthenticated(url string, data string, password string) (resp *http
 func requireUserAgent(h http.Handler, ua string) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		if !strings.Contains(req.UserAgent(), ua) {
-			writeError(w, Error{"Browser access disabled due to security vulnerability. Use Sia-UI or siac."}, http.StatusBadRequest)
+			writeError(w, Error{Message: "Browser access disabled due to security vulnerability. Use Sia-UI or siac.", Code: CodeUserAgentRequired}, http.StatusBadRequest)
 			return
 		}
 		h.ServeHTTP(w, req)
@@ -108,7 +113,7 @@ func requirePassword(h httprouter.Handle, password string) httprouter.Handle {
 		_, pass, ok := req.BasicAuth()
 		if !ok || pass != password {
 			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
-			writeError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
+			writeError(w, Error{Message: "API authentication failed."}, http.StatusUnauthorized)
 			return
 		}
 		h(w, req, ps)
@@ -124,13 +129,38 @@ func (srv *Server) initAPI(password string) {
 	// Daemon API Calls
 	router.GET("/daemon/constants", srv.daemonConstantsHandler)
 	router.GET("/daemon/version", srv.daemonVersionHandler)
+	router.GET("/daemon/events", srv.daemonEventsHandler)
 	router.GET("/daemon/update", srv.daemonUpdateHandlerGET)
 	router.POST("/daemon/update", srv.daemonUpdateHandlerPOST)
 	router.GET("/daemon/stop", requirePassword(srv.daemonStopHandler, password))
+	router.GET("/daemon/bandwidth", srv.daemonBandwidthHandler)
+	router.GET("/daemon/alerts", srv.daemonAlertsHandlerGET)
+	router.POST("/daemon/alerts/clear", requirePassword(srv.daemonAlertsClearHandler, password))
+	router.GET("/daemon/jobs", srv.daemonJobsHandler)
+	router.GET("/daemon/jobs/:id", srv.daemonJobHandler)
+	router.POST("/daemon/jobs/:id/cancel", requirePassword(srv.daemonJobCancelHandler, password))
+	router.POST("/daemon/backup", requirePassword(srv.limitRate(srv.daemonBackupHandler), password))
+
+	// Health API Calls
+	router.GET("/health", srv.healthHandler)
+	router.GET("/ready", srv.readyHandler)
+
+	// Debug API Calls
+	router.GET("/debug/pprof/*profile", requirePassword(srv.requireDebug(srv.debugPprofHandler), password))
+	router.GET("/debug/stats", requirePassword(srv.requireDebug(srv.debugStatsHandler), password))
 
 	// Consensus API Calls
 	if srv.cs != nil {
 		router.GET("/consensus", srv.consensusHandler)
+		router.GET("/consensus/alerts", srv.consensusAlertsHandler)
+		router.GET("/consensus/checksum", srv.consensusChecksumHandler)
+		router.POST("/consensus/snapshot", srv.limitRate(srv.consensusSnapshotHandler))
+		router.GET("/consensus/stats", srv.consensusStatsHandler)
+		router.GET("/consensus/siacoinoutputs", srv.consensusSiacoinOutputsHandler)
+		router.GET("/consensus/siacoinoutputs/:id", srv.consensusSiacoinOutputHandler)
+		router.GET("/consensus/siafundoutputs", srv.consensusSiafundOutputsHandler)
+		router.GET("/consensus/siafundoutputs/:id", srv.consensusSiafundOutputHandler)
+		router.GET("/consensus/filecontracts/:id", srv.consensusFileContractHandler)
 	}
 
 	// Explorer API Calls
@@ -143,8 +173,19 @@ func (srv *Server) initAPI(password string) {
 	// Gateway API Calls
 	if srv.gateway != nil {
 		router.GET("/gateway", srv.gatewayHandler)
+		router.GET("/gateway/peers", srv.gatewayPeersHandlerGET)
 		router.POST("/gateway/connect/:netaddress", requirePassword(srv.gatewayConnectHandler, password))
 		router.POST("/gateway/disconnect/:netaddress", requirePassword(srv.gatewayDisconnectHandler, password))
+		router.GET("/gateway/bans", srv.gatewayBansHandlerGET)
+		router.POST("/gateway/ban/:host", requirePassword(srv.gatewayBanHandler, password))
+		router.POST("/gateway/unban/:host", requirePassword(srv.gatewayUnbanHandler, password))
+		router.GET("/gateway/alerts", srv.gatewayAlertsHandlerGET)
+		router.POST("/gateway/alerts", requirePassword(srv.gatewayAlertsHandlerPOST, password))
+
+		// The blocklist is owned by the gateway, and shared with the host.
+		router.GET("/daemon/blocklist", srv.daemonBlocklistHandlerGET)
+		router.POST("/daemon/blocklist/add", requirePassword(srv.daemonBlocklistAddHandler, password))
+		router.POST("/daemon/blocklist/remove", requirePassword(srv.daemonBlocklistRemoveHandler, password))
 	}
 
 	// Host API Calls
@@ -153,6 +194,7 @@ func (srv *Server) initAPI(password string) {
 		router.GET("/host", srv.hostHandlerGET)                                           // Get the host status.
 		router.POST("/host", requirePassword(srv.hostHandlerPOST, password))              // Change the settings of the host.
 		router.POST("/host/announce", requirePassword(srv.hostAnnounceHandler, password)) // Announce the host to the network.
+		router.GET("/host/settings", srv.hostSettingsHandler)                             // Get the settings advertised to renters.
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", srv.storageHandler)
@@ -162,9 +204,25 @@ func (srv *Server) initAPI(password string) {
 		router.POST("/host/storage/sectors/delete/:merkleroot", requirePassword(srv.storageSectorsDeleteHandler, password))
 	}
 
+	// Light client API Calls
+	if srv.lightClient != nil {
+		router.GET("/lightclient", srv.lightClientHandler)
+		router.POST("/lightclient/synchronize/:netaddress", requirePassword(srv.lightClientSynchronizeHandler, password))
+		router.POST("/lightclient/track", requirePassword(srv.lightClientTrackHandler, password))
+		router.GET("/lightclient/transactions", srv.lightClientTransactionsHandler)
+	}
+
 	// Miner API Calls
 	if srv.miner != nil {
 		router.GET("/miner", srv.minerHandler)
+		router.POST("/miner/block", requirePassword(srv.minerBlockHandlerPOST, password))
+		router.GET("/miner/blocktemplate", requirePassword(srv.minerBlockTemplateHandlerGET, password))
+		router.GET("/miner/payouts", srv.minerPayoutsHandlerGET)
+		router.POST("/miner/payouts", requirePassword(srv.minerPayoutsHandlerPOST, password))
+		router.POST("/miner/mineblocks", requirePassword(srv.minerMineBlocksHandler, password))
+		router.GET("/miner/pool", srv.minerPoolHandlerGET)
+		router.POST("/miner/pool/start", requirePassword(srv.minerPoolStartHandler, password))
+		router.POST("/miner/pool/stop", requirePassword(srv.minerPoolStopHandler, password))
 		router.GET("/miner/header", requirePassword(srv.minerHeaderHandlerGET, password))
 		router.POST("/miner/header", requirePassword(srv.minerHeaderHandlerPOST, password))
 		router.GET("/miner/start", requirePassword(srv.minerStartHandler, password))
@@ -187,9 +245,9 @@ func (srv *Server) initAPI(password string) {
 		// router.GET("/renter/shareascii", requirePassword(srv.renterShareAsciiHandler, password))
 
 		router.POST("/renter/delete/*siapath", requirePassword(srv.renterDeleteHandler, password))
-		router.GET("/renter/download/*siapath", requirePassword(srv.renterDownloadHandler, password))
+		router.GET("/renter/download/*siapath", requirePassword(srv.limitRate(srv.renterDownloadHandler), password))
 		router.POST("/renter/rename/*siapath", requirePassword(srv.renterRenameHandler, password))
-		router.POST("/renter/upload/*siapath", requirePassword(srv.renterUploadHandler, password))
+		router.POST("/renter/upload/*siapath", requirePassword(srv.limitRate(srv.renterUploadHandler), password))
 
 		// HostDB endpoints.
 		router.GET("/hostdb/active", srv.renterHostsActiveHandler)
@@ -200,40 +258,46 @@ func (srv *Server) initAPI(password string) {
 	if srv.tpool != nil {
 		// TODO: re-enable this route once the transaction pool API has been finalized
 		//router.GET("/transactionpool/transactions", srv.transactionpoolTransactionsHandler)
+		router.GET("/transactionpool/conflicted/:id", srv.transactionpoolConflictedHandler)
 	}
 
 	// Wallet API Calls
 	if srv.wallet != nil {
 		router.GET("/wallet", srv.walletHandler)
-		router.POST("/wallet/033x", requirePassword(srv.wallet033xHandler, password))
+		router.POST("/wallet/033x", requirePassword(srv.limitRate(srv.wallet033xHandler), password))
 		router.GET("/wallet/address", requirePassword(srv.walletAddressHandler, password))
 		router.GET("/wallet/addresses", srv.walletAddressesHandler)
 		router.GET("/wallet/backup", requirePassword(srv.walletBackupHandler, password))
-		router.POST("/wallet/init", requirePassword(srv.walletInitHandler, password))
+		router.POST("/wallet/faucet", requirePassword(srv.walletFaucetHandler, password))
+		router.POST("/wallet/init", requirePassword(srv.limitRate(srv.walletInitHandler), password))
 		router.POST("/wallet/lock", requirePassword(srv.walletLockHandler, password))
-		router.POST("/wallet/seed", requirePassword(srv.walletSeedHandler, password))
+		router.POST("/wallet/seed", requirePassword(srv.limitRate(srv.walletSeedHandler), password))
 		router.GET("/wallet/seeds", requirePassword(srv.walletSeedsHandler, password))
 		router.POST("/wallet/siacoins", requirePassword(srv.walletSiacoinsHandler, password))
 		router.POST("/wallet/siafunds", requirePassword(srv.walletSiafundsHandler, password))
-		router.POST("/wallet/siagkey", requirePassword(srv.walletSiagkeyHandler, password))
+		router.POST("/wallet/siagkey", requirePassword(srv.limitRate(srv.walletSiagkeyHandler), password))
 		router.GET("/wallet/transaction/:id", srv.walletTransactionHandler)
 		router.GET("/wallet/transactions", srv.walletTransactionsHandler)
 		router.GET("/wallet/transactions/:addr", srv.walletTransactionsAddrHandler)
-		router.POST("/wallet/unlock", requirePassword(srv.walletUnlockHandler, password))
+		router.POST("/wallet/unlock", requirePassword(srv.limitRate(srv.walletUnlockHandler), password))
 	}
 
-	// Apply UserAgent middleware and create HTTP server
+	// Apply UserAgent, CORS, public call and audit middleware and create
+	// HTTP server
 	uaRouter := requireUserAgent(router, srv.requiredUserAgent)
-	srv.apiServer = &http.Server{Handler: uaRouter}
+	srv.apiServer = &http.Server{Handler: srv.auditCalls(allowPublicCalls(srv.allowCORS(uaRouter, router), router))}
 }
 
 // unrecognizedCallHandler handles calls to unknown pages (404).
 func (srv *Server) unrecognizedCallHandler(w http.ResponseWriter, req *http.Request) {
-	writeError(w, Error{"404 - Refer to API.md"}, http.StatusNotFound)
+	writeError(w, Error{Message: "404 - Refer to API.md"}, http.StatusNotFound)
 }
 
 // writeError an error to the API caller.
 func writeError(w http.ResponseWriter, err Error, code int) {
+	if err.Code == "" {
+		err.Code = statusCode(code)
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(code)
 	if json.NewEncoder(w).Encode(err) != nil {
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// auditedGETs are the GET calls that change the state of the node, and are
+// recorded in the audit log along with every call that is not a GET.
+var auditedGETs = map[string]bool{
+	"/daemon/stop":    true,
+	"/miner/start":    true,
+	"/miner/stop":     true,
+	"/wallet/address": true,
+	"/wallet/backup":  true,
+}
+
+// redactedParams are substrings of the names of the parameters whose values
+// are secret, and are not written to the audit log.
+var redactedParams = []string{"password", "seed", "key"}
+
+// An AuditEntry records a call to the API that changes the state of the node.
+// The audit log holds one entry per line, encoded as JSON.
+type AuditEntry struct {
+	Time       time.Time  `json:"time"`
+	RemoteAddr string     `json:"remoteaddr"`
+	UserAgent  string     `json:"useragent"`
+	Method     string     `json:"method"`
+	Path       string     `json:"path"`
+	Params     url.Values `json:"params"`
+	Status     int        `json:"status"`
+}
+
+// SetAuditLog makes the server append an entry to the file 'filename' for
+// each call to the API that changes the state of the node, whether it
+// succeeds or not. The file is created if it does not exist, and is never
+// truncated.
+func (srv *Server) SetAuditLog(filename string) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.auditLog != nil {
+		srv.auditLog.Close()
+	}
+	srv.auditLog = f
+	return nil
+}
+
+// audited returns whether a call is recorded in the audit log.
+func audited(req *http.Request) bool {
+	switch req.Method {
+	case "GET", "HEAD":
+		return auditedGETs[req.URL.Path]
+	case "OPTIONS":
+		return false
+	default:
+		return true
+	}
+}
+
+// redactParams returns a copy of 'params' in which the values of the secret
+// parameters are replaced.
+func redactParams(params url.Values) url.Values {
+	redacted := make(url.Values, len(params))
+	for name, values := range params {
+		redacted[name] = values
+		for _, secret := range redactedParams {
+			if strings.Contains(strings.ToLower(name), secret) {
+				redacted[name] = []string{"[redacted]"}
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+// writeAuditEntry appends an entry to the audit log, if one is set.
+func (srv *Server) writeAuditEntry(e AuditEntry) {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.auditLog == nil {
+		return
+	}
+	if _, err := srv.auditLog.Write(append(b, '\n')); err == nil {
+		err = srv.auditLog.Sync()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to write to the API audit log:", err)
+	}
+}
+
+// statusRecorder is an http.ResponseWriter that records the status of the
+// response.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status and writes it to the response.
+func (sr *statusRecorder) WriteHeader(status int) {
+	if sr.status == 0 {
+		sr.status = status
+	}
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// Write writes the body of the response, which has status 200 OK if no other
+// status was written.
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.status == 0 {
+		sr.status = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// Flush sends the buffered response to the client, if the underlying
+// ResponseWriter supports it.
+func (sr *statusRecorder) Flush() {
+	if f, ok := sr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// auditCalls is middleware that records the calls that change the state of
+// the node in the audit log. The parameters are taken from the request after
+// it is handled, so that the body of the request is only read by the
+// handler.
+func (srv *Server) auditCalls(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		srv.mu.Lock()
+		enabled := srv.auditLog != nil
+		srv.mu.Unlock()
+		if !enabled || !audited(req) {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w}
+		h.ServeHTTP(sr, req)
+		params := req.Form
+		if params == nil {
+			params = req.URL.Query()
+		}
+		if sr.status == 0 {
+			sr.status = http.StatusOK
+		}
+		srv.writeAuditEntry(AuditEntry{
+			Time:       start,
+			RemoteAddr: req.RemoteAddr,
+			UserAgent:  req.UserAgent(),
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			Params:     redactParams(params),
+			Status:     sr.status,
+		})
+	})
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestRedactParams checks that the values of secret parameters are not
+// written to the audit log.
+func TestRedactParams(t *testing.T) {
+	params := url.Values{
+		"amount":             {"1000"},
+		"encryptionpassword": {"foo"},
+		"seed":               {"words"},
+		"keyfiles":           {"/tmp/key"},
+	}
+	redacted := redactParams(params)
+	if redacted.Get("amount") != "1000" {
+		t.Error("parameter was redacted:", redacted)
+	}
+	for _, name := range []string{"encryptionpassword", "seed", "keyfiles"} {
+		if redacted.Get(name) != "[redacted]" {
+			t.Errorf("%v was not redacted: %v", name, redacted)
+		}
+	}
+	if params.Get("seed") != "words" {
+		t.Error("redactParams modified its argument")
+	}
+}
+
+// TestIntegrationAuditLog checks that the calls that change the state of the
+// node are recorded in the audit log, and that other calls are not.
+func TestIntegrationAuditLog(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationAuditLog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	filename := filepath.Join(st.dir, "audit.log")
+	if err := st.server.SetAuditLog(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.stdGetAPI("/wallet"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.stdPostAPI("/daemon/blocklist/add", url.Values{"range": {"10.0.0.0/8"}}); err != nil {
+		t.Fatal(err)
+	}
+	defer st.gateway.Blocklist().Unblock("10.0.0.0/8")
+	if err := st.stdPostAPI("/wallet/unlock", url.Values{"encryptionpassword": {"wrong"}}); err == nil {
+		t.Fatal("wallet was unlocked with the wrong password")
+	}
+	if err := st.stdGetAPI("/miner/stop"); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) != 3 {
+		t.Fatal("expected 3 audit entries, got", entries)
+	}
+	if e := entries[0]; e.Method != "POST" || e.Path != "/daemon/blocklist/add" || e.Params.Get("range") != "10.0.0.0/8" || e.Status != http.StatusNoContent || e.RemoteAddr == "" || e.Time.IsZero() {
+		t.Error("wrong entry for a successful call:", e)
+	}
+	if e := entries[1]; e.Path != "/wallet/unlock" || e.Params.Get("encryptionpassword") != "[redacted]" || e.Status < 400 {
+		t.Error("wrong entry for a failed call:", e)
+	}
+	if e := entries[2]; e.Method != "GET" || e.Path != "/miner/stop" {
+		t.Error("wrong entry for a GET call that changes state:", e)
+	}
+}
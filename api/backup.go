@@ -0,0 +1,126 @@
+package api
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	errNoBackupModules = errors.New("none of the wallet, renter, and host are loaded")
+)
+
+// DaemonBackupPOST lists the modules whose metadata was written to a backup,
+// and the height of the consensus set when the backup was made.
+type DaemonBackupPOST struct {
+	Modules []string          `json:"modules"`
+	Height  types.BlockHeight `json:"height"`
+}
+
+// managedCreateBackup writes the metadata of the wallet, renter, and host to
+// 'f' as a backup encrypted with 'password', and removes the file if the
+// backup fails. The metadata is collected in a temporary directory next to
+// 'f', which is removed once the backup is written.
+func (srv *Server) managedCreateBackup(f *os.File, password string) (DaemonBackupPOST, error) {
+	dbp, err := srv.writeBackup(f, password)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return DaemonBackupPOST{}, err
+	}
+	return dbp, nil
+}
+
+// writeBackup collects the metadata of the modules and writes the backup to
+// 'f'.
+func (srv *Server) writeBackup(f *os.File, password string) (DaemonBackupPOST, error) {
+	staging, err := ioutil.TempDir(filepath.Dir(f.Name()), ".sia-backup")
+	if err != nil {
+		return DaemonBackupPOST{}, err
+	}
+	defer os.RemoveAll(staging)
+
+	manifest := modules.BackupManifest{
+		Created: time.Now(),
+	}
+	if srv.cs != nil {
+		manifest.Height = srv.cs.Height()
+	}
+	backups := []struct {
+		dir    string
+		loaded bool
+		backup func(string) error
+	}{
+		{modules.WalletDir, srv.wallet != nil, func(dir string) error { return srv.wallet.BackupMetadata(dir) }},
+		{modules.RenterDir, srv.renter != nil, func(dir string) error { return srv.renter.BackupMetadata(dir) }},
+		{modules.HostDir, srv.host != nil, func(dir string) error { return srv.host.BackupMetadata(dir) }},
+	}
+	for _, b := range backups {
+		if !b.loaded {
+			continue
+		}
+		if err := b.backup(filepath.Join(staging, b.dir)); err != nil {
+			return DaemonBackupPOST{}, errors.New("unable to back up the " + b.dir + ": " + err.Error())
+		}
+		manifest.Modules = append(manifest.Modules, b.dir)
+	}
+
+	err = modules.WriteBackup(f, staging, manifest, modules.BackupKey(password))
+	if err != nil {
+		return DaemonBackupPOST{}, err
+	}
+	return DaemonBackupPOST{
+		Modules: manifest.Modules,
+		Height:  manifest.Height,
+	}, nil
+}
+
+// daemonBackupHandler handles the API calls to /daemon/backup, writing a
+// backup of the wallet, renter, and host to the destination file. If 'async'
+// is true, the backup is written by a job.
+func (srv *Server) daemonBackupHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if srv.wallet == nil && srv.renter == nil && srv.host == nil {
+		writeError(w, Error{Message: errNoBackupModules.Error()}, http.StatusBadRequest)
+		return
+	}
+	destination := req.FormValue("destination")
+	if !filepath.IsAbs(destination) {
+		writeError(w, Error{Message: "destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	password := req.FormValue("encryptionpassword")
+	if password == "" {
+		writeError(w, Error{Message: "encryptionpassword must be set"}, http.StatusBadRequest)
+		return
+	}
+	f, err := os.OpenFile(destination, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		writeError(w, Error{Message: "unable to create backup file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.FormValue("async") == "true" {
+		srv.startJob(w, "backup", false, func(<-chan struct{}, func(uint64, uint64)) (interface{}, error) {
+			return srv.managedCreateBackup(f, password)
+		})
+		return
+	}
+	dbp, err := srv.managedCreateBackup(f, password)
+	if err != nil {
+		writeError(w, Error{Message: "unable to create backup: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, dbp)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/wallet"
+)
+
+// TestIntegrationBackup checks that /daemon/backup writes the metadata of the
+// wallet, renter, and host, and that a wallet restored from the backup has
+// the seed of the original wallet.
+func TestIntegrationBackup(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationBackup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	dir := build.TempDir("api", "TestIntegrationBackup", "backup")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	destination := filepath.Join(dir, "sia.backup")
+	if err := st.stdPostAPI("/daemon/backup", url.Values{"destination": {destination}}); err == nil {
+		t.Fatal("backup without a password was accepted")
+	}
+	if err := st.stdPostAPI("/daemon/backup", url.Values{"destination": {"sia.backup"}, "encryptionpassword": {"foo"}}); err == nil {
+		t.Fatal("backup to a relative path was accepted")
+	}
+	var dbp DaemonBackupPOST
+	err = st.postAPI("/daemon/backup", url.Values{"destination": {destination}, "encryptionpassword": {"foo"}}, &dbp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dbp.Modules) != 3 || dbp.Height != st.cs.Height() {
+		t.Fatal("wrong response for backup:", dbp)
+	}
+
+	// The staging directory is removed once the backup is written.
+	entries, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatal("backup left files behind:", entries)
+	}
+
+	f, err := os.Open(destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	restored := filepath.Join(dir, "restored")
+	if _, err := modules.ReadBackup(f, restored, modules.BackupKey("foo")); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		filepath.Join(modules.RenterDir, "renter.json"),
+		filepath.Join(modules.RenterDir, "contractor.json"),
+		filepath.Join(modules.HostDir, "host.json"),
+		filepath.Join(modules.HostDir, "host.db"),
+		filepath.Join(modules.HostDir, modules.StorageManagerDir, "storagemanager.json"),
+		filepath.Join(modules.HostDir, modules.StorageManagerDir, "storagemanager.db"),
+	} {
+		if _, err := os.Stat(filepath.Join(restored, name)); err != nil {
+			t.Error("backup is missing", name)
+		}
+	}
+
+	w, err := wallet.New(st.cs, st.tpool, filepath.Join(restored, modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err := w.Unlock(st.walletKey); err != nil {
+		t.Fatal(err)
+	}
+	seed, _, err := w.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	origSeed, _, err := st.wallet.PrimarySeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seed != origSeed {
+		t.Fatal("restored wallet has a different seed")
+	}
+}
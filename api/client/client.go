@@ -0,0 +1,186 @@
+// Package client is a Go client for the API of siad. Each method of Client
+// wraps one call of the API, which is documented in doc/API.md; the name of
+// the method is the route of the call followed by its HTTP method. Calls that
+// fail return an api.Error, whose Code identifies the failure.
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/api"
+)
+
+const (
+	// DefaultAddress is the address that siad serves the API on by default.
+	DefaultAddress = "localhost:9980"
+
+	// DefaultUserAgent is the user agent that siad requires by default.
+	DefaultUserAgent = "Sia-Agent"
+)
+
+var (
+	// errNoContent is returned by calls that expect a response when the API
+	// responds with 204 No Content.
+	errNoContent = errors.New("expecting a response, but API returned status code 204 No Content")
+)
+
+type (
+	// A Client makes calls to the API of a siad instance.
+	Client struct {
+		// Address is the host:port that the API is served on.
+		Address string
+
+		// Password is sent with every call if it is not empty. It is
+		// required for the calls that change the state of the daemon if siad
+		// was started with --authenticate-api.
+		Password string
+
+		// UserAgent must contain the user agent that siad was started with.
+		UserAgent string
+
+		// HTTPClient sends the requests of the client. http.DefaultClient is
+		// used if it is nil.
+		HTTPClient *http.Client
+	}
+
+	// A Page selects a part of a list returned by the API. Offset is the
+	// number of items to skip, and Limit is the maximum number of items to
+	// return; a Limit of zero returns every item after the offset.
+	Page struct {
+		Offset int
+		Limit  int
+	}
+)
+
+// New creates a Client for the API served on 'address'.
+func New(address string) *Client {
+	return &Client{
+		Address:   address,
+		UserAgent: DefaultUserAgent,
+	}
+}
+
+// values adds the parameters of the page to 'vals'.
+func (p Page) values(vals url.Values) url.Values {
+	if p.Offset != 0 {
+		vals.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Limit != 0 {
+		vals.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return vals
+}
+
+// withQuery appends the encoded 'vals' to 'resource' as its query string.
+func withQuery(resource string, vals url.Values) string {
+	if len(vals) == 0 {
+		return resource
+	}
+	return resource + "?" + vals.Encode()
+}
+
+// escapePath escapes each element of a slash-separated path, such as a
+// siapath, for use in the route of a call.
+func escapePath(path string) string {
+	elems := strings.Split(path, "/")
+	for i := range elems {
+		elems[i] = url.PathEscape(elems[i])
+	}
+	return strings.Join(elems, "/")
+}
+
+// send sends a request for 'resource' to the API and returns the response,
+// whatever its status. The body of the response must be closed by the caller.
+func (c *Client) send(method, resource string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequest(method, "http://"+c.Address+resource, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Password != "" {
+		req.SetBasicAuth("", c.Password)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, errors.New("no response from daemon: " + err.Error())
+	}
+	return resp, nil
+}
+
+// do sends a request for 'resource' to the API. A non-2xx response is
+// returned as an error, which is an api.Error if the API sent one. The body of
+// the returned response must be closed by the caller.
+func (c *Client) do(method, resource string, body io.Reader, contentType string) (*http.Response, error) {
+	resp, err := c.send(method, resource, body, contentType)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var apiErr api.Error
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+			return nil, errors.New("API returned status " + resp.Status)
+		}
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// decode reads the body of 'resp' into 'obj', and closes it.
+func decode(resp *http.Response, obj interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNoContent {
+		return errNoContent
+	}
+	return json.NewDecoder(resp.Body).Decode(obj)
+}
+
+// discard reads and closes the body of 'resp', so that the connection can be
+// reused.
+func discard(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// get makes a GET call for 'resource' and decodes the response into 'obj'. If
+// 'obj' is nil, the response is discarded.
+func (c *Client) get(resource string, obj interface{}) error {
+	resp, err := c.do("GET", resource, nil, "")
+	if err != nil {
+		return err
+	}
+	if obj == nil {
+		discard(resp)
+		return nil
+	}
+	return decode(resp, obj)
+}
+
+// post makes a POST call for 'resource' with the form 'vals' and decodes the
+// response into 'obj'. If 'obj' is nil, the response is discarded.
+func (c *Client) post(resource string, vals url.Values, obj interface{}) error {
+	resp, err := c.do("POST", resource, strings.NewReader(vals.Encode()), "application/x-www-form-urlencoded")
+	if err != nil {
+		return err
+	}
+	if obj == nil {
+		discard(resp)
+		return nil
+	}
+	return decode(resp, obj)
+}
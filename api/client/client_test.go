@@ -0,0 +1,237 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// A call is a request received by a test server.
+type call struct {
+	method    string
+	path      string
+	form      map[string]string
+	userAgent string
+	password  string
+}
+
+// newTestServer starts a server that records the calls it receives in
+// 'calls' and responds with 'handler'.
+func newTestServer(calls *[]call, handler http.HandlerFunc) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		c := call{
+			method:    req.Method,
+			path:      req.URL.EscapedPath(),
+			form:      make(map[string]string),
+			userAgent: req.UserAgent(),
+		}
+		for k := range req.Form {
+			c.form[k] = req.Form.Get(k)
+		}
+		_, c.password, _ = req.BasicAuth()
+		*calls = append(*calls, c)
+		handler(w, req)
+	}))
+}
+
+// noContent responds to every call with 204 No Content.
+func noContent(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestClientCalls checks that the methods of the client call the right
+// resources with the right parameters.
+func TestClientCalls(t *testing.T) {
+	var calls []call
+	ts := newTestServer(&calls, noContent)
+	defer ts.Close()
+	c := New(strings.TrimPrefix(ts.URL, "http://"))
+	c.Password = "foo"
+
+	inbound := true
+	var uh types.UnlockHash
+	tests := []struct {
+		fn   func() error
+		want call
+	}{
+		{
+			func() error { return c.DaemonStopGet() },
+			call{method: "GET", path: "/daemon/stop"},
+		},
+		{
+			func() error { return c.DaemonBlocklistAddPost("10.0.0.0/8") },
+			call{method: "POST", path: "/daemon/blocklist/add", form: map[string]string{"range": "10.0.0.0/8"}},
+		},
+		{
+			func() error { return c.DaemonJobCancelPost("3") },
+			call{method: "POST", path: "/daemon/jobs/3/cancel"},
+		},
+		{
+			func() error { return c.GatewayBanPost("1.2.3.4", 90*time.Second) },
+			call{method: "POST", path: "/gateway/ban/1.2.3.4", form: map[string]string{"duration": "90"}},
+		},
+		{
+			func() error { return c.GatewayConnectPost("1.2.3.4:9981") },
+			call{method: "POST", path: "/gateway/connect/1.2.3.4:9981"},
+		},
+		{
+			func() error { return c.HostModifySettingPost("acceptingcontracts", true) },
+			call{method: "POST", path: "/host", form: map[string]string{"acceptingcontracts": "true"}},
+		},
+		{
+			func() error { return c.HostStorageFoldersAddPost("/tmp/folder", 1<<30) },
+			call{method: "POST", path: "/host/storage/folders/add", form: map[string]string{"path": "/tmp/folder", "size": "1073741824"}},
+		},
+		{
+			func() error { return c.MinerStartGet(2, 0) },
+			call{method: "GET", path: "/miner/start", form: map[string]string{"threads": "2"}},
+		},
+		{
+			func() error {
+				return c.MinerPayoutsPost([]modules.MinerPayoutSplit{{UnlockHash: uh, Percentage: 10}})
+			},
+			call{method: "POST", path: "/miner/payouts", form: map[string]string{"payouts": uh.String() + ":10"}},
+		},
+		{
+			func() error { return c.RenterPost(types.NewCurrency64(1000), 50) },
+			call{method: "POST", path: "/renter", form: map[string]string{"funds": "1000", "period": "50"}},
+		},
+		{
+			func() error { return c.RenterDownloadGet("dir/my file", "/tmp/dest") },
+			call{method: "GET", path: "/renter/download/dir/my%20file", form: map[string]string{"destination": "/tmp/dest"}},
+		},
+		{
+			func() error { return c.RenterRenamePost("a/b", "a/c") },
+			call{method: "POST", path: "/renter/rename/a/b", form: map[string]string{"newsiapath": "a/c"}},
+		},
+		{
+			func() error { return c.WalletUnlockPost("p&ss word") },
+			call{method: "POST", path: "/wallet/unlock", form: map[string]string{"encryptionpassword": "p&ss word"}},
+		},
+		{
+			func() error { return c.WalletSiagkeyPost([]string{"a.siakey", "b.siakey"}, "pw") },
+			call{method: "POST", path: "/wallet/siagkey", form: map[string]string{"keyfiles": "a.siakey,b.siakey", "encryptionpassword": "pw"}},
+		},
+	}
+	for i, test := range tests {
+		if err := test.fn(); err != nil {
+			t.Fatal(i, err)
+		}
+		got := calls[len(calls)-1]
+		if got.method != test.want.method || got.path != test.want.path {
+			t.Fatalf("%v: expected %v %v, got %v %v", i, test.want.method, test.want.path, got.method, got.path)
+		}
+		if fmt.Sprint(got.form) != fmt.Sprint(test.want.form) {
+			t.Fatalf("%v: expected parameters %v, got %v", i, test.want.form, got.form)
+		}
+		if got.userAgent != DefaultUserAgent || got.password != "foo" {
+			t.Fatalf("%v: wrong user agent or password: %q %q", i, got.userAgent, got.password)
+		}
+	}
+
+	// Lists are paged and filtered by their parameters.
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		calls = append(calls, call{path: req.URL.Path + "?" + req.URL.RawQuery})
+		w.Write([]byte("{}"))
+	})
+	if _, err := c.GatewayPeersGet(&inbound, Page{Offset: 10, Limit: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls[len(calls)-1].path; got != "/gateway/peers?inbound=true&limit=5&offset=10" {
+		t.Fatal("wrong call for a page of peers:", got)
+	}
+	if _, err := c.WalletTransactionsGet(0, 100, Page{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls[len(calls)-1].path; got != "/wallet/transactions?endheight=100&startheight=0" {
+		t.Fatal("wrong call for the transactions of the wallet:", got)
+	}
+}
+
+// TestClientResponses checks that the client decodes the responses and the
+// errors of the API.
+func TestClientResponses(t *testing.T) {
+	var calls []call
+	ts := newTestServer(&calls, func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/daemon/version":
+			json.NewEncoder(w).Encode(api.DaemonVersion{Version: "1.2.3"})
+		case "/daemon/jobs/7":
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(api.Error{Message: "no job with that id", Code: api.CodeJobNotFound})
+		case "/ready":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(api.DaemonReady{Checks: map[string]bool{"synced": false}})
+		case "/daemon/bandwidth":
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	defer ts.Close()
+	c := New(strings.TrimPrefix(ts.URL, "http://"))
+
+	dv, err := c.DaemonVersionGet()
+	if err != nil || dv.Version != "1.2.3" {
+		t.Fatal("wrong version:", dv, err)
+	}
+	_, err = c.DaemonJobGet("7")
+	if apiErr, ok := err.(api.Error); !ok || apiErr.Code != api.CodeJobNotFound {
+		t.Fatal("expected an api.Error with the code of the failure, got", err)
+	}
+	dr, err := c.ReadyGet()
+	if err != nil || dr.Ready || len(dr.Checks) != 1 {
+		t.Fatal("a daemon that is not ready was not reported:", dr, err)
+	}
+	if _, err := c.DaemonBandwidthGet(); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatal("expected the status of a response that is not an api.Error, got", err)
+	}
+	if _, err := c.ConsensusGet(); err != errNoContent {
+		t.Fatal("expected errNoContent, got", err)
+	}
+
+	ts.Close()
+	if _, err := c.DaemonVersionGet(); err == nil || !strings.Contains(err.Error(), "no response from daemon") {
+		t.Fatal("expected an error for a daemon that is not running, got", err)
+	}
+}
+
+// TestEventStream checks that the client reads the events of
+// /daemon/events.
+func TestEventStream(t *testing.T) {
+	var calls []call
+	ts := newTestServer(&calls, func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for h := types.BlockHeight(1); h <= 2; h++ {
+			data, _ := json.Marshal(api.Event{Type: api.EventBlock, Height: h})
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", api.EventBlock, data)
+		}
+	})
+	defer ts.Close()
+	c := New(strings.TrimPrefix(ts.URL, "http://"))
+
+	es, err := c.DaemonEventsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer es.Close()
+	for h := types.BlockHeight(1); h <= 2; h++ {
+		e, err := es.Next()
+		if err != nil || e.Type != api.EventBlock || e.Height != h {
+			t.Fatal("wrong event:", e, err)
+		}
+	}
+	if _, err := es.Next(); err != io.EOF {
+		t.Fatal("expected io.EOF at the end of the stream, got", err)
+	}
+}
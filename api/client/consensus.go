@@ -0,0 +1,102 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// outputsPage returns the parameters of a page of unspent outputs that starts
+// after the output 'after', unless it is the zero hash, and holds at most
+// 'limit' outputs, or the default number if 'limit' is zero.
+func outputsPage(after crypto.Hash, limit int) url.Values {
+	vals := url.Values{}
+	if after != (crypto.Hash{}) {
+		vals.Set("after", after.String())
+	}
+	if limit != 0 {
+		vals.Set("limit", strconv.Itoa(limit))
+	}
+	return vals
+}
+
+// ConsensusGet requests the /consensus resource.
+func (c *Client) ConsensusGet() (cg api.ConsensusGET, err error) {
+	err = c.get("/consensus", &cg)
+	return
+}
+
+// ConsensusAlertsGet requests the /consensus/alerts resource.
+func (c *Client) ConsensusAlertsGet() (cag api.ConsensusAlertsGET, err error) {
+	err = c.get("/consensus/alerts", &cag)
+	return
+}
+
+// ConsensusChecksumGet requests the /consensus/checksum resource.
+func (c *Client) ConsensusChecksumGet() (ccg api.ConsensusChecksumGET, err error) {
+	err = c.get("/consensus/checksum", &ccg)
+	return
+}
+
+// ConsensusStatsGet requests the /consensus/stats resource.
+func (c *Client) ConsensusStatsGet() (csg api.ConsensusStatsGET, err error) {
+	err = c.get("/consensus/stats", &csg)
+	return
+}
+
+// ConsensusSnapshotPost writes a snapshot of the consensus set to the
+// absolute path 'destination' on the machine of the daemon, with the
+// /consensus/snapshot resource.
+func (c *Client) ConsensusSnapshotPost(destination string) (csp api.ConsensusSnapshotPOST, err error) {
+	err = c.post("/consensus/snapshot", url.Values{"destination": {destination}}, &csp)
+	return
+}
+
+// ConsensusSnapshotAsyncPost starts a job that writes a snapshot of the
+// consensus set to 'destination', with the /consensus/snapshot resource. The
+// progress of the job is reported by DaemonJobGet.
+func (c *Client) ConsensusSnapshotAsyncPost(destination string) (djp api.DaemonJobPOST, err error) {
+	err = c.post("/consensus/snapshot", url.Values{"destination": {destination}, "async": {"true"}}, &djp)
+	return
+}
+
+// ConsensusSiacoinOutputsGet requests a page of the
+// /consensus/siacoinoutputs resource. The page starts after the output
+// 'after', or at the first output if 'after' is the zero ID, and holds at
+// most 'limit' outputs, or the default number if 'limit' is zero.
+func (c *Client) ConsensusSiacoinOutputsGet(after types.SiacoinOutputID, limit int) (csg api.ConsensusSiacoinOutputsGET, err error) {
+	err = c.get(withQuery("/consensus/siacoinoutputs", outputsPage(crypto.Hash(after), limit)), &csg)
+	return
+}
+
+// ConsensusSiacoinOutputGet requests the /consensus/siacoinoutputs/:id
+// resource.
+func (c *Client) ConsensusSiacoinOutputGet(id types.SiacoinOutputID) (csg api.ConsensusSiacoinOutputGET, err error) {
+	err = c.get("/consensus/siacoinoutputs/"+id.String(), &csg)
+	return
+}
+
+// ConsensusSiafundOutputsGet requests a page of the
+// /consensus/siafundoutputs resource, in the same way as
+// ConsensusSiacoinOutputsGet.
+func (c *Client) ConsensusSiafundOutputsGet(after types.SiafundOutputID, limit int) (csg api.ConsensusSiafundOutputsGET, err error) {
+	err = c.get(withQuery("/consensus/siafundoutputs", outputsPage(crypto.Hash(after), limit)), &csg)
+	return
+}
+
+// ConsensusSiafundOutputGet requests the /consensus/siafundoutputs/:id
+// resource.
+func (c *Client) ConsensusSiafundOutputGet(id types.SiafundOutputID) (csg api.ConsensusSiafundOutputGET, err error) {
+	err = c.get("/consensus/siafundoutputs/"+id.String(), &csg)
+	return
+}
+
+// ConsensusFileContractGet requests the /consensus/filecontracts/:id
+// resource.
+func (c *Client) ConsensusFileContractGet(id types.FileContractID) (cfg api.ConsensusFileContractGET, err error) {
+	err = c.get("/consensus/filecontracts/"+id.String(), &cfg)
+	return
+}
@@ -0,0 +1,195 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/api"
+)
+
+// An EventStream reads the events sent by /daemon/events.
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// Next blocks until the next event arrives. It returns io.EOF once the daemon
+// closes the stream.
+func (es *EventStream) Next() (api.Event, error) {
+	for es.scanner.Scan() {
+		line := es.scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var e api.Event
+		err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)
+		return e, err
+	}
+	if err := es.scanner.Err(); err != nil {
+		return api.Event{}, err
+	}
+	return api.Event{}, io.EOF
+}
+
+// Close closes the stream.
+func (es *EventStream) Close() error {
+	return es.body.Close()
+}
+
+// DaemonConstantsGet requests the /daemon/constants resource.
+func (c *Client) DaemonConstantsGet() (sc api.SiaConstants, err error) {
+	err = c.get("/daemon/constants", &sc)
+	return
+}
+
+// DaemonVersionGet requests the /daemon/version resource.
+func (c *Client) DaemonVersionGet() (dv api.DaemonVersion, err error) {
+	err = c.get("/daemon/version", &dv)
+	return
+}
+
+// DaemonEventsGet opens the stream of events of /daemon/events. The stream
+// should be closed when it is no longer needed.
+func (c *Client) DaemonEventsGet() (*EventStream, error) {
+	resp, err := c.do("GET", "/daemon/events", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{
+		body:    resp.Body,
+		scanner: bufio.NewScanner(resp.Body),
+	}, nil
+}
+
+// DaemonUpdateGet checks for an update with the /daemon/update resource.
+func (c *Client) DaemonUpdateGet() (ui api.UpdateInfo, err error) {
+	err = c.get("/daemon/update", &ui)
+	return
+}
+
+// DaemonUpdatePost applies the latest update with the /daemon/update
+// resource.
+func (c *Client) DaemonUpdatePost() error {
+	return c.post("/daemon/update", url.Values{}, nil)
+}
+
+// DaemonStopGet stops the daemon with the /daemon/stop resource.
+func (c *Client) DaemonStopGet() error {
+	return c.get("/daemon/stop", nil)
+}
+
+// DaemonBlocklistGet requests the /daemon/blocklist resource.
+func (c *Client) DaemonBlocklistGet() (dbg api.DaemonBlocklistGET, err error) {
+	err = c.get("/daemon/blocklist", &dbg)
+	return
+}
+
+// DaemonBlocklistAddPost blocks the IP range 'ipRange', which is an address
+// or a CIDR block, with the /daemon/blocklist/add resource.
+func (c *Client) DaemonBlocklistAddPost(ipRange string) error {
+	return c.post("/daemon/blocklist/add", url.Values{"range": {ipRange}}, nil)
+}
+
+// DaemonBlocklistRemovePost unblocks the IP range 'ipRange' with the
+// /daemon/blocklist/remove resource.
+func (c *Client) DaemonBlocklistRemovePost(ipRange string) error {
+	return c.post("/daemon/blocklist/remove", url.Values{"range": {ipRange}}, nil)
+}
+
+// DaemonBandwidthGet requests the /daemon/bandwidth resource.
+func (c *Client) DaemonBandwidthGet() (dbg api.DaemonBandwidthGET, err error) {
+	err = c.get("/daemon/bandwidth", &dbg)
+	return
+}
+
+// DaemonAlertsGet requests the /daemon/alerts resource.
+func (c *Client) DaemonAlertsGet() (dag api.DaemonAlertsGET, err error) {
+	err = c.get("/daemon/alerts", &dag)
+	return
+}
+
+// DaemonAlertsClearPost clears the alert with the given id with the
+// /daemon/alerts/clear resource.
+func (c *Client) DaemonAlertsClearPost(id string) error {
+	return c.post("/daemon/alerts/clear", url.Values{"id": {id}}, nil)
+}
+
+// DaemonJobsGet requests the /daemon/jobs resource.
+func (c *Client) DaemonJobsGet() (djg api.DaemonJobsGET, err error) {
+	err = c.get("/daemon/jobs", &djg)
+	return
+}
+
+// DaemonJobGet requests the /daemon/jobs/:id resource.
+func (c *Client) DaemonJobGet(id string) (j api.Job, err error) {
+	err = c.get("/daemon/jobs/"+url.PathEscape(id), &j)
+	return
+}
+
+// DaemonJobCancelPost cancels a job with the /daemon/jobs/:id/cancel
+// resource.
+func (c *Client) DaemonJobCancelPost(id string) error {
+	return c.post("/daemon/jobs/"+url.PathEscape(id)+"/cancel", url.Values{}, nil)
+}
+
+// DaemonBackupPost writes a backup of the wallet, renter, and host, encrypted
+// with 'password', to the absolute path 'destination' on the machine of the
+// daemon, with the /daemon/backup resource.
+func (c *Client) DaemonBackupPost(destination, password string) (dbp api.DaemonBackupPOST, err error) {
+	err = c.post("/daemon/backup", url.Values{"destination": {destination}, "encryptionpassword": {password}}, &dbp)
+	return
+}
+
+// DaemonBackupAsyncPost starts a job that writes a backup to 'destination',
+// with the /daemon/backup resource. The progress of the job is reported by
+// DaemonJobGet.
+func (c *Client) DaemonBackupAsyncPost(destination, password string) (djp api.DaemonJobPOST, err error) {
+	err = c.post("/daemon/backup", url.Values{"destination": {destination}, "encryptionpassword": {password}, "async": {"true"}}, &djp)
+	return
+}
+
+// HealthGet requests the /health resource.
+func (c *Client) HealthGet() (dh api.DaemonHealth, err error) {
+	err = c.get("/health", &dh)
+	return
+}
+
+// ReadyGet requests the /ready resource. A daemon that is not ready responds
+// with 503 Service Unavailable, which is not returned as an error; the checks
+// that failed are reported by the response.
+func (c *Client) ReadyGet() (dr api.DaemonReady, err error) {
+	resp, err := c.send("GET", "/ready", nil, "")
+	if err != nil {
+		return api.DaemonReady{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return api.DaemonReady{}, errors.New("API returned status " + resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&dr)
+	return
+}
+
+// DebugStatsGet requests the /debug/stats resource.
+func (c *Client) DebugStatsGet() (ds api.DebugStats, err error) {
+	err = c.get("/debug/stats", &ds)
+	return
+}
+
+// DebugPprofGet requests the profile 'profile', such as "heap" or
+// "goroutine", from the /debug/pprof resource. The profile is returned in the
+// format expected by 'go tool pprof'.
+func (c *Client) DebugPprofGet(profile string) ([]byte, error) {
+	resp, err := c.do("GET", "/debug/pprof/"+url.PathEscape(profile), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return ioutil.ReadAll(resp.Body)
+}
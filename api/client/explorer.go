@@ -0,0 +1,29 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// ExplorerGet requests the /explorer resource.
+func (c *Client) ExplorerGet() (eg api.ExplorerGET, err error) {
+	err = c.get("/explorer", &eg)
+	return
+}
+
+// ExplorerBlocksGet requests the /explorer/blocks/:height resource.
+func (c *Client) ExplorerBlocksGet(height types.BlockHeight) (ebg api.ExplorerBlockGET, err error) {
+	err = c.get(fmt.Sprintf("/explorer/blocks/%d", height), &ebg)
+	return
+}
+
+// ExplorerHashesGet requests the /explorer/hashes/:hash resource. 'hash' is
+// the hex encoding of the ID of a block, transaction, output or file
+// contract, or an unlock hash.
+func (c *Client) ExplorerHashesGet(hash string) (ehg api.ExplorerHashGET, err error) {
+	err = c.get("/explorer/hashes/"+url.PathEscape(hash), &ehg)
+	return
+}
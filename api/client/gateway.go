@@ -0,0 +1,82 @@
+package client
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// GatewayGet requests the /gateway resource.
+func (c *Client) GatewayGet() (gg api.GatewayGET, err error) {
+	err = c.get("/gateway", &gg)
+	return
+}
+
+// GatewayPeersGet requests a page of the /gateway/peers resource. If
+// 'inbound' is not nil, only the peers whose connection has that direction
+// are returned.
+func (c *Client) GatewayPeersGet(inbound *bool, page Page) (gpg api.GatewayPeersGET, err error) {
+	vals := page.values(url.Values{})
+	if inbound != nil {
+		vals.Set("inbound", strconv.FormatBool(*inbound))
+	}
+	err = c.get(withQuery("/gateway/peers", vals), &gpg)
+	return
+}
+
+// GatewayConnectPost connects the gateway to 'address' with the
+// /gateway/connect/:netaddress resource.
+func (c *Client) GatewayConnectPost(address modules.NetAddress) error {
+	return c.post("/gateway/connect/"+string(address), url.Values{}, nil)
+}
+
+// GatewayDisconnectPost disconnects the gateway from 'address' with the
+// /gateway/disconnect/:netaddress resource.
+func (c *Client) GatewayDisconnectPost(address modules.NetAddress) error {
+	return c.post("/gateway/disconnect/"+string(address), url.Values{}, nil)
+}
+
+// GatewayBansGet requests the /gateway/bans resource.
+func (c *Client) GatewayBansGet() (gbg api.GatewayBansGET, err error) {
+	err = c.get("/gateway/bans", &gbg)
+	return
+}
+
+// GatewayBanPost bans 'host' for 'duration', which is rounded down to whole
+// seconds, with the /gateway/ban/:host resource. A duration of zero bans the
+// host for the default length of time.
+func (c *Client) GatewayBanPost(host string, duration time.Duration) error {
+	vals := url.Values{}
+	if duration != 0 {
+		vals.Set("duration", strconv.FormatInt(int64(duration/time.Second), 10))
+	}
+	return c.post("/gateway/ban/"+url.PathEscape(host), vals, nil)
+}
+
+// GatewayUnbanPost lifts the ban of 'host' with the /gateway/unban/:host
+// resource.
+func (c *Client) GatewayUnbanPost(host string) error {
+	return c.post("/gateway/unban/"+url.PathEscape(host), url.Values{}, nil)
+}
+
+// GatewayAlertsGet requests the /gateway/alerts resource.
+func (c *Client) GatewayAlertsGet() (gag api.GatewayAlertsGET, err error) {
+	err = c.get("/gateway/alerts", &gag)
+	return
+}
+
+// GatewayAlertsPost broadcasts the signed network alert 'alert' with the
+// /gateway/alerts resource.
+func (c *Client) GatewayAlertsPost(alert modules.NetworkAlert) error {
+	return c.post("/gateway/alerts", url.Values{
+		"severity":  {string(alert.Severity)},
+		"message":   {alert.Message},
+		"expiry":    {fmt.Sprint(alert.Expiry)},
+		"signature": {hex.EncodeToString(alert.Signature[:])},
+	}, nil)
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// HostGet requests the /host resource.
+func (c *Client) HostGet() (hg api.HostGET, err error) {
+	err = c.get("/host", &hg)
+	return
+}
+
+// HostModifySettingPost sets the internal setting 'param' of the host, such
+// as "acceptingcontracts" or "minstorageprice", to 'value' with the /host
+// resource. Currencies are given in hastings.
+func (c *Client) HostModifySettingPost(param string, value interface{}) error {
+	return c.post("/host", url.Values{param: {fmt.Sprint(value)}}, nil)
+}
+
+// HostAnnouncePost announces the host with the /host/announce resource.
+func (c *Client) HostAnnouncePost() error {
+	return c.post("/host/announce", url.Values{}, nil)
+}
+
+// HostAnnounceAddrPost announces the host at 'address' with the
+// /host/announce resource.
+func (c *Client) HostAnnounceAddrPost(address modules.NetAddress) error {
+	return c.post("/host/announce", url.Values{"netaddress": {string(address)}}, nil)
+}
+
+// HostSettingsGet requests the /host/settings resource, which reports the
+// settings that the host advertises to renters.
+func (c *Client) HostSettingsGet() (hsg api.HostSettingsGET, err error) {
+	err = c.get("/host/settings", &hsg)
+	return
+}
+
+// HostStorageGet requests the /host/storage resource.
+func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
+	err = c.get("/host/storage", &sg)
+	return
+}
+
+// HostStorageFoldersAddPost adds a storage folder of 'size' bytes at 'path'
+// with the /host/storage/folders/add resource.
+func (c *Client) HostStorageFoldersAddPost(path string, size uint64) error {
+	return c.post("/host/storage/folders/add", url.Values{
+		"path": {path},
+		"size": {strconv.FormatUint(size, 10)},
+	}, nil)
+}
+
+// HostStorageFoldersRemovePost removes the storage folder at 'path' with the
+// /host/storage/folders/remove resource. If 'force' is true, the folder is
+// removed even if its data cannot be moved elsewhere.
+func (c *Client) HostStorageFoldersRemovePost(path string, force bool) error {
+	return c.post("/host/storage/folders/remove", url.Values{
+		"path":  {path},
+		"force": {strconv.FormatBool(force)},
+	}, nil)
+}
+
+// HostStorageFoldersResizePost resizes the storage folder at 'path' to
+// 'newSize' bytes with the /host/storage/folders/resize resource.
+func (c *Client) HostStorageFoldersResizePost(path string, newSize uint64) error {
+	return c.post("/host/storage/folders/resize", url.Values{
+		"path":    {path},
+		"newsize": {strconv.FormatUint(newSize, 10)},
+	}, nil)
+}
+
+// HostStorageSectorsDeletePost deletes the sector with the Merkle root
+// 'root' with the /host/storage/sectors/delete/:merkleroot resource.
+func (c *Client) HostStorageSectorsDeletePost(root crypto.Hash) error {
+	return c.post("/host/storage/sectors/delete/"+root.String(), url.Values{}, nil)
+}
@@ -0,0 +1,25 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/NebulousLabs/Sia/api"
+)
+
+// HostDbActiveGet requests the /hostdb/active resource. At most 'numHosts'
+// hosts are returned, or every active host if 'numHosts' is zero.
+func (c *Client) HostDbActiveGet(numHosts int) (ah api.ActiveHosts, err error) {
+	vals := url.Values{}
+	if numHosts != 0 {
+		vals.Set("numhosts", strconv.Itoa(numHosts))
+	}
+	err = c.get(withQuery("/hostdb/active", vals), &ah)
+	return
+}
+
+// HostDbAllGet requests the /hostdb/all resource.
+func (c *Client) HostDbAllGet() (ah api.AllHosts, err error) {
+	err = c.get("/hostdb/all", &ah)
+	return
+}
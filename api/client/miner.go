@@ -0,0 +1,133 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// MinerGet requests the /miner resource.
+func (c *Client) MinerGet() (mg api.MinerGET, err error) {
+	err = c.get("/miner", &mg)
+	return
+}
+
+// MinerStartGet starts the cpu miner with the /miner/start resource. A
+// 'threads' or 'cpuLimit' of zero leaves that setting unchanged.
+func (c *Client) MinerStartGet(threads, cpuLimit int) error {
+	vals := url.Values{}
+	if threads != 0 {
+		vals.Set("threads", strconv.Itoa(threads))
+	}
+	if cpuLimit != 0 {
+		vals.Set("cpulimit", strconv.Itoa(cpuLimit))
+	}
+	return c.get(withQuery("/miner/start", vals), nil)
+}
+
+// MinerStopGet stops the cpu miner with the /miner/stop resource.
+func (c *Client) MinerStopGet() error {
+	return c.get("/miner/stop", nil)
+}
+
+// MinerHeaderGet requests a block header to work on, and the target that it
+// must meet, from the /miner/header resource.
+func (c *Client) MinerHeaderGet() (types.BlockHeader, types.Target, error) {
+	resp, err := c.do("GET", "/miner/header", nil, "")
+	if err != nil {
+		return types.BlockHeader{}, types.Target{}, err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return types.BlockHeader{}, types.Target{}, err
+	}
+	var target types.Target
+	var bh types.BlockHeader
+	err = encoding.UnmarshalAll(b, &target, &bh)
+	return bh, target, err
+}
+
+// MinerHeaderPost submits a solved block header with the /miner/header
+// resource.
+func (c *Client) MinerHeaderPost(bh types.BlockHeader) error {
+	resp, err := c.do("POST", "/miner/header", bytes.NewReader(encoding.Marshal(bh)), "")
+	if err != nil {
+		return err
+	}
+	discard(resp)
+	return nil
+}
+
+// MinerBlockPost submits a solved block with the /miner/block resource.
+func (c *Client) MinerBlockPost(b types.Block) error {
+	resp, err := c.do("POST", "/miner/block", bytes.NewReader(encoding.Marshal(b)), "")
+	if err != nil {
+		return err
+	}
+	discard(resp)
+	return nil
+}
+
+// MinerBlockTemplateGet requests the /miner/blocktemplate resource.
+func (c *Client) MinerBlockTemplateGet() (mbtg api.MinerBlockTemplateGET, err error) {
+	err = c.get("/miner/blocktemplate", &mbtg)
+	return
+}
+
+// MinerPayoutsGet requests the /miner/payouts resource.
+func (c *Client) MinerPayoutsGet() (mpg api.MinerPayoutsGET, err error) {
+	err = c.get("/miner/payouts", &mpg)
+	return
+}
+
+// MinerPayoutsPost sets the payout splits of the miner with the
+// /miner/payouts resource. No splits pays the block subsidy to the wallet.
+func (c *Client) MinerPayoutsPost(splits []modules.MinerPayoutSplit) error {
+	pairs := make([]string, len(splits))
+	for i, s := range splits {
+		pairs[i] = fmt.Sprintf("%v:%v", s.UnlockHash, s.Percentage)
+	}
+	return c.post("/miner/payouts", url.Values{"payouts": {strings.Join(pairs, ",")}}, nil)
+}
+
+// MinerMineBlocksPost mines 'count' blocks with the /miner/mineblocks
+// resource.
+func (c *Client) MinerMineBlocksPost(count int) (mmbp api.MinerMineBlocksPOST, err error) {
+	err = c.post("/miner/mineblocks", url.Values{"count": {strconv.Itoa(count)}}, &mmbp)
+	return
+}
+
+// MinerPoolGet requests the /miner/pool resource.
+func (c *Client) MinerPoolGet() (mpg api.MinerPoolGET, err error) {
+	err = c.get("/miner/pool", &mpg)
+	return
+}
+
+// MinerPoolStartPost starts mining for 'pool', paying out to 'payout', with
+// the /miner/pool/start resource. A 'difficulty' of zero lets the pool choose
+// the difficulty of shares.
+func (c *Client) MinerPoolStartPost(pool modules.NetAddress, payout types.UnlockHash, difficulty uint64) error {
+	vals := url.Values{
+		"pool":   {string(pool)},
+		"payout": {payout.String()},
+	}
+	if difficulty != 0 {
+		vals.Set("difficulty", strconv.FormatUint(difficulty, 10))
+	}
+	return c.post("/miner/pool/start", vals, nil)
+}
+
+// MinerPoolStopPost stops mining for a pool with the /miner/pool/stop
+// resource.
+func (c *Client) MinerPoolStopPost() error {
+	return c.post("/miner/pool/stop", url.Values{}, nil)
+}
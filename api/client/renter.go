@@ -0,0 +1,80 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// RenterGet requests the /renter resource.
+func (c *Client) RenterGet() (rg api.RenterGET, err error) {
+	err = c.get("/renter", &rg)
+	return
+}
+
+// RenterPost sets the allowance of the renter to 'funds' hastings over
+// 'period' blocks with the /renter resource.
+func (c *Client) RenterPost(funds types.Currency, period types.BlockHeight) error {
+	return c.post("/renter", url.Values{
+		"funds":  {funds.String()},
+		"period": {fmt.Sprint(period)},
+	}, nil)
+}
+
+// RenterContractsGet requests a page of the /renter/contracts resource. If
+// 'host' is not empty, only the contracts with that host are returned.
+func (c *Client) RenterContractsGet(host modules.NetAddress, page Page) (rc api.RenterContracts, err error) {
+	vals := page.values(url.Values{})
+	if host != "" {
+		vals.Set("host", string(host))
+	}
+	err = c.get(withQuery("/renter/contracts", vals), &rc)
+	return
+}
+
+// RenterDownloadsGet requests the /renter/downloads resource.
+func (c *Client) RenterDownloadsGet() (rdq api.RenterDownloadQueue, err error) {
+	err = c.get("/renter/downloads", &rdq)
+	return
+}
+
+// RenterFilesGet requests a page of the /renter/files resource. If 'prefix'
+// is not empty, only the files whose siapath begins with it are returned.
+func (c *Client) RenterFilesGet(prefix string, page Page) (rf api.RenterFiles, err error) {
+	vals := page.values(url.Values{})
+	if prefix != "" {
+		vals.Set("prefix", prefix)
+	}
+	err = c.get(withQuery("/renter/files", vals), &rf)
+	return
+}
+
+// RenterDeletePost deletes the file at 'siaPath' with the
+// /renter/delete/*siapath resource.
+func (c *Client) RenterDeletePost(siaPath string) error {
+	return c.post("/renter/delete/"+escapePath(siaPath), url.Values{}, nil)
+}
+
+// RenterDownloadGet downloads the file at 'siaPath' to the absolute path
+// 'destination' on the machine of the daemon with the
+// /renter/download/*siapath resource. The call returns once the download
+// completes.
+func (c *Client) RenterDownloadGet(siaPath, destination string) error {
+	return c.get(withQuery("/renter/download/"+escapePath(siaPath), url.Values{"destination": {destination}}), nil)
+}
+
+// RenterRenamePost renames the file at 'siaPath' to 'newSiaPath' with the
+// /renter/rename/*siapath resource.
+func (c *Client) RenterRenamePost(siaPath, newSiaPath string) error {
+	return c.post("/renter/rename/"+escapePath(siaPath), url.Values{"newsiapath": {newSiaPath}}, nil)
+}
+
+// RenterUploadPost uploads the file at the absolute path 'source' on the
+// machine of the daemon to 'siaPath' with the /renter/upload/*siapath
+// resource.
+func (c *Client) RenterUploadPost(source, siaPath string) error {
+	return c.post("/renter/upload/"+escapePath(siaPath), url.Values{"source": {source}}, nil)
+}
@@ -0,0 +1,13 @@
+package client
+
+import (
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TransactionPoolConflictedGet requests the
+// /transactionpool/conflicted/:id resource.
+func (c *Client) TransactionPoolConflictedGet(id types.TransactionID) (tcg api.TransactionPoolConflictedGET, err error) {
+	err = c.get("/transactionpool/conflicted/"+id.String(), &tcg)
+	return
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/entropy-mnemonics"
+)
+
+// WalletGet requests the /wallet resource.
+func (c *Client) WalletGet() (wg api.WalletGET, err error) {
+	err = c.get("/wallet", &wg)
+	return
+}
+
+// Wallet033xPost loads the v0.3.3.x wallet file at 'source', which is
+// encrypted with 'password', with the /wallet/033x resource.
+func (c *Client) Wallet033xPost(source, password string) error {
+	return c.post("/wallet/033x", url.Values{
+		"source":             {source},
+		"encryptionpassword": {password},
+	}, nil)
+}
+
+// WalletAddressGet generates a new address with the /wallet/address
+// resource.
+func (c *Client) WalletAddressGet() (wag api.WalletAddressGET, err error) {
+	err = c.get("/wallet/address", &wag)
+	return
+}
+
+// WalletAddressesGet requests the /wallet/addresses resource.
+func (c *Client) WalletAddressesGet() (wag api.WalletAddressesGET, err error) {
+	err = c.get("/wallet/addresses", &wag)
+	return
+}
+
+// WalletBackupGet writes a backup of the wallet to the absolute path
+// 'destination' on the machine of the daemon with the /wallet/backup
+// resource.
+func (c *Client) WalletBackupGet(destination string) error {
+	return c.get(withQuery("/wallet/backup", url.Values{"destination": {destination}}), nil)
+}
+
+// WalletFaucetPost requests coins from the faucet at 'faucetURL' with the
+// /wallet/faucet resource, and returns once they are confirmed. If
+// 'faucetURL' is empty, the faucet that siad was started with is used.
+func (c *Client) WalletFaucetPost(faucetURL string) (wfp api.WalletFaucetPOST, err error) {
+	err = c.post("/wallet/faucet", url.Values{"url": {faucetURL}}, &wfp)
+	return
+}
+
+// WalletFaucetAsyncPost requests coins from the faucet at 'faucetURL' with
+// the /wallet/faucet resource, and starts a job that waits until they are
+// confirmed. The result of the job is reported by DaemonJobGet.
+func (c *Client) WalletFaucetAsyncPost(faucetURL string) (djp api.DaemonJobPOST, err error) {
+	err = c.post("/wallet/faucet", url.Values{"url": {faucetURL}, "async": {"true"}}, &djp)
+	return
+}
+
+// WalletInitPost initializes the wallet with a new seed, encoded with the
+// dictionary 'dictionary', with the /wallet/init resource. If 'password' is
+// empty, the wallet is encrypted with the seed.
+func (c *Client) WalletInitPost(password string, dictionary mnemonics.DictionaryID) (wip api.WalletInitPOST, err error) {
+	vals := url.Values{"dictionary": {string(dictionary)}}
+	if password != "" {
+		vals.Set("encryptionpassword", password)
+	}
+	err = c.post("/wallet/init", vals, &wip)
+	return
+}
+
+// WalletLockPost locks the wallet with the /wallet/lock resource.
+func (c *Client) WalletLockPost() error {
+	return c.post("/wallet/lock", url.Values{}, nil)
+}
+
+// WalletSeedPost adds 'seed', which is encoded with 'dictionary', to the
+// wallet encrypted with 'password', with the /wallet/seed resource.
+func (c *Client) WalletSeedPost(seed, password string, dictionary mnemonics.DictionaryID) error {
+	return c.post("/wallet/seed", url.Values{
+		"seed":               {seed},
+		"encryptionpassword": {password},
+		"dictionary":         {string(dictionary)},
+	}, nil)
+}
+
+// WalletSeedsGet requests the /wallet/seeds resource, with the seeds encoded
+// with 'dictionary'.
+func (c *Client) WalletSeedsGet(dictionary mnemonics.DictionaryID) (wsg api.WalletSeedsGET, err error) {
+	err = c.get(withQuery("/wallet/seeds", url.Values{"dictionary": {string(dictionary)}}), &wsg)
+	return
+}
+
+// WalletSiacoinsPost sends 'amount' hastings to 'destination' with the
+// /wallet/siacoins resource.
+func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiacoinsPOST, err error) {
+	err = c.post("/wallet/siacoins", url.Values{
+		"amount":      {amount.String()},
+		"destination": {destination.String()},
+	}, &wsp)
+	return
+}
+
+// WalletSiafundsPost sends 'amount' siafunds to 'destination' with the
+// /wallet/siafunds resource.
+func (c *Client) WalletSiafundsPost(amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiafundsPOST, err error) {
+	err = c.post("/wallet/siafunds", url.Values{
+		"amount":      {amount.String()},
+		"destination": {destination.String()},
+	}, &wsp)
+	return
+}
+
+// WalletSiagkeyPost loads the siag keys in 'keyfiles', which are encrypted
+// with 'password', with the /wallet/siagkey resource.
+func (c *Client) WalletSiagkeyPost(keyfiles []string, password string) error {
+	return c.post("/wallet/siagkey", url.Values{
+		"keyfiles":           {strings.Join(keyfiles, ",")},
+		"encryptionpassword": {password},
+	}, nil)
+}
+
+// WalletTransactionGet requests the /wallet/transaction/:id resource.
+func (c *Client) WalletTransactionGet(id types.TransactionID) (wtg api.WalletTransactionGETid, err error) {
+	err = c.get("/wallet/transaction/"+id.String(), &wtg)
+	return
+}
+
+// WalletTransactionsGet requests a page of the confirmed transactions
+// between the heights 'start' and 'end' from the /wallet/transactions
+// resource, along with every unconfirmed transaction.
+func (c *Client) WalletTransactionsGet(start, end types.BlockHeight, page Page) (wtg api.WalletTransactionsGET, err error) {
+	vals := page.values(url.Values{
+		"startheight": {fmt.Sprint(start)},
+		"endheight":   {fmt.Sprint(end)},
+	})
+	err = c.get(withQuery("/wallet/transactions", vals), &wtg)
+	return
+}
+
+// WalletTransactionsAddrGet requests a page of the confirmed transactions
+// related to 'addr' from the /wallet/transactions/:addr resource, along with
+// every unconfirmed transaction related to it.
+func (c *Client) WalletTransactionsAddrGet(addr types.UnlockHash, page Page) (wtg api.WalletTransactionsGETaddr, err error) {
+	err = c.get(withQuery("/wallet/transactions/"+addr.String(), page.values(url.Values{})), &wtg)
+	return
+}
+
+// WalletUnlockPost unlocks the wallet with 'password' with the
+// /wallet/unlock resource. The call returns once the wallet has rescanned
+// the blockchain, which may take several minutes.
+func (c *Client) WalletUnlockPost(password string) error {
+	return c.post("/wallet/unlock", url.Values{"encryptionpassword": {password}}, nil)
+}
+
+// WalletUnlockAsyncPost starts a job that unlocks the wallet with 'password',
+// with the /wallet/unlock resource. The progress of the job is reported by
+// DaemonJobGet.
+func (c *Client) WalletUnlockAsyncPost(password string) (djp api.DaemonJobPOST, err error) {
+	err = c.post("/wallet/unlock", url.Values{"encryptionpassword": {password}, "async": {"true"}}, &djp)
+	return
+}
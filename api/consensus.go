@@ -1,13 +1,29 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/julienschmidt/httprouter"
 )
 
+const (
+	// defaultOutputsPageSize is the number of unspent outputs returned by
+	// /consensus/siacoinoutputs and /consensus/siafundoutputs if no limit is
+	// given, and maxOutputsPageSize is the largest limit that can be given.
+	defaultOutputsPageSize = 100
+	maxOutputsPageSize     = 1000
+)
+
 // ConsensusGET contains general information about the consensus set, with tags
 // to support idiomatic json encodings.
 type ConsensusGET struct {
@@ -17,6 +33,45 @@ type ConsensusGET struct {
 	Target       types.Target      `json:"target"`
 }
 
+// ConsensusAlertsGET contains the most recent alerts raised by the consensus
+// set.
+type ConsensusAlertsGET struct {
+	Alerts []modules.Alert `json:"alerts"`
+}
+
+// ConsensusChecksumGET contains a checksum of the full consensus set and the
+// block that it was computed at.
+type ConsensusChecksumGET struct {
+	Height       types.BlockHeight `json:"height"`
+	CurrentBlock types.BlockID     `json:"currentblock"`
+	Checksum     crypto.Hash       `json:"checksum"`
+}
+
+// ConsensusStatsGET contains statistics about the current path of the
+// consensus set.
+type ConsensusStatsGET struct {
+	Height        types.BlockHeight `json:"height"`
+	CurrentBlock  types.BlockID     `json:"currentblock"`
+	Synced        bool              `json:"synced"`
+	Target        types.Target      `json:"target"`
+	Difficulty    types.Currency    `json:"difficulty"`
+	TotalWork     types.Currency    `json:"totalwork"`
+	BlockInterval float64           `json:"blockinterval"`
+	Hashrate      types.Currency    `json:"hashrate"`
+	SiafundPool   types.Currency    `json:"siafundpool"`
+	Coinbase      types.Currency    `json:"coinbase"`
+	SiacoinSupply types.Currency    `json:"siacoinsupply"`
+}
+
+// ConsensusSnapshotPOST contains the height and id of the block that an
+// exported consensus snapshot ends at, and the checksum of the consensus state
+// at that block.
+type ConsensusSnapshotPOST struct {
+	Height   types.BlockHeight `json:"height"`
+	BlockID  types.BlockID     `json:"blockid"`
+	Checksum crypto.Hash       `json:"checksum"`
+}
+
 // consensusHandler handles the API calls to /consensus.
 func (srv *Server) consensusHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	cbid := srv.cs.CurrentBlock().ID()
@@ -28,3 +83,310 @@ func (srv *Server) consensusHandler(w http.ResponseWriter, req *http.Request, _
 		Target:       currentTarget,
 	})
 }
+
+// consensusStatsHandler handles the API calls to /consensus/stats.
+func (srv *Server) consensusStatsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	stats, err := srv.cs.ChainStats()
+	if err != nil {
+		writeError(w, Error{Message: "unable to compute chain stats: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, ConsensusStatsGET{
+		Height:        stats.Height,
+		CurrentBlock:  stats.CurrentBlock,
+		Synced:        stats.Synced,
+		Target:        stats.Target,
+		Difficulty:    stats.Difficulty,
+		TotalWork:     stats.TotalWork,
+		BlockInterval: stats.BlockInterval,
+		Hashrate:      stats.Hashrate,
+		SiafundPool:   stats.SiafundPool,
+		Coinbase:      types.CalculateCoinbase(stats.Height + 1),
+		SiacoinSupply: types.CalculateNumSiacoins(stats.Height),
+	})
+}
+
+// consensusAlertsHandler handles the API calls to /consensus/alerts.
+func (srv *Server) consensusAlertsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	writeJSON(w, ConsensusAlertsGET{
+		Alerts: srv.cs.Alerts(),
+	})
+}
+
+// consensusChecksumHandler handles the API calls to /consensus/checksum.
+func (srv *Server) consensusChecksumHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	height, id, checksum := srv.cs.StateChecksum()
+	writeJSON(w, ConsensusChecksumGET{
+		Height:       height,
+		CurrentBlock: id,
+		Checksum:     checksum,
+	})
+}
+
+// A snapshotWriter counts the bytes of a snapshot as they are written, and
+// stops the export once 'cancel' is closed.
+type snapshotWriter struct {
+	w        io.Writer
+	written  uint64
+	cancel   <-chan struct{}
+	progress func(done, total uint64)
+}
+
+// Write implements io.Writer.
+func (sw *snapshotWriter) Write(p []byte) (int, error) {
+	select {
+	case <-sw.cancel:
+		return 0, errJobCancelled
+	default:
+	}
+	n, err := sw.w.Write(p)
+	sw.written += uint64(n)
+	sw.progress(sw.written, 0)
+	return n, err
+}
+
+// managedExportSnapshot writes a snapshot of the consensus set to 'f', and
+// removes the file if the export fails.
+func (srv *Server) managedExportSnapshot(f *os.File, cancel <-chan struct{}, progress func(done, total uint64)) (ConsensusSnapshotPOST, error) {
+	height, id, checksum, err := srv.cs.ExportSnapshot(&snapshotWriter{
+		w:        f,
+		cancel:   cancel,
+		progress: progress,
+	})
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		// The database may wrap the error returned by the snapshotWriter.
+		select {
+		case <-cancel:
+			return ConsensusSnapshotPOST{}, errJobCancelled
+		default:
+		}
+		return ConsensusSnapshotPOST{}, err
+	}
+	return ConsensusSnapshotPOST{
+		Height:   height,
+		BlockID:  id,
+		Checksum: checksum,
+	}, nil
+}
+
+// consensusSnapshotHandler handles the API calls to /consensus/snapshot,
+// writing a snapshot of the consensus set to the destination file. If 'async'
+// is true, the snapshot is written by a job.
+func (srv *Server) consensusSnapshotHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	destination := req.FormValue("destination")
+	if !filepath.IsAbs(destination) {
+		writeError(w, Error{Message: "destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	f, err := os.Create(destination)
+	if err != nil {
+		writeError(w, Error{Message: "unable to create snapshot file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.FormValue("async") == "true" {
+		srv.startJob(w, "snapshot", true, func(cancel <-chan struct{}, progress func(done, total uint64)) (interface{}, error) {
+			return srv.managedExportSnapshot(f, cancel, progress)
+		})
+		return
+	}
+	snapshot, err := srv.managedExportSnapshot(f, nil, func(uint64, uint64) {})
+	if err != nil {
+		writeError(w, Error{Message: "unable to export snapshot: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, snapshot)
+}
+
+// ConsensusSiacoinOutputGET contains an unspent siacoin output, or reports
+// that the output has been spent.
+type ConsensusSiacoinOutputGET struct {
+	SiacoinOutput types.SiacoinOutput `json:"siacoinoutput"`
+	Spent         bool                `json:"spent"`
+}
+
+// ConsensusSiacoinOutputsGET contains a page of unspent siacoin outputs,
+// ordered by id.
+type ConsensusSiacoinOutputsGET struct {
+	SiacoinOutputs []ConsensusSiacoinOutput `json:"siacoinoutputs"`
+}
+
+// ConsensusSiacoinOutput is an unspent siacoin output along with its id.
+type ConsensusSiacoinOutput struct {
+	ID            types.SiacoinOutputID `json:"id"`
+	SiacoinOutput types.SiacoinOutput   `json:"siacoinoutput"`
+}
+
+// ConsensusSiafundOutputsGET contains a page of unspent siafund outputs,
+// ordered by id.
+type ConsensusSiafundOutputsGET struct {
+	SiafundOutputs []ConsensusSiafundOutput `json:"siafundoutputs"`
+}
+
+// ConsensusSiafundOutput is an unspent siafund output along with its id.
+type ConsensusSiafundOutput struct {
+	ID            types.SiafundOutputID `json:"id"`
+	SiafundOutput types.SiafundOutput   `json:"siafundoutput"`
+}
+
+// ConsensusSiafundOutputGET contains an unspent siafund output along with the
+// siacoins that it can claim from the siafund pool, or reports that the output
+// has been spent.
+type ConsensusSiafundOutputGET struct {
+	SiafundOutput types.SiafundOutput `json:"siafundoutput"`
+	ClaimBalance  types.Currency      `json:"claimbalance"`
+	Spent         bool                `json:"spent"`
+}
+
+// ConsensusFileContractGET contains an open file contract along with the
+// state of its funds and proof window, or reports that the contract has been
+// resolved.
+type ConsensusFileContractGET struct {
+	FileContract   types.FileContract `json:"filecontract"`
+	RemainingFunds types.Currency     `json:"remainingfunds"`
+	WindowOpen     bool               `json:"windowopen"`
+	Spent          bool               `json:"spent"`
+}
+
+// scanOutputsPage parses the 'after' and 'limit' parameters of a request for a
+// page of unspent outputs.
+func scanOutputsPage(req *http.Request) (after crypto.Hash, limit int, err error) {
+	if s := req.FormValue("after"); s != "" {
+		after, err = scanHash(s)
+		if err != nil {
+			return crypto.Hash{}, 0, errors.New("could not read 'after': " + err.Error())
+		}
+	}
+	limit = defaultOutputsPageSize
+	if s := req.FormValue("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil {
+			return crypto.Hash{}, 0, errors.New("could not read 'limit': " + err.Error())
+		}
+		if limit < 1 || limit > maxOutputsPageSize {
+			return crypto.Hash{}, 0, fmt.Errorf("'limit' must be between 1 and %v", maxOutputsPageSize)
+		}
+	}
+	return after, limit, nil
+}
+
+// consensusSiacoinOutputsHandler handles the API calls to
+// /consensus/siacoinoutputs.
+func (srv *Server) consensusSiacoinOutputsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	after, limit, err := scanOutputsPage(req)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	scos := []ConsensusSiacoinOutput{}
+	for _, entry := range srv.cs.SiacoinOutputs(types.SiacoinOutputID(after), limit) {
+		scos = append(scos, ConsensusSiacoinOutput{
+			ID:            entry.ID,
+			SiacoinOutput: entry.SiacoinOutput,
+		})
+	}
+	writeJSON(w, ConsensusSiacoinOutputsGET{SiacoinOutputs: scos})
+}
+
+// consensusSiafundOutputsHandler handles the API calls to
+// /consensus/siafundoutputs.
+func (srv *Server) consensusSiafundOutputsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	after, limit, err := scanOutputsPage(req)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sfos := []ConsensusSiafundOutput{}
+	for _, entry := range srv.cs.SiafundOutputs(types.SiafundOutputID(after), limit) {
+		sfos = append(sfos, ConsensusSiafundOutput{
+			ID:            entry.ID,
+			SiafundOutput: entry.SiafundOutput,
+		})
+	}
+	writeJSON(w, ConsensusSiafundOutputsGET{SiafundOutputs: sfos})
+}
+
+// consensusSiacoinOutputHandler handles the API calls to
+// /consensus/siacoinoutputs/:id.
+func (srv *Server) consensusSiacoinOutputHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sco, exists := srv.cs.SiacoinOutput(types.SiacoinOutputID(id))
+	if exists {
+		writeJSON(w, ConsensusSiacoinOutputGET{SiacoinOutput: sco})
+		return
+	} else if srv.cs.IsSpent(id) {
+		writeJSON(w, ConsensusSiacoinOutputGET{Spent: true})
+		return
+	}
+	writeError(w, Error{Message: "unrecognized siacoin output id"}, http.StatusBadRequest)
+}
+
+// consensusSiafundOutputHandler handles the API calls to
+// /consensus/siafundoutputs/:id.
+func (srv *Server) consensusSiafundOutputHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sfo, exists := srv.cs.SiafundOutput(types.SiafundOutputID(id))
+	if exists {
+		stats, err := srv.cs.ChainStats()
+		if err != nil {
+			writeError(w, Error{Message: "unable to compute chain stats: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, ConsensusSiafundOutputGET{
+			SiafundOutput: sfo,
+			ClaimBalance:  siafundClaim(sfo, stats.SiafundPool),
+		})
+		return
+	} else if srv.cs.IsSpent(id) {
+		writeJSON(w, ConsensusSiafundOutputGET{Spent: true})
+		return
+	}
+	writeError(w, Error{Message: "unrecognized siafund output id"}, http.StatusBadRequest)
+}
+
+// siafundClaim returns the siacoins that a siafund output can claim from a
+// siafund pool of the given value. The claim is computed the same way as when
+// the output is spent.
+func siafundClaim(sfo types.SiafundOutput, pool types.Currency) types.Currency {
+	if pool.Cmp(sfo.ClaimStart) < 0 {
+		return types.ZeroCurrency
+	}
+	return pool.Sub(sfo.ClaimStart).Div(types.SiafundCount).Mul(sfo.Value)
+}
+
+// consensusFileContractHandler handles the API calls to
+// /consensus/filecontracts/:id.
+func (srv *Server) consensusFileContractHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	status, exists := srv.cs.FileContract(types.FileContractID(id))
+	if exists {
+		writeJSON(w, ConsensusFileContractGET{
+			FileContract:   status.FileContract,
+			RemainingFunds: status.RemainingFunds,
+			WindowOpen:     status.WindowOpen,
+		})
+		return
+	} else if srv.cs.IsSpent(id) {
+		writeJSON(w, ConsensusFileContractGET{Spent: true})
+		return
+	}
+	writeError(w, Error{Message: "unrecognized file contract id"}, http.StatusBadRequest)
+}
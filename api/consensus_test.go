@@ -1,8 +1,12 @@
 package api
 
 import (
+	"net/url"
+	"os"
 	"testing"
 
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -33,3 +37,250 @@ func TestIntegrationConsensusGET(t *testing.T) {
 		t.Error("wrong target returned in consensus GET call")
 	}
 }
+
+// TestIntegrationConsensusSnapshotPOST probes the POST call to
+// /consensus/snapshot.
+func TestIntegrationConsensusSnapshotPOST(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createServerTester("TestIntegrationConsensusSnapshotPOST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	// Relative destinations should be rejected.
+	var csp ConsensusSnapshotPOST
+	err = st.postAPI("/consensus/snapshot", url.Values{"destination": {"snapshot"}}, &csp)
+	if err == nil {
+		t.Error("relative snapshot destination was accepted")
+	}
+
+	destination := build.TempDir("api", "TestIntegrationConsensusSnapshotPOST", "consensus.snapshot")
+	err = st.postAPI("/consensus/snapshot", url.Values{"destination": {destination}}, &csp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csp.Height != st.server.cs.Height() || csp.BlockID != st.server.cs.CurrentBlock().ID() {
+		t.Error("snapshot does not end at the current block")
+	}
+	if _, _, checksum := st.server.cs.StateChecksum(); csp.Checksum != checksum {
+		t.Error("snapshot reported the wrong checksum")
+	}
+	if _, err := os.Stat(destination); err != nil {
+		t.Error("snapshot file was not created:", err)
+	}
+}
+
+// TestIntegrationConsensusSiacoinOutputGET probes the GET call to
+// /consensus/siacoinoutputs/:id.
+func TestIntegrationConsensusSiacoinOutputGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createServerTester("TestIntegrationConsensusSiacoinOutputGET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	// The miner payout of the first block has matured.
+	b, _ := st.server.cs.BlockAtHeight(1)
+	id := b.MinerPayoutID(0)
+	var csog ConsensusSiacoinOutputGET
+	err = st.getAPI("/consensus/siacoinoutputs/"+id.String(), &csog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csog.Spent || csog.SiacoinOutput.UnlockHash != b.MinerPayouts[0].UnlockHash {
+		t.Error("wrong siacoin output returned")
+	}
+
+	// Unknown ids should return an error.
+	err = st.getAPI("/consensus/siacoinoutputs/"+types.SiacoinOutputID{1}.String(), &csog)
+	if err == nil {
+		t.Error("unknown siacoin output id did not return an error")
+	}
+	var cfcg ConsensusFileContractGET
+	err = st.getAPI("/consensus/filecontracts/"+types.FileContractID{1}.String(), &cfcg)
+	if err == nil {
+		t.Error("unknown file contract id did not return an error")
+	}
+}
+
+// TestIntegrationConsensusStatsGET probes the GET call to /consensus/stats.
+func TestIntegrationConsensusStatsGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createServerTester("TestIntegrationConsensusStatsGET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	var csg ConsensusStatsGET
+	err = st.getAPI("/consensus/stats", &csg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csg.Height != st.server.cs.Height() || csg.CurrentBlock != st.server.cs.CurrentBlock().ID() {
+		t.Error("wrong block returned in consensus stats GET call")
+	}
+	if csg.Difficulty.Cmp(types.RootTarget.Difficulty()) != 0 {
+		t.Error("wrong difficulty returned in consensus stats GET call")
+	}
+
+	// Mining a block should increase the total work.
+	_, err = st.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var csg2 ConsensusStatsGET
+	err = st.getAPI("/consensus/stats", &csg2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csg2.Height != csg.Height+1 || csg2.TotalWork.Cmp(csg.TotalWork) <= 0 {
+		t.Error("total work did not increase after mining a block")
+	}
+	if csg2.SiacoinSupply.Cmp(csg.SiacoinSupply.Add(csg.Coinbase)) != 0 {
+		t.Error("siacoin supply did not increase by the coinbase of the mined block")
+	}
+	if csg2.BlockInterval > 0 && csg2.Hashrate.IsZero() {
+		t.Error("hashrate was not estimated from the recent blocks")
+	}
+}
+
+// TestIntegrationConsensusAlertsGET probes the GET call to /consensus/alerts.
+func TestIntegrationConsensusAlertsGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createServerTester("TestIntegrationConsensusAlertsGET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	var cag ConsensusAlertsGET
+	err = st.getAPI("/consensus/alerts", &cag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cag.Alerts) != 0 {
+		t.Error("new node has alerts:", cag.Alerts)
+	}
+}
+
+// TestIntegrationConsensusChecksumGET probes the GET call to
+// /consensus/checksum.
+func TestIntegrationConsensusChecksumGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createServerTester("TestIntegrationConsensusChecksumGET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	var ccg ConsensusChecksumGET
+	err = st.getAPI("/consensus/checksum", &ccg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	height, id, checksum := st.cs.StateChecksum()
+	if ccg.Height != height || ccg.CurrentBlock != id || ccg.Checksum != checksum {
+		t.Error("wrong checksum reported:", ccg)
+	}
+	if ccg.Checksum == (crypto.Hash{}) {
+		t.Error("checksum is empty")
+	}
+}
+
+// TestIntegrationConsensusSiacoinOutputsGET probes the GET call to
+// /consensus/siacoinoutputs.
+func TestIntegrationConsensusSiacoinOutputsGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createServerTester("TestIntegrationConsensusSiacoinOutputsGET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	var page ConsensusSiacoinOutputsGET
+	err = st.getAPI("/consensus/siacoinoutputs?limit=2", &page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.SiacoinOutputs) != 2 {
+		t.Fatal("expecting a page of 2 outputs, got", len(page.SiacoinOutputs))
+	}
+	var next ConsensusSiacoinOutputsGET
+	err = st.getAPI("/consensus/siacoinoutputs?limit=1&after="+page.SiacoinOutputs[0].ID.String(), &next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(next.SiacoinOutputs) != 1 || next.SiacoinOutputs[0].ID != page.SiacoinOutputs[1].ID {
+		t.Fatal("next page does not start after the given output")
+	}
+
+	err = st.getAPI("/consensus/siacoinoutputs?limit=0", &page)
+	if err == nil {
+		t.Fatal("expecting an error for a limit of 0")
+	}
+}
+
+// TestSiafundClaim probes the siafundClaim function.
+func TestSiafundClaim(t *testing.T) {
+	sfo := types.SiafundOutput{
+		Value:      types.NewCurrency64(10),
+		ClaimStart: types.SiafundCount,
+	}
+	pool := types.SiafundCount.Mul(types.NewCurrency64(4))
+	if claim := siafundClaim(sfo, pool); claim.Cmp(types.NewCurrency64(30)) != 0 {
+		t.Error("wrong siafund claim:", claim)
+	}
+	if claim := siafundClaim(sfo, types.ZeroCurrency); !claim.IsZero() {
+		t.Error("claim should be zero when the pool is smaller than the claim start:", claim)
+	}
+}
+
+// TestIntegrationConsensusSiafundOutputGET probes the GET call to
+// /consensus/siafundoutputs/:id.
+func TestIntegrationConsensusSiafundOutputGET(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createServerTester("TestIntegrationConsensusSiafundOutputGET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	var csg ConsensusStatsGET
+	err = st.getAPI("/consensus/stats", &csg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := types.GenesisBlock.Transactions[0].SiafundOutputID(0)
+	var csog ConsensusSiafundOutputGET
+	err = st.getAPI("/consensus/siafundoutputs/"+id.String(), &csog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csog.Spent {
+		t.Fatal("genesis siafund output was spent")
+	}
+	if csog.ClaimBalance.Cmp(siafundClaim(csog.SiafundOutput, csg.SiafundPool)) != 0 {
+		t.Error("wrong claim balance returned:", csog.ClaimBalance)
+	}
+}
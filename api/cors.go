@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetCORSOrigins sets the origins, such as "http://localhost:8080", that
+// browsers allow to call the API. Calls from these origins are not required to
+// have the Sia user agent, because browsers do not let pages set it; the user
+// agent check exists to keep other websites from calling the API. "*" lets
+// browsers read the responses of every origin, but without credentials, and
+// the calls are still required to have the Sia user agent.
+func (srv *Server) SetCORSOrigins(origins []string) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.corsOrigins = make(map[string]struct{})
+	for _, origin := range origins {
+		srv.corsOrigins[strings.TrimRight(origin, "/")] = struct{}{}
+	}
+}
+
+// allowedOrigin returns whether browsers may call the API from 'origin'.
+// 'explicit' is true if 'origin' is one of the origins set with
+// SetCORSOrigins, and false if it is only allowed by "*".
+func (srv *Server) allowedOrigin(origin string) (allowed, explicit bool) {
+	if origin == "" {
+		return false, false
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	_, explicit = srv.corsOrigins[origin]
+	_, all := srv.corsOrigins["*"]
+	return explicit || all, explicit
+}
+
+// allowCORS is middleware that adds the CORS headers to the responses to
+// allowed origins, and answers their preflight requests. Calls from origins
+// that were set explicitly skip the user agent check in 'withUA'; all other
+// calls are passed to 'withUA'. Credentials are never allowed for "*", as that
+// would let every website make authenticated calls to the API.
+func (srv *Server) allowCORS(withUA, withoutUA http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		allowed, explicit := srv.allowedOrigin(origin)
+		if !allowed {
+			withUA.ServeHTTP(w, req)
+			return
+		}
+
+		if explicit {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+			w.Header().Add("Vary", "Origin")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		if req.Method == "OPTIONS" && req.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !explicit {
+			withUA.ServeHTTP(w, req)
+			return
+		}
+		withoutUA.ServeHTTP(w, req)
+	})
+}
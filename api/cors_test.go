@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+)
+
+// corsCall makes a call to 'url' from 'origin' with the user agent 'ua'.
+func corsCall(t *testing.T, url, method, origin, ua string) *http.Response {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", ua)
+	req.Header.Set("Origin", origin)
+	if method == "OPTIONS" {
+		req.Header.Set("Access-Control-Request-Method", "GET")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+// TestCORS checks that browsers can call the API from the allowed origins
+// without the Sia user agent, and that other origins are refused.
+func TestCORS(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestCORS")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	st.server.SetCORSOrigins([]string{"http://localhost:8080/"})
+	url := "http://" + st.server.listener.Addr().String() + "/daemon/version"
+
+	// browserGET makes a call without the Sia user agent from 'origin'.
+	browserGET := func(method, origin string) *http.Response {
+		return corsCall(t, url, method, origin, "Mozilla/5.0")
+	}
+
+	resp := browserGET("GET", "http://localhost:8080")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "http://localhost:8080" {
+		t.Fatal("call from an allowed origin failed:", resp.Status, resp.Header)
+	}
+	if resp.Header.Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatal("credentials were not allowed for an explicit origin:", resp.Header)
+	}
+	resp = browserGET("OPTIONS", "http://localhost:8080")
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Methods") == "" {
+		t.Fatal("preflight request from an allowed origin failed:", resp.Status, resp.Header)
+	}
+	resp = browserGET("GET", "http://example.com")
+	if resp.StatusCode != http.StatusBadRequest || resp.Header.Get("Access-Control-Allow-Origin") != "" {
+		t.Fatal("call from another origin was allowed:", resp.Status)
+	}
+}
+
+// TestCORSAnyOrigin checks that "*" never allows credentials, and that calls
+// it allows are still required to have the Sia user agent.
+func TestCORSAnyOrigin(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestCORSAnyOrigin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	st.server.SetCORSOrigins([]string{"*"})
+	url := "http://" + st.server.listener.Addr().String() + "/daemon/version"
+
+	resp := corsCall(t, url, "GET", "http://example.com", "Mozilla/5.0")
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("call without the Sia user agent was allowed:", resp.Status)
+	}
+	resp = corsCall(t, url, "GET", "http://example.com", "Sia-Agent")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Fatal("call with the Sia user agent failed:", resp.Status, resp.Header)
+	}
+	if resp.Header.Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatal("credentials were allowed for every origin:", resp.Header)
+	}
+	resp = corsCall(t, url, "OPTIONS", "http://example.com", "Mozilla/5.0")
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Credentials") != "" {
+		t.Fatal("preflight request for every origin failed:", resp.Status, resp.Header)
+	}
+}
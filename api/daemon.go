@@ -15,6 +15,7 @@ import (
 	"runtime"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/inconshreveable/go-update"
@@ -26,6 +27,7 @@ import (
 type SiaConstants struct {
 	GenesisTimestamp      types.Timestamp   `json:"genesistimestamp"`
 	BlockSizeLimit        uint64            `json:"blocksizelimit"`
+	MaxTransactionSize    uint64            `json:"maxtransactionsize"`
 	BlockFrequency        types.BlockHeight `json:"blockfrequency"`
 	TargetWindow          types.BlockHeight `json:"targetwindow"`
 	MedianTimestampWindow uint64            `json:"mediantimestampwindow"`
@@ -46,8 +48,34 @@ type SiaConstants struct {
 	SiacoinPrecision types.Currency `json:"siacoinprecision"`
 }
 
+// DaemonVersion contains the fields returned by a GET call to
+// "/daemon/version".
 type DaemonVersion struct {
-	Version string `json:"version"`
+	Version         string `json:"version"`
+	GitRevision     string `json:"gitrevision"`
+	ProtocolVersion string `json:"protocolversion"`
+	Release         string `json:"release"`
+	Network         string `json:"network"`
+}
+
+// DaemonBlocklistGET contains the fields returned by a GET call to
+// "/daemon/blocklist".
+type DaemonBlocklistGET struct {
+	Ranges []string `json:"ranges"`
+}
+
+// DaemonAlertsGET contains the fields returned by a GET call to
+// "/daemon/alerts".
+type DaemonAlertsGET struct {
+	Alerts []modules.Alert `json:"alerts"`
+}
+
+// DaemonBandwidthGET contains the fields returned by a GET call to
+// "/daemon/bandwidth".
+type DaemonBandwidthGET struct {
+	Categories    []modules.BandwidthUsage `json:"categories"`
+	TotalSent     uint64                   `json:"totalsent"`
+	TotalReceived uint64                   `json:"totalreceived"`
 }
 
 // UpdateInfo indicates whether an update is available, and to what
@@ -216,7 +244,7 @@ func updateToRelease(release githubRelease) error {
 func (srv *Server) daemonUpdateHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	release, err := fetchLatestRelease()
 	if err != nil {
-		writeError(w, Error{"Failed to fetch latest release: " + err.Error()}, http.StatusInternalServerError)
+		writeError(w, Error{Message: "Failed to fetch latest release: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
 	latestVersion := release.TagName[1:] // delete leading 'v'
@@ -233,15 +261,15 @@ func (srv *Server) daemonUpdateHandlerGET(w http.ResponseWriter, _ *http.Request
 func (srv *Server) daemonUpdateHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	release, err := fetchLatestRelease()
 	if err != nil {
-		writeError(w, Error{"Failed to fetch latest release: " + err.Error()}, http.StatusInternalServerError)
+		writeError(w, Error{Message: "Failed to fetch latest release: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
 	err = updateToRelease(release)
 	if err != nil {
 		if rerr := update.RollbackError(err); rerr != nil {
-			writeError(w, Error{"Serious error: Failed to rollback from bad update: " + rerr.Error()}, http.StatusInternalServerError)
+			writeError(w, Error{Message: "Serious error: Failed to rollback from bad update: " + rerr.Error()}, http.StatusInternalServerError)
 		} else {
-			writeError(w, Error{"Failed to apply update: " + err.Error()}, http.StatusInternalServerError)
+			writeError(w, Error{Message: "Failed to apply update: " + err.Error()}, http.StatusInternalServerError)
 		}
 		return
 	}
@@ -253,6 +281,7 @@ func (srv *Server) daemonConstantsHandler(w http.ResponseWriter, _ *http.Request
 	sc := SiaConstants{
 		GenesisTimestamp:      types.GenesisTimestamp,
 		BlockSizeLimit:        types.BlockSizeLimit,
+		MaxTransactionSize:    types.MaxTransactionSize,
 		BlockFrequency:        types.BlockFrequency,
 		TargetWindow:          types.TargetWindow,
 		MedianTimestampWindow: types.MedianTimestampWindow,
@@ -278,7 +307,70 @@ func (srv *Server) daemonConstantsHandler(w http.ResponseWriter, _ *http.Request
 
 // daemonVersionHandler handles the API call that requests the daemon's version.
 func (srv *Server) daemonVersionHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	writeJSON(w, DaemonVersion{Version: build.Version})
+	writeJSON(w, DaemonVersion{
+		Version:         build.Version,
+		GitRevision:     build.GitRevision,
+		ProtocolVersion: build.ProtocolVersion,
+		Release:         build.Release,
+		Network:         types.CurrentNetwork().Name,
+	})
+}
+
+// daemonBlocklistHandlerGET handles the API call asking for the blocked IP
+// ranges.
+func (srv *Server) daemonBlocklistHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, DaemonBlocklistGET{Ranges: srv.gateway.Blocklist().Ranges()})
+}
+
+// daemonBlocklistAddHandler handles the API call to block an IP range.
+// Connected peers within the range are disconnected.
+func (srv *Server) daemonBlocklistAddHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	blocklist := srv.gateway.Blocklist()
+	if err := blocklist.Block(req.FormValue("range")); err != nil {
+		writeError(w, Error{Message: "failed to block range: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	for _, p := range srv.gateway.Peers() {
+		if blocklist.IsBlocked(p.NetAddress) {
+			srv.gateway.Disconnect(p.NetAddress)
+		}
+	}
+	writeSuccess(w)
+}
+
+// daemonBlocklistRemoveHandler handles the API call to unblock an IP range.
+func (srv *Server) daemonBlocklistRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := srv.gateway.Blocklist().Unblock(req.FormValue("range")); err != nil {
+		writeError(w, Error{Message: "failed to unblock range: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writeSuccess(w)
+}
+
+// daemonAlertsHandlerGET handles the API call asking for the active alerts
+// of the modules.
+func (srv *Server) daemonAlertsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, DaemonAlertsGET{Alerts: modules.ActiveAlerts()})
+}
+
+// daemonAlertsClearHandler handles the API call to clear an active alert.
+func (srv *Server) daemonAlertsClearHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := modules.ClearAlert(req.FormValue("id")); err != nil {
+		writeError(w, Error{Message: "failed to clear alert: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
+		return
+	}
+	writeSuccess(w)
+}
+
+// daemonBandwidthHandler handles the API call asking for the bandwidth used
+// by each subsystem.
+func (srv *Server) daemonBandwidthHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	db := DaemonBandwidthGET{Categories: modules.BandwidthTotals()}
+	for _, usage := range db.Categories {
+		db.TotalSent += usage.Sent
+		db.TotalReceived += usage.Received
+	}
+	writeJSON(w, db)
 }
 
 // daemonStopHandler handles the API call to stop the daemon cleanly.
@@ -293,7 +385,11 @@ func (srv *Server) daemonStopHandler(w http.ResponseWriter, _ *http.Request, _ h
 	}
 	f.Flush()
 
-	if err := srv.Close(); err != nil {
-		build.Critical(err)
-	}
+	// Close waits for in-progress API calls to complete, including this one,
+	// so the server must be closed after the handler returns.
+	go func() {
+		if err := srv.Close(); err != nil {
+			build.Critical(err)
+		}
+	}()
 }
@@ -1,9 +1,13 @@
 package api
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 // TestVersion checks that /daemon/version is responding with the correct
@@ -22,6 +26,9 @@ func TestVersion(t *testing.T) {
 	if dv.Version != build.Version {
 		t.Fatalf("/daemon/version reporting bad version: expected %v, got %v", build.Version, dv.Version)
 	}
+	if dv.ProtocolVersion != build.ProtocolVersion || dv.Release != build.Release || dv.Network != types.CurrentNetwork().Name {
+		t.Fatal("/daemon/version reporting bad build info:", dv)
+	}
 }
 
 /*
@@ -49,3 +56,149 @@ func TestStop(t *testing.T) {
 	}
 }
 */
+
+// TestDaemonBlocklist checks that IP ranges can be blocked and unblocked
+// through the API, and that blocking a range disconnects its peers.
+func TestDaemonBlocklist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestDaemonBlocklist1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	peer, err := gateway.New("localhost:0", build.TempDir("api", "TestDaemonBlocklist2", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+	err = st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = st.stdPostAPI("/daemon/blocklist/add", url.Values{"range": {"garbage"}})
+	if err == nil {
+		t.Fatal("API accepted an invalid range")
+	}
+	err = st.stdPostAPI("/daemon/blocklist/add", url.Values{"range": {"127.0.0.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.gateway.Blocklist().Unblock("127.0.0.1")
+	var db DaemonBlocklistGET
+	err = st.getAPI("/daemon/blocklist", &db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Ranges) != 1 || db.Ranges[0] != "127.0.0.1/32" {
+		t.Fatal("/daemon/blocklist returned the wrong ranges:", db.Ranges)
+	}
+	if len(st.gateway.Peers()) != 0 {
+		t.Fatal("peer in the blocked range was not disconnected")
+	}
+	err = st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil)
+	if err == nil {
+		t.Fatal("connected to a peer in the blocked range")
+	}
+
+	err = st.stdPostAPI("/daemon/blocklist/remove", url.Values{"range": {"127.0.0.1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.stdPostAPI("/daemon/blocklist/remove", url.Values{"range": {"127.0.0.1"}})
+	if err == nil {
+		t.Fatal("API unblocked a range that was not blocked")
+	}
+	err = st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDaemonAlerts checks that the alerts raised by the modules are listed by
+// /daemon/alerts until they are cleared.
+func TestDaemonAlerts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestDaemonAlerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	modules.RaiseAlert(modules.Alert{ID: "test.alert", Module: "test", Severity: modules.SeverityCritical, Msg: "testing"})
+	defer modules.ClearAlert("test.alert")
+	var dag DaemonAlertsGET
+	if err := st.getAPI("/daemon/alerts", &dag); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, a := range dag.Alerts {
+		found = found || (a.ID == "test.alert" && a.Severity == modules.SeverityCritical && a.Msg == "testing")
+	}
+	if !found {
+		t.Fatal("/daemon/alerts does not list the raised alert:", dag.Alerts)
+	}
+
+	if err := st.stdPostAPI("/daemon/alerts/clear", url.Values{"id": {"test.alert"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.stdPostAPI("/daemon/alerts/clear", url.Values{"id": {"test.alert"}}); err == nil {
+		t.Fatal("API cleared an alert that was not active")
+	}
+	if err := st.getAPI("/daemon/alerts", &dag); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range dag.Alerts {
+		if a.ID == "test.alert" {
+			t.Fatal("cleared alert is still listed")
+		}
+	}
+}
+
+// TestDaemonBandwidth checks that /daemon/bandwidth reports the bandwidth used
+// by each subsystem.
+func TestDaemonBandwidth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestDaemonBandwidth1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	peer, err := gateway.New("localhost:0", build.TempDir("api", "TestDaemonBandwidth2", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+
+	var before, after DaemonBandwidthGET
+	if err := st.getAPI("/daemon/bandwidth", &before); err != nil {
+		t.Fatal(err)
+	}
+	if len(before.Categories) != len(modules.BandwidthCategories) {
+		t.Fatal("/daemon/bandwidth returned the wrong categories:", before.Categories)
+	}
+	err = st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/daemon/bandwidth", &after); err != nil {
+		t.Fatal(err)
+	}
+	if after.TotalSent <= before.TotalSent || after.TotalReceived <= before.TotalReceived {
+		t.Fatal("connecting to a peer did not use any bandwidth:", before, after)
+	}
+	var sent, received uint64
+	for _, usage := range after.Categories {
+		sent += usage.Sent
+		received += usage.Received
+	}
+	if sent != after.TotalSent || received != after.TotalReceived {
+		t.Fatal("totals do not match the categories:", after)
+	}
+}
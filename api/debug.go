@@ -0,0 +1,96 @@
+package api
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// DebugStats contains the runtime statistics of siad.
+type DebugStats struct {
+	Version    string `json:"version"`
+	GoVersion  string `json:"goversion"`
+	NumCPU     int    `json:"numcpu"`
+	Goroutines int    `json:"goroutines"`
+	Uptime     int64  `json:"uptime"`
+
+	HeapAlloc    uint64 `json:"heapalloc"`
+	HeapSys      uint64 `json:"heapsys"`
+	HeapObjects  uint64 `json:"heapobjects"`
+	TotalAlloc   uint64 `json:"totalalloc"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"numgc"`
+	PauseTotalNs uint64 `json:"pausetotalns"`
+}
+
+// startTime is the time at which siad started, used to report the uptime.
+var startTime = time.Now()
+
+// EnableDebug makes the server serve the profiling and runtime statistics
+// endpoints under /debug. They are disabled by default, because profiling
+// slows the node down and goroutine dumps can reveal private information.
+func (srv *Server) EnableDebug() {
+	srv.mu.Lock()
+	srv.debug = true
+	srv.mu.Unlock()
+}
+
+// requireDebug is middleware that responds with 404 Not Found to the debug
+// API calls unless they have been enabled with EnableDebug.
+func (srv *Server) requireDebug(h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		srv.mu.Lock()
+		debug := srv.debug
+		srv.mu.Unlock()
+		if !debug {
+			srv.unrecognizedCallHandler(w, req)
+			return
+		}
+		h(w, req, ps)
+	}
+}
+
+// debugPprofHandler handles the API calls to /debug/pprof, serving the
+// profiles of the Go runtime in the format expected by 'go tool pprof'.
+func (srv *Server) debugPprofHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	switch ps.ByName("profile") {
+	case "/cmdline":
+		pprof.Cmdline(w, req)
+	case "/profile":
+		pprof.Profile(w, req)
+	case "/symbol":
+		pprof.Symbol(w, req)
+	case "/trace":
+		pprof.Trace(w, req)
+	default:
+		// Index serves the named profiles, such as heap and goroutine, and
+		// lists the available profiles.
+		pprof.Index(w, req)
+	}
+}
+
+// debugStatsHandler handles the API call to /debug/stats.
+func (srv *Server) debugStatsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	writeJSON(w, DebugStats{
+		Version:    build.Version,
+		GoVersion:  runtime.Version(),
+		NumCPU:     runtime.NumCPU(),
+		Goroutines: runtime.NumGoroutine(),
+		Uptime:     int64(time.Since(startTime).Seconds()),
+
+		HeapAlloc:    ms.HeapAlloc,
+		HeapSys:      ms.HeapSys,
+		HeapObjects:  ms.HeapObjects,
+		TotalAlloc:   ms.TotalAlloc,
+		Sys:          ms.Sys,
+		NumGC:        ms.NumGC,
+		PauseTotalNs: ms.PauseTotalNs,
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// TestDebugAPI checks that the debug API calls are only served once they have
+// been enabled.
+func TestDebugAPI(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestDebugAPI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	var ds DebugStats
+	if err := st.getAPI("/debug/stats", &ds); err == nil {
+		t.Fatal("debug API call succeeded before the debug API was enabled")
+	}
+
+	st.server.EnableDebug()
+	if err := st.getAPI("/debug/stats", &ds); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Goroutines == 0 || ds.HeapAlloc == 0 || ds.NumCPU == 0 {
+		t.Fatal("runtime statistics were not reported:", ds)
+	}
+
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	dump, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(dump), "goroutine profile") {
+		t.Fatal("goroutine dump was not served:", resp.Status)
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// The codes of the errors returned by the API, which let clients handle an
+// error without parsing its message. A code has the form 'module.error',
+// where 'module' is the module that the error came from, or 'api' for errors
+// of the API itself. Codes are stable: a code keeps its meaning across
+// releases, while messages may be reworded at any time.
+const (
+	// Errors of the API itself. An error that has no more specific code has
+	// the code for its HTTP status.
+	CodeBadRequest        = "api.bad_request"
+	CodeInternalError     = "api.internal_error"
+	CodeNotFound          = "api.not_found"
+	CodeRateLimited       = "api.rate_limited"
+	CodeUnauthorized      = "api.unauthorized"
+	CodeUnavailable       = "api.unavailable"
+	CodeUserAgentRequired = "api.user_agent_required"
+
+	CodeBlockKnown        = "consensus.block_known"
+	CodeBlockNotExtending = "consensus.block_not_extending"
+	CodeBlockUnsolved     = "consensus.block_unsolved"
+
+	CodeAlertNotActive    = "daemon.alert_not_active"
+	CodeJobFinished       = "daemon.job_finished"
+	CodeJobNotCancellable = "daemon.job_not_cancellable"
+	CodeJobNotFound       = "daemon.job_not_found"
+	CodeShuttingDown      = "daemon.shutting_down"
+
+	CodeHostAtCapacity        = "host.at_capacity"
+	CodeStorageFolderNotFound = "host.storage_folder_not_found"
+
+	CodeArbitraryDataTooLarge   = "transactionpool.arbitrary_data_too_large"
+	CodeDuplicateTransactionSet = "transactionpool.duplicate_transaction_set"
+	CodeNonStandardArbitrary    = "transactionpool.nonstandard_arbitrary_data"
+	CodeTransactionTooLarge     = "transactionpool.transaction_too_large"
+
+	CodeBadEncryptionKey  = "wallet.bad_encryption_key"
+	CodeFaucetMainnet     = "wallet.faucet_mainnet"
+	CodeFundsUnconfirmed  = "wallet.funds_unconfirmed"
+	CodeInsufficientFunds = "wallet.insufficient_funds"
+	CodeWalletLocked      = "wallet.locked"
+)
+
+// errorCodes maps the errors that clients are expected to handle to their
+// codes.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{modules.ErrBlockKnown, CodeBlockKnown},
+	{modules.ErrBlockUnsolved, CodeBlockUnsolved},
+	{modules.ErrNonExtendingBlock, CodeBlockNotExtending},
+
+	{modules.ErrAlertNotActive, CodeAlertNotActive},
+	{errJobFinished, CodeJobFinished},
+	{errJobNotCancellable, CodeJobNotCancellable},
+	{errJobNotFound, CodeJobNotFound},
+	{errJobsClosed, CodeShuttingDown},
+
+	{modules.ErrInsufficientStorageForRemoval, CodeHostAtCapacity},
+	{modules.ErrInsufficientStorageForShrink, CodeHostAtCapacity},
+	{errStorageFolderNotFound, CodeStorageFolderNotFound},
+
+	{modules.ErrDuplicateTransactionSet, CodeDuplicateTransactionSet},
+	{modules.ErrInvalidArbPrefix, CodeNonStandardArbitrary},
+	{modules.ErrLargeArbitraryData, CodeArbitraryDataTooLarge},
+	{modules.ErrLargeTransaction, CodeTransactionTooLarge},
+	{modules.ErrLargeTransactionSet, CodeTransactionTooLarge},
+
+	{modules.ErrBadEncryptionKey, CodeBadEncryptionKey},
+	{errFaucetMainnet, CodeFaucetMainnet},
+	{modules.ErrLockedWallet, CodeWalletLocked},
+	{modules.ErrLowBalance, CodeInsufficientFunds},
+	{modules.ErrPotentialDoubleSpend, CodeFundsUnconfirmed},
+}
+
+// errorCode returns the code of 'err', or the empty string if 'err' is not
+// one of the errors in errorCodes. Handlers set the code of an API error from
+// the error that caused it, since the message usually adds context to the
+// message of the error.
+func errorCode(err error) string {
+	for _, ec := range errorCodes {
+		if err == ec.err {
+			return ec.code
+		}
+	}
+	return ""
+}
+
+// statusCode returns the code of an API error that has no more specific code
+// and is sent with the HTTP status 'status'.
+func statusCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	default:
+		return CodeInternalError
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestErrorCode probes the errorCode and statusCode functions.
+func TestErrorCode(t *testing.T) {
+	errTests := []struct {
+		err  error
+		code string
+	}{
+		{modules.ErrLowBalance, CodeInsufficientFunds},
+		{modules.ErrLockedWallet, CodeWalletLocked},
+		{modules.ErrInsufficientStorageForRemoval, CodeHostAtCapacity},
+		{modules.ErrInsufficientStorageForShrink, CodeHostAtCapacity},
+		{errJobNotFound, CodeJobNotFound},
+		{modules.ErrAlertNotActive, CodeAlertNotActive},
+		{errors.New(modules.ErrLowBalance.Error()), ""},
+		{errors.New("Malformed windowsize"), ""},
+	}
+	for _, test := range errTests {
+		if code := errorCode(test.err); code != test.code {
+			t.Errorf("errorCode(%q): expected %q, got %q", test.err, test.code, code)
+		}
+	}
+
+	statusTests := []struct {
+		status int
+		code   string
+	}{
+		{http.StatusBadRequest, CodeBadRequest},
+		{http.StatusUnauthorized, CodeUnauthorized},
+		{http.StatusNotFound, CodeNotFound},
+		{http.StatusTooManyRequests, CodeRateLimited},
+		{http.StatusServiceUnavailable, CodeUnavailable},
+		{http.StatusInternalServerError, CodeInternalError},
+		{http.StatusBadGateway, CodeInternalError},
+	}
+	for _, test := range statusTests {
+		if code := statusCode(test.status); code != test.code {
+			t.Errorf("statusCode(%v): expected %v, got %v", test.status, test.code, code)
+		}
+	}
+}
+
+// TestIntegrationErrorCodes checks that the errors returned by the API carry
+// their codes.
+func TestIntegrationErrorCodes(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationErrorCodes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	err = st.stdPostAPI("/wallet/siacoins", url.Values{
+		"amount":      {"1000000000000000000000000000000000000000"},
+		"destination": {st.coinAddress()},
+	})
+	if apiErr, ok := err.(Error); !ok || apiErr.Code != CodeInsufficientFunds {
+		t.Error("expected an insufficient funds error, got", err)
+	}
+	if err := st.stdGetAPI("/nonexistent"); err == nil || err.(Error).Code != CodeNotFound {
+		t.Error("expected a not found error, got", err)
+	}
+	if err := st.stdGetAPIUA("/consensus", "Mozilla"); err == nil || err.(Error).Code != CodeUserAgentRequired {
+		t.Error("expected a user agent error, got", err)
+	}
+
+	destination := st.coinAddress()
+	if err := st.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	err = st.stdPostAPI("/wallet/siacoins", url.Values{
+		"amount":      {"1"},
+		"destination": {destination},
+	})
+	if apiErr, ok := err.(Error); !ok || apiErr.Code != CodeWalletLocked {
+		t.Error("expected a locked wallet error, got", err)
+	}
+}
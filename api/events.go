@@ -0,0 +1,225 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// EventBlock is the type of the Event that is sent for each block that
+	// is added to the current path. Its data is the ID of the block.
+	EventBlock = "block"
+
+	// EventFundsReceived is the type of the Event that is sent when the
+	// confirmed siacoin balance of the wallet increases. Its data is the
+	// amount of the increase.
+	EventFundsReceived = "fundsreceived"
+
+	// EventAlertRaised is the type of the Event that is sent when a module
+	// raises an alert. Its data is the alert.
+	EventAlertRaised = "alertraised"
+
+	// EventAlertCleared is the type of the Event that is sent when an alert
+	// is cleared. Its data is the alert.
+	EventAlertCleared = "alertcleared"
+
+	// eventBufferSize is the number of events that are queued for each
+	// stream. Events are dropped for streams that do not keep up.
+	eventBufferSize = 64
+)
+
+// An Event is a change to the state of the node, sent to the clients of the
+// /daemon/events stream. Events of the host have the types of the host
+// events, and their data is the ID of the file contract.
+type Event struct {
+	Type   string            `json:"type"`
+	Height types.BlockHeight `json:"height"`
+	Data   interface{}       `json:"data"`
+}
+
+// An eventHub collects the events of the modules and sends them to each open
+// event stream.
+type eventHub struct {
+	wallet modules.Wallet
+
+	// height is the height of the current block, which is tracked by
+	// following the consensus changes.
+	height  types.BlockHeight
+	streams map[chan Event]struct{}
+	closed  bool
+	mu      sync.Mutex
+}
+
+// newEventHub creates an eventHub and subscribes it to the alerts, and to the
+// consensus set and the host if they are loaded.
+func newEventHub(cs modules.ConsensusSet, h modules.Host, w modules.Wallet) (*eventHub, error) {
+	eh := &eventHub{
+		wallet:  w,
+		streams: make(map[chan Event]struct{}),
+	}
+	if cs != nil {
+		eh.height = cs.Height()
+		if err := cs.ConsensusSetSubscribe(eh, modules.ConsensusChangeRecent); err != nil {
+			return nil, err
+		}
+	}
+	if h != nil {
+		h.HostSubscribe(eh)
+	}
+	modules.AlertSubscribe(eh)
+	return eh, nil
+}
+
+// publish sends an event to every open stream. The hub must be locked.
+func (eh *eventHub) publish(e Event) {
+	for stream := range eh.streams {
+		select {
+		case stream <- e:
+		default:
+		}
+	}
+}
+
+// ProcessConsensusChange sends an event for each block applied by a consensus
+// change, and an event if the change increased the balance of the wallet.
+func (eh *eventHub) ProcessConsensusChange(cc modules.ConsensusChange) {
+	// Find the value of the outputs that the change created and spent in
+	// the wallet. The wallet is called before locking the hub, so that the
+	// hub is never locked while waiting on another module.
+	var received, spent types.Currency
+	if eh.wallet != nil && len(cc.SiacoinOutputDiffs) > 0 {
+		addrs := make(map[types.UnlockHash]struct{})
+		for _, addr := range eh.wallet.AllAddresses() {
+			addrs[addr] = struct{}{}
+		}
+		for _, diff := range cc.SiacoinOutputDiffs {
+			if _, exists := addrs[diff.SiacoinOutput.UnlockHash]; !exists {
+				continue
+			}
+			if diff.Direction == modules.DiffApply {
+				received = received.Add(diff.SiacoinOutput.Value)
+			} else {
+				spent = spent.Add(diff.SiacoinOutput.Value)
+			}
+		}
+	}
+
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	eh.height -= types.BlockHeight(len(cc.RevertedBlocks))
+	for _, b := range cc.AppliedBlocks {
+		eh.height++
+		eh.publish(Event{Type: EventBlock, Height: eh.height, Data: b.ID()})
+	}
+	if received.Cmp(spent) > 0 {
+		eh.publish(Event{Type: EventFundsReceived, Height: eh.height, Data: received.Sub(spent)})
+	}
+}
+
+// ReceiveHostEvent sends an event of the host to every open stream.
+func (eh *eventHub) ReceiveHostEvent(he modules.HostEvent) {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	eh.publish(Event{Type: he.Type, Height: he.Height, Data: he.ContractID})
+}
+
+// ReceiveAlert sends an event for an alert that was raised or cleared.
+func (eh *eventHub) ReceiveAlert(a modules.Alert, active bool) {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	e := Event{Type: EventAlertCleared, Height: eh.height, Data: a}
+	if active {
+		e.Type = EventAlertRaised
+	}
+	eh.publish(e)
+}
+
+// subscribe opens a new stream of events. The returned channel is closed when
+// the hub is closed. ok is false if the hub has already been closed.
+func (eh *eventHub) subscribe() (stream chan Event, ok bool) {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	if eh.closed {
+		return nil, false
+	}
+	stream = make(chan Event, eventBufferSize)
+	eh.streams[stream] = struct{}{}
+	return stream, true
+}
+
+// unsubscribe closes a stream of events.
+func (eh *eventHub) unsubscribe(stream chan Event) {
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	if _, exists := eh.streams[stream]; exists {
+		delete(eh.streams, stream)
+		close(stream)
+	}
+}
+
+// close closes every stream, so that the handlers serving them return, and
+// unsubscribes the hub from the alerts, the consensus set, and the host.
+func (eh *eventHub) close(cs modules.ConsensusSet, h modules.Host) {
+	modules.AlertUnsubscribe(eh)
+	if cs != nil {
+		cs.Unsubscribe(eh)
+	}
+	if h != nil {
+		h.Unsubscribe(eh)
+	}
+
+	eh.mu.Lock()
+	defer eh.mu.Unlock()
+	eh.closed = true
+	for stream := range eh.streams {
+		delete(eh.streams, stream)
+		close(stream)
+	}
+}
+
+// daemonEventsHandler handles the API call that streams the events of the
+// node as server-sent events. The stream stays open until the client
+// disconnects or the server is closed.
+func (srv *Server) daemonEventsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, Error{Message: "streaming is not supported"}, http.StatusInternalServerError)
+		return
+	}
+	stream, ok := srv.events.subscribe()
+	if !ok {
+		writeError(w, Error{Message: "server is shutting down"}, http.StatusServiceUnavailable)
+		return
+	}
+	defer srv.events.unsubscribe(stream)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	for {
+		select {
+		case e, ok := <-stream:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			f.Flush()
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestEventHub checks that the event hub tracks the block height and sends
+// the events of the modules to every stream.
+func TestEventHub(t *testing.T) {
+	eh, err := newEventHub(nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eh.height = 10
+	stream, ok := eh.subscribe()
+	if !ok {
+		t.Fatal("unable to subscribe to the hub")
+	}
+
+	b1 := types.Block{Timestamp: 1}
+	b2 := types.Block{Timestamp: 2}
+	eh.ProcessConsensusChange(modules.ConsensusChange{
+		RevertedBlocks: []types.Block{{}},
+		AppliedBlocks:  []types.Block{b1, b2},
+	})
+	eh.ReceiveHostEvent(modules.HostEvent{Type: modules.HostEventProofSubmitted, ContractID: types.FileContractID{1}, Height: 11})
+	alert := modules.Alert{ID: "test.alert", Module: "test", Severity: modules.SeverityWarning, Msg: "testing"}
+	eh.ReceiveAlert(alert, true)
+	eh.ReceiveAlert(alert, false)
+	for _, expected := range []Event{
+		{Type: EventBlock, Height: 10, Data: b1.ID()},
+		{Type: EventBlock, Height: 11, Data: b2.ID()},
+		{Type: modules.HostEventProofSubmitted, Height: 11, Data: types.FileContractID{1}},
+		{Type: EventAlertRaised, Height: 11, Data: alert},
+		{Type: EventAlertCleared, Height: 11, Data: alert},
+	} {
+		if e := <-stream; e != expected {
+			t.Fatalf("expected event %v, got %v", expected, e)
+		}
+	}
+
+	// Closing the hub closes the streams and refuses new ones.
+	eh.close(nil, nil)
+	if _, ok := <-stream; ok {
+		t.Fatal("stream was not closed")
+	}
+	if _, ok := eh.subscribe(); ok {
+		t.Fatal("subscribed to a closed hub")
+	}
+}
+
+// TestIntegrationDaemonEvents checks that /daemon/events streams new blocks,
+// and that the stream ends when the server is closed.
+func TestIntegrationDaemonEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationDaemonEvents")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + "/daemon/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatal("wrong content type:", resp.Header.Get("Content-Type"))
+	}
+
+	b, err := st.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if !strings.HasPrefix(scanner.Text(), "data: ") {
+			continue
+		}
+		var e struct {
+			Type   string
+			Height types.BlockHeight
+			Data   types.BlockID
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(scanner.Text(), "data: ")), &e); err != nil {
+			t.Fatal(err)
+		}
+		if e.Type != EventBlock || e.Data != b.ID() || e.Height != st.cs.Height() {
+			t.Fatal("wrong event for the new block:", e)
+		}
+		break
+	}
+
+	// Closing the server ends the stream without waiting for the shutdown
+	// timeout.
+	start := time.Now()
+	if err := st.server.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for scanner.Scan() {
+	}
+	if time.Since(start) > shutdownTimeout/2 {
+		t.Fatal("event stream delayed the shutdown of the server")
+	}
+}
@@ -203,14 +203,14 @@ func (srv *Server) explorerBlocksHandler(w http.ResponseWriter, req *http.Reques
 	var height types.BlockHeight
 	_, err := fmt.Sscan(ps.ByName("height"), &height)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
 	// Fetch and return the explorer block.
 	block, exists := srv.cs.BlockAtHeight(height)
 	if !exists {
-		writeError(w, Error{"no block found at input height in call to /explorer/block"}, http.StatusBadRequest)
+		writeError(w, Error{Message: "no block found at input height in call to /explorer/block"}, http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, ExplorerBlockGET{
@@ -253,7 +253,7 @@ func (srv *Server) explorerHashHandler(w http.ResponseWriter, req *http.Request,
 	if err != nil {
 		addr, err := scanAddress(ps.ByName("hash"))
 		if err != nil {
-			writeError(w, Error{err.Error()}, http.StatusBadRequest)
+			writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 			return
 		}
 		hash = crypto.Hash(addr)
@@ -262,7 +262,7 @@ func (srv *Server) explorerHashHandler(w http.ResponseWriter, req *http.Request,
 	// TODO: lookups on the zero hash are too expensive to allow. Need a
 	// better way to handle this case.
 	if hash == (crypto.Hash{}) {
-		writeError(w, Error{"can't lookup the empty unlock hash"}, http.StatusBadRequest)
+		writeError(w, Error{Message: "can't lookup the empty unlock hash"}, http.StatusBadRequest)
 		return
 	}
 
@@ -348,7 +348,7 @@ func (srv *Server) explorerHashHandler(w http.ResponseWriter, req *http.Request,
 	}
 
 	// Hash not found, return an error.
-	writeError(w, Error{"unrecognized hash used as input to /explorer/hash"}, http.StatusBadRequest)
+	writeError(w, Error{Message: "unrecognized hash used as input to /explorer/hash"}, http.StatusBadRequest)
 }
 
 // explorerHandler handles API calls to /explorer
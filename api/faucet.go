@@ -0,0 +1,164 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	errFaucetMainnet = errors.New("the faucet is only available on test networks")
+	errNoFaucetURL   = errors.New("no faucet url was given, and siad was not started with --faucet-url")
+
+	// errServerShutdown is returned by calls that stop waiting for the faucet
+	// because the API server is shutting down.
+	errServerShutdown = errors.New("the API server is shutting down")
+)
+
+var (
+	// faucetPollInterval is how often the wallet is checked for the coins
+	// sent by the faucet.
+	faucetPollInterval = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 1 * time.Second
+		case "standard":
+			return 5 * time.Second
+		case "testing":
+			return 50 * time.Millisecond
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// faucetRequestTimeout is how long the faucet has to respond to a
+	// request for coins.
+	faucetRequestTimeout = 30 * time.Second
+)
+
+// WalletFaucetPOST contains the coins that the faucet sent to the wallet in
+// the POST call to /wallet/faucet, once they are confirmed.
+type WalletFaucetPOST struct {
+	Address            types.UnlockHash      `json:"address"`
+	Amount             types.Currency        `json:"amount"`
+	TransactionIDs     []types.TransactionID `json:"transactionids"`
+	ConfirmationHeight types.BlockHeight     `json:"confirmationheight"`
+}
+
+// SetFaucetURL sets the faucet that /wallet/faucet requests coins from when
+// the call does not name one.
+func (srv *Server) SetFaucetURL(faucetURL string) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.faucetURL = faucetURL
+}
+
+// requestFaucetCoins asks the faucet at 'faucetURL' to send coins to 'addr'.
+// The faucet is sent the address and the name of the network as a form, and
+// any response with a 2xx status is taken as a promise to send the coins.
+func requestFaucetCoins(faucetURL string, addr types.UnlockHash) error {
+	client := &http.Client{Timeout: faucetRequestTimeout}
+	resp, err := client.PostForm(faucetURL, url.Values{
+		"address": {addr.String()},
+		"network": {types.CurrentNetwork().Name},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		return errors.New("faucet responded with " + resp.Status + ": " + strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// managedWaitForFaucet waits until the wallet has a confirmed transaction
+// that sends coins to 'addr'. Because 'addr' is a new address, every such
+// transaction was sent by the faucet. The wait ends early if 'cancel' is
+// closed or the server shuts down.
+func (srv *Server) managedWaitForFaucet(addr types.UnlockHash, cancel <-chan struct{}) (WalletFaucetPOST, error) {
+	for {
+		wfp := WalletFaucetPOST{Address: addr}
+		for _, pt := range srv.wallet.AddressTransactions(addr) {
+			var received bool
+			for _, output := range pt.Outputs {
+				if output.FundType == types.SpecifierSiacoinOutput && output.RelatedAddress == addr {
+					wfp.Amount = wfp.Amount.Add(output.Value)
+					received = true
+				}
+			}
+			if received {
+				wfp.TransactionIDs = append(wfp.TransactionIDs, pt.TransactionID)
+				wfp.ConfirmationHeight = pt.ConfirmationHeight
+			}
+		}
+		if len(wfp.TransactionIDs) > 0 {
+			return wfp, nil
+		}
+		select {
+		case <-cancel:
+			return WalletFaucetPOST{}, errJobCancelled
+		case <-srv.shutdown:
+			return WalletFaucetPOST{}, errServerShutdown
+		case <-time.After(faucetPollInterval):
+		}
+	}
+}
+
+// walletFaucetHandler handles API calls to /wallet/faucet, requesting coins
+// from a faucet on a test network and waiting until they are confirmed. If
+// 'async' is true, the wait is done by a job.
+func (srv *Server) walletFaucetHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if types.CurrentNetwork().Name == "mainnet" {
+		writeError(w, Error{Message: errFaucetMainnet.Error(), Code: CodeFaucetMainnet}, http.StatusBadRequest)
+		return
+	}
+	faucetURL := req.FormValue("url")
+	if faucetURL == "" {
+		srv.mu.Lock()
+		faucetURL = srv.faucetURL
+		srv.mu.Unlock()
+	}
+	if faucetURL == "" {
+		writeError(w, Error{Message: errNoFaucetURL.Error()}, http.StatusBadRequest)
+		return
+	}
+	if u, err := url.Parse(faucetURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		writeError(w, Error{Message: "faucet url must be an http or https url"}, http.StatusBadRequest)
+		return
+	}
+
+	uc, err := srv.wallet.NextAddress()
+	if err != nil {
+		writeError(w, Error{Message: "unable to get an address for the faucet: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	addr := uc.UnlockHash()
+	if err := requestFaucetCoins(faucetURL, addr); err != nil {
+		writeError(w, Error{Message: "unable to request coins from the faucet: " + err.Error()}, http.StatusBadGateway)
+		return
+	}
+
+	if req.FormValue("async") == "true" {
+		srv.startJob(w, "faucet", true, func(cancel <-chan struct{}, _ func(uint64, uint64)) (interface{}, error) {
+			return srv.managedWaitForFaucet(addr, cancel)
+		})
+		return
+	}
+	wfp, err := srv.managedWaitForFaucet(addr, req.Context().Done())
+	if err != nil {
+		writeError(w, Error{Message: "stopped waiting for the faucet: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, wfp)
+}
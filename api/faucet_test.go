@@ -0,0 +1,145 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestIntegrationWalletFaucet checks that /wallet/faucet requests coins from a
+// faucet and waits until they are confirmed.
+func TestIntegrationWalletFaucet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationWalletFaucet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	// The faucet pays out of the wallet of the server tester, and reports
+	// each payment on 'sent'.
+	amount := types.SiacoinPrecision.Mul64(100)
+	sent := make(chan struct{}, 1)
+	faucet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.FormValue("network") != types.CurrentNetwork().Name {
+			http.Error(w, "wrong network", http.StatusBadRequest)
+			return
+		}
+		var addr types.UnlockHash
+		if err := addr.LoadString(req.FormValue("address")); err != nil {
+			http.Error(w, "bad address", http.StatusBadRequest)
+			return
+		}
+		if _, err := st.wallet.SendSiacoins(amount, addr); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sent <- struct{}{}
+	}))
+	defer faucet.Close()
+
+	// Without a url, the call fails until a default faucet is set.
+	if err := st.stdPostAPI("/wallet/faucet", url.Values{}); err == nil || !strings.Contains(err.Error(), errNoFaucetURL.Error()) {
+		t.Fatal("expected errNoFaucetURL, got", err)
+	}
+	st.server.SetFaucetURL(faucet.URL)
+
+	// The call returns once the coins are in a block.
+	type result struct {
+		wfp WalletFaucetPOST
+		err error
+	}
+	done := make(chan result)
+	go func() {
+		var wfp WalletFaucetPOST
+		err := st.postAPI("/wallet/faucet", url.Values{}, &wfp)
+		done <- result{wfp, err}
+	}()
+	<-sent
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	res := <-done
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if res.wfp.Amount.Cmp(amount) != 0 || len(res.wfp.TransactionIDs) != 1 {
+		t.Fatal("wrong response from the faucet call:", res.wfp)
+	}
+	pt, ok := st.wallet.Transaction(res.wfp.TransactionIDs[0])
+	if !ok || pt.ConfirmationHeight != res.wfp.ConfirmationHeight {
+		t.Fatal("wrong confirmation height:", res.wfp.ConfirmationHeight)
+	}
+
+	// An async call waits for the coins in a job.
+	var djp DaemonJobPOST
+	if err := st.postAPI("/wallet/faucet", url.Values{"url": {faucet.URL}, "async": {"true"}}, &djp); err != nil {
+		t.Fatal(err)
+	}
+	<-sent
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	j, err := waitForJob(st.server.jobs, djp.JobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wfp, ok := j.Result.(WalletFaucetPOST); j.Status != JobCompleted || j.Type != "faucet" || !ok || wfp.Amount.Cmp(amount) != 0 {
+		t.Fatal("faucet job did not complete:", j)
+	}
+
+	// The error of a faucet that refuses the request is returned.
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "faucet is empty", http.StatusServiceUnavailable)
+	}))
+	defer empty.Close()
+	if err := st.stdPostAPI("/wallet/faucet", url.Values{"url": {empty.URL}}); err == nil || !strings.Contains(err.Error(), "faucet is empty") {
+		t.Fatal("expected the error of the faucet, got", err)
+	}
+	if err := st.stdPostAPI("/wallet/faucet", url.Values{"url": {"ftp://example.com"}}); err == nil {
+		t.Fatal("faucet with an ftp url was accepted")
+	}
+}
+
+// TestIntegrationWalletFaucetShutdown checks that a call waiting for the
+// faucet returns when the server shuts down, rather than holding up Close.
+func TestIntegrationWalletFaucetShutdown(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationWalletFaucetShutdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	// The faucet accepts every request, but never sends any coins.
+	requested := make(chan struct{}, 1)
+	faucet := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requested <- struct{}{}
+	}))
+	defer faucet.Close()
+
+	done := make(chan error)
+	go func() {
+		done <- st.stdPostAPI("/wallet/faucet", url.Values{"url": {faucet.URL}})
+	}()
+	<-requested
+	start := time.Now()
+	if err := st.server.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= shutdownTimeout {
+		t.Fatal("the faucet call held up Close for", elapsed)
+	}
+	if err := <-done; err == nil || !strings.Contains(err.Error(), errServerShutdown.Error()) {
+		t.Fatal("expected errServerShutdown, got", err)
+	}
+}
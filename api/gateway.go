@@ -1,8 +1,13 @@
 package api
 
 import (
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 
 	"github.com/julienschmidt/httprouter"
@@ -14,6 +19,18 @@ type GatewayGET struct {
 	Peers      []modules.Peer     `json:"peers"`
 }
 
+// GatewayBansGET contains the fields returned by a GET call to
+// "/gateway/bans".
+type GatewayBansGET struct {
+	Bans []modules.PeerBan `json:"bans"`
+}
+
+// GatewayAlertsGET contains the fields returned by a GET call to
+// "/gateway/alerts".
+type GatewayAlertsGET struct {
+	Alerts []modules.Alert `json:"alerts"`
+}
+
 // gatewayHandler handles the API call asking for the gatway status.
 func (srv *Server) gatewayHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	peers := srv.gateway.Peers()
@@ -26,12 +43,47 @@ func (srv *Server) gatewayHandler(w http.ResponseWriter, req *http.Request, _ ht
 	writeJSON(w, GatewayGET{srv.gateway.Address(), peers})
 }
 
+// GatewayPeersGET contains the fields returned by a GET call to
+// "/gateway/peers".
+type GatewayPeersGET struct {
+	Peers []modules.PeerStats `json:"peers"`
+}
+
+// gatewayPeersHandlerGET handles the API call asking for statistics about the
+// gateway's peers. The peers are ordered by address, and can be paged with
+// 'offset' and 'limit' and filtered by the direction of their connection with
+// 'inbound'.
+func (srv *Server) gatewayPeersHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	offset, limit, err := scanPage(req)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	inbound := req.FormValue("inbound")
+	if inbound != "" && inbound != "true" && inbound != "false" {
+		writeError(w, Error{Message: "'inbound' must be true or false"}, http.StatusBadRequest)
+		return
+	}
+
+	peers := []modules.PeerStats{}
+	for _, p := range srv.gateway.PeerStats() {
+		if inbound == "" || p.Inbound == (inbound == "true") {
+			peers = append(peers, p)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].NetAddress < peers[j].NetAddress
+	})
+	start, end := pageBounds(len(peers), offset, limit)
+	writeJSON(w, GatewayPeersGET{Peers: peers[start:end]})
+}
+
 // gatewayConnectHandler handles the API call to add a peer to the gateway.
 func (srv *Server) gatewayConnectHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	addr := modules.NetAddress(ps.ByName("netaddress"))
 	err := srv.gateway.Connect(addr)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
@@ -43,7 +95,84 @@ func (srv *Server) gatewayDisconnectHandler(w http.ResponseWriter, req *http.Req
 	addr := modules.NetAddress(ps.ByName("netaddress"))
 	err := srv.gateway.Disconnect(addr)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	writeSuccess(w)
+}
+
+// gatewayBansHandlerGET handles the API call asking for the banned hosts.
+func (srv *Server) gatewayBansHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	bans := srv.gateway.Bans()
+	if bans == nil {
+		bans = make([]modules.PeerBan, 0)
+	}
+	writeJSON(w, GatewayBansGET{bans})
+}
+
+// gatewayBanHandler handles the API call to ban a host. The length of the ban
+// is given in seconds by the optional 'duration' parameter.
+func (srv *Server) gatewayBanHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var seconds uint64
+	if d := req.FormValue("duration"); d != "" {
+		_, err := fmt.Sscan(d, &seconds)
+		if err != nil {
+			writeError(w, Error{Message: "Couldn't parse duration: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err := srv.gateway.BanPeer(ps.ByName("host"), time.Duration(seconds)*time.Second)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	writeSuccess(w)
+}
+
+// gatewayUnbanHandler handles the API call to lift the ban on a host.
+func (srv *Server) gatewayUnbanHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	err := srv.gateway.UnbanPeer(ps.ByName("host"))
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	writeSuccess(w)
+}
+
+// gatewayAlertsHandlerGET handles the API call asking for the network alerts
+// that the gateway has received.
+func (srv *Server) gatewayAlertsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	alerts := srv.gateway.Alerts()
+	if alerts == nil {
+		alerts = make([]modules.Alert, 0)
+	}
+	writeJSON(w, GatewayAlertsGET{alerts})
+}
+
+// gatewayAlertsHandlerPOST handles the API call to broadcast a signed network
+// alert.
+func (srv *Server) gatewayAlertsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	na := modules.NetworkAlert{
+		Severity: modules.AlertSeverity(req.FormValue("severity")),
+		Message:  req.FormValue("message"),
+	}
+	_, err := fmt.Sscan(req.FormValue("expiry"), &na.Expiry)
+	if err != nil {
+		writeError(w, Error{Message: "Couldn't parse expiry: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sig, err := hex.DecodeString(req.FormValue("signature"))
+	if err != nil || len(sig) != crypto.SignatureSize {
+		writeError(w, Error{Message: "Couldn't parse signature: must be 64 hex-encoded bytes"}, http.StatusBadRequest)
+		return
+	}
+	copy(na.Signature[:], sig)
+	err = srv.gateway.BroadcastNetworkAlert(na)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
@@ -1,10 +1,16 @@
 package api
 
 import (
+	"encoding/hex"
+	"fmt"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 // TestGatewayStatus checks that the /gateway/status call is returning a corect
@@ -93,3 +99,165 @@ func TestGatewayPeerDisconnect(t *testing.T) {
 		t.Fatal("/gateway/disconnect did not disconnect from peer", peer.Address())
 	}
 }
+
+// TestGatewayBans checks that hosts can be banned and unbanned through the
+// API, and that banning a host disconnects its peers.
+func TestGatewayBans(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestGatewayBans1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	peer, err := gateway.New("localhost:0", build.TempDir("api", "TestGatewayBans2", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+	err = st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host := peer.Address().Host()
+	values := url.Values{}
+	values.Set("duration", "60")
+	err = st.stdPostAPI("/gateway/ban/"+host, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bans GatewayBansGET
+	err = st.getAPI("/gateway/bans", &bans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bans.Bans) != 1 || bans.Bans[0].Host != host {
+		t.Fatal("/gateway/ban did not ban the host:", bans.Bans)
+	}
+	var info GatewayGET
+	err = st.getAPI("/gateway", &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Peers) != 0 {
+		t.Fatal("/gateway/ban did not disconnect from the banned peer")
+	}
+	if st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil) == nil {
+		t.Fatal("connected to a banned peer")
+	}
+
+	err = st.stdPostAPI("/gateway/unban/"+host, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.getAPI("/gateway/bans", &bans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bans.Bans) != 0 {
+		t.Fatal("/gateway/unban did not lift the ban:", bans.Bans)
+	}
+	if st.stdPostAPI("/gateway/unban/"+host, nil) == nil {
+		t.Fatal("unbanned a host that is not banned")
+	}
+	if st.stdPostAPI("/gateway/ban/notanip", nil) == nil {
+		t.Fatal("banned an invalid host")
+	}
+}
+
+// TestGatewayPeers checks that /gateway/peers returns statistics about the
+// connection to each peer.
+func TestGatewayPeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestGatewayPeers1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	peer, err := gateway.New("localhost:0", build.TempDir("api", "TestGatewayPeers2", "gateway"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer peer.Close()
+	err = st.stdPostAPI("/gateway/connect/"+string(peer.Address()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the OnConnectRPCs to finish.
+	time.Sleep(100 * time.Millisecond)
+
+	var gp GatewayPeersGET
+	err = st.getAPI("/gateway/peers", &gp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gp.Peers) != 1 || gp.Peers[0].NetAddress != peer.Address() {
+		t.Fatal("/gateway/peers returned the wrong peers:", gp.Peers)
+	}
+	stats := gp.Peers[0]
+	if stats.Inbound {
+		t.Fatal("peer should be outbound")
+	}
+	if stats.ConnectedSince.IsZero() || stats.Latency <= 0 {
+		t.Fatal("connection time and latency were not recorded:", stats)
+	}
+	if stats.BytesSent == 0 || stats.BytesReceived == 0 {
+		t.Fatal("traffic was not counted:", stats)
+	}
+}
+
+// TestGatewayAlerts checks that a network alert posted to /gateway/alerts is
+// verified and listed by GET /gateway/alerts.
+func TestGatewayAlerts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestGatewayAlerts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	var ga GatewayAlertsGET
+	if err := st.getAPI("/gateway/alerts", &ga); err != nil {
+		t.Fatal(err)
+	}
+	if len(ga.Alerts) != 0 {
+		t.Fatal("expected no alerts, got", ga.Alerts)
+	}
+
+	na, err := modules.SignNetworkAlert(modules.NetworkAlert{
+		Severity: modules.SeverityWarning,
+		Message:  "upgrade before height 100",
+		Expiry:   types.CurrentTimestamp() + 3600,
+	}, modules.NetworkAlertTestingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := url.Values{}
+	values.Set("severity", string(na.Severity))
+	values.Set("message", na.Message)
+	values.Set("expiry", fmt.Sprint(na.Expiry))
+	values.Set("signature", hex.EncodeToString(na.Signature[:]))
+
+	// A modified message does not match the signature.
+	values.Set("message", "downgrade before height 100")
+	if err := st.stdPostAPI("/gateway/alerts", values); err == nil {
+		t.Fatal("alert with an invalid signature was accepted")
+	}
+	values.Set("message", na.Message)
+	if err := st.stdPostAPI("/gateway/alerts", values); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.getAPI("/gateway/alerts", &ga); err != nil {
+		t.Fatal(err)
+	}
+	if len(ga.Alerts) != 1 || ga.Alerts[0].Msg != na.Message || ga.Alerts[0].Severity != na.Severity {
+		t.Fatal("wrong alerts:", ga.Alerts)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type (
+	// DaemonHealth is the response to /health. It is only sent while the
+	// process is alive and serving the API.
+	DaemonHealth struct {
+		Status string `json:"status"`
+	}
+
+	// DaemonReady is the response to /ready. Checks holds the result of each
+	// readiness check of the loaded modules: "synced" for the consensus set,
+	// "walletunlocked" for the wallet, and "hostlistening" for the host. The
+	// daemon is ready when every check passes.
+	DaemonReady struct {
+		Ready  bool            `json:"ready"`
+		Checks map[string]bool `json:"checks"`
+	}
+)
+
+// publicCalls are the GET calls that are served without the Sia user agent.
+// Orchestration tools and load balancers cannot be configured to send the
+// user agent, and renters and websites should be able to check the settings
+// of a host. None of the calls reveal anything that needs protecting from
+// other websites.
+var publicCalls = map[string]bool{
+	"/health":        true,
+	"/ready":         true,
+	"/host/settings": true,
+}
+
+// allowPublicCalls is middleware that passes the public calls to 'withoutUA',
+// skipping the user agent check in 'withUA'.
+func allowPublicCalls(withUA, withoutUA http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == "GET" && publicCalls[req.URL.Path] {
+			withoutUA.ServeHTTP(w, req)
+			return
+		}
+		withUA.ServeHTTP(w, req)
+	})
+}
+
+// healthHandler handles the API call to /health, which reports that the
+// process is alive.
+func (srv *Server) healthHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, DaemonHealth{Status: "ok"})
+}
+
+// readyHandler handles the API call to /ready, which reports whether the
+// daemon is ready to serve: the consensus set is synced, the wallet is
+// unlocked, and the host is accepting connections. Modules that are not
+// loaded are not checked. The response has status 503 Service Unavailable
+// until the daemon is ready.
+func (srv *Server) readyHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	dr := DaemonReady{
+		Ready:  true,
+		Checks: make(map[string]bool),
+	}
+	if srv.cs != nil {
+		dr.Checks["synced"] = srv.cs.Synced()
+	}
+	if srv.wallet != nil {
+		dr.Checks["walletunlocked"] = srv.wallet.Unlocked()
+	}
+	if srv.host != nil {
+		dr.Checks["hostlistening"] = srv.host.Listening()
+	}
+	for _, ok := range dr.Checks {
+		dr.Ready = dr.Ready && ok
+	}
+
+	if !dr.Ready {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(dr)
+		return
+	}
+	writeJSON(w, dr)
+}
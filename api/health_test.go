@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestIntegrationHealth checks that /health and /ready are served without the
+// Sia user agent, and that /ready reports the wallet being locked.
+func TestIntegrationHealth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationHealth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	addr := "http://" + st.server.listener.Addr().String()
+
+	// Call the endpoints without the Sia user agent, as a load balancer
+	// would.
+	resp, err := http.Get(addr + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dh DaemonHealth
+	err = json.NewDecoder(resp.Body).Decode(&dh)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || dh.Status != "ok" {
+		t.Fatal("unexpected health response:", resp.StatusCode, dh)
+	}
+
+	getReady := func() (int, DaemonReady) {
+		resp, err := http.Get(addr + "/ready")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var dr DaemonReady
+		if err := json.NewDecoder(resp.Body).Decode(&dr); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, dr
+	}
+	status, dr := getReady()
+	if status != http.StatusOK || !dr.Ready {
+		t.Fatal("server tester is not ready:", status, dr)
+	}
+	for _, check := range []string{"synced", "walletunlocked", "hostlistening"} {
+		if ok, exists := dr.Checks[check]; !exists || !ok {
+			t.Fatalf("check %q did not pass: %v", check, dr.Checks)
+		}
+	}
+
+	// Lock the wallet; the daemon is no longer ready.
+	if err := st.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	status, dr = getReady()
+	if status != http.StatusServiceUnavailable || dr.Ready || dr.Checks["walletunlocked"] {
+		t.Fatal("locked wallet was not reported:", status, dr)
+	}
+
+	// Other calls still require the user agent.
+	resp, err = http.Get(addr + "/daemon/version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("call without the user agent was not rejected:", resp.StatusCode)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/julienschmidt/httprouter"
 )
@@ -27,6 +28,13 @@ type (
 		NetworkMetrics   modules.HostNetworkMetrics   `json:"networkmetrics"`
 	}
 
+	// HostSettingsGET contains the settings that the host advertises to
+	// renters, and the public key that the host signs them with.
+	HostSettingsGET struct {
+		Settings  modules.HostExternalSettings `json:"settings"`
+		PublicKey types.SiaPublicKey           `json:"publickey"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
@@ -62,6 +70,17 @@ func (srv *Server) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ ht
 	writeJSON(w, hg)
 }
 
+// hostSettingsHandler handles the API call to /host/settings, which returns the
+// prices, capacity and contract parameters that the host advertises. The call
+// is public: it reveals nothing that the host does not already send to any
+// renter that asks.
+func (srv *Server) hostSettingsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, HostSettingsGET{
+		Settings:  srv.host.ExternalSettings(),
+		PublicKey: srv.host.PublicKey(),
+	})
+}
+
 // hostHandlerPOST handles POST request to the /host API endpoint, which sets
 // the internal settings of the host.
 func (srv *Server) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -91,14 +110,14 @@ func (srv *Server) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ h
 		if req.FormValue(qs) != "" { // skip empty values
 			_, err := fmt.Sscan(req.FormValue(qs), qsVars[qs])
 			if err != nil {
-				writeError(w, Error{"Malformed " + qs}, http.StatusBadRequest)
+				writeError(w, Error{Message: "Malformed " + qs}, http.StatusBadRequest)
 				return
 			}
 		}
 	}
 	err := srv.host.SetInternalSettings(settings)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
@@ -114,7 +133,7 @@ func (srv *Server) hostAnnounceHandler(w http.ResponseWriter, req *http.Request,
 		err = srv.host.Announce()
 	}
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
@@ -134,12 +153,12 @@ func (srv *Server) storageFoldersAddHandler(w http.ResponseWriter, req *http.Req
 	var folderSize uint64
 	_, err := fmt.Sscan(req.FormValue("size"), &folderSize)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	err = srv.host.AddStorageFolder(folderPath, folderSize)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
@@ -149,26 +168,26 @@ func (srv *Server) storageFoldersAddHandler(w http.ResponseWriter, req *http.Req
 func (srv *Server) storageFoldersResizeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
 	if folderPath == "" {
-		writeError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		writeError(w, Error{Message: "path parameter is required"}, http.StatusBadRequest)
 		return
 	}
 
 	storageFolders := srv.host.StorageFolders()
 	folderIndex, err := folderIndex(folderPath, storageFolders)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
 	var newSize uint64
 	_, err = fmt.Sscan(req.FormValue("newsize"), &newSize)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	err = srv.host.ResizeStorageFolder(folderIndex, newSize)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
@@ -179,21 +198,21 @@ func (srv *Server) storageFoldersResizeHandler(w http.ResponseWriter, req *http.
 func (srv *Server) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
 	if folderPath == "" {
-		writeError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		writeError(w, Error{Message: "path parameter is required"}, http.StatusBadRequest)
 		return
 	}
 
 	storageFolders := srv.host.StorageFolders()
 	folderIndex, err := folderIndex(folderPath, storageFolders)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
 	force := req.FormValue("force") == "true"
 	err = srv.host.RemoveStorageFolder(folderIndex, force)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
@@ -204,12 +223,12 @@ func (srv *Server) storageFoldersRemoveHandler(w http.ResponseWriter, req *http.
 func (srv *Server) storageSectorsDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	sectorRoot, err := scanHash(ps.ByName("merkleroot"))
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	err = srv.host.DeleteSector(sectorRoot)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
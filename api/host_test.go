@@ -1,5 +1,52 @@
 package api
 
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// TestIntegrationHostSettings checks that the settings of the host can be
+// read from /host/settings without the Sia user agent.
+func TestIntegrationHostSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationHostSettings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	resp, err := http.Get("http://" + st.server.listener.Addr().String() + "/host/settings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var hsg HostSettingsGET
+	if err := json.NewDecoder(resp.Body).Decode(&hsg); err != nil {
+		t.Fatal(err)
+	}
+	es := st.host.ExternalSettings()
+	if resp.StatusCode != http.StatusOK || hsg.Settings.NetAddress != es.NetAddress || hsg.Settings.StoragePrice.Cmp(es.StoragePrice) != 0 {
+		t.Fatal("wrong host settings:", resp.StatusCode, hsg.Settings)
+	}
+	if pk := st.host.PublicKey(); hsg.PublicKey.Algorithm != pk.Algorithm || !bytes.Equal(hsg.PublicKey.Key, pk.Key) {
+		t.Fatal("wrong public key:", hsg.PublicKey)
+	}
+
+	// Other calls still require the user agent.
+	resp, err = http.Get("http://" + st.server.listener.Addr().String() + "/host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("/host was served without the user agent:", resp.Status)
+	}
+}
+
 /*
 // TestIntegrationRenewing tests that the renter and host manage contract
 // renewals properly.
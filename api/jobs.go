@@ -0,0 +1,283 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// The statuses of a Job.
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+
+	// maxFinishedJobs is the number of finished jobs that are kept, so that
+	// their results can be queried after they finish.
+	maxFinishedJobs = 100
+
+	// jobProgressInterval is the interval at which jobs that poll for their
+	// progress update it.
+	jobProgressInterval = time.Second
+)
+
+var (
+	errJobCancelled      = errors.New("job was cancelled")
+	errJobFinished       = errors.New("job has already finished")
+	errJobNotCancellable = errors.New("job cannot be cancelled")
+	errJobNotFound       = errors.New("no job with that id")
+	errJobsClosed        = errors.New("server is shutting down")
+)
+
+type (
+	// A Job is a long-running operation that runs in the background. The
+	// meaning of Progress and Total depends on the type of the job; Total is
+	// zero if the amount of work is not known in advance.
+	Job struct {
+		ID          string      `json:"id"`
+		Type        string      `json:"type"`
+		Status      string      `json:"status"`
+		Progress    uint64      `json:"progress"`
+		Total       uint64      `json:"total"`
+		Cancellable bool        `json:"cancellable"`
+		Error       string      `json:"error,omitempty"`
+		Result      interface{} `json:"result,omitempty"`
+		StartTime   time.Time   `json:"starttime"`
+		EndTime     time.Time   `json:"endtime"`
+	}
+
+	// DaemonJobsGET contains the jobs of the daemon, in the order that they
+	// were started.
+	DaemonJobsGET struct {
+		Jobs []Job `json:"jobs"`
+	}
+
+	// DaemonJobPOST contains the id of a job that was started by an API call.
+	DaemonJobPOST struct {
+		JobID string `json:"jobid"`
+	}
+)
+
+// A jobFunc performs the work of a job. It reports its progress through
+// 'progress', and should return errJobCancelled soon after 'cancel' is
+// closed, if the job is cancellable.
+type jobFunc func(cancel <-chan struct{}, progress func(done, total uint64)) (interface{}, error)
+
+// A jobManager runs jobs in the background and keeps track of their status.
+type jobManager struct {
+	// ids holds the ids of the jobs in the order that they were started.
+	ids    []string
+	jobs   map[string]*job
+	nextID uint64
+	closed bool
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+}
+
+// A job is a Job and the channel that cancels it.
+type job struct {
+	Job
+	cancel chan struct{}
+}
+
+// newJobManager creates an empty jobManager.
+func newJobManager() *jobManager {
+	return &jobManager{
+		jobs: make(map[string]*job),
+	}
+}
+
+// start runs 'fn' in the background as a job of type 'typ', and returns the
+// id of the job.
+func (jm *jobManager) start(typ string, cancellable bool, fn jobFunc) (string, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	if jm.closed {
+		return "", errJobsClosed
+	}
+	jm.nextID++
+	j := &job{
+		Job: Job{
+			ID:          strconv.FormatUint(jm.nextID, 10),
+			Type:        typ,
+			Status:      JobRunning,
+			Cancellable: cancellable,
+			StartTime:   time.Now(),
+		},
+		cancel: make(chan struct{}),
+	}
+	jm.jobs[j.ID] = j
+	jm.ids = append(jm.ids, j.ID)
+	jm.pruneJobs()
+
+	jm.wg.Add(1)
+	go jm.threadedRunJob(j, fn)
+	return j.ID, nil
+}
+
+// threadedRunJob runs the work of a job and records its outcome.
+func (jm *jobManager) threadedRunJob(j *job, fn jobFunc) {
+	defer jm.wg.Done()
+	progress := func(done, total uint64) {
+		jm.mu.Lock()
+		j.Progress, j.Total = done, total
+		jm.mu.Unlock()
+	}
+	result, err := fn(j.cancel, progress)
+
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	j.EndTime = time.Now()
+	switch {
+	case err == errJobCancelled:
+		j.Status = JobCancelled
+	case err != nil:
+		j.Status = JobFailed
+		j.Error = err.Error()
+	default:
+		j.Status = JobCompleted
+		j.Result = result
+	}
+	jm.pruneJobs()
+}
+
+// pruneJobs forgets the oldest finished jobs once more than maxFinishedJobs
+// have finished. Running jobs are never forgotten. The manager must be locked.
+func (jm *jobManager) pruneJobs() {
+	finished := 0
+	for _, id := range jm.ids {
+		if jm.jobs[id].Status != JobRunning {
+			finished++
+		}
+	}
+	ids := jm.ids[:0]
+	for _, id := range jm.ids {
+		if finished > maxFinishedJobs && jm.jobs[id].Status != JobRunning {
+			delete(jm.jobs, id)
+			finished--
+			continue
+		}
+		ids = append(ids, id)
+	}
+	jm.ids = ids
+}
+
+// job returns the job with the given id.
+func (jm *jobManager) job(id string) (Job, error) {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	j, exists := jm.jobs[id]
+	if !exists {
+		return Job{}, errJobNotFound
+	}
+	return j.Job, nil
+}
+
+// allJobs returns every job, in the order that they were started.
+func (jm *jobManager) allJobs() []Job {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	jobs := make([]Job, 0, len(jm.ids))
+	for _, id := range jm.ids {
+		jobs = append(jobs, jm.jobs[id].Job)
+	}
+	return jobs
+}
+
+// cancelJob asks the job with the given id to stop. The job is cancelled once
+// its work returns.
+func (jm *jobManager) cancelJob(id string) error {
+	jm.mu.Lock()
+	defer jm.mu.Unlock()
+	j, exists := jm.jobs[id]
+	if !exists {
+		return errJobNotFound
+	} else if !j.Cancellable {
+		return errJobNotCancellable
+	} else if j.Status != JobRunning {
+		return errJobFinished
+	}
+	select {
+	case <-j.cancel:
+	default:
+		close(j.cancel)
+	}
+	return nil
+}
+
+// close cancels the running jobs, refuses new jobs, and waits up to 'timeout'
+// for the running jobs to return, so that the modules are not closed while
+// jobs are using them.
+func (jm *jobManager) close(timeout time.Duration) error {
+	jm.mu.Lock()
+	jm.closed = true
+	for _, j := range jm.jobs {
+		if j.Cancellable && j.Status == JobRunning {
+			select {
+			case <-j.cancel:
+			default:
+				close(j.cancel)
+			}
+		}
+	}
+	jm.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		jm.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("timed out waiting for the running jobs")
+	}
+}
+
+// startJob starts a job for an API call, and responds with its id.
+func (srv *Server) startJob(w http.ResponseWriter, typ string, cancellable bool, fn jobFunc) {
+	id, err := srv.jobs.start(typ, cancellable, fn)
+	if err != nil {
+		writeError(w, Error{Message: "unable to start job: " + err.Error(), Code: errorCode(err)}, http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Location", "/daemon/jobs/"+id)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(DaemonJobPOST{JobID: id})
+}
+
+// daemonJobsHandler handles the API call to /daemon/jobs.
+func (srv *Server) daemonJobsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, DaemonJobsGET{Jobs: srv.jobs.allJobs()})
+}
+
+// daemonJobHandler handles the API call to /daemon/jobs/:id.
+func (srv *Server) daemonJobHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	j, err := srv.jobs.job(ps.ByName("id"))
+	if err != nil {
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, j)
+}
+
+// daemonJobCancelHandler handles the API call to /daemon/jobs/:id/cancel.
+func (srv *Server) daemonJobCancelHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	err := srv.jobs.cancelJob(ps.ByName("id"))
+	if err == errJobNotFound {
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusNotFound)
+		return
+	} else if err != nil {
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
+		return
+	}
+	writeSuccess(w)
+}
@@ -0,0 +1,201 @@
+package api
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+)
+
+// waitForJob polls the job manager until the job with the given id finishes.
+func waitForJob(jm *jobManager, id string) (Job, error) {
+	for i := 0; i < 100; i++ {
+		j, err := jm.job(id)
+		if err != nil {
+			return Job{}, err
+		}
+		if j.Status != JobRunning {
+			return j, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return Job{}, errors.New("job did not finish")
+}
+
+// TestJobManager checks that the job manager records the progress and outcome
+// of jobs, and cancels them.
+func TestJobManager(t *testing.T) {
+	jm := newJobManager()
+
+	// A job that completes reports its progress and result.
+	release := make(chan struct{})
+	id, err := jm.start("test", false, func(_ <-chan struct{}, progress func(done, total uint64)) (interface{}, error) {
+		progress(1, 2)
+		<-release
+		return "result", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j, _ := jm.job(id); j.Progress != 1; j, _ = jm.job(id) {
+		time.Sleep(time.Millisecond)
+	}
+	if j, _ := jm.job(id); j.Status != JobRunning || j.Total != 2 {
+		t.Fatal("wrong state for running job:", j)
+	}
+	if err := jm.cancelJob(id); err != errJobNotCancellable {
+		t.Fatal("expected errJobNotCancellable, got", err)
+	}
+	close(release)
+	j, err := waitForJob(jm, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Status != JobCompleted || j.Result != "result" || j.EndTime.IsZero() {
+		t.Fatal("wrong state for completed job:", j)
+	}
+	if err := jm.cancelJob(id); err != errJobNotCancellable {
+		t.Fatal("expected errJobNotCancellable, got", err)
+	}
+
+	// A job that fails reports its error.
+	id, _ = jm.start("test", false, func(<-chan struct{}, func(uint64, uint64)) (interface{}, error) {
+		return nil, errors.New("failure")
+	})
+	if j, err := waitForJob(jm, id); err != nil || j.Status != JobFailed || j.Error != "failure" {
+		t.Fatal("wrong state for failed job:", j, err)
+	}
+
+	// A cancellable job is cancelled.
+	id, _ = jm.start("test", true, func(cancel <-chan struct{}, _ func(uint64, uint64)) (interface{}, error) {
+		<-cancel
+		return nil, errJobCancelled
+	})
+	if err := jm.cancelJob(id); err != nil {
+		t.Fatal(err)
+	}
+	if j, err := waitForJob(jm, id); err != nil || j.Status != JobCancelled {
+		t.Fatal("wrong state for cancelled job:", j, err)
+	}
+	if err := jm.cancelJob(id); err != errJobFinished {
+		t.Fatal("expected errJobFinished, got", err)
+	}
+	if err := jm.cancelJob("0"); err != errJobNotFound {
+		t.Fatal("expected errJobNotFound, got", err)
+	}
+	if jobs := jm.allJobs(); len(jobs) != 3 || jobs[0].ID != "1" || jobs[2].ID != "3" {
+		t.Fatal("wrong list of jobs:", jobs)
+	}
+
+	// Only the most recent finished jobs are kept.
+	for i := 0; i < maxFinishedJobs; i++ {
+		id, _ = jm.start("test", false, func(<-chan struct{}, func(uint64, uint64)) (interface{}, error) {
+			return nil, nil
+		})
+		if _, err := waitForJob(jm, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jobs := jm.allJobs()
+	if len(jobs) != maxFinishedJobs || jobs[0].ID != "4" || jobs[len(jobs)-1].ID != id {
+		t.Fatal("finished jobs were not pruned:", len(jobs), jobs[0].ID)
+	}
+
+	// Closing the manager cancels the running jobs and refuses new ones.
+	id, _ = jm.start("test", true, func(cancel <-chan struct{}, _ func(uint64, uint64)) (interface{}, error) {
+		<-cancel
+		return nil, errJobCancelled
+	})
+	if err := jm.close(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if j, _ := jm.job(id); j.Status != JobCancelled {
+		t.Fatal("running job was not cancelled:", j)
+	}
+	if _, err := jm.start("test", false, nil); err != errJobsClosed {
+		t.Fatal("expected errJobsClosed, got", err)
+	}
+}
+
+// TestIntegrationJobs checks that snapshots and wallet unlocks can be run as
+// jobs, and that the jobs are reported by /daemon/jobs.
+func TestIntegrationJobs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationJobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	destination := build.TempDir("api", "TestIntegrationJobs", "consensus.snapshot")
+	var djp DaemonJobPOST
+	err = st.postAPI("/consensus/snapshot", url.Values{"destination": {destination}, "async": {"true"}}, &djp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var j Job
+	for i := 0; i < 100; i++ {
+		if err := st.getAPI("/daemon/jobs/"+djp.JobID, &j); err != nil {
+			t.Fatal(err)
+		}
+		if j.Status != JobRunning {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if j.Status != JobCompleted || j.Type != "snapshot" || j.Progress == 0 {
+		t.Fatal("snapshot job did not complete:", j)
+	}
+	result, ok := j.Result.(map[string]interface{})
+	if !ok || result["height"] != float64(st.cs.Height()) {
+		t.Fatal("wrong result for snapshot job:", j.Result)
+	}
+	if _, err := os.Stat(destination); err != nil {
+		t.Fatal("snapshot file was not created:", err)
+	}
+	if err := st.stdPostAPI("/daemon/jobs/"+djp.JobID+"/cancel", url.Values{}); err == nil {
+		t.Fatal("finished job was cancelled")
+	}
+
+	// Unlocking the wallet with the wrong password fails the job.
+	if err := st.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	err = st.postAPI("/wallet/unlock", url.Values{"encryptionpassword": {"wrong"}, "async": {"true"}}, &djp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j, err = waitForJob(st.server.jobs, djp.JobID); err != nil {
+		t.Fatal(err)
+	}
+	if j.Status != JobFailed || j.Cancellable {
+		t.Fatal("unlock with the wrong password did not fail:", j)
+	}
+
+	// Unlocking with the right key reports the scanned height.
+	var done, total uint64
+	err = st.server.managedUnlockWallet([]crypto.TwofishKey{st.walletKey}, func(d, t uint64) {
+		done, total = d, t
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != uint64(st.cs.Height()) || done != total {
+		t.Fatal("wrong progress for unlock:", done, total)
+	}
+
+	var djg DaemonJobsGET
+	if err := st.getAPI("/daemon/jobs", &djg); err != nil {
+		t.Fatal(err)
+	}
+	if len(djg.Jobs) != 2 || djg.Jobs[1].ID != djp.JobID || djg.Jobs[0].ID != strconv.Itoa(1) {
+		t.Fatal("wrong list of jobs:", djg.Jobs)
+	}
+}
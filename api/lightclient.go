@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type (
+	// LightClientGET contains the status of the light client.
+	LightClientGET struct {
+		Height       types.BlockHeight `json:"height"`
+		CurrentBlock types.BlockID     `json:"currentblock"`
+	}
+
+	// LightClientTransactionsGET contains the proven transactions that are
+	// relevant to the light client.
+	LightClientTransactionsGET struct {
+		Transactions []types.TransactionProof `json:"transactions"`
+	}
+)
+
+// lightClientHandler handles the API call to /lightclient.
+func (srv *Server) lightClientHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, LightClientGET{
+		Height:       srv.lightClient.Height(),
+		CurrentBlock: srv.lightClient.CurrentHeader().ID(),
+	})
+}
+
+// lightClientSynchronizeHandler handles the API call to synchronize the light
+// client with a peer.
+func (srv *Server) lightClientSynchronizeHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	addr := modules.NetAddress(ps.ByName("netaddress"))
+	if err := srv.lightClient.Synchronize(addr); err != nil {
+		writeError(w, Error{Message: "failed to synchronize with " + string(addr) + ": " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writeSuccess(w)
+}
+
+// lightClientTrackHandler handles the API call to add an address or a file
+// contract to the objects that are relevant to the light client.
+func (srv *Server) lightClientTrackHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	address, contract := req.FormValue("address"), req.FormValue("contract")
+	if (address == "") == (contract == "") {
+		writeError(w, Error{Message: "exactly one of 'address' and 'contract' must be provided"}, http.StatusBadRequest)
+		return
+	}
+	var err error
+	if address != "" {
+		var uh types.UnlockHash
+		uh, err = scanAddress(address)
+		if err != nil {
+			writeError(w, Error{Message: "unable to parse address: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = srv.lightClient.TrackUnlockHash(uh)
+	} else {
+		id, scanErr := scanHash(contract)
+		if scanErr != nil {
+			writeError(w, Error{Message: "unable to parse contract id: " + scanErr.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = srv.lightClient.TrackContract(types.FileContractID(id))
+	}
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	writeSuccess(w)
+}
+
+// lightClientTransactionsHandler handles the API call to
+// /lightclient/transactions.
+func (srv *Server) lightClientTransactionsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	writeJSON(w, LightClientTransactionsGET{Transactions: srv.lightClient.Transactions()})
+}
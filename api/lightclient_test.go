@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/modules/lightclient"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestIntegrationLightClient checks that a light client follows the chain of
+// a full node through the API, and receives the transactions of the addresses
+// that it tracks.
+func TestIntegrationLightClient(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationLightClient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	// Create a server with only a gateway and a light client.
+	testdir := build.TempDir("api", "TestIntegrationLightClient", "light")
+	g, err := gateway.New("localhost:0", filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc, err := lightclient.New(g, filepath.Join(testdir, modules.LightClientDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := NewServer("localhost:0", "Sia-Agent", "", nil, nil, g, nil, lc, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lst := &serverTester{gateway: g, server: srv, dir: testdir}
+	go srv.Serve()
+	defer srv.Close()
+	if err := g.Connect(st.gateway.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Track an address, and send coins to it.
+	addr := st.coinAddress()
+	if err := lst.stdPostAPI("/lightclient/track", url.Values{"address": {addr}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := lst.stdPostAPI("/lightclient/track", url.Values{}); err == nil {
+		t.Error("expected an error when tracking nothing")
+	}
+	err = st.stdPostAPI("/wallet/siacoins", url.Values{
+		"amount":      {"1000000"},
+		"destination": {addr},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lst.stdPostAPI("/lightclient/synchronize/"+string(st.gateway.Address()), nil); err != nil {
+		t.Fatal(err)
+	}
+	var lcg LightClientGET
+	if err := lst.getAPI("/lightclient", &lcg); err != nil {
+		t.Fatal(err)
+	}
+	if lcg.Height != st.cs.Height() || lcg.CurrentBlock != st.cs.CurrentBlock().ID() {
+		t.Fatalf("light client is at %v (height %v), full node is at %v (height %v)", lcg.CurrentBlock, lcg.Height, st.cs.CurrentBlock().ID(), st.cs.Height())
+	}
+	var ltg LightClientTransactionsGET
+	if err := lst.getAPI("/lightclient/transactions", &ltg); err != nil {
+		t.Fatal(err)
+	}
+	var uh types.UnlockHash
+	if err := uh.LoadString(addr); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, tp := range ltg.Transactions {
+		for _, sco := range tp.Transaction.SiacoinOutputs {
+			found = found || sco.UnlockHash == uh
+		}
+	}
+	if !found {
+		t.Error("light client did not receive the transaction sending coins to the tracked address")
+	}
+}
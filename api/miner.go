@@ -1,9 +1,13 @@
 package api
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/julienschmidt/httprouter"
@@ -12,28 +16,65 @@ import (
 type (
 	// MinerGET contains the information that is returned after a GET request
 	// to /miner.
-	MinerGET struct {
-		BlocksMined      int  `json:"blocksmined"`
-		CPUHashrate      int  `json:"cpuhashrate"`
-		CPUMining        bool `json:"cpumining"`
-		StaleBlocksMined int  `json:"staleblocksmined"`
+	MinerGET modules.MinerStatus
+
+	// MinerMineBlocksPOST contains the ids of the blocks mined by a POST
+	// request to /miner/mineblocks.
+	MinerMineBlocksPOST struct {
+		BlockIDs []types.BlockID `json:"blockids"`
+	}
+
+	// MinerPoolGET contains the state of the pool client of the miner.
+	MinerPoolGET modules.PoolStatus
+
+	// MinerPayoutsGET contains the addresses that the block subsidy is split
+	// between.
+	MinerPayoutsGET struct {
+		Payouts []modules.MinerPayoutSplit `json:"payouts"`
+	}
+
+	// MinerBlockTemplateGET contains a block that is ready for nonce
+	// grinding, its header, and the target that the block must meet.
+	MinerBlockTemplateGET struct {
+		Block  types.Block       `json:"block"`
+		Header types.BlockHeader `json:"header"`
+		Target types.Target      `json:"target"`
 	}
 )
 
 // minerHandler handles the API call that queries the miner's status.
 func (srv *Server) minerHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	blocksMined, staleMined := srv.miner.BlocksMined()
-	mg := MinerGET{
-		BlocksMined:      blocksMined,
-		CPUHashrate:      srv.miner.CPUHashrate(),
-		CPUMining:        srv.miner.CPUMining(),
-		StaleBlocksMined: staleMined,
-	}
-	writeJSON(w, mg)
+	writeJSON(w, MinerGET(srv.miner.Status()))
 }
 
-// minerStartHandler handles the API call that starts the miner.
+// minerStartHandler handles the API call that starts the miner. The number of
+// threads and the cpu limit are set by the optional 'threads' and 'cpulimit'
+// parameters.
 func (srv *Server) minerStartHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if t := req.FormValue("threads"); t != "" {
+		var threads int
+		_, err := fmt.Sscan(t, &threads)
+		if err != nil {
+			writeError(w, Error{Message: "Couldn't parse threads: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := srv.miner.SetCPUThreads(threads); err != nil {
+			writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if l := req.FormValue("cpulimit"); l != "" {
+		var limit int
+		_, err := fmt.Sscan(l, &limit)
+		if err != nil {
+			writeError(w, Error{Message: "Couldn't parse cpulimit: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := srv.miner.SetCPULimit(limit); err != nil {
+			writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	srv.miner.StartCPUMining()
 	writeSuccess(w)
 }
@@ -49,24 +90,148 @@ func (srv *Server) minerStopHandler(w http.ResponseWriter, req *http.Request, _
 func (srv *Server) minerHeaderHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	bhfw, target, err := srv.miner.HeaderForWork()
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	w.Write(encoding.MarshalAll(target, bhfw))
 }
 
+// minerPayoutsHandlerGET handles the API call that returns the payout splits
+// of the miner.
+func (srv *Server) minerPayoutsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	writeJSON(w, MinerPayoutsGET{Payouts: srv.miner.PayoutSplits()})
+}
+
+// minerPayoutsHandlerPOST handles the API call that sets the payout splits of
+// the miner. The 'payouts' parameter is a comma separated list of
+// 'address:percentage' pairs. Leaving it empty pays the block subsidy to the
+// wallet.
+func (srv *Server) minerPayoutsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var splits []modules.MinerPayoutSplit
+	if p := req.FormValue("payouts"); p != "" {
+		for _, pair := range strings.Split(p, ",") {
+			fields := strings.Split(pair, ":")
+			if len(fields) != 2 {
+				writeError(w, Error{Message: "Couldn't parse payout '" + pair + "': expected address:percentage"}, http.StatusBadRequest)
+				return
+			}
+			addr, err := scanAddress(fields[0])
+			if err != nil {
+				writeError(w, Error{Message: "Couldn't parse address: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			var percentage uint64
+			_, err = fmt.Sscan(fields[1], &percentage)
+			if err != nil {
+				writeError(w, Error{Message: "Couldn't parse percentage: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			splits = append(splits, modules.MinerPayoutSplit{UnlockHash: addr, Percentage: percentage})
+		}
+	}
+	if err := srv.miner.SetPayoutSplits(splits); err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writeSuccess(w)
+}
+
+// minerBlockHandlerPOST handles the API call to submit a fully formed block
+// that was mined outside of the daemon.
+func (srv *Server) minerBlockHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var b types.Block
+	err := encoding.NewDecoder(io.LimitReader(req.Body, int64(types.BlockSizeLimit))).Decode(&b)
+	if err != nil {
+		writeError(w, Error{Message: "Couldn't decode block: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = srv.miner.SubmitBlock(b)
+	if err != nil {
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
+		return
+	}
+	writeSuccess(w)
+}
+
+// minerMineBlocksHandler handles the API call that mines blocks on demand.
+func (srv *Server) minerMineBlocksHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var count int
+	_, err := fmt.Sscan(req.FormValue("count"), &count)
+	if err != nil {
+		writeError(w, Error{Message: "Couldn't parse count: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	ids, err := srv.miner.MineBlocks(count)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, MinerMineBlocksPOST{BlockIDs: ids})
+}
+
+// minerPoolHandlerGET handles the API call that returns the state of the
+// pool client.
+func (srv *Server) minerPoolHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	writeJSON(w, MinerPoolGET(srv.miner.PoolStatus()))
+}
+
+// minerPoolStartHandler handles the API call that starts mining for a pool.
+func (srv *Server) minerPoolStartHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	payout, err := scanAddress(req.FormValue("payout"))
+	if err != nil {
+		writeError(w, Error{Message: "Couldn't parse payout address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var difficulty uint64
+	if d := req.FormValue("difficulty"); d != "" {
+		_, err := fmt.Sscan(d, &difficulty)
+		if err != nil {
+			writeError(w, Error{Message: "Couldn't parse difficulty: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	err = srv.miner.StartPoolMining(modules.NetAddress(req.FormValue("pool")), payout, difficulty)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writeSuccess(w)
+}
+
+// minerPoolStopHandler handles the API call that stops mining for a pool.
+func (srv *Server) minerPoolStopHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	srv.miner.StopPoolMining()
+	writeSuccess(w)
+}
+
+// minerBlockTemplateHandlerGET handles the API call that retrieves a block
+// template for work. The header of the template can be submitted to
+// /miner/header once it has been solved.
+func (srv *Server) minerBlockTemplateHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	b, target, err := srv.miner.BlockTemplate()
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, MinerBlockTemplateGET{
+		Block:  b,
+		Header: b.Header(),
+		Target: target,
+	})
+}
+
 // minerHeaderHandlerPOST handles the API call to submit a block header to the
 // miner.
 func (srv *Server) minerHeaderHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var bh types.BlockHeader
 	err := encoding.NewDecoder(req.Body).Decode(&bh)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	err = srv.miner.SubmitHeader(bh)
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
@@ -1,11 +1,17 @@
 package api
 
 import (
+	"bytes"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
 	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -41,6 +47,9 @@ func TestIntegrationMinerGET(t *testing.T) {
 	if mg.CPUMining != st.server.miner.CPUMining() {
 		t.Error("mismatched cpu miner status")
 	}
+	if mg.CPUThreads != st.server.miner.CPUThreads() {
+		t.Error("mismatched cpu miner threads")
+	}
 }
 
 // TestIntegrationMinerStartStop checks that the miner start and miner stop api endpoints
@@ -57,7 +66,7 @@ func TestIntegrationMinerStartStop(t *testing.T) {
 
 	// Start the cpu miner, give time for the first hashrate readings to
 	// appear.
-	err = st.stdGetAPI("/miner/start")
+	err = st.stdGetAPI("/miner/start?threads=2")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -75,6 +84,25 @@ func TestIntegrationMinerStartStop(t *testing.T) {
 	if !mg.CPUMining {
 		t.Error("cpu is not reporting through the api that it is mining")
 	}
+	if mg.CPUThreads != 2 {
+		t.Error("cpu miner is not using the requested number of threads:", mg.CPUThreads)
+	}
+
+	// Throttle the running miner.
+	if err := st.stdGetAPI("/miner/start?cpulimit=0"); err == nil {
+		t.Error("expected an error for a cpu limit of 0")
+	}
+	err = st.stdGetAPI("/miner/start?cpulimit=50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = st.getAPI("/miner", &mg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mg.CPULimit != 50 || !mg.CPUMining {
+		t.Error("cpu miner is not reporting the requested cpu limit:", mg.CPULimit)
+	}
 
 	// Stop the cpu miner and wait for the stop call to go through.
 	err = st.stdGetAPI("/miner/stop")
@@ -147,3 +175,226 @@ func TestIntegrationMinerHeader(t *testing.T) {
 		t.Errorf("block height did not increase after trying to mine a block through the api, started at %v and ended at %v", startingHeight, st.cs.Height())
 	}
 }
+
+// TestIntegrationMinerBlockTemplate checks that a block template fetched
+// through the api can be solved and submitted to /miner/header.
+func TestIntegrationMinerBlockTemplate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationMinerBlockTemplate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	startingHeight := st.cs.Height()
+
+	var mbt MinerBlockTemplateGET
+	err = st.getAPI("/miner/blocktemplate", &mbt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mbt.Header != mbt.Block.Header() {
+		t.Fatal("block template header does not match the block")
+	}
+	if mbt.Block.ParentID != st.cs.CurrentBlock().ID() {
+		t.Fatal("block template does not build on the current block")
+	}
+
+	// Grind the header until it meets the target.
+	header := mbt.Header
+	for id := header.ID(); bytes.Compare(mbt.Target[:], id[:]) < 0; id = header.ID() {
+		header.Nonce[0]++
+	}
+
+	// Submit the solved header through the api and check that the height of
+	// the blockchain increases.
+	resp, err := HttpPOST("http://"+st.server.listener.Addr().String()+"/miner/header", string(encoding.Marshal(header)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if st.cs.Height() != startingHeight+1 {
+		t.Fatalf("block height did not increase after submitting a block template, started at %v and ended at %v", startingHeight, st.cs.Height())
+	}
+}
+
+// TestIntegrationMinerPayouts checks that the payout splits of the miner can
+// be set and queried through the api.
+func TestIntegrationMinerPayouts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationMinerPayouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	addr1 := types.UnlockHash{1}
+	addr2 := types.UnlockHash{2}
+	payouts := addr1.String() + ":40," + addr2.String() + ":60"
+
+	// Malformed and invalid splits are rejected.
+	if err := st.stdPostAPI("/miner/payouts", url.Values{"payouts": {addr1.String()}}); err == nil {
+		t.Fatal("expected an error for a payout without a percentage")
+	}
+	if err := st.stdPostAPI("/miner/payouts", url.Values{"payouts": {addr1.String() + ":40"}}); err == nil {
+		t.Fatal("expected an error for percentages that do not add up to 100")
+	}
+
+	// Set the splits and check that new blocks pay them.
+	if err := st.stdPostAPI("/miner/payouts", url.Values{"payouts": {payouts}}); err != nil {
+		t.Fatal(err)
+	}
+	var mpg MinerPayoutsGET
+	if err := st.getAPI("/miner/payouts", &mpg); err != nil {
+		t.Fatal(err)
+	}
+	if len(mpg.Payouts) != 2 || mpg.Payouts[0].UnlockHash != addr1 || mpg.Payouts[1].Percentage != 60 {
+		t.Fatal("wrong payout splits:", mpg.Payouts)
+	}
+	var mbt MinerBlockTemplateGET
+	if err := st.getAPI("/miner/blocktemplate", &mbt); err != nil {
+		t.Fatal(err)
+	}
+	if len(mbt.Block.MinerPayouts) != 2 || mbt.Block.MinerPayouts[1].UnlockHash != addr2 {
+		t.Fatal("block template does not pay the payout splits:", mbt.Block.MinerPayouts)
+	}
+
+	// Clearing the splits pays the wallet again.
+	if err := st.stdPostAPI("/miner/payouts", url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/miner/payouts", &mpg); err != nil {
+		t.Fatal(err)
+	}
+	if len(mpg.Payouts) != 0 {
+		t.Fatal("payout splits were not cleared:", mpg.Payouts)
+	}
+}
+
+// TestIntegrationMinerPool checks that pool mining can be started, queried,
+// and stopped through the api.
+func TestIntegrationMinerPool(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationMinerPool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	// Listen for the pool client, without acting as a pool.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	payout := types.UnlockHash{1}
+	if err := st.stdPostAPI("/miner/pool/start", url.Values{"pool": {l.Addr().String()}}); err == nil {
+		t.Fatal("expected an error when starting pool mining without a payout address")
+	}
+	vals := url.Values{
+		"pool":       {l.Addr().String()},
+		"payout":     {payout.String()},
+		"difficulty": {"1000"},
+	}
+	if err := st.stdPostAPI("/miner/pool/start", vals); err != nil {
+		t.Fatal(err)
+	}
+	var mpg MinerPoolGET
+	if err := st.getAPI("/miner/pool", &mpg); err != nil {
+		t.Fatal(err)
+	}
+	if !mpg.PoolMining || mpg.Pool != modules.NetAddress(l.Addr().String()) || mpg.PayoutAddress != payout || mpg.Difficulty != 1000 {
+		t.Fatal("wrong pool status:", mpg)
+	}
+
+	if err := st.stdPostAPI("/miner/pool/stop", url.Values{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/miner/pool", &mpg); err != nil {
+		t.Fatal(err)
+	}
+	if mpg.PoolMining {
+		t.Fatal("pool mining did not stop")
+	}
+}
+
+// TestIntegrationMinerMineBlocks checks that blocks can be mined on demand
+// through the api.
+func TestIntegrationMinerMineBlocks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationMinerMineBlocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	if err := st.stdPostAPI("/miner/mineblocks", url.Values{"count": {"0"}}); err == nil {
+		t.Fatal("expected an error when mining zero blocks")
+	}
+	startHeight := st.cs.Height()
+	var mmb MinerMineBlocksPOST
+	if err := st.postAPI("/miner/mineblocks", url.Values{"count": {"3"}}, &mmb); err != nil {
+		t.Fatal(err)
+	}
+	if len(mmb.BlockIDs) != 3 || st.cs.Height() != startHeight+3 {
+		t.Fatal("wrong number of blocks mined:", len(mmb.BlockIDs), st.cs.Height()-startHeight)
+	}
+	if st.cs.CurrentBlock().ID() != mmb.BlockIDs[2] {
+		t.Fatal("the last block mined is not the current block")
+	}
+}
+
+// TestIntegrationMinerBlockPOST checks that a block mined outside of the
+// daemon can be submitted through the api.
+func TestIntegrationMinerBlockPOST(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationMinerBlockPOST")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+	startingHeight := st.cs.Height()
+
+	var mbt MinerBlockTemplateGET
+	err = st.getAPI("/miner/blocktemplate", &mbt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := mbt.Block
+
+	// A malformed block is rejected.
+	resp, err := HttpPOST("http://"+st.server.listener.Addr().String()+"/miner/block", "not a block")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("expected a malformed block to be rejected, got status", resp.StatusCode)
+	}
+
+	// Solve the block and submit it.
+	for id := b.ID(); bytes.Compare(mbt.Target[:], id[:]) < 0; id = b.ID() {
+		b.Nonce[0]++
+	}
+	resp, err = HttpPOST("http://"+st.server.listener.Addr().String()+"/miner/block", string(encoding.Marshal(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatal("block was not accepted, got status", resp.StatusCode)
+	}
+	if st.cs.Height() != startingHeight+1 || st.cs.CurrentBlock().ID() != b.ID() {
+		t.Fatal("submitted block was not added to the blockchain")
+	}
+}
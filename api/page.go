@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// scanPage parses the 'offset' and 'limit' parameters of a request for a page
+// of a list. 'offset' is the number of items to skip. A 'limit' of zero, the
+// default, returns every item after the offset, so that calls that do not
+// page receive the whole list.
+func scanPage(req *http.Request) (offset, limit int, err error) {
+	if s := req.FormValue("offset"); s != "" {
+		offset, err = strconv.Atoi(s)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("'offset' must be a non-negative integer")
+		}
+	}
+	if s := req.FormValue("limit"); s != "" {
+		limit, err = strconv.Atoi(s)
+		if err != nil || limit < 0 {
+			return 0, 0, errors.New("'limit' must be a non-negative integer")
+		}
+	}
+	return offset, limit, nil
+}
+
+// pageBounds returns the bounds of the page of a list of 'n' items that
+// starts at 'offset' and holds at most 'limit' items, or every remaining item
+// if 'limit' is zero.
+func pageBounds(n, offset, limit int) (start, end int) {
+	if offset > n {
+		offset = n
+	}
+	end = n
+	if limit > 0 && limit < n-offset {
+		end = offset + limit
+	}
+	return offset, end
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// TestPageBounds checks the bounds of pages of a list.
+func TestPageBounds(t *testing.T) {
+	tests := []struct {
+		n, offset, limit int
+		start, end       int
+	}{
+		{0, 0, 0, 0, 0},
+		{10, 0, 0, 0, 10},
+		{10, 3, 0, 3, 10},
+		{10, 3, 4, 3, 7},
+		{10, 8, 4, 8, 10},
+		{10, 10, 4, 10, 10},
+		{10, 20, 4, 10, 10},
+	}
+	for _, test := range tests {
+		start, end := pageBounds(test.n, test.offset, test.limit)
+		if start != test.start || end != test.end {
+			t.Errorf("pageBounds(%v, %v, %v): expected [%v:%v], got [%v:%v]", test.n, test.offset, test.limit, test.start, test.end, start, end)
+		}
+	}
+}
+
+// TestScanPage checks that the paging parameters are parsed and validated.
+func TestScanPage(t *testing.T) {
+	tests := []struct {
+		query         string
+		offset, limit int
+		valid         bool
+	}{
+		{"", 0, 0, true},
+		{"offset=5&limit=10", 5, 10, true},
+		{"limit=0", 0, 0, true},
+		{"offset=-1", 0, 0, false},
+		{"limit=-1", 0, 0, false},
+		{"limit=ten", 0, 0, false},
+	}
+	for _, test := range tests {
+		req, err := http.NewRequest("GET", "/?"+test.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offset, limit, err := scanPage(req)
+		if (err == nil) != test.valid {
+			t.Errorf("%q: expected valid %v, got error %v", test.query, test.valid, err)
+		} else if offset != test.offset || limit != test.limit {
+			t.Errorf("%q: expected %v %v, got %v %v", test.query, test.offset, test.limit, offset, limit)
+		}
+	}
+}
+
+// TestIntegrationPagination checks that the list calls return the requested
+// pages of their lists.
+func TestIntegrationPagination(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationPagination")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	// The blocks mined by the server tester give the wallet enough
+	// transactions to page through.
+	call := fmt.Sprintf("/wallet/transactions?startheight=0&endheight=%v", st.cs.Height())
+	var all, page WalletTransactionsGET
+	if err := st.getAPI(call, &all); err != nil {
+		t.Fatal(err)
+	}
+	if len(all.ConfirmedTransactions) < 3 {
+		t.Fatal("not enough transactions to page through:", len(all.ConfirmedTransactions))
+	}
+	if err := st.getAPI(call+"&offset=1&limit=2", &page); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(page.ConfirmedTransactions, all.ConfirmedTransactions[1:3]) {
+		t.Fatal("wrong page of transactions")
+	}
+	if err := st.getAPI(call+"&limit=-1", &page); err == nil {
+		t.Fatal("negative limit was accepted")
+	}
+
+	var gpg GatewayPeersGET
+	if err := st.getAPI("/gateway/peers?inbound=true&limit=10", &gpg); err != nil {
+		t.Fatal(err)
+	}
+	if gpg.Peers == nil || len(gpg.Peers) != 0 {
+		t.Fatal("expected an empty list of peers:", gpg.Peers)
+	}
+	if err := st.getAPI("/gateway/peers?inbound=maybe", &gpg); err == nil {
+		t.Fatal("invalid inbound filter was accepted")
+	}
+
+	var rf RenterFiles
+	if err := st.getAPI("/renter/files?prefix=missing&offset=5", &rf); err != nil {
+		t.Fatal(err)
+	}
+	if rf.Files == nil || len(rf.Files) != 0 {
+		t.Fatal("expected an empty list of files:", rf.Files)
+	}
+}
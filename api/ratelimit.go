@@ -0,0 +1,109 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+var (
+	errInvalidRateLimit = errors.New("rate limit must not be negative, and needs a positive interval")
+)
+
+// A rateLimiter limits the number of expensive API calls, such as uploads and
+// wallet rescans, that each client can make within an interval. Clients are
+// identified by their IP address. A limit of zero disables the limiter.
+type rateLimiter struct {
+	limit    int
+	interval time.Duration
+	clients  map[string]*clientCalls
+	mu       sync.Mutex
+}
+
+// clientCalls counts the calls made by a client since the start of its
+// current interval.
+type clientCalls struct {
+	start time.Time
+	calls int
+}
+
+// newRateLimiter creates a disabled rateLimiter.
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		clients: make(map[string]*clientCalls),
+	}
+}
+
+// setLimit allows each client 'limit' expensive calls per 'interval'.
+func (rl *rateLimiter) setLimit(limit int, interval time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.limit = limit
+	rl.interval = interval
+	rl.clients = make(map[string]*clientCalls)
+}
+
+// allow records a call by 'client' at time 'now'. If the client has used up
+// its calls for the current interval, allow returns false and the time at
+// which the client can call again.
+func (rl *rateLimiter) allow(client string, now time.Time) (bool, time.Time) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.limit <= 0 {
+		return true, time.Time{}
+	}
+
+	// Forget the clients whose intervals have ended, so that the map does
+	// not grow with every client that has ever made a call.
+	for c, cc := range rl.clients {
+		if now.Sub(cc.start) >= rl.interval {
+			delete(rl.clients, c)
+		}
+	}
+
+	cc, exists := rl.clients[client]
+	if !exists {
+		cc = &clientCalls{start: now}
+		rl.clients[client] = cc
+	}
+	if cc.calls >= rl.limit {
+		return false, cc.start.Add(rl.interval)
+	}
+	cc.calls++
+	return true, time.Time{}
+}
+
+// SetRateLimit allows each client 'limit' calls per 'interval' to the
+// expensive API calls, which include uploads, downloads, and the wallet calls
+// that rescan the blockchain or derive keys. A limit of zero, the default,
+// removes the limit.
+func (srv *Server) SetRateLimit(limit int, interval time.Duration) error {
+	if limit < 0 || (limit > 0 && interval <= 0) {
+		return errInvalidRateLimit
+	}
+	srv.rateLimiter.setLimit(limit, interval)
+	return nil
+}
+
+// limitRate is middleware that responds with 429 Too Many Requests to the
+// clients that have exceeded the rate limit of the expensive API calls.
+func (srv *Server) limitRate(h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		client, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			client = req.RemoteAddr
+		}
+		if ok, retry := srv.rateLimiter.allow(client, time.Now()); !ok {
+			wait := int(time.Until(retry).Seconds()) + 1
+			w.Header().Set("Retry-After", fmt.Sprint(wait))
+			writeError(w, Error{Message: fmt.Sprintf("rate limit exceeded, try again in %v seconds", wait)}, http.StatusTooManyRequests)
+			return
+		}
+		h(w, req, ps)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestRateLimiter probes the allow method of the rateLimiter.
+func TestRateLimiter(t *testing.T) {
+	rl := newRateLimiter()
+	now := time.Now()
+	for i := 0; i < 10; i++ {
+		if ok, _ := rl.allow("a", now); !ok {
+			t.Fatal("disabled rate limiter refused a call")
+		}
+	}
+
+	rl.setLimit(2, time.Minute)
+	for i := 0; i < 2; i++ {
+		if ok, _ := rl.allow("a", now); !ok {
+			t.Fatal("call within the limit was refused")
+		}
+	}
+	ok, retry := rl.allow("a", now.Add(time.Second))
+	if ok || !retry.Equal(now.Add(time.Minute)) {
+		t.Fatal("call over the limit was allowed, or has the wrong retry time:", ok, retry)
+	}
+	// Other clients have their own limits.
+	if ok, _ := rl.allow("b", now.Add(time.Second)); !ok {
+		t.Fatal("call from another client was refused")
+	}
+	// The limit resets after the interval, and finished intervals are
+	// forgotten.
+	if ok, _ := rl.allow("a", now.Add(time.Minute)); !ok {
+		t.Fatal("call after the interval was refused")
+	}
+	rl.allow("a", now.Add(2*time.Minute))
+	if _, exists := rl.clients["b"]; exists {
+		t.Fatal("client with a finished interval was not forgotten")
+	}
+}
+
+// TestIntegrationRateLimit checks that the expensive API calls are refused
+// once a client exceeds the rate limit.
+func TestIntegrationRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	st, err := createServerTester("TestIntegrationRateLimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.Close()
+
+	if err := st.server.SetRateLimit(-1, time.Minute); err == nil {
+		t.Fatal("negative rate limit was accepted")
+	}
+	if err := st.server.SetRateLimit(1, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	unlockURL := "http://" + st.server.listener.Addr().String() + "/wallet/unlock"
+	resp, err := HttpPOST(unlockURL, "encryptionpassword=wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.Fatal("first call was rate limited")
+	}
+	resp, err = HttpPOST(unlockURL, "encryptionpassword=wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatal("second call was not rate limited:", resp.Status)
+	}
+
+	// Calls that are not expensive are not limited.
+	var dv DaemonVersion
+	if err := st.getAPI("/daemon/version", &dv); err != nil {
+		t.Fatal(err)
+	}
+}
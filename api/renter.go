@@ -1,8 +1,10 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -93,25 +95,25 @@ func (srv *Server) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _
 	// scan values
 	funds, ok := scanAmount(req.FormValue("funds"))
 	if !ok {
-		writeError(w, Error{"Couldn't parse funds"}, http.StatusBadRequest)
+		writeError(w, Error{Message: "Couldn't parse funds"}, http.StatusBadRequest)
 		return
 	}
 	// var hosts uint64
 	// _, err := fmt.Sscan(req.FormValue("hosts"), &hosts)
 	// if err != nil {
-	// 	writeError(w, Error{"Couldn't parse hosts: "+err.Error()}, http.StatusBadRequest)
+	// 	writeError(w, Error{Message: "Couldn't parse hosts: "+err.Error()}, http.StatusBadRequest)
 	// 	return
 	// }
 	var period types.BlockHeight
 	_, err := fmt.Sscan(req.FormValue("period"), &period)
 	if err != nil {
-		writeError(w, Error{"Couldn't parse period: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "Couldn't parse period: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
 	// var renewWindow types.BlockHeight
 	// _, err = fmt.Sscan(req.FormValue("renewwindow"), &renewWindow)
 	// if err != nil {
-	// 	writeError(w, Error{"Couldn't parse renewwindow: "+err.Error()}, http.StatusBadRequest)
+	// 	writeError(w, Error{Message: "Couldn't parse renewwindow: "+err.Error()}, http.StatusBadRequest)
 	// 	return
 	// }
 
@@ -126,16 +128,28 @@ func (srv *Server) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _
 		},
 	})
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
 }
 
 // renterContractsHandler handles the API call to request the Renter's contracts.
-func (srv *Server) renterContractsHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+// The contracts are ordered by id, and can be paged with 'offset' and 'limit'
+// and filtered by the address of their host with 'host'.
+func (srv *Server) renterContractsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	offset, limit, err := scanPage(req)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	host := modules.NetAddress(req.FormValue("host"))
+
 	contracts := []RenterContract{}
 	for _, c := range srv.renter.Contracts() {
+		if host != "" && c.NetAddress != host {
+			continue
+		}
 		contracts = append(contracts, RenterContract{
 			EndHeight:   c.EndHeight(),
 			ID:          c.ID,
@@ -144,8 +158,12 @@ func (srv *Server) renterContractsHandler(w http.ResponseWriter, _ *http.Request
 			Size:        modules.SectorSize * uint64(len(c.MerkleRoots)),
 		})
 	}
+	sort.Slice(contracts, func(i, j int) bool {
+		return bytes.Compare(contracts[i].ID[:], contracts[j].ID[:]) < 0
+	})
+	start, end := pageBounds(len(contracts), offset, limit)
 	writeJSON(w, RenterContracts{
-		Contracts: contracts,
+		Contracts: contracts[start:end],
 	})
 }
 
@@ -160,7 +178,7 @@ func (srv *Server) renterDownloadsHandler(w http.ResponseWriter, _ *http.Request
 func (srv *Server) renterLoadHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	files, err := srv.renter.LoadSharedFiles(req.FormValue("source"))
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
@@ -172,7 +190,7 @@ func (srv *Server) renterLoadHandler(w http.ResponseWriter, req *http.Request, _
 func (srv *Server) renterLoadAsciiHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	files, err := srv.renter.LoadSharedFilesAscii(req.FormValue("asciisia"))
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
@@ -184,17 +202,36 @@ func (srv *Server) renterLoadAsciiHandler(w http.ResponseWriter, req *http.Reque
 func (srv *Server) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	err := srv.renter.RenameFile(strings.TrimPrefix(ps.ByName("siapath"), "/"), req.FormValue("newsiapath"))
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
 	writeSuccess(w)
 }
 
-// renterFilesHandler handles the API call to list all of the files.
+// renterFilesHandler handles the API call to list all of the files. The files
+// are ordered by siapath, and can be paged with 'offset' and 'limit' and
+// filtered by the beginning of their siapath with 'prefix'.
 func (srv *Server) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	offset, limit, err := scanPage(req)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	prefix := req.FormValue("prefix")
+
+	files := []modules.FileInfo{}
+	for _, f := range srv.renter.FileList() {
+		if strings.HasPrefix(f.SiaPath, prefix) {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].SiaPath < files[j].SiaPath
+	})
+	start, end := pageBounds(len(files), offset, limit)
 	writeJSON(w, RenterFiles{
-		Files: srv.renter.FileList(),
+		Files: files[start:end],
 	})
 }
 
@@ -203,7 +240,7 @@ func (srv *Server) renterFilesHandler(w http.ResponseWriter, req *http.Request,
 func (srv *Server) renterDeleteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	err := srv.renter.DeleteFile(strings.TrimPrefix(ps.ByName("siapath"), "/"))
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
@@ -214,7 +251,7 @@ func (srv *Server) renterDeleteHandler(w http.ResponseWriter, req *http.Request,
 func (srv *Server) renterDownloadHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	err := srv.renter.Download(strings.TrimPrefix(ps.ByName("siapath"), "/"), req.FormValue("destination"))
 	if err != nil {
-		writeError(w, Error{"Download failed: " + err.Error()}, http.StatusInternalServerError)
+		writeError(w, Error{Message: "Download failed: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
 
@@ -226,7 +263,7 @@ func (srv *Server) renterDownloadHandler(w http.ResponseWriter, req *http.Reques
 func (srv *Server) renterShareHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	err := srv.renter.ShareFiles(strings.Split(req.FormValue("siapaths"), ","), req.FormValue("destination"))
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 
@@ -238,7 +275,7 @@ func (srv *Server) renterShareHandler(w http.ResponseWriter, req *http.Request,
 func (srv *Server) renterShareAsciiHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	ascii, err := srv.renter.ShareFilesAscii(strings.Split(req.FormValue("siapaths"), ","))
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, RenterShareASCII{
@@ -255,7 +292,7 @@ func (srv *Server) renterUploadHandler(w http.ResponseWriter, req *http.Request,
 		ErasureCode: nil,
 	})
 	if err != nil {
-		writeError(w, Error{"Upload failed: " + err.Error()}, http.StatusInternalServerError)
+		writeError(w, Error{Message: "Upload failed: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
 
@@ -275,7 +312,7 @@ func (srv *Server) renterHostsActiveHandler(w http.ResponseWriter, req *http.Req
 		// Parse the value for 'numhosts'.
 		_, err := fmt.Sscan(req.FormValue("numhosts"), &numHosts)
 		if err != nil {
-			writeError(w, Error{err.Error()}, http.StatusBadRequest)
+			writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
 			return
 		}
 
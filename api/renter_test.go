@@ -112,9 +112,9 @@ func TestRenterConflicts(t *testing.T) {
 
 	// Upload using the same nickname.
 	err = st.stdPostAPI("/renter/upload/foo/bar.sia/test", uploadValues)
-	expectedErr := Error{"Upload failed: " + renter.ErrPathOverload.Error()}
+	expectedErr := Error{Message: "Upload failed: " + renter.ErrPathOverload.Error(), Code: CodeInternalError}
 	if err != expectedErr {
-		t.Fatalf("expected %v, got %v", Error{"Upload failed: " + renter.ErrPathOverload.Error()}, err)
+		t.Fatalf("expected %v, got %v", expectedErr, err)
 	}
 
 	// Upload using nickname that conflicts with folder.
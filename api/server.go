@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net"
@@ -9,31 +10,85 @@ import (
 	"os/signal"
 	"strings"
 	"sync"
+	"syscall"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
 )
 
+var (
+	// shutdownTimeout is the amount of time that Close waits for in-flight
+	// API calls to complete before closing their connections.
+	shutdownTimeout = func() time.Duration {
+		if build.Release == "dev" {
+			return 10 * time.Second
+		}
+		if build.Release == "standard" {
+			return 30 * time.Second
+		}
+		if build.Release == "testing" {
+			return 3 * time.Second
+		}
+		panic("unrecognized release constant in api")
+	}()
+)
+
 // A Server is essentially a collection of modules and an API server to talk
 // to them all.
 type Server struct {
-	cs       modules.ConsensusSet
-	explorer modules.Explorer
-	gateway  modules.Gateway
-	host     modules.Host
-	miner    modules.Miner
-	renter   modules.Renter
-	tpool    modules.TransactionPool
-	wallet   modules.Wallet
+	cs          modules.ConsensusSet
+	explorer    modules.Explorer
+	gateway     modules.Gateway
+	host        modules.Host
+	lightClient modules.LightClient
+	miner       modules.Miner
+	renter      modules.Renter
+	tpool       modules.TransactionPool
+	wallet      modules.Wallet
 
 	apiServer         *http.Server
+	events            *eventHub
+	jobs              *jobManager
 	listener          net.Listener
 	requiredUserAgent string
 
+	// extraListeners are the listeners added with Listen, which serve the
+	// API alongside the primary listener.
+	extraListeners []net.Listener
+
+	// debug indicates whether the /debug API calls are enabled, and
+	// corsOrigins are the origins that browsers may call the API from.
+	debug       bool
+	corsOrigins map[string]struct{}
+
+	// auditLog is the file that the calls that change the state of the node
+	// are recorded in, if it has been set with SetAuditLog.
+	auditLog *os.File
+
+	// faucetURL is the faucet that /wallet/faucet requests coins from when
+	// the call does not name one.
+	faucetURL string
+	mu        sync.Mutex
+
+	// rateLimiter limits the expensive API calls of each client.
+	rateLimiter *rateLimiter
+
 	// wg is used to block Close() from returning until Serve() has finished. A
 	// WaitGroup is used instead of a chan struct{} so that Close() can be called
 	// without necessarily calling Serve() first.
 	wg sync.WaitGroup
+
+	// shutdown is closed when Close begins, so that API calls which wait on
+	// the modules can return instead of holding up the shutdown.
+	shutdown chan struct{}
+
+	// closeOnce ensures that the modules are only closed once, no matter how
+	// many times Close is called. closed is closed once Close has finished,
+	// and closeErr holds the error that it returned.
+	closeOnce sync.Once
+	closed    chan struct{}
+	closeErr  error
 }
 
 // NewServer creates a new API server from the provided modules. The API will
@@ -41,24 +96,37 @@ type Server struct {
 // the empty string. Usernames are ignored for authentication. This type of
 // authentication sends passwords in plaintext and should therefore only be
 // used if the APIaddr is localhost.
-func NewServer(APIaddr string, requiredUserAgent string, requiredPassword string, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) (*Server, error) {
+func NewServer(APIaddr string, requiredUserAgent string, requiredPassword string, cs modules.ConsensusSet, e modules.Explorer, g modules.Gateway, h modules.Host, lc modules.LightClient, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) (*Server, error) {
 	l, err := net.Listen("tcp", APIaddr)
 	if err != nil {
 		return nil, err
 	}
 
 	srv := &Server{
-		cs:       cs,
-		explorer: e,
-		gateway:  g,
-		host:     h,
-		miner:    m,
-		renter:   r,
-		tpool:    tp,
-		wallet:   w,
+		cs:          cs,
+		explorer:    e,
+		gateway:     g,
+		host:        h,
+		lightClient: lc,
+		miner:       m,
+		renter:      r,
+		tpool:       tp,
+		wallet:      w,
 
 		listener:          l,
 		requiredUserAgent: requiredUserAgent,
+		rateLimiter:       newRateLimiter(),
+		jobs:              newJobManager(),
+
+		shutdown: make(chan struct{}),
+		closed:   make(chan struct{}),
+	}
+
+	// Subscribe to the events of the modules.
+	srv.events, err = newEventHub(cs, h, w)
+	if err != nil {
+		l.Close()
+		return nil, err
 	}
 
 	// Register API handlers
@@ -67,45 +135,115 @@ func NewServer(APIaddr string, requiredUserAgent string, requiredPassword string
 	return srv, nil
 }
 
-// Serve listens for and handles API calls. It is a blocking function.
+// Serve listens for and handles API calls. It is a blocking function. If the
+// server is stopped by Close, by a call to /daemon/stop or by SIGINT or
+// SIGTERM, Serve does not return until all of the modules have been closed.
 func (srv *Server) Serve() error {
 	// Block the Close() method until Serve() has finished.
 	srv.wg.Add(1)
-	defer srv.wg.Done()
 
-	// stop the server if a kill signal is caught
+	// Stop the server if an interrupt or termination signal is caught. The
+	// signal handler is removed after the first signal, so that a second
+	// signal kills siad if shutting down takes too long.
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, os.Kill)
-	defer signal.Stop(sigChan)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	stop := make(chan struct{})
 	defer close(stop)
 	go func() {
 		select {
 		case <-sigChan:
+			signal.Stop(sigChan)
 			fmt.Println("\rCaught stop signal, quitting...")
 			srv.Close()
 		case <-stop:
 			// Don't leave a dangling goroutine.
+			signal.Stop(sigChan)
 		}
 	}()
 
-	// The server will run until an error is encountered or the listener is
-	// closed, via either the Close method or the signal handling above.
-	// Closing the listener will result in the benign error handled below.
+	// The server will run until an error is encountered or the server is
+	// shut down, via either the Close method or the signal handling above.
+	// Shutting down the server will result in the benign errors handled
+	// below.
 	err := srv.apiServer.Serve(srv.listener)
+	srv.wg.Done()
+	if err == http.ErrServerClosed {
+		// Wait for the modules to be closed, so that the caller does not
+		// exit before their state has been saved.
+		<-srv.closed
+		return nil
+	}
 	if err != nil && !strings.HasSuffix(err.Error(), "use of closed network connection") {
 		return err
 	}
 	return nil
 }
 
-// Close closes the Server's listener, causing the HTTP server to shut down.
+// Listen makes the server accept API calls on an additional address, such as
+// a second interface or port. Calls made to the additional address require the
+// same user agent and password as the primary address.
+func (srv *Server) Listen(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv.mu.Lock()
+	srv.extraListeners = append(srv.extraListeners, l)
+	srv.mu.Unlock()
+
+	srv.wg.Add(1)
+	go func() {
+		defer srv.wg.Done()
+		// Serve returns an error when the listener is closed by Close.
+		srv.apiServer.Serve(l)
+	}()
+	return nil
+}
+
+// Close shuts down the HTTP server and then closes each module, saving their
+// state to disk. API calls that are in progress are given shutdownTimeout to
+// complete. Close can be called more than once, and every call returns the
+// result of the first.
 func (srv *Server) Close() error {
+	srv.closeOnce.Do(func() {
+		srv.closeErr = srv.managedClose()
+		close(srv.closed)
+	})
+	<-srv.closed
+	return srv.closeErr
+}
+
+// managedClose stops the HTTP server and closes the modules.
+func (srv *Server) managedClose() error {
 	var errs []error
 
-	// Close the listener, which will cause Server.Serve() to return.
-	if err := srv.listener.Close(); err != nil {
-		errs = append(errs, fmt.Errorf("listener.Close failed: %v", err))
+	// Shutdown does not cancel the contexts of the calls in progress, so
+	// signal the calls that wait on the modules to return.
+	close(srv.shutdown)
+
+	// End the event streams, which would otherwise keep their calls in
+	// progress until the timeout.
+	srv.events.close(srv.cs, srv.host)
+
+	// Stop accepting API calls and wait for the calls in progress to
+	// complete. Shutdown closes the listeners, which will cause
+	// Server.Serve() to return.
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.apiServer.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("apiServer.Shutdown failed: %v", err))
+	}
+
+	// Shutdown only closes the listeners that are being served, so close the
+	// listeners explicitly in case Serve() was never called.
+	listeners := []net.Listener{srv.listener}
+	srv.mu.Lock()
+	listeners = append(listeners, srv.extraListeners...)
+	srv.mu.Unlock()
+	for _, l := range listeners {
+		if err := l.Close(); err != nil && !strings.HasSuffix(err.Error(), "use of closed network connection") {
+			errs = append(errs, fmt.Errorf("listener.Close failed: %v", err))
+		}
 	}
 
 	// Wait for Server.Serve() to exit. We wait so that it's guaranteed that the
@@ -113,7 +251,14 @@ func (srv *Server) Close() error {
 	// useful during testing so that we don't exit a test before Serve() finishes.
 	srv.wg.Wait()
 
-	// Safely close each module.
+	// Cancel the running jobs, and wait for them to return before closing
+	// the modules that they use.
+	if err := srv.jobs.close(shutdownTimeout); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Safely close each module. The modules are closed in the reverse order
+	// of their dependencies, so that no module is used after it is closed.
 	mods := []struct {
 		name string
 		c    io.Closer
@@ -124,6 +269,7 @@ func (srv *Server) Close() error {
 		{"miner", srv.miner},
 		{"wallet", srv.wallet},
 		{"tpool", srv.tpool},
+		{"lightclient", srv.lightClient},
 		{"consensus", srv.cs},
 		{"gateway", srv.gateway},
 	}
@@ -135,5 +281,14 @@ func (srv *Server) Close() error {
 		}
 	}
 
+	srv.mu.Lock()
+	if srv.auditLog != nil {
+		if err := srv.auditLog.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("auditLog.Close failed: %v", err))
+		}
+		srv.auditLog = nil
+	}
+	srv.mu.Unlock()
+
 	return build.JoinErrors(errs, "\n")
 }
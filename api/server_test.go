@@ -1,8 +1,13 @@
 package api
 
 import (
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"testing"
+	"time"
 )
 
 // TestExplorerPreset checks that the default configuration for the explorer is
@@ -129,3 +134,89 @@ func TestAuthentication(t *testing.T) {
 		t.Fatal("authenticated API call failed with the correct password")
 	}
 }
+
+// TestServerListen checks that a server serves the API on the additional
+// addresses added with Listen, with the same authentication, and that the
+// additional listeners are closed along with the server.
+func TestServerListen(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createAuthenticatedServerTester("TestServerListen", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.server.Listen("localhost:0"); err != nil {
+		t.Fatal(err)
+	}
+	testGETURL := "http://" + st.server.extraListeners[0].Addr().String() + "/wallet/seeds"
+
+	resp, err := HttpGET(testGETURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("unauthenticated API call succeeded on an additional address")
+	}
+	resp, err = HttpGETAuthenticated(testGETURL, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("authenticated API call failed on an additional address:", resp.Status)
+	}
+
+	if err := st.server.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := net.Dial("tcp", st.server.extraListeners[0].Addr().String()); err == nil {
+		t.Fatal("additional listener is still open after the server was closed")
+	}
+}
+
+// TestServerSignal checks that the server shuts down and closes the modules
+// when siad receives SIGTERM.
+func TestServerSignal(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	st, err := createServerTester("TestServerSignal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Make an API call to be sure that Serve is handling signals.
+	var dv DaemonVersion
+	if err := st.getAPI("/daemon/version", &dv); err != nil {
+		t.Fatal(err)
+	}
+
+	// Catch the signal in the test as well, so that the test binary is not
+	// killed if the server fails to handle it.
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Skip("unable to send SIGTERM:", err)
+	}
+	<-sigChan
+
+	select {
+	case <-st.server.closed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("server was not closed after SIGTERM")
+	}
+	if err := st.server.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.getAPI("/daemon/version", &dv); err == nil {
+		t.Fatal("API call succeeded after the server was stopped")
+	}
+}
@@ -12,6 +12,25 @@ type TransactionPoolGET struct {
 	Transactions []types.Transaction `json:"transactions"`
 }
 
+// TransactionPoolConflictedGET reports whether an unconfirmed transaction has
+// a competing spend.
+type TransactionPoolConflictedGET struct {
+	Conflicted bool `json:"conflicted"`
+}
+
+// transactionpoolConflictedHandler handles the API call to
+// /transactionpool/conflicted/:id.
+func (srv *Server) transactionpoolConflictedHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanHash(ps.ByName("id"))
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, TransactionPoolConflictedGET{
+		Conflicted: srv.tpool.Conflicted(types.TransactionID(id)),
+	})
+}
+
 // transactionpoolTransactionsHandler handles the API call to get the
 // transaction pool trasactions.
 func (srv *Server) transactionpoolTransactionsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
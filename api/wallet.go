@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
@@ -129,18 +130,18 @@ func (srv *Server) wallet033xHandler(w http.ResponseWriter, req *http.Request, _
 			return
 		}
 		if err != nil && err != modules.ErrBadEncryptionKey {
-			writeError(w, Error{"error when calling /wallet/033x: " + err.Error()}, http.StatusBadRequest)
+			writeError(w, Error{Message: "error when calling /wallet/033x: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 			return
 		}
 	}
-	writeError(w, Error{modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	writeError(w, Error{Message: modules.ErrBadEncryptionKey.Error(), Code: CodeBadEncryptionKey}, http.StatusBadRequest)
 }
 
 // walletAddressHandler handles API calls to /wallet/address.
 func (srv *Server) walletAddressHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	unlockConditions, err := srv.wallet.NextAddress()
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/addresses: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/addresses: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, WalletAddressGET{
@@ -159,7 +160,7 @@ func (srv *Server) walletAddressesHandler(w http.ResponseWriter, req *http.Reque
 func (srv *Server) walletBackupHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	err := srv.wallet.CreateBackup(req.FormValue("destination"))
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/backup: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/backup: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
@@ -173,7 +174,7 @@ func (srv *Server) walletInitHandler(w http.ResponseWriter, req *http.Request, _
 	}
 	seed, err := srv.wallet.Encrypt(encryptionKey)
 	if err != nil {
-		writeError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error when calling /wallet/init: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
@@ -183,7 +184,7 @@ func (srv *Server) walletInitHandler(w http.ResponseWriter, req *http.Request, _
 	}
 	seedStr, err := modules.SeedToString(seed, dictID)
 	if err != nil {
-		writeError(w, Error{"error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error when calling /wallet/init: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, WalletInitPOST{
@@ -200,7 +201,7 @@ func (srv *Server) walletSeedHandler(w http.ResponseWriter, req *http.Request, _
 	}
 	seed, err := modules.StringToSeed(req.FormValue("seed"), dictID)
 	if err != nil {
-		writeError(w, Error{"error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error when calling /wallet/seed: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
@@ -212,11 +213,11 @@ func (srv *Server) walletSeedHandler(w http.ResponseWriter, req *http.Request, _
 			return
 		}
 		if err != nil && err != modules.ErrBadEncryptionKey {
-			writeError(w, Error{"error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+			writeError(w, Error{Message: "error when calling /wallet/seed: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 			return
 		}
 	}
-	writeError(w, Error{"error when calling /wallet/seed: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	writeError(w, Error{Message: "error when calling /wallet/seed: " + modules.ErrBadEncryptionKey.Error(), Code: CodeBadEncryptionKey}, http.StatusBadRequest)
 }
 
 // walletSiagkeyHandler handles API calls to /wallet/siagkey.
@@ -231,18 +232,18 @@ func (srv *Server) walletSiagkeyHandler(w http.ResponseWriter, req *http.Request
 			return
 		}
 		if err != nil && err != modules.ErrBadEncryptionKey {
-			writeError(w, Error{"error when calling /wallet/siagkey: " + err.Error()}, http.StatusBadRequest)
+			writeError(w, Error{Message: "error when calling /wallet/siagkey: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 			return
 		}
 	}
-	writeError(w, Error{"error when calling /wallet/siagkey: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	writeError(w, Error{Message: "error when calling /wallet/siagkey: " + modules.ErrBadEncryptionKey.Error(), Code: CodeBadEncryptionKey}, http.StatusBadRequest)
 }
 
 // walletLockHanlder handles API calls to /wallet/lock.
 func (srv *Server) walletLockHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	err := srv.wallet.Lock()
 	if err != nil {
-		writeError(w, Error{err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	writeSuccess(w)
@@ -258,26 +259,26 @@ func (srv *Server) walletSeedsHandler(w http.ResponseWriter, req *http.Request,
 	// Get the primary seed information.
 	primarySeed, progress, err := srv.wallet.PrimarySeed()
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/seeds: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	primarySeedStr, err := modules.SeedToString(primarySeed, dictionary)
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/seeds: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
 	// Get the list of seeds known to the wallet.
 	allSeeds, err := srv.wallet.AllSeeds()
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/seeds: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	var allSeedsStrs []string
 	for _, seed := range allSeeds {
 		str, err := modules.SeedToString(seed, dictionary)
 		if err != nil {
-			writeError(w, Error{"error after call to /wallet/seeds: " + err.Error()}, http.StatusBadRequest)
+			writeError(w, Error{Message: "error after call to /wallet/seeds: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 			return
 		}
 		allSeedsStrs = append(allSeedsStrs, str)
@@ -293,18 +294,18 @@ func (srv *Server) walletSeedsHandler(w http.ResponseWriter, req *http.Request,
 func (srv *Server) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	amount, ok := scanAmount(req.FormValue("amount"))
 	if !ok {
-		writeError(w, Error{"could not read 'amount' from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+		writeError(w, Error{Message: "could not read 'amount' from POST call to /wallet/siacoins"}, http.StatusBadRequest)
 		return
 	}
 	dest, err := scanAddress(req.FormValue("destination"))
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/siacoins: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/siacoins: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
 	txns, err := srv.wallet.SendSiacoins(amount, dest)
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
+		writeError(w, Error{Message: "error after call to /wallet/siacoins: " + err.Error(), Code: errorCode(err)}, http.StatusInternalServerError)
 		return
 	}
 	var txids []types.TransactionID
@@ -320,18 +321,18 @@ func (srv *Server) walletSiacoinsHandler(w http.ResponseWriter, req *http.Reques
 func (srv *Server) walletSiafundsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	amount, ok := scanAmount(req.FormValue("amount"))
 	if !ok {
-		writeError(w, Error{"could not read 'amount' from POST call to /wallet/siafunds"}, http.StatusBadRequest)
+		writeError(w, Error{Message: "could not read 'amount' from POST call to /wallet/siafunds"}, http.StatusBadRequest)
 		return
 	}
 	dest, err := scanAddress(req.FormValue("destination"))
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/siafunds: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/siafunds: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
 	txns, err := srv.wallet.SendSiafunds(amount, dest)
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/siafunds: " + err.Error()}, http.StatusInternalServerError)
+		writeError(w, Error{Message: "error after call to /wallet/siafunds: " + err.Error(), Code: errorCode(err)}, http.StatusInternalServerError)
 		return
 	}
 	var txids []types.TransactionID
@@ -350,13 +351,13 @@ func (srv *Server) walletTransactionHandler(w http.ResponseWriter, req *http.Req
 	jsonID := "\"" + ps.ByName("id") + "\""
 	err := id.UnmarshalJSON([]byte(jsonID))
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/history: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/history: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
 	txn, ok := srv.wallet.Transaction(id)
 	if !ok {
-		writeError(w, Error{"error when calling /wallet/transaction/$(id): transaction not found"}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error when calling /wallet/transaction/$(id): transaction not found"}, http.StatusBadRequest)
 		return
 	}
 	writeJSON(w, WalletTransactionGETid{
@@ -364,70 +365,124 @@ func (srv *Server) walletTransactionHandler(w http.ResponseWriter, req *http.Req
 	})
 }
 
-// walletTransactionsHandler handles API calls to /wallet/transactions.
+// walletTransactionsHandler handles API calls to /wallet/transactions. The
+// confirmed transactions can be paged with 'offset' and 'limit'.
 func (srv *Server) walletTransactionsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	offset, limit, err := scanPage(req)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
 	startheightStr, endheightStr := req.FormValue("startheight"), req.FormValue("endheight")
 	if startheightStr == "" || endheightStr == "" {
-		writeError(w, Error{"startheight and endheight must be provided to a /wallet/transactions call."}, http.StatusBadRequest)
+		writeError(w, Error{Message: "startheight and endheight must be provided to a /wallet/transactions call."}, http.StatusBadRequest)
 		return
 	}
 	// Get the start and end blocks.
 	start, err := strconv.Atoi(startheightStr)
 	if err != nil {
-		writeError(w, Error{"parsing integer value for parameter `startheight` failed: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "parsing integer value for parameter `startheight` failed: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
 	end, err := strconv.Atoi(endheightStr)
 	if err != nil {
-		writeError(w, Error{"parsing integer value for parameter `endheight` failed: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "parsing integer value for parameter `endheight` failed: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
 	confirmedTxns, err := srv.wallet.Transactions(types.BlockHeight(start), types.BlockHeight(end))
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/transactions: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 	unconfirmedTxns := srv.wallet.UnconfirmedTransactions()
 
+	pageStart, pageEnd := pageBounds(len(confirmedTxns), offset, limit)
 	writeJSON(w, WalletTransactionsGET{
-		ConfirmedTransactions:   confirmedTxns,
+		ConfirmedTransactions:   confirmedTxns[pageStart:pageEnd],
 		UnconfirmedTransactions: unconfirmedTxns,
 	})
 }
 
 // walletTransactionsAddrHandler handles API calls to
-// /wallet/transactions/:addr.
+// /wallet/transactions/:addr. The confirmed transactions can be paged with
+// 'offset' and 'limit'.
 func (srv *Server) walletTransactionsAddrHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	offset, limit, err := scanPage(req)
+	if err != nil {
+		writeError(w, Error{Message: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
 	// Parse the address being input.
 	jsonAddr := "\"" + ps.ByName("addr") + "\""
 	var addr types.UnlockHash
-	err := addr.UnmarshalJSON([]byte(jsonAddr))
+	err = addr.UnmarshalJSON([]byte(jsonAddr))
 	if err != nil {
-		writeError(w, Error{"error after call to /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+		writeError(w, Error{Message: "error after call to /wallet/transactions: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
 		return
 	}
 
 	confirmedATs := srv.wallet.AddressTransactions(addr)
 	unconfirmedATs := srv.wallet.AddressUnconfirmedTransactions(addr)
+	start, end := pageBounds(len(confirmedATs), offset, limit)
 	writeJSON(w, WalletTransactionsGETaddr{
-		ConfirmedTransactions:   confirmedATs,
+		ConfirmedTransactions:   confirmedATs[start:end],
 		UnconfirmedTransactions: unconfirmedATs,
 	})
 }
 
-// walletUnlockHandler handles API calls to /wallet/unlock.
-func (srv *Server) walletUnlockHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
-	potentialKeys := encryptionKeys(req.FormValue("encryptionpassword"))
-	for _, key := range potentialKeys {
+// managedUnlockWallet unlocks the wallet with the first of 'keys' that is
+// correct. While the wallet rescans the blockchain, which it does the first
+// time it is unlocked, its scanned height is reported through 'progress'.
+func (srv *Server) managedUnlockWallet(keys []crypto.TwofishKey, progress func(done, total uint64)) error {
+	report := func() {
+		scanned, height := uint64(srv.wallet.Height()), uint64(srv.cs.Height())
+		if scanned > height {
+			scanned = height
+		}
+		progress(scanned, height)
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(jobProgressInterval):
+				report()
+			}
+		}
+	}()
+
+	for _, key := range keys {
 		err := srv.wallet.Unlock(key)
 		if err == nil {
-			writeSuccess(w)
-			return
+			report()
+			return nil
 		}
-		if err != nil && err != modules.ErrBadEncryptionKey {
-			writeError(w, Error{"error when calling /wallet/unlock: " + err.Error()}, http.StatusBadRequest)
-			return
+		if err != modules.ErrBadEncryptionKey {
+			return err
 		}
 	}
-	writeError(w, Error{"error when calling /wallet/unlock: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+	return modules.ErrBadEncryptionKey
+}
+
+// walletUnlockHandler handles API calls to /wallet/unlock. If 'async' is true,
+// the wallet is unlocked by a job, so that the progress of the rescan can be
+// followed.
+func (srv *Server) walletUnlockHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	potentialKeys := encryptionKeys(req.FormValue("encryptionpassword"))
+	if req.FormValue("async") == "true" {
+		srv.startJob(w, "walletunlock", false, func(_ <-chan struct{}, progress func(done, total uint64)) (interface{}, error) {
+			return nil, srv.managedUnlockWallet(potentialKeys, progress)
+		})
+		return
+	}
+	err := srv.managedUnlockWallet(potentialKeys, func(uint64, uint64) {})
+	if err != nil {
+		writeError(w, Error{Message: "error when calling /wallet/unlock: " + err.Error(), Code: errorCode(err)}, http.StatusBadRequest)
+		return
+	}
+	writeSuccess(w)
 }
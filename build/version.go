@@ -7,7 +7,12 @@ import (
 
 const (
 	// Version is the current version of siad.
-	Version = "1.0.0"
+	Version = "1.0.1"
+
+	// ProtocolVersion is the version of the peer-to-peer protocol spoken by
+	// siad. It is the version of siad that last changed the protocol, so it
+	// only increases when peers need to handle a change to the protocol.
+	ProtocolVersion = "1.0.1"
 
 	// MaxEncodedVersionLength is the maximum length of a version string encoded
 	// with the encode package. 100 is much larger than any version number we send
@@ -17,6 +22,10 @@ const (
 	MaxEncodedVersionLength = 100
 )
 
+// GitRevision is the git commit that siad was built from. It is set by the
+// Makefile with -ldflags, and is empty for binaries built without it.
+var GitRevision string
+
 // IsVersion returns whether str is a valid version number.
 func IsVersion(str string) bool {
 	for _, n := range strings.Split(str, ".") {
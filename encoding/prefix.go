@@ -5,17 +5,80 @@ import (
 	"io"
 )
 
+// A MalformedError is returned by ReadPrefix and ReadObject when the data read
+// is not a valid encoding, either because its length prefix exceeds the
+// maximum length or because it cannot be decoded into the object. Unlike the
+// errors of the underlying reader, it means that the sender misbehaved, rather
+// than that the connection failed.
+type MalformedError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *MalformedError) Error() string {
+	return e.Err.Error()
+}
+
+// IsMalformed returns true if err is a *MalformedError.
+func IsMalformed(err error) bool {
+	_, ok := err.(*MalformedError)
+	return ok
+}
+
+const (
+	// errorPrefix is the length prefix that marks a RemoteError. No object
+	// can have this length, so the marker is never mistaken for the prefix
+	// of an object.
+	errorPrefix = ^uint64(0)
+
+	// maxErrorLen is the maximum length of the message of a RemoteError.
+	maxErrorLen = 1 << 10
+)
+
+// A RemoteError is returned by ReadPrefix and ReadObject when the other end of
+// the connection wrote an error with WriteError instead of the expected
+// object, typically because it rejected a malformed request.
+type RemoteError struct {
+	Msg string
+}
+
+// Error implements the error interface.
+func (e *RemoteError) Error() string {
+	return "remote peer returned an error: " + e.Msg
+}
+
+// IsRemote returns true if err is a *RemoteError.
+func IsRemote(err error) bool {
+	_, ok := err.(*RemoteError)
+	return ok
+}
+
 // ReadPrefix reads an 8-byte length prefixes, followed by the number of bytes
 // specified in the prefix. The operation is aborted if the prefix exceeds a
-// specified maximum length.
+// specified maximum length. If the other end wrote an error with WriteError,
+// a *RemoteError is returned.
 func ReadPrefix(r io.Reader, maxLen uint64) ([]byte, error) {
 	prefix := make([]byte, 8)
 	if _, err := io.ReadFull(r, prefix); err != nil {
 		return nil, err
 	}
 	dataLen := DecUint64(prefix)
+	if dataLen == errorPrefix {
+		if _, err := io.ReadFull(r, prefix); err != nil {
+			return nil, err
+		}
+		msgLen := DecUint64(prefix)
+		if msgLen > maxErrorLen {
+			return nil, &MalformedError{fmt.Errorf("error length %d exceeds maxLen of %d", msgLen, maxErrorLen)}
+		}
+		msg := make([]byte, msgLen)
+		if _, err := io.ReadFull(r, msg); err != nil {
+			return nil, err
+		}
+		return nil, &RemoteError{string(msg)}
+	}
 	if dataLen > maxLen {
-		return nil, fmt.Errorf("length %d exceeds maxLen of %d", dataLen, maxLen)
+		return nil, &MalformedError{fmt.Errorf("length %d exceeds maxLen of %d", dataLen, maxLen)}
 	}
 	// read dataLen bytes
 	data := make([]byte, dataLen)
@@ -29,7 +92,10 @@ func ReadObject(r io.Reader, obj interface{}, maxLen uint64) error {
 	if err != nil {
 		return err
 	}
-	return Unmarshal(data, obj)
+	if err := Unmarshal(data, obj); err != nil {
+		return &MalformedError{err}
+	}
+	return nil
 }
 
 // WritePrefix writes a length-prefixed byte slice to w.
@@ -51,3 +117,17 @@ func WritePrefix(w io.Writer, data []byte) error {
 func WriteObject(w io.Writer, v interface{}) error {
 	return WritePrefix(w, Marshal(v))
 }
+
+// WriteError writes an error to w in place of the object that the other end
+// expects, which will receive it as a *RemoteError. Messages longer than
+// maxErrorLen are truncated.
+func WriteError(w io.Writer, err error) error {
+	msg := err.Error()
+	if len(msg) > maxErrorLen {
+		msg = msg[:maxErrorLen]
+	}
+	if _, err := w.Write(EncUint64(errorPrefix)); err != nil {
+		return err
+	}
+	return WritePrefix(w, []byte(msg))
+}
@@ -54,6 +54,8 @@ func TestReadPrefix(t *testing.T) {
 	_, err = ReadPrefix(b, 3)
 	if err == nil || err.Error() != "length 4 exceeds maxLen of 3" {
 		t.Error("expected maxLen error, got", err)
+	} else if !IsMalformed(err) {
+		t.Error("maxLen error is not a MalformedError")
 	}
 
 	// no data after length prefix
@@ -91,6 +93,8 @@ func TestReadObject(t *testing.T) {
 	err = ReadObject(b, &obj, 3)
 	if err == nil || err.Error() != "could not decode type string: "+io.ErrUnexpectedEOF.Error() {
 		t.Error("expected unexpected EOF, got", err)
+	} else if !IsMalformed(err) {
+		t.Error("decoding error is not a MalformedError")
 	}
 }
 
@@ -164,3 +168,37 @@ func TestReadWritePrefix(t *testing.T) {
 		t.Errorf("read/write mismatch: wrote %s, read %s", obj, robj)
 	}
 }
+
+// TestWriteError checks that errors written with WriteError are read as a
+// *RemoteError in place of an object.
+func TestWriteError(t *testing.T) {
+	b := new(bytes.Buffer)
+	if err := WriteError(b, io.ErrUnexpectedEOF); err != nil {
+		t.Fatal(err)
+	}
+	var obj string
+	err := ReadObject(b, &obj, 100)
+	if re, ok := err.(*RemoteError); !ok || re.Msg != io.ErrUnexpectedEOF.Error() {
+		t.Fatal("expected a RemoteError, got", err)
+	}
+	if IsMalformed(err) || !IsRemote(err) {
+		t.Error("RemoteError was misclassified")
+	}
+
+	// Long messages are truncated.
+	b.Reset()
+	if err := WriteError(b, &RemoteError{string(make([]byte, 2*maxErrorLen))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPrefix(b, 0); !IsRemote(err) || len(err.(*RemoteError).Msg) != maxErrorLen {
+		t.Error("expected a truncated RemoteError, got", err)
+	}
+
+	// Error messages that exceed maxErrorLen are malformed.
+	b.Reset()
+	b.Write(EncUint64(errorPrefix))
+	b.Write(EncUint64(maxErrorLen + 1))
+	if _, err := ReadPrefix(b, 0); !IsMalformed(err) {
+		t.Error("expected a MalformedError, got", err)
+	}
+}
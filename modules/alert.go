@@ -0,0 +1,144 @@
+package modules
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// SeverityInfo alerts report events that do not require any action.
+	SeverityInfo AlertSeverity = "info"
+
+	// SeverityWarning alerts report events that may require the attention of
+	// the operator.
+	SeverityWarning AlertSeverity = "warning"
+
+	// SeverityCritical alerts report events that require immediate action,
+	// such as events that may cause the loss of funds.
+	SeverityCritical AlertSeverity = "critical"
+)
+
+var (
+	// ErrAlertNotActive is returned when clearing an alert that has not been
+	// raised.
+	ErrAlertNotActive = errors.New("no active alert has that id")
+)
+
+var (
+	// activeAlerts contains the conditions that the modules have raised and
+	// that have not been cleared, indexed by their ID. Like the blocklist,
+	// they are kept in memory only, and are shared by every module of the
+	// process.
+	activeAlerts     = make(map[string]Alert)
+	alertSubscribers []AlertSubscriber
+	alertsMu         sync.Mutex
+)
+
+type (
+	// AlertSeverity indicates how urgently an alert should be handled.
+	AlertSeverity string
+
+	// An Alert is a notable event that a module wants to bring to the
+	// attention of the operator of the node. Alerts that are raised with
+	// RaiseAlert report an ongoing condition, and have an ID that stays the
+	// same for as long as the condition lasts.
+	Alert struct {
+		ID       string        `json:"id,omitempty"`
+		Module   string        `json:"module"`
+		Severity AlertSeverity `json:"severity"`
+		Msg      string        `json:"msg"`
+		Time     time.Time     `json:"time"`
+	}
+
+	// An Alerter is a module that raises alerts.
+	Alerter interface {
+		// Alerts returns the most recent alerts raised by the module, oldest
+		// first.
+		Alerts() []Alert
+	}
+
+	// An AlertSubscriber is notified when an alert is raised or cleared.
+	AlertSubscriber interface {
+		// ReceiveAlert is called with each alert that is raised, with
+		// 'active' set to true, and with each alert that is cleared, with
+		// 'active' set to false. It is called while the alert registry is
+		// locked, and must not block or raise or clear alerts.
+		ReceiveAlert(a Alert, active bool)
+	}
+)
+
+// RaiseAlert records that the condition described by 'a' is ongoing. The
+// alert stays active until it is cleared with ClearAlert, either by the
+// module once the condition is resolved or by the operator. Raising an alert
+// that is already active with the same severity and message has no effect,
+// so that modules can raise an alert each time they find the condition. The
+// time of the alert is set to the current time if it is zero.
+func RaiseAlert(a Alert) {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+	alertsMu.Lock()
+	defer alertsMu.Unlock()
+	if old, exists := activeAlerts[a.ID]; exists && old.Severity == a.Severity && old.Msg == a.Msg {
+		return
+	}
+	activeAlerts[a.ID] = a
+	for _, s := range alertSubscribers {
+		s.ReceiveAlert(a, true)
+	}
+}
+
+// ClearAlert marks the condition of the alert with the given ID as resolved.
+func ClearAlert(id string) error {
+	alertsMu.Lock()
+	defer alertsMu.Unlock()
+	a, exists := activeAlerts[id]
+	if !exists {
+		return ErrAlertNotActive
+	}
+	delete(activeAlerts, id)
+	for _, s := range alertSubscribers {
+		s.ReceiveAlert(a, false)
+	}
+	return nil
+}
+
+// ActiveAlerts returns the alerts that have been raised and not cleared,
+// oldest first.
+func ActiveAlerts() []Alert {
+	alertsMu.Lock()
+	defer alertsMu.Unlock()
+	alerts := make([]Alert, 0, len(activeAlerts))
+	for _, a := range activeAlerts {
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Time.Equal(alerts[j].Time) {
+			return alerts[i].ID < alerts[j].ID
+		}
+		return alerts[i].Time.Before(alerts[j].Time)
+	})
+	return alerts
+}
+
+// AlertSubscribe adds a subscriber that is notified of each alert that is
+// raised or cleared.
+func AlertSubscribe(s AlertSubscriber) {
+	alertsMu.Lock()
+	defer alertsMu.Unlock()
+	alertSubscribers = append(alertSubscribers, s)
+}
+
+// AlertUnsubscribe removes a subscriber added with AlertSubscribe.
+func AlertUnsubscribe(s AlertSubscriber) {
+	alertsMu.Lock()
+	defer alertsMu.Unlock()
+	for i := range alertSubscribers {
+		if alertSubscribers[i] == s {
+			alertSubscribers = append(alertSubscribers[:i], alertSubscribers[i+1:]...)
+			return
+		}
+	}
+}
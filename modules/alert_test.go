@@ -0,0 +1,67 @@
+package modules
+
+import (
+	"testing"
+)
+
+// alertRecorder is an AlertSubscriber that records the alerts it receives.
+type alertRecorder struct {
+	raised  []Alert
+	cleared []Alert
+}
+
+// ReceiveAlert records an alert that was raised or cleared.
+func (ar *alertRecorder) ReceiveAlert(a Alert, active bool) {
+	if active {
+		ar.raised = append(ar.raised, a)
+	} else {
+		ar.cleared = append(ar.cleared, a)
+	}
+}
+
+// TestAlerts checks that alerts stay active until they are cleared, and that
+// subscribers are notified of each change.
+func TestAlerts(t *testing.T) {
+	ar := new(alertRecorder)
+	AlertSubscribe(ar)
+	defer AlertUnsubscribe(ar)
+
+	RaiseAlert(Alert{ID: "test.first", Module: "test", Severity: SeverityWarning, Msg: "first"})
+	RaiseAlert(Alert{ID: "test.second", Module: "test", Severity: SeverityCritical, Msg: "second"})
+	// Raising an active alert again has no effect, unless it changed.
+	RaiseAlert(Alert{ID: "test.first", Module: "test", Severity: SeverityWarning, Msg: "first"})
+	if len(ar.raised) != 2 {
+		t.Fatal("expected 2 raised alerts, got", ar.raised)
+	}
+	RaiseAlert(Alert{ID: "test.first", Module: "test", Severity: SeverityWarning, Msg: "changed"})
+	if len(ar.raised) != 3 || ar.raised[2].Msg != "changed" || ar.raised[2].Time.IsZero() {
+		t.Fatal("changed alert was not raised:", ar.raised)
+	}
+
+	alerts := ActiveAlerts()
+	if len(alerts) != 2 || alerts[0].ID != "test.second" || alerts[1].Msg != "changed" {
+		t.Fatal("wrong active alerts:", alerts)
+	}
+
+	if err := ClearAlert("test.first"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ClearAlert("test.first"); err != ErrAlertNotActive {
+		t.Fatal("expected ErrAlertNotActive, got", err)
+	}
+	if len(ar.cleared) != 1 || ar.cleared[0].ID != "test.first" {
+		t.Fatal("wrong cleared alerts:", ar.cleared)
+	}
+	if alerts := ActiveAlerts(); len(alerts) != 1 || alerts[0].ID != "test.second" {
+		t.Fatal("wrong active alerts after clearing:", alerts)
+	}
+
+	// Unsubscribed subscribers are not notified.
+	AlertUnsubscribe(ar)
+	if err := ClearAlert("test.second"); err != nil {
+		t.Fatal(err)
+	}
+	if len(ar.cleared) != 1 {
+		t.Fatal("unsubscribed subscriber was notified")
+	}
+}
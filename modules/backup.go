@@ -0,0 +1,200 @@
+package modules
+
+// backup.go contains the format of the backups written by the /daemon/backup
+// API call and restored by 'siad --restore'. A backup is a gzipped tar archive
+// of the metadata of the wallet, renter, and host, laid out as in the sia
+// directory, and encrypted with a key derived from a password.
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// backupManifestFilename is the name of the file in a backup archive that
+	// holds the BackupManifest.
+	backupManifestFilename = "manifest.json"
+)
+
+var (
+	// ErrBackupDecrypt is returned by ReadBackup if the backup cannot be
+	// decrypted with the key.
+	ErrBackupDecrypt = errors.New("unable to decrypt backup: wrong password or corrupted file")
+
+	errBackupHeader   = errors.New("file is not a Sia backup")
+	errBackupManifest = errors.New("backup does not contain a manifest")
+	errBackupPath     = errors.New("backup contains a file outside of the sia directory")
+
+	// backupHeader is written at the start of every backup.
+	backupHeader = types.Specifier{'S', 'i', 'a', ' ', 'B', 'a', 'c', 'k', 'u', 'p'}
+)
+
+// A BackupManifest describes the contents of a backup.
+type BackupManifest struct {
+	// Modules lists the directories of the modules whose metadata is in the
+	// backup, such as WalletDir.
+	Modules []string          `json:"modules"`
+	Height  types.BlockHeight `json:"height"`
+	Created time.Time         `json:"created"`
+}
+
+// BackupKey derives the key that a backup is encrypted with from a password.
+// The password is hashed together with a constant so that the key differs
+// from the wallet key even if the wallet has the same password.
+func BackupKey(password string) crypto.TwofishKey {
+	return crypto.TwofishKey(crypto.HashAll("backup", password))
+}
+
+// WriteBackup writes every file in 'dir' to 'w' as a backup with the given
+// manifest, encrypted with 'key'.
+func WriteBackup(w io.Writer, dir string, manifest BackupManifest, key crypto.TwofishKey) error {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+
+	m, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(&tar.Header{
+		Name:    backupManifestFilename,
+		Mode:    0600,
+		Size:    int64(len(m)),
+		ModTime: manifest.Created,
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := tw.Write(m); err != nil {
+		return err
+	}
+
+	err = filepath.Walk(dir, func(filename string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, filename)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		f, err := os.Open(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	ct, err := key.EncryptBytes(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(backupHeader[:]); err != nil {
+		return err
+	}
+	_, err = w.Write(ct)
+	return err
+}
+
+// ReadBackup decrypts the backup read from 'r' with 'key' and writes its
+// files to 'dir', which is normally the sia directory. No file is written if
+// the backup would overwrite an existing file, so that a backup cannot be
+// mixed with the files of another wallet, renter, or host.
+func ReadBackup(r io.Reader, dir string, key crypto.TwofishKey) (BackupManifest, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	if len(b) < len(backupHeader) || !bytes.Equal(b[:len(backupHeader)], backupHeader[:]) {
+		return BackupManifest{}, errBackupHeader
+	}
+	plaintext, err := key.DecryptBytes(b[len(backupHeader):])
+	if err != nil {
+		return BackupManifest{}, ErrBackupDecrypt
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(plaintext))
+	if err != nil {
+		return BackupManifest{}, err
+	}
+	tr := tar.NewReader(gz)
+
+	// Read every file before writing any of them, so that a backup that
+	// cannot be restored leaves 'dir' untouched.
+	var manifest BackupManifest
+	var haveManifest bool
+	files := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return BackupManifest{}, err
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return BackupManifest{}, err
+		}
+		if hdr.Name == backupManifestFilename {
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				return BackupManifest{}, err
+			}
+			haveManifest = true
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return BackupManifest{}, errBackupPath
+		}
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if _, err := os.Stat(filename); err == nil {
+			return BackupManifest{}, errors.New("restoring the backup would overwrite " + filename)
+		} else if !os.IsNotExist(err) {
+			return BackupManifest{}, err
+		}
+		files[filename] = data
+	}
+	if !haveManifest {
+		return BackupManifest{}, errBackupManifest
+	}
+
+	for filename, data := range files {
+		if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+			return BackupManifest{}, err
+		}
+		if err := ioutil.WriteFile(filename, data, 0600); err != nil {
+			return BackupManifest{}, err
+		}
+	}
+	return manifest, nil
+}
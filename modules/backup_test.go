@@ -0,0 +1,115 @@
+package modules
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+// TestBackup checks that a backup restores the files that it was written
+// from, and that it cannot be restored with the wrong password or over
+// existing files.
+func TestBackup(t *testing.T) {
+	dir := build.TempDir("modules", "TestBackup")
+	src := filepath.Join(dir, "src")
+	files := map[string]string{
+		filepath.Join(WalletDir, "wallet.json"):           "seeds",
+		filepath.Join(RenterDir, "photos", "cat.jpg.sia"): "file",
+		filepath.Join(HostDir, "host.db"):                 "obligations",
+	}
+	for name, contents := range files {
+		filename := filepath.Join(src, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	manifest := BackupManifest{
+		Modules: []string{WalletDir, RenterDir, HostDir},
+		Height:  7,
+	}
+	buf := new(bytes.Buffer)
+	if err := WriteBackup(buf, src, manifest, BackupKey("password")); err != nil {
+		t.Fatal(err)
+	}
+	backup := buf.Bytes()
+	if bytes.Contains(backup, []byte("obligations")) {
+		t.Fatal("backup is not encrypted")
+	}
+
+	dst := filepath.Join(dir, "dst")
+	if _, err := ReadBackup(bytes.NewReader(backup), dst, BackupKey("wrong")); err != ErrBackupDecrypt {
+		t.Fatal("expected ErrBackupDecrypt, got", err)
+	}
+	if _, err := ReadBackup(bytes.NewReader([]byte("garbage")), dst, BackupKey("password")); err != errBackupHeader {
+		t.Fatal("expected errBackupHeader, got", err)
+	}
+	m, err := ReadBackup(bytes.NewReader(backup), dst, BackupKey("password"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Height != manifest.Height || len(m.Modules) != 3 || m.Modules[1] != RenterDir {
+		t.Fatal("wrong manifest:", m)
+	}
+	for name, contents := range files {
+		b, err := ioutil.ReadFile(filepath.Join(dst, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != contents {
+			t.Fatalf("wrong contents of %v: %q", name, b)
+		}
+	}
+
+	// Restoring over the restored files fails without writing anything.
+	if err := os.Remove(filepath.Join(dst, WalletDir, "wallet.json")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBackup(bytes.NewReader(backup), dst, BackupKey("password")); err == nil {
+		t.Fatal("backup overwrote existing files")
+	}
+	if _, err := os.Stat(filepath.Join(dst, WalletDir, "wallet.json")); !os.IsNotExist(err) {
+		t.Fatal("failed restore wrote a file:", err)
+	}
+}
+
+// TestBackupPath checks that a backup cannot write files outside of the
+// directory that it is restored to.
+func TestBackupPath(t *testing.T) {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range []string{backupManifestFilename, "../evil"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: 2}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+	key := BackupKey("password")
+	ct, err := key.EncryptBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := build.TempDir("modules", "TestBackupPath")
+	backup := append(backupHeader[:], ct...)
+	if _, err := ReadBackup(bytes.NewReader(backup), filepath.Join(dir, "dst"), key); err != errBackupPath {
+		t.Fatal("expected errBackupPath, got", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil")); !os.IsNotExist(err) {
+		t.Fatal("backup wrote a file outside of the directory")
+	}
+}
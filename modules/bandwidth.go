@@ -0,0 +1,129 @@
+package modules
+
+import (
+	"net"
+	"sync/atomic"
+)
+
+// A BandwidthCategory is the purpose that bandwidth was used for.
+type BandwidthCategory string
+
+const (
+	// BandwidthBlockSync is used to download and relay blocks and headers.
+	BandwidthBlockSync BandwidthCategory = "blocksync"
+
+	// BandwidthTransactionRelay is used to relay unconfirmed transactions.
+	BandwidthTransactionRelay BandwidthCategory = "transactionrelay"
+
+	// BandwidthUploads is used to upload data to hosts, which is counted by
+	// both the renter and the host.
+	BandwidthUploads BandwidthCategory = "uploads"
+
+	// BandwidthDownloads is used to download data from hosts, which is
+	// counted by both the renter and the host.
+	BandwidthDownloads BandwidthCategory = "downloads"
+
+	// BandwidthOther is any bandwidth used for another purpose, such as
+	// sharing nodes or forming contracts.
+	BandwidthOther BandwidthCategory = "other"
+)
+
+// BandwidthCategories lists every BandwidthCategory.
+var BandwidthCategories = []BandwidthCategory{
+	BandwidthBlockSync,
+	BandwidthTransactionRelay,
+	BandwidthUploads,
+	BandwidthDownloads,
+	BandwidthOther,
+}
+
+// BandwidthUsage is the number of bytes sent and received for a purpose since
+// the daemon started.
+type BandwidthUsage struct {
+	Category BandwidthCategory `json:"category"`
+	Sent     uint64            `json:"sent"`
+	Received uint64            `json:"received"`
+}
+
+// bandwidthCounter holds the totals of a BandwidthCategory. It is only
+// accessed atomically.
+type bandwidthCounter struct {
+	sent     uint64
+	received uint64
+}
+
+// bandwidthCounters holds the totals of every BandwidthCategory. It is never
+// modified after initialization, so it is not protected by a mutex.
+var bandwidthCounters = func() map[BandwidthCategory]*bandwidthCounter {
+	counters := make(map[BandwidthCategory]*bandwidthCounter)
+	for _, c := range BandwidthCategories {
+		counters[c] = new(bandwidthCounter)
+	}
+	return counters
+}()
+
+// counter returns the counter of a BandwidthCategory. Unknown categories are
+// counted as BandwidthOther.
+func (c BandwidthCategory) counter() *bandwidthCounter {
+	if bc, ok := bandwidthCounters[c]; ok {
+		return bc
+	}
+	return bandwidthCounters[BandwidthOther]
+}
+
+// BandwidthTotals returns the bandwidth used for each purpose since the
+// daemon started, in the order of BandwidthCategories. The totals include the
+// peer-to-peer traffic of the gateway and the traffic between renters and
+// hosts.
+func BandwidthTotals() []BandwidthUsage {
+	totals := make([]BandwidthUsage, len(BandwidthCategories))
+	for i, c := range BandwidthCategories {
+		bc := c.counter()
+		totals[i] = BandwidthUsage{
+			Category: c,
+			Sent:     atomic.LoadUint64(&bc.sent),
+			Received: atomic.LoadUint64(&bc.received),
+		}
+	}
+	return totals
+}
+
+// A BandwidthConn is a net.Conn that counts the bytes it sends and receives
+// towards a BandwidthCategory.
+type BandwidthConn struct {
+	net.Conn
+
+	// counter is the *bandwidthCounter of the connection's category. It is
+	// accessed atomically, because the category may change while another
+	// goroutine uses the connection.
+	counter atomic.Value
+}
+
+// NewBandwidthConn wraps a connection so that its traffic is counted towards
+// 'category'.
+func NewBandwidthConn(conn net.Conn, category BandwidthCategory) *BandwidthConn {
+	bc := &BandwidthConn{Conn: conn}
+	bc.SetCategory(category)
+	return bc
+}
+
+// SetCategory changes the category that the connection's traffic is counted
+// towards. It is used when the purpose of a connection is only known after
+// reading a request from it.
+func (bc *BandwidthConn) SetCategory(category BandwidthCategory) {
+	bc.counter.Store(category.counter())
+}
+
+// Read reads data from the connection, counting the bytes received.
+func (bc *BandwidthConn) Read(p []byte) (int, error) {
+	n, err := bc.Conn.Read(p)
+	atomic.AddUint64(&bc.counter.Load().(*bandwidthCounter).received, uint64(n))
+	return n, err
+}
+
+// Write writes data to the connection, counting the bytes sent.
+func (bc *BandwidthConn) Write(p []byte) (int, error) {
+	n, err := bc.Conn.Write(p)
+	atomic.AddUint64(&bc.counter.Load().(*bandwidthCounter).sent, uint64(n))
+	return n, err
+}
@@ -0,0 +1,80 @@
+package modules
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+// bandwidthUsage returns the current totals of a BandwidthCategory.
+func bandwidthUsage(c BandwidthCategory) BandwidthUsage {
+	for _, usage := range BandwidthTotals() {
+		if usage.Category == c {
+			return usage
+		}
+	}
+	return BandwidthUsage{}
+}
+
+// TestBandwidthConn checks that a BandwidthConn counts the bytes it sends and
+// receives towards its category.
+func TestBandwidthConn(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	bc := NewBandwidthConn(c1, BandwidthTransactionRelay)
+
+	before := bandwidthUsage(BandwidthTransactionRelay)
+	go c2.Write(make([]byte, 100))
+	if _, err := io.ReadFull(bc, make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+	go io.ReadFull(c2, make([]byte, 50))
+	if _, err := bc.Write(make([]byte, 50)); err != nil {
+		t.Fatal(err)
+	}
+	after := bandwidthUsage(BandwidthTransactionRelay)
+	if after.Received-before.Received < 100 || after.Sent-before.Sent < 50 {
+		t.Fatal("traffic was not counted:", before, after)
+	}
+
+	// After changing the category, traffic is counted towards the new
+	// category.
+	bc.SetCategory(BandwidthDownloads)
+	before = bandwidthUsage(BandwidthDownloads)
+	go io.ReadFull(c2, make([]byte, 70))
+	if _, err := bc.Write(make([]byte, 70)); err != nil {
+		t.Fatal(err)
+	}
+	after = bandwidthUsage(BandwidthDownloads)
+	if after.Sent-before.Sent < 70 {
+		t.Fatal("traffic was not counted towards the new category:", before, after)
+	}
+}
+
+// TestBandwidthTotals checks that BandwidthTotals lists every category, and
+// that unknown categories are counted as BandwidthOther.
+func TestBandwidthTotals(t *testing.T) {
+	totals := BandwidthTotals()
+	if len(totals) != len(BandwidthCategories) {
+		t.Fatal("wrong number of categories:", len(totals))
+	}
+	for i, usage := range totals {
+		if usage.Category != BandwidthCategories[i] {
+			t.Fatal("categories are in the wrong order:", totals)
+		}
+	}
+
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	bc := NewBandwidthConn(c1, BandwidthCategory("unknown"))
+	before := bandwidthUsage(BandwidthOther)
+	go io.ReadFull(c2, make([]byte, 10))
+	if _, err := bc.Write(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if after := bandwidthUsage(BandwidthOther); after.Sent-before.Sent < 10 {
+		t.Fatal("unknown category was not counted as other:", before, after)
+	}
+}
@@ -0,0 +1,113 @@
+package modules
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+var (
+	errRangeNotBlocked = errors.New("IP range is not blocked")
+	errInvalidIPRange  = errors.New("IP range must be an IP address or a CIDR range")
+)
+
+// A Blocklist contains the IP ranges that are not allowed to connect to the
+// gateway, the host, or the relay. A Blocklist can be shared by several
+// modules, so that blocking a range applies to all of them. It is kept in
+// memory only. A nil Blocklist does not block any addresses.
+type Blocklist struct {
+	ranges []*net.IPNet
+	mu     sync.RWMutex
+}
+
+// NewBlocklist returns an empty Blocklist.
+func NewBlocklist() *Blocklist {
+	return new(Blocklist)
+}
+
+// parseIPRange parses an IP address or a CIDR range, such as "10.0.0.0/8". A
+// single IP address is treated as a range containing only that address.
+func parseIPRange(r string) (*net.IPNet, error) {
+	if _, ipnet, err := net.ParseCIDR(r); err == nil {
+		return ipnet, nil
+	}
+	ip := net.ParseIP(r)
+	if ip == nil {
+		return nil, errInvalidIPRange
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(8*net.IPv4len, 8*net.IPv4len)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(8*net.IPv6len, 8*net.IPv6len)}, nil
+}
+
+// Block adds an IP address or CIDR range to the blocklist. Peers and renters
+// connecting from a blocked address are rejected, and the gateway will not
+// connect to blocked peers. Blocking a range that is already blocked has no
+// effect.
+func (bl *Blocklist) Block(r string) error {
+	ipnet, err := parseIPRange(r)
+	if err != nil {
+		return err
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	for _, blocked := range bl.ranges {
+		if blocked.String() == ipnet.String() {
+			return nil
+		}
+	}
+	bl.ranges = append(bl.ranges, ipnet)
+	return nil
+}
+
+// Unblock removes an IP address or CIDR range from the blocklist. The range
+// must match a range that was blocked; addresses within a blocked range cannot
+// be unblocked individually.
+func (bl *Blocklist) Unblock(r string) error {
+	ipnet, err := parseIPRange(r)
+	if err != nil {
+		return err
+	}
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	for i, blocked := range bl.ranges {
+		if blocked.String() == ipnet.String() {
+			bl.ranges = append(bl.ranges[:i], bl.ranges[i+1:]...)
+			return nil
+		}
+	}
+	return errRangeNotBlocked
+}
+
+// Ranges returns the blocked IP ranges in CIDR notation, in the order in which
+// they were blocked.
+func (bl *Blocklist) Ranges() []string {
+	if bl == nil {
+		return nil
+	}
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	ranges := make([]string, 0, len(bl.ranges))
+	for _, ipnet := range bl.ranges {
+		ranges = append(ranges, ipnet.String())
+	}
+	return ranges
+}
+
+// IsBlocked returns true if the host of 'addr' is an IP address within a
+// blocked range. Hostnames are not resolved, and are never blocked.
+func (bl *Blocklist) IsBlocked(addr NetAddress) bool {
+	ip := net.ParseIP(addr.Host())
+	if bl == nil || ip == nil {
+		return false
+	}
+	bl.mu.RLock()
+	defer bl.mu.RUnlock()
+	for _, ipnet := range bl.ranges {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
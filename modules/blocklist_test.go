@@ -0,0 +1,72 @@
+package modules
+
+import (
+	"testing"
+)
+
+// TestBlocklist probes the methods of the Blocklist type.
+func TestBlocklist(t *testing.T) {
+	bl := NewBlocklist()
+
+	for _, r := range []string{"", "garbage", "10.0.0.0/33", "sia.tech", "1.2.3.4:9981"} {
+		if err := bl.Block(r); err != errInvalidIPRange {
+			t.Errorf("Block(%q): expected %v, got %v", r, errInvalidIPRange, err)
+		}
+	}
+
+	// Blocked ranges are normalized, and duplicates are ignored.
+	for _, r := range []string{"10.1.2.3/8", "192.168.1.5", "2001:db8::/32", "192.168.1.5/32"} {
+		if err := bl.Block(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ranges := bl.Ranges()
+	expected := []string{"10.0.0.0/8", "192.168.1.5/32", "2001:db8::/32"}
+	if len(ranges) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, ranges)
+	}
+	for i := range ranges {
+		if ranges[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, ranges)
+		}
+	}
+
+	tests := []struct {
+		addr    NetAddress
+		blocked bool
+	}{
+		{"10.0.0.1:9981", true},
+		{"10.255.255.255:9982", true},
+		{"11.0.0.1:9981", false},
+		{"192.168.1.5:9981", true},
+		{"192.168.1.6:9981", false},
+		{"[2001:db8::1]:9981", true},
+		{"[2001:db9::1]:9981", false},
+		{"sia.tech:9981", false},
+	}
+	for _, test := range tests {
+		if bl.IsBlocked(test.addr) != test.blocked {
+			t.Errorf("IsBlocked(%v): expected %v", test.addr, test.blocked)
+		}
+	}
+
+	// Ranges can only be unblocked as a whole.
+	if err := bl.Unblock("10.0.0.1"); err != errRangeNotBlocked {
+		t.Fatal("expected errRangeNotBlocked, got", err)
+	}
+	if err := bl.Unblock("10.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	if bl.IsBlocked("10.0.0.1:9981") {
+		t.Fatal("address is blocked after its range was unblocked")
+	}
+	if err := bl.Unblock("10.0.0.0/8"); err != errRangeNotBlocked {
+		t.Fatal("expected errRangeNotBlocked, got", err)
+	}
+
+	// A nil Blocklist does not block anything.
+	var nilList *Blocklist
+	if nilList.IsBlocked("10.0.0.1:9981") || len(nilList.Ranges()) != 0 {
+		t.Fatal("nil Blocklist blocks addresses")
+	}
+}
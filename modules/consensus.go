@@ -2,6 +2,7 @@ package modules
 
 import (
 	"errors"
+	"io"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/types"
@@ -88,6 +89,18 @@ type (
 		// applied.
 		AppliedBlocks []types.Block
 
+		// ReorgDepth is the number of blocks that were reverted by the change.
+		// A non-zero ReorgDepth indicates that the blockchain reorganized.
+		ReorgDepth types.BlockHeight
+
+		// RevertedTransactions is the list of transactions from the reverted
+		// blocks that do not appear in any of the applied blocks, meaning that
+		// the transactions left the blockchain as a result of the change. The
+		// transactions are presented in the order that they appeared in the
+		// blockchain, oldest block first, so that they can be resubmitted as
+		// a set.
+		RevertedTransactions []types.Transaction
+
 		// SiacoinOutputDiffs contains the set of siacoin diffs that were applied
 		// to the consensus set in the recent change. The direction for the set of
 		// diffs is 'DiffApply'.
@@ -160,6 +173,66 @@ type (
 		Adjusted  types.Currency
 	}
 
+	// ChainStats contains statistics about the current path of a consensus
+	// set.
+	ChainStats struct {
+		Height       types.BlockHeight
+		CurrentBlock types.BlockID
+		Synced       bool
+
+		// Target is the target that the next block must meet, and Difficulty
+		// is the expected number of hashes needed to meet it.
+		Target     types.Target
+		Difficulty types.Currency
+
+		// TotalWork is the sum of the difficulties of all blocks in the
+		// current path.
+		TotalWork types.Currency
+
+		// BlockInterval is the average number of seconds between recent
+		// blocks. It is zero if there are not enough blocks to compute an
+		// average.
+		BlockInterval float64
+
+		// Hashrate is an estimate of the number of hashes per second
+		// performed by the network, computed from the work and the
+		// timestamps of the same recent blocks as BlockInterval. It is zero
+		// if there are not enough blocks to compute an estimate.
+		Hashrate types.Currency
+
+		// SiafundPool is the total value of the siafund fees that have been
+		// collected from file contracts. Siafund holders can claim a portion
+		// of the pool proportional to the siafunds that they hold.
+		SiafundPool types.Currency
+	}
+
+	// A SiacoinOutputEntry is an unspent siacoin output along with its id.
+	SiacoinOutputEntry struct {
+		ID            types.SiacoinOutputID
+		SiacoinOutput types.SiacoinOutput
+	}
+
+	// A SiafundOutputEntry is an unspent siafund output along with its id.
+	SiafundOutputEntry struct {
+		ID            types.SiafundOutputID
+		SiafundOutput types.SiafundOutput
+	}
+
+	// A FileContractStatus contains an open file contract, along with the
+	// state of its funds and of its storage proof window.
+	FileContractStatus struct {
+		FileContract types.FileContract
+
+		// RemainingFunds is the value of the first valid proof output of the
+		// contract, which holds the funds that the renter has not yet spent
+		// on storage.
+		RemainingFunds types.Currency
+
+		// WindowOpen is true if the storage proof window of the contract has
+		// started, meaning a storage proof can be submitted.
+		WindowOpen bool
+	}
+
 	// A ConsensusSet accepts blocks and builds an understanding of network
 	// consensus.
 	ConsensusSet interface {
@@ -171,10 +244,18 @@ type (
 		// still be returned.
 		AcceptBlock(types.Block) error
 
+		// Alerts returns the most recent alerts raised by the consensus set,
+		// such as alerts about deep reorgs and competing forks.
+		Alerts() []Alert
+
 		// BlockAtHeight returns the block found at the input height, with a
 		// bool to indicate whether that block exists.
 		BlockAtHeight(types.BlockHeight) (types.Block, bool)
 
+		// ChainStats returns statistics about the current path, such as the
+		// difficulty, the total work, and the average block interval.
+		ChainStats() (ChainStats, error)
+
 		// ChildTarget returns the target required to extend the current heaviest
 		// fork. This function is typically used by miners looking to extend the
 		// heaviest fork.
@@ -194,6 +275,16 @@ type (
 		// blockchain.
 		CurrentBlock() types.Block
 
+		// ExportSnapshot writes a snapshot of the consensus set at its
+		// current height to the writer, returning the height and id of the
+		// block that the snapshot ends at, and the checksum of the consensus
+		// state at that block.
+		ExportSnapshot(io.Writer) (types.BlockHeight, types.BlockID, crypto.Hash, error)
+
+		// FileContract returns the status of the open file contract with the
+		// given id, and false if there is no such open contract.
+		FileContract(types.FileContractID) (FileContractStatus, bool)
+
 		// Height returns the current height of consensus.
 		Height() types.BlockHeight
 
@@ -204,12 +295,40 @@ type (
 		// current path, false otherwise.
 		InCurrentPath(types.BlockID) bool
 
+		// IsSpent returns true if the siacoin output, siafund output, or file
+		// contract with the given id has been spent or resolved in the
+		// current path.
+		IsSpent(crypto.Hash) bool
+
 		// MinimumValidChildTimestamp returns the earliest timestamp that is
 		// valid on the current longest fork according to the consensus set. This is
 		// a required piece of information for the miner, who could otherwise be at
 		// risk of mining invalid blocks.
 		MinimumValidChildTimestamp(types.BlockID) (types.Timestamp, bool)
 
+		// SiacoinOutput returns the unspent siacoin output with the given
+		// id, and false if there is no such unspent output.
+		SiacoinOutput(types.SiacoinOutputID) (types.SiacoinOutput, bool)
+
+		// SiacoinOutputs returns up to 'limit' unspent siacoin outputs in
+		// order of id, starting after the output with the given id. The id
+		// of the last output returned can be used to get the next page.
+		SiacoinOutputs(after types.SiacoinOutputID, limit int) []SiacoinOutputEntry
+
+		// SiafundOutput returns the unspent siafund output with the given
+		// id, and false if there is no such unspent output.
+		SiafundOutput(types.SiafundOutputID) (types.SiafundOutput, bool)
+
+		// SiafundOutputs returns up to 'limit' unspent siafund outputs in
+		// order of id, starting after the output with the given id.
+		SiafundOutputs(after types.SiafundOutputID, limit int) []SiafundOutputEntry
+
+		// StateChecksum returns a checksum of the full consensus set and of
+		// the blocks in the current path, along with the height and id of the
+		// block that it was computed at. Nodes with the same current block
+		// should report the same checksum.
+		StateChecksum() (types.BlockHeight, types.BlockID, crypto.Hash)
+
 		// StorageProofSegment returns the segment to be used in the storage proof for
 		// a given file contract.
 		StorageProofSegment(types.FileContractID) (uint64, error)
@@ -235,6 +354,8 @@ func (cc ConsensusChange) Append(cc2 ConsensusChange) ConsensusChange {
 	return ConsensusChange{
 		RevertedBlocks:            append(cc.RevertedBlocks, cc2.RevertedBlocks...),
 		AppliedBlocks:             append(cc.AppliedBlocks, cc2.AppliedBlocks...),
+		ReorgDepth:                cc.ReorgDepth + cc2.ReorgDepth,
+		RevertedTransactions:      append(cc.RevertedTransactions, cc2.RevertedTransactions...),
 		SiacoinOutputDiffs:        append(cc.SiacoinOutputDiffs, cc2.SiacoinOutputDiffs...),
 		FileContractDiffs:         append(cc.FileContractDiffs, cc2.FileContractDiffs...),
 		SiafundOutputDiffs:        append(cc.SiafundOutputDiffs, cc2.SiafundOutputDiffs...),
@@ -51,10 +51,16 @@ func (cs *ConsensusSet) validateHeaderAndBlock(tx dbTx, b types.Block) error {
 	if err != nil {
 		return err
 	}
+	// Check that the block does not conflict with a checkpoint.
+	if err := cs.checkCheckpoint(parent.Height+1, id); err != nil {
+		return err
+	}
+
 	// Check that the timestamp is not too far in the past to be acceptable.
 	minTimestamp := cs.blockRuleHelper.minimumValidChildTimestamp(blockMap, &parent)
 
-	return cs.blockValidator.ValidateBlock(b, minTimestamp, parent.ChildTarget, parent.Height+1)
+	target := cs.difficulty.blockTarget(&parent, b.Timestamp)
+	return cs.blockValidator.ValidateBlock(b, minTimestamp, target, parent.Height+1)
 }
 
 // checkHeaderTarget returns true if the header's ID meets the given target.
@@ -97,10 +103,15 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 	}
 
 	// Check that the target of the new block is sufficient.
-	if !checkHeaderTarget(h, parent.ChildTarget) {
+	if !checkHeaderTarget(h, cs.difficulty.blockTarget(&parent, h.Timestamp)) {
 		return modules.ErrBlockUnsolved
 	}
 
+	// Check that the header does not conflict with a checkpoint.
+	if err := cs.checkCheckpoint(parent.Height+1, id); err != nil {
+		return err
+	}
+
 	// TODO: check if the block is a non extending block once headers-first
 	// downloads are implemented.
 
@@ -139,6 +150,7 @@ func (cs *ConsensusSet) validateHeader(tx dbTx, h types.BlockHeader) error {
 // unneeded.
 func (cs *ConsensusSet) addBlockToTree(b types.Block) (ce changeEntry, err error) {
 	var nonExtending bool
+	var forkLen types.BlockHeight
 	err = cs.db.Update(func(tx *bolt.Tx) error {
 		pb, err := getBlockMap(tx, b.ParentID)
 		if build.DEBUG && err != nil {
@@ -153,6 +165,7 @@ func (cs *ConsensusSet) addBlockToTree(b types.Block) (ce changeEntry, err error
 		// set to indicate that modules.ErrNonExtending should be returned.
 		nonExtending = !newNode.heavierThan(currentNode)
 		if nonExtending {
+			forkLen = competingForkLen(tx, newNode)
 			return nil
 		}
 		var revertedBlocks, appliedBlocks []*processedBlock
@@ -166,6 +179,9 @@ func (cs *ConsensusSet) addBlockToTree(b types.Block) (ce changeEntry, err error
 		for _, an := range appliedBlocks {
 			ce.AppliedBlocks = append(ce.AppliedBlocks, an.Block.ID())
 		}
+		if cs.pruning {
+			pruneBlocks(tx)
+		}
 		// To have correct error handling, appendChangeLog must be called
 		// before appending to the in-memory changelog. If this call fails, the
 		// change is going to be reverted, but the in-memory changelog is not
@@ -184,6 +200,7 @@ func (cs *ConsensusSet) addBlockToTree(b types.Block) (ce changeEntry, err error
 	if err != nil {
 		return changeEntry{}, err
 	}
+	cs.managedAlertChainChange(len(ce.RevertedBlocks), forkLen)
 	if nonExtending {
 		return changeEntry{}, modules.ErrNonExtendingBlock
 	}
@@ -207,6 +224,7 @@ func (cs *ConsensusSet) managedAcceptBlock(b types.Block) error {
 	cs.mu.Lock()
 
 	// Start verification inside of a bolt View tx.
+	keepOrphan := false
 	err := cs.db.View(func(tx *bolt.Tx) error {
 		// Do not accept a block if the database is inconsistent.
 		if inconsistencyDetected(tx) {
@@ -243,12 +261,20 @@ func (cs *ConsensusSet) managedAcceptBlock(b types.Block) error {
 					}
 				}()
 			}
+			// Orphans that meet a target near the current target are kept
+			// until their parents are found.
+			if err == errOrphan {
+				keepOrphan = checkTarget(b, cs.orphanTarget(tx))
+			}
 			return err
 		}
 		return nil
 	})
 	if err != nil {
 		cs.mu.Unlock()
+		if keepOrphan {
+			cs.managedAddOrphan(b)
+		}
 		return err
 	}
 
@@ -259,6 +285,12 @@ func (cs *ConsensusSet) managedAcceptBlock(b types.Block) error {
 	changeEntry, err := cs.addBlockToTree(b)
 	if err != nil {
 		cs.mu.Unlock()
+		// A block that does not extend the current path is still added to
+		// the block tree, and its orphaned children may create a heavier
+		// fork.
+		if err == modules.ErrNonExtendingBlock {
+			cs.managedAcceptOrphans(b.ID())
+		}
 		return err
 	}
 	// If appliedBlocks is 0, revertedBlocks will also be 0.
@@ -266,7 +298,9 @@ func (cs *ConsensusSet) managedAcceptBlock(b types.Block) error {
 		panic("appliedBlocks and revertedBlocks are mismatched!")
 	}
 
-	// Updates complete, demote the lock.
+	// Updates complete, demote the lock. Once the lock is released, any
+	// orphaned children of the block are accepted.
+	defer cs.managedAcceptOrphans(b.ID())
 	cs.mu.Demote()
 	defer cs.mu.DemotedUnlock()
 	if len(changeEntry.AppliedBlocks) > 0 {
@@ -517,6 +517,9 @@ func (cst *consensusSetTester) testFileContractRevision() {
 	if err != nil {
 		panic(err)
 	}
+	if cst.cs.IsSpent(crypto.Hash(fcid)) {
+		panic("revised file contract is reported as spent")
+	}
 
 	// Create and submit a storage proof for the file contract.
 	segmentIndex, err := cst.cs.StorageProofSegment(fcid)
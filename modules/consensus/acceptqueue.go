@@ -0,0 +1,117 @@
+package consensus
+
+// acceptqueue.go implements the block acceptance queue. Blocks received from
+// peers are not accepted by the RPC handlers themselves, as acceptance holds
+// the consensus lock for the duration of validation, which would stall every
+// other handler during heavy synchronization. Instead, the handlers place the
+// blocks in a queue, and a single background thread accepts them in the order
+// that they were received.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errAcceptQueueFull    = errors.New("block acceptance queue is full")
+	errConsensusSetClosed = errors.New("consensus set has been closed")
+
+	// acceptQueueSize is the number of blocks that can wait in the acceptance
+	// queue. Blocks received while the queue is full are rejected; they will
+	// be fetched again during the next synchronization with the peer.
+	acceptQueueSize = func() int {
+		switch build.Release {
+		case "dev":
+			return 50
+		case "standard":
+			return 100
+		case "testing":
+			return 10
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// queuedBlock is a block waiting in the acceptance queue.
+type queuedBlock struct {
+	block types.Block
+
+	// peer is the address of the peer that sent the block. If the block is
+	// an orphan, its parents are requested from the peer.
+	peer modules.NetAddress
+
+	// result, if not nil, receives the result of accepting the block.
+	result chan error
+}
+
+// managedQueueBlock adds a block to the acceptance queue without waiting for
+// it to be accepted.
+func (cs *ConsensusSet) managedQueueBlock(qb queuedBlock) error {
+	select {
+	case <-cs.closeChan:
+		return errConsensusSetClosed
+	default:
+	}
+	select {
+	case cs.acceptQueue <- qb:
+		return nil
+	default:
+		return errAcceptQueueFull
+	}
+}
+
+// managedQueueBlockAndWait adds a block to the acceptance queue and returns
+// the result of accepting it. Unlike managedQueueBlock, it waits for room in
+// the queue if the queue is full.
+func (cs *ConsensusSet) managedQueueBlockAndWait(b types.Block, peer modules.NetAddress) error {
+	result := make(chan error, 1)
+	select {
+	case cs.acceptQueue <- queuedBlock{block: b, peer: peer, result: result}:
+	case <-cs.closeChan:
+		return errConsensusSetClosed
+	}
+	select {
+	case err := <-result:
+		return err
+	case <-cs.closeChan:
+		return errConsensusSetClosed
+	}
+}
+
+// threadedProcessAcceptQueue accepts the blocks in the acceptance queue until
+// the consensus set is closed.
+func (cs *ConsensusSet) threadedProcessAcceptQueue() {
+	for {
+		select {
+		case <-cs.closeChan:
+			return
+		case qb := <-cs.acceptQueue:
+			err := cs.AcceptBlock(qb.block)
+			if err == errOrphan && qb.peer != "" {
+				// The block is kept in the orphan pool, and accepted once
+				// its parents have been received from the peer.
+				go func(peer modules.NetAddress) {
+					err := cs.gateway.RPC(peer, "SendBlocks", cs.threadedReceiveBlocks)
+					if err != nil {
+						cs.log.Debugln("WARN: failed to get parents of orphan block:", err)
+					}
+				}(qb.peer)
+			}
+			if err != nil && qb.peer != "" {
+				if err != modules.ErrBlockKnown && err != modules.ErrNonExtendingBlock {
+					cs.log.Debugf("WARN: block %v relayed by %v was rejected: %v", qb.block.ID(), qb.peer, err)
+				}
+				cs.managedReportInvalidBlock(qb.peer, qb.block.ID(), err)
+			} else if err == nil && qb.peer != "" {
+				cs.gateway.RecordRelayedBlock(qb.peer, qb.block.ID())
+			}
+			if qb.result != nil {
+				qb.result <- err
+			}
+		}
+	}
+}
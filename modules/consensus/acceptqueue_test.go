@@ -0,0 +1,87 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestAcceptQueue checks that blocks can be queued while the consensus set is
+// locked, that queued blocks are accepted in order, and that the queue is
+// bounded.
+func TestAcceptQueue(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestAcceptQueue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	startHeight := cst.cs.Height()
+
+	// Create two blocks, the second of which is a child of the first.
+	b1, err := cst.miner.FindBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, _ := cst.cs.ChildTarget(b1.ParentID)
+	b2 := types.Block{
+		ParentID:     b1.ID(),
+		Timestamp:    types.CurrentTimestamp(),
+		MinerPayouts: []types.SiacoinOutput{{Value: types.CalculateCoinbase(startHeight + 2)}},
+	}
+	for solved := false; !solved; {
+		b2, solved = cst.miner.SolveBlock(b2, target)
+	}
+
+	// Queue the blocks while the consensus set is locked. Queueing should
+	// not wait for the lock.
+	cst.cs.mu.Lock()
+	if err := cst.cs.managedQueueBlock(queuedBlock{block: b1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.cs.managedQueueBlock(queuedBlock{block: b2}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once b1 has been taken from the queue, fill the rest of the queue. No
+	// more blocks should be accepted.
+	for i := 0; i < 50 && len(cst.cs.acceptQueue) != 1; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(cst.cs.acceptQueue) != 1 {
+		t.Fatal("first block was not taken from the queue")
+	}
+	for i := 1; i < acceptQueueSize; i++ {
+		if err := cst.cs.managedQueueBlock(queuedBlock{block: b2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cst.cs.managedQueueBlock(queuedBlock{block: b2}); err != errAcceptQueueFull {
+		t.Fatal("expected errAcceptQueueFull, got", err)
+	}
+	cst.cs.mu.Unlock()
+
+	// The blocks ahead of this one are accepted first, so by the time the
+	// result arrives both blocks should be in the consensus set.
+	err = cst.cs.managedQueueBlockAndWait(b2, "")
+	if err != modules.ErrBlockKnown {
+		t.Fatal("expected ErrBlockKnown, got", err)
+	}
+	if cst.cs.CurrentBlock().ID() != b2.ID() || cst.cs.Height() != startHeight+2 {
+		t.Fatal("queued blocks were not accepted")
+	}
+
+	// Blocks cannot be queued after the consensus set has been closed.
+	cst.Close()
+	if err := cst.cs.managedQueueBlock(queuedBlock{block: b2}); err != errConsensusSetClosed {
+		t.Fatal("expected errConsensusSetClosed, got", err)
+	}
+	// Closing the consensus set again returns an error instead of panicking.
+	if err := cst.cs.Close(); err != errConsensusSetClosed {
+		t.Fatal("expected errConsensusSetClosed, got", err)
+	}
+}
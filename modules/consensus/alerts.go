@@ -0,0 +1,137 @@
+package consensus
+
+// alerts.go raises alerts about events that threaten the finality of recent
+// blocks. A reorg that reverts several blocks can undo transactions and
+// storage proofs that were considered confirmed, and a competing chain near
+// the tip suggests that such a reorg may be imminent. Hosts in particular may
+// need to resubmit storage proofs when either happens.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+const (
+	// alertIDDeepReorg and alertIDCompetingFork are the IDs with which the
+	// consensus alerts are reported to the alert registry. Each new reorg or
+	// fork replaces the previous alert of its kind until it is cleared.
+	alertIDDeepReorg     = "consensus.deepreorg"
+	alertIDCompetingFork = "consensus.competingfork"
+)
+
+var (
+	// deepReorgThreshold is the number of reverted blocks at which a reorg
+	// raises an alert.
+	deepReorgThreshold = func() int {
+		switch build.Release {
+		case "dev":
+			return 3
+		case "standard":
+			return 6
+		case "testing":
+			return 2
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// competingForkLength is the number of blocks that a fork must have to be
+	// considered a competing chain, and competingForkWindow is the distance
+	// from the current block within which the tip of the fork must be.
+	// Forks of a single block happen regularly and are not reported.
+	competingForkLength = func() types.BlockHeight {
+		switch build.Release {
+		case "dev":
+			return 2
+		case "standard":
+			return 3
+		case "testing":
+			return 2
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+	competingForkWindow = func() types.BlockHeight {
+		switch build.Release {
+		case "dev":
+			return 3
+		case "standard":
+			return 6
+		case "testing":
+			return 3
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// maxAlerts is the number of alerts kept by the consensus set. Older
+	// alerts are discarded.
+	maxAlerts = func() int {
+		switch build.Release {
+		case "dev":
+			return 50
+		case "standard":
+			return 100
+		case "testing":
+			return 5
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// competingForkLen returns the number of blocks that the fork ending in 'pb'
+// has on top of the current path. Zero is returned if the fork is too far
+// behind the current block to compete with it.
+func competingForkLen(tx *bolt.Tx, pb *processedBlock) types.BlockHeight {
+	if pb.Height+competingForkWindow < blockHeight(tx) {
+		return 0
+	}
+	path := backtrackToCurrentPath(tx, pb)
+	return pb.Height - path[0].Height
+}
+
+// managedRegisterAlert adds an alert to the list of alerts, logs it, and
+// raises it in the alert registry under 'id'.
+func (cs *ConsensusSet) managedRegisterAlert(id string, severity modules.AlertSeverity, msg string) {
+	cs.log.Println("ALERT:", msg)
+	a := modules.Alert{
+		ID:       id,
+		Module:   "consensus",
+		Severity: severity,
+		Msg:      msg,
+		Time:     time.Now(),
+	}
+	cs.alertMu.Lock()
+	cs.alerts = append(cs.alerts, a)
+	if len(cs.alerts) > maxAlerts {
+		cs.alerts = cs.alerts[len(cs.alerts)-maxAlerts:]
+	}
+	cs.alertMu.Unlock()
+	modules.RaiseAlert(a)
+}
+
+// managedAlertChainChange raises alerts for a reorg that reverted
+// 'revertedBlocks' blocks, or for a competing fork of 'forkLen' blocks.
+func (cs *ConsensusSet) managedAlertChainChange(revertedBlocks int, forkLen types.BlockHeight) {
+	if revertedBlocks >= deepReorgThreshold {
+		cs.managedRegisterAlert(alertIDDeepReorg, modules.SeverityCritical, fmt.Sprintf("reorg reverted %v blocks; transactions and storage proofs in the reverted blocks may need to be resubmitted", revertedBlocks))
+	}
+	if forkLen >= competingForkLength {
+		cs.managedRegisterAlert(alertIDCompetingFork, modules.SeverityWarning, fmt.Sprintf("competing chain of %v blocks found near the current block; a reorg may follow", forkLen))
+	}
+}
+
+// Alerts returns the most recent alerts raised by the consensus set, oldest
+// first.
+func (cs *ConsensusSet) Alerts() []modules.Alert {
+	cs.alertMu.Lock()
+	defer cs.alertMu.Unlock()
+	return append([]modules.Alert(nil), cs.alerts...)
+}
@@ -0,0 +1,95 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestChainChangeAlerts checks that alerts are raised for competing forks and
+// deep reorgs.
+func TestChainChangeAlerts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := blankConsensusSetTester("TestChainChangeAlerts1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst1.Close()
+	cst2, err := blankConsensusSetTester("TestChainChangeAlerts2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst2.Close()
+
+	// Mine two blocks on cst1 and a longer fork on cst2.
+	for i := 0; i < 2; i++ {
+		_, err := cst1.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var fork []types.Block
+	for i := 0; i < 3; i++ {
+		b, err := cst2.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fork = append(fork, b)
+	}
+
+	// A fork of a single block is not reported.
+	err = cst1.cs.AcceptBlock(fork[0])
+	if err != modules.ErrNonExtendingBlock {
+		t.Fatal("expected ErrNonExtendingBlock, got", err)
+	}
+	if len(cst1.cs.Alerts()) != 0 {
+		t.Fatal("alert raised for a single block fork")
+	}
+
+	// A fork as long as the current path is a competing chain.
+	err = cst1.cs.AcceptBlock(fork[1])
+	if err != modules.ErrNonExtendingBlock {
+		t.Fatal("expected ErrNonExtendingBlock, got", err)
+	}
+	alerts := cst1.cs.Alerts()
+	if len(alerts) != 1 || alerts[0].Severity != modules.SeverityWarning {
+		t.Fatal("competing chain did not raise a warning:", alerts)
+	}
+
+	// Switching to the fork reverts two blocks, which is a deep reorg.
+	err = cst1.cs.AcceptBlock(fork[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts = cst1.cs.Alerts()
+	if len(alerts) != 2 || alerts[1].Severity != modules.SeverityCritical {
+		t.Fatal("deep reorg did not raise a critical alert:", alerts)
+	}
+
+	// The alerts are reported to the alert registry.
+	defer modules.ClearAlert(alertIDCompetingFork)
+	defer modules.ClearAlert(alertIDDeepReorg)
+	var reported int
+	for _, a := range modules.ActiveAlerts() {
+		if a.ID == alertIDCompetingFork || a.ID == alertIDDeepReorg {
+			reported++
+		}
+	}
+	if reported != 2 {
+		t.Fatal("alerts were not reported to the alert registry:", modules.ActiveAlerts())
+	}
+
+	// Only the most recent alerts are kept.
+	for i := 0; i < maxAlerts; i++ {
+		cst1.cs.managedRegisterAlert("consensus.test", modules.SeverityInfo, "test alert")
+	}
+	defer modules.ClearAlert("consensus.test")
+	alerts = cst1.cs.Alerts()
+	if len(alerts) != maxAlerts || alerts[0].Severity != modules.SeverityInfo {
+		t.Fatal("old alerts were not discarded")
+	}
+}
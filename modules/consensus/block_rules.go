@@ -23,7 +23,13 @@ type stdBlockRuleHelper struct{}
 // To boost performance, minimumValidChildTimestamp is passed a bucket that it
 // can use from inside of a boltdb transaction.
 func (rh stdBlockRuleHelper) minimumValidChildTimestamp(blockMap dbBucket, pb *processedBlock) types.Timestamp {
-	// Get the previous MedianTimestampWindow timestamps.
+	return medianTimestamp(recentTimestamps(blockMap, pb))
+}
+
+// recentTimestamps returns the timestamps of 'pb' and its previous
+// MedianTimestampWindow-1 ancestors, most recent first. If there are not
+// enough ancestors, the genesis timestamp is repeated.
+func recentTimestamps(blockMap dbBucket, pb *processedBlock) types.TimestampSlice {
 	windowTimes := make(types.TimestampSlice, types.MedianTimestampWindow)
 	windowTimes[0] = pb.Block.Timestamp
 	parent := pb.Block.ParentID
@@ -44,8 +50,13 @@ func (rh stdBlockRuleHelper) minimumValidChildTimestamp(blockMap dbBucket, pb *p
 		copy(parent[:], parentBytes[:32])
 		windowTimes[i] = types.Timestamp(encoding.DecUint64(parentBytes[40:48]))
 	}
-	sort.Sort(windowTimes)
+	return windowTimes
+}
 
-	// Return the median of the sorted timestamps.
-	return windowTimes[len(windowTimes)/2]
+// medianTimestamp returns the median of a set of timestamps, which is the
+// earliest timestamp that a block following them may have.
+func medianTimestamp(windowTimes types.TimestampSlice) types.Timestamp {
+	sorted := append(types.TimestampSlice(nil), windowTimes...)
+	sort.Sort(sorted)
+	return sorted[len(sorted)/2]
 }
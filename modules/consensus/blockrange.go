@@ -0,0 +1,95 @@
+package consensus
+
+// blockrange.go implements the SendBlkRange RPC, which returns the blocks of
+// the current path within a range of heights. Headers-first synchronization
+// uses it to download block bodies in batches, instead of making a round trip
+// for every block with SendBlk.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+const (
+	// blockRangeVersion is the first version of siad that supports the
+	// SendBlkRange RPC.
+	blockRangeVersion = "1.0.1"
+)
+
+var (
+	errBlockRangeSize = errors.New("peer sent more blocks than were requested")
+)
+
+// A blockRangeRequest requests the blocks of the current path at heights
+// [Start, Start+Count). At most MaxCatchUpBlocks blocks are returned, and
+// fewer blocks are returned if the range extends past the current block.
+type blockRangeRequest struct {
+	Start types.BlockHeight
+	Count uint64
+}
+
+// rpcSendBlockRange is the receiving end of the SendBlkRange RPC. It reads a
+// blockRangeRequest and returns the requested blocks in order.
+func (cs *ConsensusSet) rpcSendBlockRange(conn modules.PeerConn) error {
+	var req blockRangeRequest
+	err := encoding.ReadObject(conn, &req, 16)
+	if err != nil {
+		return err
+	}
+	if req.Count > uint64(MaxCatchUpBlocks) {
+		req.Count = uint64(MaxCatchUpBlocks)
+	}
+
+	blocks := []types.Block{}
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		if req.Start < getPruneHeight(tx) {
+			return errPrunedHistory
+		}
+		height := blockHeight(tx)
+		for i := req.Start; i <= height && uint64(i-req.Start) < req.Count; i++ {
+			id, err := getPath(tx, i)
+			if build.DEBUG && err != nil {
+				panic(err)
+			}
+			pb, err := getBlockMap(tx, id)
+			if build.DEBUG && err != nil {
+				panic(err)
+			}
+			blocks = append(blocks, pb.Block)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, blocks)
+}
+
+// receiveBlockRange returns an RPCFunc that is the calling end of the
+// SendBlkRange RPC. The blocks sent by the peer are written to 'blocks'. The
+// blocks are not validated; the caller is responsible for checking that they
+// match the expected headers before applying them in order.
+func receiveBlockRange(start types.BlockHeight, count uint64, blocks *[]types.Block) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		if err := setSyncDeadline(conn); err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, blockRangeRequest{Start: start, Count: count}); err != nil {
+			return err
+		}
+		maxLen := 8 + uint64(MaxCatchUpBlocks)*types.BlockSizeLimit
+		if err := encoding.ReadObject(conn, blocks, maxLen); err != nil {
+			return err
+		}
+		if uint64(len(*blocks)) > count {
+			return errBlockRangeSize
+		}
+		return nil
+	}
+}
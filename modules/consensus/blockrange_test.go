@@ -0,0 +1,235 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestIntegrationSendBlockRange checks that the SendBlkRange RPC returns the
+// blocks of the current path within the requested range, and that the size
+// of the range is limited.
+func TestIntegrationSendBlockRange(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	remoteCST, err := blankConsensusSetTester("TestIntegrationSendBlockRange - remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remoteCST.Close()
+	localCST, err := blankConsensusSetTester("TestIntegrationSendBlockRange - local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer localCST.Close()
+	err = localCST.cs.gateway.Connect(remoteCST.cs.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the OnConnectRPCs to finish.
+	time.Sleep(100 * time.Millisecond)
+
+	for i := types.BlockHeight(0); i < 2*MaxCatchUpBlocks; i++ {
+		b, err := remoteCST.miner.FindBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = remoteCST.cs.managedAcceptBlock(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	remoteHeight := remoteCST.cs.Height()
+
+	tests := []struct {
+		start types.BlockHeight
+		count uint64
+		want  types.BlockHeight
+		msg   string
+	}{
+		{
+			start: 1,
+			count: 2,
+			want:  2,
+			msg:   "a range within the current path should be returned in full",
+		},
+		{
+			start: 1,
+			count: uint64(MaxCatchUpBlocks) + 1,
+			want:  MaxCatchUpBlocks,
+			msg:   "at most MaxCatchUpBlocks blocks should be returned",
+		},
+		{
+			start: remoteHeight - 1,
+			count: 5,
+			want:  2,
+			msg:   "a range extending past the current block should be truncated",
+		},
+		{
+			start: remoteHeight + 1,
+			count: 5,
+			want:  0,
+			msg:   "a range beyond the current block should be empty",
+		},
+	}
+	for _, tt := range tests {
+		var blocks []types.Block
+		err := localCST.cs.gateway.RPC(remoteCST.cs.gateway.Address(), "SendBlkRange", receiveBlockRange(tt.start, tt.count, &blocks))
+		if err != nil {
+			t.Fatalf("%v: %v", tt.msg, err)
+		}
+		if types.BlockHeight(len(blocks)) != tt.want {
+			t.Fatalf("%v: expected %v blocks, got %v", tt.msg, tt.want, len(blocks))
+		}
+		for i, b := range blocks {
+			id, err := remoteCST.cs.dbGetPath(tt.start + types.BlockHeight(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.ID() != id {
+				t.Fatalf("%v: block %v is not in the current path", tt.msg, i)
+			}
+		}
+	}
+}
+
+// TestIntegrationDownloadBodies checks that block bodies are downloaded in
+// order, both with SendBlkRange and with the SendBlk fallback used for older
+// peers.
+func TestIntegrationDownloadBodies(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	remoteCST, err := blankConsensusSetTester("TestIntegrationDownloadBodies - remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remoteCST.Close()
+	localCST, err := blankConsensusSetTester("TestIntegrationDownloadBodies - local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer localCST.Close()
+	err = localCST.cs.gateway.Connect(remoteCST.cs.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	var headers []types.BlockHeader
+	for i := types.BlockHeight(0); i < 2*MaxCatchUpBlocks+1; i++ {
+		b, err := remoteCST.miner.FindBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = remoteCST.cs.managedAcceptBlock(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, b.Header())
+	}
+
+	addr := remoteCST.cs.gateway.Address()
+	blocks, err := localCST.cs.managedDownloadBodies(addr, 1, headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range headers {
+		if blocks[i].ID() != headers[i].ID() {
+			t.Fatal("downloaded block does not match its header:", i)
+		}
+	}
+
+	// Download a chunk using the SendBlk fallback.
+	fallback := make([]types.Block, 2)
+	err = localCST.cs.managedDownloadChunk(addr, false, 1, headers[:2], fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fallback[0].ID() != headers[0].ID() || fallback[1].ID() != headers[1].ID() {
+		t.Fatal("SendBlk fallback downloaded the wrong blocks")
+	}
+
+	// A range starting at the wrong height does not match the headers.
+	err = localCST.cs.managedDownloadChunk(addr, true, 2, headers[:2], make([]types.Block, 2))
+	if err != errBodyMismatch {
+		t.Fatal("expected errBodyMismatch, got", err)
+	}
+}
+
+// TestIntegrationDownloadBodiesMultiplePeers checks that block bodies are
+// downloaded from several peers, and that the chunks of a peer that cannot
+// provide them are downloaded from the other peers.
+func TestIntegrationDownloadBodiesMultiplePeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	remoteCST, err := blankConsensusSetTester("TestIntegrationDownloadBodiesMultiplePeers - remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remoteCST.Close()
+	helperCST, err := blankConsensusSetTester("TestIntegrationDownloadBodiesMultiplePeers - helper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer helperCST.Close()
+	forkedCST, err := blankConsensusSetTester("TestIntegrationDownloadBodiesMultiplePeers - forked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer forkedCST.Close()
+	localCST, err := blankConsensusSetTester("TestIntegrationDownloadBodiesMultiplePeers - local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer localCST.Close()
+
+	// The helper has the same blocks as the remote, while the forked peer is
+	// on a different chain and cannot provide any of them.
+	var headers []types.BlockHeader
+	for i := types.BlockHeight(0); i < 4*MaxCatchUpBlocks+1; i++ {
+		b, err := remoteCST.miner.FindBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := remoteCST.cs.managedAcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+		if err := helperCST.cs.managedAcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, b.Header())
+
+		fb, err := forkedCST.miner.FindBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := forkedCST.cs.managedAcceptBlock(fb); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, cst := range []*consensusSetTester{remoteCST, helperCST, forkedCST} {
+		err = localCST.cs.gateway.Connect(cst.cs.gateway.Address())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	blocks, err := localCST.cs.managedDownloadBodies(remoteCST.cs.gateway.Address(), 1, headers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range headers {
+		if blocks[i].ID() != headers[i].ID() {
+			t.Fatal("downloaded block does not match its header:", i)
+		}
+	}
+}
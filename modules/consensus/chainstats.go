@@ -0,0 +1,77 @@
+package consensus
+
+import (
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// blockIntervalWindow is the number of recent blocks that are used to
+	// compute the average block interval reported in the chain stats.
+	blockIntervalWindow = func() types.BlockHeight {
+		switch build.Release {
+		case "dev":
+			return 20
+		case "standard":
+			return 144
+		case "testing":
+			return 10
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// networkHashrate estimates the hashrate of the network from the work done
+// between two blocks of the same path and the time between their timestamps.
+// Zero is returned if the timestamps do not increase.
+func networkHashrate(start, end *processedBlock) types.Currency {
+	if end.Block.Timestamp <= start.Block.Timestamp {
+		return types.ZeroCurrency
+	}
+	work := end.Depth.Difficulty().Sub(start.Depth.Difficulty())
+	return work.Div64(uint64(end.Block.Timestamp - start.Block.Timestamp))
+}
+
+// ChainStats returns statistics about the current path of the consensus set.
+func (cs *ConsensusSet) ChainStats() (stats modules.ChainStats, err error) {
+	stats.Synced = cs.Synced()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		pb := currentProcessedBlock(tx)
+		stats.Height = pb.Height
+		stats.CurrentBlock = pb.Block.ID()
+		stats.Target = pb.ChildTarget
+		stats.Difficulty = pb.ChildTarget.Difficulty()
+		stats.TotalWork = pb.Depth.Difficulty()
+		stats.SiafundPool = getSiafundPool(tx)
+
+		// Average the interval between the blocks in the window. The genesis
+		// timestamp is excluded, as it is not related to the time that the
+		// first block was mined, so at least two blocks are needed on top of
+		// the genesis block.
+		if pb.Height < 2 {
+			return nil
+		}
+		window := blockIntervalWindow
+		if window > pb.Height-1 {
+			window = pb.Height - 1
+		}
+		startID, err := getPath(tx, pb.Height-window)
+		if err != nil {
+			return err
+		}
+		start, err := getBlockMap(tx, startID)
+		if err != nil {
+			return err
+		}
+		if pb.Block.Timestamp > start.Block.Timestamp {
+			stats.BlockInterval = float64(pb.Block.Timestamp-start.Block.Timestamp) / float64(window)
+		}
+		stats.Hashrate = networkHashrate(start, pb)
+		return nil
+	})
+	return stats, err
+}
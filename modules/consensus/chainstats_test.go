@@ -0,0 +1,75 @@
+package consensus
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestNetworkHashrate probes the networkHashrate function.
+func TestNetworkHashrate(t *testing.T) {
+	// Ten blocks at a difficulty of 1000 mined over 100 seconds is a
+	// hashrate of about 100 hashes per second.
+	start := &processedBlock{Depth: types.RootDepth}
+	start.Block.Timestamp = 1000
+	end := &processedBlock{Depth: start.Depth}
+	end.Block.Timestamp = 1100
+	target := types.RootDepth.MulDifficulty(big.NewRat(1000, 1))
+	for i := 0; i < 10; i++ {
+		end.Depth = end.Depth.AddDifficulties(target)
+	}
+	hashrate, err := networkHashrate(start, end).Uint64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hashrate < 99 || hashrate > 101 {
+		t.Fatal("wrong hashrate:", hashrate)
+	}
+
+	// Without any time between the blocks, there is no estimate.
+	end.Block.Timestamp = start.Block.Timestamp
+	if !networkHashrate(start, end).IsZero() {
+		t.Fatal("expected a hashrate of zero")
+	}
+}
+
+// TestChainStatsShortChain checks that ChainStats handles chains that are too
+// short to compute an average block interval.
+func TestChainStatsShortChain(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	cst, err := blankConsensusSetTester("TestChainStatsShortChain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// With only the genesis block, and with a single block on top of it,
+	// there is no interval to average.
+	for height := types.BlockHeight(0); height < 2; height++ {
+		stats, err := cst.cs.ChainStats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Height != height {
+			t.Fatalf("expected height %v, got %v", height, stats.Height)
+		}
+		if stats.BlockInterval != 0 || !stats.Hashrate.IsZero() {
+			t.Fatal("expected no block interval or hashrate at height", height)
+		}
+		if _, err := cst.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// With two blocks on top of the genesis block there is an estimate.
+	stats, err := cst.cs.ChainStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Height != 2 || stats.TotalWork.IsZero() {
+		t.Fatal("unexpected stats:", stats.Height, stats.TotalWork)
+	}
+}
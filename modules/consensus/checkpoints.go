@@ -0,0 +1,107 @@
+package consensus
+
+// checkpoints.go implements block checkpoints. A checkpoint is a known-good
+// block id at a given height. Blocks that conflict with a checkpoint are
+// rejected, which prevents an attacker from feeding a syncing node a deep
+// bogus fork. Blocks that are known to be ancestors of the highest checkpoint
+// are assumed to be valid, and the signatures of their transactions are not
+// verified during the initial blockchain download. All other consensus rules
+// are still enforced for these blocks.
+//
+// A block is only known to be an ancestor of the checkpoint once a header
+// chain that reaches the checkpoint has been validated during headers-first
+// synchronization. The signatures of all other blocks are verified, including
+// those of forks that branch off below the checkpoint.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	errCheckpointConflict = errors.New("checkpoint conflicts with the current path")
+	errCheckpointMismatch = errors.New("block conflicts with a checkpoint")
+
+	// defaultCheckpoints are the checkpoints compiled into the consensus set.
+	// Checkpoints for the standard network are added at release time, and
+	// should only ever be blocks that are buried deep beneath the tip of the
+	// blockchain. Further checkpoints can be configured with the
+	// --checkpoints flag of siad, which calls AddCheckpoint.
+	defaultCheckpoints = func() map[types.BlockHeight]types.BlockID {
+		switch build.Release {
+		case "dev":
+			return map[types.BlockHeight]types.BlockID{}
+		case "standard":
+			return map[types.BlockHeight]types.BlockID{}
+		case "testing":
+			return map[types.BlockHeight]types.BlockID{}
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// checkCheckpoint returns errCheckpointMismatch if there is a checkpoint at
+// the given height and the id does not match the checkpoint.
+func (cs *ConsensusSet) checkCheckpoint(height types.BlockHeight, id types.BlockID) error {
+	checkpoint, exists := cs.checkpoints[height]
+	if exists && checkpoint != id {
+		return errCheckpointMismatch
+	}
+	return nil
+}
+
+// assumeValid returns true if the block with the given id is an ancestor of
+// the highest checkpoint, meaning the signatures of its transactions do not
+// need to be verified.
+func (cs *ConsensusSet) assumeValid(id types.BlockID) bool {
+	_, exists := cs.checkpointAncestors[id]
+	return exists
+}
+
+// recordCheckpointAncestors records the headers that are ancestors of the
+// highest checkpoint. 'headers' must form a contiguous chain that builds on the
+// block at 'forkHeight', as checked by validateHeaderChain. If the header at
+// the height of the highest checkpoint is the checkpointed block, it and the
+// headers before it are its ancestors.
+func (cs *ConsensusSet) recordCheckpointAncestors(headers []types.BlockHeader, forkHeight types.BlockHeight) {
+	if len(cs.checkpoints) == 0 || cs.checkpointHeight <= forkHeight || cs.checkpointHeight > forkHeight+types.BlockHeight(len(headers)) {
+		return
+	}
+	n := int(cs.checkpointHeight - forkHeight)
+	if headers[n-1].ID() != cs.checkpoints[cs.checkpointHeight] {
+		return
+	}
+	ancestors := make(map[types.BlockID]struct{}, n)
+	for _, h := range headers[:n] {
+		ancestors[h.ID()] = struct{}{}
+	}
+	cs.checkpointAncestors = ancestors
+}
+
+// AddCheckpoint adds a checkpoint to the consensus set. An error is returned
+// if the current path already contains a different block at the height of the
+// checkpoint.
+func (cs *ConsensusSet) AddCheckpoint(height types.BlockHeight, id types.BlockID) error {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		pathID, err := getPath(tx, height)
+		if err == nil && pathID != id {
+			return errCheckpointConflict
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	cs.checkpoints[height] = id
+	if height > cs.checkpointHeight {
+		cs.checkpointHeight = height
+	}
+	return nil
+}
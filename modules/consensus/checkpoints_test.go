@@ -0,0 +1,146 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestCheckpointMismatch checks that blocks conflicting with a checkpoint are
+// rejected, and that checkpoints conflicting with the current path cannot be
+// added.
+func TestCheckpointMismatch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestCheckpointMismatch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// Add a checkpoint at the next height that does not match the next block.
+	b, err := cst.miner.FindBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cst.cs.AddCheckpoint(cst.cs.Height()+1, types.BlockID{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cst.cs.AcceptBlock(b)
+	if err != errCheckpointMismatch {
+		t.Fatal("expected errCheckpointMismatch, got", err)
+	}
+
+	// Checkpoints conflicting with the current path are rejected, matching
+	// checkpoints are not.
+	err = cst.cs.AddCheckpoint(1, types.BlockID{1})
+	if err != errCheckpointConflict {
+		t.Error("expected errCheckpointConflict, got", err)
+	}
+	current := cst.cs.CurrentBlock()
+	err = cst.cs.AddCheckpoint(cst.cs.Height(), current.ID())
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCheckpointAssumeValid checks that the signatures of transactions are
+// only skipped for blocks that are known to be ancestors of the highest
+// checkpoint, and that forks below the checkpoint are still verified.
+func TestCheckpointAssumeValid(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestCheckpointAssumeValid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// Create a transaction with a corrupted signature.
+	txnBuilder := cst.wallet.StartTransaction()
+	err = txnBuilder.FundSiacoins(types.NewCurrency64(50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnBuilder.AddSiacoinOutput(types.SiacoinOutput{Value: types.NewCurrency64(50)})
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs := txnSet[len(txnSet)-1].TransactionSignatures
+	sigs[0].Signature[0]++
+
+	// Without a checkpoint, the block is rejected.
+	height := cst.cs.Height()
+	block, target, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append(block.Transactions, txnSet...)
+	badBlock, _ := cst.miner.SolveBlock(block, target)
+	err = cst.cs.AcceptBlock(badBlock)
+	if err != crypto.ErrInvalidSignature {
+		t.Fatal("expected ErrInvalidSignature, got", err)
+	}
+
+	// A fork below a checkpoint is still verified. The timestamp is changed
+	// so that the block is not recognized as a known DoS block.
+	err = cst.cs.AddCheckpoint(height+3, types.BlockID{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Timestamp++
+	forkBlock, _ := cst.miner.SolveBlock(block, target)
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	err = cst.cs.AcceptBlock(forkBlock)
+	if err != modules.ErrNonExtendingBlock {
+		t.Fatal("expected ErrNonExtendingBlock, got", err)
+	}
+	forkChild := types.Block{
+		ParentID:     forkBlock.ID(),
+		Timestamp:    forkBlock.Timestamp,
+		MinerPayouts: []types.SiacoinOutput{{Value: types.CalculateCoinbase(height + 2)}},
+	}
+	forkChild, _ = cst.miner.SolveBlock(forkChild, target)
+	err = cst.cs.AcceptBlock(forkChild)
+	if err != crypto.ErrInvalidSignature {
+		t.Fatal("expected ErrInvalidSignature, got", err)
+	}
+
+	// Once a header chain that reaches the checkpoint has been seen, the
+	// signatures of the blocks in it are assumed to be valid.
+	block, target, err = cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions = append(block.Transactions, txnSet...)
+	assumedBlock, _ := cst.miner.SolveBlock(block, target)
+	child := types.Block{
+		ParentID:     assumedBlock.ID(),
+		Timestamp:    assumedBlock.Timestamp,
+		MinerPayouts: []types.SiacoinOutput{{Value: types.CalculateCoinbase(height + 3)}},
+	}
+	child, _ = cst.miner.SolveBlock(child, target)
+	err = cst.cs.AddCheckpoint(height+3, child.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cst.cs.mu.Lock()
+	cst.cs.recordCheckpointAncestors([]types.BlockHeader{assumedBlock.Header(), child.Header()}, height+1)
+	cst.cs.mu.Unlock()
+	if err := cst.cs.AcceptBlock(assumedBlock); err != nil {
+		t.Fatal(err)
+	}
+	if err := cst.cs.AcceptBlock(child); err != nil {
+		t.Fatal(err)
+	}
+}
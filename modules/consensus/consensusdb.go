@@ -8,6 +8,7 @@ package consensus
 
 import (
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
@@ -60,6 +61,12 @@ var (
 	// SiafundPool is a database bucket storing the current value of the
 	// siafund pool.
 	SiafundPool = []byte("SiafundPool")
+
+	// SpentIDs is a database bucket containing the ids of the siacoin
+	// outputs, siafund outputs, and file contracts that have been spent or
+	// resolved in the current path. Databases created before the bucket was
+	// introduced only contain the items spent after the upgrade.
+	SpentIDs = []byte("SpentIDs")
 )
 
 // createConsensusObjects initialzes the consensus portions of the database.
@@ -74,6 +81,7 @@ func (cs *ConsensusSet) createConsensusDB(tx *bolt.Tx) error {
 		FileContracts,
 		SiafundOutputs,
 		SiafundPool,
+		SpentIDs,
 	}
 	for _, bucket := range buckets {
 		_, err := tx.CreateBucket(bucket)
@@ -418,6 +426,29 @@ func removeSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) {
 	}
 }
 
+// isSpent returns true if the siacoin output, siafund output, or file contract
+// with the given id has been spent or resolved in the current path.
+func isSpent(tx *bolt.Tx, id crypto.Hash) bool {
+	return tx.Bucket(SpentIDs).Get(id[:]) != nil
+}
+
+// markSpent records that the item with the given id has been spent.
+func markSpent(tx *bolt.Tx, id crypto.Hash) {
+	err := tx.Bucket(SpentIDs).Put(id[:], []byte{})
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
+// unmarkSpent removes the spent record of the item with the given id, which
+// happens when its spend is reverted.
+func unmarkSpent(tx *bolt.Tx, id crypto.Hash) {
+	err := tx.Bucket(SpentIDs).Delete(id[:])
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
 // getSiafundPool returns the current value of the siafund pool. No error is
 // returned as the siafund pool should always be available.
 func getSiafundPool(tx *bolt.Tx) (pool types.Currency) {
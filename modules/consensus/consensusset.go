@@ -9,8 +9,10 @@ package consensus
 import (
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/persist"
@@ -68,8 +70,51 @@ type ConsensusSet struct {
 	checkingConsistency bool
 
 	// synced is true if initial blockchain download has finished. It indicates
-	// whether the consensus set is synced with the network.
-	synced bool
+	// whether the consensus set is synced with the network. synced is
+	// protected by syncedMu instead of mu, so that it can be read while a
+	// block is being accepted.
+	synced   bool
+	syncedMu sync.RWMutex
+
+	// pruning indicates whether the transactions of deeply buried blocks
+	// should be discarded as new blocks are added to the current path.
+	pruning bool
+
+	// checkpoints are known-good block ids at specific heights. Blocks that
+	// conflict with a checkpoint are rejected. checkpointHeight is the height
+	// of the highest checkpoint, and the blocks in checkpointAncestors are
+	// known to be its ancestors and are assumed to have valid signatures.
+	checkpoints         map[types.BlockHeight]types.BlockID
+	checkpointHeight    types.BlockHeight
+	checkpointAncestors map[types.BlockID]struct{}
+
+	// orphans contains blocks whose parents are unknown. The orphan pool has
+	// its own lock so that orphans can be added and removed without holding
+	// the consensus set lock.
+	orphans  map[types.BlockID]types.Block
+	orphanMu sync.Mutex
+
+	// alerts contains the most recent alerts raised by the consensus set,
+	// oldest first.
+	alerts  []modules.Alert
+	alertMu sync.Mutex
+
+	// difficulty contains the parameters of the difficulty adjustment
+	// algorithm.
+	difficulty DifficultyConfig
+
+	// validationCache holds transactions that are known to pass
+	// StandaloneValid, so that transactions seen by the transaction pool are
+	// not validated a second time when they appear in a block.
+	validationCache *validationCache
+
+	// acceptQueue holds the blocks received from peers that are waiting to
+	// be accepted by threadedProcessAcceptQueue. closeChan is closed when the
+	// consensus set is closed, and closeOnce ensures that it is only closed
+	// once.
+	acceptQueue chan queuedBlock
+	closeChan   chan struct{}
+	closeOnce   sync.Once
 
 	// Interfaces to abstract the dependencies of the ConsensusSet.
 	marshaler       encoding.GenericMarshaler
@@ -77,6 +122,12 @@ type ConsensusSet struct {
 	blockValidator  blockValidator
 
 	// Utilities
+	//
+	// mu protects the in-memory state of the consensus set, and is held for
+	// writing while blocks are added to the database. Methods that only read
+	// the database do not need to hold mu, as a bolt read transaction sees a
+	// consistent snapshot of the last committed update, even while a block
+	// is being accepted.
 	db         *persist.BoltDatabase
 	log        *persist.Logger
 	mu         demotemutex.DemoteMutex
@@ -87,10 +138,20 @@ type ConsensusSet struct {
 // there is an existing block database present in the persist directory, it
 // will be loaded.
 func New(gateway modules.Gateway, persistDir string) (*ConsensusSet, error) {
+	return NewCustomDifficulty(gateway, persistDir, DefaultDifficultyConfig())
+}
+
+// NewCustomDifficulty returns a new ConsensusSet that adjusts the difficulty
+// according to the provided parameters instead of the parameters of the
+// network selected by build.Release.
+func NewCustomDifficulty(gateway modules.Gateway, persistDir string, difficulty DifficultyConfig) (*ConsensusSet, error) {
 	// Check for nil dependencies.
 	if gateway == nil {
 		return nil, errNilGateway
 	}
+	if err := difficulty.validate(); err != nil {
+		return nil, err
+	}
 
 	// Create the ConsensusSet object.
 	cs := &ConsensusSet{
@@ -98,13 +159,20 @@ func New(gateway modules.Gateway, persistDir string) (*ConsensusSet, error) {
 
 		blockRoot: processedBlock{
 			Block:       types.GenesisBlock,
-			ChildTarget: types.RootTarget,
+			ChildTarget: difficulty.RootTarget,
 			Depth:       types.RootDepth,
 
 			DiffsGenerated: true,
 		},
 
-		dosBlocks: make(map[types.BlockID]struct{}),
+		dosBlocks:   make(map[types.BlockID]struct{}),
+		checkpoints: make(map[types.BlockHeight]types.BlockID),
+		orphans:     make(map[types.BlockID]types.Block),
+		difficulty:  difficulty,
+
+		validationCache: newValidationCache(),
+		acceptQueue:     make(chan queuedBlock, acceptQueueSize),
+		closeChan:       make(chan struct{}),
 
 		marshaler:       encoding.StdGenericMarshaler{},
 		blockRuleHelper: stdBlockRuleHelper{},
@@ -113,6 +181,14 @@ func New(gateway modules.Gateway, persistDir string) (*ConsensusSet, error) {
 		persistDir: persistDir,
 	}
 
+	// Load the compiled-in checkpoints.
+	for height, id := range defaultCheckpoints {
+		cs.checkpoints[height] = id
+		if height > cs.checkpointHeight {
+			cs.checkpointHeight = height
+		}
+	}
+
 	// Create the diffs for the genesis siafund outputs.
 	for i, siafundOutput := range types.GenesisBlock.Transactions[0].SiafundOutputs {
 		sfid := types.GenesisBlock.Transactions[0].SiafundOutputID(uint64(i))
@@ -130,6 +206,9 @@ func New(gateway modules.Gateway, persistDir string) (*ConsensusSet, error) {
 		return nil, err
 	}
 
+	// Start accepting blocks received from peers.
+	go cs.threadedProcessAcceptQueue()
+
 	go func() {
 		// Sync with the network. Don't sync if we are testing because typically we
 		// don't have any mock peers to synchronize with in testing.
@@ -143,12 +222,15 @@ func New(gateway modules.Gateway, persistDir string) (*ConsensusSet, error) {
 		gateway.RegisterRPC("RelayBlock", cs.rpcRelayBlock) // COMPATv0.5.1
 		gateway.RegisterRPC("RelayHeader", cs.rpcRelayHeader)
 		gateway.RegisterRPC("SendBlk", cs.rpcSendBlk)
+		gateway.RegisterRPC("SendBlkRange", cs.rpcSendBlockRange)
+		gateway.RegisterRPC("SendHeaders", cs.rpcSendHeaders)
+		gateway.RegisterRPC("SendTxnProofs", cs.rpcSendTxnProofs)
 		gateway.RegisterConnectCall("SendBlocks", cs.threadedReceiveBlocks)
 
 		// Mark that we are synced with the network.
-		cs.mu.Lock()
+		cs.syncedMu.Lock()
 		cs.synced = true
-		cs.mu.Unlock()
+		cs.syncedMu.Unlock()
 	}()
 
 	return cs, nil
@@ -161,6 +243,9 @@ func (cs *ConsensusSet) BlockAtHeight(height types.BlockHeight) (block types.Blo
 		if err != nil {
 			return err
 		}
+		if isPruned(tx, id) {
+			return errPrunedHistory
+		}
 		pb, err := getBlockMap(tx, id)
 		if err != nil {
 			return err
@@ -186,16 +271,30 @@ func (cs *ConsensusSet) ChildTarget(id types.BlockID) (target types.Target, exis
 	return target, exists
 }
 
-// Close safely closes the block database.
+// Close safely closes the block database. Calling Close more than once
+// returns errConsensusSetClosed.
 func (cs *ConsensusSet) Close() error {
+	err := errConsensusSetClosed
+	cs.closeOnce.Do(func() {
+		err = cs.managedClose()
+	})
+	return err
+}
+
+// managedClose closes the block database and stops the acceptance queue.
+func (cs *ConsensusSet) managedClose() error {
+	close(cs.closeChan)
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
 
-	if cs.synced {
+	if cs.Synced() {
 		cs.gateway.UnregisterRPC("SendBlocks")
 		cs.gateway.UnregisterRPC("RelayBlock") // COMPATv0.5.1
 		cs.gateway.UnregisterRPC("RelayHeader")
 		cs.gateway.UnregisterRPC("SendBlk")
+		cs.gateway.UnregisterRPC("SendBlkRange")
+		cs.gateway.UnregisterRPC("SendHeaders")
+		cs.gateway.UnregisterRPC("SendTxnProofs")
 		cs.gateway.UnregisterConnectCall("SendBlocks")
 	}
 
@@ -211,9 +310,6 @@ func (cs *ConsensusSet) Close() error {
 
 // CurrentBlock returns the latest block in the heaviest known blockchain.
 func (cs *ConsensusSet) CurrentBlock() (block types.Block) {
-	cs.mu.RLock()
-	defer cs.mu.RUnlock()
-
 	_ = cs.db.View(func(tx *bolt.Tx) error {
 		pb := currentProcessedBlock(tx)
 		block = pb.Block
@@ -222,6 +318,24 @@ func (cs *ConsensusSet) CurrentBlock() (block types.Block) {
 	return block
 }
 
+// FileContract returns the status of the open file contract with the given id.
+func (cs *ConsensusSet) FileContract(id types.FileContractID) (status modules.FileContractStatus, exists bool) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		fc, err := getFileContract(tx, id)
+		if err != nil {
+			return err
+		}
+		status.FileContract = fc
+		if len(fc.ValidProofOutputs) > 0 {
+			status.RemainingFunds = fc.ValidProofOutputs[0].Value
+		}
+		status.WindowOpen = blockHeight(tx) >= fc.WindowStart
+		exists = true
+		return nil
+	})
+	return status, exists
+}
+
 // Height returns the height of the consensus set.
 func (cs *ConsensusSet) Height() (height types.BlockHeight) {
 	_ = cs.db.View(func(tx *bolt.Tx) error {
@@ -251,6 +365,16 @@ func (cs *ConsensusSet) InCurrentPath(id types.BlockID) (inPath bool) {
 	return inPath
 }
 
+// IsSpent returns true if the siacoin output, siafund output, or file contract
+// with the given id has been spent or resolved in the current path.
+func (cs *ConsensusSet) IsSpent(id crypto.Hash) (spent bool) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		spent = isSpent(tx, id)
+		return nil
+	})
+	return spent
+}
+
 // MinimumValidChildTimestamp returns the earliest timestamp that the next block
 // can have in order for it to be considered valid.
 func (cs *ConsensusSet) MinimumValidChildTimestamp(id types.BlockID) (timestamp types.Timestamp, exists bool) {
@@ -267,6 +391,44 @@ func (cs *ConsensusSet) MinimumValidChildTimestamp(id types.BlockID) (timestamp
 	return timestamp, exists
 }
 
+// SiacoinOutput returns the unspent siacoin output with the given id.
+func (cs *ConsensusSet) SiacoinOutput(id types.SiacoinOutputID) (sco types.SiacoinOutput, exists bool) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		sco, err = getSiacoinOutput(tx, id)
+		exists = err == nil
+		return nil
+	})
+	return sco, exists
+}
+
+// SiafundOutput returns the unspent siafund output with the given id.
+func (cs *ConsensusSet) SiafundOutput(id types.SiafundOutputID) (sfo types.SiafundOutput, exists bool) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		sfo, err = getSiafundOutput(tx, id)
+		exists = err == nil
+		return nil
+	})
+	return sfo, exists
+}
+
+// StateChecksum returns a checksum of the full consensus set and of the
+// processed blocks in the current path, along with the height and id of the
+// current block. Consensus sets that agree on the current block should always
+// have the same checksum, so comparing checksums is a cheap way to detect
+// consensus divergence. The whole consensus set and the whole current path
+// are read to compute the checksum.
+func (cs *ConsensusSet) StateChecksum() (height types.BlockHeight, id types.BlockID, checksum crypto.Hash) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		height = blockHeight(tx)
+		id = currentBlockID(tx)
+		checksum = stateChecksum(tx)
+		return nil
+	})
+	return height, id, checksum
+}
+
 // StorageProofSegment returns the segment to be used in the storage proof for
 // a given file contract.
 func (cs *ConsensusSet) StorageProofSegment(fcid types.FileContractID) (index uint64, err error) {
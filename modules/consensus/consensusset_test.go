@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -206,3 +207,75 @@ func TestDatabaseClosing(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// TestReadsDuringBlockAcceptance checks that the read-only methods of the
+// consensus set do not wait for the consensus lock, which is held while blocks
+// are being accepted.
+func TestReadsDuringBlockAcceptance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestReadsDuringBlockAcceptance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	cst.cs.mu.Lock()
+	defer cst.cs.mu.Unlock()
+	done := make(chan struct{})
+	go func() {
+		cst.cs.CurrentBlock()
+		cst.cs.Height()
+		cst.cs.ChainStats()
+		cst.cs.Synced()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reads blocked on the consensus lock")
+	}
+}
+
+// TestStateChecksum checks that consensus sets with the same blocks report the
+// same state checksum, and that the checksum changes with the blocks.
+func TestStateChecksum(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := blankConsensusSetTester("TestStateChecksum1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst1.Close()
+	cst2, err := blankConsensusSetTester("TestStateChecksum2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst2.Close()
+
+	_, _, genesisChecksum := cst1.cs.StateChecksum()
+	b, err := cst1.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	height1, id1, checksum1 := cst1.cs.StateChecksum()
+	if height1 != 1 || id1 != b.ID() {
+		t.Fatal("checksum was computed at the wrong block")
+	}
+	if checksum1 == genesisChecksum {
+		t.Fatal("checksum did not change after a block was added")
+	}
+
+	err = cst2.cs.AcceptBlock(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, checksum2 := cst2.cs.StateChecksum()
+	if checksum2 != checksum1 {
+		t.Fatal("consensus sets with the same blocks have different checksums")
+	}
+}
@@ -75,6 +75,35 @@ func consensusChecksum(tx *bolt.Tx) crypto.Hash {
 	return tree.Root()
 }
 
+// pathChecksum returns a checksum of the processed blocks in the current
+// path. It covers the heights, depths, child targets, and diffs of the
+// blocks, which are the same on every consensus set with the same current
+// block. The transactions are left out, as they may have been pruned.
+func pathChecksum(tx *bolt.Tx) crypto.Hash {
+	tree := crypto.NewTree()
+	for height := types.BlockHeight(0); height <= blockHeight(tx); height++ {
+		id, err := getPath(tx, height)
+		if err != nil {
+			manageErr(tx, err)
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			manageErr(tx, err)
+		}
+		tree.Push(id[:])
+		tree.Push(encoding.MarshalAll(pb.Height, pb.Depth, pb.ChildTarget, pb.SiacoinOutputDiffs, pb.FileContractDiffs, pb.SiafundOutputDiffs, pb.DelayedSiacoinOutputDiffs, pb.SiafundPoolDiffs))
+	}
+	return tree.Root()
+}
+
+// stateChecksum returns a checksum of the consensus set together with the
+// processed blocks of the current path. Unlike consensusChecksum, it covers
+// the data that is needed to extend and revert the current path, so it can be
+// used to verify a consensus database obtained from an untrusted source.
+func stateChecksum(tx *bolt.Tx) crypto.Hash {
+	return crypto.HashAll(consensusChecksum(tx), pathChecksum(tx))
+}
+
 // checkSiacoinCount checks that the number of siacoins countable within the
 // consensus set equal the expected number of siacoins for the block height.
 func checkSiacoinCount(tx *bolt.Tx) {
@@ -275,6 +304,62 @@ func checkDSCOs(tx *bolt.Tx) {
 	}
 }
 
+// checkFileContractExpirations checks that the file contract expiration index
+// contains exactly the open file contracts, each under the height at which its
+// proof window closes, and that no contract in the index has already expired.
+func checkFileContractExpirations(tx *bolt.Tx) {
+	currentHeight := blockHeight(tx)
+	indexed := make(map[types.FileContractID]types.BlockHeight)
+	err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		if !bytes.HasPrefix(name, prefixFCEX) {
+			return nil
+		}
+		var height types.BlockHeight
+		err := encoding.Unmarshal(name[len(prefixFCEX):], &height)
+		if err != nil {
+			manageErr(tx, err)
+		}
+		return b.ForEach(func(idBytes, _ []byte) error {
+			if height <= currentHeight {
+				return errors.New("file contract expiration index contains expired contracts")
+			}
+			var id types.FileContractID
+			copy(id[:], idBytes)
+			if _, exists := indexed[id]; exists {
+				return errors.New("file contract appears twice in the expiration index")
+			}
+			indexed[id] = height
+			return nil
+		})
+	})
+	if err != nil {
+		manageErr(tx, err)
+	}
+
+	var numContracts int
+	err = tx.Bucket(FileContracts).ForEach(func(idBytes, fcBytes []byte) error {
+		numContracts++
+		var id types.FileContractID
+		copy(id[:], idBytes)
+		var fc types.FileContract
+		err := encoding.Unmarshal(fcBytes, &fc)
+		if err != nil {
+			manageErr(tx, err)
+		}
+		height, exists := indexed[id]
+		if !exists || height != fc.WindowEnd {
+			return errors.New("file contract is missing from the expiration index")
+		}
+		return nil
+	})
+	if err != nil {
+		manageErr(tx, err)
+	}
+	if numContracts != len(indexed) {
+		manageErr(tx, errors.New("file contract expiration index has the wrong number of contracts"))
+	}
+}
+
 // checkRevertApply reverts the most recent block, checking to see that the
 // consensus set hash matches the hash obtained for the previous block. Then it
 // applies the block again and checks that the consensus set hash matches the
@@ -317,6 +402,7 @@ func (cs *ConsensusSet) checkConsistency(tx *bolt.Tx) {
 	}
 	cs.checkingConsistency = true
 	checkDSCOs(tx)
+	checkFileContractExpirations(tx)
 	checkSiacoinCount(tx)
 	checkSiafundCount(tx)
 	if build.DEBUG {
@@ -0,0 +1,61 @@
+package consensus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestCheckFileContractExpirations checks that checkFileContractExpirations
+// detects file contracts that are missing from the expiration index.
+func TestCheckFileContractExpirations(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestCheckFileContractExpirations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	errRollback := errors.New("rollback")
+	fc := types.FileContract{
+		Payout:    types.NewCurrency64(300e3),
+		WindowEnd: cst.cs.Height() + 10,
+	}
+	id := types.FileContractID{1}
+
+	// An indexed contract passes the check.
+	err = cst.cs.db.Update(func(tx *bolt.Tx) error {
+		addFileContract(tx, id, fc)
+		checkFileContractExpirations(tx)
+		return errRollback
+	})
+	if err != errRollback {
+		t.Fatal(err)
+	}
+
+	// A contract missing from the index fails the check.
+	err = cst.cs.db.Update(func(tx *bolt.Tx) (err error) {
+		addFileContract(tx, id, fc)
+		bucket := tx.Bucket(append(prefixFCEX, encoding.Marshal(fc.WindowEnd)...))
+		if err := bucket.Delete(id[:]); err != nil {
+			return err
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				err = errRollback
+			}
+		}()
+		checkFileContractExpirations(tx)
+		return errors.New("missing index entry was not detected")
+	})
+	if err != errRollback {
+		t.Fatal(err)
+	}
+}
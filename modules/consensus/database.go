@@ -103,6 +103,10 @@ func (cs *ConsensusSet) initDB(tx *bolt.Tx) error {
 	if err != nil {
 		return err
 	}
+	err = createPruneBucket(tx)
+	if err != nil {
+		return err
+	}
 
 	// Place a 'false' in the consistency bucket to indicate that no
 	// inconsistencies have been found.
@@ -0,0 +1,151 @@
+package consensus
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errInvalidDifficultyConfig = errors.New("invalid difficulty adjustment parameters")
+)
+
+// A DifficultyConfig contains the parameters of the difficulty adjustment
+// algorithm. Test networks can use a custom DifficultyConfig to mine blocks
+// quickly without changing the rules of the main network. Every node on a
+// network must use the same DifficultyConfig, and a consensus database must
+// always be opened with the DifficultyConfig that it was created with.
+type DifficultyConfig struct {
+	// BlockFrequency is the desired number of seconds between blocks.
+	BlockFrequency types.BlockHeight
+
+	// RootTarget is the target of the children of the genesis block.
+	RootTarget types.Target
+
+	// TargetWindow is the number of blocks whose timestamps are used to
+	// adjust the target. The target is adjusted every TargetWindow/2 blocks.
+	TargetWindow types.BlockHeight
+
+	// MaxAdjustmentUp and MaxAdjustmentDown clamp the change in target at
+	// each adjustment.
+	MaxAdjustmentUp   *big.Rat
+	MaxAdjustmentDown *big.Rat
+
+	// MinDifficultyGap, if nonzero, allows a block whose timestamp is more
+	// than MinDifficultyGap seconds after the timestamp of its parent to meet
+	// RootTarget instead of the child target of its parent, and keeps the
+	// target from becoming easier than RootTarget. It is meant for test
+	// networks, which would otherwise stall when most of their hashpower
+	// leaves.
+	MinDifficultyGap types.Timestamp
+}
+
+// DefaultDifficultyConfig returns the difficulty adjustment parameters of the
+// network in use.
+func DefaultDifficultyConfig() DifficultyConfig {
+	return NetworkDifficultyConfig(types.CurrentNetwork())
+}
+
+// NetworkDifficultyConfig returns the difficulty adjustment parameters of the
+// network described by 'p'.
+func NetworkDifficultyConfig(p types.NetworkParams) DifficultyConfig {
+	return DifficultyConfig{
+		BlockFrequency:    p.BlockFrequency,
+		RootTarget:        p.RootTarget,
+		TargetWindow:      p.TargetWindow,
+		MaxAdjustmentUp:   new(big.Rat).Set(p.MaxAdjustmentUp),
+		MaxAdjustmentDown: new(big.Rat).Set(p.MaxAdjustmentDown),
+		MinDifficultyGap:  p.MinDifficultyGap,
+	}
+}
+
+// validate returns an error if the parameters cannot be used to adjust the
+// difficulty.
+func (dc DifficultyConfig) validate() error {
+	one := big.NewRat(1, 1)
+	if dc.BlockFrequency == 0 || dc.TargetWindow < 2 || dc.RootTarget == (types.Target{}) {
+		return errInvalidDifficultyConfig
+	}
+	if dc.MaxAdjustmentUp == nil || dc.MaxAdjustmentUp.Cmp(one) < 0 {
+		return errInvalidDifficultyConfig
+	}
+	if dc.MaxAdjustmentDown == nil || dc.MaxAdjustmentDown.Sign() <= 0 || dc.MaxAdjustmentDown.Cmp(one) > 0 {
+		return errInvalidDifficultyConfig
+	}
+	return nil
+}
+
+// adjustmentHeight returns true if the target is adjusted for the children of
+// blocks at 'height'.
+func (dc DifficultyConfig) adjustmentHeight(height types.BlockHeight) bool {
+	return height%(dc.TargetWindow/2) == 0
+}
+
+// clampTargetAdjustment returns a clamped version of the base adjustment
+// value. The clamp keeps the maximum adjustment to ~7x every 2000 blocks on
+// the main network. This ensures that raising and lowering the difficulty
+// requires a minimum amount of total work, which prevents certain classes of
+// difficulty adjusting attacks.
+func (dc DifficultyConfig) clampTargetAdjustment(base *big.Rat) *big.Rat {
+	if base.Cmp(dc.MaxAdjustmentUp) > 0 {
+		return dc.MaxAdjustmentUp
+	} else if base.Cmp(dc.MaxAdjustmentDown) < 0 {
+		return dc.MaxAdjustmentDown
+	}
+	return base
+}
+
+// minDifficultyBlock returns true if a block with the given timestamp is
+// allowed to meet RootTarget instead of the child target of its parent.
+func (dc DifficultyConfig) minDifficultyBlock(parentTimestamp, timestamp types.Timestamp) bool {
+	return dc.MinDifficultyGap != 0 && timestamp > parentTimestamp+dc.MinDifficultyGap
+}
+
+// blockTarget returns the target that a block with the given timestamp must
+// meet to be a child of 'parent'.
+func (dc DifficultyConfig) blockTarget(parent *processedBlock, timestamp types.Timestamp) types.Target {
+	return dc.BlockTarget(parent.ChildTarget, parent.Block.Timestamp, timestamp)
+}
+
+// floorTarget returns 'target', or RootTarget if 'target' is easier than
+// RootTarget and the difficulty floor is enabled.
+func (dc DifficultyConfig) floorTarget(target types.Target) types.Target {
+	if dc.MinDifficultyGap != 0 && target.Cmp(dc.RootTarget) > 0 {
+		return dc.RootTarget
+	}
+	return target
+}
+
+// BlockTarget returns the target that a block with the given timestamp must
+// meet, given the timestamp of its parent and the child target of its parent.
+// The target also determines how much work the block adds to its chain.
+func (dc DifficultyConfig) BlockTarget(parentChildTarget types.Target, parentTimestamp, timestamp types.Timestamp) types.Target {
+	if dc.minDifficultyBlock(parentTimestamp, timestamp) {
+		return dc.RootTarget
+	}
+	return parentChildTarget
+}
+
+// ChildTarget returns the target of the children of the block at 'height',
+// given the child target of its parent. 'windowStart' returns the timestamp of
+// the ancestor of the block that is the given number of blocks below it, and
+// is only called at the heights where the target is adjusted.
+func (dc DifficultyConfig) ChildTarget(parentChildTarget types.Target, height types.BlockHeight, timestamp types.Timestamp, windowStart func(types.BlockHeight) types.Timestamp) types.Target {
+	if !dc.adjustmentHeight(height) {
+		return parentChildTarget
+	}
+
+	// The target is adjusted in proportion to the time that passed between
+	// the block and its TargetWindow'th ancestor, or the genesis block if
+	// there are not TargetWindow blocks yet. The target is converted to a
+	// big.Rat to provide infinite precision during the calculation.
+	windowSize := dc.TargetWindow
+	if height < windowSize {
+		windowSize = height
+	}
+	timePassed := timestamp - windowStart(windowSize)
+	expectedTimePassed := dc.BlockFrequency * windowSize
+	adjustment := dc.clampTargetAdjustment(big.NewRat(int64(timePassed), int64(expectedTimePassed)))
+	return dc.floorTarget(types.RatToTarget(new(big.Rat).Mul(parentChildTarget.Rat(), adjustment)))
+}
@@ -0,0 +1,187 @@
+package consensus
+
+import (
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestDifficultyConfigValidate probes the validate method of the
+// DifficultyConfig type.
+func TestDifficultyConfigValidate(t *testing.T) {
+	if err := DefaultDifficultyConfig().validate(); err != nil {
+		t.Fatal("default difficulty config is invalid:", err)
+	}
+	invalid := []func(*DifficultyConfig){
+		func(dc *DifficultyConfig) { dc.BlockFrequency = 0 },
+		func(dc *DifficultyConfig) { dc.TargetWindow = 1 },
+		func(dc *DifficultyConfig) { dc.RootTarget = types.Target{} },
+		func(dc *DifficultyConfig) { dc.MaxAdjustmentUp = nil },
+		func(dc *DifficultyConfig) { dc.MaxAdjustmentUp = big.NewRat(1, 2) },
+		func(dc *DifficultyConfig) { dc.MaxAdjustmentDown = big.NewRat(0, 1) },
+		func(dc *DifficultyConfig) { dc.MaxAdjustmentDown = big.NewRat(2, 1) },
+	}
+	for i, modify := range invalid {
+		dc := DefaultDifficultyConfig()
+		modify(&dc)
+		if err := dc.validate(); err != errInvalidDifficultyConfig {
+			t.Error(i, "expected errInvalidDifficultyConfig, got", err)
+		}
+	}
+}
+
+// TestCustomDifficulty checks that a consensus set created with a custom
+// difficulty config adjusts the target according to the config.
+func TestCustomDifficulty(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testdir := build.TempDir(modules.ConsensusDir, "TestCustomDifficulty")
+	g, err := gateway.New("localhost:0", filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	// Blocks are mined much faster than the desired block frequency, so the
+	// target should be halved at every adjustment.
+	dc := DifficultyConfig{
+		BlockFrequency:    1e9,
+		RootTarget:        types.Target{128},
+		TargetWindow:      4,
+		MaxAdjustmentUp:   big.NewRat(2, 1),
+		MaxAdjustmentDown: big.NewRat(1, 2),
+	}
+	cs, err := NewCustomDifficulty(g, filepath.Join(testdir, modules.ConsensusDir), dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	expected := []types.Target{{128}, {128}, {64}, {64}, {32}}
+	for height := types.BlockHeight(1); height < types.BlockHeight(len(expected)); height++ {
+		parent := cs.CurrentBlock()
+		target, _ := cs.ChildTarget(parent.ID())
+		if target != expected[height-1] {
+			t.Fatalf("wrong target at height %v: expected %v, got %v", height, expected[height-1], target)
+		}
+		b := types.Block{
+			ParentID:     parent.ID(),
+			Timestamp:    types.CurrentTimestamp(),
+			MinerPayouts: []types.SiacoinOutput{{Value: types.CalculateCoinbase(height)}},
+		}
+		for !checkTarget(b, target) {
+			b.Nonce[0]++
+			if b.Nonce[0] == 0 {
+				b.Nonce[1]++
+			}
+		}
+		err = cs.AcceptBlock(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	target, _ := cs.ChildTarget(cs.CurrentBlock().ID())
+	if target != expected[len(expected)-1] {
+		t.Fatal("wrong target after the final adjustment:", target)
+	}
+}
+
+// TestMinDifficultyGap checks that a block found more than MinDifficultyGap
+// seconds after its parent only needs to meet the root target, and that other
+// blocks still need to meet the child target of their parent.
+func TestMinDifficultyGap(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testdir := build.TempDir(modules.ConsensusDir, "TestMinDifficultyGap")
+	g, err := gateway.New("localhost:0", filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	dc := DifficultyConfig{
+		BlockFrequency:    1e9,
+		RootTarget:        types.Target{128},
+		TargetWindow:      4,
+		MaxAdjustmentUp:   big.NewRat(2, 1),
+		MaxAdjustmentDown: big.NewRat(1, 2),
+		MinDifficultyGap:  100,
+	}
+	cs, err := NewCustomDifficulty(g, filepath.Join(testdir, modules.ConsensusDir), dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+
+	// mineBlock returns a child of the current block with the given
+	// timestamp that meets 'target' but not 'harder'.
+	mineBlock := func(timestamp types.Timestamp, target, harder types.Target) types.Block {
+		b := types.Block{
+			ParentID:     cs.CurrentBlock().ID(),
+			Timestamp:    timestamp,
+			MinerPayouts: []types.SiacoinOutput{{Value: types.CalculateCoinbase(cs.Height() + 1)}},
+		}
+		for !checkTarget(b, target) || checkTarget(b, harder) {
+			b.Nonce[0]++
+			if b.Nonce[0] == 0 {
+				b.Nonce[1]++
+			}
+		}
+		return b
+	}
+
+	// Mine blocks with the timestamp of the genesis block until the target
+	// has been halved twice.
+	for i := 0; i < 4; i++ {
+		target, _ := cs.ChildTarget(cs.CurrentBlock().ID())
+		if err := cs.AcceptBlock(mineBlock(types.GenesisTimestamp, target, types.Target{})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target, _ := cs.ChildTarget(cs.CurrentBlock().ID())
+	if target != (types.Target{32}) {
+		t.Fatal("wrong target:", target)
+	}
+
+	// A block that follows its parent closely must meet the child target.
+	b := mineBlock(types.GenesisTimestamp+dc.MinDifficultyGap, dc.RootTarget, target)
+	if err := cs.AcceptBlock(b); err != modules.ErrBlockUnsolved {
+		t.Fatal("expected ErrBlockUnsolved, got", err)
+	}
+
+	// A block found after the gap only needs to meet the root target. The
+	// target of its children is unchanged.
+	b = mineBlock(types.CurrentTimestamp(), dc.RootTarget, target)
+	if err := cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	if childTarget, _ := cs.ChildTarget(b.ID()); childTarget != target {
+		t.Fatal("minimum difficulty block changed the child target:", childTarget)
+	}
+}
+
+// TestFloorTarget checks that the target is kept from becoming easier than
+// the root target only when the minimum difficulty gap is enabled.
+func TestFloorTarget(t *testing.T) {
+	dc := DefaultDifficultyConfig()
+	dc.RootTarget = types.Target{64}
+	if dc.floorTarget(types.Target{128}) != (types.Target{128}) {
+		t.Fatal("target was floored without a minimum difficulty gap")
+	}
+	dc.MinDifficultyGap = 100
+	if dc.floorTarget(types.Target{128}) != dc.RootTarget {
+		t.Fatal("target easier than the root target was not floored")
+	}
+	if dc.floorTarget(types.Target{32}) != (types.Target{32}) {
+		t.Fatal("target harder than the root target was floored")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
@@ -52,6 +53,22 @@ func commitDiffSetSanity(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirect
 	}
 }
 
+// commitSpentDiff updates the spent record of the item with the given id.
+// Only a diff with a direction of DiffRevert removes an item that exists, so
+// applying one marks the item as spent and reverting one unmarks it. Diffs with
+// a direction of DiffApply create items, and committing them in either
+// direction leaves the spent record untouched.
+func commitSpentDiff(tx *bolt.Tx, id crypto.Hash, diffDir, dir modules.DiffDirection) {
+	if diffDir != modules.DiffRevert {
+		return
+	}
+	if dir == modules.DiffApply {
+		markSpent(tx, id)
+	} else {
+		unmarkSpent(tx, id)
+	}
+}
+
 // commitSiacoinOutputDiff applies or reverts a SiacoinOutputDiff.
 func commitSiacoinOutputDiff(tx *bolt.Tx, scod modules.SiacoinOutputDiff, dir modules.DiffDirection) {
 	if scod.Direction == dir {
@@ -59,6 +76,7 @@ func commitSiacoinOutputDiff(tx *bolt.Tx, scod modules.SiacoinOutputDiff, dir mo
 	} else {
 		removeSiacoinOutput(tx, scod.ID)
 	}
+	commitSpentDiff(tx, crypto.Hash(scod.ID), scod.Direction, dir)
 }
 
 // commitFileContractDiff applies or reverts a FileContractDiff.
@@ -68,6 +86,14 @@ func commitFileContractDiff(tx *bolt.Tx, fcd modules.FileContractDiff, dir modul
 	} else {
 		removeFileContract(tx, fcd.ID)
 	}
+	commitSpentDiff(tx, crypto.Hash(fcd.ID), fcd.Direction, dir)
+
+	// A revision removes the old file contract and adds the revised one under
+	// the same id, so the contract is live again once the revised contract is
+	// applied.
+	if fcd.Direction == modules.DiffApply && dir == modules.DiffApply {
+		unmarkSpent(tx, crypto.Hash(fcd.ID))
+	}
 }
 
 // commitSiafundOutputDiff applies or reverts a Siafund output diff.
@@ -77,6 +103,7 @@ func commitSiafundOutputDiff(tx *bolt.Tx, sfod modules.SiafundOutputDiff, dir mo
 	} else {
 		removeSiafundOutput(tx, sfod.ID)
 	}
+	commitSpentDiff(tx, crypto.Hash(sfod.ID), sfod.Direction, dir)
 }
 
 // commitDelayedSiacoinOutputDiff applies or reverts a delayedSiacoinOutputDiff.
@@ -200,8 +227,9 @@ func commitDiffSet(tx *bolt.Tx, pb *processedBlock, dir modules.DiffDirection) {
 // consensus state. These two actions must happen at the same time because
 // transactions are allowed to depend on each other. We can't be sure that a
 // transaction is valid unless we have applied all of the previous transactions
-// in the block, which means we need to apply while we verify.
-func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
+// in the block, which means we need to apply while we verify. The signatures
+// of the transactions are only checked if 'checkSignatures' is set.
+func generateAndApplyDiff(tx *bolt.Tx, vc *validationCache, pb *processedBlock, checkSignatures bool) error {
 	// Sanity check - the block being applied should have the current block as
 	// a parent.
 	if build.DEBUG && pb.Block.ParentID != currentBlockID(tx) {
@@ -213,11 +241,19 @@ func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
 	// applied.
 	createDSCOBucket(tx, pb.Height+types.MaturityDelay)
 
+	// Check the signatures and other standalone properties of every
+	// transaction in parallel. The height is the same for every transaction,
+	// as the current path is only updated after the whole block is applied.
+	err := validStandaloneTransactions(vc, pb.Block.Transactions, blockHeight(tx), checkSignatures)
+	if err != nil {
+		return err
+	}
+
 	// Validate and apply each transaction in the block. They cannot be
 	// validated all at once because some transactions may not be valid until
 	// previous transactions have been applied.
 	for _, txn := range pb.Block.Transactions {
-		err := validTransaction(tx, txn)
+		err := validTransactionState(tx, txn)
 		if err != nil {
 			return err
 		}
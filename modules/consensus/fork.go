@@ -17,13 +17,17 @@ var (
 // in the ConsensusSet's current path (the "common parent"). It returns the
 // (inclusive) set of blocks between the common parent and 'pb', starting from
 // the former.
+//
+// The id of each block is tracked separately instead of being recomputed from
+// the block, because the ids of pruned blocks cannot be recomputed.
 func backtrackToCurrentPath(tx *bolt.Tx, pb *processedBlock) []*processedBlock {
 	path := []*processedBlock{pb}
+	id := pb.Block.ID()
 	for {
 		// Error is not checked in production code - an error can only indicate
 		// that pb.Height > blockHeight(tx).
 		currentPathID, err := getPath(tx, pb.Height)
-		if currentPathID == pb.Block.ID() {
+		if currentPathID == id {
 			break
 		}
 		// Sanity check - an error should only indicate that pb.Height >
@@ -34,7 +38,8 @@ func backtrackToCurrentPath(tx *bolt.Tx, pb *processedBlock) []*processedBlock {
 
 		// Prepend the next block to the list of blocks leading from the
 		// current path to the input block.
-		pb, err = getBlockMap(tx, pb.Block.ParentID)
+		id = pb.Block.ParentID
+		pb, err = getBlockMap(tx, id)
 		if build.DEBUG && err != nil {
 			panic(err)
 		}
@@ -67,6 +72,11 @@ func (cs *ConsensusSet) revertToBlock(tx *bolt.Tx, pb *processedBlock) (reverted
 			cs.maybeCheckConsistency(tx)
 		}
 	}
+
+	// Validation results are not trusted across a reorg.
+	if len(revertedBlocks) > 0 {
+		cs.validationCache.reset()
+	}
 	return revertedBlocks
 }
 
@@ -81,12 +91,14 @@ func (cs *ConsensusSet) applyUntilBlock(tx *bolt.Tx, pb *processedBlock) (applie
 		if block.DiffsGenerated {
 			commitDiffSet(tx, block, modules.DiffApply)
 		} else {
-			err := generateAndApplyDiff(tx, block)
+			id := block.Block.ID()
+			err := generateAndApplyDiff(tx, cs.validationCache, block, !cs.assumeValid(id))
 			if err != nil {
 				// Mark the block as invalid.
-				cs.dosBlocks[block.Block.ID()] = struct{}{}
+				cs.dosBlocks[id] = struct{}{}
 				return nil, err
 			}
+			delete(cs.checkpointAncestors, id)
 		}
 		appliedBlocks = append(appliedBlocks, block)
 
@@ -104,9 +116,16 @@ func (cs *ConsensusSet) applyUntilBlock(tx *bolt.Tx, pb *processedBlock) (applie
 // forkBlockchain will move the consensus set onto the 'newBlock' fork. An
 // error will be returned if any of the blocks applied in the transition are
 // found to be invalid. forkBlockchain is atomic; the ConsensusSet is only
-// updated if the function returns nil.
+// updated if the function returns nil. Forks that would revert pruned blocks
+// are rejected.
 func (cs *ConsensusSet) forkBlockchain(tx *bolt.Tx, newBlock *processedBlock) (revertedBlocks, appliedBlocks []*processedBlock, err error) {
 	commonParent := backtrackToCurrentPath(tx, newBlock)[0]
+	// Blocks that have been pruned cannot be reverted, and the common parent
+	// must still be intact for the revert to find it.
+	pruneHeight := getPruneHeight(tx)
+	if pruneHeight > 1 && commonParent.Height < pruneHeight {
+		return nil, nil, errPrunedFork
+	}
 	revertedBlocks = cs.revertToBlock(tx, commonParent)
 	appliedBlocks, err = cs.applyUntilBlock(tx, newBlock)
 	if err != nil {
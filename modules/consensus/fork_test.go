@@ -1,8 +1,10 @@
 package consensus
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
 )
 
@@ -103,3 +105,57 @@ func TestRevertToNode(t *testing.T) {
 	}()
 	cst.cs.dbRevertToNode(pb)
 }
+
+// TestForkReusesDiffs checks that blocks are reverted and re-applied using the
+// diffs that were stored when they were first applied.
+func TestForkReusesDiffs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestForkReusesDiffs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	pb := cst.cs.dbCurrentProcessedBlock()
+	if !pb.DiffsGenerated {
+		t.Fatal("diffs were not stored for the current block")
+	}
+	storedBlock := encoding.Marshal(*pb)
+	parent, err := cst.cs.dbGetBlockMap(pb.Block.ParentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grandParent, err := cst.cs.dbGetBlockMap(parent.Block.ParentID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reverting the blocks should restore the consensus set to the state it
+	// had at the grandparent.
+	cst.cs.dbRevertToNode(grandParent)
+	if cst.cs.dbConsensusChecksum() != grandParent.ConsensusChecksum {
+		t.Fatal("consensus set was not restored by reverting the stored diffs")
+	}
+
+	// Re-applying the blocks should use the stored diffs, leaving the
+	// processed blocks unchanged.
+	_, appliedBlocks, err := cst.cs.dbForkBlockchain(pb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(appliedBlocks) != 2 {
+		t.Fatal("wrong number of blocks re-applied:", len(appliedBlocks))
+	}
+	if cst.cs.dbConsensusChecksum() != pb.ConsensusChecksum {
+		t.Fatal("consensus set was not restored by re-applying the stored diffs")
+	}
+	reappliedBlock, err := cst.cs.dbGetBlockMap(pb.Block.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoding.Marshal(*reappliedBlock), storedBlock) {
+		t.Fatal("diffs of the re-applied block changed")
+	}
+}
@@ -0,0 +1,491 @@
+package consensus
+
+// headers.go implements headers-first synchronization. Instead of downloading
+// full blocks from a single peer, the syncing node first downloads the headers
+// of the blocks it is missing. The headers are cheap to transfer and to
+// validate, and allow the node to determine whether the peer's chain is worth
+// downloading at all. The block bodies are then fetched in parallel from
+// multiple peers and applied in order.
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// MaxCatchUpHeaders is the maximum number of block headers that are sent
+	// in a single call to the SendHeaders RPC.
+	MaxCatchUpHeaders = func() types.BlockHeight {
+		switch build.Release {
+		case "dev":
+			return 500
+		case "standard":
+			return 2000
+		case "testing":
+			return 8
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+	// maxSyncHeaders is the maximum number of headers that are downloaded
+	// from a peer in a single headers-first synchronization. The remaining
+	// headers are downloaded in the next synchronization.
+	maxSyncHeaders = func() int {
+		switch build.Release {
+		case "dev":
+			return 20e3
+		case "standard":
+			return 100e3
+		case "testing":
+			return 64
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+	// maxBodyDownloadPeers is the maximum number of peers that block bodies
+	// are downloaded from concurrently during headers-first synchronization.
+	maxBodyDownloadPeers = func() int {
+		switch build.Release {
+		case "dev":
+			return 4
+		case "standard":
+			return 8
+		case "testing":
+			return 3
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	errBodyMismatch         = errors.New("peer sent a block that does not match the requested header")
+	errHeadersNotContiguous = errors.New("received headers do not form a contiguous chain")
+)
+
+// syncStart returns the height of the child of the most recent block in
+// knownBlocks that is in the current path. False is returned if none of the
+// known blocks are in the current path, or if the most recent known block is
+// the current block.
+func syncStart(tx *bolt.Tx, knownBlocks [32]types.BlockID) (types.BlockHeight, bool) {
+	csHeight := blockHeight(tx)
+	for _, id := range knownBlocks {
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			continue
+		}
+		pathID, err := getPath(tx, pb.Height)
+		if err != nil {
+			continue
+		}
+		if pathID != id {
+			continue
+		}
+		if pb.Height == csHeight {
+			return 0, false
+		}
+		// Start from the child of the common block.
+		return pb.Height + 1, true
+	}
+	return 0, false
+}
+
+// targetWindowTimestamps returns the timestamps of 'pb' and of up to
+// 'TargetWindow' of its ancestors, ordered by height.
+func (cs *ConsensusSet) targetWindowTimestamps(blockMap *bolt.Bucket, pb *processedBlock) []types.Timestamp {
+	timestamps := []types.Timestamp{pb.Block.Timestamp}
+	parent := pb.Block.ParentID
+	for i := types.BlockHeight(0); i < cs.difficulty.TargetWindow && parent != (types.BlockID{}); i++ {
+		pbBytes := blockMap.Get(parent[:])
+		timestamps = append(timestamps, types.Timestamp(encoding.DecUint64(pbBytes[40:48])))
+		copy(parent[:], pbBytes[:32])
+	}
+	for i, j := 0, len(timestamps)-1; i < j; i, j = i+1, j-1 {
+		timestamps[i], timestamps[j] = timestamps[j], timestamps[i]
+	}
+	return timestamps
+}
+
+// validateHeaderChain checks that a set of headers forms a contiguous chain
+// that builds on a known block, that every header meets its target, and that
+// every timestamp is no earlier than the median of the preceding timestamps
+// and not in the extreme future. The height of the block that the headers
+// build on and the depth of the last header are returned.
+//
+// The targets are computed from the timestamps in the same way as for full
+// blocks, so the depth is exact. A header chain that passes this check may
+// still contain blocks that get rejected once the full blocks are validated.
+func (cs *ConsensusSet) validateHeaderChain(tx *bolt.Tx, headers []types.BlockHeader) (types.BlockHeight, types.Target, error) {
+	parent, err := getBlockMap(tx, headers[0].ParentID)
+	if err != nil {
+		return 0, types.Target{}, errOrphan
+	}
+	childTarget := parent.ChildTarget
+	depth := parent.Depth
+	height := parent.Height
+	parentID := headers[0].ParentID
+	parentTimestamp := parent.Block.Timestamp
+	maxTimestamp := types.CurrentTimestamp() + types.ExtremeFutureThreshold
+	blockMap := tx.Bucket(BlockMap)
+	windowTimes := recentTimestamps(blockMap, parent)
+	targetTimes := cs.targetWindowTimestamps(blockMap, parent)
+	for _, h := range headers {
+		id := h.ID()
+		if h.ParentID != parentID {
+			return 0, types.Target{}, errHeadersNotContiguous
+		}
+		if _, exists := cs.dosBlocks[id]; exists {
+			return 0, types.Target{}, errDoSBlock
+		}
+		headerTarget := cs.difficulty.BlockTarget(childTarget, parentTimestamp, h.Timestamp)
+		if !checkHeaderTarget(h, headerTarget) {
+			return 0, types.Target{}, modules.ErrBlockUnsolved
+		}
+		if h.Timestamp > maxTimestamp {
+			return 0, types.Target{}, errExtremeFutureTimestamp
+		}
+		// Each header must also follow the median rule applied to full
+		// blocks, using the timestamps of the preceding headers.
+		if h.Timestamp < medianTimestamp(windowTimes) {
+			return 0, types.Target{}, errEarlyTimestamp
+		}
+		windowTimes = append(types.TimestampSlice{h.Timestamp}, windowTimes[:len(windowTimes)-1]...)
+		height++
+		if err := cs.checkCheckpoint(height, id); err != nil {
+			return 0, types.Target{}, err
+		}
+		depth = depth.AddDifficulties(headerTarget)
+
+		// Compute the target of the children of the header, as setChildTarget
+		// does for full blocks.
+		targetTimes = append(targetTimes, h.Timestamp)
+		childTarget = cs.difficulty.ChildTarget(childTarget, height, h.Timestamp, func(depth types.BlockHeight) types.Timestamp {
+			return targetTimes[len(targetTimes)-1-int(depth)]
+		})
+		parentID = id
+		parentTimestamp = h.Timestamp
+	}
+	return parent.Height, depth, nil
+}
+
+// rpcSendHeaders is the receiving end of the SendHeaders RPC. It reads the
+// same 32 block ids as the SendBlocks RPC and returns up to
+// 'MaxCatchUpHeaders' headers starting from the child of the most recent
+// known block, followed by a boolean indicating whether more headers are
+// available.
+func (cs *ConsensusSet) rpcSendHeaders(conn modules.PeerConn) error {
+	var knownBlocks [32]types.BlockID
+	err := encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
+	if err != nil {
+		return err
+	}
+
+	headers := []types.BlockHeader{}
+	moreAvailable := false
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		start, found := syncStart(tx, knownBlocks)
+		if !found {
+			return nil
+		}
+		// The headers of pruned blocks cannot be recomputed, as the merkle
+		// root depends on the discarded transactions.
+		if start < getPruneHeight(tx) {
+			return errPrunedHistory
+		}
+		height := blockHeight(tx)
+		for i := start; i <= height && i < start+MaxCatchUpHeaders; i++ {
+			id, err := getPath(tx, i)
+			if build.DEBUG && err != nil {
+				panic(err)
+			}
+			pb, err := getBlockMap(tx, id)
+			if build.DEBUG && err != nil {
+				panic(err)
+			}
+			headers = append(headers, pb.Block.Header())
+		}
+		moreAvailable = start+MaxCatchUpHeaders <= height
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = encoding.WriteObject(conn, headers); err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, moreAvailable)
+}
+
+// receiveHeaders returns an RPCFunc that is the calling end of the
+// SendHeaders RPC. The headers sent by the peer and the flag indicating
+// whether more headers are available are written to the provided pointers.
+func receiveHeaders(history [32]types.BlockID, headers *[]types.BlockHeader, moreAvailable *bool) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		if err := setSyncDeadline(conn); err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, history); err != nil {
+			return err
+		}
+		maxLen := 8 + uint64(MaxCatchUpHeaders)*types.BlockHeaderSize
+		if err := encoding.ReadObject(conn, headers, maxLen); err != nil {
+			return err
+		}
+		return encoding.ReadObject(conn, moreAvailable, 1)
+	}
+}
+
+// fetchBlock returns an RPCFunc that is the calling end of the SendBlk RPC.
+// Unlike threadedReceiveBlock, the block is not submitted to the consensus
+// set, and is instead written to 'b' after checking that it matches 'id'.
+func fetchBlock(id types.BlockID, b *types.Block) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		if err := encoding.WriteObject(conn, id); err != nil {
+			return err
+		}
+		var block types.Block
+		if err := encoding.ReadObject(conn, &block, types.BlockSizeLimit); err != nil {
+			return err
+		}
+		if block.ID() != id {
+			return errBodyMismatch
+		}
+		*b = block
+		return nil
+	}
+}
+
+// managedDownloadChunk downloads the blocks corresponding to a contiguous set
+// of headers from a peer, the first of which is at height 'start' in the
+// peer's current path. If the peer supports the SendBlkRange RPC, the blocks
+// are downloaded in a single call, otherwise they are downloaded one at a time
+// with SendBlk.
+func (cs *ConsensusSet) managedDownloadChunk(peer modules.NetAddress, useRange bool, start types.BlockHeight, headers []types.BlockHeader, blocks []types.Block) error {
+	if !useRange {
+		for j := range headers {
+			err := cs.gateway.RPC(peer, "SendBlk", fetchBlock(headers[j].ID(), &blocks[j]))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var received []types.Block
+	err := cs.gateway.RPC(peer, "SendBlkRange", receiveBlockRange(start, uint64(len(headers)), &received))
+	if err != nil {
+		return err
+	}
+	// The peer may have reorganized since it sent the headers, in which case
+	// the blocks will not match.
+	if len(received) != len(headers) {
+		return errBodyMismatch
+	}
+	for j := range received {
+		if received[j].ID() != headers[j].ID() {
+			return errBodyMismatch
+		}
+	}
+	copy(blocks, received)
+	return nil
+}
+
+// managedDownloadBodies downloads the blocks corresponding to a set of
+// headers, the first of which is at height 'start'. The headers are split
+// into chunks of up to 'MaxCatchUpBlocks' blocks, which are downloaded
+// concurrently from the peer that sent the headers and up to
+// 'maxBodyDownloadPeers'-1 other outbound peers. Each peer takes the next
+// pending chunk as soon as it finishes its previous one, so faster peers
+// download more of the chunks. A peer that fails to provide a chunk is not
+// used again, and the chunk is returned to the queue for the remaining peers.
+// Chunks that no peer could provide are requested from the peer that sent the
+// headers one final time.
+func (cs *ConsensusSet) managedDownloadBodies(addr modules.NetAddress, start types.BlockHeight, headers []types.BlockHeader) ([]types.Block, error) {
+	peers := []modules.NetAddress{addr}
+	useRange := make(map[modules.NetAddress]bool)
+	for _, p := range cs.gateway.Peers() {
+		useRange[p.NetAddress] = build.VersionCmp(p.Version, blockRangeVersion) >= 0
+		if len(peers) >= maxBodyDownloadPeers {
+			continue
+		}
+		if p.Inbound || p.NetAddress == addr {
+			continue
+		}
+		peers = append(peers, p.NetAddress)
+	}
+
+	chunkSize := int(MaxCatchUpBlocks)
+	numChunks := (len(headers) + chunkSize - 1) / chunkSize
+	chunk := func(c int) (types.BlockHeight, int, int) {
+		first := c * chunkSize
+		last := first + chunkSize
+		if last > len(headers) {
+			last = len(headers)
+		}
+		return start + types.BlockHeight(first), first, last
+	}
+
+	// pending is the queue of chunks that have not been downloaded. Chunks
+	// are taken from the front of the queue, and failed chunks are returned
+	// to the back.
+	var mu sync.Mutex
+	pending := make([]int, numChunks)
+	for c := range pending {
+		pending[c] = c
+	}
+	blocks := make([]types.Block, len(headers))
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(peer modules.NetAddress) {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if len(pending) == 0 {
+					mu.Unlock()
+					return
+				}
+				c := pending[0]
+				pending = pending[1:]
+				mu.Unlock()
+
+				height, first, last := chunk(c)
+				err := cs.managedDownloadChunk(peer, useRange[peer], height, headers[first:last], blocks[first:last])
+				if err != nil {
+					cs.log.Debugf("WARN: failed to download block bodies from %v: %v", peer, err)
+					mu.Lock()
+					pending = append(pending, c)
+					mu.Unlock()
+					return
+				}
+			}
+		}(peer)
+	}
+	wg.Wait()
+
+	for _, c := range pending {
+		height, first, last := chunk(c)
+		err := cs.managedDownloadChunk(addr, useRange[addr], height, headers[first:last], blocks[first:last])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blocks, nil
+}
+
+// managedDownloadHeaders downloads the headers of the blocks that the peer
+// has in its current path after the most recent common block, up to
+// 'maxSyncHeaders' headers. The returned bool is true if the peer has more
+// headers than were downloaded.
+func (cs *ConsensusSet) managedDownloadHeaders(addr modules.NetAddress) ([]types.BlockHeader, bool, error) {
+	var history [32]types.BlockID
+	err := cs.db.View(func(tx *bolt.Tx) error {
+		history = blockHistory(tx)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	var headers []types.BlockHeader
+	moreAvailable := true
+	for moreAvailable {
+		if len(headers) >= maxSyncHeaders {
+			return headers, true, nil
+		}
+		var batch []types.BlockHeader
+		err := cs.gateway.RPC(addr, "SendHeaders", receiveHeaders(history, &batch, &moreAvailable))
+		if err != nil {
+			return nil, false, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		headers = append(headers, batch...)
+		// Put the last received header at the front of the history so that
+		// the peer continues from where the previous batch ended. The
+		// genesis block stays at the end of the history.
+		copy(history[1:31], history[:30])
+		history[0] = batch[len(batch)-1].ID()
+	}
+	return headers, false, nil
+}
+
+// managedHeadersFirstSync synchronizes the consensus set with a peer by
+// downloading the peer's headers ahead of the full blocks. Header chains that
+// do not have more work than the current path are discarded without
+// downloading any block bodies.
+func (cs *ConsensusSet) managedHeadersFirstSync(addr modules.NetAddress) error {
+	headers, truncated, err := cs.managedDownloadHeaders(addr)
+	if err != nil {
+		return err
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+
+	// Validate the headers and determine whether the chain they describe is
+	// heavier than the current path.
+	var forkHeight types.BlockHeight
+	var heavier bool
+	cs.mu.RLock()
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		var depth types.Target
+		var err error
+		forkHeight, depth, err = cs.validateHeaderChain(tx, headers)
+		if err != nil {
+			return err
+		}
+		// A smaller depth is a heavier chain.
+		heavier = depth.Cmp(currentProcessedBlock(tx).Depth) < 0
+		return nil
+	})
+	cs.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	cs.mu.Lock()
+	cs.recordCheckpointAncestors(headers, forkHeight)
+	cs.mu.Unlock()
+	// The work of the peer's chain is not known if its headers were
+	// truncated, in which case the bodies are downloaded as they would be by
+	// SendBlocks, and the remaining headers are downloaded in the next
+	// synchronization.
+	if !heavier && !truncated {
+		return nil
+	}
+
+	// Download and apply the bodies one batch at a time, in order.
+	start := forkHeight + 1
+	for len(headers) > 0 {
+		n := len(headers)
+		if n > int(MaxCatchUpHeaders) {
+			n = int(MaxCatchUpHeaders)
+		}
+		blocks, err := cs.managedDownloadBodies(addr, start, headers[:n])
+		if err != nil {
+			return err
+		}
+		for _, block := range blocks {
+			acceptErr := cs.managedAcceptBlock(block)
+			// Blocks on the peer's fork do not extend the current path until
+			// the fork becomes heavier than the current path.
+			if acceptErr == modules.ErrNonExtendingBlock || acceptErr == modules.ErrBlockKnown {
+				acceptErr = nil
+			}
+			if acceptErr != nil {
+				return acceptErr
+			}
+		}
+		headers = headers[n:]
+		start += types.BlockHeight(n)
+	}
+	return nil
+}
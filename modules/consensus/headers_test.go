@@ -0,0 +1,291 @@
+package consensus
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestIntegrationHeadersFirstSync checks that headers-first synchronization
+// catches up and reorgs the local consensus set in the same situations as the
+// SendBlocks RPC.
+func TestIntegrationHeadersFirstSync(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	tests := []struct {
+		remoteBlocksToMine types.BlockHeight
+		localBlocksToMine  types.BlockHeight
+		msg                string
+	}{
+		{
+			msg: "nothing should happen when both CSs are at the genesis block",
+		},
+		{
+			remoteBlocksToMine: 5,
+			msg:                "the local CS should catch up to the remote CS",
+		},
+		{
+			remoteBlocksToMine: 3*MaxCatchUpHeaders + 1,
+			msg:                "the local CS should catch up across multiple header batches",
+		},
+		{
+			remoteBlocksToMine: 10,
+			localBlocksToMine:  5,
+			msg:                "the local CS should reorg to the longer remote chain",
+		},
+		{
+			remoteBlocksToMine: 2*MaxCatchUpHeaders + 3,
+			localBlocksToMine:  MaxCatchUpHeaders,
+			msg:                "the local CS should reorg to a longer remote chain spanning multiple batches",
+		},
+		{
+			remoteBlocksToMine: 5,
+			localBlocksToMine:  10,
+			msg:                "the local CS should not change when the remote chain is shorter",
+		},
+	}
+	for i, tt := range tests {
+		remoteCST, err := blankConsensusSetTester(filepath.Join("TestIntegrationHeadersFirstSync - remote", strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		localCST, err := blankConsensusSetTester(filepath.Join("TestIntegrationHeadersFirstSync - local", strconv.Itoa(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = localCST.cs.gateway.Connect(remoteCST.cs.gateway.Address())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Wait for the OnConnectRPCs to finish.
+		time.Sleep(100 * time.Millisecond)
+
+		for j := types.BlockHeight(0); j < tt.remoteBlocksToMine; j++ {
+			b, err := remoteCST.miner.FindBlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = remoteCST.cs.managedAcceptBlock(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		for j := types.BlockHeight(0); j < tt.localBlocksToMine; j++ {
+			b, err := localCST.miner.FindBlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = localCST.cs.managedAcceptBlock(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		localCurrentBlockID := localCST.cs.CurrentBlock().ID()
+
+		err = localCST.cs.managedHeadersFirstSync(remoteCST.cs.gateway.Address())
+		if err != nil {
+			t.Errorf("%v: %v", tt.msg, err)
+		}
+		if tt.remoteBlocksToMine > tt.localBlocksToMine {
+			if localCST.cs.CurrentBlock().ID() != remoteCST.cs.CurrentBlock().ID() {
+				t.Errorf("%v: remote and local CSTs have different current blocks", tt.msg)
+			}
+		} else if localCST.cs.CurrentBlock().ID() != localCurrentBlockID {
+			t.Errorf("%v: the local CS changed its current block", tt.msg)
+		}
+
+		err = localCST.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = remoteCST.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestIntegrationHeadersFirstSyncTruncated checks that a peer with more than
+// 'maxSyncHeaders' new headers is synchronized with over multiple calls.
+func TestIntegrationHeadersFirstSyncTruncated(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	remoteCST, err := blankConsensusSetTester("TestIntegrationHeadersFirstSyncTruncated - remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer remoteCST.Close()
+	localCST, err := blankConsensusSetTester("TestIntegrationHeadersFirstSyncTruncated - local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer localCST.Close()
+	err = localCST.cs.gateway.Connect(remoteCST.cs.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// Blocks accepted with managedAcceptBlock are not relayed to the local
+	// CS.
+	for i := 0; i < maxSyncHeaders+5; i++ {
+		b, err := remoteCST.miner.FindBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = remoteCST.cs.managedAcceptBlock(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = localCST.cs.managedHeadersFirstSync(remoteCST.cs.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height := localCST.cs.Height(); height != types.BlockHeight(maxSyncHeaders) {
+		t.Fatalf("expected height %v after the first sync, got %v", maxSyncHeaders, height)
+	}
+	err = localCST.cs.managedHeadersFirstSync(remoteCST.cs.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if localCST.cs.CurrentBlock().ID() != remoteCST.cs.CurrentBlock().ID() {
+		t.Fatal("local CS did not catch up after the second sync")
+	}
+}
+
+// TestValidateHeaderChainDepth checks that the depth computed from a chain of
+// headers matches the depth of the full blocks, across difficulty
+// adjustments.
+func TestValidateHeaderChainDepth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester("TestValidateHeaderChainDepth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	for i := types.BlockHeight(0); i < types.TargetWindow+types.TargetWindow/4; i++ {
+		_, err := cst.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		var headers []types.BlockHeader
+		for height := types.BlockHeight(1); height <= blockHeight(tx); height++ {
+			id, err := getPath(tx, height)
+			if err != nil {
+				return err
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			headers = append(headers, pb.Block.Header())
+		}
+		_, depth, err := cst.cs.validateHeaderChain(tx, headers)
+		if err != nil {
+			return err
+		}
+		if depth != currentProcessedBlock(tx).Depth {
+			t.Error("depth of the headers does not match the depth of the blocks")
+		}
+		// Without the last header, the chain is lighter.
+		_, depth, err = cst.cs.validateHeaderChain(tx, headers[:len(headers)-1])
+		if err != nil {
+			return err
+		}
+		if depth.Cmp(currentProcessedBlock(tx).Depth) <= 0 {
+			t.Error("shorter chain is not lighter")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestValidateHeaderChain probes the header checks performed before block
+// bodies are downloaded.
+func TestValidateHeaderChain(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestValidateHeaderChain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// Build a valid chain of headers on top of the current block without
+	// adding the blocks to the consensus set.
+	b, target, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	solve := func(h types.BlockHeader) types.BlockHeader {
+		for !checkHeaderTarget(h, target) {
+			h.Nonce[0]++
+			if h.Nonce[0] == 0 {
+				h.Nonce[1]++
+			}
+		}
+		return h
+	}
+	var headers []types.BlockHeader
+	h := b.Header()
+	for i := 0; i < 3; i++ {
+		h = solve(h)
+		headers = append(headers, h)
+		h.ParentID = h.ID()
+	}
+
+	check := func(headers []types.BlockHeader) error {
+		return cst.cs.db.View(func(tx *bolt.Tx) error {
+			_, _, err := cst.cs.validateHeaderChain(tx, headers)
+			return err
+		})
+	}
+	if err := check(headers); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(headers[1:]); err != errOrphan {
+		t.Error("expected errOrphan, got", err)
+	}
+	if err := check([]types.BlockHeader{headers[0], headers[2]}); err != errHeadersNotContiguous {
+		t.Error("expected errHeadersNotContiguous, got", err)
+	}
+	future := headers[0]
+	future.Timestamp = types.CurrentTimestamp() + 2*types.ExtremeFutureThreshold
+	if err := check([]types.BlockHeader{solve(future)}); err != errExtremeFutureTimestamp {
+		t.Error("expected errExtremeFutureTimestamp, got", err)
+	}
+	early := headers[2]
+	early.Timestamp = 0
+	if err := check([]types.BlockHeader{headers[0], headers[1], solve(early)}); err != errEarlyTimestamp {
+		t.Error("expected errEarlyTimestamp, got", err)
+	}
+	unsolved := headers[0]
+	for checkHeaderTarget(unsolved, target) {
+		unsolved.Nonce[0]++
+	}
+	if err := check([]types.BlockHeader{unsolved}); err != modules.ErrBlockUnsolved {
+		t.Error("expected ErrBlockUnsolved, got", err)
+	}
+}
@@ -0,0 +1,61 @@
+package consensus
+
+// lightclient.go implements the serving end of the light client protocol.
+// Light clients download block headers using the SendHeaders RPC, and then use
+// the SendTxnProofs RPC to download Merkle proofs for the transactions that
+// are relevant to them.
+
+import (
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+const (
+	// maxTxnFilterSize is the maximum size of an encoded transaction filter
+	// accepted by the SendTxnProofs RPC.
+	maxTxnFilterSize = 1 << 20
+)
+
+// rpcSendTxnProofs is the receiving end of the SendTxnProofs RPC. It reads a
+// list of up to 'MaxCatchUpHeaders' block ids and a transaction filter, and
+// returns proofs for every transaction in those blocks that matches the
+// filter. Blocks that are unknown or pruned are skipped.
+func (cs *ConsensusSet) rpcSendTxnProofs(conn modules.PeerConn) error {
+	var ids []types.BlockID
+	err := encoding.ReadObject(conn, &ids, 8+uint64(MaxCatchUpHeaders)*crypto.HashSize)
+	if err != nil {
+		return err
+	}
+	var filter modules.TransactionFilter
+	err = encoding.ReadObject(conn, &filter, maxTxnFilterSize)
+	if err != nil {
+		return err
+	}
+
+	proofs := []types.TransactionProof{}
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		for _, id := range ids {
+			if isPruned(tx, id) {
+				continue
+			}
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				continue
+			}
+			for i, txn := range pb.Block.Transactions {
+				if filter.Match(txn) {
+					proofs = append(proofs, pb.Block.TransactionProof(i))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, proofs)
+}
@@ -0,0 +1,89 @@
+package consensus
+
+// orphans.go implements the orphan pool. Blocks whose parent is unknown are
+// kept in the orphan pool while the missing parents are requested from the
+// peer that sent them. When a parent is added to the block tree, its orphaned
+// children are accepted as well, which means that a node that falls a few
+// blocks behind the tip does not need to download the orphans a second time.
+//
+// Orphans cannot be fully validated, so only blocks that meet a target close
+// to the current target are kept, and the pool is limited in size.
+
+import (
+	"math/big"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// maxOrphanBlocks is the maximum number of blocks held in the orphan
+	// pool. When the pool is full, an arbitrary orphan is evicted to make
+	// room for a new one.
+	maxOrphanBlocks = func() int {
+		switch build.Release {
+		case "dev":
+			return 20
+		case "standard":
+			return 50
+		case "testing":
+			return 5
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// orphanTarget returns the easiest target that an orphan block can meet to be
+// kept in the orphan pool. Orphans are expected to be near the tip of the
+// current path, so the target is the current child target, loosened by one
+// maximum adjustment.
+func (cs *ConsensusSet) orphanTarget(tx *bolt.Tx) types.Target {
+	target := currentProcessedBlock(tx).ChildTarget
+	return types.RatToTarget(new(big.Rat).Mul(target.Rat(), cs.difficulty.MaxAdjustmentUp))
+}
+
+// managedAddOrphan adds a block to the orphan pool, evicting another orphan if
+// the pool is full.
+func (cs *ConsensusSet) managedAddOrphan(b types.Block) {
+	cs.orphanMu.Lock()
+	defer cs.orphanMu.Unlock()
+	id := b.ID()
+	if _, exists := cs.orphans[id]; exists {
+		return
+	}
+	if len(cs.orphans) >= maxOrphanBlocks {
+		for evictID := range cs.orphans {
+			delete(cs.orphans, evictID)
+			break
+		}
+	}
+	cs.orphans[id] = b
+}
+
+// managedAcceptOrphans removes the children of 'parentID' from the orphan pool
+// and tries to add them to the consensus set. The children of the accepted
+// orphans are accepted as well. Orphans that turn out to be invalid are
+// discarded.
+func (cs *ConsensusSet) managedAcceptOrphans(parentID types.BlockID) {
+	cs.orphanMu.Lock()
+	delete(cs.orphans, parentID)
+	var children []types.Block
+	for id, b := range cs.orphans {
+		if b.ParentID == parentID {
+			children = append(children, b)
+			delete(cs.orphans, id)
+		}
+	}
+	cs.orphanMu.Unlock()
+
+	for _, b := range children {
+		err := cs.managedAcceptBlock(b)
+		if err != nil && err != modules.ErrNonExtendingBlock && err != modules.ErrBlockKnown {
+			cs.log.Debugln("WARN: discarding invalid orphan block:", err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestOrphanPool checks that orphan blocks are kept until their parents
+// arrive, and then accepted.
+func TestOrphanPool(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := blankConsensusSetTester("TestOrphanPool1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst1.Close()
+	cst2, err := blankConsensusSetTester("TestOrphanPool2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst2.Close()
+
+	// Mine a chain on cst2.
+	var blocks []types.Block
+	for i := 0; i < 4; i++ {
+		b, err := cst2.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		blocks = append(blocks, b)
+	}
+
+	// Give cst1 the blocks in reverse order. All but the first block are
+	// orphans, and should be accepted once the first block arrives.
+	for i := len(blocks) - 1; i > 0; i-- {
+		err := cst1.cs.AcceptBlock(blocks[i])
+		if err != errOrphan {
+			t.Fatal("expected errOrphan, got", err)
+		}
+	}
+	if len(cst1.cs.orphans) != len(blocks)-1 {
+		t.Fatal("orphans were not added to the orphan pool")
+	}
+	err = cst1.cs.AcceptBlock(blocks[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cst1.cs.CurrentBlock().ID() != cst2.cs.CurrentBlock().ID() {
+		t.Fatal("orphans were not accepted after their parent arrived")
+	}
+	if len(cst1.cs.orphans) != 0 {
+		t.Error("accepted orphans were not removed from the orphan pool")
+	}
+}
+
+// TestOrphanPoolLimit checks that the orphan pool does not grow beyond
+// maxOrphanBlocks.
+func TestOrphanPoolLimit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester("TestOrphanPoolLimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	for i := 0; i < 2*maxOrphanBlocks; i++ {
+		b := types.Block{
+			ParentID:  types.BlockID{byte(i), 1},
+			Timestamp: types.CurrentTimestamp(),
+		}
+		for !checkTarget(b, types.RootTarget) {
+			b.Nonce[0]++
+		}
+		err := cst.cs.AcceptBlock(b)
+		if err != errOrphan {
+			t.Fatal("expected errOrphan, got", err)
+		}
+	}
+	if len(cst.cs.orphans) != maxOrphanBlocks {
+		t.Fatalf("expected %v orphans, got %v", maxOrphanBlocks, len(cst.cs.orphans))
+	}
+
+	// Orphans that do not meet the orphan target are not kept.
+	cst.cs.orphans = make(map[types.BlockID]types.Block)
+	b := types.Block{ParentID: types.BlockID{1}}
+	for checkTarget(b, types.Target{192}) {
+		b.Nonce[0]++
+	}
+	err = cst.cs.AcceptBlock(b)
+	if err != errOrphan {
+		t.Fatal("expected errOrphan, got", err)
+	}
+	if len(cst.cs.orphans) != 0 {
+		t.Error("unsolved orphan was added to the orphan pool")
+	}
+}
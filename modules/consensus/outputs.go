@@ -0,0 +1,72 @@
+package consensus
+
+// outputs.go provides paginated iteration over the unspent outputs of the
+// consensus set. Each page is read in a single bolt transaction, which gives
+// a consistent view of the database without holding the consensus set lock
+// or copying the whole output set. Outputs are returned in the byte order of
+// their ids, which is the order that bolt stores them in, so the id of the
+// last output in a page is enough to find the next page.
+
+import (
+	"bytes"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// forEachAfter calls 'fn' on up to 'limit' entries of a bucket, starting with
+// the first entry whose key is greater than 'after'.
+func forEachAfter(b *bolt.Bucket, after []byte, limit int, fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	k, v := c.Seek(after)
+	if k != nil && bytes.Equal(k, after) {
+		k, v = c.Next()
+	}
+	for n := 0; k != nil && n < limit; n++ {
+		err := fn(k, v)
+		if err != nil {
+			return err
+		}
+		k, v = c.Next()
+	}
+	return nil
+}
+
+// SiacoinOutputs returns up to 'limit' unspent siacoin outputs in order of
+// id, starting with the first output whose id is greater than 'after'.
+func (cs *ConsensusSet) SiacoinOutputs(after types.SiacoinOutputID, limit int) (entries []modules.SiacoinOutputEntry) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		return forEachAfter(tx.Bucket(SiacoinOutputs), after[:], limit, func(k, v []byte) error {
+			var entry modules.SiacoinOutputEntry
+			copy(entry.ID[:], k)
+			err := encoding.Unmarshal(v, &entry.SiacoinOutput)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries
+}
+
+// SiafundOutputs returns up to 'limit' unspent siafund outputs in order of
+// id, starting with the first output whose id is greater than 'after'.
+func (cs *ConsensusSet) SiafundOutputs(after types.SiafundOutputID, limit int) (entries []modules.SiafundOutputEntry) {
+	_ = cs.db.View(func(tx *bolt.Tx) error {
+		return forEachAfter(tx.Bucket(SiafundOutputs), after[:], limit, func(k, v []byte) error {
+			var entry modules.SiafundOutputEntry
+			copy(entry.ID[:], k)
+			err := encoding.Unmarshal(v, &entry.SiafundOutput)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	return entries
+}
@@ -0,0 +1,82 @@
+package consensus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestSiacoinOutputsPages checks that paging through the unspent siacoin
+// outputs returns every output exactly once, in order of id.
+func TestSiacoinOutputsPages(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestSiacoinOutputsPages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	var total int
+	_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+		total = tx.Bucket(SiacoinOutputs).Stats().KeyN
+		return nil
+	})
+	if total < 3 {
+		t.Fatal("not enough outputs to test paging:", total)
+	}
+
+	var after types.SiacoinOutputID
+	var seen int
+	for {
+		page := cst.cs.SiacoinOutputs(after, 2)
+		if len(page) == 0 {
+			break
+		}
+		if len(page) > 2 {
+			t.Fatal("page is larger than the limit")
+		}
+		for _, entry := range page {
+			if bytes.Compare(entry.ID[:], after[:]) <= 0 {
+				t.Fatal("outputs are not in order of id")
+			}
+			sco, exists := cst.cs.SiacoinOutput(entry.ID)
+			if !exists || sco.Value.Cmp(entry.SiacoinOutput.Value) != 0 {
+				t.Fatal("page contains an output that does not match the consensus set")
+			}
+			after = entry.ID
+			seen++
+		}
+	}
+	if seen != total {
+		t.Fatalf("paged through %v outputs, expected %v", seen, total)
+	}
+}
+
+// TestSiafundOutputsPages checks that the siafund outputs can be paged
+// through.
+func TestSiafundOutputsPages(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestSiafundOutputsPages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	all := cst.cs.SiafundOutputs(types.SiafundOutputID{}, 1000)
+	if len(all) == 0 {
+		t.Fatal("no siafund outputs were returned")
+	}
+	rest := cst.cs.SiafundOutputs(all[0].ID, 1000)
+	if len(rest) != len(all)-1 {
+		t.Fatal("the output given as 'after' was not skipped")
+	}
+}
@@ -35,6 +35,19 @@ func (cs *ConsensusSet) loadDB() error {
 			return cs.initDB(tx)
 		}
 
+		// Databases created before pruning was introduced do not have a
+		// prune height bucket.
+		err := createPruneBucket(tx)
+		if err != nil {
+			return err
+		}
+		// Databases created before spent ids were tracked do not have a
+		// spent ids bucket.
+		_, err = tx.CreateBucketIfNotExists(SpentIDs)
+		if err != nil {
+			return err
+		}
+
 		// Check that inconsistencies have not been detected in the database.
 		if inconsistencyDetected(tx) {
 			return errors.New("database contains inconsistencies")
@@ -30,6 +30,11 @@ type processedBlock struct {
 	Depth       types.Target
 	ChildTarget types.Target
 
+	// The diffs record every change that the block made to the consensus
+	// set. They are generated the first time that the block is applied, and
+	// are used from then on to revert and re-apply the block without
+	// revalidating it, and to build the consensus changes that are sent to
+	// subscribers.
 	DiffsGenerated            bool
 	SiacoinOutputDiffs        []modules.SiacoinOutputDiff
 	FileContractDiffs         []modules.FileContractDiff
@@ -56,47 +61,16 @@ func (pb *processedBlock) childDepth() types.Target {
 	return pb.Depth.AddDifficulties(pb.ChildTarget)
 }
 
-// targetAdjustmentBase returns the magnitude that the target should be
-// adjusted by before a clamp is applied.
-func (cs *ConsensusSet) targetAdjustmentBase(blockMap *bolt.Bucket, pb *processedBlock) *big.Rat {
-	// Grab the block that was generated 'TargetWindow' blocks prior to the
-	// parent. If there are not 'TargetWindow' blocks yet, stop at the genesis
-	// block.
-	var windowSize types.BlockHeight
-	parent := pb.Block.ParentID
+// ancestorTimestamp returns the timestamp of the ancestor of 'pb' that is
+// 'depth' blocks below it.
+func ancestorTimestamp(blockMap *bolt.Bucket, pb *processedBlock, depth types.BlockHeight) types.Timestamp {
 	current := pb.Block.ID()
-	for windowSize = 0; windowSize < types.TargetWindow && parent != (types.BlockID{}); windowSize++ {
+	parent := pb.Block.ParentID
+	for i := types.BlockHeight(0); i < depth && parent != (types.BlockID{}); i++ {
 		current = parent
 		copy(parent[:], blockMap.Get(parent[:])[:32])
 	}
-	timestamp := types.Timestamp(encoding.DecUint64(blockMap.Get(current[:])[40:48]))
-
-	// The target of a child is determined by the amount of time that has
-	// passed between the generation of its immediate parent and its
-	// TargetWindow'th parent. The expected amount of seconds to have passed is
-	// TargetWindow*BlockFrequency. The target is adjusted in proportion to how
-	// time has passed vs. the expected amount of time to have passed.
-	//
-	// The target is converted to a big.Rat to provide infinite precision
-	// during the calculation. The big.Rat is just the int representation of a
-	// target.
-	timePassed := pb.Block.Timestamp - timestamp
-	expectedTimePassed := types.BlockFrequency * windowSize
-	return big.NewRat(int64(timePassed), int64(expectedTimePassed))
-}
-
-// clampTargetAdjustment returns a clamped version of the base adjustment
-// value. The clamp keeps the maximum adjustment to ~7x every 2000 blocks. This
-// ensures that raising and lowering the difficulty requires a minimum amount
-// of total work, which prevents certain classes of difficulty adjusting
-// attacks.
-func clampTargetAdjustment(base *big.Rat) *big.Rat {
-	if base.Cmp(types.MaxAdjustmentUp) > 0 {
-		return types.MaxAdjustmentUp
-	} else if base.Cmp(types.MaxAdjustmentDown) < 0 {
-		return types.MaxAdjustmentDown
-	}
-	return base
+	return types.Timestamp(encoding.DecUint64(blockMap.Get(current[:])[40:48]))
 }
 
 // setChildTarget computes the target of a blockNode's child. All children of a node
@@ -110,24 +84,21 @@ func (cs *ConsensusSet) setChildTarget(blockMap *bolt.Bucket, pb *processedBlock
 		panic(err)
 	}
 
-	if pb.Height%(types.TargetWindow/2) != 0 {
-		pb.ChildTarget = parent.ChildTarget
-		return
-	}
-	adjustment := clampTargetAdjustment(cs.targetAdjustmentBase(blockMap, pb))
-	adjustedRatTarget := new(big.Rat).Mul(parent.ChildTarget.Rat(), adjustment)
-	pb.ChildTarget = types.RatToTarget(adjustedRatTarget)
+	pb.ChildTarget = cs.difficulty.ChildTarget(parent.ChildTarget, pb.Height, pb.Block.Timestamp, func(depth types.BlockHeight) types.Timestamp {
+		return ancestorTimestamp(blockMap, pb, depth)
+	})
 }
 
 // newChild creates a blockNode from a block and adds it to the parent's set of
 // children. The new node is also returned. It necessairly modifies the database
 func (cs *ConsensusSet) newChild(tx *bolt.Tx, pb *processedBlock, b types.Block) *processedBlock {
-	// Create the child node.
+	// Create the child node. A block mined at the minimum difficulty only
+	// adds the minimum difficulty to the depth.
 	childID := b.ID()
 	child := &processedBlock{
 		Block:  b,
 		Height: pb.Height + 1,
-		Depth:  pb.childDepth(),
+		Depth:  pb.Depth.AddDifficulties(cs.difficulty.blockTarget(pb, b.Timestamp)),
 	}
 	blockMap := tx.Bucket(BlockMap)
 	cs.setChildTarget(blockMap, child)
@@ -0,0 +1,133 @@
+package consensus
+
+// prune.go implements the optional pruning mode of the consensus set. When
+// pruning is enabled, the transactions of blocks in the current path are
+// discarded once the blocks are buried beneath 'pruneDepth' other blocks. The
+// block headers, miner payouts, and diffs are kept, meaning that the consensus
+// set can still compute targets and timestamps. Pruned blocks are never
+// reverted: forks whose common parent has been pruned are rejected with
+// errPrunedFork. Pruned blocks can also no longer be served to peers or to
+// subscribers that need to catch up from before the prune height.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	// BlockPruneHeight is a database bucket storing the height below which
+	// the transactions of all blocks in the current path have been discarded.
+	// The genesis block is never pruned.
+	BlockPruneHeight = []byte("BlockPruneHeight")
+
+	errPrunedFork    = errors.New("block forks from a pruned portion of the blockchain")
+	errPrunedHistory = errors.New("requested blocks have been pruned from the consensus set")
+
+	// pruneDepth is the number of blocks that need to be built on top of a
+	// block before the transactions of that block are discarded. Reorgs of
+	// pruneDepth or more blocks are rejected by a pruned consensus set. pruneDepth must
+	// be larger than the maturity delay, as the explorer and the miner look up
+	// blocks that are MaturityDelay blocks old.
+	pruneDepth = func() types.BlockHeight {
+		switch build.Release {
+		case "dev":
+			return 100
+		case "standard":
+			return 1000
+		case "testing":
+			return 10
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// createPruneBucket creates the prune height bucket if it does not exist yet,
+// setting the prune height to 1 so that only the genesis block is considered
+// unpruned in new or older databases.
+func createPruneBucket(tx *bolt.Tx) error {
+	bucket, err := tx.CreateBucketIfNotExists(BlockPruneHeight)
+	if err != nil {
+		return err
+	}
+	if bucket.Get(BlockPruneHeight) != nil {
+		return nil
+	}
+	return bucket.Put(BlockPruneHeight, encoding.Marshal(types.BlockHeight(1)))
+}
+
+// getPruneHeight returns the height below which blocks in the current path
+// have been pruned.
+func getPruneHeight(tx *bolt.Tx) (height types.BlockHeight) {
+	err := encoding.Unmarshal(tx.Bucket(BlockPruneHeight).Get(BlockPruneHeight), &height)
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+	return height
+}
+
+// setPruneHeight updates the height below which blocks in the current path
+// have been pruned.
+func setPruneHeight(tx *bolt.Tx, height types.BlockHeight) {
+	err := tx.Bucket(BlockPruneHeight).Put(BlockPruneHeight, encoding.Marshal(height))
+	if build.DEBUG && err != nil {
+		panic(err)
+	}
+}
+
+// isPruned returns true if the block with the given id is in the current path
+// and has had its transactions discarded.
+func isPruned(tx *bolt.Tx, id types.BlockID) bool {
+	pb, err := getBlockMap(tx, id)
+	if err != nil {
+		return false
+	}
+	if pb.Height == 0 || pb.Height >= getPruneHeight(tx) {
+		return false
+	}
+	pathID, err := getPath(tx, pb.Height)
+	return err == nil && pathID == id
+}
+
+// pruneBlocks discards the transactions of every block in the current path
+// that is buried beneath at least pruneDepth blocks. The block is stored
+// under its original id, as the id of a block cannot be recomputed once its
+// transactions have been discarded.
+func pruneBlocks(tx *bolt.Tx) {
+	height := blockHeight(tx)
+	if height < pruneDepth {
+		return
+	}
+	pruneHeight := getPruneHeight(tx)
+	for ; pruneHeight <= height-pruneDepth; pruneHeight++ {
+		id, err := getPath(tx, pruneHeight)
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+		pb, err := getBlockMap(tx, id)
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+		pb.Block.Transactions = nil
+		err = tx.Bucket(BlockMap).Put(id[:], encoding.Marshal(*pb))
+		if build.DEBUG && err != nil {
+			panic(err)
+		}
+	}
+	setPruneHeight(tx, pruneHeight)
+}
+
+// SetPruning enables or disables the pruning mode of the consensus set. When
+// enabled, the transactions of deeply buried blocks are discarded, reducing
+// the disk footprint of the consensus database. Blocks that have already been
+// pruned stay pruned after pruning is disabled.
+func (cs *ConsensusSet) SetPruning(enabled bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.pruning = enabled
+}
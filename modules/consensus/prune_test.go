@@ -0,0 +1,78 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestPruning checks that a consensus set with pruning enabled discards the
+// transactions of deeply buried blocks while leaving recent blocks intact.
+func TestPruning(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestPruning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// The block at height 1 contains the siafund transaction from
+	// addSiafunds.
+	b, exists := cst.cs.BlockAtHeight(1)
+	if !exists || len(b.Transactions) == 0 {
+		t.Fatal("block at height 1 should exist and contain transactions")
+	}
+	oldID := b.ID()
+
+	cst.cs.SetPruning(true)
+	for i := 0; i < int(pruneDepth); i++ {
+		_, err := cst.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The block at height 1 should be pruned, the current block should not.
+	_, exists = cst.cs.BlockAtHeight(1)
+	if exists {
+		t.Error("pruned block is still being returned by BlockAtHeight")
+	}
+	_, exists = cst.cs.BlockAtHeight(cst.cs.Height())
+	if !exists {
+		t.Error("current block was pruned")
+	}
+	_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+		if !isPruned(tx, oldID) {
+			t.Error("block at height 1 is not marked as pruned")
+		}
+		pb, err := getBlockMap(tx, oldID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pb.Block.Transactions) != 0 {
+			t.Error("pruned block still has transactions")
+		}
+		if getPruneHeight(tx) != cst.cs.Height()-pruneDepth+1 {
+			t.Error("wrong prune height:", getPruneHeight(tx))
+		}
+		return nil
+	})
+
+	// Subscribing from the beginning requires the pruned blocks.
+	ms := newMockSubscriber()
+	err = cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning)
+	if err != errPrunedHistory {
+		t.Error("expected errPrunedHistory, got", err)
+	}
+
+	// Blocks should continue to be accepted after pruning.
+	_, err = cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
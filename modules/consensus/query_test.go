@@ -0,0 +1,177 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestStateQueries probes the SiacoinOutput, FileContract, and IsSpent
+// methods of the consensus set.
+func TestStateQueries(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestStateQueries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// Create a transaction that spends siacoins and creates a file contract.
+	payout := types.NewCurrency64(400e6)
+	height := cst.cs.Height()
+	fc := types.FileContract{
+		WindowStart: height + 3,
+		WindowEnd:   height + 4,
+		Payout:      payout,
+		ValidProofOutputs: []types.SiacoinOutput{{
+			Value: types.PostTax(height, payout),
+		}},
+		MissedProofOutputs: []types.SiacoinOutput{{
+			Value: types.PostTax(height, payout),
+		}},
+	}
+	txnBuilder := cst.wallet.StartTransaction()
+	err = txnBuilder.FundSiacoins(payout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcIndex := txnBuilder.AddFileContract(fc)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first transaction of the set spends a confirmed output.
+	txn := txnSet[len(txnSet)-1]
+	spentID := txnSet[0].SiacoinInputs[0].ParentID
+	if _, exists := cst.cs.SiacoinOutput(spentID); !exists {
+		t.Fatal("funding output is not in the consensus set")
+	}
+	if cst.cs.IsSpent(crypto.Hash(spentID)) {
+		t.Fatal("unspent output is reported as spent")
+	}
+	err = cst.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, exists := cst.cs.SiacoinOutput(spentID); exists {
+		t.Error("spent output is still in the consensus set")
+	}
+	if !cst.cs.IsSpent(crypto.Hash(spentID)) {
+		t.Error("spent output is not reported as spent")
+	}
+	fcid := txn.FileContractID(fcIndex)
+	status, exists := cst.cs.FileContract(fcid)
+	if !exists {
+		t.Fatal("file contract is not in the consensus set")
+	}
+	if status.RemainingFunds.Cmp(types.PostTax(height, payout)) != 0 {
+		t.Error("wrong remaining funds reported:", status.RemainingFunds)
+	}
+	if status.WindowOpen {
+		t.Error("proof window is reported as open before it started")
+	}
+
+	// Mine until the proof window opens, then until the contract expires.
+	for cst.cs.Height() < fc.WindowStart {
+		_, err = cst.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	status, exists = cst.cs.FileContract(fcid)
+	if !exists || !status.WindowOpen {
+		t.Error("proof window is not reported as open")
+	}
+	for cst.cs.Height() <= fc.WindowEnd {
+		_, err = cst.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, exists := cst.cs.FileContract(fcid); exists {
+		t.Error("expired file contract is still in the consensus set")
+	}
+	if !cst.cs.IsSpent(crypto.Hash(fcid)) {
+		t.Error("expired file contract is not reported as resolved")
+	}
+}
+
+// TestIsSpentAfterReorg checks that reverting the block that created an output
+// does not report the output as spent, and that reverting the block that spent
+// an output reports it as unspent again.
+func TestIsSpentAfterReorg(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestIsSpentAfterReorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+
+	// Create a block without transactions that will later form a competing
+	// fork.
+	forkBlock, forkTarget, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mine a block that spends a wallet output and creates new outputs.
+	txns, err := cst.wallet.SendSiacoins(types.NewCurrency64(1), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spentID := txns[0].SiacoinInputs[0].ParentID
+	createdID := txns[len(txns)-1].SiacoinOutputID(0)
+	if _, err := cst.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if !cst.cs.IsSpent(crypto.Hash(spentID)) || cst.cs.IsSpent(crypto.Hash(createdID)) {
+		t.Fatal("outputs have the wrong spent status before the reorg")
+	}
+
+	// Extend the competing fork by two blocks, reverting the block.
+	solvedForkBlock, _ := cst.miner.SolveBlock(forkBlock, forkTarget)
+	err = cst.cs.AcceptBlock(solvedForkBlock)
+	if err != modules.ErrNonExtendingBlock {
+		t.Fatal("expected ErrNonExtendingBlock, got", err)
+	}
+	child, _, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	child.ParentID = solvedForkBlock.ID()
+	child.Transactions = nil
+	childTarget, ok := cst.cs.ChildTarget(solvedForkBlock.ID())
+	if !ok {
+		t.Fatal("no child target for the fork block")
+	}
+	solvedChild, _ := cst.miner.SolveBlock(child, childTarget)
+	if err := cst.cs.AcceptBlock(solvedChild); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, exists := cst.cs.SiacoinOutput(createdID); exists {
+		t.Error("output of a reverted block is still in the consensus set")
+	}
+	if cst.cs.IsSpent(crypto.Hash(createdID)) {
+		t.Error("output of a reverted block is reported as spent")
+	}
+	if _, exists := cst.cs.SiacoinOutput(spentID); !exists {
+		t.Error("output spent in a reverted block is not back in the consensus set")
+	}
+	if cst.cs.IsSpent(crypto.Hash(spentID)) {
+		t.Error("output spent in a reverted block is still reported as spent")
+	}
+}
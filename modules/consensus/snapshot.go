@@ -0,0 +1,225 @@
+package consensus
+
+// snapshot.go implements exporting and importing the consensus database. A
+// snapshot contains a header describing the block that the snapshot was taken
+// at, followed by a copy of the full consensus database. New nodes can import
+// a snapshot instead of downloading and validating the whole blockchain. The
+// snapshot file itself is untrusted: the importing node requires the user to
+// supply the id of the block that the snapshot ends at and the checksum of the
+// consensus state at that block, both obtained from a trusted source, and
+// verifies the consensus state in the snapshot against them. The checksum
+// covers the diffs of the blocks in the current path, and the heights, depths
+// and child targets of those blocks are recomputed from the block headers.
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+var (
+	errSnapshotBlock     = errors.New("snapshot contains a processed block that does not match the blockchain")
+	errSnapshotChecksum  = errors.New("snapshot consensus state does not match its checksum")
+	errSnapshotExists    = errors.New("cannot import a snapshot over an existing consensus database")
+	errSnapshotHeader    = errors.New("snapshot header does not match the snapshot database")
+	errSnapshotUntrusted = errors.New("snapshot does not end at the trusted block with the trusted checksum")
+
+	snapshotMetadata = persist.Metadata{
+		Header:  "Consensus Set Snapshot",
+		Version: "1.0.0",
+	}
+)
+
+// snapshotHeader is written at the start of every snapshot, and describes the
+// consensus database that follows it.
+type snapshotHeader struct {
+	Metadata persist.Metadata
+	Height   types.BlockHeight
+	ID       types.BlockID
+	Checksum crypto.Hash
+}
+
+// ExportSnapshot writes a snapshot of the consensus set at its current height
+// to 'w', returning the height and id of the block that the snapshot ends at,
+// and the checksum of the consensus state at that block.
+func (cs *ConsensusSet) ExportSnapshot(w io.Writer) (height types.BlockHeight, id types.BlockID, checksum crypto.Hash, err error) {
+	// The bolt transaction provides a consistent view of the database, so
+	// the consensus set lock does not need to be held while the (potentially
+	// large) snapshot is written, which would stall block acceptance.
+	err = cs.db.View(func(tx *bolt.Tx) error {
+		header := snapshotHeader{
+			Metadata: snapshotMetadata,
+			Height:   blockHeight(tx),
+			ID:       currentBlockID(tx),
+			Checksum: stateChecksum(tx),
+		}
+		err := encoding.WriteObject(w, header)
+		if err != nil {
+			return err
+		}
+		_, err = tx.WriteTo(w)
+		if err != nil {
+			return err
+		}
+		height, id, checksum = header.Height, header.ID, header.Checksum
+		return nil
+	})
+	return height, id, checksum, err
+}
+
+// verifySnapshotPath checks that the processed blocks in the current path of
+// the consensus database in 'tx' form a chain, and recomputes their heights,
+// depths and child targets. The ids of blocks whose transactions have been
+// pruned cannot be recomputed, so only their links to their parents are
+// checked.
+func verifySnapshotPath(tx *bolt.Tx, dc DifficultyConfig) error {
+	pruneHeight := types.BlockHeight(1)
+	if tx.Bucket(BlockPruneHeight) != nil {
+		pruneHeight = getPruneHeight(tx)
+	}
+	var parent *processedBlock
+	var parentID types.BlockID
+	var timestamps []types.Timestamp
+	for height := types.BlockHeight(0); height <= blockHeight(tx); height++ {
+		id, err := getPath(tx, height)
+		if err != nil {
+			return err
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		if pb.Height != height {
+			return errSnapshotBlock
+		}
+		if (height == 0 || height >= pruneHeight) && pb.Block.ID() != id {
+			return errSnapshotBlock
+		}
+		timestamps = append(timestamps, pb.Block.Timestamp)
+
+		if height == 0 {
+			if pb.Depth != types.RootDepth || pb.ChildTarget != dc.RootTarget {
+				return errSnapshotBlock
+			}
+		} else {
+			depth := parent.Depth.AddDifficulties(dc.blockTarget(parent, pb.Block.Timestamp))
+			childTarget := dc.ChildTarget(parent.ChildTarget, height, pb.Block.Timestamp, func(window types.BlockHeight) types.Timestamp {
+				return timestamps[height-window]
+			})
+			if pb.Block.ParentID != parentID || pb.Depth != depth || pb.ChildTarget != childTarget {
+				return errSnapshotBlock
+			}
+		}
+		parent, parentID = pb, id
+	}
+	return nil
+}
+
+// verifySnapshot checks that the consensus database in 'tx' is a valid
+// consensus database described by 'header'. The header must already have been
+// checked against the trusted block id and checksum.
+func verifySnapshot(tx *bolt.Tx, header snapshotHeader) error {
+	if !dbInitialized(tx) || inconsistencyDetected(tx) {
+		return errSnapshotHeader
+	}
+	genesisID, err := getPath(tx, 0)
+	if err != nil {
+		return err
+	}
+	if genesisID != types.GenesisBlock.ID() {
+		return errors.New("snapshot has wrong genesis block")
+	}
+	if blockHeight(tx) != header.Height || currentBlockID(tx) != header.ID {
+		return errSnapshotHeader
+	}
+	if err := verifySnapshotPath(tx, DefaultDifficultyConfig()); err != nil {
+		return err
+	}
+	if stateChecksum(tx) != header.Checksum {
+		return errSnapshotChecksum
+	}
+	return nil
+}
+
+// ImportSnapshot reads a snapshot from 'r' and installs it as the consensus
+// database in 'persistDir'. The snapshot must end at 'trustedID', and its
+// consensus state must match 'trustedChecksum'. Both should be obtained from a
+// trusted source such as another node controlled by the user (see
+// StateChecksum); the values in the snapshot file are not trusted. The
+// targets of the blocks are checked against the difficulty rules of the
+// network in use. ImportSnapshot must be called before the consensus set is
+// created with New.
+func ImportSnapshot(r io.Reader, persistDir string, trustedID types.BlockID, trustedChecksum crypto.Hash) error {
+	filename := filepath.Join(persistDir, DatabaseFilename)
+	if _, err := os.Stat(filename); err == nil {
+		return errSnapshotExists
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	var header snapshotHeader
+	err := encoding.ReadObject(r, &header, 1024)
+	if err != nil {
+		return err
+	}
+	if header.Metadata.Header != snapshotMetadata.Header {
+		return persist.ErrBadHeader
+	} else if header.Metadata.Version != snapshotMetadata.Version {
+		return persist.ErrBadVersion
+	}
+	if header.ID != trustedID || header.Checksum != trustedChecksum {
+		return errSnapshotUntrusted
+	}
+
+	// Copy the database into a temporary file, verify it, and then move it
+	// into place.
+	err = os.MkdirAll(persistDir, 0700)
+	if err != nil {
+		return err
+	}
+	tmpFilename := filename + "_temp"
+	f, err := os.OpenFile(tmpFilename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpFilename)
+	_, err = io.Copy(f, r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Sync()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	db, err := persist.OpenDatabase(dbMetadata, tmpFilename)
+	if err != nil {
+		return err
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		return verifySnapshot(tx, header)
+	})
+	if err != nil {
+		db.Close()
+		return err
+	}
+	err = db.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFilename, filename)
+}
@@ -0,0 +1,196 @@
+package consensus
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
+)
+
+// TestSnapshotExportImport checks that a consensus set created from a
+// snapshot matches the consensus set that exported the snapshot.
+func TestSnapshotExportImport(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestSnapshotExportImport")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	cst.testBlockSuite()
+
+	var buf bytes.Buffer
+	height, id, checksum, err := cst.cs.ExportSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != cst.cs.Height() || id != cst.cs.CurrentBlock().ID() {
+		t.Fatal("snapshot does not end at the current block")
+	}
+	if _, _, stateChecksum := cst.cs.StateChecksum(); checksum != stateChecksum {
+		t.Fatal("snapshot reported the wrong checksum")
+	}
+	snapshot := buf.Bytes()
+
+	// Importing the snapshot with the wrong trusted id should fail.
+	testdir := build.TempDir(modules.ConsensusDir, "TestSnapshotExportImport - import")
+	csDir := filepath.Join(testdir, modules.ConsensusDir)
+	err = ImportSnapshot(bytes.NewReader(snapshot), csDir, types.BlockID{}, checksum)
+	if err != errSnapshotUntrusted {
+		t.Fatal("expected errSnapshotUntrusted, got", err)
+	}
+	// Importing the snapshot with the wrong trusted checksum should fail.
+	err = ImportSnapshot(bytes.NewReader(snapshot), csDir, id, crypto.Hash{})
+	if err != errSnapshotUntrusted {
+		t.Fatal("expected errSnapshotUntrusted, got", err)
+	}
+
+	// A tampered snapshot that carries a matching, forged checksum in its
+	// header should be rejected, as the checksum must match the trusted one.
+	var header snapshotHeader
+	r := bytes.NewReader(snapshot)
+	err = encoding.ReadObject(r, &header, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header.Checksum[0]++
+	var forged bytes.Buffer
+	encoding.WriteObject(&forged, header)
+	forged.ReadFrom(r)
+	err = ImportSnapshot(&forged, csDir, id, checksum)
+	if err != errSnapshotUntrusted {
+		t.Fatal("expected errSnapshotUntrusted, got", err)
+	}
+
+	// A snapshot database whose state does not match the checksum should be
+	// rejected.
+	err = cst.cs.db.View(func(tx *bolt.Tx) error {
+		return verifySnapshot(tx, header)
+	})
+	if err != errSnapshotChecksum {
+		t.Fatal("expected errSnapshotChecksum, got", err)
+	}
+	if _, err := os.Stat(filepath.Join(csDir, DatabaseFilename)); !os.IsNotExist(err) {
+		t.Fatal("failed import left a consensus database behind")
+	}
+
+	// Import the snapshot and check that the new consensus set matches.
+	err = ImportSnapshot(bytes.NewReader(snapshot), csDir, id, checksum)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := gateway.New("localhost:0", filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	cs, err := New(g, csDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cs.Close()
+	if cs.CurrentBlock().ID() != id {
+		t.Fatal("imported consensus set is on the wrong block")
+	}
+	if cs.dbConsensusChecksum() != cst.cs.dbConsensusChecksum() {
+		t.Fatal("imported consensus set has a different checksum")
+	}
+
+	// A second import should not overwrite the existing database.
+	err = ImportSnapshot(bytes.NewReader(snapshot), csDir, id, checksum)
+	if err != errSnapshotExists {
+		t.Fatal("expected errSnapshotExists, got", err)
+	}
+}
+
+// TestSnapshotTamperedBlocks checks that a snapshot whose processed blocks
+// have been edited is rejected. The edits do not change the consensus
+// checksum, so they must be caught by recomputing the blocks or by the path
+// checksum.
+func TestSnapshotTamperedBlocks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestSnapshotTamperedBlocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	cst.testBlockSuite()
+
+	var buf bytes.Buffer
+	_, id, _, err := cst.cs.ExportSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(buf.Bytes())
+	var header snapshotHeader
+	err = encoding.ReadObject(r, &header, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshotDB := make([]byte, r.Len())
+	r.Read(snapshotDB)
+
+	tests := []struct {
+		name   string
+		tamper func(*processedBlock)
+		err    error
+	}{
+		{"none", func(*processedBlock) {}, nil},
+		{"height", func(pb *processedBlock) { pb.Height++ }, errSnapshotBlock},
+		{"depth", func(pb *processedBlock) { pb.Depth[len(pb.Depth)-1]++ }, errSnapshotBlock},
+		{"child target", func(pb *processedBlock) { pb.ChildTarget[len(pb.ChildTarget)-1]++ }, errSnapshotBlock},
+		{"diffs", func(pb *processedBlock) { pb.DelayedSiacoinOutputDiffs = nil }, errSnapshotChecksum},
+	}
+	for _, test := range tests {
+		// Write the database of the snapshot to a file, and edit the current
+		// block.
+		testdir := build.TempDir(modules.ConsensusDir, "TestSnapshotTamperedBlocks", test.name)
+		err := os.MkdirAll(testdir, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filename := filepath.Join(testdir, DatabaseFilename)
+		err = ioutil.WriteFile(filename, snapshotDB, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db, err := persist.OpenDatabase(dbMetadata, filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.Update(func(tx *bolt.Tx) error {
+			pb, err := getBlockMap(tx, id)
+			if err != nil {
+				return err
+			}
+			test.tamper(pb)
+			return tx.Bucket(BlockMap).Put(id[:], encoding.Marshal(*pb))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.View(func(tx *bolt.Tx) error {
+			return verifySnapshot(tx, header)
+		})
+		if err != test.err {
+			t.Errorf("%v: expected %v, got %v", test.name, test.err, err)
+		}
+		db.Close()
+	}
+}
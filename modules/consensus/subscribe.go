@@ -2,6 +2,7 @@ package consensus
 
 import (
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/NebulousLabs/bolt"
 )
@@ -13,6 +14,9 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 		ID: ce.ID(),
 	}
 	for _, revertedBlockID := range ce.RevertedBlocks {
+		if isPruned(tx, revertedBlockID) {
+			return modules.ConsensusChange{}, errPrunedHistory
+		}
 		revertedBlock, err := getBlockMap(tx, revertedBlockID)
 		if err != nil {
 			cs.log.Critical("getBlockMap failed in computeConsensusChange:", err)
@@ -49,6 +53,9 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 		}
 	}
 	for _, appliedBlockID := range ce.AppliedBlocks {
+		if isPruned(tx, appliedBlockID) {
+			return modules.ConsensusChange{}, errPrunedHistory
+		}
 		appliedBlock, err := getBlockMap(tx, appliedBlockID)
 		if err != nil {
 			cs.log.Critical("getBlockMap failed in computeConsensusChange:", err)
@@ -73,8 +80,28 @@ func (cs *ConsensusSet) computeConsensusChange(tx *bolt.Tx, ce changeEntry) (mod
 		}
 	}
 
+	// Determine which transactions left the blockchain. Transactions are
+	// presented in the order they appeared in the blockchain, so that parents
+	// come before the children that spend their outputs.
+	cc.ReorgDepth = types.BlockHeight(len(cc.RevertedBlocks))
+	if len(cc.RevertedBlocks) > 0 {
+		appliedTxns := make(map[types.TransactionID]struct{})
+		for _, block := range cc.AppliedBlocks {
+			for _, txn := range block.Transactions {
+				appliedTxns[txn.ID()] = struct{}{}
+			}
+		}
+		for i := len(cc.RevertedBlocks) - 1; i >= 0; i-- {
+			for _, txn := range cc.RevertedBlocks[i].Transactions {
+				if _, exists := appliedTxns[txn.ID()]; !exists {
+					cc.RevertedTransactions = append(cc.RevertedTransactions, txn)
+				}
+			}
+		}
+	}
+
 	currentBlock := currentBlockID(tx)
-	if cs.synced && ce.AppliedBlocks[len(ce.AppliedBlocks)-1] == currentBlock {
+	if cs.Synced() && ce.AppliedBlocks[len(ce.AppliedBlocks)-1] == currentBlock {
 		cc.Synced = true
 	}
 	return cc, nil
@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/NebulousLabs/bolt"
 )
 
 // mockSubscriber receives and holds changes to the consensus set, remembering
@@ -148,3 +151,95 @@ func TestUnsubscribe(t *testing.T) {
 		t.Error("mock subscriber was not correctly unsubscribed")
 	}
 }
+
+// TestReorgConsensusChange checks that a consensus change caused by a reorg
+// reports the depth of the reorg and the transactions that left the
+// blockchain.
+func TestReorgConsensusChange(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := createConsensusSetTester("TestReorgConsensusChange")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst.Close()
+	ms := newMockSubscriber()
+	cst.cs.ConsensusSetSubscribe(&ms, modules.ConsensusChangeBeginning)
+
+	// Create a block without transactions that will later form a competing
+	// fork.
+	forkBlock, forkTarget, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create and accept a block containing a wallet transaction.
+	txns, err := cst.wallet.SendSiacoins(types.NewCurrency64(1), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnBlock, txnTarget, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txnBlock.Transactions) == 0 {
+		t.Fatal("block does not contain the wallet transaction")
+	}
+	solvedTxnBlock, _ := cst.miner.SolveBlock(txnBlock, txnTarget)
+	err = cst.cs.AcceptBlock(solvedTxnBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc := ms.updates[len(ms.updates)-1]; cc.ReorgDepth != 0 || len(cc.RevertedTransactions) != 0 {
+		t.Error("consensus change without reverted blocks reports a reorg")
+	}
+
+	// Extend the competing fork by two blocks, causing a reorg.
+	solvedForkBlock, _ := cst.miner.SolveBlock(forkBlock, forkTarget)
+	err = cst.cs.AcceptBlock(solvedForkBlock)
+	if err != modules.ErrNonExtendingBlock {
+		t.Fatal("expected ErrNonExtendingBlock, got", err)
+	}
+	child, _, err := cst.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	child.ParentID = solvedForkBlock.ID()
+	child.Transactions = nil
+	var childTarget types.Target
+	_ = cst.cs.db.View(func(tx *bolt.Tx) error {
+		pb, err := getBlockMap(tx, solvedForkBlock.ID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		childTarget = pb.ChildTarget
+		return nil
+	})
+	solvedChild, _ := cst.miner.SolveBlock(child, childTarget)
+	err = cst.cs.AcceptBlock(solvedChild)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cc := ms.updates[len(ms.updates)-1]
+	if cc.ReorgDepth != 1 {
+		t.Error("expected a reorg depth of 1, got", cc.ReorgDepth)
+	}
+	reverted := make(map[types.TransactionID]int)
+	for i, txn := range cc.RevertedTransactions {
+		reverted[txn.ID()] = i
+	}
+	prev := -1
+	for _, txn := range txns {
+		i, exists := reverted[txn.ID()]
+		if !exists {
+			t.Fatal("wallet transaction is missing from the reverted transactions")
+		}
+		if i < prev {
+			t.Error("reverted transactions are not in the order they appeared in the block")
+		}
+		prev = i
+	}
+}
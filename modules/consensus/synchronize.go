@@ -85,6 +85,31 @@ var (
 	errSendBlocksStalled = errors.New("SendBlocks RPC timed and never received any blocks")
 )
 
+// isInvalidBlockErr returns true if a block was rejected for an error that
+// proves the block or header invalid. Errors that depend on the state of the
+// local node, such as orphaned or future blocks, are not proof of invalidity.
+func isInvalidBlockErr(err error) bool {
+	switch err {
+	case errBadMinerPayouts, errEarlyTimestamp, errLargeBlock, errDoSBlock,
+		errCheckpointMismatch, modules.ErrBlockUnsolved:
+		return true
+	}
+	return false
+}
+
+// managedReportInvalidBlock reports the peer that sent a block to the gateway
+// if the block was rejected as invalid. Blocks that fail while their
+// transactions are applied are marked as DoS blocks, which is how they are
+// told apart from blocks that failed for local reasons.
+func (cs *ConsensusSet) managedReportInvalidBlock(peer modules.NetAddress, id types.BlockID, err error) {
+	cs.mu.RLock()
+	_, dos := cs.dosBlocks[id]
+	cs.mu.RUnlock()
+	if dos || isInvalidBlockErr(err) {
+		cs.gateway.ReportMisbehavior(peer, modules.MisbehaviorInvalidBlock)
+	}
+}
+
 // blockHistory returns up to 32 block ids, starting with recent blocks and
 // then proving exponentially increasingly less recent blocks. The genesis
 // block is always included as the last block. This block history can be used
@@ -127,11 +152,11 @@ func blockHistory(tx *bolt.Tx) (blockIDs [32]types.BlockID) {
 	return blockIDs
 }
 
-// threadedReceiveBlocks is the calling end of the SendBlocks RPC.
-func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) (returnErr error) {
-	// Set a deadline after which SendBlocks will timeout. During IBD, esepcially,
-	// SendBlocks will timeout. This is by design so that IBD switches peers to
-	// prevent any one peer from stalling IBD.
+// setSyncDeadline sets the deadline after which a SendBlocks or SendHeaders
+// call will timeout. During IBD, esepcially, SendBlocks will timeout. This is
+// by design so that IBD switches peers to prevent any one peer from stalling
+// IBD.
+func setSyncDeadline(conn modules.PeerConn) error {
 	err := conn.SetDeadline(time.Now().Add(sendBlocksTimeout))
 	// Ignore errors returned by SetDeadline if the conn is a pipe in testing.
 	// Pipes do not support Set{,Read,Write}Deadline and should only be used in
@@ -139,6 +164,12 @@ func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) (returnErr
 	if opErr, ok := err.(*net.OpError); ok && opErr.Op == "set" && opErr.Net == "pipe" && build.Release == "testing" {
 		err = nil
 	}
+	return err
+}
+
+// threadedReceiveBlocks is the calling end of the SendBlocks RPC.
+func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) (returnErr error) {
+	err := setSyncDeadline(conn)
 	if err != nil {
 		return err
 	}
@@ -156,12 +187,10 @@ func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) (returnErr
 
 	// Get blockIDs to send.
 	var history [32]types.BlockID
-	cs.mu.RLock()
 	err = cs.db.View(func(tx *bolt.Tx) error {
 		history = blockHistory(tx)
 		return nil
 	})
-	cs.mu.RUnlock()
 	if err != nil {
 		return err
 	}
@@ -224,6 +253,7 @@ func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) (returnErr
 				acceptErr = nil
 			}
 			if acceptErr != nil {
+				cs.managedReportInvalidBlock(conn.RPCAddr(), block.ID(), acceptErr)
 				return acceptErr
 			}
 		}
@@ -237,10 +267,17 @@ func (cs *ConsensusSet) threadedReceiveBlocks(conn modules.PeerConn) (returnErr
 // that BlockHeight onwards are returned. It also sends a boolean indicating
 // whether more blocks are available.
 func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
+	// Sending every batch of blocks may take longer than the gateway's
+	// default RPC deadline.
+	err := setSyncDeadline(conn)
+	if err != nil {
+		return err
+	}
+
 	// Read a list of blocks known to the requester and find the most recent
 	// block from the current path.
 	var knownBlocks [32]types.BlockID
-	err := encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
+	err = encoding.ReadObject(conn, &knownBlocks, 32*crypto.HashSize)
 	if err != nil {
 		return err
 	}
@@ -248,29 +285,12 @@ func (cs *ConsensusSet) rpcSendBlocks(conn modules.PeerConn) error {
 	// Find the most recent block from knownBlocks in the current path.
 	found := false
 	var start types.BlockHeight
-	var csHeight types.BlockHeight
 	cs.mu.RLock()
 	err = cs.db.View(func(tx *bolt.Tx) error {
-		csHeight = blockHeight(tx)
-		for _, id := range knownBlocks {
-			pb, err := getBlockMap(tx, id)
-			if err != nil {
-				continue
-			}
-			pathID, err := getPath(tx, pb.Height)
-			if err != nil {
-				continue
-			}
-			if pathID != pb.Block.ID() {
-				continue
-			}
-			if pb.Height == csHeight {
-				break
-			}
-			found = true
-			// Start from the child of the common block.
-			start = pb.Height + 1
-			break
+		start, found = syncStart(tx, knownBlocks)
+		// Blocks that have been pruned cannot be sent to the caller.
+		if found && start < getPruneHeight(tx) {
+			return errPrunedHistory
 		}
 		return nil
 	})
@@ -342,23 +362,13 @@ func (cs *ConsensusSet) rpcRelayBlock(conn modules.PeerConn) error {
 		return err
 	}
 
-	// Submit the block to the consensus set and broadcast it.
-	err = cs.AcceptBlock(b)
-	if err == errOrphan {
-		// If the block is an orphan, try to find the parents. The block
-		// received from the peer is discarded and will be downloaded again if
-		// the parent is found.
-		go func() {
-			err := cs.gateway.RPC(conn.RPCAddr(), "SendBlocks", cs.threadedReceiveBlocks)
-			if err != nil {
-				cs.log.Debugln("WARN: failed to get parents of orphan block:", err)
-			}
-		}()
-	}
-	if err != nil {
-		return err
-	}
-	return nil
+	// Queue the block to be accepted and broadcast. If the block is an
+	// orphan, its parents are requested from the peer once it reaches the
+	// front of the queue. The block is accepted after the RPC returns, so an
+	// error is only returned if the block cannot be queued; if the block is
+	// rejected, the rejection is logged and the peer is penalized by the
+	// queue.
+	return cs.managedQueueBlock(queuedBlock{block: b, peer: conn.RPCAddr()})
 }
 
 // rpcRelayHeader is an RPC that accepts a block header from a peer.
@@ -387,6 +397,7 @@ func (cs *ConsensusSet) rpcRelayHeader(conn modules.PeerConn) error {
 		}()
 		return nil
 	} else if err != nil {
+		cs.managedReportInvalidBlock(conn.RPCAddr(), h.ID(), err)
 		return err
 	}
 	// If the header is valid and extends the heaviest chain, fetch, accept it,
@@ -410,8 +421,10 @@ func (cs *ConsensusSet) rpcSendBlk(conn modules.PeerConn) error {
 	}
 	// Lookup the corresponding block.
 	var b types.Block
-	cs.mu.RLock()
 	err = cs.db.View(func(tx *bolt.Tx) error {
+		if isPruned(tx, id) {
+			return errPrunedHistory
+		}
 		pb, err := getBlockMap(tx, id)
 		if err != nil {
 			return err
@@ -419,7 +432,6 @@ func (cs *ConsensusSet) rpcSendBlk(conn modules.PeerConn) error {
 		b = pb.Block
 		return nil
 	})
-	cs.mu.RUnlock()
 	if err != nil {
 		return err
 	}
@@ -432,10 +444,11 @@ func (cs *ConsensusSet) rpcSendBlk(conn modules.PeerConn) error {
 }
 
 // threadedReceiveBlock takes a block id and returns an RPCFunc that requests
-// that block and then calls AcceptBlock on it. The returned function should be
-// used as the calling end of the SendBlk RPC. Note that although the function
-// itself does not do any locking, it is still prefixed with "threaded" because
-// the function it returns calls the exported method AcceptBlock.
+// that block and then calls AcceptBlock on it through the acceptance queue.
+// The returned function should be used as the calling end of the SendBlk RPC.
+// Note that although the function itself does not do any locking, it is still
+// prefixed with "threaded" because the function it returns waits for the
+// block to be accepted.
 func (cs *ConsensusSet) threadedReceiveBlock(id types.BlockID) modules.RPCFunc {
 	managedFN := func(conn modules.PeerConn) error {
 		if err := encoding.WriteObject(conn, id); err != nil {
@@ -445,9 +458,11 @@ func (cs *ConsensusSet) threadedReceiveBlock(id types.BlockID) modules.RPCFunc {
 		if err := encoding.ReadObject(conn, &block, types.BlockSizeLimit); err != nil {
 			return err
 		}
-		if err := cs.AcceptBlock(block); err != nil {
+		if err := cs.managedQueueBlockAndWait(block, ""); err != nil {
+			cs.managedReportInvalidBlock(conn.RPCAddr(), block.ID(), err)
 			return err
 		}
+		cs.gateway.RecordRelayedBlock(conn.RPCAddr(), block.ID())
 		return nil
 	}
 	return managedFN
@@ -476,7 +491,13 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() {
 				continue
 			}
 
-			err := cs.gateway.RPC(p.NetAddress, "SendBlocks", cs.threadedReceiveBlocks)
+			// Synchronize headers-first, falling back to SendBlocks if the peer
+			// does not support SendHeaders or if headers-first sync fails.
+			err := cs.managedHeadersFirstSync(p.NetAddress)
+			if err != nil {
+				cs.log.Debugf("WARN: headers-first sync with %v failed, falling back to SendBlocks: %v", p.NetAddress, err)
+				err = cs.gateway.RPC(p.NetAddress, "SendBlocks", cs.threadedReceiveBlocks)
+			}
 			if err == nil {
 				numOutboundSynced++
 				continue
@@ -516,7 +537,7 @@ func (cs *ConsensusSet) threadedInitialBlockchainDownload() {
 
 // Synced returns true if the consensus set is synced with the network.
 func (cs *ConsensusSet) Synced() bool {
-	cs.mu.RLock()
-	defer cs.mu.RUnlock()
+	cs.syncedMu.RLock()
+	defer cs.syncedMu.RUnlock()
 	return cs.synced
 }
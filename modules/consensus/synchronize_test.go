@@ -280,9 +280,9 @@ func TestSendBlocksBroadcastsOnce(t *testing.T) {
 		},
 	}
 	for _, test := range tests {
-		cst1.cs.mu.Lock()
+		cst1.cs.syncedMu.Lock()
 		cst1.cs.synced = test.synced
-		cst1.cs.mu.Unlock()
+		cst1.cs.syncedMu.Unlock()
 		mg.mu.Lock()
 		mg.numBroadcasts = 0
 		mg.mu.Unlock()
@@ -0,0 +1,139 @@
+// Package testchain provides a private, in-process blockchain for unit tests.
+// A Chain mines blocks on demand against a configurable difficulty and can
+// fabricate funded wallets, so that tests of modules that depend on the
+// consensus set can use real consensus state instead of stubbing it out by
+// hand. The package only depends on the gateway and the consensus set, which
+// allows it to be used by the tests of every module built on top of them.
+package testchain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// solveAttempts is the number of nonces that MineBlock will try before
+	// giving up on a block.
+	solveAttempts = 1e6
+)
+
+var (
+	// easyTarget is the target used by New. Half of all block ids meet the
+	// target.
+	easyTarget = types.Target{128}
+
+	errUnsolved = errors.New("could not solve block, the difficulty is too high")
+)
+
+// A Chain is a private blockchain that only grows when blocks are mined
+// through it.
+type Chain struct {
+	Gateway      modules.Gateway
+	ConsensusSet *consensus.ConsensusSet
+
+	persistDir string
+}
+
+// FixedDifficultyConfig returns difficulty parameters that never adjust the
+// target, so every block of the chain is mined against 'target'.
+func FixedDifficultyConfig(target types.Target) consensus.DifficultyConfig {
+	dc := consensus.DefaultDifficultyConfig()
+	dc.RootTarget = target
+	dc.MaxAdjustmentUp = big.NewRat(1, 1)
+	dc.MaxAdjustmentDown = big.NewRat(1, 1)
+	return dc
+}
+
+// New creates a Chain containing only the genesis block, where blocks take
+// an expected two hashes to mine. The chain stores its data in a testing
+// directory derived from 'name'.
+func New(name string) (*Chain, error) {
+	return NewCustomDifficulty(name, FixedDifficultyConfig(easyTarget))
+}
+
+// NewCustomDifficulty creates a Chain containing only the genesis block that
+// adjusts the difficulty according to the provided parameters.
+func NewCustomDifficulty(name string, difficulty consensus.DifficultyConfig) (*Chain, error) {
+	testdir := build.TempDir("testchain", name)
+	g, err := gateway.New("localhost:0", filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		return nil, err
+	}
+	cs, err := consensus.NewCustomDifficulty(g, filepath.Join(testdir, modules.ConsensusDir), difficulty)
+	if err != nil {
+		g.Close()
+		return nil, err
+	}
+	return &Chain{
+		Gateway:      g,
+		ConsensusSet: cs,
+
+		persistDir: testdir,
+	}, nil
+}
+
+// MineBlock mines a block containing 'txns' on top of the current block and
+// adds it to the consensus set. The miner payout is sent to 'payout'.
+func (c *Chain) MineBlock(payout types.UnlockHash, txns ...types.Transaction) (types.Block, error) {
+	parent := c.ConsensusSet.CurrentBlock()
+	target, _ := c.ConsensusSet.ChildTarget(parent.ID())
+	b := types.Block{
+		ParentID:     parent.ID(),
+		Timestamp:    types.CurrentTimestamp(),
+		Transactions: txns,
+	}
+	if b.Timestamp < parent.Timestamp {
+		b.Timestamp = parent.Timestamp
+	}
+	b.MinerPayouts = []types.SiacoinOutput{{
+		Value:      b.CalculateSubsidy(c.ConsensusSet.Height() + 1),
+		UnlockHash: payout,
+	}}
+
+	solved := false
+	for i := uint64(0); i < solveAttempts; i++ {
+		binary.LittleEndian.PutUint64(b.Nonce[:], i)
+		id := b.ID()
+		if bytes.Compare(target[:], id[:]) >= 0 {
+			solved = true
+			break
+		}
+	}
+	if !solved {
+		return types.Block{}, errUnsolved
+	}
+	err := c.ConsensusSet.AcceptBlock(b)
+	if err != nil {
+		return types.Block{}, err
+	}
+	return b, nil
+}
+
+// MineBlocks mines 'n' empty blocks whose payouts cannot be spent.
+func (c *Chain) MineBlocks(n int) error {
+	for i := 0; i < n; i++ {
+		_, err := c.MineBlock(types.UnlockHash{})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the consensus set and the gateway of the chain.
+func (c *Chain) Close() error {
+	errs := []error{
+		c.ConsensusSet.Close(),
+		c.Gateway.Close(),
+	}
+	return build.JoinErrors(errs, "; ")
+}
@@ -0,0 +1,123 @@
+package testchain
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestMineBlock checks that blocks can be mined on demand and that the
+// payouts go to the requested address.
+func TestMineBlock(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	c, err := New("TestMineBlock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	err = c.MineBlocks(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ConsensusSet.Height() != 3 {
+		t.Fatal("expecting height 3, got", c.ConsensusSet.Height())
+	}
+	uh := types.UnlockHash{1}
+	b, err := c.MineBlock(uh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ConsensusSet.CurrentBlock().ID() != b.ID() {
+		t.Fatal("mined block is not the current block")
+	}
+	if b.MinerPayouts[0].UnlockHash != uh {
+		t.Fatal("payout was not sent to the requested address")
+	}
+}
+
+// TestMineBlockDifficulty checks that the chain respects a custom difficulty.
+func TestMineBlockDifficulty(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dc := FixedDifficultyConfig(types.Target{})
+	_, err := NewCustomDifficulty("TestMineBlockDifficulty", dc)
+	if err == nil {
+		t.Fatal("chain accepted an impossible difficulty")
+	}
+
+	c, err := NewCustomDifficulty("TestMineBlockDifficulty", FixedDifficultyConfig(types.Target{0, 128}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for i := 0; i < 5; i++ {
+		b, err := c.MineBlock(types.UnlockHash{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := b.ID()
+		if id[0] != 0 || id[1] > 128 {
+			t.Fatal("block does not meet the target:", id)
+		}
+	}
+	target, _ := c.ConsensusSet.ChildTarget(c.ConsensusSet.CurrentBlock().ID())
+	if target != (types.Target{0, 128}) {
+		t.Fatal("target was adjusted:", target)
+	}
+}
+
+// TestFundedWallet checks that a funded wallet can spend its siacoins.
+func TestFundedWallet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	c, err := New("TestFundedWallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	w, err := c.FundedWallet(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := w.Balance()
+	if balance.IsZero() {
+		t.Fatal("funded wallet is empty")
+	}
+
+	// Send siacoins to a second wallet.
+	w2, err := c.NewWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	amount := types.SiacoinPrecision.Mul64(100)
+	fee := types.SiacoinPrecision
+	txn, err := w.SendSiacoins(amount, w2.UnlockHash(), fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.MineBlock(types.UnlockHash{}, txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w2.Balance().Cmp(amount) != 0 {
+		t.Fatal("recipient has the wrong balance:", w2.Balance())
+	}
+	if w.Balance().Cmp(balance.Sub(amount).Sub(fee)) != 0 {
+		t.Fatal("sender has the wrong balance:", w.Balance())
+	}
+
+	// Spending more than the balance fails.
+	_, err = w2.SendSiacoins(amount, w.UnlockHash(), fee)
+	if err != errInsufficientFunds {
+		t.Fatal("expecting errInsufficientFunds, got", err)
+	}
+}
@@ -0,0 +1,153 @@
+package testchain
+
+// wallet.go implements a minimal wallet that controls a single key. The
+// wallet keeps no state of its own; its outputs are found by scanning the
+// unspent outputs of the consensus set, which is fast enough for the small
+// chains used in testing.
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// outputsPageSize is the number of outputs read from the consensus set at
+	// a time when scanning for the outputs of a wallet.
+	outputsPageSize = 1000
+)
+
+var (
+	errInsufficientFunds = errors.New("wallet does not have enough confirmed siacoins")
+)
+
+// A Wallet holds a single key and spends the confirmed siacoin outputs of the
+// chain that are sent to its address.
+type Wallet struct {
+	SecretKey        crypto.SecretKey
+	UnlockConditions types.UnlockConditions
+
+	chain *Chain
+}
+
+// NewWallet returns an empty wallet with a freshly generated key.
+func (c *Chain) NewWallet() (*Wallet, error) {
+	sk, pk, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return nil, err
+	}
+	return &Wallet{
+		SecretKey: sk,
+		UnlockConditions: types.UnlockConditions{
+			PublicKeys: []types.SiaPublicKey{{
+				Algorithm: types.SignatureEd25519,
+				Key:       pk[:],
+			}},
+			SignaturesRequired: 1,
+		},
+		chain: c,
+	}, nil
+}
+
+// FundedWallet returns a new wallet that has received the payouts of 'blocks'
+// blocks. Enough blocks are mined afterwards for the payouts to mature, so the
+// funds can be spent right away.
+func (c *Chain) FundedWallet(blocks int) (*Wallet, error) {
+	w, err := c.NewWallet()
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < blocks; i++ {
+		_, err = c.MineBlock(w.UnlockHash())
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = c.MineBlocks(int(types.MaturityDelay))
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// UnlockHash returns the address of the wallet.
+func (w *Wallet) UnlockHash() types.UnlockHash {
+	return w.UnlockConditions.UnlockHash()
+}
+
+// outputs returns the confirmed siacoin outputs that belong to the wallet.
+func (w *Wallet) outputs() map[types.SiacoinOutputID]types.SiacoinOutput {
+	uh := w.UnlockHash()
+	outputs := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	var after types.SiacoinOutputID
+	for {
+		entries := w.chain.ConsensusSet.SiacoinOutputs(after, outputsPageSize)
+		for _, entry := range entries {
+			if entry.SiacoinOutput.UnlockHash == uh {
+				outputs[entry.ID] = entry.SiacoinOutput
+			}
+		}
+		if len(entries) < outputsPageSize {
+			return outputs
+		}
+		after = entries[len(entries)-1].ID
+	}
+}
+
+// Balance returns the sum of the confirmed siacoin outputs of the wallet.
+func (w *Wallet) Balance() types.Currency {
+	var balance types.Currency
+	for _, sco := range w.outputs() {
+		balance = balance.Add(sco.Value)
+	}
+	return balance
+}
+
+// SendSiacoins returns a signed transaction that sends 'amount' siacoins to
+// 'dest' and pays 'fee' to the miner. Any change is returned to the wallet.
+// The transaction is not submitted; it can be mined with Chain.MineBlock or
+// given to a transaction pool.
+func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash, fee types.Currency) (types.Transaction, error) {
+	total := amount.Add(fee)
+	var txn types.Transaction
+	var funded types.Currency
+	for id, sco := range w.outputs() {
+		if funded.Cmp(total) >= 0 {
+			break
+		}
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         id,
+			UnlockConditions: w.UnlockConditions,
+		})
+		funded = funded.Add(sco.Value)
+	}
+	if funded.Cmp(total) < 0 {
+		return types.Transaction{}, errInsufficientFunds
+	}
+
+	txn.SiacoinOutputs = []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+	if change := funded.Sub(total); !change.IsZero() {
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{Value: change, UnlockHash: w.UnlockHash()})
+	}
+	if !fee.IsZero() {
+		txn.MinerFees = []types.Currency{fee}
+	}
+
+	// Sign each input with the wallet key.
+	for _, sci := range txn.SiacoinInputs {
+		txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+			ParentID:       crypto.Hash(sci.ParentID),
+			CoveredFields:  types.CoveredFields{WholeTransaction: true},
+			PublicKeyIndex: 0,
+		})
+	}
+	for i := range txn.TransactionSignatures {
+		sig, err := crypto.SignHash(txn.SigHash(i), w.SecretKey)
+		if err != nil {
+			return types.Transaction{}, err
+		}
+		txn.TransactionSignatures[i].Signature = sig[:]
+	}
+	return txn, nil
+}
@@ -0,0 +1,103 @@
+package consensus_test
+
+// The tests in this file use the testchain package, which cannot be imported
+// by the internal tests of the consensus package without an import cycle.
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules/consensus/testchain"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestIntegrationSpendOutputs checks that siacoins sent in a mined block are
+// moved from the inputs to the outputs of the transaction, and that the miner
+// receives the fee.
+func TestIntegrationSpendOutputs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	c, err := testchain.New("TestIntegrationSpendOutputs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	sender, err := c.FundedWallet(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := c.NewWallet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	balance := sender.Balance()
+	amount, fee := types.SiacoinPrecision, types.SiacoinPrecision.Div64(10)
+	txn, err := sender.SendSiacoins(amount, recipient.UnlockHash(), fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.MineBlock(types.UnlockHash{1}, txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sco, exists := c.ConsensusSet.SiacoinOutput(txn.SiacoinOutputID(0)); !exists || sco.Value.Cmp(amount) != 0 {
+		t.Fatal("output of the transaction is missing from the consensus set")
+	}
+	for _, sci := range txn.SiacoinInputs {
+		if _, exists := c.ConsensusSet.SiacoinOutput(sci.ParentID); exists {
+			t.Fatal("spent output is still in the consensus set")
+		}
+	}
+	if recipient.Balance().Cmp(amount) != 0 {
+		t.Fatal("recipient has the wrong balance:", recipient.Balance())
+	}
+	if sender.Balance().Cmp(balance.Sub(amount).Sub(fee)) != 0 {
+		t.Fatal("sender has the wrong balance:", sender.Balance())
+	}
+	subsidy := types.CalculateCoinbase(c.ConsensusSet.Height()).Add(fee)
+	if b.MinerPayouts[0].Value.Cmp(subsidy) != 0 {
+		t.Fatal("miner payout does not include the fee")
+	}
+}
+
+// TestIntegrationDoubleSpendBlock checks that a block spending outputs that
+// were spent by an earlier block is rejected.
+func TestIntegrationDoubleSpendBlock(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	c, err := testchain.New("TestIntegrationDoubleSpendBlock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	w, err := c.FundedWallet(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both transactions spend the same output, as neither has been mined
+	// when they are created.
+	txn1, err := w.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{1}, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn2, err := w.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{2}, types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.MineBlock(types.UnlockHash{}, txn1); err != nil {
+		t.Fatal(err)
+	}
+	height := c.ConsensusSet.Height()
+	if _, err := c.MineBlock(types.UnlockHash{}, txn2); err == nil {
+		t.Fatal("block with a double spend was accepted")
+	}
+	if c.ConsensusSet.Height() != height {
+		t.Fatal("consensus set changed after rejecting a block")
+	}
+}
@@ -0,0 +1,100 @@
+package consensus
+
+// validationcache.go implements a cache of transactions that have passed
+// StandaloneValid. Transactions are typically validated once when they enter
+// the transaction pool and again when they appear in a block, and the
+// signature checks dominate the cost of both. The cache allows the second
+// check to be skipped.
+//
+// Transaction ids do not cover the signatures of a transaction, so entries are
+// keyed by a hash of the full transaction and the height at which it was
+// validated. Only successful validations are cached.
+
+import (
+	"sync"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// maxValidationCacheSize is the maximum number of transactions held in
+	// the validation cache. When the cache is full, an arbitrary entry is
+	// evicted to make room for a new one.
+	maxValidationCacheSize = func() int {
+		switch build.Release {
+		case "dev":
+			return 1e3
+		case "standard":
+			return 10e3
+		case "testing":
+			return 50
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// validationCache holds the transactions that are known to pass
+// StandaloneValid at a given height. A nil validationCache is valid and
+// caches nothing.
+type validationCache struct {
+	entries map[crypto.Hash]struct{}
+	mu      sync.Mutex
+}
+
+// newValidationCache returns an empty validation cache.
+func newValidationCache() *validationCache {
+	return &validationCache{
+		entries: make(map[crypto.Hash]struct{}),
+	}
+}
+
+// validationKey returns the cache key of a transaction validated at 'height'.
+func validationKey(t types.Transaction, height types.BlockHeight) crypto.Hash {
+	return crypto.HashAll(t, height)
+}
+
+// standaloneValid runs StandaloneValid on a transaction, skipping the checks
+// if the transaction is already known to be valid at 'height'.
+func (vc *validationCache) standaloneValid(t types.Transaction, height types.BlockHeight) error {
+	if vc == nil {
+		return t.StandaloneValid(height)
+	}
+	key := validationKey(t, height)
+	vc.mu.Lock()
+	_, exists := vc.entries[key]
+	vc.mu.Unlock()
+	if exists {
+		return nil
+	}
+
+	err := t.StandaloneValid(height)
+	if err != nil {
+		return err
+	}
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	if len(vc.entries) >= maxValidationCacheSize {
+		for evictKey := range vc.entries {
+			delete(vc.entries, evictKey)
+			break
+		}
+	}
+	vc.entries[key] = struct{}{}
+	return nil
+}
+
+// reset removes every entry from the cache. The cache is reset whenever
+// blocks are reverted, so that no validation result outlives the chain that it
+// was computed on.
+func (vc *validationCache) reset() {
+	if vc == nil {
+		return
+	}
+	vc.mu.Lock()
+	vc.entries = make(map[crypto.Hash]struct{})
+	vc.mu.Unlock()
+}
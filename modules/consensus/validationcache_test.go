@@ -0,0 +1,119 @@
+package consensus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestValidationCache probes the standaloneValid and reset methods of the
+// validationCache type.
+func TestValidationCache(t *testing.T) {
+	vc := newValidationCache()
+
+	// Valid transactions are cached at the height they were validated at.
+	txn := types.Transaction{ArbitraryData: [][]byte{{1}}}
+	if err := vc.standaloneValid(txn, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := vc.entries[validationKey(txn, 5)]; !exists {
+		t.Fatal("valid transaction was not cached")
+	}
+	if _, exists := vc.entries[validationKey(txn, 6)]; exists {
+		t.Fatal("transaction was cached at the wrong height")
+	}
+
+	// Invalid transactions are not cached.
+	invalid := types.Transaction{MinerFees: []types.Currency{{}}}
+	if err := vc.standaloneValid(invalid, 5); err != types.ErrZeroMinerFee {
+		t.Fatal("expected ErrZeroMinerFee, got", err)
+	}
+	if _, exists := vc.entries[validationKey(invalid, 5)]; exists {
+		t.Fatal("invalid transaction was cached")
+	}
+
+	// Cached transactions are not validated again.
+	vc.entries[validationKey(invalid, 5)] = struct{}{}
+	if err := vc.standaloneValid(invalid, 5); err != nil {
+		t.Fatal("cached transaction was validated again:", err)
+	}
+
+	// The cache should not grow beyond its limit.
+	for i := 0; i < maxValidationCacheSize*2; i++ {
+		txn := types.Transaction{ArbitraryData: [][]byte{{byte(i), byte(i >> 8)}}}
+		if err := vc.standaloneValid(txn, 5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(vc.entries) != maxValidationCacheSize {
+		t.Fatal("validation cache has the wrong size:", len(vc.entries))
+	}
+
+	vc.reset()
+	if len(vc.entries) != 0 {
+		t.Fatal("reset did not empty the cache")
+	}
+
+	// A nil cache validates every transaction.
+	var nilCache *validationCache
+	if err := nilCache.standaloneValid(invalid, 5); err != types.ErrZeroMinerFee {
+		t.Fatal("expected ErrZeroMinerFee, got", err)
+	}
+	nilCache.reset()
+}
+
+// TestValidationCacheReorg checks that the validation cache is emptied when
+// blocks are reverted.
+func TestValidationCacheReorg(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst1, err := createConsensusSetTester("TestValidationCacheReorg - 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst1.Close()
+	cst2, err := createConsensusSetTester("TestValidationCacheReorg - 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cst2.Close()
+
+	// Give cst1 a transaction in its cache, then give cst2 a longer chain.
+	_, err = cst1.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := types.Transaction{ArbitraryData: [][]byte{{1}}}
+	key := validationKey(txn, cst1.cs.Height())
+	cst1.cs.validationCache.standaloneValid(txn, cst1.cs.Height())
+	for i := 0; i < 2; i++ {
+		_, err = cst2.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, exists := cst1.cs.validationCache.entries[key]; !exists {
+		t.Fatal("transaction is missing from the validation cache")
+	}
+
+	// Connecting the testers causes cst1 to reorg onto cst2's chain.
+	err = cst1.gateway.Connect(cst2.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50 && cst1.cs.CurrentBlock().ID() != cst2.cs.CurrentBlock().ID(); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if cst1.cs.CurrentBlock().ID() != cst2.cs.CurrentBlock().ID() {
+		t.Fatal("testers did not synchronize")
+	}
+	cst1.cs.validationCache.mu.Lock()
+	_, exists := cst1.cs.validationCache.entries[key]
+	cst1.cs.validationCache.mu.Unlock()
+	if exists {
+		t.Fatal("validation cache was not reset by the reorg")
+	}
+}
@@ -3,6 +3,8 @@ package consensus
 import (
 	"errors"
 	"math/big"
+	"runtime"
+	"sync"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
@@ -25,6 +27,10 @@ var (
 	errUnfinishedFileContract     = errors.New("file contract window has not yet openend")
 	errUnrecognizedFileContractID = errors.New("cannot fetch storage proof segment for unknown file contract")
 	errWrongUnlockConditions      = errors.New("transaction contains incorrect unlock conditions")
+
+	// validationThreads is the number of workers used to verify the
+	// signatures of the transactions in a block.
+	validationThreads = runtime.NumCPU()
 )
 
 // validSiacoins checks that the siacoin inputs and outputs are valid in the
@@ -284,17 +290,21 @@ func validSiafunds(tx *bolt.Tx, t types.Transaction) (err error) {
 
 // validTransaction checks that all fields are valid within the current
 // consensus state. If not an error is returned.
-func validTransaction(tx *bolt.Tx, t types.Transaction) error {
+func validTransaction(tx *bolt.Tx, vc *validationCache, t types.Transaction) error {
 	// StandaloneValid will check things like signatures and properties that
 	// should be inherent to the transaction. (storage proof rules, etc.)
-	err := t.StandaloneValid(blockHeight(tx))
+	err := vc.standaloneValid(t, blockHeight(tx))
 	if err != nil {
 		return err
 	}
+	return validTransactionState(tx, t)
+}
 
-	// Check that each portion of the transaction is legal given the current
-	// consensus set.
-	err = validSiacoins(tx, t)
+// validTransactionState checks that each portion of the transaction is legal
+// given the current consensus set. The checks performed by StandaloneValid are
+// skipped.
+func validTransactionState(tx *bolt.Tx, t types.Transaction) error {
+	err := validSiacoins(tx, t)
 	if err != nil {
 		return err
 	}
@@ -313,6 +323,46 @@ func validTransaction(tx *bolt.Tx, t types.Transaction) error {
 	return nil
 }
 
+// validStandaloneTransactions runs StandaloneValid on a set of transactions
+// using a pool of 'validationThreads' workers. StandaloneValid does not
+// depend on the consensus set, which means the expensive signature checks of
+// independent transactions can be performed concurrently. Storage proofs are
+// not verified here, because a proof may depend on a file contract revision
+// that appears earlier in the same block. The error of the first invalid
+// transaction is returned, so the result does not depend on scheduling. If
+// 'checkSignatures' is false, the signature checks are skipped. Transactions
+// found in 'vc' are not checked again.
+func validStandaloneTransactions(vc *validationCache, txns []types.Transaction, height types.BlockHeight, checkSignatures bool) error {
+	errs := make([]error, len(txns))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < validationThreads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range indices {
+				if checkSignatures {
+					errs[j] = vc.standaloneValid(txns[j], height)
+				} else {
+					errs[j] = txns[j].StandaloneValidUnsigned(height)
+				}
+			}
+		}()
+	}
+	for i := range txns {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // TryTransactionSet applies the input transactions to the consensus set to
 // determine if they are valid. An error is returned IFF they are not a valid
 // set in the current consensus set. The size of the transactions and the set
@@ -337,7 +387,7 @@ func (cs *ConsensusSet) TryTransactionSet(txns []types.Transaction) (modules.Con
 	err := cs.db.Update(func(tx *bolt.Tx) error {
 		diffHolder.Height = blockHeight(tx)
 		for _, txn := range txns {
-			err := validTransaction(tx, txn)
+			err := validTransaction(tx, cs.validationCache, txn)
 			if err != nil {
 				return err
 			}
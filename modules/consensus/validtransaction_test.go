@@ -856,3 +856,35 @@ func TestValidTransaction(t *testing.T) {
 	}
 }
 */
+
+// TestValidStandaloneTransactions checks that validStandaloneTransactions
+// returns the error of the first invalid transaction, regardless of the order
+// in which the workers finish.
+func TestValidStandaloneTransactions(t *testing.T) {
+	zeroOutput := types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{}},
+	}
+	zeroFee := types.Transaction{
+		MinerFees: []types.Currency{{}},
+	}
+	txns := make([]types.Transaction, 100)
+	err := validStandaloneTransactions(nil, txns, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns[40] = zeroOutput
+	txns[80] = zeroFee
+	err = validStandaloneTransactions(nil, txns, 0, true)
+	if err != types.ErrZeroOutput {
+		t.Error("expected ErrZeroOutput, got", err)
+	}
+	txns[20] = zeroFee
+	err = validStandaloneTransactions(nil, txns, 0, true)
+	if err != types.ErrZeroMinerFee {
+		t.Error("expected ErrZeroMinerFee, got", err)
+	}
+	err = validStandaloneTransactions(nil, nil, 0, true)
+	if err != nil {
+		t.Error(err)
+	}
+}
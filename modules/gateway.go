@@ -2,6 +2,9 @@ package modules
 
 import (
 	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
 )
 
 const (
@@ -11,6 +14,20 @@ const (
 	// WellConnectedThreshold is the number of outbound connections at which the
 	// gateway will not attempt to make new outbound connections.
 	WellConnectedThreshold = 8
+
+	// MisbehaviorInvalidBlock is the misbehavior score reported for a peer
+	// that sends an invalid block or block header. A single invalid block is
+	// enough for the peer to be banned.
+	MisbehaviorInvalidBlock = 100
+
+	// MisbehaviorMalformedRPC is the misbehavior score reported for a peer
+	// that sends an RPC object that cannot be decoded.
+	MisbehaviorMalformedRPC = 20
+
+	// MisbehaviorNonstandardTransaction is the misbehavior score reported for
+	// a peer that relays a transaction set that breaks the IsStandard rules
+	// of the transaction pool.
+	MisbehaviorNonstandardTransaction = 10
 )
 
 // TODO: Move this and its functionality into the gateway package.
@@ -30,6 +47,13 @@ var (
 		"79.51.183.132:9981",
 		"176.9.59.110:9981",
 	}
+
+	// DNSSeeds is a list of hostnames that resolve to the addresses of
+	// current peers. Unlike BootstrapPeers, the addresses behind a seed are
+	// maintained by its operator, so the list does not go stale between
+	// releases. A seed may include a port, which then applies to all of its
+	// addresses.
+	DNSSeeds []string
 )
 
 type (
@@ -38,6 +62,47 @@ type (
 		NetAddress NetAddress `json:"netaddress"`
 		Version    string     `json:"version"`
 		Inbound    bool       `json:"inbound"`
+
+		// RPCs contains the names of the RPCs that the peer advertised when
+		// the connection was established, truncated to 8 characters. It is
+		// empty for peers older than v1.0.1.
+		RPCs []string `json:"rpcs"`
+
+		// ProtocolVersion and GitRevision identify the build of the peer.
+		// They are empty for peers that do not report them.
+		ProtocolVersion string `json:"protocolversion"`
+		GitRevision     string `json:"gitrevision"`
+	}
+
+	// PeerStats contains statistics about the connection to a peer, for
+	// debugging connectivity issues.
+	PeerStats struct {
+		Peer
+
+		// ConnectedSince is the time at which the connection was established.
+		ConnectedSince time.Time `json:"connectedsince"`
+
+		// Latency is the round trip time to the peer. It is zero if the
+		// latency has not been measured.
+		Latency time.Duration `json:"latency"`
+
+		// BytesSent and BytesReceived are the number of bytes that have been
+		// sent to and received from the peer over the connection.
+		BytesSent     uint64 `json:"bytessent"`
+		BytesReceived uint64 `json:"bytesreceived"`
+
+		// LastBlock is the ID of the most recent block relayed by the peer
+		// that was accepted, and LastBlockTime is the time at which it was
+		// relayed. Both are zero if the peer has not relayed a block.
+		LastBlock     types.BlockID `json:"lastblock"`
+		LastBlockTime time.Time     `json:"lastblocktime"`
+	}
+
+	// A PeerBan is a host that the Gateway refuses to connect to, or accept
+	// connections from, until the ban expires.
+	PeerBan struct {
+		Host   string    `json:"host"`
+		Expiry time.Time `json:"expiry"`
 	}
 
 	// A PeerConn is the connection type used when communicating with peers during
@@ -74,6 +139,13 @@ type (
 		// Peers returns the addresses that the Gateway is currently connected to.
 		Peers() []Peer
 
+		// PeerStats returns statistics about the connection to each peer.
+		PeerStats() []PeerStats
+
+		// RecordRelayedBlock records that a peer relayed a block that was
+		// accepted.
+		RecordRelayedBlock(NetAddress, types.BlockID)
+
 		// RegisterRPC registers a function to handle incoming connections that
 		// supply the given RPC ID.
 		RegisterRPC(string, RPCFunc)
@@ -98,10 +170,37 @@ type (
 		// address that the Gateway is not connected to.
 		RPC(NetAddress, string, RPCFunc) error
 
+		// ReportMisbehavior adds to the misbehavior score of the host of the
+		// given peer. Hosts whose score exceeds the ban threshold are
+		// disconnected and temporarily banned.
+		ReportMisbehavior(NetAddress, int)
+
+		// BanPeer bans a host for the given duration, disconnecting all peers
+		// on that host. A non-positive duration uses the default ban duration.
+		BanPeer(host string, duration time.Duration) error
+
+		// UnbanPeer lifts the ban on a host.
+		UnbanPeer(host string) error
+
+		// Bans returns the hosts that are currently banned.
+		Bans() []PeerBan
+
+		// Blocklist returns the IP ranges that the Gateway refuses to connect
+		// to, or accept connections from.
+		Blocklist() *Blocklist
+
 		// Broadcast transmits obj, prefaced by the RPC name, to all of the
 		// given peers in parallel.
 		Broadcast(name string, obj interface{}, peers []Peer)
 
+		// Alerts returns the unexpired network alerts that the Gateway has
+		// received, oldest first.
+		Alerts() []Alert
+
+		// BroadcastNetworkAlert verifies a signed network alert and relays it
+		// to all peers.
+		BroadcastNetworkAlert(NetworkAlert) error
+
 		// Close safely stops the Gateway's listener process.
 		Close() error
 	}
@@ -0,0 +1,216 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+)
+
+const (
+	// bansFile is the name of the file that contains the banned hosts.
+	bansFile = "bans.json"
+
+	// banThreshold is the misbehavior score at which a host is banned.
+	banThreshold = 100
+)
+
+var (
+	// banDuration is the length of a ban triggered by misbehavior, and the
+	// default length of a ban requested through BanPeer.
+	banDuration = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 1 * time.Hour
+		case "standard":
+			return 24 * time.Hour
+		case "testing":
+			return 5 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// bansMetadata contains the header and version strings that identify the
+	// ban list persist file.
+	bansMetadata = persist.Metadata{
+		Header:  "Sia Ban List",
+		Version: "1.0.1",
+	}
+
+	errHostNotBanned = errors.New("host is not banned")
+	errInvalidHost   = errors.New("host must be an IP address")
+	errPeerBanned    = errors.New("peer is banned")
+	errPeerBlocked   = errors.New("peer is in a blocked IP range")
+)
+
+// isBanned returns true if the host has been banned and the ban has not yet
+// expired.
+func (g *Gateway) isBanned(host string) bool {
+	expiry, exists := g.bans[host]
+	return exists && time.Now().Before(expiry)
+}
+
+// banHost bans a host until the given time and saves the ban list.
+func (g *Gateway) banHost(host string, expiry time.Time) {
+	g.bans[host] = expiry
+	delete(g.misbehavior, host)
+	if err := g.saveBans(); err != nil {
+		g.log.Println("WARN: failed to save the ban list:", err)
+	}
+}
+
+// managedDisconnectHost disconnects all peers on the given host.
+func (g *Gateway) managedDisconnectHost(host string) {
+	var addrs []modules.NetAddress
+	g.mu.RLock()
+	for addr := range g.peers {
+		if addr.Host() == host {
+			addrs = append(addrs, addr)
+		}
+	}
+	g.mu.RUnlock()
+	for _, addr := range addrs {
+		if err := g.Disconnect(addr); err != nil {
+			g.log.Debugf("WARN: failed to disconnect banned peer %v: %v", addr, err)
+		}
+	}
+}
+
+// ReportMisbehavior adds 'score' to the misbehavior score of the host of
+// 'addr'. If the total reaches banThreshold, the host is banned for
+// banDuration and all of its peers are disconnected. Scores are kept in
+// memory only, and are cleared when the host is banned.
+func (g *Gateway) ReportMisbehavior(addr modules.NetAddress, score int) {
+	host := addr.Host()
+	if host == "" || score <= 0 {
+		return
+	}
+
+	g.mu.Lock()
+	if g.isBanned(host) {
+		g.mu.Unlock()
+		return
+	}
+	g.misbehavior[host] += score
+	banned := g.misbehavior[host] >= banThreshold
+	if banned {
+		g.banHost(host, time.Now().Add(banDuration))
+	}
+	g.mu.Unlock()
+
+	if banned {
+		g.log.Printf("INFO: banned %v for misbehavior (last reported by %v)", host, addr)
+		g.managedDisconnectHost(host)
+	} else {
+		g.log.Debugf("INFO: peer %v misbehaved, score increased by %v", addr, score)
+	}
+}
+
+// BanPeer bans a host for 'duration' and disconnects all of its peers. If
+// 'duration' is not positive, banDuration is used.
+func (g *Gateway) BanPeer(host string, duration time.Duration) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	if net.ParseIP(host) == nil {
+		return errInvalidHost
+	}
+	if duration <= 0 {
+		duration = banDuration
+	}
+	g.mu.Lock()
+	g.banHost(host, time.Now().Add(duration))
+	g.mu.Unlock()
+
+	g.log.Printf("INFO: banned %v for %v", host, duration)
+	g.managedDisconnectHost(host)
+	return nil
+}
+
+// UnbanPeer lifts the ban on a host, and forgets its misbehavior score.
+func (g *Gateway) UnbanPeer(host string) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.isBanned(host) {
+		return errHostNotBanned
+	}
+	delete(g.bans, host)
+	delete(g.misbehavior, host)
+	g.log.Println("INFO: unbanned", host)
+	return g.saveBans()
+}
+
+// Bans returns the hosts that are currently banned, sorted by host.
+func (g *Gateway) Bans() []modules.PeerBan {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var hosts []string
+	for host := range g.bans {
+		if g.isBanned(host) {
+			hosts = append(hosts, host)
+		}
+	}
+	sort.Strings(hosts)
+	var bans []modules.PeerBan
+	for _, host := range hosts {
+		bans = append(bans, modules.PeerBan{Host: host, Expiry: g.bans[host]})
+	}
+	return bans
+}
+
+// Blocklist returns the IP ranges that the Gateway refuses to connect to, or
+// accept connections from.
+func (g *Gateway) Blocklist() *modules.Blocklist {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.blocklist
+}
+
+// SetBlocklist replaces the Blocklist of the Gateway, which may be shared with
+// other modules. Connected peers are not affected.
+func (g *Gateway) SetBlocklist(bl *modules.Blocklist) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.blocklist = bl
+}
+
+// saveBans stores the unexpired bans on disk.
+func (g *Gateway) saveBans() error {
+	var bans []modules.PeerBan
+	for host, expiry := range g.bans {
+		if !g.isBanned(host) {
+			delete(g.bans, host)
+			continue
+		}
+		bans = append(bans, modules.PeerBan{Host: host, Expiry: expiry})
+	}
+	return persist.SaveFile(bansMetadata, bans, filepath.Join(g.persistDir, bansFile))
+}
+
+// loadBans loads the ban list from disk, discarding expired bans.
+func (g *Gateway) loadBans() error {
+	var bans []modules.PeerBan
+	err := persist.LoadFile(bansMetadata, &bans, filepath.Join(g.persistDir, bansFile))
+	if err != nil {
+		return err
+	}
+	for _, b := range bans {
+		if time.Now().Before(b.Expiry) {
+			g.bans[b.Host] = b.Expiry
+		}
+	}
+	return nil
+}
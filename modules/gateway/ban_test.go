@@ -0,0 +1,276 @@
+package gateway
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestReportMisbehavior checks that a host is banned once its misbehavior
+// score reaches banThreshold, and that peers on a banned host are
+// disconnected and cannot reconnect.
+func TestReportMisbehavior(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newTestingGateway("TestReportMisbehavior1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestReportMisbehavior2", t)
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A score below the threshold does not ban the host.
+	g1.ReportMisbehavior(g2.Address(), banThreshold-1)
+	if len(g1.Bans()) != 0 {
+		t.Fatal("host was banned below the threshold")
+	}
+	if len(g1.Peers()) != 1 {
+		t.Fatal("peer was disconnected below the threshold")
+	}
+
+	// Reaching the threshold bans the host and disconnects the peer.
+	g1.ReportMisbehavior(g2.Address(), 1)
+	bans := g1.Bans()
+	if len(bans) != 1 || bans[0].Host != g2.Address().Host() {
+		t.Fatal("host was not banned:", bans)
+	}
+	if len(g1.Peers()) != 0 {
+		t.Fatal("banned peer was not disconnected")
+	}
+	g1.mu.RLock()
+	_, scored := g1.misbehavior[g2.Address().Host()]
+	g1.mu.RUnlock()
+	if scored {
+		t.Fatal("misbehavior score was not cleared after the ban")
+	}
+
+	// Neither side can reconnect while the ban is in place.
+	if err := g1.Connect(g2.Address()); err != errPeerBanned {
+		t.Fatal("expected errPeerBanned, got", err)
+	}
+	if err := g2.Connect(g1.Address()); err == nil {
+		t.Fatal("banned host was able to connect")
+	}
+
+	// Once the host is unbanned, the peers can connect again.
+	if err := g1.UnbanPeer(g2.Address().Host()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.UnbanPeer(g2.Address().Host()); err != errHostNotBanned {
+		t.Fatal("expected errHostNotBanned, got", err)
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBanPeer checks that manual bans expire after the requested duration
+// and that invalid hosts are rejected.
+func TestBanPeer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway("TestBanPeer", t)
+	defer g.Close()
+
+	if err := g.BanPeer("foo", time.Minute); err != errInvalidHost {
+		t.Fatal("expected errInvalidHost, got", err)
+	}
+	if err := g.BanPeer("111.111.111.111", 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.BanPeer("222.222.222.222", 0); err != nil {
+		t.Fatal(err)
+	}
+	bans := g.Bans()
+	if len(bans) != 2 || bans[0].Host != "111.111.111.111" || bans[1].Host != "222.222.222.222" {
+		t.Fatal("bans were not recorded:", bans)
+	}
+	if bans[1].Expiry.Sub(time.Now()) > banDuration {
+		t.Fatal("default ban is longer than banDuration")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	bans = g.Bans()
+	if len(bans) != 1 || bans[0].Host != "222.222.222.222" {
+		t.Fatal("expired ban was not lifted:", bans)
+	}
+	g.mu.RLock()
+	banned := g.isBanned("111.111.111.111")
+	g.mu.RUnlock()
+	if banned {
+		t.Fatal("host is still banned after the ban expired")
+	}
+}
+
+// TestLoadBans checks that bans persist across restarts.
+func TestLoadBans(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway("TestLoadBans", t)
+	if err := g.BanPeer("111.111.111.111", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	g.ReportMisbehavior(modules.NetAddress("222.222.222.222:9981"), banThreshold)
+	bans := g.Bans()
+	g.Close()
+
+	g2, err := New("localhost:0", g.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g2.Close()
+	bans2 := g2.Bans()
+	if len(bans2) != len(bans) {
+		t.Fatal("gateway did not load the ban list:", bans2)
+	}
+	for i := range bans {
+		if bans[i].Host != bans2[i].Host || !bans[i].Expiry.Equal(bans2[i].Expiry) {
+			t.Fatal("loaded ban does not match the saved ban:", bans2[i])
+		}
+	}
+}
+
+// TestBlockedPeers checks that the Gateway does not connect to, or accept
+// connections from, peers in a blocked IP range.
+func TestBlockedPeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newTestingGateway("TestBlockedPeers1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestBlockedPeers2", t)
+	defer g2.Close()
+
+	if err := g1.Blocklist().Block("127.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Connect(g2.Address()); err != errPeerBlocked {
+		t.Fatal("expected errPeerBlocked, got", err)
+	}
+
+	// Inbound connections from the blocked range are closed before the
+	// version handshake.
+	conn, err := net.Dial("tcp", string(g1.Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := connectVersionHandshake(conn, build.Version); err == nil {
+		t.Fatal("gateway accepted a connection from a blocked peer")
+	}
+
+	// The blocklist of one gateway does not affect other gateways.
+	if g2.Blocklist().IsBlocked(g1.Address()) {
+		t.Fatal("blocklist leaked between gateways")
+	}
+
+	if err := g1.Blocklist().Unblock("127.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// misbehaviorScore returns the misbehavior score of a host.
+func (g *Gateway) misbehaviorScore(host string) int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.misbehavior[host]
+}
+
+// TestMalformedRPC checks that peers which send malformed messages during an
+// RPC are reported, whether they call the RPC or respond to it. A caller whose
+// request is rejected receives an *encoding.RemoteError, and a caller that
+// receives a malformed reply gets an *encoding.MalformedError.
+func TestMalformedRPC(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newTestingGateway("TestMalformedRPC1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestMalformedRPC2", t)
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	host := g1.Address().Host()
+
+	// waitForScore waits until the score of the host reaches 'score'.
+	waitForScore := func(g *Gateway, score int) {
+		for i := 0; i < 50 && g.misbehaviorScore(host) < score; i++ {
+			time.Sleep(20 * time.Millisecond)
+		}
+		if s := g.misbehaviorScore(host); s != score {
+			t.Fatalf("expected misbehavior score %v, got %v", score, s)
+		}
+	}
+
+	// A caller that sends a message longer than the handler allows is
+	// reported by the handler, and receives the error in place of the reply.
+	g2.RegisterRPC("Foo", func(conn modules.PeerConn) error {
+		var i uint64
+		if err := encoding.ReadObject(conn, &i, 8); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, i)
+	})
+	err := g1.RPC(g2.Address(), "Foo", func(conn modules.PeerConn) error {
+		if err := encoding.WriteObject(conn, make([]byte, 100)); err != nil {
+			return err
+		}
+		var i uint64
+		return encoding.ReadObject(conn, &i, 8)
+	})
+	if !encoding.IsRemote(err) {
+		t.Fatal("expected a RemoteError, got", err)
+	}
+	waitForScore(g2, modules.MisbehaviorMalformedRPC)
+	if s := g1.misbehaviorScore(g2.Address().Host()); s != 0 {
+		t.Fatal("caller reported the peer that rejected its request:", s)
+	}
+
+	// A handler that responds with a message that cannot be decoded is
+	// reported by the caller.
+	g1.RegisterRPC("Bar", func(conn modules.PeerConn) error {
+		return encoding.WritePrefix(conn, []byte{2})
+	})
+	err = g2.RPC(g1.Address(), "Bar", func(conn modules.PeerConn) error {
+		var b bool
+		return encoding.ReadObject(conn, &b, 1)
+	})
+	if !encoding.IsMalformed(err) {
+		t.Fatal("expected a MalformedError, got", err)
+	}
+	waitForScore(g2, 2*modules.MisbehaviorMalformedRPC)
+
+	// A caller that sends a malformed RPC ID is reported.
+	p, err := g1.peers[g2.Address()].open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := encoding.WritePrefix(p, make([]byte, 9)); err != nil {
+		t.Fatal(err)
+	}
+	waitForScore(g2, 3*modules.MisbehaviorMalformedRPC)
+
+	// Both sides are still connected.
+	if len(g1.Peers()) != 1 || len(g2.Peers()) != 1 {
+		t.Fatal("peers were disconnected below the ban threshold")
+	}
+}
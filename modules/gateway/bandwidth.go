@@ -0,0 +1,29 @@
+package gateway
+
+import (
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// rpcBandwidthCategories maps the RPCs of other modules to the purpose of
+// their bandwidth. RPCs that are not listed are counted as
+// modules.BandwidthOther.
+var rpcBandwidthCategories = map[rpcID]modules.BandwidthCategory{
+	handlerName("SendBlocks"):    modules.BandwidthBlockSync,
+	handlerName("SendBlk"):       modules.BandwidthBlockSync,
+	handlerName("SendBlkRange"):  modules.BandwidthBlockSync,
+	handlerName("SendHeaders"):   modules.BandwidthBlockSync,
+	handlerName("SendTxnProofs"): modules.BandwidthBlockSync,
+	handlerName("RelayBlock"):    modules.BandwidthBlockSync,
+	handlerName("RelayHeader"):   modules.BandwidthBlockSync,
+
+	handlerName("RelayTransactionSet"): modules.BandwidthTransactionRelay,
+	handlerName("RelayTxnInv"):         modules.BandwidthTransactionRelay,
+}
+
+// rpcBandwidthCategory returns the purpose of the bandwidth used by an RPC.
+func rpcBandwidthCategory(id rpcID) modules.BandwidthCategory {
+	if c, ok := rpcBandwidthCategories[id]; ok {
+		return c
+	}
+	return modules.BandwidthOther
+}
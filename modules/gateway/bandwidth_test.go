@@ -0,0 +1,60 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// transactionRelayUsage returns the bandwidth used to relay transactions.
+func transactionRelayUsage() modules.BandwidthUsage {
+	for _, usage := range modules.BandwidthTotals() {
+		if usage.Category == modules.BandwidthTransactionRelay {
+			return usage
+		}
+	}
+	return modules.BandwidthUsage{}
+}
+
+// TestRPCBandwidth checks that the bandwidth of RPCs is counted towards the
+// purpose of the RPC.
+func TestRPCBandwidth(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newTestingGateway("TestRPCBandwidth1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestRPCBandwidth2", t)
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	data := make([]byte, 1000)
+	g2.RegisterRPC("RelayTransactionSet", func(conn modules.PeerConn) error {
+		if err := encoding.ReadObject(conn, &data, uint64(len(data))+8); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, true)
+	})
+	before := transactionRelayUsage()
+	err := g1.RPC(g2.Address(), "RelayTransactionSet", func(conn modules.PeerConn) error {
+		if err := encoding.WriteObject(conn, data); err != nil {
+			return err
+		}
+		var ack bool
+		return encoding.ReadObject(conn, &ack, 1)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := transactionRelayUsage()
+	if after.Sent-before.Sent < 1000 || after.Received-before.Received < 1000 {
+		t.Fatal("RPC bandwidth was not counted as transaction relay:", before, after)
+	}
+	if rpcBandwidthCategory(handlerName("ShareNodes")) != modules.BandwidthOther {
+		t.Fatal("ShareNodes should be counted as other bandwidth")
+	}
+}
@@ -0,0 +1,57 @@
+package gateway
+
+import (
+	"compress/flate"
+	"io"
+	"net"
+	"sync"
+)
+
+// compressedConn is a net.Conn that compresses the data written to it and
+// decompresses the data read from it. Both ends of the connection must use a
+// compressedConn, which is negotiated with the session header. Every write is
+// flushed, so that RPC messages are not held back waiting for more data.
+type compressedConn struct {
+	net.Conn
+	r io.ReadCloser
+
+	w   *flate.Writer
+	wMu sync.Mutex
+}
+
+// newCompressedConn wraps a connection so that its traffic is compressed.
+func newCompressedConn(conn net.Conn) net.Conn {
+	// flate.NewWriter only returns an error for invalid compression levels.
+	w, _ := flate.NewWriter(conn, flate.BestSpeed)
+	return &compressedConn{
+		Conn: conn,
+		r:    flate.NewReader(conn),
+		w:    w,
+	}
+}
+
+// Read reads decompressed data from the connection.
+func (cc *compressedConn) Read(p []byte) (int, error) {
+	return cc.r.Read(p)
+}
+
+// Write compresses data and writes it to the connection.
+func (cc *compressedConn) Write(p []byte) (int, error) {
+	cc.wMu.Lock()
+	defer cc.wMu.Unlock()
+	n, err := cc.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, cc.w.Flush()
+}
+
+// SetCompression sets whether the Gateway offers to compress the sessions of
+// new peers. Compression is only used with peers that offer it as well. It
+// reduces the bandwidth used by block synchronization, at the cost of some
+// CPU time.
+func (g *Gateway) SetCompression(enabled bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.compression = enabled
+}
@@ -0,0 +1,143 @@
+package gateway
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestSessionHeaderEncoding checks that sessionHeaders are encoded with the
+// Compression and build fields, and that headers sent without them can still
+// be decoded.
+func TestSessionHeaderEncoding(t *testing.T) {
+	sh := sessionHeader{
+		GenesisID:       types.GenesisID,
+		RPCs:            []rpcID{handlerName("ShareNodes")},
+		Compression:     true,
+		ProtocolVersion: "1.0.1",
+		GitRevision:     "abc123",
+		PublicAddress:   "expyuzz4wqqyqhjn.onion:9981",
+	}
+	var decoded sessionHeader
+	if err := encoding.Unmarshal(encoding.Marshal(sh), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GenesisID != sh.GenesisID || len(decoded.RPCs) != 1 || decoded.RPCs[0] != sh.RPCs[0] || !decoded.Compression {
+		t.Fatal("sessionHeader did not survive encoding:", decoded)
+	}
+	if decoded.ProtocolVersion != sh.ProtocolVersion || decoded.GitRevision != sh.GitRevision {
+		t.Fatal("build fields did not survive encoding:", decoded)
+	}
+	if decoded.PublicAddress != sh.PublicAddress {
+		t.Fatal("public address did not survive encoding:", decoded)
+	}
+
+	// A header without the public address.
+	noAddress := struct {
+		GenesisID       types.BlockID
+		RPCs            []rpcID
+		Compression     bool
+		ProtocolVersion string
+		GitRevision     string
+	}{sh.GenesisID, sh.RPCs, true, sh.ProtocolVersion, sh.GitRevision}
+	decoded = sessionHeader{}
+	if err := encoding.Unmarshal(encoding.Marshal(noAddress), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GitRevision != sh.GitRevision || decoded.PublicAddress != "" {
+		t.Fatal("header without the public address was decoded incorrectly:", decoded)
+	}
+
+	// A header without the build fields.
+	noBuild := struct {
+		GenesisID   types.BlockID
+		RPCs        []rpcID
+		Compression bool
+	}{sh.GenesisID, sh.RPCs, true}
+	decoded = sessionHeader{}
+	if err := encoding.Unmarshal(encoding.Marshal(noBuild), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Compression || decoded.ProtocolVersion != "" || decoded.GitRevision != "" {
+		t.Fatal("header without the build fields was decoded incorrectly:", decoded)
+	}
+
+	// A header without the Compression field.
+	old := struct {
+		GenesisID types.BlockID
+		RPCs      []rpcID
+	}{sh.GenesisID, sh.RPCs}
+	decoded = sessionHeader{}
+	if err := encoding.Unmarshal(encoding.Marshal(old), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.GenesisID != sh.GenesisID || len(decoded.RPCs) != 1 || decoded.Compression {
+		t.Fatal("header without the Compression field was decoded incorrectly:", decoded)
+	}
+}
+
+// TestCompressedSession checks that sessions are compressed only when both
+// peers offer compression, and that RPCs work over compressed sessions.
+func TestCompressedSession(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// transfer sends a compressible payload from g1 to g2 and back, and
+	// returns the number of bytes that g1 sent over the connection.
+	payload := bytes.Repeat([]byte("Sia block data "), 10e3)
+	transfer := func(g1, g2 *Gateway) uint64 {
+		g2.RegisterRPC("Echo", func(conn modules.PeerConn) error {
+			var b []byte
+			if err := encoding.ReadObject(conn, &b, uint64(2*len(payload))); err != nil {
+				return err
+			}
+			return encoding.WriteObject(conn, b)
+		})
+		if err := g1.Connect(g2.Address()); err != nil {
+			t.Fatal(err)
+		}
+		// Wait for the OnConnectRPCs to finish.
+		time.Sleep(100 * time.Millisecond)
+		before := g1.PeerStats()[0].BytesSent
+		var echoed []byte
+		err := g1.RPC(g2.Address(), "Echo", func(conn modules.PeerConn) error {
+			if err := encoding.WriteObject(conn, payload); err != nil {
+				return err
+			}
+			return encoding.ReadObject(conn, &echoed, uint64(2*len(payload)))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(echoed, payload) {
+			t.Fatal("payload was corrupted")
+		}
+		return g1.PeerStats()[0].BytesSent - before
+	}
+
+	g1 := newTestingGateway("TestCompressedSession1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestCompressedSession2", t)
+	defer g2.Close()
+	g1.SetCompression(true)
+	g2.SetCompression(true)
+	if sent := transfer(g1, g2); sent >= uint64(len(payload))/10 {
+		t.Fatalf("session was not compressed: sent %v bytes for a %v byte payload", sent, len(payload))
+	}
+
+	// Compression is not used unless both peers offer it.
+	g3 := newTestingGateway("TestCompressedSession3", t)
+	defer g3.Close()
+	g4 := newTestingGateway("TestCompressedSession4", t)
+	defer g4.Close()
+	g3.SetCompression(true)
+	if sent := transfer(g3, g4); sent < uint64(len(payload)) {
+		t.Fatalf("session was compressed: sent %v bytes for a %v byte payload", sent, len(payload))
+	}
+}
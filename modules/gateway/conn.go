@@ -17,3 +17,27 @@ type peerConn struct {
 func (pc peerConn) RPCAddr() modules.NetAddress {
 	return pc.dialbackAddr
 }
+
+// SetRateLimits limits the bandwidth used by the Gateway's peers. Each peer is
+// limited to 'perPeer' bytes per second, and all peers together are limited
+// by 'global', which may be shared with other modules. A nil 'global' or a
+// zero 'perPeer' removes the corresponding limit. Both limits apply to peers
+// that connect after the call; existing peers keep the limits they connected
+// with. Calling SetRate on 'global' adjusts every connection sharing it,
+// including existing ones.
+func (g *Gateway) SetRateLimits(global *modules.RateLimit, perPeer int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rateLimit = global
+	g.peerRateLimit = perPeer
+}
+
+// limitConn wraps the connection of a new peer so that it respects the
+// Gateway's rate limits.
+func (g *Gateway) limitConn(conn net.Conn) net.Conn {
+	var peerLimit *modules.RateLimit
+	if g.peerRateLimit > 0 {
+		peerLimit = modules.NewRateLimit(g.peerRateLimit)
+	}
+	return modules.NewRateLimitedConn(conn, peerLimit, g.rateLimit)
+}
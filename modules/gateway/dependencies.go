@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// dependencies defines the network functions used by the Gateway, so that
+// tests can run gateways on a simulated network.
+type dependencies interface {
+	// dial connects to a peer or node.
+	dial(modules.NetAddress, time.Duration) (net.Conn, error)
+
+	// listen creates a listener for incoming peer connections.
+	listen(string) (net.Listener, error)
+
+	// lookupHost resolves a hostname to its IP addresses.
+	lookupHost(string) ([]string, error)
+}
+
+// productionDependencies implements the dependencies of the Gateway using the
+// real network.
+type productionDependencies struct{}
+
+// dial connects to a peer or node, through the proxy if one has been set.
+func (productionDependencies) dial(addr modules.NetAddress, timeout time.Duration) (net.Conn, error) {
+	return modules.Dial(addr, timeout)
+}
+
+// listen creates a TCP listener.
+func (productionDependencies) listen(addr string) (net.Listener, error) {
+	return net.Listen("tcp", addr)
+}
+
+// lookupHost resolves a hostname using the system resolver.
+func (productionDependencies) lookupHost(host string) ([]string, error) {
+	return net.LookupHost(host)
+}
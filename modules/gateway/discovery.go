@@ -0,0 +1,219 @@
+package gateway
+
+// discovery.go implements discovery of peers on the local network. Gateways
+// with LAN discovery enabled periodically multicast an announcement containing
+// their genesis ID and port, and connect to the gateways whose announcements
+// they receive. This makes it possible to set up a local test network without
+// configuring any peers.
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// lanDiscoveryAddr is the multicast group that LAN announcements are sent
+	// to. It is in the administratively scoped range, so announcements are
+	// not routed beyond the local network.
+	lanDiscoveryAddr = "239.255.83.73:9983"
+
+	// maxLANAnnouncementSize is the largest announcement that will be
+	// decoded.
+	maxLANAnnouncementSize = 256
+)
+
+var (
+	// lanAnnouncementSpecifier identifies a LAN announcement.
+	lanAnnouncementSpecifier = types.Specifier{'L', 'A', 'N', ' ', 'a', 'n', 'n', 'o', 'u', 'n', 'c', 'e'}
+
+	// lanDiscoveryInterval is how often a LAN announcement is sent.
+	lanDiscoveryInterval = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 10 * time.Second
+		case "standard":
+			return 1 * time.Minute
+		case "testing":
+			return 500 * time.Millisecond
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	errLANDiscoveryEnabled = errors.New("LAN discovery is already enabled")
+)
+
+// A lanAnnouncement is multicast by gateways with LAN discovery enabled. The
+// address of the gateway is the source address of the announcement combined
+// with the announced port. The Nonce is chosen randomly by each gateway, so
+// that it can ignore its own announcements.
+type lanAnnouncement struct {
+	Specifier types.Specifier
+	GenesisID types.BlockID
+	Port      string
+	Nonce     [8]byte
+}
+
+// EnableLANDiscovery makes the Gateway announce itself to, and connect to,
+// the other gateways on the local network that have LAN discovery enabled.
+// Discovered gateways are added to the node list, and are connected to as long
+// as the Gateway has free outbound peer slots.
+func (g *Gateway) EnableLANDiscovery() error {
+	g.mu.Lock()
+	if g.lanDiscovery {
+		g.mu.Unlock()
+		return errLANDiscoveryEnabled
+	}
+	g.lanDiscovery = true
+	g.mu.Unlock()
+
+	l, conn, err := g.managedOpenLANConns()
+	if err != nil {
+		g.mu.Lock()
+		g.lanDiscovery = false
+		g.mu.Unlock()
+		return err
+	}
+	nonceBytes, err := crypto.RandBytes(8)
+	if err != nil {
+		l.Close()
+		conn.Close()
+		g.mu.Lock()
+		g.lanDiscovery = false
+		g.mu.Unlock()
+		return err
+	}
+	g.mu.RLock()
+	ann := lanAnnouncement{
+		Specifier: lanAnnouncementSpecifier,
+		GenesisID: types.GenesisID,
+		Port:      g.port,
+	}
+	g.mu.RUnlock()
+	copy(ann.Nonce[:], nonceBytes)
+
+	// Closing the connections unblocks the discovery threads, which are
+	// tracked by the thread group. If the Gateway has already been closed,
+	// OnStop closes the connections immediately.
+	g.threads.OnStop(func() {
+		l.Close()
+		conn.Close()
+	})
+	go g.threadedListenLAN(l, ann.Nonce)
+	go g.threadedAnnounceLAN(conn, ann)
+	return nil
+}
+
+// managedOpenLANConns opens the connections used to receive and send LAN
+// announcements.
+func (g *Gateway) managedOpenLANConns() (l, conn *net.UDPConn, err error) {
+	group, err := net.ResolveUDPAddr("udp4", lanDiscoveryAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err = net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err = net.DialUDP("udp4", nil, group)
+	if err != nil {
+		l.Close()
+		return nil, nil, err
+	}
+	return l, conn, nil
+}
+
+// threadedAnnounceLAN periodically multicasts the Gateway's LAN announcement.
+func (g *Gateway) threadedAnnounceLAN(conn *net.UDPConn, ann lanAnnouncement) {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	msg := encoding.Marshal(ann)
+	for {
+		if _, err := conn.Write(msg); err != nil {
+			g.log.Debugln("WARN: failed to send LAN announcement:", err)
+		}
+		select {
+		case <-time.After(lanDiscoveryInterval):
+		case <-g.threads.StopChan():
+			return
+		}
+	}
+}
+
+// threadedListenLAN receives the LAN announcements of other gateways, and
+// connects to the gateways that are on the same network.
+func (g *Gateway) threadedListenLAN(l *net.UDPConn, nonce [8]byte) {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	// connecting holds the LAN nodes that are currently being connected to,
+	// so that the repeated announcements of a node do not result in
+	// concurrent connection attempts. It is protected by g.mu.
+	connecting := make(map[modules.NetAddress]struct{})
+
+	buf := make([]byte, maxLANAnnouncementSize)
+	for {
+		n, from, err := l.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		var ann lanAnnouncement
+		if err := encoding.Unmarshal(buf[:n], &ann); err != nil {
+			continue
+		}
+		if ann.Specifier != lanAnnouncementSpecifier || ann.GenesisID != types.GenesisID || ann.Nonce == nonce {
+			continue
+		}
+		addr := modules.NetAddress(net.JoinHostPort(from.IP.String(), ann.Port))
+		if addr.IsValid() != nil {
+			continue
+		}
+
+		g.mu.Lock()
+		_, isPeer := g.peers[addr]
+		_, isConnecting := connecting[addr]
+		full := g.numOutboundPeers() >= g.maxOutboundPeers
+		if !isPeer && g.addNode(addr) == nil {
+			g.save()
+			g.log.Debugln("INFO: discovered LAN node", addr)
+		}
+		connect := !isPeer && !isConnecting && !full
+		if connect {
+			connecting[addr] = struct{}{}
+		}
+		g.mu.Unlock()
+		if !connect {
+			continue
+		}
+
+		// Connect in a separate thread, so that the listener keeps draining
+		// announcements and never blocks the shutdown of the Gateway.
+		go func(addr modules.NetAddress) {
+			defer func() {
+				g.mu.Lock()
+				delete(connecting, addr)
+				g.mu.Unlock()
+			}()
+			if g.threads.Add() != nil {
+				return
+			}
+			defer g.threads.Done()
+
+			if err := g.Connect(addr); err != nil {
+				g.log.Debugf("WARN: failed to connect to LAN node %v: %v", addr, err)
+			}
+		}(addr)
+	}
+}
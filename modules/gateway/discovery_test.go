@@ -0,0 +1,87 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+// TestLANDiscovery checks that gateways with LAN discovery enabled connect to
+// each other.
+func TestLANDiscovery(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// The gateways listen on all interfaces, as announcements are received
+	// from the address of the interface that they were sent on.
+	g1, err := New(":0", build.TempDir("gateway", "TestLANDiscovery1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g1.Close()
+	g2, err := New(":0", build.TempDir("gateway", "TestLANDiscovery2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g2.Close()
+
+	if err := g1.EnableLANDiscovery(); err != nil {
+		t.Skip("multicast is not supported:", err)
+	}
+	if err := g1.EnableLANDiscovery(); err != errLANDiscoveryEnabled {
+		t.Fatal("expected errLANDiscoveryEnabled, got", err)
+	}
+	if err := g2.EnableLANDiscovery(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; len(g1.Peers()) == 0 || len(g2.Peers()) == 0; i++ {
+		if i == 50 {
+			t.Fatal("gateways did not discover each other")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if p := g1.Peers()[0]; p.NetAddress.Port() != g2.port {
+		t.Fatal("g1 is connected to the wrong peer:", p.NetAddress)
+	}
+}
+
+// TestLANDiscoveryClose checks that a Gateway with LAN discovery enabled can
+// be closed while it is receiving announcements.
+func TestLANDiscoveryClose(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	g1, err := New(":0", build.TempDir("gateway", "TestLANDiscoveryClose1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g1.Close()
+	g2, err := New(":0", build.TempDir("gateway", "TestLANDiscoveryClose2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.EnableLANDiscovery(); err != nil {
+		t.Skip("multicast is not supported:", err)
+	}
+	if err := g2.EnableLANDiscovery(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * lanDiscoveryInterval)
+
+	closed := make(chan error)
+	go func() {
+		closed <- g2.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Close did not return")
+	}
+}
@@ -0,0 +1,139 @@
+package gateway
+
+// diversity.go makes the Gateway harder to eclipse. An attacker that controls
+// the outbound peers of a node can hide blocks and transactions from it, such
+// as a host's storage proofs. To make this expensive, the peer manager spreads
+// outbound peers across address groups, so that an attacker needs addresses
+// in many subnets, and periodically replaces a few long-lived outbound peers,
+// so that an attacker cannot keep its peers in place once it has them.
+//
+// Address groups are based on subnets: /16 for IPv4 and /32 for IPv6. These
+// roughly correspond to the allocations of individual networks, and unlike
+// ASNs they can be computed without a routing database.
+
+import (
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// maxOutboundPeersPerGroup is the number of outbound peers that the peer
+	// manager connects to in a single address group.
+	maxOutboundPeersPerGroup = 2
+
+	// peerRotationDivisor determines the fraction of outbound peers that are
+	// replaced at each rotation.
+	peerRotationDivisor = 4
+)
+
+var (
+	// peerRotationInterval is how often the peer manager replaces some of
+	// its outbound peers. Only peers that have been connected for at least
+	// this long are replaced.
+	peerRotationInterval = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 10 * time.Minute
+		case "standard":
+			return 1 * time.Hour
+		case "testing":
+			return 5 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// addressGroup returns the address group of a node. Loopback addresses each
+// form their own group, so that local test networks are not restricted.
+// Hostnames, such as .onion addresses, are grouped by host.
+func addressGroup(addr modules.NetAddress) string {
+	if addr.IsLoopback() {
+		return string(addr)
+	}
+	ip := net.ParseIP(addr.Host())
+	if ip == nil {
+		return addr.Host()
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4.Mask(net.CIDRMask(16, 32)).String()
+	}
+	return ip.Mask(net.CIDRMask(32, 128)).String()
+}
+
+// outboundGroups returns the number of outbound peers in each address group.
+func (g *Gateway) outboundGroups() map[string]int {
+	groups := make(map[string]int)
+	for addr, p := range g.peers {
+		if !p.Inbound {
+			groups[addressGroup(addr)]++
+		}
+	}
+	return groups
+}
+
+// randomDiverseNode returns a random node, preferring nodes that the Gateway
+// has connected to successfully in the past. Nodes that are already peers,
+// and nodes in address groups that already hold maxOutboundPeersPerGroup
+// outbound peers, are not selected.
+func (g *Gateway) randomDiverseNode() (modules.NetAddress, error) {
+	groups := g.outboundGroups()
+	return g.randomReliableNode(func(addr modules.NetAddress) bool {
+		_, isPeer := g.peers[addr]
+		return !isPeer && groups[addressGroup(addr)] < maxOutboundPeersPerGroup
+	})
+}
+
+// rotationCandidates returns a random selection of the outbound peers that
+// have been connected for at least peerRotationInterval, containing at most
+// one in peerRotationDivisor of the outbound peers, but at least one peer if
+// any are old enough.
+func (g *Gateway) rotationCandidates() []modules.NetAddress {
+	var old []modules.NetAddress
+	outbound := 0
+	for addr, p := range g.peers {
+		if p.Inbound {
+			continue
+		}
+		outbound++
+		if p.stats != nil && time.Since(p.stats.connectedSince) >= peerRotationInterval {
+			old = append(old, addr)
+		}
+	}
+	n := outbound / peerRotationDivisor
+	if n == 0 {
+		n = 1
+	}
+	if n > len(old) {
+		n = len(old)
+	}
+	perm, _ := crypto.Perm(len(old))
+	candidates := make([]modules.NetAddress, n)
+	for i := range candidates {
+		candidates[i] = old[perm[i]]
+	}
+	return candidates
+}
+
+// managedRotatePeers disconnects some of the long-lived outbound peers, so
+// that the peer manager replaces them with new peers.
+func (g *Gateway) managedRotatePeers() {
+	g.mu.Lock()
+	var rotated []*peer
+	for _, addr := range g.rotationCandidates() {
+		rotated = append(rotated, g.peers[addr])
+		delete(g.peers, addr)
+	}
+	g.mu.Unlock()
+
+	for _, p := range rotated {
+		if err := p.sess.Close(); err != nil {
+			g.log.Debugln("WARN: failed to close the session of rotated peer", p.NetAddress, err)
+		}
+		g.log.Debugln("INFO: rotated out peer", p.NetAddress)
+	}
+}
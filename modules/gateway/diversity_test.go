@@ -0,0 +1,132 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestAddressGroup checks that addresses are grouped by subnet.
+func TestAddressGroup(t *testing.T) {
+	tests := []struct {
+		a, b      modules.NetAddress
+		sameGroup bool
+	}{
+		{"1.2.3.4:9981", "1.2.200.1:9982", true},
+		{"1.2.3.4:9981", "1.3.3.4:9981", false},
+		{"[2001:db8:1::1]:9981", "[2001:db8:2::1]:9981", true},
+		{"[2001:db8::1]:9981", "[2001:db9::1]:9981", false},
+		{"1.2.3.4:9981", "[::ffff:1.2.3.4]:9981", true},
+		{"127.0.0.1:9981", "127.0.0.1:9982", false},
+		{"expyuzz4wqqyqhjn.onion:9981", "expyuzz4wqqyqhjn.onion:9982", true},
+	}
+	for _, test := range tests {
+		if (addressGroup(test.a) == addressGroup(test.b)) != test.sameGroup {
+			t.Errorf("expected sameGroup(%v, %v) == %v", test.a, test.b, test.sameGroup)
+		}
+	}
+}
+
+// TestRandomDiverseNode checks that randomDiverseNode does not select nodes
+// in address groups that already hold maxOutboundPeersPerGroup outbound
+// peers.
+func TestRandomDiverseNode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	g := newTestingGateway("TestRandomDiverseNode", t)
+	defer g.Close()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, err := g.randomDiverseNode(); err != errNoPeers {
+		t.Fatal("randomDiverseNode should fail when the gateway has 0 nodes")
+	}
+
+	crowded := modules.NetAddress("111.111.1.1:9981")
+	diverse := modules.NetAddress("222.222.1.1:9981")
+	for _, addr := range []modules.NetAddress{crowded, diverse} {
+		if err := g.addNode(addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < maxOutboundPeersPerGroup; i++ {
+		addr := modules.NetAddress("111.111.2.1:" + string('1'+byte(i)) + "000")
+		g.peers[addr] = &peer{Peer: modules.Peer{NetAddress: addr}}
+	}
+	for i := 0; i < 100; i++ {
+		addr, err := g.randomDiverseNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr != diverse {
+			t.Fatal("selected a node in a crowded address group:", addr)
+		}
+	}
+
+	// Inbound peers do not count towards the limit.
+	for addr, p := range g.peers {
+		p.Inbound = true
+		g.peers[addr] = p
+	}
+	selected := make(map[modules.NetAddress]bool)
+	for i := 0; i < 100; i++ {
+		addr, err := g.randomDiverseNode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		selected[addr] = true
+	}
+	if !selected[crowded] {
+		t.Fatal("inbound peers counted towards the outbound group limit")
+	}
+}
+
+// TestRotatePeers checks that managedRotatePeers disconnects a fraction of
+// the long-lived outbound peers.
+func TestRotatePeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g := newTestingGateway("TestRotatePeers", t)
+	defer g.Close()
+	var peers []*Gateway
+	for _, name := range []string{"TestRotatePeers1", "TestRotatePeers2"} {
+		p := newTestingGateway(name, t)
+		defer p.Close()
+		if err := g.Connect(p.Address()); err != nil {
+			t.Fatal(err)
+		}
+		peers = append(peers, p)
+	}
+
+	// New peers are not rotated.
+	g.managedRotatePeers()
+	if len(g.Peers()) != 2 {
+		t.Fatal("a new peer was rotated out")
+	}
+
+	// Once a peer is old enough, it is rotated, but the newer peer is not.
+	old := peers[0].Address()
+	g.mu.Lock()
+	g.peers[old].stats.connectedSince = time.Now().Add(-peerRotationInterval)
+	g.mu.Unlock()
+	g.managedRotatePeers()
+	remaining := g.Peers()
+	if len(remaining) != 1 || remaining[0].NetAddress != peers[1].Address() {
+		t.Fatal("expected only the old peer to be rotated out, peers are", remaining)
+	}
+
+	// The rotated peer remains a node, so that it can be reconnected to
+	// later.
+	g.mu.RLock()
+	_, isNode := g.nodes[old]
+	g.mu.RUnlock()
+	if !isNode {
+		t.Fatal("rotated peer was removed from the node list")
+	}
+}
@@ -0,0 +1,91 @@
+package gateway
+
+// dnsseed.go implements bootstrapping from DNS seeds. A DNS seed is a hostname
+// whose A and AAAA records are the addresses of reachable nodes, kept current
+// by the operator of the seed. Unlike the hardcoded bootstrap peers, the seeds
+// do not need a new release when the nodes they point to go away.
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// defaultDNSSeedPort is the port of the nodes returned by a DNS seed
+	// that does not specify a port.
+	defaultDNSSeedPort = "9981"
+
+	// maxDNSSeedNodes is the number of nodes that are taken from a single DNS
+	// seed, so that one seed cannot fill the node list.
+	maxDNSSeedNodes = 32
+)
+
+var (
+	errNoDNSSeedNodes = errors.New("no DNS seed returned any nodes")
+)
+
+// ResolveDNSSeeds looks up each DNS seed and adds the addresses it returns to
+// the node list, from which the Gateway picks its outbound peers. A seed is
+// either a hostname, whose nodes listen on the default port, or a hostname and
+// port. The number of nodes added is returned, along with an error if no seed
+// returned a usable address.
+func (g *Gateway) ResolveDNSSeeds(seeds []string) (int, error) {
+	if err := g.threads.Add(); err != nil {
+		return 0, err
+	}
+	defer g.threads.Done()
+
+	var addrs []modules.NetAddress
+	for _, seed := range seeds {
+		ips, err := g.managedLookupDNSSeed(seed)
+		if err != nil {
+			g.log.Printf("WARN: failed to resolve DNS seed %v: %v", seed, err)
+			continue
+		}
+		addrs = append(addrs, ips...)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	added := 0
+	for _, addr := range addrs {
+		if err := g.addNode(addr); err == nil {
+			added++
+		}
+	}
+	if added > 0 {
+		g.save()
+	}
+	g.log.Printf("INFO: added %v nodes from %v DNS seeds", added, len(seeds))
+	if len(addrs) == 0 {
+		return 0, errNoDNSSeedNodes
+	}
+	return added, nil
+}
+
+// managedLookupDNSSeed returns the node addresses of a DNS seed.
+func (g *Gateway) managedLookupDNSSeed(seed string) ([]modules.NetAddress, error) {
+	host, port := seed, defaultDNSSeedPort
+	if strings.Contains(seed, ":") {
+		var err error
+		host, port, err = net.SplitHostPort(seed)
+		if err != nil {
+			return nil, err
+		}
+	}
+	ips, err := g.deps.lookupHost(host)
+	if err != nil {
+		return nil, err
+	}
+	if len(ips) > maxDNSSeedNodes {
+		ips = ips[:maxDNSSeedNodes]
+	}
+	addrs := make([]modules.NetAddress, len(ips))
+	for i, ip := range ips {
+		addrs[i] = modules.NetAddress(net.JoinHostPort(ip, port))
+	}
+	return addrs, nil
+}
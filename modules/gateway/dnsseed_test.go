@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// dnsSeedDependencies resolves hostnames using a fixed table of DNS seeds.
+type dnsSeedDependencies struct {
+	productionDependencies
+	seeds map[string][]string
+}
+
+func (dd dnsSeedDependencies) lookupHost(host string) ([]string, error) {
+	ips, exists := dd.seeds[host]
+	if !exists {
+		return nil, errors.New("no such host")
+	}
+	return ips, nil
+}
+
+// TestResolveDNSSeeds checks that the addresses returned by DNS seeds are
+// added to the node list.
+func TestResolveDNSSeeds(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	deps := dnsSeedDependencies{seeds: map[string][]string{
+		"seed1.example.com": {"1.2.3.4", "5.6.7.8"},
+		"seed2.example.com": {"2001:db8::1"},
+	}}
+	g, err := newGateway(deps, "localhost:0", build.TempDir("gateway", "TestResolveDNSSeeds"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+
+	if _, err := g.ResolveDNSSeeds([]string{"unknown.example.com"}); err != errNoDNSSeedNodes {
+		t.Fatal("expected errNoDNSSeedNodes, got", err)
+	}
+
+	n, err := g.ResolveDNSSeeds([]string{"seed1.example.com", "seed2.example.com:9000", "unknown.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatal("expected 3 nodes to be added, got", n)
+	}
+	g.mu.RLock()
+	for _, addr := range []modules.NetAddress{"1.2.3.4:9981", "5.6.7.8:9981", "[2001:db8::1]:9000"} {
+		if _, exists := g.nodes[addr]; !exists {
+			t.Error("node list is missing", addr)
+		}
+	}
+	g.mu.RUnlock()
+
+	// Resolving the seeds again adds no new nodes.
+	if n, err := g.ResolveDNSSeeds([]string{"seed1.example.com"}); err != nil || n != 0 {
+		t.Fatal("expected no nodes to be added, got", n, err)
+	}
+}
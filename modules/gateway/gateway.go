@@ -7,8 +7,10 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/persist"
 	siasync "github.com/NebulousLabs/Sia/sync"
@@ -25,6 +27,11 @@ type Gateway struct {
 	myAddr   modules.NetAddress
 	port     string
 
+	// publicAddr is set when the Gateway's address was set explicitly with
+	// SetPublicAddress, in which case it is not overwritten by the
+	// discovered external IP.
+	publicAddr bool
+
 	// handlers are the RPCs that the Gateway can handle.
 	handlers map[rpcID]modules.RPCFunc
 	// initRPCs are the RPCs that the Gateway calls upon connecting to a peer.
@@ -34,14 +41,50 @@ type Gateway struct {
 	peers map[modules.NetAddress]*peer
 
 	// nodes is the set of all known nodes (i.e. potential peers) on the
-	// network.
-	nodes map[modules.NetAddress]struct{}
+	// network, along with their connection history.
+	nodes map[modules.NetAddress]*node
+
+	// misbehavior is the misbehavior score of each host that has been
+	// reported but not yet banned, and bans maps banned hosts to the time
+	// at which their ban expires.
+	misbehavior map[string]int
+	bans        map[string]time.Time
+
+	// networkAlerts are the unexpired network alerts that the Gateway has
+	// received.
+	networkAlerts map[crypto.Hash]receivedAlert
+
+	// maxInboundPeers and maxOutboundPeers are the number of inbound and
+	// outbound peer slots.
+	maxInboundPeers  int
+	maxOutboundPeers int
+
+	// lanDiscovery is true once LAN discovery has been enabled.
+	lanDiscovery bool
+
+	// rpcTimeout is the deadline for the connection handshakes and for each
+	// RPC.
+	rpcTimeout time.Duration
+
+	// compression is true if the Gateway offers to compress the sessions of
+	// new peers.
+	compression bool
+
+	// rateLimit limits the combined bandwidth of all peers, and
+	// peerRateLimit is the number of bytes per second allowed for each peer.
+	rateLimit     *modules.RateLimit
+	peerRateLimit int64
+
+	// blocklist contains the IP ranges that the Gateway refuses to connect
+	// to, or accept connections from.
+	blocklist *modules.Blocklist
 
 	// threads is used to signal the Gateway's goroutines to shut down and to wait
 	// for all goroutines to exit before returning from Close().
 	threads siasync.ThreadGroup
 
 	persistDir string
+	deps       dependencies
 
 	log *persist.Logger
 	mu  sync.RWMutex
@@ -54,6 +97,21 @@ func (g *Gateway) Address() modules.NetAddress {
 	return g.myAddr
 }
 
+// SetPublicAddress sets the address that the Gateway advertises to its peers,
+// instead of the address discovered through UPnP or myexternalip.com. This
+// allows the Gateway to be reached through a Tor onion service that forwards
+// to its listening port.
+func (g *Gateway) SetPublicAddress(addr modules.NetAddress) error {
+	if err := addr.IsValid(); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.myAddr = addr
+	g.publicAddr = true
+	return nil
+}
+
 // Close saves the state of the Gateway and stops its listener process.
 func (g *Gateway) Close() error {
 	if err := g.threads.Stop(); err != nil {
@@ -86,7 +144,12 @@ func (g *Gateway) Close() error {
 }
 
 // New returns an initialized Gateway.
-func New(addr string, persistDir string) (g *Gateway, err error) {
+func New(addr string, persistDir string) (*Gateway, error) {
+	return newGateway(productionDependencies{}, addr, persistDir)
+}
+
+// newGateway creates a Gateway with the given dependencies.
+func newGateway(deps dependencies, addr string, persistDir string) (g *Gateway, err error) {
 	// Create the directory if it doesn't exist.
 	err = os.MkdirAll(persistDir, 0700)
 	if err != nil {
@@ -94,11 +157,21 @@ func New(addr string, persistDir string) (g *Gateway, err error) {
 	}
 
 	g = &Gateway{
-		handlers:   make(map[rpcID]modules.RPCFunc),
-		initRPCs:   make(map[string]modules.RPCFunc),
-		peers:      make(map[modules.NetAddress]*peer),
-		nodes:      make(map[modules.NetAddress]struct{}),
-		persistDir: persistDir,
+		handlers:    make(map[rpcID]modules.RPCFunc),
+		initRPCs:    make(map[string]modules.RPCFunc),
+		peers:       make(map[modules.NetAddress]*peer),
+		nodes:       make(map[modules.NetAddress]*node),
+		misbehavior: make(map[string]int),
+		bans:        make(map[string]time.Time),
+		blocklist:   modules.NewBlocklist(),
+
+		networkAlerts: make(map[crypto.Hash]receivedAlert),
+
+		maxInboundPeers:  defaultMaxInboundPeers,
+		maxOutboundPeers: defaultMaxOutboundPeers,
+		rpcTimeout:       DefaultRPCTimeout,
+		persistDir:       persistDir,
+		deps:             deps,
 	}
 
 	// Create the logger.
@@ -110,12 +183,19 @@ func New(addr string, persistDir string) (g *Gateway, err error) {
 	// Register RPCs.
 	g.RegisterRPC("ShareNodes", g.shareNodes)
 	g.RegisterConnectCall("ShareNodes", g.requestNodes)
+	g.RegisterRPC("Ping", g.rpcPing)
+	g.RegisterRPC("RelayAlert", g.rpcRelayAlert)
+	g.RegisterRPC("ShareAlerts", g.shareAlerts)
+	g.RegisterConnectCall("ShareAlerts", g.requestAlerts)
 
 	// Load the old node list. If it doesn't exist, no problem, but if it does,
 	// we want to know about any errors preventing us from loading it.
 	if loadErr := g.load(); loadErr != nil && !os.IsNotExist(loadErr) {
 		return nil, loadErr
 	}
+	if loadErr := g.loadBans(); loadErr != nil && !os.IsNotExist(loadErr) {
+		return nil, loadErr
+	}
 
 	// Add the bootstrap peers to the node list.
 	if build.Release == "standard" {
@@ -130,7 +210,7 @@ func New(addr string, persistDir string) (g *Gateway, err error) {
 
 	// Create listener and set address.
 	threadedListenClosedChan := make(chan struct{})
-	g.listener, err = net.Listen("tcp", addr)
+	g.listener, err = g.deps.listen(addr)
 	if err != nil {
 		return
 	}
@@ -162,12 +242,38 @@ func New(addr string, persistDir string) (g *Gateway, err error) {
 	// and node lists healthy.
 	go g.threadedPeerManager()
 	go g.threadedNodeManager()
+	// Ping peers to measure their latency and detect dead connections.
+	go g.threadedPingPeers()
+	// Warn the operator if the Gateway stays without peers.
+	go g.threadedMonitorPeers()
 
 	// Spawn the primary listener.
-	go g.threadedListen(threadedListenClosedChan)
+	go g.threadedListen(g.listener, threadedListenClosedChan)
 
 	return
 }
 
+// Listen makes the Gateway accept peer connections on an additional address,
+// such as a second interface or port. Only the port of the Gateway's primary
+// listener is forwarded and advertised to peers.
+func (g *Gateway) Listen(addr string) error {
+	l, err := g.deps.listen(addr)
+	if err != nil {
+		return err
+	}
+	closeChan := make(chan struct{})
+	go g.threadedListen(l, closeChan)
+	// If the Gateway has already been closed, OnStop closes the listener
+	// immediately.
+	g.threads.OnStop(func() {
+		err := l.Close()
+		if err != nil {
+			g.log.Println("WARN: closing the listener failed:", err)
+		}
+		<-closeChan
+	})
+	return nil
+}
+
 // enforce that Gateway satisfies the modules.Gateway interface
 var _ modules.Gateway = (*Gateway)(nil)
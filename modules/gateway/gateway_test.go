@@ -69,6 +69,64 @@ func TestAddress(t *testing.T) {
 	}
 }
 
+// TestSetPublicAddress checks that the address set with SetPublicAddress is
+// advertised as the Gateway's address.
+func TestSetPublicAddress(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	g := newTestingGateway("TestSetPublicAddress", t)
+	defer g.Close()
+	if err := g.SetPublicAddress("garbage"); err == nil {
+		t.Fatal("SetPublicAddress accepted an invalid address")
+	}
+	onion := modules.NetAddress("expyuzz4wqqyqhjn.onion:9981")
+	if err := g.SetPublicAddress(onion); err != nil {
+		t.Fatal(err)
+	}
+	if g.Address() != onion {
+		t.Fatalf("wrong address: expected %v, got %v", onion, g.Address())
+	}
+	if sh := g.ourSessionHeader(); sh.PublicAddress != onion {
+		t.Fatalf("public address was not advertised: expected %v, got %v", onion, sh.PublicAddress)
+	}
+}
+
+// TestAdditionalListener checks that a Gateway accepts peers on the additional
+// addresses added with Listen.
+func TestAdditionalListener(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	g1 := newTestingGateway("TestAdditionalListener1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestAdditionalListener2", t)
+	defer g2.Close()
+
+	// Find a free port for the additional listener.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := modules.NetAddress(l.Addr().String())
+	l.Close()
+	if err := g1.Listen(string(addr)); err != nil {
+		t.Fatal(err)
+	}
+	if g1.Address() == addr {
+		t.Fatal("additional address replaced the primary address")
+	}
+
+	if err := g2.Connect(addr); err != nil {
+		t.Fatal("failed to connect to the additional address:", err)
+	}
+	if peers := g1.Peers(); len(peers) != 1 || !peers[0].Inbound {
+		t.Fatal("g1 did not accept g2 as a peer:", peers)
+	}
+}
+
 // TestPeers checks that two gateways are able to connect to each other.
 func TestPeers(t *testing.T) {
 	if testing.Short() {
@@ -0,0 +1,86 @@
+package gateway
+
+// isolation.go warns the operator when the Gateway has had no peers for a
+// while. A node without peers does not learn of new blocks, so its wallet
+// balance and the contracts of its host and renter stop being up to date.
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// alertIDNoPeers is the ID of the alert that is raised while the Gateway
+	// has no peers.
+	alertIDNoPeers = "gateway.nopeers"
+)
+
+var (
+	// noPeersTimeout is how long the Gateway must be without peers before
+	// an alert is raised.
+	noPeersTimeout = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 2 * time.Minute
+		case "standard":
+			return 10 * time.Minute
+		case "testing":
+			return 3 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// peerCheckInterval is how often the Gateway checks whether it has any
+	// peers.
+	peerCheckInterval = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 10 * time.Second
+		case "standard":
+			return 30 * time.Second
+		case "testing":
+			return 500 * time.Millisecond
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// threadedMonitorPeers raises an alert once the Gateway has been without
+// peers for noPeersTimeout, and clears it when a peer connects.
+func (g *Gateway) threadedMonitorPeers() {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	lastPeer := time.Now()
+	for {
+		select {
+		case <-time.After(peerCheckInterval):
+		case <-g.threads.StopChan():
+			return
+		}
+
+		g.mu.RLock()
+		numPeers := len(g.peers)
+		g.mu.RUnlock()
+		if numPeers > 0 {
+			lastPeer = time.Now()
+			modules.ClearAlert(alertIDNoPeers)
+			continue
+		}
+		if time.Since(lastPeer) >= noPeersTimeout {
+			modules.RaiseAlert(modules.Alert{
+				ID:       alertIDNoPeers,
+				Module:   "gateway",
+				Severity: modules.SeverityWarning,
+				Msg:      fmt.Sprintf("no peers have been connected since %v", lastPeer.Format(time.RFC3339)),
+			})
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package gateway
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// alertRecorder records the IDs of the alerts that are raised and cleared.
+type alertRecorder struct {
+	raised  map[string]bool
+	cleared map[string]bool
+	mu      sync.Mutex
+}
+
+// ReceiveAlert records an alert that was raised or cleared.
+func (ar *alertRecorder) ReceiveAlert(a modules.Alert, active bool) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	if active {
+		ar.raised[a.ID] = true
+	} else {
+		ar.cleared[a.ID] = true
+	}
+}
+
+// TestNoPeersAlert checks that an alert is raised when the gateway has no
+// peers for noPeersTimeout, and cleared when a peer connects.
+func TestNoPeersAlert(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ar := &alertRecorder{raised: make(map[string]bool), cleared: make(map[string]bool)}
+	modules.AlertSubscribe(ar)
+	defer modules.AlertUnsubscribe(ar)
+
+	g1 := newTestingGateway("TestNoPeersAlert1", t)
+	defer g1.Close()
+	time.Sleep(noPeersTimeout + 2*peerCheckInterval)
+	ar.mu.Lock()
+	raised := ar.raised[alertIDNoPeers]
+	ar.mu.Unlock()
+	if !raised {
+		t.Fatal("no alert was raised for a gateway without peers")
+	}
+
+	g2 := newTestingGateway("TestNoPeersAlert2", t)
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * peerCheckInterval)
+	ar.mu.Lock()
+	cleared := ar.cleared[alertIDNoPeers]
+	ar.mu.Unlock()
+	if !cleared {
+		t.Fatal("alert was not cleared when a peer connected")
+	}
+}
@@ -0,0 +1,189 @@
+package gateway
+
+// networkalerts.go relays network alerts, which are signed messages from the
+// developers. Valid alerts are relayed to every peer, and are shared with new
+// peers when they connect, so that alerts reach nodes that were offline when
+// the alert was broadcast. Alerts expire, and are discarded once they do.
+// Network alerts are also raised in the alert registry while they are
+// unexpired.
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// maxNetworkAlerts is the number of unexpired network alerts that the
+	// Gateway stores and shares. Further alerts are rejected until some
+	// expire.
+	maxNetworkAlerts = 20
+)
+
+var (
+	errNetworkAlertKnown = errors.New("network alert has already been received")
+	errTooManyAlerts     = errors.New("too many network alerts are active")
+)
+
+// A receivedAlert is a network alert along with the time at which the Gateway
+// received it.
+type receivedAlert struct {
+	modules.NetworkAlert
+	received time.Time
+}
+
+// alertsByTime sorts network alerts by the time at which they were received.
+type alertsByTime []receivedAlert
+
+func (a alertsByTime) Len() int           { return len(a) }
+func (a alertsByTime) Less(i, j int) bool { return a[i].received.Before(a[j].received) }
+func (a alertsByTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// networkAlertID returns the ID with which the network alert with the given
+// hash is raised in the alert registry.
+func networkAlertID(id crypto.Hash) string {
+	return "gateway.networkalert." + id.String()
+}
+
+// pruneNetworkAlerts removes the expired network alerts.
+func (g *Gateway) pruneNetworkAlerts() {
+	for id, ra := range g.networkAlerts {
+		if modules.VerifyNetworkAlert(ra.NetworkAlert) == modules.ErrNetworkAlertExpired {
+			delete(g.networkAlerts, id)
+			// The operator may already have cleared the alert.
+			modules.ClearAlert(networkAlertID(id))
+		}
+	}
+}
+
+// addNetworkAlert verifies a network alert and adds it to the set of alerts.
+func (g *Gateway) addNetworkAlert(na modules.NetworkAlert) error {
+	id := na.ID()
+	if _, exists := g.networkAlerts[id]; exists {
+		return errNetworkAlertKnown
+	}
+	if err := modules.VerifyNetworkAlert(na); err != nil {
+		return err
+	}
+	g.pruneNetworkAlerts()
+	if len(g.networkAlerts) >= maxNetworkAlerts {
+		return errTooManyAlerts
+	}
+	ra := receivedAlert{na, time.Now()}
+	g.networkAlerts[id] = ra
+	g.log.Printf("ALERT: network alert (%v): %v", na.Severity, na.Message)
+	modules.RaiseAlert(ra.alert(id))
+	return nil
+}
+
+// networkAlertList returns the unexpired network alerts, sorted by the time at
+// which they were received.
+func (g *Gateway) networkAlertList() []receivedAlert {
+	g.pruneNetworkAlerts()
+	alerts := make([]receivedAlert, 0, len(g.networkAlerts))
+	for _, ra := range g.networkAlerts {
+		alerts = append(alerts, ra)
+	}
+	sort.Sort(alertsByTime(alerts))
+	return alerts
+}
+
+// alert returns the modules.Alert that reports the network alert with the
+// given hash.
+func (ra receivedAlert) alert(id crypto.Hash) modules.Alert {
+	return modules.Alert{
+		ID:       networkAlertID(id),
+		Module:   "gateway",
+		Severity: ra.Severity,
+		Msg:      ra.Message,
+		Time:     ra.received,
+	}
+}
+
+// Alerts returns the unexpired network alerts that the Gateway has received,
+// oldest first.
+func (g *Gateway) Alerts() []modules.Alert {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var alerts []modules.Alert
+	for _, ra := range g.networkAlertList() {
+		alerts = append(alerts, ra.alert(ra.ID()))
+	}
+	return alerts
+}
+
+// BroadcastNetworkAlert verifies a network alert and relays it to all peers.
+func (g *Gateway) BroadcastNetworkAlert(na modules.NetworkAlert) error {
+	if err := g.threads.Add(); err != nil {
+		return err
+	}
+	defer g.threads.Done()
+
+	g.mu.Lock()
+	err := g.addNetworkAlert(na)
+	g.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	go g.Broadcast("RelayAlert", na, g.Peers())
+	return nil
+}
+
+// rpcRelayAlert is an RPC that receives a network alert from a peer. New
+// alerts are relayed to the other peers.
+func (g *Gateway) rpcRelayAlert(conn modules.PeerConn) error {
+	var na modules.NetworkAlert
+	if err := encoding.ReadObject(conn, &na, modules.MaxNetworkAlertSize); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	err := g.addNetworkAlert(na)
+	g.mu.Unlock()
+	if err == errNetworkAlertKnown {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var peers []modules.Peer
+	for _, p := range g.Peers() {
+		if p.NetAddress != conn.RPCAddr() {
+			peers = append(peers, p)
+		}
+	}
+	go g.Broadcast("RelayAlert", na, peers)
+	return nil
+}
+
+// shareAlerts is the receiving end of the ShareAlerts RPC. It writes the
+// unexpired network alerts to the caller.
+func (g *Gateway) shareAlerts(conn modules.PeerConn) error {
+	g.mu.Lock()
+	var alerts []modules.NetworkAlert
+	for _, ra := range g.networkAlertList() {
+		alerts = append(alerts, ra.NetworkAlert)
+	}
+	g.mu.Unlock()
+	return encoding.WriteObject(conn, alerts)
+}
+
+// requestAlerts is the calling end of the ShareAlerts RPC.
+func (g *Gateway) requestAlerts(conn modules.PeerConn) error {
+	var alerts []modules.NetworkAlert
+	if err := encoding.ReadObject(conn, &alerts, maxNetworkAlerts*modules.MaxNetworkAlertSize); err != nil {
+		return err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, na := range alerts {
+		err := g.addNetworkAlert(na)
+		if err != nil && err != errNetworkAlertKnown {
+			g.log.Debugf("WARN: peer %v shared an invalid network alert: %v", conn.RPCAddr(), err)
+		}
+	}
+	return nil
+}
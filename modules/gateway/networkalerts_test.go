@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// signedNetworkAlert returns a network alert signed with the testing key.
+func signedNetworkAlert(msg string, t *testing.T) modules.NetworkAlert {
+	na, err := modules.SignNetworkAlert(modules.NetworkAlert{
+		Severity: modules.SeverityCritical,
+		Message:  msg,
+		Expiry:   types.CurrentTimestamp() + 3600,
+	}, modules.NetworkAlertTestingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return na
+}
+
+// waitForAlerts waits until the Gateway has received n network alerts.
+func waitForAlerts(g *Gateway, n int, t *testing.T) []modules.Alert {
+	for i := 0; i < 50; i++ {
+		if alerts := g.Alerts(); len(alerts) == n {
+			return alerts
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("expected %v alerts, got %v", n, len(g.Alerts()))
+	return nil
+}
+
+// TestBroadcastNetworkAlert checks that network alerts are relayed across
+// peers, and that invalid alerts are rejected.
+func TestBroadcastNetworkAlert(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newTestingGateway("TestBroadcastNetworkAlert1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestBroadcastNetworkAlert2", t)
+	defer g2.Close()
+	g3 := newTestingGateway("TestBroadcastNetworkAlert3", t)
+	defer g3.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.Connect(g3.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// An alert with a bad signature is rejected.
+	na := signedNetworkAlert("upgrade required", t)
+	forged := na
+	forged.Message = "downgrade required"
+	if err := g1.BroadcastNetworkAlert(forged); err != crypto.ErrInvalidSignature {
+		t.Fatal("expected ErrInvalidSignature, got", err)
+	}
+
+	// An expired alert is rejected.
+	expired, err := modules.SignNetworkAlert(modules.NetworkAlert{
+		Severity: modules.SeverityInfo,
+		Message:  "old news",
+		Expiry:   types.CurrentTimestamp() - 1,
+	}, modules.NetworkAlertTestingKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.BroadcastNetworkAlert(expired); err != modules.ErrNetworkAlertExpired {
+		t.Fatal("expected ErrNetworkAlertExpired, got", err)
+	}
+
+	// A valid alert reaches g3 through g2.
+	if err := g1.BroadcastNetworkAlert(na); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.BroadcastNetworkAlert(na); err != errNetworkAlertKnown {
+		t.Fatal("expected errNetworkAlertKnown, got", err)
+	}
+	alerts := waitForAlerts(g3, 1, t)
+	if alerts[0].Msg != na.Message || alerts[0].Severity != na.Severity || alerts[0].Module != "gateway" {
+		t.Fatal("wrong alert:", alerts[0])
+	}
+
+	// The alert is raised in the alert registry.
+	id := networkAlertID(na.ID())
+	defer modules.ClearAlert(id)
+	var raised bool
+	for _, a := range modules.ActiveAlerts() {
+		raised = raised || a.ID == id
+	}
+	if !raised {
+		t.Fatal("network alert was not raised in the alert registry")
+	}
+}
+
+// TestShareAlerts checks that network alerts are shared with new peers.
+func TestShareAlerts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newTestingGateway("TestShareAlerts1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestShareAlerts2", t)
+	defer g2.Close()
+
+	if err := g1.BroadcastNetworkAlert(signedNetworkAlert("first", t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.BroadcastNetworkAlert(signedNetworkAlert("second", t)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.Connect(g1.Address()); err != nil {
+		t.Fatal(err)
+	}
+	waitForAlerts(g2, 2, t)
+}
@@ -13,6 +13,17 @@ import (
 const (
 	maxSharedNodes = 10
 	minPeers       = 3
+
+	// maxNodeWeight is the largest weight that randomReliableNode gives to a
+	// node, so that a handful of long-lived nodes cannot crowd out the rest
+	// of the node list.
+	maxNodeWeight = 10
+
+	// maxNodeFailures is the number of consecutive failed dials after which
+	// a node that has been connected to before is removed from the node
+	// list. Nodes that have never been connected to are removed after their
+	// first failure.
+	maxNodeFailures = 3
 )
 
 var (
@@ -20,7 +31,36 @@ var (
 	errOurAddress = errors.New("can't add our own address")
 )
 
-// addNode adds an address to the set of nodes on the network.
+// A node is a potential peer, along with a record of how reliably the Gateway
+// has been able to connect to it. The record is persisted, which allows the
+// Gateway to prefer historically reliable nodes after a restart.
+type node struct {
+	NetAddress modules.NetAddress
+
+	// LastSeen is the last time that the Gateway connected to the node.
+	LastSeen time.Time
+
+	// Successes is the number of successful connections to the node, and
+	// Failures is the number of failed dials since the last success.
+	Successes uint64
+	Failures  uint64
+}
+
+// weight returns the relative likelihood that the node is selected by
+// randomReliableNode.
+func (n *node) weight() int {
+	if n.Failures > 0 {
+		return 1
+	}
+	if n.Successes >= maxNodeWeight-1 {
+		return maxNodeWeight
+	}
+	return 1 + int(n.Successes)
+}
+
+// addNode adds an address to the set of nodes on the network. Nodes are
+// identified by their IP address, except when connecting through a proxy,
+// which resolves hostnames such as .onion addresses itself.
 func (g *Gateway) addNode(addr modules.NetAddress) error {
 	if addr == g.myAddr {
 		return errOurAddress
@@ -28,13 +68,35 @@ func (g *Gateway) addNode(addr modules.NetAddress) error {
 		return errNodeExists
 	} else if addr.IsValid() != nil {
 		return errors.New("address is not valid: " + string(addr))
-	} else if net.ParseIP(addr.Host()) == nil {
+	} else if net.ParseIP(addr.Host()) == nil && modules.Proxy() == "" {
 		return errors.New("address must be an IP address: " + string(addr))
 	}
-	g.nodes[addr] = struct{}{}
+	g.nodes[addr] = &node{NetAddress: addr}
 	return nil
 }
 
+// markNodeSeen records a successful connection to a node.
+func (g *Gateway) markNodeSeen(addr modules.NetAddress) {
+	n, exists := g.nodes[addr]
+	if !exists {
+		return
+	}
+	n.LastSeen = time.Now()
+	n.Successes++
+	n.Failures = 0
+}
+
+// markNodeFailed records a failed dial to a node. A bool is returned
+// indicating whether the node should be removed from the node list.
+func (g *Gateway) markNodeFailed(addr modules.NetAddress) bool {
+	n, exists := g.nodes[addr]
+	if !exists {
+		return false
+	}
+	n.Failures++
+	return n.Successes == 0 || n.Failures >= maxNodeFailures
+}
+
 func (g *Gateway) removeNode(addr modules.NetAddress) error {
 	if _, exists := g.nodes[addr]; !exists {
 		return errors.New("no record of that node")
@@ -57,18 +119,57 @@ func (g *Gateway) randomNode() (modules.NetAddress, error) {
 	return "", errNoPeers
 }
 
+// randomReliableNode returns a random node for which 'eligible' returns true,
+// preferring nodes that the Gateway has connected to successfully in the past.
+// A nil 'eligible' allows every node.
+func (g *Gateway) randomReliableNode(eligible func(modules.NetAddress) bool) (modules.NetAddress, error) {
+	totalWeight := 0
+	for addr, n := range g.nodes {
+		if eligible == nil || eligible(addr) {
+			totalWeight += n.weight()
+		}
+	}
+	if totalWeight > 0 {
+		r, _ := crypto.RandIntn(totalWeight)
+		for addr, n := range g.nodes {
+			if eligible != nil && !eligible(addr) {
+				continue
+			}
+			r -= n.weight()
+			if r < 0 {
+				return addr, nil
+			}
+		}
+	}
+
+	return "", errNoPeers
+}
+
 // shareNodes is the receiving end of the ShareNodes RPC. It writes up to 10
-// randomly selected nodes to the caller.
+// randomly selected nodes to the caller. Nodes that the Gateway is currently
+// failing to connect to are not shared, so that unreachable addresses do not
+// spread through the network.
 func (g *Gateway) shareNodes(conn modules.PeerConn) error {
 	g.mu.RLock()
+	var candidates []modules.NetAddress
+	for addr, n := range g.nodes {
+		if n.Failures == 0 {
+			candidates = append(candidates, addr)
+		}
+	}
+	g.mu.RUnlock()
+
+	perm, err := crypto.Perm(len(candidates))
+	if err != nil {
+		return err
+	}
 	var nodes []modules.NetAddress
-	for node := range g.nodes {
+	for _, i := range perm {
 		if len(nodes) == maxSharedNodes {
 			break
 		}
-		nodes = append(nodes, node)
+		nodes = append(nodes, candidates[i])
 	}
-	g.mu.RUnlock()
 	return encoding.WriteObject(conn, nodes)
 }
 
@@ -133,15 +234,24 @@ func (g *Gateway) threadedNodeManager() {
 		}
 
 		// try to connect
-		conn, err := net.DialTimeout("tcp", string(node), dialTimeout)
+		conn, err := g.deps.dial(node, dialTimeout)
 		if err != nil {
 			g.mu.Lock()
-			g.removeNode(node)
+			remove := g.markNodeFailed(node)
+			if remove {
+				g.removeNode(node)
+			}
 			g.save()
 			g.mu.Unlock()
-			g.log.Debugf("INFO: removing node %q because dialing it failed: %v", node, err)
+			if remove {
+				g.log.Debugf("INFO: removing node %q because dialing it failed: %v", node, err)
+			}
 			continue
 		}
+		g.mu.Lock()
+		g.markNodeSeen(node)
+		g.save()
+		g.mu.Unlock()
 		// if connection succeeds, supply an unacceptable version to ensure
 		// they won't try to add us as a peer
 		encoding.WriteObject(conn, "0.0.0")
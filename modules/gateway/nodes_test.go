@@ -38,6 +38,16 @@ func TestAddNode(t *testing.T) {
 	if err := g.addNode(g.myAddr); err != errOurAddress {
 		t.Error("addNode added our own address")
 	}
+
+	// Hostnames, such as onion addresses, are resolved by the proxy, so they
+	// are accepted when connecting through one.
+	if err := modules.SetProxy("127.0.0.1:9050"); err != nil {
+		t.Fatal(err)
+	}
+	defer modules.SetProxy("")
+	if err := g.addNode("expyuzz4wqqyqhjn.onion:9981"); err != nil {
+		t.Error("addNode rejected an onion address when using a proxy:", err)
+	}
 }
 
 func TestRemoveNode(t *testing.T) {
@@ -136,6 +146,89 @@ func TestRandomNode(t *testing.T) {
 	}
 }
 
+// TestRandomReliableNode checks that randomReliableNode prefers nodes that
+// have been connected to successfully, and avoids nodes that are failing.
+func TestRandomReliableNode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	g := newTestingGateway("TestRandomReliableNode", t)
+	defer g.Close()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, err := g.randomReliableNode(nil); err != errNoPeers {
+		t.Fatal("randomReliableNode should fail when the gateway has 0 nodes")
+	}
+
+	reliable := modules.NetAddress("111.111.111.111:1111")
+	unknown := modules.NetAddress("111.111.111.111:2222")
+	failing := modules.NetAddress("111.111.111.111:3333")
+	for _, addr := range []modules.NetAddress{reliable, unknown, failing} {
+		if err := g.addNode(addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < maxNodeWeight; i++ {
+		g.markNodeSeen(reliable)
+		g.markNodeSeen(failing)
+	}
+	if g.markNodeFailed(failing) {
+		t.Fatal("reliable node should not be removed after a single failure")
+	}
+
+	counts := make(map[modules.NetAddress]int)
+	for i := 0; i < 1200; i++ {
+		addr, err := g.randomReliableNode(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[addr]++
+	}
+	if counts[reliable] < 4*counts[unknown] || counts[reliable] < 4*counts[failing] {
+		t.Fatal("reliable node was not preferred:", counts)
+	}
+	if counts[unknown] == 0 || counts[failing] == 0 {
+		t.Fatal("unreliable nodes were never selected:", counts)
+	}
+}
+
+// TestMarkNodeFailed checks when failing nodes are removed.
+func TestMarkNodeFailed(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	g := newTestingGateway("TestMarkNodeFailed", t)
+	defer g.Close()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.addNode(dummyNode); err != nil {
+		t.Fatal(err)
+	}
+	if !g.markNodeFailed(dummyNode) {
+		t.Fatal("node that was never seen should be removed after a failure")
+	}
+
+	g.markNodeSeen(dummyNode)
+	for i := 1; i < maxNodeFailures; i++ {
+		if g.markNodeFailed(dummyNode) {
+			t.Fatal("seen node was removed after", i, "failures")
+		}
+	}
+	if !g.markNodeFailed(dummyNode) {
+		t.Fatal("seen node was not removed after", maxNodeFailures, "failures")
+	}
+
+	// A success resets the failures.
+	g.markNodeSeen(dummyNode)
+	if g.nodes[dummyNode].Failures != 0 || g.nodes[dummyNode].Successes != 2 {
+		t.Fatal("node history was not updated:", g.nodes[dummyNode])
+	}
+}
+
 func TestShareNodes(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -170,10 +263,10 @@ func TestShareNodes(t *testing.T) {
 
 	// remove all nodes from both peers
 	g1.mu.Lock()
-	g1.nodes = map[modules.NetAddress]struct{}{}
+	g1.nodes = map[modules.NetAddress]*node{}
 	g1.mu.Unlock()
 	g2.mu.Lock()
-	g2.nodes = map[modules.NetAddress]struct{}{}
+	g2.nodes = map[modules.NetAddress]*node{}
 	g2.mu.Unlock()
 
 	// SharePeers should now return no peers
@@ -206,6 +299,23 @@ func TestShareNodes(t *testing.T) {
 	if len(nodes) != maxSharedNodes {
 		t.Fatalf("gateway gave wrong number of nodes: expected %v, got %v", maxSharedNodes, len(nodes))
 	}
+
+	// nodes that are failing should not be shared
+	g2.mu.Lock()
+	for addr := range g2.nodes {
+		g2.markNodeFailed(addr)
+	}
+	g2.mu.Unlock()
+	nodes = nil
+	err = g1.RPC(g2.Address(), "ShareNodes", func(conn modules.PeerConn) error {
+		return encoding.ReadObject(conn, &nodes, maxSharedNodes*modules.MaxEncodedNetAddressLength)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 0 {
+		t.Fatal("gateway shared failing nodes:", nodes)
+	}
 }
 
 // TestNodesAreSharedOnConnect tests that nodes that a gateway has never seen
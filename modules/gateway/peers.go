@@ -1,21 +1,33 @@
 package gateway
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 	"github.com/NebulousLabs/muxado"
 )
 
 const (
-	// the gateway will not accept inbound connections above this threshold
-	fullyConnectedThreshold = 128
+	// defaultMaxInboundPeers is the default number of inbound peers that the
+	// gateway keeps. Once it is reached, new inbound peers replace existing
+	// inbound peers.
+	defaultMaxInboundPeers = 120
+
+	// defaultMaxOutboundPeers is the default number of outbound peers that the
+	// peer manager connects to.
+	defaultMaxOutboundPeers = modules.WellConnectedThreshold
 
 	// the gateway will ask for more addresses below this threshold
 	minNodeListLen = 100
@@ -26,6 +38,14 @@ const (
 	// Reject peers < v0.4.0 as the previous version is v0.3.3 which is
 	// pre-hardfork.
 	minAcceptableVersion = "0.4.0"
+
+	// sessionHeaderVersion is the version at which peers began exchanging a
+	// sessionHeader after the port handshake.
+	sessionHeaderVersion = "1.0.1"
+
+	// maxEncodedSessionHeaderSize is the maximum size of an encoded
+	// sessionHeader.
+	maxEncodedSessionHeaderSize = 4e3
 )
 
 var (
@@ -56,7 +76,27 @@ var (
 		}
 	}()
 
+	// DefaultRPCTimeout is the default deadline for the connection handshakes
+	// and for each RPC. RPCs that are expected to take longer extend the
+	// deadline themselves.
+	DefaultRPCTimeout = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 1 * time.Minute
+		case "standard":
+			return 2 * time.Minute
+		case "testing":
+			return 30 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
 	errPeerRejectedConn = errors.New("peer rejected connection")
+	errPeerGenesisID    = errors.New("peer has a different genesis block, and is on a different network")
+
+	errNegativePeerLimit  = errors.New("peer limits cannot be negative")
+	errNonPositiveTimeout = errors.New("RPC timeout must be positive")
 )
 
 // insufficientVersionError indicates a peer's version is insufficient.
@@ -75,9 +115,79 @@ func (s invalidVersionError) Error() string {
 	return "invalid version: " + string(s)
 }
 
+// A sessionHeader is exchanged by peers >= v1.0.1 when a connection is
+// established. The GenesisID identifies the network that the peer is on, and
+// RPCs lists the RPCs that the peer could handle when the connection was
+// established. Modules register their RPCs as they start up, so the list is
+// only advisory and RPCs that are missing from it may still be called.
+// Compression is true if the peer offers to compress the session; the session
+// is compressed if both peers offer it. ProtocolVersion and GitRevision
+// identify the build of the peer, to help diagnose incompatibilities.
+// PublicAddress is the address that the peer was configured to be reached on,
+// such as an onion address, if it differs from the address it connects from.
+type sessionHeader struct {
+	GenesisID       types.BlockID
+	RPCs            []rpcID
+	Compression     bool
+	ProtocolVersion string
+	GitRevision     string
+	PublicAddress   modules.NetAddress
+}
+
+// MarshalSia implements the encoding.SiaMarshaler interface.
+func (sh sessionHeader) MarshalSia(w io.Writer) error {
+	return encoding.NewEncoder(w).EncodeAll(sh.GenesisID, sh.RPCs, sh.Compression, sh.ProtocolVersion, sh.GitRevision, sh.PublicAddress)
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface. Compression,
+// ProtocolVersion, GitRevision and PublicAddress were added to the
+// sessionHeader after it was introduced, so headers that end before them are
+// decoded as not offering compression, with an unknown build, and without a
+// public address.
+func (sh *sessionHeader) UnmarshalSia(r io.Reader) error {
+	err := encoding.NewDecoder(r).DecodeAll(&sh.GenesisID, &sh.RPCs)
+	if err != nil {
+		return err
+	}
+	var b [1]byte
+	_, err = io.ReadFull(r, b[:])
+	if err == io.EOF {
+		sh.Compression = false
+		return nil
+	} else if err != nil {
+		return err
+	}
+	sh.Compression = b[0] == 1
+
+	rest, err := ioutil.ReadAll(r)
+	if err != nil || len(rest) == 0 {
+		return err
+	}
+	restReader := bytes.NewReader(rest)
+	dec := encoding.NewDecoder(restReader)
+	err = dec.DecodeAll(&sh.ProtocolVersion, &sh.GitRevision)
+	if err != nil || restReader.Len() == 0 {
+		return err
+	}
+	return dec.Decode(&sh.PublicAddress)
+}
+
+// rpcNames returns the names of the RPCs advertised in the sessionHeader,
+// sorted alphabetically.
+func (sh sessionHeader) rpcNames() []string {
+	rpcs := make([]string, 0, len(sh.RPCs))
+	for _, id := range sh.RPCs {
+		rpcs = append(rpcs, strings.TrimRight(id.String(), " "))
+	}
+	sort.Strings(rpcs)
+	return rpcs
+}
+
 type peer struct {
 	modules.Peer
-	sess muxado.Session
+	sess   muxado.Session
+	stats  *peerStats
+	relays *relayQueue
 }
 
 func (p *peer) open() (modules.PeerConn, error) {
@@ -99,6 +209,9 @@ func (p *peer) accept() (modules.PeerConn, error) {
 // addPeer adds a peer to the Gateway's peer list and spawns a listener thread
 // to handle its requests.
 func (g *Gateway) addPeer(p *peer) {
+	if p.relays == nil {
+		p.relays = newRelayQueue()
+	}
 	g.peers[p.NetAddress] = p
 	go g.threadedListenPeer(p)
 }
@@ -139,12 +252,12 @@ func (g *Gateway) randomInboundPeer() (modules.NetAddress, error) {
 
 // threadedListen handles incoming connection requests. If the connection is
 // accepted, the peer will be added to the Gateway's peer list.
-func (g *Gateway) threadedListen(closeChan chan struct{}) {
+func (g *Gateway) threadedListen(l net.Listener, closeChan chan struct{}) {
 	// Signal that the threadedListen thread has completed upon returning.
 	defer close(closeChan)
 
 	for {
-		conn, err := g.listener.Accept()
+		conn, err := l.Accept()
 		if err != nil {
 			return
 		}
@@ -173,6 +286,31 @@ func (g *Gateway) threadedAcceptConn(conn net.Conn) {
 	addr := modules.NetAddress(conn.RemoteAddr().String())
 	g.log.Debugf("INFO: %v wants to connect", addr)
 
+	g.mu.RLock()
+	banned := g.isBanned(addr.Host())
+	noInbound := g.maxInboundPeers == 0
+	blocklist := g.blocklist
+	g.mu.RUnlock()
+	if banned {
+		g.log.Debugf("INFO: rejected connection from banned peer %v", addr)
+		conn.Close()
+		return
+	}
+	if blocklist.IsBlocked(addr) {
+		g.log.Debugf("INFO: rejected connection from blocked peer %v", addr)
+		conn.Close()
+		return
+	}
+	if noInbound {
+		g.log.Debugf("INFO: rejected connection from %v, as inbound peers are disabled", addr)
+		conn.Close()
+		return
+	}
+
+	g.mu.RLock()
+	timeout := g.rpcTimeout
+	g.mu.RUnlock()
+	conn.SetDeadline(time.Now().Add(timeout))
 	remoteVersion, err := acceptConnVersionHandshake(conn, build.Version)
 	if err != nil {
 		g.log.Debugf("INFO: %v wanted to connect but version handshake failed: %v", addr, err)
@@ -204,13 +342,18 @@ func (g *Gateway) managedAcceptConnOldPeer(conn net.Conn, remoteVersion string)
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	// The handshake is complete, so the deadline that limited it is removed
+	// before the connection is used for the peer's session.
+	conn.SetDeadline(time.Time{})
+	stats, sessConn := newPeerStats(g.limitConn(conn))
 	g.acceptPeer(&peer{
 		Peer: modules.Peer{
 			NetAddress: addr,
 			Inbound:    true,
 			Version:    remoteVersion,
 		},
-		sess: muxado.Server(conn),
+		sess:  muxado.Server(sessConn),
+		stats: stats,
 	})
 
 	return nil
@@ -226,6 +369,18 @@ func (g *Gateway) managedAcceptConnNewPeer(conn net.Conn, remoteVersion string)
 	if err != nil {
 		return err
 	}
+	var theirs sessionHeader
+	var rpcs []string
+	var compress bool
+	if build.VersionCmp(remoteVersion, sessionHeaderVersion) >= 0 {
+		ours := g.ourSessionHeader()
+		theirs, err = acceptConnSessionHeaderHandshake(conn, ours)
+		if err != nil {
+			return err
+		}
+		rpcs = theirs.rpcNames()
+		compress = ours.Compression && theirs.Compression
+	}
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -239,53 +394,105 @@ func (g *Gateway) managedAcceptConnNewPeer(conn net.Conn, remoteVersion string)
 	if err != nil && err != errNodeExists {
 		return fmt.Errorf("error adding node %q: %v", remoteAddr, err)
 	}
+	g.markNodeSeen(remoteAddr)
+	// A peer that is reached through a public address, such as an onion
+	// service, connects from an address that others cannot dial. Its public
+	// address is added as a node, so that it is shared with other peers.
+	if theirs.PublicAddress != "" && theirs.PublicAddress != remoteAddr {
+		err = g.addNode(theirs.PublicAddress)
+		if err == nil {
+			g.log.Debugf("INFO: peer %v announced its public address %v", remoteAddr, theirs.PublicAddress)
+		} else if err != errNodeExists {
+			g.log.Debugf("WARN: peer %v announced an unusable public address %v: %v", remoteAddr, theirs.PublicAddress, err)
+		}
+	}
 	err = g.save()
 	if err != nil {
 		return fmt.Errorf("error saving node list: %v", err)
 	}
 
+	// The handshake is complete, so the deadline that limited it is removed
+	// before the connection is used for the peer's session.
+	conn.SetDeadline(time.Time{})
+	stats, sessConn := newPeerStats(g.limitConn(conn))
+	if compress {
+		sessConn = newCompressedConn(sessConn)
+	}
 	g.acceptPeer(&peer{
 		Peer: modules.Peer{
 			NetAddress: remoteAddr,
 			Inbound:    true,
 			Version:    remoteVersion,
+			RPCs:       rpcs,
+
+			ProtocolVersion: theirs.ProtocolVersion,
+			GitRevision:     theirs.GitRevision,
 		},
-		sess: muxado.Server(conn),
+		sess:  muxado.Server(sessConn),
+		stats: stats,
 	})
 
 	return nil
 }
 
 // acceptPeer makes room for the peer if necessary by kicking out existing
-// peers, then adds the peer to the peer list.
+// inbound peers, then adds the peer to the peer list. Outbound peers are never
+// kicked out, so inbound connections cannot push out the peers that the
+// Gateway chose for itself.
 func (g *Gateway) acceptPeer(p *peer) {
-	// If we are already fully connected, kick out an old peer to make room
+	// If the inbound slots are full, kick out an inbound peer to make room
 	// for the new one. Importantly, prioritize kicking a peer with the same
 	// IP as the connecting peer. This protects against Sybil attacks.
-	if len(g.peers) >= fullyConnectedThreshold {
-		// first choose a random peer, preferably inbound. If have only
-		// outbound peers, we'll wind up kicking an outbound peer; but
-		// subsequent inbound connections will kick each other instead of
-		// continuing to replace outbound peers.
+	if g.numInboundPeers() >= g.maxInboundPeers {
 		kick, err := g.randomInboundPeer()
-		if err != nil {
-			kick, _ = g.randomPeer()
-		}
-		// if another peer shares this IP, choose that one instead
-		for addr := range g.peers {
-			if addr.Host() == p.NetAddress.Host() {
-				kick = addr
+		for addr, peer := range g.peers {
+			if peer.Inbound && addr.Host() == p.NetAddress.Host() {
+				kick, err = addr, nil
 				break
 			}
 		}
-		g.peers[kick].sess.Close()
-		delete(g.peers, kick)
-		g.log.Printf("INFO: disconnected from %v to make room for %v", kick, p.NetAddress)
+		if err == nil {
+			g.peers[kick].sess.Close()
+			delete(g.peers, kick)
+			g.log.Printf("INFO: disconnected from %v to make room for %v", kick, p.NetAddress)
+		}
 	}
 
 	g.addPeer(p)
 }
 
+// numInboundPeers returns the number of peers that initiated their
+// connection.
+func (g *Gateway) numInboundPeers() (n int) {
+	for _, p := range g.peers {
+		if p.Inbound {
+			n++
+		}
+	}
+	return n
+}
+
+// numOutboundPeers returns the number of peers that the Gateway connected to.
+func (g *Gateway) numOutboundPeers() int {
+	return len(g.peers) - g.numInboundPeers()
+}
+
+// SetPeerLimits sets the maximum number of inbound and outbound peers. Once
+// the inbound limit is reached, new inbound peers replace existing inbound
+// peers; an inbound limit of zero rejects all inbound connections. The peer
+// manager makes outbound connections until the outbound limit is reached.
+// Peers that are connected manually with Connect are not limited.
+func (g *Gateway) SetPeerLimits(maxInbound, maxOutbound int) error {
+	if maxInbound < 0 || maxOutbound < 0 {
+		return errNegativePeerLimit
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.maxInboundPeers = maxInbound
+	g.maxOutboundPeers = maxOutbound
+	return nil
+}
+
 // acceptConnPortHandshake performs the port handshake and should be called on
 // the side accepting a connection request. The remote address is only returned
 // if err == nil.
@@ -325,6 +532,64 @@ func connectPortHandshake(conn net.Conn, port string) error {
 	return nil
 }
 
+// ourSessionHeader returns the sessionHeader that the Gateway sends to its
+// peers.
+func (g *Gateway) ourSessionHeader() sessionHeader {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	sh := sessionHeader{
+		GenesisID:       types.GenesisID,
+		Compression:     g.compression,
+		ProtocolVersion: build.ProtocolVersion,
+		GitRevision:     build.GitRevision,
+	}
+	if g.publicAddr {
+		sh.PublicAddress = g.myAddr
+	}
+	for id := range g.handlers {
+		sh.RPCs = append(sh.RPCs, id)
+	}
+	return sh
+}
+
+// checkSessionHeader returns an error if the peer that sent 'sh' is on a
+// different network.
+func checkSessionHeader(sh sessionHeader) error {
+	if sh.GenesisID != types.GenesisID {
+		return errPeerGenesisID
+	}
+	return nil
+}
+
+// acceptConnSessionHeaderHandshake performs the session header handshake and
+// should be called on the side accepting a connection request. Our header is
+// always sent, so that the remote peer also learns when the networks differ.
+// The remote peer's header is returned if err == nil.
+func acceptConnSessionHeaderHandshake(conn net.Conn, ours sessionHeader) (sessionHeader, error) {
+	var theirs sessionHeader
+	if err := encoding.ReadObject(conn, &theirs, maxEncodedSessionHeaderSize); err != nil {
+		return sessionHeader{}, fmt.Errorf("failed to read remote session header: %v", err)
+	}
+	if err := encoding.WriteObject(conn, ours); err != nil {
+		return sessionHeader{}, fmt.Errorf("failed to write session header: %v", err)
+	}
+	return theirs, checkSessionHeader(theirs)
+}
+
+// connectSessionHeaderHandshake performs the session header handshake and
+// should be called on the side initiating the connection request. The remote
+// peer's header is returned if err == nil.
+func connectSessionHeaderHandshake(conn net.Conn, ours sessionHeader) (sessionHeader, error) {
+	if err := encoding.WriteObject(conn, ours); err != nil {
+		return sessionHeader{}, fmt.Errorf("failed to write session header: %v", err)
+	}
+	var theirs sessionHeader
+	if err := encoding.ReadObject(conn, &theirs, maxEncodedSessionHeaderSize); err != nil {
+		return sessionHeader{}, fmt.Errorf("failed to read remote session header: %v", err)
+	}
+	return theirs, checkSessionHeader(theirs)
+}
+
 // acceptableVersion returns an error if the version is unacceptable.
 func acceptableVersion(version string) error {
 	if !build.IsVersion(version) {
@@ -390,18 +655,24 @@ func (g *Gateway) managedConnectOldPeer(conn net.Conn, remoteVersion string, rem
 	if err != nil && err != errNodeExists {
 		return err
 	}
+	g.markNodeSeen(remoteAddr)
 	err = g.save()
 	if err != nil {
 		return fmt.Errorf("error saving node list: %v", err)
 	}
 
+	// The handshake is complete, so the deadline that limited it is removed
+	// before the connection is used for the peer's session.
+	conn.SetDeadline(time.Time{})
+	stats, sessConn := newPeerStats(g.limitConn(conn))
 	g.addPeer(&peer{
 		Peer: modules.Peer{
 			NetAddress: remoteAddr,
 			Inbound:    false,
 			Version:    remoteVersion,
 		},
-		sess: muxado.Client(conn),
+		sess:  muxado.Client(sessConn),
+		stats: stats,
 	})
 	return nil
 }
@@ -418,6 +689,18 @@ func (g *Gateway) managedConnectNewPeer(conn net.Conn, remoteVersion string, rem
 	if err != nil {
 		return err
 	}
+	var theirs sessionHeader
+	var rpcs []string
+	var compress bool
+	if build.VersionCmp(remoteVersion, sessionHeaderVersion) >= 0 {
+		ours := g.ourSessionHeader()
+		theirs, err = connectSessionHeaderHandshake(conn, ours)
+		if err != nil {
+			return err
+		}
+		rpcs = theirs.rpcNames()
+		compress = ours.Compression && theirs.Compression
+	}
 
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -426,18 +709,31 @@ func (g *Gateway) managedConnectNewPeer(conn net.Conn, remoteVersion string, rem
 	if err != nil && err != errNodeExists {
 		return err
 	}
+	g.markNodeSeen(remoteAddr)
 	err = g.save()
 	if err != nil {
 		return fmt.Errorf("error saving node list: %v", err)
 	}
 
+	// The handshake is complete, so the deadline that limited it is removed
+	// before the connection is used for the peer's session.
+	conn.SetDeadline(time.Time{})
+	stats, sessConn := newPeerStats(g.limitConn(conn))
+	if compress {
+		sessConn = newCompressedConn(sessConn)
+	}
 	g.addPeer(&peer{
 		Peer: modules.Peer{
 			NetAddress: remoteAddr,
 			Inbound:    false,
 			Version:    remoteVersion,
+			RPCs:       rpcs,
+
+			ProtocolVersion: theirs.ProtocolVersion,
+			GitRevision:     theirs.GitRevision,
 		},
-		sess: muxado.Client(conn),
+		sess:  muxado.Client(sessConn),
+		stats: stats,
 	})
 	return nil
 }
@@ -450,32 +746,63 @@ func (g *Gateway) Connect(addr modules.NetAddress) error {
 	}
 	defer g.threads.Done()
 
-	if addr == g.Address() {
-		return errors.New("can't connect to our own address")
-	}
 	if err := addr.IsValid(); err != nil {
 		return errors.New("can't connect to invalid address")
 	}
-	if net.ParseIP(addr.Host()) == nil {
-		return errors.New("address must be an IP address")
+	// Peers are identified by their IP address, so hostnames are resolved
+	// before connecting. When connecting through a proxy, hostnames are left
+	// for the proxy to resolve, so that DNS requests do not leak outside of
+	// it and .onion addresses can be reached.
+	if modules.Proxy() == "" {
+		var err error
+		addr, err = addr.ResolveIP()
+		if err != nil {
+			return fmt.Errorf("can't resolve address: %v", err)
+		}
+	}
+	if addr == g.Address() {
+		return errors.New("can't connect to our own address")
 	}
 
 	g.mu.RLock()
 	_, exists := g.peers[addr]
+	banned := g.isBanned(addr.Host())
+	blocklist := g.blocklist
 	g.mu.RUnlock()
 	if exists {
 		return errors.New("peer already added")
 	}
+	if banned {
+		return errPeerBanned
+	}
+	if blocklist.IsBlocked(addr) {
+		return errPeerBlocked
+	}
 
-	conn, err := net.DialTimeout("tcp", string(addr), dialTimeout)
+	conn, err := g.deps.dial(addr, dialTimeout)
 	if err != nil {
+		// Record the failure so that the node is less likely to be selected
+		// by the peer manager. Unreachable nodes are removed by the node
+		// manager.
+		g.mu.Lock()
+		g.markNodeFailed(addr)
+		g.mu.Unlock()
 		return err
 	}
+	g.mu.RLock()
+	timeout := g.rpcTimeout
+	g.mu.RUnlock()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// The duration of the version handshake is used as an estimate of the
+	// latency to the peer.
+	start := time.Now()
 	remoteVersion, err := connectVersionHandshake(conn, build.Version)
 	if err != nil {
 		conn.Close()
 		return err
 	}
+	latency := time.Since(start)
 
 	if build.VersionCmp(remoteVersion, "1.0.0") < 0 {
 		err = g.managedConnectOldPeer(conn, remoteVersion, addr)
@@ -487,6 +814,12 @@ func (g *Gateway) Connect(addr modules.NetAddress) error {
 		return err
 	}
 
+	g.mu.Lock()
+	if p, exists := g.peers[addr]; exists {
+		p.stats.latency = latency
+	}
+	g.mu.Unlock()
+
 	g.log.Debugln("INFO: connected to new peer", addr)
 
 	// call initRPCs
@@ -535,28 +868,36 @@ func (g *Gateway) Disconnect(addr modules.NetAddress) error {
 }
 
 // threadedPeerManager tries to keep the Gateway well-connected. As long as
-// the Gateway is not well-connected, it tries to connect to random nodes.
+// the Gateway is not well-connected, it tries to connect to random nodes,
+// spreading its outbound peers across address groups. Once it is
+// well-connected, it periodically replaces some of its outbound peers.
 func (g *Gateway) threadedPeerManager() {
 	if g.threads.Add() != nil {
 		return
 	}
 	defer g.threads.Done()
 
+	nextRotation := time.Now().Add(peerRotationInterval)
 	for {
 		// If we are well-connected, sleep in increments of five minutes until
-		// we are no longer well-connected.
+		// we are no longer well-connected, or until it is time to rotate
+		// peers.
 		g.mu.RLock()
-		numOutboundPeers := 0
-		for _, p := range g.peers {
-			if !p.Inbound {
-				numOutboundPeers++
-			}
-		}
-		addr, err := g.randomNode()
+		wellConnected := g.numOutboundPeers() >= g.maxOutboundPeers
+		addr, err := g.randomDiverseNode()
 		g.mu.RUnlock()
-		if numOutboundPeers >= modules.WellConnectedThreshold {
+		if wellConnected {
+			if !time.Now().Before(nextRotation) {
+				g.managedRotatePeers()
+				nextRotation = time.Now().Add(peerRotationInterval)
+				continue
+			}
+			wait := 5 * time.Minute
+			if untilRotation := nextRotation.Sub(time.Now()); untilRotation < wait {
+				wait = untilRotation
+			}
 			select {
-			case <-time.After(5 * time.Minute):
+			case <-time.After(wait):
 			case <-g.threads.StopChan():
 				return
 			}
@@ -2,11 +2,13 @@ package gateway
 
 import (
 	"net"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 	"github.com/NebulousLabs/muxado"
 )
 
@@ -154,6 +156,25 @@ func TestListen(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	theirs, err := connectSessionHeaderHandshake(conn, sessionHeader{GenesisID: types.GenesisID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theirs.ProtocolVersion != build.ProtocolVersion {
+		t.Fatal("gateway did not report its protocol version:", theirs.ProtocolVersion)
+	}
+	if theirs.GitRevision != build.GitRevision {
+		t.Fatal("gateway did not report its git revision:", theirs.GitRevision)
+	}
+	// The advertised RPCs must match exactly the handlers the gateway
+	// registered, so that the check does not go stale when RPCs are added.
+	rpcs := theirs.rpcNames()
+	if expected := g.ourSessionHeader().rpcNames(); !reflect.DeepEqual(rpcs, expected) {
+		t.Fatalf("gateway advertised RPCs %v, expected %v", rpcs, expected)
+	}
+	if len(rpcs) != 4 || rpcs[0] != "Ping" || rpcs[1] != "RelayAle" || rpcs[2] != "ShareAle" || rpcs[3] != "ShareNod" {
+		t.Fatal("gateway did not advertise its RPCs:", rpcs)
+	}
 
 	// g should add the peer
 	var ok bool
@@ -172,6 +193,37 @@ func TestListen(t *testing.T) {
 		g.mu.RUnlock()
 	}
 
+	// compliant connect from a different network
+	conn, err = net.Dial("tcp", string(g.Address()))
+	if err != nil {
+		t.Fatal("dial failed:", err)
+	}
+	addr = modules.NetAddress(conn.LocalAddr().String())
+	_, err = connectVersionHandshake(conn, build.Version)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = connectPortHandshake(conn, addr.Port())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// g sends its header before rejecting the connection, so only g sees
+	// the mismatch.
+	_, err = connectSessionHeaderHandshake(conn, sessionHeader{GenesisID: types.BlockID{1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		g.mu.RLock()
+		_, ok := g.peers[addr]
+		g.mu.RUnlock()
+		if ok {
+			t.Fatal("gateway should not have added a peer from a different network")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	conn.Close()
+
 	// uncompliant connect
 	conn, err = net.Dial("tcp", string(g.Address()))
 	if err != nil {
@@ -336,8 +388,8 @@ func TestUnitAcceptableVersion(t *testing.T) {
 	}
 }
 
-// TestConnectRejectsInvalidAddrs tests that Connect only connects to valid IP
-// addresses.
+// TestConnectRejectsInvalidAddrs tests that Connect only connects to valid
+// addresses, and that hostnames are resolved to IP addresses.
 func TestConnectRejectsInvalidAddrs(t *testing.T) {
 	g := newTestingGateway("TestConnectRejectsInvalidAddrs", t)
 	defer g.Close()
@@ -366,13 +418,13 @@ func TestConnectRejectsInvalidAddrs(t *testing.T) {
 			msg:     "Connect should reject invalid NetAddresses",
 		},
 		{
-			addr:    modules.NetAddress(net.JoinHostPort("localhost", g2Port)),
+			addr:    "unresolvable.invalid:123",
 			wantErr: true,
-			msg:     "Connect should reject non-IP addresses",
+			msg:     "Connect should reject hostnames that cannot be resolved",
 		},
 		{
-			addr: g2.Address(),
-			msg:  "Connect failed to connect to another gateway",
+			addr: modules.NetAddress(net.JoinHostPort("localhost", g2Port)),
+			msg:  "Connect failed to connect to another gateway by hostname",
 		},
 		{
 			addr:    g2.Address(),
@@ -494,6 +546,16 @@ func TestConnectRejectsVersions(t *testing.T) {
 			if remoteVersion != build.Version {
 				panic("remoteVersion != build.Version")
 			}
+			if build.VersionCmp(tt.version, "1.0.0") >= 0 {
+				if _, err := acceptConnPortHandshake(conn); err != nil {
+					panic(err)
+				}
+			}
+			if build.VersionCmp(tt.version, sessionHeaderVersion) >= 0 {
+				if _, err := acceptConnSessionHeaderHandshake(conn, sessionHeader{GenesisID: types.GenesisID}); err != nil {
+					panic(err)
+				}
+			}
 		}()
 		err = g.Connect(modules.NetAddress(listener.Addr().String()))
 		switch {
@@ -686,8 +748,8 @@ func TestPeerManager(t *testing.T) {
 
 	// g1's node list should only contain g2
 	g1.mu.Lock()
-	g1.nodes = map[modules.NetAddress]struct{}{}
-	g1.nodes[g2.Address()] = struct{}{}
+	g1.nodes = map[modules.NetAddress]*node{}
+	g1.nodes[g2.Address()] = &node{NetAddress: g2.Address()}
 	g1.mu.Unlock()
 
 	// when peerManager wakes up, it should connect to g2.
@@ -699,3 +761,70 @@ func TestPeerManager(t *testing.T) {
 		t.Fatal("gateway did not connect to g2:", g1.peers)
 	}
 }
+
+// TestPeerLimits checks that inbound peers replace each other once the
+// inbound limit is reached, without displacing outbound peers, and that
+// inbound connections are rejected when the inbound limit is zero.
+func TestPeerLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g := newTestingGateway("TestPeerLimits", t)
+	defer g.Close()
+	if err := g.SetPeerLimits(-1, 1); err != errNegativePeerLimit {
+		t.Fatal("expected errNegativePeerLimit, got", err)
+	}
+	if err := g.SetPeerLimits(1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// An outbound peer.
+	out := newTestingGateway("TestPeerLimits - outbound", t)
+	defer out.Close()
+	if err := g.Connect(out.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two inbound peers; the second replaces the first.
+	in1 := newTestingGateway("TestPeerLimits - inbound 1", t)
+	defer in1.Close()
+	in2 := newTestingGateway("TestPeerLimits - inbound 2", t)
+	defer in2.Close()
+	for _, in := range []*Gateway{in1, in2} {
+		if err := in.Connect(g.Address()); err != nil {
+			t.Fatal(err)
+		}
+		var peers map[modules.NetAddress]bool
+		for i := 0; i < 50; i++ {
+			peers = make(map[modules.NetAddress]bool)
+			for _, p := range g.Peers() {
+				peers[p.NetAddress] = p.Inbound
+			}
+			if _, ok := peers[in.Address()]; ok {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if len(peers) != 2 {
+			t.Fatalf("expected 2 peers, got %v", len(peers))
+		}
+		if inbound, ok := peers[in.Address()]; !ok || !inbound {
+			t.Fatal("inbound peer was not added")
+		}
+		if inbound, ok := peers[out.Address()]; !ok || inbound {
+			t.Fatal("outbound peer was displaced by an inbound peer")
+		}
+	}
+
+	// With no inbound slots, connections are rejected.
+	if err := g.SetPeerLimits(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	in3 := newTestingGateway("TestPeerLimits - inbound 3", t)
+	defer in3.Close()
+	if err := in3.Connect(g.Address()); err == nil {
+		t.Fatal("inbound connection was accepted with no inbound slots")
+	}
+}
@@ -15,33 +15,61 @@ const (
 	logFile = modules.GatewayDir + ".log"
 )
 
-// persistMetadata contains the header and version strings that identify the
-// gateway persist file.
-var persistMetadata = persist.Metadata{
-	Header:  "Sia Node List",
-	Version: "0.3.3",
-}
+var (
+	// persistMetadata contains the header and version strings that identify
+	// the gateway persist file.
+	persistMetadata = persist.Metadata{
+		Header:  "Sia Node List",
+		Version: "1.0.0",
+	}
+
+	// compatPersistMetadata identifies gateway persist files that only
+	// contain the addresses of the nodes, without their connection history.
+	compatPersistMetadata = persist.Metadata{
+		Header:  "Sia Node List",
+		Version: "0.3.3",
+	}
+)
 
 // persistData returns the data in the Gateway that will be saved to disk.
-func (g *Gateway) persistData() (nodes []modules.NetAddress) {
-	for node := range g.nodes {
-		nodes = append(nodes, node)
+func (g *Gateway) persistData() (nodes []*node) {
+	for _, n := range g.nodes {
+		nodes = append(nodes, n)
 	}
 	return
 }
 
 // load loads the Gateway's persistent data from disk.
 func (g *Gateway) load() error {
-	var nodes []modules.NetAddress
+	var nodes []*node
 	err := persist.LoadFile(persistMetadata, &nodes, filepath.Join(g.persistDir, nodesFile))
+	if err == persist.ErrBadVersion {
+		err = g.compatLoad(&nodes)
+	}
 	if err != nil {
 		return err
 	}
-	for _, node := range nodes {
-		err := g.addNode(node)
+	for _, n := range nodes {
+		err := g.addNode(n.NetAddress)
 		if err != nil {
-			g.log.Printf("WARN: error loading node '%v' from persist: %v", node, err)
+			g.log.Printf("WARN: error loading node '%v' from persist: %v", n.NetAddress, err)
+			continue
 		}
+		g.nodes[n.NetAddress] = n
+	}
+	return nil
+}
+
+// compatLoad loads a node list that was saved before the Gateway kept track
+// of the connection history of nodes.
+func (g *Gateway) compatLoad(nodes *[]*node) error {
+	var addrs []modules.NetAddress
+	err := persist.LoadFile(compatPersistMetadata, &addrs, filepath.Join(g.persistDir, nodesFile))
+	if err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		*nodes = append(*nodes, &node{NetAddress: addr})
 	}
 	return nil
 }
@@ -1,7 +1,11 @@
 package gateway
 
 import (
+	"path/filepath"
 	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
 )
 
 func TestLoad(t *testing.T) {
@@ -12,7 +16,9 @@ func TestLoad(t *testing.T) {
 	g := newTestingGateway("TestLoad", t)
 	g.mu.Lock()
 	g.addNode(dummyNode)
+	g.markNodeSeen(dummyNode)
 	g.save()
+	lastSeen := g.nodes[dummyNode].LastSeen
 	g.mu.Unlock()
 	g.Close()
 
@@ -20,7 +26,41 @@ func TestLoad(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if _, ok := g2.nodes[dummyNode]; !ok {
+	defer g2.Close()
+	n, ok := g2.nodes[dummyNode]
+	if !ok {
+		t.Fatal("gateway did not load old peer list:", g2.nodes)
+	}
+	if n.Successes != 1 || !n.LastSeen.Equal(lastSeen) {
+		t.Fatal("gateway did not load the node history:", n)
+	}
+}
+
+// TestCompatLoad checks that node lists saved without a connection history
+// can still be loaded.
+func TestCompatLoad(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	g := newTestingGateway("TestCompatLoad", t)
+	g.Close()
+	addrs := []modules.NetAddress{dummyNode}
+	err := persist.SaveFile(compatPersistMetadata, addrs, filepath.Join(g.persistDir, nodesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g2, err := New("localhost:0", g.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g2.Close()
+	n, ok := g2.nodes[dummyNode]
+	if !ok {
 		t.Fatal("gateway did not load old peer list:", g2.nodes)
 	}
+	if n.Successes != 0 || !n.LastSeen.IsZero() {
+		t.Fatal("compat node should not have a history:", n)
+	}
 }
@@ -0,0 +1,163 @@
+package gateway
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// pingInterval is how often each peer is pinged.
+	pingInterval = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 30 * time.Second
+		case "standard":
+			return 1 * time.Minute
+		case "testing":
+			return 1 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// pingTimeout is how long a peer has to respond to a ping.
+	pingTimeout = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 20 * time.Second
+		case "standard":
+			return 30 * time.Second
+		case "testing":
+			return 1 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// maxPingFailures is the number of consecutive pings that a peer can fail
+	// to respond to before it is disconnected.
+	maxPingFailures = 3
+
+	errPingMismatch = errors.New("peer responded to ping with the wrong nonce")
+)
+
+// supportsPing returns true if the peer advertised the Ping RPC when it
+// connected. Older peers are not pinged.
+func supportsPing(p *peer) bool {
+	i := sort.SearchStrings(p.RPCs, "Ping")
+	return i < len(p.RPCs) && p.RPCs[i] == "Ping"
+}
+
+// rpcPing is the receiving end of the Ping RPC. It echoes a nonce back to the
+// caller.
+func (g *Gateway) rpcPing(conn modules.PeerConn) error {
+	var nonce [8]byte
+	if err := encoding.ReadObject(conn, &nonce, uint64(len(nonce))); err != nil {
+		return err
+	}
+	return encoding.WriteObject(conn, nonce)
+}
+
+// managedPing calls the Ping RPC on a peer and returns the round trip time.
+func (g *Gateway) managedPing(addr modules.NetAddress) (time.Duration, error) {
+	var rtt time.Duration
+	err := g.managedRPC(addr, "Ping", func(conn modules.PeerConn) error {
+		if err := conn.SetDeadline(time.Now().Add(pingTimeout)); err != nil {
+			return err
+		}
+		var nonce, echo [8]byte
+		b, err := crypto.RandBytes(len(nonce))
+		if err != nil {
+			return err
+		}
+		copy(nonce[:], b)
+
+		start := time.Now()
+		if err := encoding.WriteObject(conn, nonce); err != nil {
+			return err
+		}
+		if err := encoding.ReadObject(conn, &echo, uint64(len(echo))); err != nil {
+			return err
+		}
+		if echo != nonce {
+			return errPingMismatch
+		}
+		rtt = time.Since(start)
+		return nil
+	})
+	return rtt, err
+}
+
+// threadedPingPeer pings a peer, recording its latency if it responds. Peers
+// that fail to respond to maxPingFailures consecutive pings are disconnected,
+// so that connections which died without being closed are not kept around.
+func (g *Gateway) threadedPingPeer(addr modules.NetAddress) {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	rtt, err := g.managedPing(addr)
+
+	g.mu.Lock()
+	p, exists := g.peers[addr]
+	if !exists {
+		g.mu.Unlock()
+		return
+	}
+	if err == nil {
+		p.stats.latency = rtt
+		p.stats.pingFailures = 0
+		g.mu.Unlock()
+		return
+	}
+	p.stats.pingFailures++
+	dead := p.stats.pingFailures >= maxPingFailures
+	if dead {
+		delete(g.peers, addr)
+	}
+	g.mu.Unlock()
+
+	g.log.Debugf("WARN: peer %v did not respond to ping: %v", addr, err)
+	if dead {
+		p.sess.Close()
+		g.log.Printf("INFO: disconnected from peer %v: %v", addr, errUnreachable)
+		if !p.Inbound {
+			go g.threadedReconnect(addr)
+		}
+	}
+}
+
+// threadedPingPeers periodically pings every peer that supports the Ping RPC.
+func (g *Gateway) threadedPingPeers() {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	for {
+		select {
+		case <-time.After(pingInterval):
+		case <-g.threads.StopChan():
+			return
+		}
+
+		g.mu.RLock()
+		var addrs []modules.NetAddress
+		for addr, p := range g.peers {
+			if supportsPing(p) {
+				addrs = append(addrs, addr)
+			}
+		}
+		g.mu.RUnlock()
+		for _, addr := range addrs {
+			go g.threadedPingPeer(addr)
+		}
+	}
+}
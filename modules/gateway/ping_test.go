@@ -0,0 +1,88 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestPing checks that peers respond to pings, and that their latency is
+// recorded.
+func TestPing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newTestingGateway("TestPing1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestPing2", t)
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	g1.mu.RLock()
+	supported := supportsPing(g1.peers[g2.Address()])
+	g1.mu.RUnlock()
+	if !supported {
+		t.Fatal("peer did not advertise the Ping RPC")
+	}
+	rtt, err := g1.managedPing(g2.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rtt <= 0 {
+		t.Fatal("ping returned a non-positive round trip time:", rtt)
+	}
+
+	// Wait for the peer to be pinged in the background.
+	time.Sleep(2 * pingInterval)
+	g1.mu.RLock()
+	latency := g1.peers[g2.Address()].stats.latency
+	failures := g1.peers[g2.Address()].stats.pingFailures
+	g1.mu.RUnlock()
+	if latency <= 0 || latency > pingTimeout {
+		t.Fatal("peer latency was not recorded:", latency)
+	}
+	if failures != 0 {
+		t.Fatal("responsive peer failed pings:", failures)
+	}
+}
+
+// TestPingDisconnectsDeadPeers checks that peers that stop responding to pings
+// are disconnected.
+func TestPingDisconnectsDeadPeers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newTestingGateway("TestPingDisconnectsDeadPeers1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestPingDisconnectsDeadPeers2", t)
+	defer g2.Close()
+	// g2 connects to g1, so that g1 does not try to reconnect to g2 after
+	// disconnecting it.
+	if err := g2.Connect(g1.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Make g2 stop responding to pings, without closing the connection.
+	stop := make(chan struct{})
+	defer close(stop)
+	g2.UnregisterRPC("Ping")
+	g2.RegisterRPC("Ping", func(modules.PeerConn) error {
+		<-stop
+		return nil
+	})
+
+	deadline := time.Now().Add(time.Duration(maxPingFailures+2) * (pingInterval + pingTimeout))
+	for len(g1.Peers()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("peer that did not respond to pings was not disconnected")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,102 @@
+package gateway
+
+import (
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+const (
+	// maxReconnectAttempts is the number of times the Gateway tries to
+	// reconnect to an outbound peer whose connection dropped before giving
+	// up. Once it gives up, the peer manager replaces the peer with a random
+	// node.
+	maxReconnectAttempts = 8
+)
+
+var (
+	// reconnectBaseDelay is the delay before the first attempt to reconnect
+	// to a dropped peer. The delay doubles with each failed attempt.
+	reconnectBaseDelay = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 5 * time.Second
+		case "standard":
+			return 10 * time.Second
+		case "testing":
+			return 100 * time.Millisecond
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// maxReconnectDelay is the longest delay between two attempts to
+	// reconnect to a dropped peer.
+	maxReconnectDelay = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 2 * time.Minute
+		case "standard":
+			return 10 * time.Minute
+		case "testing":
+			return 1 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// reconnectDelay returns the delay before the given reconnection attempt,
+// counting from zero. The delay grows exponentially up to maxReconnectDelay,
+// and is jittered so that peers that dropped at the same time, such as when
+// the network went down, are not all redialed at once.
+func reconnectDelay(attempt int) time.Duration {
+	delay := maxReconnectDelay
+	if attempt < 32 && reconnectBaseDelay<<uint(attempt) < maxReconnectDelay {
+		delay = reconnectBaseDelay << uint(attempt)
+	}
+	// Choose a delay in [delay/2, delay).
+	jitter, err := crypto.RandIntn(int(delay / 2))
+	if err != nil {
+		return delay
+	}
+	return delay/2 + time.Duration(jitter)
+}
+
+// threadedReconnect tries to reconnect to an outbound peer whose connection
+// dropped unexpectedly, backing off exponentially between attempts. It stops
+// once the peer is connected again, or if the Gateway no longer needs more
+// outbound peers.
+func (g *Gateway) threadedReconnect(addr modules.NetAddress) {
+	if g.threads.Add() != nil {
+		return
+	}
+	defer g.threads.Done()
+
+	for attempt := 0; attempt < maxReconnectAttempts; attempt++ {
+		select {
+		case <-time.After(reconnectDelay(attempt)):
+		case <-g.threads.StopChan():
+			return
+		}
+
+		g.mu.RLock()
+		_, connected := g.peers[addr]
+		full := g.numOutboundPeers() >= g.maxOutboundPeers
+		banned := g.isBanned(addr.Host())
+		g.mu.RUnlock()
+		if connected || full || banned {
+			return
+		}
+
+		err := g.Connect(addr)
+		if err == nil {
+			g.log.Println("INFO: reconnected to peer", addr)
+			return
+		}
+		g.log.Debugf("WARN: attempt %v to reconnect to %v failed: %v", attempt+1, addr, err)
+	}
+	g.log.Printf("INFO: giving up on reconnecting to %v after %v attempts", addr, maxReconnectAttempts)
+}
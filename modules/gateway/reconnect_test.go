@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReconnectDelay checks that reconnectDelay backs off exponentially, with
+// jitter, up to maxReconnectDelay.
+func TestReconnectDelay(t *testing.T) {
+	for attempt := 0; attempt < 100; attempt++ {
+		max := maxReconnectDelay
+		if attempt < 32 && reconnectBaseDelay<<uint(attempt) < max {
+			max = reconnectBaseDelay << uint(attempt)
+		}
+		for i := 0; i < 10; i++ {
+			if d := reconnectDelay(attempt); d < max/2 || d >= max {
+				t.Fatalf("attempt %v: delay %v is outside of [%v, %v)", attempt, d, max/2, max)
+			}
+		}
+	}
+}
+
+// TestReconnect checks that the Gateway reconnects to outbound peers whose
+// connection drops, but not to peers that it disconnected from on purpose.
+func TestReconnect(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newTestingGateway("TestReconnect1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestReconnect2", t)
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Drop the connection from g2's side; g1 should reconnect.
+	if err := g2.Disconnect(g1.Address()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; len(g1.Peers()) != 1 || len(g2.Peers()) != 1; i++ {
+		if i == 50 {
+			t.Fatal("g1 did not reconnect to g2")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// Disconnecting from g1's side should not trigger a reconnection.
+	if err := g1.Disconnect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(4 * reconnectBaseDelay)
+	if len(g1.Peers()) != 0 {
+		t.Fatal("g1 reconnected to a peer that it disconnected from")
+	}
+}
@@ -0,0 +1,117 @@
+package gateway
+
+// relay.go implements the send queues of relay RPCs. Relays to a peer are
+// limited to a few at a time, and when a peer is saturated, waiting block
+// relays are sent before waiting transaction relays. This keeps the
+// propagation latency of blocks low when many transactions are being relayed.
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	// maxConcurrentRelays is the number of relay RPCs that can be in progress
+	// to a single peer at once. Further relays wait in the peer's queue.
+	maxConcurrentRelays = 4
+
+	// maxQueuedTransactionRelays is the number of transaction relays that can
+	// wait in a peer's queue. Transaction relays beyond this limit are
+	// dropped, as the peer will learn of the transactions from its other
+	// peers. Block relays are never dropped.
+	maxQueuedTransactionRelays = 1000
+)
+
+// The priorities of relay RPCs, from highest to lowest.
+const (
+	relayPriorityBlock = iota
+	relayPriorityTransaction
+	numRelayPriorities
+)
+
+var (
+	// relayPriorities maps relay RPCs to their priority. RPCs that are not
+	// listed are not queued.
+	relayPriorities = map[rpcID]int{
+		handlerName("RelayBlock"):  relayPriorityBlock,
+		handlerName("RelayHeader"): relayPriorityBlock,
+
+		handlerName("RelayTransactionSet"): relayPriorityTransaction,
+		handlerName("RelayTxnInv"):         relayPriorityTransaction,
+	}
+
+	errRelayCancelled = errors.New("relay was cancelled while waiting to be sent")
+	errRelayQueueFull = errors.New("too many relays are waiting to be sent to the peer")
+)
+
+// A relayQueue limits the number of relay RPCs in progress to a peer, and
+// orders the waiting relays by priority.
+type relayQueue struct {
+	active  int
+	waiting [numRelayPriorities][]chan struct{}
+	mu      sync.Mutex
+}
+
+// newRelayQueue returns an empty relayQueue.
+func newRelayQueue() *relayQueue {
+	return new(relayQueue)
+}
+
+// acquire waits until a relay of the given priority may be sent. If acquire
+// returns nil, release must be called once the relay is complete. An error is
+// returned if the queue is full, or if 'cancel' is closed while waiting.
+func (rq *relayQueue) acquire(priority int, cancel <-chan struct{}) error {
+	rq.mu.Lock()
+	if rq.active < maxConcurrentRelays {
+		rq.active++
+		rq.mu.Unlock()
+		return nil
+	}
+	if priority == relayPriorityTransaction && len(rq.waiting[priority]) >= maxQueuedTransactionRelays {
+		rq.mu.Unlock()
+		return errRelayQueueFull
+	}
+	ready := make(chan struct{})
+	rq.waiting[priority] = append(rq.waiting[priority], ready)
+	rq.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-cancel:
+	}
+
+	// Remove the relay from the queue. If it was handed a slot in the
+	// meantime, the slot is passed on.
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	for i, c := range rq.waiting[priority] {
+		if c == ready {
+			rq.waiting[priority] = append(rq.waiting[priority][:i], rq.waiting[priority][i+1:]...)
+			return errRelayCancelled
+		}
+	}
+	rq.releaseLocked()
+	return errRelayCancelled
+}
+
+// release marks a relay as complete, allowing the highest priority waiting
+// relay to be sent.
+func (rq *relayQueue) release() {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	rq.releaseLocked()
+}
+
+// releaseLocked hands the slot of a completed relay to the highest priority
+// waiting relay. The slot stays active if it is handed on.
+func (rq *relayQueue) releaseLocked() {
+	for p := range rq.waiting {
+		if len(rq.waiting[p]) > 0 {
+			close(rq.waiting[p][0])
+			rq.waiting[p] = rq.waiting[p][1:]
+			return
+		}
+	}
+	rq.active--
+}
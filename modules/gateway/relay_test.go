@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRelayQueuePriority checks that waiting block relays are sent before
+// waiting transaction relays once a slot is released.
+func TestRelayQueuePriority(t *testing.T) {
+	rq := newRelayQueue()
+	for i := 0; i < maxConcurrentRelays; i++ {
+		if err := rq.acquire(relayPriorityTransaction, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Queue a transaction relay, followed by a block relay.
+	order := make(chan int, 2)
+	queue := func(priority int) {
+		if err := rq.acquire(priority, nil); err != nil {
+			t.Error(err)
+			return
+		}
+		order <- priority
+	}
+	go queue(relayPriorityTransaction)
+	for !rq.isWaiting(relayPriorityTransaction) {
+		time.Sleep(time.Millisecond)
+	}
+	go queue(relayPriorityBlock)
+	for !rq.isWaiting(relayPriorityBlock) {
+		time.Sleep(time.Millisecond)
+	}
+
+	rq.release()
+	if p := <-order; p != relayPriorityBlock {
+		t.Fatal("transaction relay was sent before the block relay")
+	}
+	rq.release()
+	if p := <-order; p != relayPriorityTransaction {
+		t.Fatal("expected the transaction relay to be sent")
+	}
+}
+
+// isWaiting returns true if a relay of the given priority is waiting.
+func (rq *relayQueue) isWaiting(priority int) bool {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	return len(rq.waiting[priority]) > 0
+}
+
+// TestRelayQueueLimits checks that transaction relays are dropped when the
+// queue is full, and that cancelled relays leave the queue.
+func TestRelayQueueLimits(t *testing.T) {
+	rq := newRelayQueue()
+	for i := 0; i < maxConcurrentRelays; i++ {
+		if err := rq.acquire(relayPriorityBlock, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cancel := make(chan struct{})
+	errs := make(chan error, maxQueuedTransactionRelays)
+	for i := 0; i < maxQueuedTransactionRelays; i++ {
+		go func() {
+			errs <- rq.acquire(relayPriorityTransaction, cancel)
+		}()
+	}
+	for {
+		rq.mu.Lock()
+		n := len(rq.waiting[relayPriorityTransaction])
+		rq.mu.Unlock()
+		if n == maxQueuedTransactionRelays {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := rq.acquire(relayPriorityTransaction, nil); err != errRelayQueueFull {
+		t.Fatal("expected errRelayQueueFull, got", err)
+	}
+
+	// Block relays are queued even when transaction relays are not.
+	blockErr := make(chan error)
+	go func() {
+		blockErr <- rq.acquire(relayPriorityBlock, nil)
+	}()
+	for !rq.isWaiting(relayPriorityBlock) {
+		time.Sleep(time.Millisecond)
+	}
+
+	close(cancel)
+	for i := 0; i < maxQueuedTransactionRelays; i++ {
+		if err := <-errs; err != errRelayCancelled {
+			t.Fatal("expected errRelayCancelled, got", err)
+		}
+	}
+	rq.release()
+	if err := <-blockErr; err != nil {
+		t.Fatal(err)
+	}
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+	if rq.active != maxConcurrentRelays || len(rq.waiting[relayPriorityTransaction]) != 0 {
+		t.Fatal("queue is in the wrong state:", rq.active, len(rq.waiting[relayPriorityTransaction]))
+	}
+}
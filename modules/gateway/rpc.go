@@ -36,26 +36,65 @@ func (g *Gateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc)
 		return err
 	}
 	defer g.threads.Done()
+	return g.managedRPC(addr, name, fn)
+}
 
+// managedRPC calls an RPC on the given address. Unlike RPC, it does not join
+// the thread group, so that goroutines which already hold a thread do not
+// call Add while the Gateway is stopping.
+func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
 	g.mu.RLock()
 	peer, ok := g.peers[addr]
+	timeout := g.rpcTimeout
 	g.mu.RUnlock()
 	if !ok {
 		return errors.New("can't call RPC on unconnected peer " + string(addr))
 	}
 
+	// Relays wait for their turn in the peer's send queue.
+	id := handlerName(name)
+	if priority, isRelay := relayPriorities[id]; isRelay {
+		if err := peer.relays.acquire(priority, g.threads.StopChan()); err != nil {
+			return err
+		}
+		defer peer.relays.release()
+	}
+
 	conn, err := peer.open()
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
+	conn = &peerConn{modules.NewBandwidthConn(conn, rpcBandwidthCategory(id)), addr}
+	// Set a deadline so that a stalled peer cannot block the RPC forever.
+	// RPCs that are expected to take longer extend the deadline.
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
 
 	// write header
-	if err := encoding.WriteObject(conn, handlerName(name)); err != nil {
+	if err := encoding.WriteObject(conn, id); err != nil {
 		return err
 	}
 	// call fn
-	return fn(conn)
+	err = fn(conn)
+	if encoding.IsMalformed(err) {
+		g.managedReportMalformed(addr, id, err)
+	}
+	return err
+}
+
+// SetRPCTimeout sets the deadline for the connection handshakes and for each
+// RPC, so that stalled peers cannot block RPCs forever. RPCs that are expected
+// to take longer extend the deadline themselves.
+func (g *Gateway) SetRPCTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return errNonPositiveTimeout
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rpcTimeout = timeout
+	return nil
 }
 
 // RegisterRPC registers an RPCFunc as a handler for a given identifier. To
@@ -142,6 +181,15 @@ func (g *Gateway) threadedListenPeer(p *peer) {
 		conn, err := p.accept()
 		if err != nil {
 			g.log.Println("WARN: lost connection to peer", p.NetAddress)
+			// Peers that are disconnected on purpose are removed from the
+			// peer list before their session is closed, so a peer that is
+			// still in the list dropped unexpectedly.
+			g.mu.RLock()
+			dropped := g.peers[p.NetAddress] == p
+			g.mu.RUnlock()
+			if dropped && !p.Inbound {
+				go g.threadedReconnect(p.NetAddress)
+			}
 			return
 		}
 
@@ -160,10 +208,28 @@ func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
 	}
 	defer g.threads.Done()
 
+	// Set a deadline so that a stalled peer cannot block the handler forever.
+	// Handlers that are expected to take longer extend the deadline.
+	g.mu.RLock()
+	timeout := g.rpcTimeout
+	g.mu.RUnlock()
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return
+	}
+
+	// The purpose of the connection's bandwidth is known once the RPC has
+	// been read.
+	bc := modules.NewBandwidthConn(conn, modules.BandwidthOther)
+	conn = &peerConn{bc, conn.RPCAddr()}
+
 	var id rpcID
 	if err := encoding.ReadObject(conn, &id, 8); err != nil {
+		if encoding.IsMalformed(err) {
+			g.managedRejectMalformed(conn, id, err)
+		}
 		return
 	}
+	bc.SetCategory(rpcBandwidthCategory(id))
 	// call registered handler for this ID
 	g.mu.RLock()
 	fn, ok := g.handlers[id]
@@ -180,11 +246,29 @@ func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
 	if err == modules.ErrDuplicateTransactionSet || err == modules.ErrBlockKnown {
 		err = nil
 	}
-	if err != nil {
+	if encoding.IsMalformed(err) {
+		g.managedRejectMalformed(conn, id, err)
+	} else if err != nil {
 		g.log.Debugf("WARN: incoming RPC \"%v\" from conn %v failed: %v", id, conn.RPCAddr(), err)
 	}
 }
 
+// managedReportMalformed reports a peer that sent a message which could not be
+// decoded during an RPC. The stream of the RPC is closed by the caller, but the
+// peer stays connected unless its misbehavior score reaches banThreshold.
+func (g *Gateway) managedReportMalformed(addr modules.NetAddress, id rpcID, err error) {
+	g.log.Debugf("WARN: peer %v sent a malformed message during RPC \"%v\": %v", addr, id, err)
+	g.ReportMisbehavior(addr, modules.MisbehaviorMalformedRPC)
+}
+
+// managedRejectMalformed reports a peer that sent a malformed message while
+// calling an RPC, and writes the error back over the connection so that the
+// peer receives an *encoding.RemoteError instead of an unexplained EOF.
+func (g *Gateway) managedRejectMalformed(conn modules.PeerConn, id rpcID, err error) {
+	g.managedReportMalformed(conn.RPCAddr(), id, err)
+	_ = encoding.WriteError(conn, err)
+}
+
 // Broadcast calls an RPC on all of the specified peers. The calls are run in
 // parallel. Broadcasts are restricted to "one-way" RPCs, which simply write an
 // object and disconnect. This is why Broadcast takes an interface{} instead of
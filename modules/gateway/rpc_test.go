@@ -534,3 +534,96 @@ func TestCallingRPCFromRPC(t *testing.T) {
 		t.Fatal("expected BAR RPC to be called")
 	}
 }
+
+// TestRateLimitedRPC checks that RPCs with a peer are slowed down by the
+// per-peer rate limit.
+func TestRateLimitedRPC(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g1 := newTestingGateway("TestRateLimitedRPC1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestRateLimitedRPC2", t)
+	defer g2.Close()
+
+	const rate = 100e3
+	g2.SetRateLimits(nil, rate)
+	err := g2.Connect(g1.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send two seconds' worth of data. The first second's worth is allowed
+	// immediately, so the RPC should take at least another second.
+	data := make([]byte, 2*rate)
+	g1.RegisterRPC("Foo", func(conn modules.PeerConn) error {
+		return encoding.WriteObject(conn, data)
+	})
+	start := time.Now()
+	err = g2.RPC(g1.Address(), "Foo", func(conn modules.PeerConn) error {
+		var recv []byte
+		return encoding.ReadObject(conn, &recv, 3*rate)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatal("per-peer rate limit was not enforced:", elapsed)
+	}
+}
+
+// TestRPCTimeout checks that an RPC to a stalled peer fails once the RPC
+// timeout has passed, on both the calling and the handling end.
+func TestRPCTimeout(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newTestingGateway("TestRPCTimeout1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestRPCTimeout2", t)
+	defer g2.Close()
+	if err := g1.SetRPCTimeout(0); err != errNonPositiveTimeout {
+		t.Fatal("expected errNonPositiveTimeout, got", err)
+	}
+	if err := g1.SetRPCTimeout(200 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := g2.SetRPCTimeout(200 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The handler waits for data that the caller never sends, and the caller
+	// waits for a response that the handler never sends.
+	handlerErr := make(chan error, 1)
+	g2.RegisterRPC("Stall", func(conn modules.PeerConn) error {
+		var b bool
+		err := encoding.ReadObject(conn, &b, 1)
+		handlerErr <- err
+		return err
+	})
+	start := time.Now()
+	err := g1.RPC(g2.Address(), "Stall", func(conn modules.PeerConn) error {
+		var b bool
+		return encoding.ReadObject(conn, &b, 1)
+	})
+	if err == nil {
+		t.Fatal("RPC to a stalled peer succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatal("RPC was not abandoned after the timeout:", elapsed)
+	}
+	select {
+	case err := <-handlerErr:
+		if err == nil {
+			t.Fatal("handler of a stalled RPC succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler was not abandoned after the timeout")
+	}
+}
@@ -0,0 +1,130 @@
+package gateway
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/netsim"
+)
+
+// simDependencies runs a Gateway on a simulated network.
+type simDependencies struct {
+	host *netsim.Host
+}
+
+func (sd simDependencies) dial(addr modules.NetAddress, timeout time.Duration) (net.Conn, error) {
+	return sd.host.Dial(string(addr), timeout)
+}
+
+func (sd simDependencies) listen(addr string) (net.Listener, error) {
+	return sd.host.Listen(addr)
+}
+
+func (sd simDependencies) lookupHost(host string) ([]string, error) {
+	return nil, errors.New("the simulated network has no DNS")
+}
+
+// newSimGateway returns a Gateway running on 'host' of a simulated network.
+func newSimGateway(n *netsim.Network, host string, name string, t *testing.T) *Gateway {
+	g, err := newGateway(simDependencies{n.Host(host)}, ":0", build.TempDir("gateway", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// TestSimulatedLatency checks that gateways can connect over a simulated
+// network, and that the measured latency of a peer reflects the latency of
+// the link.
+func TestSimulatedLatency(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	n := netsim.NewNetwork(0)
+	n.SetLatency("10.0.0.1", "10.0.0.2", 50*time.Millisecond)
+	g1 := newSimGateway(n, "10.0.0.1", "TestSimulatedLatency1", t)
+	defer g1.Close()
+	g2 := newSimGateway(n, "10.0.0.2", "TestSimulatedLatency2", t)
+	defer g2.Close()
+
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	rtt, err := g1.managedPing(g2.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rtt < 100*time.Millisecond {
+		t.Fatal("ping round trip was shorter than the link latency:", rtt)
+	}
+}
+
+// TestSimulatedPartition checks that gateways cannot connect across a
+// partition, and that they can connect once it heals.
+func TestSimulatedPartition(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	n := netsim.NewNetwork(0)
+	g1 := newSimGateway(n, "10.0.0.1", "TestSimulatedPartition1", t)
+	defer g1.Close()
+	g2 := newSimGateway(n, "10.0.0.2", "TestSimulatedPartition2", t)
+	defer g2.Close()
+
+	n.Partition([]string{"10.0.0.1"}, []string{"10.0.0.2"})
+	if err := g1.Connect(g2.Address()); err == nil {
+		t.Fatal("connected across a partition")
+	}
+	n.Heal()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestSimulatedLoss checks that RPCs between gateways deliver their data
+// intact over a lossy link.
+func TestSimulatedLoss(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	n := netsim.NewNetwork(0)
+	if err := n.SetLoss("10.0.0.1", "10.0.0.2", 0.2); err != nil {
+		t.Fatal(err)
+	}
+	g1 := newSimGateway(n, "10.0.0.1", "TestSimulatedLoss1", t)
+	defer g1.Close()
+	g2 := newSimGateway(n, "10.0.0.2", "TestSimulatedLoss2", t)
+	defer g2.Close()
+
+	data := make([]byte, 1<<16)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	g2.RegisterRPC("Data", func(conn modules.PeerConn) error {
+		return encoding.WriteObject(conn, data)
+	})
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	var received []byte
+	err := g1.RPC(g2.Address(), "Data", func(conn modules.PeerConn) error {
+		return encoding.ReadObject(conn, &received, uint64(len(data))+8)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(received) != string(data) {
+		t.Fatal("data was corrupted in transit")
+	}
+}
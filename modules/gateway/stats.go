@@ -0,0 +1,100 @@
+package gateway
+
+import (
+	"net"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// peerStats tracks statistics about the connection to a peer. The byte
+// counters are updated atomically by the peer's connection; the other fields
+// are protected by the Gateway's mutex.
+type peerStats struct {
+	// bytesSent and bytesReceived are accessed atomically, and are placed
+	// first to keep them 64-bit aligned on 32-bit platforms.
+	bytesSent     uint64
+	bytesReceived uint64
+
+	connectedSince time.Time
+	latency        time.Duration
+	lastBlock      types.BlockID
+	lastBlockTime  time.Time
+
+	// pingFailures is the number of consecutive pings that the peer has
+	// failed to respond to.
+	pingFailures int
+}
+
+// countingConn is a net.Conn that counts the bytes read from and written to
+// it.
+type countingConn struct {
+	net.Conn
+	stats *peerStats
+}
+
+// Read reads data from the connection, adding the number of bytes read to the
+// peer's statistics.
+func (cc countingConn) Read(p []byte) (int, error) {
+	n, err := cc.Conn.Read(p)
+	atomic.AddUint64(&cc.stats.bytesReceived, uint64(n))
+	return n, err
+}
+
+// Write writes data to the connection, adding the number of bytes written to
+// the peer's statistics.
+func (cc countingConn) Write(p []byte) (int, error) {
+	n, err := cc.Conn.Write(p)
+	atomic.AddUint64(&cc.stats.bytesSent, uint64(n))
+	return n, err
+}
+
+// newPeerStats returns the statistics for a new connection, along with the
+// connection wrapped so that its traffic is counted.
+func newPeerStats(conn net.Conn) (*peerStats, net.Conn) {
+	stats := &peerStats{connectedSince: time.Now()}
+	return stats, countingConn{Conn: conn, stats: stats}
+}
+
+// PeerStats returns statistics about the connection to each peer, sorted by
+// address.
+func (g *Gateway) PeerStats() []modules.PeerStats {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	var addrs []string
+	for addr := range g.peers {
+		addrs = append(addrs, string(addr))
+	}
+	sort.Strings(addrs)
+
+	stats := make([]modules.PeerStats, 0, len(addrs))
+	for _, addr := range addrs {
+		p := g.peers[modules.NetAddress(addr)]
+		stats = append(stats, modules.PeerStats{
+			Peer:           p.Peer,
+			ConnectedSince: p.stats.connectedSince,
+			Latency:        p.stats.latency,
+			BytesSent:      atomic.LoadUint64(&p.stats.bytesSent),
+			BytesReceived:  atomic.LoadUint64(&p.stats.bytesReceived),
+			LastBlock:      p.stats.lastBlock,
+			LastBlockTime:  p.stats.lastBlockTime,
+		})
+	}
+	return stats
+}
+
+// RecordRelayedBlock records that the peer at 'addr' relayed a block that was
+// accepted. Blocks relayed by addresses that are not peers are ignored.
+func (g *Gateway) RecordRelayedBlock(addr modules.NetAddress, id types.BlockID) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, exists := g.peers[addr]
+	if !exists {
+		return
+	}
+	p.stats.lastBlock = id
+	p.stats.lastBlockTime = time.Now()
+}
@@ -0,0 +1,73 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestPeerStats checks that the Gateway tracks the traffic of each peer and
+// the blocks that they relay.
+func TestPeerStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g1 := newTestingGateway("TestPeerStats1", t)
+	defer g1.Close()
+	g2 := newTestingGateway("TestPeerStats2", t)
+	defer g2.Close()
+	if err := g1.Connect(g2.Address()); err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the OnConnectRPCs to finish.
+	time.Sleep(100 * time.Millisecond)
+
+	stats := g1.PeerStats()
+	if len(stats) != 1 || stats[0].NetAddress != g2.Address() {
+		t.Fatal("wrong peer stats:", stats)
+	}
+	if stats[0].Latency <= 0 {
+		t.Fatal("latency to an outbound peer was not recorded")
+	}
+	sent, received := stats[0].BytesSent, stats[0].BytesReceived
+
+	// Traffic from an RPC should be counted.
+	data := make([]byte, 1000)
+	g2.RegisterRPC("Echo", func(conn modules.PeerConn) error {
+		var b []byte
+		if err := encoding.ReadObject(conn, &b, 2000); err != nil {
+			return err
+		}
+		return encoding.WriteObject(conn, b)
+	})
+	err := g1.RPC(g2.Address(), "Echo", func(conn modules.PeerConn) error {
+		if err := encoding.WriteObject(conn, data); err != nil {
+			return err
+		}
+		return encoding.ReadObject(conn, &data, 2000)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats = g1.PeerStats()
+	if stats[0].BytesSent < sent+1000 || stats[0].BytesReceived < received+1000 {
+		t.Fatalf("RPC traffic was not counted: sent %v -> %v, received %v -> %v", sent, stats[0].BytesSent, received, stats[0].BytesReceived)
+	}
+
+	// Relayed blocks are recorded for peers only.
+	if !stats[0].LastBlockTime.IsZero() {
+		t.Fatal("peer has not relayed a block")
+	}
+	id := types.BlockID{1, 2, 3}
+	g1.RecordRelayedBlock(g2.Address(), id)
+	g1.RecordRelayedBlock("1.2.3.4:5678", types.BlockID{4})
+	stats = g1.PeerStats()
+	if len(stats) != 1 || stats[0].LastBlock != id || stats[0].LastBlockTime.IsZero() {
+		t.Fatal("relayed block was not recorded:", stats)
+	}
+}
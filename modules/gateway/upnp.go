@@ -53,6 +53,12 @@ func (g *Gateway) threadedLearnHostname() {
 	if build.Release == "testing" {
 		return
 	}
+	// Querying UPnP devices and myexternalip.com would reveal our IP when
+	// connecting through a proxy.
+	if modules.Proxy() != "" {
+		g.log.Println("INFO: not discovering external IP, as connections go through a proxy")
+		return
+	}
 
 	var host string
 
@@ -78,6 +84,10 @@ func (g *Gateway) threadedLearnHostname() {
 	}
 
 	g.mu.Lock()
+	if g.publicAddr {
+		g.mu.Unlock()
+		return
+	}
 	g.myAddr = addr
 	g.mu.Unlock()
 
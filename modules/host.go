@@ -7,6 +7,14 @@ import (
 const (
 	// HostDir names the directory that contains the host persistence.
 	HostDir = "host"
+
+	// HostEventContractAccepted is the type of the HostEvent that is sent
+	// when the host accepts a new or renewed file contract.
+	HostEventContractAccepted = "contractaccepted"
+
+	// HostEventProofSubmitted is the type of the HostEvent that is sent when
+	// the host submits a storage proof to the transaction pool.
+	HostEventProofSubmitted = "proofsubmitted"
 )
 
 var (
@@ -79,6 +87,23 @@ type (
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
 	}
 
+	// A HostEvent is a change to one of the host's storage obligations,
+	// reported to the subscribers of the host.
+	HostEvent struct {
+		Type       string               `json:"type"`
+		ContractID types.FileContractID `json:"contractid"`
+		Height     types.BlockHeight    `json:"height"`
+	}
+
+	// A HostSubscriber receives a notification for each event of the host's
+	// storage obligations.
+	HostSubscriber interface {
+		// ReceiveHostEvent is called with each event of the host. It is
+		// called while the host is locked, and must not block or call the
+		// host.
+		ReceiveHostEvent(HostEvent)
+	}
+
 	// A Host can take storage from disk and offer it to the network, managing
 	// things such as announcements, settings, and implementing all of the RPCs
 	// of the host protocol.
@@ -89,6 +114,12 @@ type (
 		// AnnounceAddress submits an announcement using the given address.
 		AnnounceAddress(NetAddress) error
 
+		// BackupMetadata writes a copy of the host's settings, storage
+		// obligations, and storage folder metadata into a directory, laid out
+		// as in the host's persist directory. The sector data in the storage
+		// folders is not copied.
+		BackupMetadata(dir string) error
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
@@ -100,13 +131,28 @@ type (
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// Listening returns true while the host is accepting renter
+		// connections.
+		Listening() bool
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
+		// PublicKey returns the public key that the host signs its settings
+		// and announcements with.
+		PublicKey() types.SiaPublicKey
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// HostSubscribe adds a subscriber to the host. Subscribers are
+		// notified of the events of the host's storage obligations.
+		HostSubscribe(HostSubscriber)
+
+		// Unsubscribe removes a subscriber from the host.
+		Unsubscribe(HostSubscriber)
+
 		// The storage manager provides an interface for adding and removing
 		// storage folders and data sectors to the host.
 		StorageManager
@@ -0,0 +1,71 @@
+package host
+
+// alerts.go raises the alerts of the host. The host warns the operator when
+// the wallet cannot cover the collateral of new contracts, and when a storage
+// proof is missed, which loses the collateral of the contract.
+
+import (
+	"fmt"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// alertIDLowCollateral is the ID of the alert that is raised while the
+	// wallet balance is too low to pay the collateral of a new contract.
+	alertIDLowCollateral = "host.lowcollateral"
+)
+
+// missedProofAlertID returns the ID of the alert that is raised when the
+// storage proof for a contract is missed.
+func missedProofAlertID(id types.FileContractID) string {
+	return "host.missedproof." + id.String()
+}
+
+// requiredCollateral returns the collateral that the host may need to lock in
+// a single new contract: the maximum collateral of a contract, limited to the
+// part of the collateral budget that is not yet locked.
+func (h *Host) requiredCollateral() types.Currency {
+	required := h.settings.MaxCollateral
+	if h.financialMetrics.LockedStorageCollateral.Cmp(h.settings.CollateralBudget) >= 0 {
+		return types.ZeroCurrency
+	}
+	remaining := h.settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
+	if remaining.Cmp(required) < 0 {
+		required = remaining
+	}
+	return required
+}
+
+// checkCollateralBalance raises an alert if the host is accepting contracts
+// and the wallet does not have enough siacoins to pay the collateral of a new
+// contract, and clears the alert otherwise. The balance of a locked wallet is
+// not known, so the alert is left as it is until the wallet is unlocked.
+func (h *Host) checkCollateralBalance() {
+	if !h.settings.AcceptingContracts {
+		modules.ClearAlert(alertIDLowCollateral)
+		return
+	}
+	if !h.wallet.Unlocked() {
+		return
+	}
+	balance, _, _ := h.wallet.ConfirmedBalance()
+	required := h.requiredCollateral()
+	if balance.Cmp(required) >= 0 {
+		modules.ClearAlert(alertIDLowCollateral)
+		return
+	}
+	modules.RaiseAlert(modules.Alert{
+		ID:       alertIDLowCollateral,
+		Module:   "host",
+		Severity: modules.SeverityWarning,
+		Msg:      lowCollateralMsg(balance, required),
+	})
+}
+
+// lowCollateralMsg returns the message of the alert that is raised when the
+// wallet balance is below the collateral that a new contract may require.
+func lowCollateralMsg(balance, required types.Currency) string {
+	return fmt.Sprintf("wallet balance of %v H is below the %v H of collateral that a new contract may require", balance, required)
+}
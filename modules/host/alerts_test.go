@@ -0,0 +1,77 @@
+package host
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// alertRecorder records the alerts that are raised.
+type alertRecorder struct {
+	raised []modules.Alert
+	mu     sync.Mutex
+}
+
+// ReceiveAlert records an alert that was raised.
+func (ar *alertRecorder) ReceiveAlert(a modules.Alert, active bool) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+	if active {
+		ar.raised = append(ar.raised, a)
+	}
+}
+
+// TestCollateralAlert checks that the host raises an alert while it accepts
+// contracts whose collateral the wallet cannot pay.
+func TestCollateralAlert(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester("TestCollateralAlert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	ar := new(alertRecorder)
+	modules.AlertSubscribe(ar)
+	defer modules.AlertUnsubscribe(ar)
+
+	// Other hosts of the test process raise the same alert, so the alert of
+	// this host is recognized by its message, which contains the required
+	// collateral.
+	balance, _, _ := ht.wallet.ConfirmedBalance()
+	required := balance.Add(types.NewCurrency64(12345))
+	settings := ht.host.InternalSettings()
+	settings.AcceptingContracts = true
+	settings.MaxCollateral = required
+	settings.CollateralBudget = required.Mul64(2)
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	ar.mu.Lock()
+	var alert modules.Alert
+	for _, a := range ar.raised {
+		if a.ID == alertIDLowCollateral && a.Msg == lowCollateralMsg(balance, required) {
+			alert = a
+		}
+	}
+	ar.mu.Unlock()
+	if alert.Severity != modules.SeverityWarning {
+		t.Fatal("no alert for a balance below the collateral of a contract:", ar.raised)
+	}
+
+	// Lowering the collateral resolves the condition.
+	settings.MaxCollateral = balance
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range modules.ActiveAlerts() {
+		if a == alert {
+			t.Fatal("alert was not cleared")
+		}
+	}
+}
@@ -104,6 +104,16 @@ var (
 	// having been closed.
 	errHostClosed = errors.New("call is disabled because the host is closed")
 
+	// errSettingsMaxDuration is returned if the max duration of the host's
+	// settings would leave no time to revise a contract before the revision
+	// submission buffer.
+	errSettingsMaxDuration = errors.New("max duration must be longer than the revision submission buffer")
+
+	// errSettingsWindowSize is returned if the window size of the host's
+	// settings would not leave time to resubmit a storage proof that fails to
+	// confirm.
+	errSettingsWindowSize = errors.New("window size must be longer than the resubmission timeout")
+
 	// Nil dependency errors.
 	errNilCS     = errors.New("host cannot use a nil state")
 	errNilTpool  = errors.New("host cannot use a nil transaction pool")
@@ -124,6 +134,10 @@ type Host struct {
 	atomicSettingsCalls       uint64
 	atomicUnrecognizedCalls   uint64
 
+	// atomicListening is 1 while the host's primary listener is accepting
+	// connections.
+	atomicListening uint64
+
 	// Dependencies.
 	cs     modules.ConsensusSet
 	tpool  modules.TransactionPool
@@ -131,6 +145,13 @@ type Host struct {
 	dependencies
 	modules.StorageManager
 
+	// prioritizer is the local miner, if the node is also mining. The host
+	// asks it to include its storage proofs in every block it mines.
+	prioritizer modules.TransactionPrioritizer
+
+	// subscribers are notified of the events of the storage obligations.
+	subscribers []modules.HostSubscriber
+
 	// Consensus Tracking.
 	blockHeight  types.BlockHeight
 	recentChange modules.ConsensusChangeID
@@ -148,8 +169,13 @@ type Host struct {
 	//
 	// The announced bool indicates whether the host remembers having a
 	// successful announcement with the current address.
+	//
+	// The relay address is the address of the relay that the host is reached
+	// through, if any. While a relay is used, the auto address is the address
+	// that the relay listens on for the host.
 	announced        bool
 	autoAddress      modules.NetAddress
+	relayAddress     modules.NetAddress
 	financialMetrics modules.HostFinancialMetrics
 	publicKey        types.SiaPublicKey
 	revisionNumber   uint64
@@ -162,6 +188,17 @@ type Host struct {
 	// be locked separately.
 	lockedStorageObligations map[types.FileContractID]*siasync.TryMutex
 
+	// Bandwidth limits. globalRateLimit limits the combined bandwidth of all
+	// renter connections and may be shared with other modules, while
+	// connRateLimit is the number of bytes per second allowed for each
+	// connection.
+	globalRateLimit *modules.RateLimit
+	connRateLimit   int64
+
+	// blocklist contains the IP ranges that are not allowed to connect to the
+	// host. It may be shared with other modules.
+	blocklist *modules.Blocklist
+
 	// Utilities.
 	db         *persist.BoltDatabase
 	listener   net.Listener
@@ -172,6 +209,31 @@ type Host struct {
 	tg         siasync.ThreadGroup
 }
 
+// SetTransactionPrioritizer sets the miner that the host asks to include its
+// storage proofs in the blocks that it mines, regardless of their fees. This
+// reduces the chance of the host losing its collateral when it is also mining.
+func (h *Host) SetTransactionPrioritizer(tp modules.TransactionPrioritizer) {
+	lockID := h.mu.Lock()
+	defer h.mu.Unlock(lockID)
+	h.prioritizer = tp
+}
+
+// managedPrioritizeTransactions asks the local miner, if any, to include
+// 'txns' in the blocks that it mines until 'expiration'.
+func (h *Host) managedPrioritizeTransactions(txns []types.Transaction, expiration types.BlockHeight) {
+	lockID := h.mu.RLock()
+	prioritizer := h.prioritizer
+	h.mu.RUnlock(lockID)
+	if prioritizer == nil {
+		return
+	}
+	ids := make([]types.TransactionID, len(txns))
+	for i, txn := range txns {
+		ids[i] = txn.ID()
+	}
+	prioritizer.PrioritizeTransactions(ids, expiration)
+}
+
 // checkUnlockHash will check that the host has an unlock hash. If the host
 // does not have an unlock hash, an attempt will be made to get an unlock hash
 // from the wallet. That may fail due to the wallet being locked, in which case
@@ -323,6 +385,14 @@ func (h *Host) ExternalSettings() modules.HostExternalSettings {
 	return h.externalSettings()
 }
 
+// PublicKey returns the public key of the host, which signs the host's
+// settings and announcements.
+func (h *Host) PublicKey() types.SiaPublicKey {
+	lockID := h.mu.RLock()
+	defer h.mu.RUnlock(lockID)
+	return h.publicKey
+}
+
 // FinancialMetrics returns information about the financial commitments,
 // rewards, and activities of the host.
 func (h *Host) FinancialMetrics() modules.HostFinancialMetrics {
@@ -361,16 +431,35 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 			return errors.New("internal settings not updated, invalid NetAddress: " + err.Error())
 		}
 	}
+	if settings.WindowSize <= resubmissionTimeout {
+		return errors.New("internal settings not updated: " + errSettingsWindowSize.Error())
+	}
+	if settings.MaxDuration <= revisionSubmissionBuffer {
+		return errors.New("internal settings not updated: " + errSettingsMaxDuration.Error())
+	}
 
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
 	// another blockchain announcement.
+	reannounce := false
 	if h.settings.NetAddress != settings.NetAddress && settings.NetAddress != h.autoAddress {
 		h.announced = false
+		reannounce = settings.NetAddress != ""
 	}
 
 	h.settings = settings
 	h.revisionNumber++
+	h.checkCollateralBalance()
+
+	// Announce the new address right away, so that renters do not keep
+	// connecting to the old one. As with a change of the auto address, there
+	// is no need to announce a host that has no use for renters.
+	if reannounce && (settings.AcceptingContracts || h.financialMetrics.ContractCount > 0) {
+		err = h.announce(settings.NetAddress)
+		if err != nil {
+			h.log.Println("WARN: unable to announce the new net address:", err)
+		}
+	}
 
 	err = h.saveSync()
 	if err != nil {
@@ -273,6 +273,31 @@ func TestHostMultiClose(t *testing.T) {
 	}
 }
 
+// TestHostListening checks that the host reports that it is listening until
+// it is closed.
+func TestHostListening(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester("TestHostListening")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	if !ht.host.Listening() {
+		t.Fatal("host is not listening after startup")
+	}
+	err = ht.host.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ht.host.Listening() {
+		t.Fatal("host is listening after being closed")
+	}
+}
+
 // TestNilValues tries initializing the host with nil values.
 func TestNilValues(t *testing.T) {
 	if testing.Short() {
@@ -400,6 +425,49 @@ func TestSetAndGetInternalSettings(t *testing.T) {
 	}
 }
 
+// TestSetInternalSettingsValidation checks that SetInternalSettings rejects
+// window bounds that the host could not honor, and announces a new net
+// address.
+func TestSetInternalSettingsValidation(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester("TestSetInternalSettingsValidation")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	settings := ht.host.InternalSettings()
+	settings.WindowSize = resubmissionTimeout
+	if err := ht.host.SetInternalSettings(settings); err == nil {
+		t.Error("expected an error for a window size that is too small")
+	}
+	settings = ht.host.InternalSettings()
+	settings.MaxDuration = revisionSubmissionBuffer
+	if err := ht.host.SetInternalSettings(settings); err == nil {
+		t.Error("expected an error for a max duration that is too short")
+	}
+	if settings = ht.host.InternalSettings(); settings.WindowSize != defaultWindowSize || settings.MaxDuration != defaultMaxDuration {
+		t.Fatal("invalid settings were applied:", settings)
+	}
+
+	// A host that accepts contracts announces its new net address.
+	settings.AcceptingContracts = true
+	settings.NetAddress = "foo.com:123"
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	lockID := ht.host.mu.RLock()
+	announced := ht.host.announced
+	ht.host.mu.RUnlock(lockID)
+	if !announced {
+		t.Error("host did not announce its new net address")
+	}
+}
+
 /*
 // TestSetAndGetSettings checks that the functions for interacting with the
 // hosts settings object are working as expected.
@@ -71,3 +71,15 @@ func (h *Host) managedRPCSettings(conn net.Conn) error {
 	h.mu.Unlock(lockID)
 	return crypto.WriteSignedObject(conn, hes, secretKey)
 }
+
+// managedRPCQuerySettings is a read-only rpc that returns the host's settings,
+// signed by the host, without advancing their revision number.
+func (h *Host) managedRPCQuerySettings(conn net.Conn) error {
+	conn.SetDeadline(time.Now().Add(modules.NegotiateSettingsTime))
+
+	lockID := h.mu.RLock()
+	secretKey := h.secretKey
+	hes := h.externalSettings()
+	h.mu.RUnlock(lockID)
+	return crypto.WriteSignedObject(conn, hes, secretKey)
+}
@@ -0,0 +1,53 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// TestRPCQuerySettings checks that anyone can query the settings of the host,
+// and that querying them does not advance their revision number.
+func TestRPCQuerySettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester("TestRPCQuerySettings")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	querySettings := func() modules.HostExternalSettings {
+		conn, err := modules.Dial(ht.host.NetAddress(), modules.NegotiateSettingsTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		if err := encoding.WriteObject(conn, modules.RPCQuerySettings); err != nil {
+			t.Fatal(err)
+		}
+		var pk crypto.PublicKey
+		copy(pk[:], ht.host.PublicKey().Key)
+		var settings modules.HostExternalSettings
+		if err := crypto.ReadSignedObject(conn, &settings, modules.NegotiateMaxHostExternalSettingsLen, pk); err != nil {
+			t.Fatal(err)
+		}
+		return settings
+	}
+
+	settings := querySettings()
+	expected := ht.host.ExternalSettings()
+	if settings.StoragePrice.Cmp(expected.StoragePrice) != 0 || settings.MaxDuration != expected.MaxDuration || settings.NetAddress != expected.NetAddress {
+		t.Fatal("queried settings do not match the host's settings:", settings, expected)
+	}
+	if again := querySettings(); again.RevisionNumber != settings.RevisionNumber {
+		t.Fatal("querying the settings advanced their revision number:", settings.RevisionNumber, again.RevisionNumber)
+	}
+	if ht.host.NetworkMetrics().SettingsCalls != 2 {
+		t.Fatal("settings queries were not counted:", ht.host.NetworkMetrics().SettingsCalls)
+	}
+}
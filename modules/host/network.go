@@ -89,7 +89,30 @@ func (h *Host) initNetworking(address string) (err error) {
 	}()
 
 	// Launch the listener.
-	go h.threadedListen(threadedListenerClosedChan)
+	atomic.StoreUint64(&h.atomicListening, 1)
+	go h.threadedListen(h.listener, threadedListenerClosedChan)
+	return nil
+}
+
+// Listen makes the host accept renter connections on an additional address,
+// such as a second interface or port. Only the port of the host's primary
+// listener is forwarded and announced.
+func (h *Host) Listen(address string) error {
+	l, err := h.dependencies.listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	closeChan := make(chan struct{})
+	go h.threadedListen(l, closeChan)
+	// If the host has already been closed, OnStop closes the listener
+	// immediately.
+	h.tg.OnStop(func() {
+		err := l.Close()
+		if err != nil {
+			h.log.Println("WARN: closing the listener failed:", err)
+		}
+		<-closeChan
+	})
 	return nil
 }
 
@@ -114,6 +137,11 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	}
 	defer h.tg.Done()
 
+	// Count the connection's bandwidth. Its purpose is known once the RPC
+	// has been read.
+	bc := modules.NewBandwidthConn(conn, modules.BandwidthOther)
+	conn = bc
+
 	// Set an initial duration that is generous, but finite. RPCs can extend
 	// this if desired.
 	err = conn.SetDeadline(time.Now().Add(5 * time.Minute))
@@ -130,9 +158,30 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		return
 	}
 
+	// If the renter is opening an encrypted session, complete the handshake
+	// and read the RPC specifier from the session.
+	if id == modules.RPCSession {
+		lockID := h.mu.RLock()
+		secretKey := h.secretKey
+		h.mu.RUnlock(lockID)
+		sess, err := modules.NewHostSession(conn, secretKey)
+		if err != nil {
+			atomic.AddUint64(&h.atomicErroredCalls, 1)
+			h.log.Debugf("WARN: incoming conn %v failed to open a session: %v", conn.RemoteAddr(), err)
+			return
+		}
+		conn = sess
+		if err := encoding.ReadObject(conn, &id, 16); err != nil {
+			atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
+			h.log.Debugf("WARN: incoming session %v was malformed: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+
 	switch id {
 	case modules.RPCDownload:
 		atomic.AddUint64(&h.atomicDownloadCalls, 1)
+		bc.SetCategory(modules.BandwidthDownloads)
 		err = h.managedRPCDownload(conn)
 	case modules.RPCRenewContract:
 		atomic.AddUint64(&h.atomicRenewCalls, 1)
@@ -142,6 +191,7 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 		err = h.managedRPCFormContract(conn)
 	case modules.RPCReviseContract:
 		atomic.AddUint64(&h.atomicReviseCalls, 1)
+		bc.SetCategory(modules.BandwidthUploads)
 		err = h.managedRPCReviseContract(conn)
 	case modules.RPCRecentRevision:
 		atomic.AddUint64(&h.atomicRecentRevisionCalls, 1)
@@ -155,6 +205,9 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	case modules.RPCSettings:
 		atomic.AddUint64(&h.atomicSettingsCalls, 1)
 		err = h.managedRPCSettings(conn)
+	case modules.RPCQuerySettings:
+		atomic.AddUint64(&h.atomicSettingsCalls, 1)
+		err = h.managedRPCQuerySettings(conn)
 	case rpcSettingsDeprecated:
 		h.log.Debugln("Received deprecated settings call")
 	default:
@@ -179,20 +232,72 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 }
 
 // listen listens for incoming RPCs and spawns an appropriate handler for each.
-func (h *Host) threadedListen(closeChan chan struct{}) {
+func (h *Host) threadedListen(l net.Listener, closeChan chan struct{}) {
 	defer close(closeChan)
 
 	// Receive connections until an error is returned by the listener. When an
 	// error is returned, there will be no more calls to receive.
 	for {
 		// Block until there is a connection to handle.
-		conn, err := h.listener.Accept()
+		conn, err := l.Accept()
 		if err != nil {
+			if l == h.listener {
+				atomic.StoreUint64(&h.atomicListening, 0)
+			}
 			return
 		}
+		if h.managedIsBlocked(modules.NetAddress(conn.RemoteAddr().String())) {
+			h.log.Debugln("INFO: rejected connection from blocked address", conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
 
-		go h.threadedHandleConn(conn)
+		go h.threadedHandleConn(h.managedLimitConn(conn))
+	}
+}
+
+// managedLimitConn wraps an incoming connection so that it respects the
+// host's rate limits.
+func (h *Host) managedLimitConn(conn net.Conn) net.Conn {
+	lockID := h.mu.RLock()
+	global, perConn := h.globalRateLimit, h.connRateLimit
+	h.mu.RUnlock(lockID)
+	var connLimit *modules.RateLimit
+	if perConn > 0 {
+		connLimit = modules.NewRateLimit(perConn)
 	}
+	return modules.NewRateLimitedConn(conn, connLimit, global)
+}
+
+// SetRateLimits limits the bandwidth used by renters. Each connection is
+// limited to 'perConn' bytes per second, and all connections together are
+// limited by 'global', which may be shared with other modules. A nil 'global'
+// or a zero 'perConn' removes the corresponding limit. The limits apply to
+// connections that are accepted after the call. Calling SetRate on 'global'
+// adjusts every connection sharing it, including existing ones.
+func (h *Host) SetRateLimits(global *modules.RateLimit, perConn int64) {
+	lockID := h.mu.Lock()
+	defer h.mu.Unlock(lockID)
+	h.globalRateLimit = global
+	h.connRateLimit = perConn
+}
+
+// SetBlocklist sets the IP ranges that are not allowed to connect to the host.
+// The Blocklist may be shared with other modules. A nil Blocklist allows every
+// address.
+func (h *Host) SetBlocklist(bl *modules.Blocklist) {
+	lockID := h.mu.Lock()
+	defer h.mu.Unlock(lockID)
+	h.blocklist = bl
+}
+
+// managedIsBlocked returns true if 'addr' is within a range of the host's
+// blocklist.
+func (h *Host) managedIsBlocked(addr modules.NetAddress) bool {
+	lockID := h.mu.RLock()
+	bl := h.blocklist
+	h.mu.RUnlock(lockID)
+	return bl.IsBlocked(addr)
 }
 
 // NetAddress returns the address at which the host can be reached.
@@ -206,6 +311,12 @@ func (h *Host) NetAddress() modules.NetAddress {
 	return h.autoAddress
 }
 
+// Listening returns true while the host's primary listener is accepting
+// renter connections.
+func (h *Host) Listening() bool {
+	return atomic.LoadUint64(&h.atomicListening) == 1
+}
+
 // NetworkMetrics returns information about the types of rpc calls that have
 // been made to the host.
 func (h *Host) NetworkMetrics() modules.HostNetworkMetrics {
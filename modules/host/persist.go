@@ -263,3 +263,31 @@ func (h *Host) save() error {
 func (h *Host) saveSync() error {
 	return persist.SaveFileSync(persistMetadata, h.persistData(), filepath.Join(h.persistDir, settingsFile))
 }
+
+// BackupMetadata writes a copy of the host's settings and storage obligation
+// database into 'dir', followed by the metadata of the storage manager. The
+// host is locked while the copy is written, so that the settings and the
+// storage obligations are consistent with each other.
+func (h *Host) BackupMetadata(dir string) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	lockID := h.mu.RLock()
+	defer h.mu.RUnlock(lockID)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	err := persist.SaveFileSync(persistMetadata, h.persistData(), filepath.Join(dir, settingsFile))
+	if err != nil {
+		return err
+	}
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(filepath.Join(dir, dbFilename), 0600)
+	})
+	if err != nil {
+		return err
+	}
+	return h.StorageManager.BackupMetadata(filepath.Join(dir, modules.StorageManagerDir))
+}
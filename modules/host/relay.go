@@ -0,0 +1,219 @@
+package host
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// relayRetryDelay is how long the host waits between attempts to
+	// register with its relay again after losing the connection to it.
+	relayRetryDelay = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 30 * time.Second
+		case "standard":
+			return 1 * time.Minute
+		case "testing":
+			return 100 * time.Millisecond
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	errRelayInUse = errors.New("host is already using a relay")
+)
+
+// relayedConn is a renter connection that was forwarded by a relay. Its
+// remote address is the address of the renter, rather than of the relay.
+type relayedConn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+// RemoteAddr returns the address of the renter.
+func (rc relayedConn) RemoteAddr() net.Addr {
+	return rc.remoteAddr
+}
+
+// UseRelay makes the host reachable through the relay at 'relay', so that
+// hosts which cannot accept incoming connections, such as hosts behind a
+// strict NAT, can still serve renters. The address that the relay listens on
+// for the host becomes the host's auto address, and is announced unless
+// settings.NetAddress has been set. If the connection to the relay is lost,
+// the host registers with the relay again.
+func (h *Host) UseRelay(relay modules.NetAddress) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	lockID := h.mu.Lock()
+	inUse := h.relayAddress != ""
+	if !inUse {
+		h.relayAddress = relay
+	}
+	h.mu.Unlock(lockID)
+	if inUse {
+		return errRelayInUse
+	}
+
+	control, addr, err := h.managedRegisterRelay(relay)
+	if err != nil {
+		lockID = h.mu.Lock()
+		h.relayAddress = ""
+		h.mu.Unlock(lockID)
+		return err
+	}
+	lockID = h.mu.Lock()
+	h.updateAutoAddress(addr)
+	h.mu.Unlock(lockID)
+	h.log.Printf("INFO: reachable through relay %v at %v", relay, addr)
+
+	go h.threadedServeRelay(relay, control)
+	return nil
+}
+
+// managedRegisterRelay opens a control connection to a relay and registers
+// the host, returning the connection and the address that the relay listens
+// on for the host.
+func (h *Host) managedRegisterRelay(relay modules.NetAddress) (net.Conn, modules.NetAddress, error) {
+	conn, err := modules.Dial(relay, modules.NegotiateSettingsTime)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := h.registerRelay(conn, relay); err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	var port string
+	if err := encoding.ReadObject(conn, &port, modules.MaxRelayPortLength); err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	addr := modules.NetAddress(net.JoinHostPort(relay.Host(), port))
+	if err := addr.IsValid(); err != nil {
+		conn.Close()
+		return nil, "", errors.New("relay sent an invalid port: " + err.Error())
+	}
+	return conn, addr, nil
+}
+
+// registerRelay calls the RelayRegister RPC on a control connection.
+func (h *Host) registerRelay(conn net.Conn, relay modules.NetAddress) error {
+	if err := conn.SetDeadline(time.Now().Add(modules.NegotiateSettingsTime)); err != nil {
+		return err
+	}
+	if err := encoding.WriteObject(conn, modules.RPCRelayRegister); err != nil {
+		return err
+	}
+	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
+		return errors.New("relay " + string(relay) + " rejected the host: " + err.Error())
+	}
+	return nil
+}
+
+// threadedServeRelay joins the renter connections that the relay forwards,
+// registering with the relay again whenever the control connection is lost.
+func (h *Host) threadedServeRelay(relay modules.NetAddress, control net.Conn) {
+	if h.tg.Add() != nil {
+		control.Close()
+		return
+	}
+	defer h.tg.Done()
+
+	for {
+		err := h.managedServeRelay(relay, control)
+		control.Close()
+		select {
+		case <-h.tg.StopChan():
+			return
+		default:
+		}
+		h.log.Printf("WARN: lost connection to relay %v: %v", relay, err)
+
+		for {
+			select {
+			case <-time.After(relayRetryDelay):
+			case <-h.tg.StopChan():
+				return
+			}
+			var addr modules.NetAddress
+			control, addr, err = h.managedRegisterRelay(relay)
+			if err != nil {
+				h.log.Debugf("WARN: failed to register with relay %v: %v", relay, err)
+				continue
+			}
+			lockID := h.mu.Lock()
+			h.updateAutoAddress(addr)
+			h.mu.Unlock(lockID)
+			h.log.Printf("INFO: reachable through relay %v at %v", relay, addr)
+			break
+		}
+	}
+}
+
+// managedServeRelay reads requests from the control connection of a relay,
+// joining each renter connection that the relay forwards. It returns once the
+// control connection fails or the host is stopped.
+func (h *Host) managedServeRelay(relay modules.NetAddress, control net.Conn) error {
+	// Close the control connection when the host is stopped.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-h.tg.StopChan():
+		case <-done:
+		}
+		control.Close()
+	}()
+
+	for {
+		// The relay sends a keepalive when idle, so a relay that is silent
+		// for much longer than the keepalive interval is gone.
+		if err := control.SetReadDeadline(time.Now().Add(3 * modules.RelayKeepaliveInterval)); err != nil {
+			return err
+		}
+		var req modules.RelayRequest
+		if err := encoding.ReadObject(control, &req, modules.MaxRelayRequestSize); err != nil {
+			return err
+		}
+		if req.Token == ([16]byte{}) {
+			continue
+		}
+		if h.managedIsBlocked(req.RemoteAddr) {
+			h.log.Debugln("INFO: rejected relayed connection from blocked address", req.RemoteAddr)
+			continue
+		}
+		go h.threadedJoinRelay(relay, req)
+	}
+}
+
+// threadedJoinRelay joins a renter connection that is waiting at the relay,
+// and handles it like a direct connection.
+func (h *Host) threadedJoinRelay(relay modules.NetAddress, req modules.RelayRequest) {
+	conn, err := modules.Dial(relay, modules.NegotiateSettingsTime)
+	if err != nil {
+		h.log.Debugf("WARN: failed to join relayed connection from %v: %v", req.RemoteAddr, err)
+		return
+	}
+	if err := encoding.WriteObject(conn, modules.RPCRelayJoin); err != nil {
+		conn.Close()
+		return
+	}
+	if err := encoding.WriteObject(conn, req.Token); err != nil {
+		conn.Close()
+		return
+	}
+
+	var remoteAddr net.Addr = conn.RemoteAddr()
+	if addr, err := net.ResolveTCPAddr("tcp", string(req.RemoteAddr)); err == nil {
+		remoteAddr = addr
+	}
+	h.threadedHandleConn(h.managedLimitConn(relayedConn{conn, remoteAddr}))
+}
@@ -0,0 +1,60 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/relay"
+)
+
+// TestUseRelay checks that renters can reach a host through a relay, and that
+// the host advertises the relay's address.
+func TestUseRelay(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester("TestUseRelay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+	r, err := relay.New("localhost:0", build.TempDir(modules.HostDir, "TestUseRelay", modules.RelayDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if err := ht.host.UseRelay(r.Address()); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.UseRelay(r.Address()); err != errRelayInUse {
+		t.Fatal("expected errRelayInUse, got", err)
+	}
+	addr := ht.host.NetAddress()
+	if addr.Host() != r.Address().Host() || addr.Port() == ht.host.port {
+		t.Fatal("host does not advertise the relay's address:", addr)
+	}
+
+	// Request the host's settings through the relay.
+	conn, err := modules.Dial(addr, modules.NegotiateSettingsTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := encoding.WriteObject(conn, modules.RPCSettings); err != nil {
+		t.Fatal(err)
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], ht.host.publicKey.Key)
+	var settings modules.HostExternalSettings
+	if err := crypto.ReadSignedObject(conn, &settings, modules.NegotiateMaxHostExternalSettingsLen, pk); err != nil {
+		t.Fatal(err)
+	}
+	if settings.NetAddress != addr {
+		t.Fatal("host settings have the wrong address:", settings.NetAddress)
+	}
+}
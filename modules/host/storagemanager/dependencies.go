@@ -30,6 +30,10 @@ var (
 type (
 	// dependencies defines all of the dependencies of the StorageManager.
 	dependencies interface {
+		// freeSpace returns the number of bytes available on the filesystem
+		// holding a folder.
+		freeSpace(string) (uint64, error)
+
 		// loadFile allows the host to load a persistence structure form disk.
 		loadFile(persist.Metadata, interface{}, string) error
 
@@ -89,6 +93,12 @@ func composeErrors(errs ...error) error {
 	return errors.New(strings.Join(errStrings, "; "))
 }
 
+// freeSpace returns the number of bytes available on the filesystem holding a
+// folder.
+func (productionDependencies) freeSpace(s string) (uint64, error) {
+	return freeSpace(s)
+}
+
 // loadFile allows the host to load a persistence structure form disk.
 func (productionDependencies) loadFile(m persist.Metadata, i interface{}, s string) error {
 	return persist.LoadFile(m, i, s)
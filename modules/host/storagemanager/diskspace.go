@@ -0,0 +1,41 @@
+package storagemanager
+
+// diskspace.go warns the operator when a disk holding a storage folder runs
+// low on space. Sectors are written to the storage folders as they arrive, so
+// a disk that is shared with other files can fill up before the host has
+// used the capacity that it offered to renters.
+
+import (
+	"fmt"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// diskSpaceAlertID returns the ID of the alert that is raised while the disk
+// holding a storage folder is nearly full.
+func diskSpaceAlertID(sf *storageFolder) string {
+	return "host.diskspace." + sf.uidString()
+}
+
+// checkDiskSpace raises an alert for each storage folder whose disk does not
+// have room for the unused capacity of the folder, and clears the alert of
+// the folders whose disk does. Folders whose free space cannot be determined
+// are skipped.
+func (sm *StorageManager) checkDiskSpace() {
+	for _, sf := range sm.storageFolders {
+		free, err := sm.dependencies.freeSpace(sf.Path)
+		if err != nil {
+			continue
+		}
+		if free >= sf.SizeRemaining {
+			modules.ClearAlert(diskSpaceAlertID(sf))
+			continue
+		}
+		modules.RaiseAlert(modules.Alert{
+			ID:       diskSpaceAlertID(sf),
+			Module:   "host",
+			Severity: modules.SeverityWarning,
+			Msg:      fmt.Sprintf("disk holding storage folder %v has %v bytes free, but the folder has %v bytes of unused capacity", sf.Path, free, sf.SizeRemaining),
+		})
+	}
+}
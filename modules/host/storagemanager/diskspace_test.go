@@ -0,0 +1,94 @@
+package storagemanager
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// smallDisk is a dependency that reports a fixed amount of free disk space.
+type smallDisk struct {
+	free *uint64
+
+	productionDependencies
+}
+
+// freeSpace returns the free space of the small disk.
+func (sd smallDisk) freeSpace(string) (uint64, error) {
+	return *sd.free, nil
+}
+
+// diskSpaceAlert returns the disk space alert of a storage folder, and
+// whether it is active.
+func diskSpaceAlert(sf *storageFolder) (modules.Alert, bool) {
+	for _, a := range modules.ActiveAlerts() {
+		if a.ID == diskSpaceAlertID(sf) {
+			return a, true
+		}
+	}
+	return modules.Alert{}, false
+}
+
+// TestDiskSpaceAlert checks that an alert is raised while the disk of a
+// storage folder does not have room for the unused capacity of the folder.
+func TestDiskSpaceAlert(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	smt, err := newStorageManagerTester("TestDiskSpaceAlert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer smt.Close()
+
+	// Replace the storage manager with one whose disk has room for a single
+	// sector.
+	err = smt.sm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	free := modules.SectorSize
+	smt.sm, err = newStorageManager(smallDisk{free: &free}, filepath.Join(smt.persistDir, modules.StorageManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = smt.addRandFolder(minimumStorageFolderSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sf := smt.sm.storageFolders[0]
+	if a, ok := diskSpaceAlert(sf); !ok || a.Severity != modules.SeverityWarning {
+		t.Fatal("no alert for a full disk:", a)
+	}
+
+	// The alert is cleared once the disk has room for the folder.
+	free = minimumStorageFolderSize
+	sectorRoot, sectorData, err := createSector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = smt.sm.AddSector(sectorRoot, 10, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := diskSpaceAlert(sf); ok {
+		t.Fatal("alert was not cleared")
+	}
+
+	// Removing the folder clears its alert.
+	free = 0
+	smt.sm.mu.Lock()
+	smt.sm.checkDiskSpace()
+	smt.sm.mu.Unlock()
+	if _, ok := diskSpaceAlert(sf); !ok {
+		t.Fatal("no alert for a full disk")
+	}
+	err = smt.sm.RemoveStorageFolder(0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := diskSpaceAlert(sf); ok {
+		t.Fatal("alert of a removed folder was not cleared")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+// +build windows
+
+package storagemanager
+
+import (
+	"errors"
+)
+
+// errFreeSpaceUnsupported is returned by freeSpace on Windows, where the free
+// space of a disk is not available from the syscall package.
+var errFreeSpaceUnsupported = errors.New("free disk space cannot be determined on windows")
+
+// freeSpace returns the number of bytes available to unprivileged users on
+// the filesystem holding 'path'. It is not supported on Windows.
+func freeSpace(path string) (uint64, error) {
+	return 0, errFreeSpaceUnsupported
+}
@@ -83,3 +83,26 @@ func (sm *StorageManager) save() error {
 func (sm *StorageManager) saveSync() error {
 	return persist.SaveFileSync(persistMetadata, sm.persistData(), filepath.Join(sm.persistDir, settingsFile))
 }
+
+// BackupMetadata writes a copy of the persistent data and the database of the
+// storage manager into 'dir'.
+func (sm *StorageManager) BackupMetadata(dir string) error {
+	sm.resourceLock.RLock()
+	defer sm.resourceLock.RUnlock()
+	if sm.closed {
+		return errStorageManagerClosed
+	}
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	err := persist.SaveFileSync(persistMetadata, sm.persistData(), filepath.Join(dir, settingsFile))
+	if err != nil {
+		return err
+	}
+	return sm.db.View(func(tx *bolt.Tx) error {
+		return tx.CopyFile(filepath.Join(dir, dbFilename), 0600)
+	})
+}
@@ -232,6 +232,7 @@ func (sm *StorageManager) AddSector(sectorRoot crypto.Hash, expiryHeight types.B
 	if err != nil {
 		return err
 	}
+	sm.checkDiskSpace()
 	return sm.save()
 }
 
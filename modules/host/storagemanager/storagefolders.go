@@ -80,12 +80,12 @@ var (
 	// errInsufficientRemainingStorageForRemoval is returned if the remaining
 	// storage folders do not have enough space remaining to support being
 	// removed.
-	errInsufficientRemainingStorageForRemoval = errors.New("not enough storage remaining to support removal of disk")
+	errInsufficientRemainingStorageForRemoval = modules.ErrInsufficientStorageForRemoval
 
 	// errInsufficientRemainingStorageForShrink is returned if the remaining
 	// storage folders do not have enough space remaining to support being
 	// reduced in size.
-	errInsufficientRemainingStorageForShrink = errors.New("not enough storage remaining to support shrinking of disk")
+	errInsufficientRemainingStorageForShrink = modules.ErrInsufficientStorageForShrink
 
 	// errLargeStorageFolder is returned if a new storage folder or a resized
 	// storage folder would exceed the maximum allowed size.
@@ -391,6 +391,7 @@ func (sm *StorageManager) AddStorageFolder(path string, size uint64) error {
 	if sm.closed {
 		return errStorageManagerClosed
 	}
+	defer sm.checkDiskSpace()
 
 	// Check that the maximum number of allowed storage folders has not been
 	// exceeded.
@@ -523,6 +524,7 @@ func (sm *StorageManager) RemoveStorageFolder(removalIndex int, force bool) erro
 
 	// Remove the storage folder from the host and then save the host.
 	sm.storageFolders = append(sm.storageFolders[0:removalIndex], sm.storageFolders[removalIndex+1:]...)
+	modules.ClearAlert(diskSpaceAlertID(removalFolder))
 	removeErr := sm.dependencies.removeFile(filepath.Join(sm.persistDir, removalFolder.uidString()))
 	saveErr := sm.saveSync()
 	return composeErrors(saveErr, removeErr)
@@ -542,6 +544,7 @@ func (sm *StorageManager) ResizeStorageFolder(storageFolderIndex int, newSize ui
 	if sm.closed {
 		return errStorageManagerClosed
 	}
+	defer sm.checkDiskSpace()
 
 	// Check that the inputs are valid.
 	if storageFolderIndex >= len(sm.storageFolders) || storageFolderIndex < 0 {
@@ -150,6 +150,7 @@ func newStorageManager(dependencies dependencies, persistDir string) (*StorageMa
 		_ = sm.db.Close()
 		return nil, err
 	}
+	sm.checkDiskSpace()
 	return sm, nil
 }
 
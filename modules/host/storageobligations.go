@@ -31,6 +31,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/NebulousLabs/Sia/build"
@@ -396,6 +397,11 @@ func (h *Host) addStorageObligation(so storageObligation) error {
 		h.log.Println("Error with transaction set, redacting obligation, id", so.id())
 		return composeErrors(err, h.removeStorageObligation(so, obligationRejected))
 	}
+	h.updateSubscribers(modules.HostEvent{
+		Type:       modules.HostEventContractAccepted,
+		ContractID: soid,
+		Height:     h.blockHeight,
+	})
 	return nil
 }
 
@@ -670,7 +676,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID, wg *sync.Wait
 			// be confirmed, and the origin transaction may be confirmed, which
 			// would confuse the revenue stuff a bit. Might happen frequently
 			// due to the dynamic fee pool.
-			h.log.Println("Full time has elapsed, but the revision transaction could not be submitted to consensus, id", so.id())
+			h.log.Warnln("Full time has elapsed, but the revision transaction could not be submitted to consensus, id", so.id())
 			lockID := h.mu.Lock()
 			h.removeStorageObligation(so, obligationRejected)
 			h.mu.Unlock(lockID)
@@ -725,10 +731,28 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID, wg *sync.Wait
 	if !so.ProofConfirmed && blockHeight >= so.expiration()+resubmissionTimeout {
 		h.log.Debugln("Host is attempting a storage proof for", so.id())
 
+		// An obligation without any sectors does not need a storage proof,
+		// so it is removed without reporting a missed proof.
+		if len(so.SectorRoots) == 0 {
+			h.log.Debugln("No sectors stored, storage proof not needed for", so.id())
+			lockID := h.mu.Lock()
+			err := h.removeStorageObligation(so, obligationFailed)
+			h.mu.Unlock(lockID)
+			if err != nil {
+				h.log.Println("Error removing storage obligation:", err)
+			}
+			return
+		}
 		// If the window has closed, the host has failed and the obligation can
 		// be removed.
-		if so.proofDeadline() < blockHeight || len(so.SectorRoots) == 0 {
-			h.log.Debugln("storage proof not confirmed by deadline, id", so.id())
+		if so.proofDeadline() < blockHeight {
+			h.log.Errorln("Missed storage proof, not confirmed by deadline, id", so.id())
+			modules.RaiseAlert(modules.Alert{
+				ID:       missedProofAlertID(so.id()),
+				Module:   "host",
+				Severity: modules.SeverityCritical,
+				Msg:      fmt.Sprintf("missed the storage proof for contract %v, losing %v H of collateral", so.id(), so.RiskedCollateral),
+			})
 			lockID := h.mu.Lock()
 			err := h.removeStorageObligation(so, obligationFailed)
 			h.mu.Unlock(lockID)
@@ -742,7 +766,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID, wg *sync.Wait
 		// the segment.
 		segmentIndex, err := h.cs.StorageProofSegment(so.id())
 		if err != nil {
-			h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
+			h.log.Errorln("Host got an error when fetching a storage proof segment:", err)
 			return
 		}
 		sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
@@ -750,7 +774,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID, wg *sync.Wait
 		sectorRoot := so.SectorRoots[sectorIndex]
 		sectorBytes, err := h.ReadSector(sectorRoot)
 		if err != nil {
-			h.log.Debugln(err)
+			h.log.Errorln("Host unable to read the sector for a storage proof:", err)
 			return
 		}
 
@@ -781,26 +805,27 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID, wg *sync.Wait
 		if so.value().Cmp(feeRecommendation) < 0 {
 			// There's no sense submitting the storage proof if the fee is more
 			// than the anticipated revenue.
-			h.log.Debugln("Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost")
+			h.log.Warnln("Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost, id", so.id())
 			return
 		}
 		txnSize := uint64(len(encoding.Marshal(sp)) + 300)
 		requiredFee := feeRecommendation.Mul64(txnSize)
 		err = builder.FundSiacoins(requiredFee)
 		if err != nil {
-			h.log.Println("Host error when funding a storage proof transaction fee:", err)
+			h.log.Errorln("Host error when funding a storage proof transaction fee:", err)
 			return
 		}
 		builder.AddMinerFee(requiredFee)
 		builder.AddStorageProof(sp)
 		storageProofSet, err := builder.Sign(true)
 		if err != nil {
-			h.log.Println("Host error when signing the storage proof transaction:", err)
+			h.log.Errorln("Host error when signing the storage proof transaction:", err)
 			return
 		}
+		h.managedPrioritizeTransactions(storageProofSet, so.proofDeadline())
 		err = h.tpool.AcceptTransactionSet(storageProofSet)
 		if err != nil {
-			h.log.Println("Host unable to submit storage proof transaction to transaction pool:", err)
+			h.log.Errorln("Host unable to submit storage proof transaction to transaction pool:", err)
 			return
 		}
 		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
@@ -808,6 +833,11 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID, wg *sync.Wait
 		// Queue another action item to check whether there the storage proof
 		// got confirmed.
 		lockID := h.mu.Lock()
+		h.updateSubscribers(modules.HostEvent{
+			Type:       modules.HostEventProofSubmitted,
+			ContractID: so.id(),
+			Height:     h.blockHeight,
+		})
 		err = h.queueActionItem(so.proofDeadline(), so.id())
 		h.mu.Unlock(lockID)
 		if err != nil {
@@ -0,0 +1,36 @@
+package host
+
+import (
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// updateSubscribers sends an event to all subscribers. The host must be
+// locked.
+func (h *Host) updateSubscribers(he modules.HostEvent) {
+	for _, subscriber := range h.subscribers {
+		subscriber.ReceiveHostEvent(he)
+	}
+}
+
+// HostSubscribe adds a subscriber to the host. Subscribers will receive every
+// event of the host's storage obligations from now on.
+func (h *Host) HostSubscribe(subscriber modules.HostSubscriber) {
+	lockID := h.mu.Lock()
+	defer h.mu.Unlock(lockID)
+	h.subscribers = append(h.subscribers, subscriber)
+}
+
+// Unsubscribe removes a subscriber from the host. If the subscriber is not in
+// h.subscribers, Unsubscribe does nothing. If the subscriber occurs more than
+// once in h.subscribers, only the earliest occurrence is removed.
+func (h *Host) Unsubscribe(subscriber modules.HostSubscriber) {
+	lockID := h.mu.Lock()
+	defer h.mu.Unlock(lockID)
+
+	for i := range h.subscribers {
+		if h.subscribers[i] == subscriber {
+			h.subscribers = append(h.subscribers[0:i], h.subscribers[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// hostEventSubscriber records the events of the host.
+type hostEventSubscriber struct {
+	events []modules.HostEvent
+}
+
+// ReceiveHostEvent records an event.
+func (hes *hostEventSubscriber) ReceiveHostEvent(he modules.HostEvent) {
+	hes.events = append(hes.events, he)
+}
+
+// TestHostSubscribe checks that subscribers are notified of the events of the
+// host until they unsubscribe.
+func TestHostSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	ht, err := blankHostTester("TestHostSubscribe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	hes := new(hostEventSubscriber)
+	ht.host.HostSubscribe(hes)
+	he := modules.HostEvent{
+		Type:       modules.HostEventContractAccepted,
+		ContractID: types.FileContractID{1},
+		Height:     ht.host.blockHeight,
+	}
+	lockID := ht.host.mu.Lock()
+	ht.host.updateSubscribers(he)
+	ht.host.mu.Unlock(lockID)
+	if len(hes.events) != 1 || hes.events[0] != he {
+		t.Fatal("subscriber was not notified:", hes.events)
+	}
+
+	ht.host.Unsubscribe(hes)
+	lockID = ht.host.mu.Lock()
+	ht.host.updateSubscribers(he)
+	ht.host.mu.Unlock(lockID)
+	if len(hes.events) != 1 {
+		t.Fatal("subscriber was notified after unsubscribing")
+	}
+}
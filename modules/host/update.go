@@ -326,6 +326,10 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// change.
 	h.recentChange = cc.ID
 
+	// The wallet balance may have changed, so check that it still covers
+	// the collateral of new contracts.
+	h.checkCollateralBalance()
+
 	// Save the host.
 	err = h.save()
 	if err != nil {
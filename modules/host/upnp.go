@@ -50,10 +50,12 @@ func (h *Host) managedLearnHostname() {
 	}
 	lockID := h.mu.RLock()
 	netAddr := h.settings.NetAddress
+	relayAddr := h.relayAddress
 	h.mu.RUnlock(lockID)
-	// If the settings indicate that an address has been manually set, there is
-	// no reason to learn the hostname.
-	if netAddr != "" {
+	// If the settings indicate that an address has been manually set, or if
+	// the host is reached through a relay, there is no reason to learn the
+	// hostname.
+	if netAddr != "" || relayAddr != "" {
 		return
 	}
 
@@ -78,6 +80,12 @@ func (h *Host) managedLearnHostname() {
 		h.log.Printf("WARN: discovered hostname %q is invalid: %v", autoAddress, err)
 		return
 	}
+	h.updateAutoAddress(autoAddress)
+}
+
+// updateAutoAddress sets the auto address of the host, announcing the new
+// address if it has changed.
+func (h *Host) updateAutoAddress(autoAddress modules.NetAddress) {
 	if autoAddress == h.autoAddress && h.announced {
 		// Nothing to do - the auto address has not changed and the previous
 		// annoucement was successful.
@@ -85,7 +93,7 @@ func (h *Host) managedLearnHostname() {
 	}
 
 	h.autoAddress = autoAddress
-	err = h.save()
+	err := h.save()
 	if err != nil {
 		h.log.Println(err)
 	}
@@ -100,7 +108,7 @@ func (h *Host) managedLearnHostname() {
 			// Set h.announced to false, as the address has changed yet the
 			// renewed annoucement has failed.
 			h.announced = false
-			h.log.Debugln("unable to announce address after upnp-detected address change:", err)
+			h.log.Debugln("unable to announce address after auto address change:", err)
 		}
 	}
 }
@@ -0,0 +1,114 @@
+package modules
+
+import (
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// LightClientDir is the name of the directory that is typically used for
+	// the light client.
+	LightClientDir = "lightclient"
+)
+
+type (
+	// A TransactionFilter selects the transactions that are relevant to a
+	// light client. A transaction is relevant if it spends from or sends to
+	// one of the unlock hashes, or if it creates, revises, or proves one of
+	// the file contracts.
+	TransactionFilter struct {
+		UnlockHashes    []types.UnlockHash     `json:"unlockhashes"`
+		FileContractIDs []types.FileContractID `json:"filecontractids"`
+	}
+
+	// A LightClient tracks the headers of the blockchain and the transactions
+	// that are relevant to it, without downloading or validating full blocks.
+	// The relevant transactions are proven to be part of the blockchain using
+	// Merkle proofs against the block headers.
+	LightClient interface {
+		// CurrentHeader returns the header of the most recent block known to
+		// the light client.
+		CurrentHeader() types.BlockHeader
+
+		// Height returns the height of the most recent block known to the
+		// light client.
+		Height() types.BlockHeight
+
+		// Synchronize downloads headers and transaction proofs from a peer,
+		// switching to the peer's chain if it is heavier.
+		Synchronize(NetAddress) error
+
+		// TrackContract adds a file contract to the set of relevant objects.
+		// Only transactions in blocks that are downloaded afterwards will be
+		// found.
+		TrackContract(types.FileContractID) error
+
+		// TrackUnlockHash adds an address to the set of relevant objects.
+		// Only transactions in blocks that are downloaded afterwards will be
+		// found.
+		TrackUnlockHash(types.UnlockHash) error
+
+		// Transactions returns the proven transactions in the current chain
+		// that are relevant to the light client.
+		Transactions() []types.TransactionProof
+
+		// Close safely shuts down the light client.
+		Close() error
+	}
+)
+
+// Match returns true if the transaction is relevant according to the filter.
+func (tf TransactionFilter) Match(txn types.Transaction) bool {
+	hasUnlockHash := func(uh types.UnlockHash) bool {
+		for _, filterUH := range tf.UnlockHashes {
+			if uh == filterUH {
+				return true
+			}
+		}
+		return false
+	}
+	hasContract := func(id types.FileContractID) bool {
+		for _, filterID := range tf.FileContractIDs {
+			if id == filterID {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, sci := range txn.SiacoinInputs {
+		if hasUnlockHash(sci.UnlockConditions.UnlockHash()) {
+			return true
+		}
+	}
+	for _, sco := range txn.SiacoinOutputs {
+		if hasUnlockHash(sco.UnlockHash) {
+			return true
+		}
+	}
+	for i := range txn.FileContracts {
+		if hasContract(txn.FileContractID(uint64(i))) {
+			return true
+		}
+	}
+	for _, fcr := range txn.FileContractRevisions {
+		if hasContract(fcr.ParentID) {
+			return true
+		}
+	}
+	for _, sp := range txn.StorageProofs {
+		if hasContract(sp.ParentID) {
+			return true
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if hasUnlockHash(sfi.UnlockConditions.UnlockHash()) {
+			return true
+		}
+	}
+	for _, sfo := range txn.SiafundOutputs {
+		if hasUnlockHash(sfo.UnlockHash) {
+			return true
+		}
+	}
+	return false
+}
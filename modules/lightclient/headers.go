@@ -0,0 +1,104 @@
+package lightclient
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errEarlyTimestamp  = errors.New("header timestamp is too early")
+	errFutureTimestamp = errors.New("header timestamp is too far in the future")
+	errOrphanHeader    = errors.New("header does not build on the previous header")
+	errUnsolvedHeader  = errors.New("header does not meet the target")
+)
+
+// childTarget computes the target of the children of the last header in
+// 'headers'. 'targets' must contain the child targets of every header except
+// for the last one.
+func childTarget(dc consensus.DifficultyConfig, headers []types.BlockHeader, targets []types.Target) types.Target {
+	height := types.BlockHeight(len(headers) - 1)
+	if height == 0 {
+		return dc.RootTarget
+	}
+	return dc.ChildTarget(targets[height-1], height, headers[height].Timestamp, func(depth types.BlockHeight) types.Timestamp {
+		return headers[height-depth].Timestamp
+	})
+}
+
+// minimumValidChildTimestamp returns the earliest timestamp that the child of
+// the last header in 'headers' can have, which is the median timestamp of the
+// previous MedianTimestampWindow headers.
+func minimumValidChildTimestamp(headers []types.BlockHeader) types.Timestamp {
+	windowTimes := make(types.TimestampSlice, types.MedianTimestampWindow)
+	height := len(headers) - 1
+	for i := range windowTimes {
+		if height-i >= 0 {
+			windowTimes[i] = headers[height-i].Timestamp
+		} else {
+			windowTimes[i] = headers[0].Timestamp
+		}
+	}
+	sort.Sort(windowTimes)
+	return windowTimes[len(windowTimes)/2]
+}
+
+// appendHeader validates a header against the chain formed by 'headers' and
+// 'targets', and returns the chain extended by the header.
+func appendHeader(dc consensus.DifficultyConfig, headers []types.BlockHeader, targets []types.Target, h types.BlockHeader) ([]types.BlockHeader, []types.Target, error) {
+	parent := headers[len(headers)-1]
+	if h.ParentID != parent.ID() {
+		return nil, nil, errOrphanHeader
+	}
+	id := h.ID()
+	target := dc.BlockTarget(targets[len(targets)-1], parent.Timestamp, h.Timestamp)
+	if bytes.Compare(target[:], id[:]) < 0 {
+		return nil, nil, errUnsolvedHeader
+	}
+	if h.Timestamp < minimumValidChildTimestamp(headers) {
+		return nil, nil, errEarlyTimestamp
+	}
+	if h.Timestamp > types.CurrentTimestamp()+types.FutureThreshold {
+		return nil, nil, errFutureTimestamp
+	}
+
+	headers = append(headers, h)
+	targets = append(targets, childTarget(dc, headers, targets))
+	return headers, targets, nil
+}
+
+// chainWork returns the total difficulty of the headers above 'forkHeight' in
+// the chain described by 'headers' and 'targets'. Each header adds the
+// difficulty of the target that it had to meet.
+func chainWork(dc consensus.DifficultyConfig, headers []types.BlockHeader, targets []types.Target, forkHeight types.BlockHeight) types.Currency {
+	var work types.Currency
+	for i := int(forkHeight); i < len(headers)-1; i++ {
+		target := dc.BlockTarget(targets[i], headers[i].Timestamp, headers[i+1].Timestamp)
+		work = work.Add(target.Difficulty())
+	}
+	return work
+}
+
+// blockHistory returns up to 32 block ids from the chain, starting with
+// recent blocks and then proving exponentially increasingly less recent
+// blocks, with the genesis block as the final element. It matches the block
+// history used by the consensus set.
+func blockHistory(headers []types.BlockHeader) (blockIDs [32]types.BlockID) {
+	height := types.BlockHeight(len(headers) - 1)
+	step := types.BlockHeight(1)
+	for i := 0; i < 31; i++ {
+		blockIDs[i] = headers[height].ID()
+		if i >= 9 {
+			step *= 2
+		}
+		if height <= step {
+			break
+		}
+		height -= step
+	}
+	blockIDs[31] = headers[0].ID()
+	return blockIDs
+}
@@ -0,0 +1,148 @@
+// Package lightclient implements a light client that tracks only the headers
+// of the blockchain, along with Merkle proofs for the transactions that are
+// relevant to it. This allows wallets and renters on constrained devices to
+// use the network without downloading and validating the full blockchain.
+//
+// A light client verifies the proof of work, the difficulty adjustments, and
+// the timestamps of every header, and follows the heaviest chain. It cannot
+// verify that the transactions in a block are valid, and it cannot detect a
+// peer that withholds relevant transactions. Light clients should therefore
+// synchronize with multiple peers.
+package lightclient
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/persist"
+	siasync "github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errNilGateway = errors.New("cannot have a nil gateway as input")
+)
+
+// A LightClient tracks block headers and relevant transaction proofs.
+type LightClient struct {
+	gateway modules.Gateway
+
+	// difficulty contains the difficulty adjustment rules of the network,
+	// which are shared with the consensus set.
+	difficulty consensus.DifficultyConfig
+
+	// headers contains the headers of the current chain, indexed by height.
+	// childTargets contains the target that the child of each header must
+	// meet. headerHeights maps the id of each header to its height.
+	headers       []types.BlockHeader
+	childTargets  []types.Target
+	headerHeights map[types.BlockID]types.BlockHeight
+
+	// filter selects the relevant transactions, and proofs contains the
+	// proofs for the relevant transactions in the current chain.
+	filter modules.TransactionFilter
+	proofs []types.TransactionProof
+
+	log        *persist.Logger
+	mu         sync.RWMutex
+	persistDir string
+	syncMu     sync.Mutex
+	tg         siasync.ThreadGroup
+}
+
+// New returns a light client that starts at the genesis block, or at the
+// chain stored in the persist directory if it exists. The light client
+// synchronizes with its outbound peers in the background.
+func New(g modules.Gateway, persistDir string) (*LightClient, error) {
+	if g == nil {
+		return nil, errNilGateway
+	}
+	lc := &LightClient{
+		gateway:    g,
+		difficulty: consensus.DefaultDifficultyConfig(),
+		persistDir: persistDir,
+	}
+	lc.resetChain()
+	err := lc.initPersist()
+	if err != nil {
+		return nil, err
+	}
+	go lc.threadedSynchronize()
+	return lc, nil
+}
+
+// resetChain sets the chain of the light client to only the genesis block.
+func (lc *LightClient) resetChain() {
+	genesis := types.GenesisBlock.Header()
+	lc.headers = []types.BlockHeader{genesis}
+	lc.childTargets = []types.Target{lc.difficulty.RootTarget}
+	lc.headerHeights = map[types.BlockID]types.BlockHeight{
+		genesis.ID(): 0,
+	}
+}
+
+// Close saves the state of the light client and shuts it down.
+func (lc *LightClient) Close() error {
+	if err := lc.tg.Stop(); err != nil {
+		return err
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	err := lc.saveSync()
+	if err != nil {
+		lc.log.Println("ERROR: unable to save light client:", err)
+	}
+	return lc.log.Close()
+}
+
+// CurrentHeader returns the header of the most recent block known to the
+// light client.
+func (lc *LightClient) CurrentHeader() types.BlockHeader {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.headers[len(lc.headers)-1]
+}
+
+// Height returns the height of the most recent block known to the light
+// client.
+func (lc *LightClient) Height() types.BlockHeight {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return types.BlockHeight(len(lc.headers) - 1)
+}
+
+// TrackContract adds a file contract to the set of relevant objects.
+func (lc *LightClient) TrackContract(id types.FileContractID) error {
+	if err := lc.tg.Add(); err != nil {
+		return err
+	}
+	defer lc.tg.Done()
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.filter.FileContractIDs = append(lc.filter.FileContractIDs, id)
+	return lc.save()
+}
+
+// TrackUnlockHash adds an address to the set of relevant objects.
+func (lc *LightClient) TrackUnlockHash(uh types.UnlockHash) error {
+	if err := lc.tg.Add(); err != nil {
+		return err
+	}
+	defer lc.tg.Done()
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.filter.UnlockHashes = append(lc.filter.UnlockHashes, uh)
+	return lc.save()
+}
+
+// Transactions returns the proven transactions in the current chain that are
+// relevant to the light client.
+func (lc *LightClient) Transactions() []types.TransactionProof {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	proofs := make([]types.TransactionProof, len(lc.proofs))
+	copy(proofs, lc.proofs)
+	return proofs
+}
@@ -0,0 +1,301 @@
+package lightclient
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/modules/miner"
+	"github.com/NebulousLabs/Sia/modules/transactionpool"
+	"github.com/NebulousLabs/Sia/modules/wallet"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// lightClientTester contains a full node and a light client that is connected
+// to the full node.
+type lightClientTester struct {
+	cs      modules.ConsensusSet
+	gateway modules.Gateway
+	miner   modules.TestMiner
+	wallet  modules.Wallet
+
+	lcGateway   modules.Gateway
+	lightClient *LightClient
+	testdir     string
+}
+
+// Close shuts down the light client tester.
+func (lct *lightClientTester) Close() error {
+	lct.lightClient.Close()
+	lct.lcGateway.Close()
+	lct.cs.Close()
+	return lct.gateway.Close()
+}
+
+// createLightClientTester creates a full node with a funded wallet and a
+// light client connected to it.
+func createLightClientTester(name string) (*lightClientTester, error) {
+	testdir := build.TempDir(modules.LightClientDir, name)
+	g, err := gateway.New("localhost:0", filepath.Join(testdir, modules.GatewayDir))
+	if err != nil {
+		return nil, err
+	}
+	cs, err := consensus.New(g, filepath.Join(testdir, modules.ConsensusDir))
+	if err != nil {
+		return nil, err
+	}
+	tp, err := transactionpool.New(cs, g, filepath.Join(testdir, modules.TransactionPoolDir))
+	if err != nil {
+		return nil, err
+	}
+	w, err := wallet.New(cs, tp, filepath.Join(testdir, modules.WalletDir))
+	if err != nil {
+		return nil, err
+	}
+	key, err := crypto.GenerateTwofishKey()
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Encrypt(key)
+	if err != nil {
+		return nil, err
+	}
+	err = w.Unlock(key)
+	if err != nil {
+		return nil, err
+	}
+	m, err := miner.New(cs, tp, w, filepath.Join(testdir, modules.MinerDir))
+	if err != nil {
+		return nil, err
+	}
+	for i := types.BlockHeight(0); i <= types.MaturityDelay; i++ {
+		_, err := m.AddBlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lcg, err := gateway.New("localhost:0", filepath.Join(testdir, "light", modules.GatewayDir))
+	if err != nil {
+		return nil, err
+	}
+	err = lcg.Connect(g.Address())
+	if err != nil {
+		return nil, err
+	}
+	lc, err := New(lcg, filepath.Join(testdir, "light", modules.LightClientDir))
+	if err != nil {
+		return nil, err
+	}
+	return &lightClientTester{
+		cs:      cs,
+		gateway: g,
+		miner:   m,
+		wallet:  w,
+
+		lcGateway:   lcg,
+		lightClient: lc,
+		testdir:     testdir,
+	}, nil
+}
+
+// TestSynchronize checks that the light client follows the chain of a full
+// node and receives proofs for the relevant transactions.
+func TestSynchronize(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	lct, err := createLightClientTester("TestSynchronize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lct.Close()
+
+	// Track an address and send coins to it.
+	addr := types.UnlockHash{1, 2, 3}
+	err = lct.lightClient.TrackUnlockHash(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txns, err := lct.wallet.SendSiacoins(types.NewCurrency64(1e6), addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Mine past a difficulty adjustment so that the target computations of
+	// the light client are checked.
+	for i := types.BlockHeight(0); i < types.TargetWindow/2+5; i++ {
+		_, err := lct.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// There are more than 'maxSyncHeaders' headers, so the light client
+	// catches up over multiple calls.
+	for i := 0; i < 3 && lct.lightClient.Height() < lct.cs.Height(); i++ {
+		err = lct.lightClient.Synchronize(lct.gateway.Address())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && lct.lightClient.Height() != types.BlockHeight(maxSyncHeaders) {
+			t.Fatalf("expected height %v after the first sync, got %v", maxSyncHeaders, lct.lightClient.Height())
+		}
+	}
+	if lct.lightClient.Height() != lct.cs.Height() {
+		t.Fatalf("light client height is %v, full node height is %v", lct.lightClient.Height(), lct.cs.Height())
+	}
+	if lct.lightClient.CurrentHeader().ID() != lct.cs.CurrentBlock().ID() {
+		t.Error("light client is on a different block than the full node")
+	}
+	for i, h := range lct.lightClient.headers {
+		target, _ := lct.cs.ChildTarget(h.ID())
+		if target != lct.lightClient.childTargets[i] {
+			t.Fatal("child target mismatch at height", i)
+		}
+	}
+
+	// The transaction sending coins to the tracked address should be proven.
+	proofs := lct.lightClient.Transactions()
+	if len(proofs) != 1 || proofs[0].Transaction.ID() != txns[len(txns)-1].ID() {
+		t.Fatal("light client did not receive a proof for the relevant transaction")
+	}
+
+	// Synchronizing again should not change anything.
+	err = lct.lightClient.Synchronize(lct.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lct.lightClient.Transactions()) != 1 {
+		t.Error("proofs changed after a redundant synchronization")
+	}
+
+	// The chain and proofs should survive a restart.
+	height := lct.lightClient.Height()
+	err = lct.lightClient.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lct.lightClient, err = New(lct.lcGateway, filepath.Join(lct.testdir, "light", modules.LightClientDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lct.lightClient.Height() != height {
+		t.Error("light client lost headers after restarting")
+	}
+	if len(lct.lightClient.Transactions()) != 1 {
+		t.Error("light client lost proofs after restarting")
+	}
+}
+
+// TestAppendHeader probes the header validation of the light client.
+func TestAppendHeader(t *testing.T) {
+	dc := consensus.DefaultDifficultyConfig()
+	genesis := types.GenesisBlock.Header()
+	headers := []types.BlockHeader{genesis}
+	targets := []types.Target{types.RootTarget}
+
+	// Solve a child of the genesis block.
+	child := types.BlockHeader{
+		ParentID:  genesis.ID(),
+		Timestamp: types.CurrentTimestamp(),
+	}
+	for {
+		id := child.ID()
+		if types.RootTarget.Cmp(types.Target(id)) >= 0 {
+			break
+		}
+		child.Nonce[0]++
+	}
+	_, _, err := appendHeader(dc, headers, targets, child)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orphan := child
+	orphan.ParentID = types.BlockID{1}
+	if _, _, err := appendHeader(dc, headers, targets, orphan); err != errOrphanHeader {
+		t.Error("expected errOrphanHeader, got", err)
+	}
+	future := child
+	future.Timestamp += 2 * types.FutureThreshold
+	for {
+		id := future.ID()
+		if types.RootTarget.Cmp(types.Target(id)) >= 0 {
+			break
+		}
+		future.Nonce[0]++
+	}
+	if _, _, err := appendHeader(dc, headers, targets, future); err != errFutureTimestamp {
+		t.Error("expected errFutureTimestamp, got", err)
+	}
+	unsolved := child
+	for {
+		id := unsolved.ID()
+		if types.RootTarget.Cmp(types.Target(id)) < 0 {
+			break
+		}
+		unsolved.Nonce[1]++
+	}
+	if _, _, err := appendHeader(dc, headers, targets, unsolved); err != errUnsolvedHeader {
+		t.Error("expected errUnsolvedHeader, got", err)
+	}
+}
+
+// TestAppendMinDifficultyHeader checks that the light client follows the
+// minimum difficulty rule of the testnet in the same way as the consensus set.
+func TestAppendMinDifficultyHeader(t *testing.T) {
+	p := types.TestnetNetwork()
+	dc := consensus.NetworkDifficultyConfig(p)
+	if dc.MinDifficultyGap == 0 {
+		t.Fatal("testnet does not have a minimum difficulty gap")
+	}
+
+	// Start from a chain whose children must meet a target that is much
+	// harder than the root target.
+	genesis := p.GenesisBlock().Header()
+	hardTarget := types.Target{0, 0, 0, 1}
+	headers := []types.BlockHeader{genesis}
+	targets := []types.Target{hardTarget}
+
+	// solve returns a header with the given timestamp that meets the root
+	// target, but not the hard target.
+	solve := func(timestamp types.Timestamp) types.BlockHeader {
+		h := types.BlockHeader{
+			ParentID:  genesis.ID(),
+			Timestamp: timestamp,
+		}
+		for {
+			id := h.ID()
+			if dc.RootTarget.Cmp(types.Target(id)) >= 0 && hardTarget.Cmp(types.Target(id)) < 0 {
+				return h
+			}
+			h.Nonce[0]++
+		}
+	}
+
+	// A header found soon after its parent must meet the hard target.
+	early := solve(genesis.Timestamp + 1)
+	if _, _, err := appendHeader(dc, headers, targets, early); err != errUnsolvedHeader {
+		t.Error("expected errUnsolvedHeader, got", err)
+	}
+
+	// A header found more than MinDifficultyGap after its parent only needs
+	// to meet the root target, and only adds the root difficulty to the
+	// chain.
+	late := solve(genesis.Timestamp + dc.MinDifficultyGap + 1)
+	newHeaders, newTargets, err := appendHeader(dc, headers, targets, late)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newTargets[1] != hardTarget {
+		t.Error("minimum difficulty header changed the child target")
+	}
+	if chainWork(dc, newHeaders, newTargets, 0).Cmp(dc.RootTarget.Difficulty()) != 0 {
+		t.Error("minimum difficulty header added the wrong amount of work")
+	}
+}
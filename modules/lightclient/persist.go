@@ -0,0 +1,102 @@
+package lightclient
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	logFile      = modules.LightClientDir + ".log"
+	settingsFile = modules.LightClientDir + ".json"
+)
+
+var (
+	settingsMetadata = persist.Metadata{
+		Header:  "Light Client Settings",
+		Version: "1.0.0",
+	}
+)
+
+// persistence contains all of the persistent light client data. The child
+// targets are not saved, as they are recomputed from the headers on load.
+type persistence struct {
+	Headers []types.BlockHeader
+	Filter  modules.TransactionFilter
+	Proofs  []types.TransactionProof
+}
+
+// initPersist initializes the persistence of the light client.
+func (lc *LightClient) initPersist() error {
+	err := os.MkdirAll(lc.persistDir, 0700)
+	if err != nil {
+		return err
+	}
+	lc.log, err = persist.NewFileLogger(filepath.Join(lc.persistDir, logFile))
+	if err != nil {
+		return err
+	}
+
+	filename := filepath.Join(lc.persistDir, settingsFile)
+	_, err = os.Stat(filename)
+	if os.IsNotExist(err) {
+		return lc.save()
+	} else if err != nil {
+		return err
+	}
+	return lc.load()
+}
+
+// load loads the light client persistence from disk, revalidating the stored
+// headers.
+func (lc *LightClient) load() error {
+	var data persistence
+	err := persist.LoadFile(settingsMetadata, &data, filepath.Join(lc.persistDir, settingsFile))
+	if err != nil {
+		return err
+	}
+
+	headers := lc.headers
+	targets := lc.childTargets
+	if len(data.Headers) == 0 || data.Headers[0].ID() != headers[0].ID() {
+		return errOrphanHeader
+	}
+	for _, h := range data.Headers[1:] {
+		headers, targets, err = appendHeader(lc.difficulty, headers, targets, h)
+		if err != nil {
+			return err
+		}
+	}
+	for i, h := range headers {
+		lc.headerHeights[h.ID()] = types.BlockHeight(i)
+	}
+	lc.headers = headers
+	lc.childTargets = targets
+	lc.filter = data.Filter
+	lc.proofs = data.Proofs
+	return nil
+}
+
+// persistData returns the data in the light client that will be saved to
+// disk.
+func (lc *LightClient) persistData() persistence {
+	return persistence{
+		Headers: lc.headers,
+		Filter:  lc.filter,
+		Proofs:  lc.proofs,
+	}
+}
+
+// save saves the light client persistence to disk.
+func (lc *LightClient) save() error {
+	return persist.SaveFile(settingsMetadata, lc.persistData(), filepath.Join(lc.persistDir, settingsFile))
+}
+
+// saveSync saves the light client persistence to disk, and then syncs to
+// disk.
+func (lc *LightClient) saveSync() error {
+	return persist.SaveFileSync(settingsMetadata, lc.persistData(), filepath.Join(lc.persistDir, settingsFile))
+}
@@ -0,0 +1,264 @@
+package lightclient
+
+import (
+	"errors"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/consensus"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// syncTimeout is the amount of time that a single SendHeaders or
+	// SendTxnProofs call may take.
+	syncTimeout = 5 * time.Minute
+)
+
+var (
+	// maxSyncHeaders is the maximum number of headers that are downloaded
+	// from a peer in a single call to Synchronize. The remaining headers are
+	// downloaded in the next call.
+	maxSyncHeaders = func() int {
+		switch build.Release {
+		case "dev":
+			return 20e3
+		case "standard":
+			return 100e3
+		case "testing":
+			return 64
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// syncInterval is the amount of time between the synchronizations of
+	// the light client with its peers.
+	syncInterval = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 30 * time.Second
+		case "standard":
+			return 2 * time.Minute
+		case "testing":
+			return time.Minute
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	errUnknownForkPoint = errors.New("peer sent headers that do not build on a known block")
+)
+
+// receiveHeaders returns an RPCFunc that is the calling end of the
+// SendHeaders RPC.
+func receiveHeaders(history [32]types.BlockID, headers *[]types.BlockHeader, moreAvailable *bool) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		if err := conn.SetDeadline(time.Now().Add(syncTimeout)); err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, history); err != nil {
+			return err
+		}
+		maxLen := 8 + uint64(consensus.MaxCatchUpHeaders)*types.BlockHeaderSize
+		if err := encoding.ReadObject(conn, headers, maxLen); err != nil {
+			return err
+		}
+		return encoding.ReadObject(conn, moreAvailable, 1)
+	}
+}
+
+// receiveProofs returns an RPCFunc that is the calling end of the
+// SendTxnProofs RPC.
+func receiveProofs(ids []types.BlockID, filter modules.TransactionFilter, proofs *[]types.TransactionProof) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		if err := conn.SetDeadline(time.Now().Add(syncTimeout)); err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, ids); err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(conn, filter); err != nil {
+			return err
+		}
+		return encoding.ReadObject(conn, proofs, uint64(len(ids))*types.BlockSizeLimit)
+	}
+}
+
+// managedDownloadHeaders downloads the headers of the blocks that the peer
+// has in its current path after the most recent common block, up to
+// 'maxSyncHeaders' headers.
+func (lc *LightClient) managedDownloadHeaders(addr modules.NetAddress) ([]types.BlockHeader, error) {
+	lc.mu.RLock()
+	history := blockHistory(lc.headers)
+	lc.mu.RUnlock()
+
+	var headers []types.BlockHeader
+	moreAvailable := true
+	for moreAvailable && len(headers) < maxSyncHeaders {
+		var batch []types.BlockHeader
+		err := lc.gateway.RPC(addr, "SendHeaders", receiveHeaders(history, &batch, &moreAvailable))
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		headers = append(headers, batch...)
+		copy(history[1:31], history[:30])
+		history[0] = batch[len(batch)-1].ID()
+	}
+	return headers, nil
+}
+
+// managedDownloadProofs downloads and verifies the proofs of the relevant
+// transactions in the blocks of 'headers'. Proofs that do not verify or that
+// do not match the filter are discarded.
+func (lc *LightClient) managedDownloadProofs(addr modules.NetAddress, headers []types.BlockHeader, filter modules.TransactionFilter) ([]types.TransactionProof, error) {
+	if len(filter.UnlockHashes) == 0 && len(filter.FileContractIDs) == 0 {
+		return nil, nil
+	}
+	byID := make(map[types.BlockID]types.BlockHeader)
+	for _, h := range headers {
+		byID[h.ID()] = h
+	}
+
+	var verified []types.TransactionProof
+	for len(headers) > 0 {
+		n := len(headers)
+		if n > int(consensus.MaxCatchUpHeaders) {
+			n = int(consensus.MaxCatchUpHeaders)
+		}
+		ids := make([]types.BlockID, n)
+		for i, h := range headers[:n] {
+			ids[i] = h.ID()
+		}
+		var proofs []types.TransactionProof
+		err := lc.gateway.RPC(addr, "SendTxnProofs", receiveProofs(ids, filter, &proofs))
+		if err != nil {
+			return nil, err
+		}
+		for _, tp := range proofs {
+			h, exists := byID[tp.BlockID]
+			if !exists || !filter.Match(tp.Transaction) || !tp.Verify(h) {
+				lc.log.Debugln("WARN: discarding invalid transaction proof from", addr)
+				continue
+			}
+			verified = append(verified, tp)
+		}
+		headers = headers[n:]
+	}
+	return verified, nil
+}
+
+// Synchronize downloads the headers of a peer's chain, switching to that
+// chain if it is valid and heavier than the current chain, and downloads the
+// proofs of the relevant transactions in the new blocks. At most
+// 'maxSyncHeaders' headers are downloaded per call, so catching up with a
+// long chain takes multiple calls.
+func (lc *LightClient) Synchronize(addr modules.NetAddress) error {
+	if err := lc.tg.Add(); err != nil {
+		return err
+	}
+	defer lc.tg.Done()
+	return lc.managedSynchronize(addr)
+}
+
+// managedSynchronize synchronizes the light client with a peer. The caller
+// must hold a thread of the thread group.
+func (lc *LightClient) managedSynchronize(addr modules.NetAddress) error {
+	// Only one synchronization may happen at a time, which guarantees that
+	// the chain does not change while the network calls are made.
+	lc.syncMu.Lock()
+	defer lc.syncMu.Unlock()
+
+	newHeaders, err := lc.managedDownloadHeaders(addr)
+	if err != nil {
+		return err
+	}
+	if len(newHeaders) == 0 {
+		return nil
+	}
+
+	// Build the candidate chain and check that it is heavier than the
+	// current chain.
+	lc.mu.RLock()
+	forkHeight, exists := lc.headerHeights[newHeaders[0].ParentID]
+	if !exists {
+		lc.mu.RUnlock()
+		return errUnknownForkPoint
+	}
+	headers := append([]types.BlockHeader(nil), lc.headers[:forkHeight+1]...)
+	targets := append([]types.Target(nil), lc.childTargets[:forkHeight+1]...)
+	currentWork := chainWork(lc.difficulty, lc.headers, lc.childTargets, forkHeight)
+	filter := modules.TransactionFilter{
+		UnlockHashes:    append([]types.UnlockHash(nil), lc.filter.UnlockHashes...),
+		FileContractIDs: append([]types.FileContractID(nil), lc.filter.FileContractIDs...),
+	}
+	lc.mu.RUnlock()
+	for _, h := range newHeaders {
+		headers, targets, err = appendHeader(lc.difficulty, headers, targets, h)
+		if err != nil {
+			return err
+		}
+	}
+	if chainWork(lc.difficulty, headers, targets, forkHeight).Cmp(currentWork) <= 0 {
+		return nil
+	}
+
+	proofs, err := lc.managedDownloadProofs(addr, newHeaders, filter)
+	if err != nil {
+		return err
+	}
+
+	// Switch to the new chain, dropping the proofs of reverted blocks.
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	for _, h := range lc.headers[forkHeight+1:] {
+		delete(lc.headerHeights, h.ID())
+	}
+	for i := forkHeight + 1; i < types.BlockHeight(len(headers)); i++ {
+		lc.headerHeights[headers[i].ID()] = i
+	}
+	lc.headers = headers
+	lc.childTargets = targets
+	var kept []types.TransactionProof
+	for _, tp := range lc.proofs {
+		if _, exists := lc.headerHeights[tp.BlockID]; exists {
+			kept = append(kept, tp)
+		}
+	}
+	lc.proofs = append(kept, proofs...)
+	return lc.save()
+}
+
+// threadedSynchronize synchronizes the light client with each of its outbound
+// peers every 'syncInterval', until the light client is closed.
+func (lc *LightClient) threadedSynchronize() {
+	if err := lc.tg.Add(); err != nil {
+		return
+	}
+	defer lc.tg.Done()
+	for {
+		select {
+		case <-lc.tg.StopChan():
+			return
+		case <-time.After(syncInterval):
+		}
+		for _, p := range lc.gateway.Peers() {
+			if p.Inbound {
+				continue
+			}
+			select {
+			case <-lc.tg.StopChan():
+				return
+			default:
+			}
+			if err := lc.managedSynchronize(p.NetAddress); err != nil {
+				lc.log.Debugln("WARN: unable to synchronize with", p.NetAddress, err)
+			}
+		}
+	}
+}
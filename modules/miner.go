@@ -20,16 +20,112 @@ type BlockManager interface {
 	// corresponds to the header for 50 calls.
 	HeaderForWork() (types.BlockHeader, types.Target, error)
 
+	// BlockTemplate returns a block that can be grinded on, along with its
+	// target. The header of the block can be resubmitted to the miner with
+	// SubmitHeader, which allows external miners to inspect the transactions
+	// they are mining without tracking the wallet or the transaction pool.
+	BlockTemplate() (types.Block, types.Target, error)
+
 	// SubmitHeader takes a block header that has been worked on and has a
 	// valid target.
 	SubmitHeader(types.BlockHeader) error
 
+	// SubmitBlock takes a fully formed block that was assembled and solved
+	// outside of the miner, such as by a pool server. The block is validated
+	// and applied by the consensus set, which relays it to peers.
+	SubmitBlock(types.Block) error
+
 	// BlocksMined returns the number of blocks and stale blocks that have been
 	// mined using this miner.
 	BlocksMined() (goodBlocks, staleBlocks int)
+
+	// PayoutSplits returns the addresses that the block subsidy is paid to.
+	// If no splits are set, the subsidy is paid to an address of the wallet.
+	PayoutSplits() []MinerPayoutSplit
+
+	// SetPayoutSplits sets the addresses that the block subsidy is paid to.
+	// The percentages of the splits must add up to 100. Setting no splits
+	// pays the subsidy to an address of the wallet.
+	SetPayoutSplits([]MinerPayoutSplit) error
+
+	// MineBlocks mines 'n' blocks on top of the current block and returns
+	// their ids. It is intended for the regtest network, where blocks can be
+	// mined instantly, and returns an error on networks with real
+	// difficulties.
+	MineBlocks(n int) ([]types.BlockID, error)
+
+	// MinerSubscribe adds a subscriber to the miner. Subscribers are notified
+	// each time the miner finds a block that extends the blockchain.
+	MinerSubscribe(MinerSubscriber)
+
+	// Unsubscribe removes a subscriber from the miner.
+	Unsubscribe(MinerSubscriber)
+
+	TransactionPrioritizer
+}
+
+// A TransactionPrioritizer includes chosen transactions in the blocks that it
+// mines regardless of their fees. The host uses it to make sure that its own
+// storage proofs are confirmed when it is also mining.
+type TransactionPrioritizer interface {
+	// PrioritizeTransactions includes the transactions with the given ids in
+	// every block mined while they are in the transaction pool, ahead of all
+	// other transactions, until the block height exceeds 'expiration'. It
+	// should be called before the transactions are submitted to the
+	// transaction pool.
+	PrioritizeTransactions(ids []types.TransactionID, expiration types.BlockHeight)
+}
+
+// A FoundBlock describes a block found by the miner, and is sent to the
+// subscribers of the miner.
+type FoundBlock struct {
+	ID     types.BlockID     `json:"id"`
+	Height types.BlockHeight `json:"height"`
+
+	// Payout is the total value of the miner payouts of the block, which is
+	// the block subsidy plus the transaction fees.
+	Payout types.Currency `json:"payout"`
+}
+
+// A MinerSubscriber receives a notification each time the miner finds a
+// block, which can be used to trigger notifications or to sweep payouts.
+type MinerSubscriber interface {
+	// ReceiveFoundBlock is called with each block found by the miner that
+	// was accepted into the blockchain. It is called after the block has
+	// been accepted, and must not block.
+	ReceiveFoundBlock(FoundBlock)
+}
+
+// A MinerPayoutSplit is an address that receives a percentage of the subsidy
+// of each block found by the miner.
+type MinerPayoutSplit struct {
+	UnlockHash types.UnlockHash `json:"unlockhash"`
+	Percentage uint64           `json:"percentage"`
+}
+
+// A MinerStatus reports the state of the cpu miner and the number of blocks
+// that the miner has found.
+type MinerStatus struct {
+	// CPUMining is true if the cpu miner is enabled.
+	CPUMining bool `json:"cpumining"`
+
+	// CPUThreads is the number of threads that the cpu miner uses.
+	CPUThreads int `json:"cputhreads"`
+
+	// CPULimit is the percentage of cpu time that each thread may use.
+	CPULimit int `json:"cpulimit"`
+
+	// CPUHashrate is the number of solve attempts per second made by the cpu
+	// miner. It is zero if the cpu miner is not running.
+	CPUHashrate int `json:"cpuhashrate"`
+
+	// BlocksMined and StaleBlocksMined are the number of blocks found by the
+	// miner that are and are not in the current blockchain.
+	BlocksMined      int `json:"blocksmined"`
+	StaleBlocksMined int `json:"staleblocksmined"`
 }
 
-// CPUMiner provides access to a single-threaded cpu miner.
+// CPUMiner provides access to a multi-threaded cpu miner.
 type CPUMiner interface {
 	// CPUHashrate returns the hashrate of the cpu miner in hashes per second.
 	CPUHashrate() int
@@ -43,6 +139,75 @@ type CPUMiner interface {
 
 	// StopMining turns off the miner, but keeps the same number of threads.
 	StopCPUMining()
+
+	// CPUThreads returns the number of threads that the cpu miner uses.
+	CPUThreads() int
+
+	// SetCPUThreads sets the number of threads that the cpu miner uses. It
+	// defaults to the number of cpu cores.
+	SetCPUThreads(int) error
+
+	// CPULimit returns the percentage of cpu time that each thread of the
+	// miner may use.
+	CPULimit() int
+
+	// SetCPULimit sets the percentage of cpu time that each thread of the
+	// miner may use, so that background mining does not occupy the whole
+	// cpu. It defaults to 100, and applies to both cpu and pool mining.
+	SetCPULimit(int) error
+
+	// Status returns the state of the cpu miner and the number of blocks
+	// that have been found, for display in the CLI and UIs.
+	Status() MinerStatus
+}
+
+// A PoolStatus reports the state of the pool client of the miner.
+type PoolStatus struct {
+	// PoolMining is true if the miner is contributing shares to a pool.
+	// Connected is true if the miner is currently connected to the pool.
+	PoolMining bool `json:"poolmining"`
+	Connected  bool `json:"connected"`
+
+	// Pool is the address of the pool, and PayoutAddress is the address that
+	// the miner registered with the pool to receive its payouts.
+	Pool          NetAddress       `json:"pool"`
+	PayoutAddress types.UnlockHash `json:"payoutaddress"`
+
+	// Difficulty is the share difficulty set by the pool. Shares are only
+	// submitted once the pool has set a difficulty.
+	Difficulty uint64 `json:"difficulty"`
+
+	// Hashrate is the number of solve attempts per second made for the pool.
+	Hashrate int `json:"hashrate"`
+
+	// SharesAccepted and SharesRejected are the number of shares that the
+	// pool has accepted and rejected since pool mining was started.
+	SharesAccepted int `json:"sharesaccepted"`
+	SharesRejected int `json:"sharesrejected"`
+
+	// LastError is the error that ended the most recent connection to the
+	// pool, if any.
+	LastError string `json:"lasterror"`
+}
+
+// PoolMiner contributes shares to a stratum-like mining pool instead of
+// mining blocks for the local node. It grinds with the threads of the cpu
+// miner; starting pool mining stops the cpu miner, and starting the cpu miner
+// stops pool mining.
+type PoolMiner interface {
+	// StartPoolMining connects to a pool and starts contributing shares to
+	// it, registering 'payout' as the address that receives the payouts of
+	// the pool. A nonzero difficulty is suggested to the pool as the share
+	// difficulty. The miner reconnects to the pool until StopPoolMining is
+	// called.
+	StartPoolMining(pool NetAddress, payout types.UnlockHash, difficulty uint64) error
+
+	// StopPoolMining disconnects from the pool and stops contributing
+	// shares.
+	StopPoolMining()
+
+	// PoolStatus returns the state of the pool client.
+	PoolStatus() PoolStatus
 }
 
 // TestMiner provides direct access to block fetching, solving, and
@@ -76,5 +241,6 @@ type TestMiner interface {
 type Miner interface {
 	BlockManager
 	CPUMiner
+	PoolMiner
 	io.Closer
 }
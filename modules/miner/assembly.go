@@ -0,0 +1,274 @@
+package miner
+
+// assembly.go selects the unconfirmed transactions that go into a block. The
+// transactions with the highest fee per byte are selected first, so that the
+// miner collects as much in fees as the block size limit allows. A
+// transaction that spends the outputs of other unconfirmed transactions can
+// only be included along with its ancestors, so transactions are ranked by
+// the fee density of their ancestor package - the transaction together with
+// all of its unconfirmed ancestors. This lets a high-fee child pay for a
+// low-fee parent. Prioritized transactions, such as the storage proofs of a
+// host that is also mining, are selected before all others regardless of
+// their fees.
+
+import (
+	"sort"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+type (
+	// A txnPackage is an unconfirmed transaction along with the indices of
+	// its unconfirmed ancestors, and the combined size and fees of the
+	// transaction and its ancestors.
+	txnPackage struct {
+		index     int
+		ancestors map[int]struct{}
+		size      uint64
+		fees      types.Currency
+		priority  bool
+	}
+
+	// packagesByFeeDensity sorts packages by fee per byte, highest first,
+	// after the packages of prioritized transactions. Packages with the same
+	// fee density keep the order of the transaction pool.
+	packagesByFeeDensity []txnPackage
+)
+
+func (p packagesByFeeDensity) Len() int      { return len(p) }
+func (p packagesByFeeDensity) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p packagesByFeeDensity) Less(i, j int) bool {
+	if p[i].priority != p[j].priority {
+		return p[i].priority
+	}
+	// Compare fees[i]/size[i] to fees[j]/size[j] without division.
+	cmp := p[i].fees.Mul64(p[j].size).Cmp(p[j].fees.Mul64(p[i].size))
+	if cmp != 0 {
+		return cmp > 0
+	}
+	return p[i].index < p[j].index
+}
+
+// spentObjects returns the ids of the outputs and file contracts that a
+// transaction spends or revises.
+func spentObjects(txn types.Transaction) []crypto.Hash {
+	var ids []crypto.Hash
+	for _, sci := range txn.SiacoinInputs {
+		ids = append(ids, crypto.Hash(sci.ParentID))
+	}
+	for _, sfi := range txn.SiafundInputs {
+		ids = append(ids, crypto.Hash(sfi.ParentID))
+	}
+	for _, fcr := range txn.FileContractRevisions {
+		ids = append(ids, crypto.Hash(fcr.ParentID))
+	}
+	for _, sp := range txn.StorageProofs {
+		ids = append(ids, crypto.Hash(sp.ParentID))
+	}
+	return ids
+}
+
+// createdObjects returns the ids of the outputs and file contracts that a
+// transaction creates.
+func createdObjects(txn types.Transaction) []crypto.Hash {
+	var ids []crypto.Hash
+	for i := range txn.SiacoinOutputs {
+		ids = append(ids, crypto.Hash(txn.SiacoinOutputID(uint64(i))))
+	}
+	for i := range txn.SiafundOutputs {
+		ids = append(ids, crypto.Hash(txn.SiafundOutputID(uint64(i))))
+	}
+	for i := range txn.FileContracts {
+		ids = append(ids, crypto.Hash(txn.FileContractID(uint64(i))))
+	}
+	return ids
+}
+
+// transactionParents returns the indices of the transactions in 'txns' that
+// create the outputs and file contracts spent by each transaction. Parents
+// must appear earlier in 'txns' than their children, which is the order in
+// which the transaction pool provides them.
+func transactionParents(txns []types.Transaction) [][]int {
+	creators := make(map[crypto.Hash]int)
+	parents := make([][]int, len(txns))
+	for i, txn := range txns {
+		for _, id := range spentObjects(txn) {
+			if j, exists := creators[id]; exists {
+				parents[i] = append(parents[i], j)
+			}
+		}
+		for _, id := range createdObjects(txn) {
+			creators[id] = i
+		}
+	}
+	return parents
+}
+
+// pruneTransactions returns the transactions in 'txns' that remain valid
+// after the consensus change 'cc', without revalidating them against the
+// consensus set. Transactions that were confirmed by the change are removed,
+// as are transactions that spend outputs or file contracts that the change
+// removed from the consensus set - either because they were spent by the
+// applied blocks or because they were created by the reverted blocks - along
+// with all of their descendants.
+func pruneTransactions(txns []types.Transaction, cc modules.ConsensusChange) []types.Transaction {
+	confirmed := make(map[types.TransactionID]struct{})
+	for _, b := range cc.AppliedBlocks {
+		for _, txn := range b.Transactions {
+			confirmed[txn.ID()] = struct{}{}
+		}
+	}
+	// An object can be removed and added back by the same change, such as a
+	// revised file contract, so only the final direction of each object is
+	// considered.
+	removed := make(map[crypto.Hash]struct{})
+	markDiff := func(id crypto.Hash, dir modules.DiffDirection) {
+		if dir == modules.DiffRevert {
+			removed[id] = struct{}{}
+		} else {
+			delete(removed, id)
+		}
+	}
+	for _, diff := range cc.SiacoinOutputDiffs {
+		markDiff(crypto.Hash(diff.ID), diff.Direction)
+	}
+	for _, diff := range cc.SiafundOutputDiffs {
+		markDiff(crypto.Hash(diff.ID), diff.Direction)
+	}
+	for _, diff := range cc.FileContractDiffs {
+		markDiff(crypto.Hash(diff.ID), diff.Direction)
+	}
+
+	// Parents come before their children, so the outputs of invalid parents
+	// are marked as removed before the children are checked.
+	var valid []types.Transaction
+	for _, txn := range txns {
+		if _, exists := confirmed[txn.ID()]; exists {
+			continue
+		}
+		invalid := false
+		for _, id := range spentObjects(txn) {
+			if _, exists := removed[id]; exists {
+				invalid = true
+				break
+			}
+		}
+		if invalid {
+			for _, id := range createdObjects(txn) {
+				removed[id] = struct{}{}
+			}
+			continue
+		}
+		valid = append(valid, txn)
+	}
+	return valid
+}
+
+// selectTransactions returns the transactions that pay the most in fees
+// without exceeding 'sizeLimit' bytes, in an order where every transaction
+// follows its unconfirmed ancestors. The transactions in 'priority' are
+// selected first.
+func selectTransactions(txns []types.Transaction, sizeLimit uint64, priority map[types.TransactionID]types.BlockHeight) []types.Transaction {
+	sizes := make([]uint64, len(txns))
+	fees := make([]types.Currency, len(txns))
+	for i, txn := range txns {
+		sizes[i] = uint64(len(encoding.Marshal(txn)))
+		fees[i] = transactionFees([]types.Transaction{txn})
+	}
+
+	// Build the ancestor package of each transaction. Parents come before
+	// their children, so the ancestors of the parents are already known.
+	parents := transactionParents(txns)
+	packages := make([]txnPackage, len(txns))
+	for i := range txns {
+		ancestors := make(map[int]struct{})
+		for _, p := range parents[i] {
+			ancestors[p] = struct{}{}
+			for a := range packages[p].ancestors {
+				ancestors[a] = struct{}{}
+			}
+		}
+		pkg := txnPackage{index: i, ancestors: ancestors, size: sizes[i], fees: fees[i]}
+		if len(priority) != 0 {
+			_, pkg.priority = priority[txns[i].ID()]
+		}
+		for a := range ancestors {
+			pkg.size += sizes[a]
+			pkg.fees = pkg.fees.Add(fees[a])
+		}
+		packages[i] = pkg
+	}
+	sorted := append([]txnPackage(nil), packages...)
+	sort.Sort(packagesByFeeDensity(sorted))
+
+	// Add packages in order of fee density, skipping the ancestors that have
+	// already been added with an earlier package.
+	included := make([]bool, len(txns))
+	var selected []int
+	var size uint64
+	for _, pkg := range sorted {
+		if included[pkg.index] {
+			continue
+		}
+		missing := []int{pkg.index}
+		missingSize := sizes[pkg.index]
+		for a := range pkg.ancestors {
+			if !included[a] {
+				missing = append(missing, a)
+				missingSize += sizes[a]
+			}
+		}
+		if size+missingSize > sizeLimit {
+			continue
+		}
+		size += missingSize
+		sort.Ints(missing)
+		for _, i := range missing {
+			included[i] = true
+		}
+		selected = append(selected, missing...)
+	}
+
+	selectedTxns := make([]types.Transaction, len(selected))
+	for i, index := range selected {
+		selectedTxns[i] = txns[index]
+	}
+	return selectedTxns
+}
+
+// PrioritizeTransactions includes the transactions with the given ids in
+// every block mined while they are in the transaction pool, ahead of all
+// other transactions, until the block height exceeds 'expiration'.
+func (m *Miner) PrioritizeTransactions(ids []types.TransactionID, expiration types.BlockHeight) {
+	if err := m.tg.Add(); err != nil {
+		return
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, id := range ids {
+		m.priorityTxns[id] = expiration
+	}
+}
+
+// prunePriorityTransactions stops prioritizing transactions that have been
+// confirmed in 'applied' or have expired.
+func (m *Miner) prunePriorityTransactions(applied []types.Block) {
+	if len(m.priorityTxns) == 0 {
+		return
+	}
+	for _, b := range applied {
+		for _, txn := range b.Transactions {
+			delete(m.priorityTxns, txn.ID())
+		}
+	}
+	for id, expiration := range m.priorityTxns {
+		if expiration < m.persist.Height {
+			delete(m.priorityTxns, id)
+		}
+	}
+}
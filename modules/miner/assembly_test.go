@@ -0,0 +1,198 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// feeTxn returns a transaction paying 'fee' in miner fees, padded with
+// arbitrary data of length 'padding'.
+func feeTxn(fee uint64, padding int) types.Transaction {
+	return types.Transaction{
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(fee)}},
+		MinerFees:      []types.Currency{types.NewCurrency64(fee)},
+		ArbitraryData:  [][]byte{make([]byte, padding)},
+	}
+}
+
+// TestTransactionParents checks that transactionParents finds the
+// transactions that create the outputs spent by each transaction.
+func TestTransactionParents(t *testing.T) {
+	parent := feeTxn(1, 0)
+	child := feeTxn(2, 0)
+	child.SiacoinInputs = []types.SiacoinInput{{ParentID: parent.SiacoinOutputID(0)}}
+	unrelated := feeTxn(3, 0)
+	unrelated.SiacoinInputs = []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}}
+
+	parents := transactionParents([]types.Transaction{parent, child, unrelated})
+	if len(parents[0]) != 0 || len(parents[2]) != 0 {
+		t.Fatal("transactions without unconfirmed parents were given parents:", parents)
+	}
+	if len(parents[1]) != 1 || parents[1][0] != 0 {
+		t.Fatal("child transaction has the wrong parents:", parents)
+	}
+}
+
+// TestSelectTransactions checks that selectTransactions prefers the
+// transactions with the highest fee per byte, lets children pay for their
+// parents, and respects the size limit.
+func TestSelectTransactions(t *testing.T) {
+	// A low-fee parent with a high-fee child, a medium-fee independent
+	// transaction and a low-fee independent transaction, all the same size.
+	parent := feeTxn(1, 100)
+	child := feeTxn(100, 100)
+	child.SiacoinInputs = []types.SiacoinInput{{ParentID: parent.SiacoinOutputID(0)}}
+	medium := feeTxn(20, 100)
+	low := feeTxn(2, 100)
+	txns := []types.Transaction{low, parent, medium, child}
+
+	// Everything fits, so everything is selected, with the parent before the
+	// child.
+	selected := selectTransactions(txns, types.MaxTransactionSize, nil)
+	if len(selected) != len(txns) {
+		t.Fatal("expected all transactions to be selected, got", len(selected))
+	}
+	parentIndex, childIndex := -1, -1
+	for i, txn := range selected {
+		if txn.ID() == parent.ID() {
+			parentIndex = i
+		}
+		if txn.ID() == child.ID() {
+			childIndex = i
+		}
+	}
+	if parentIndex == -1 || childIndex == -1 || parentIndex > childIndex {
+		t.Fatal("parent was not placed before its child")
+	}
+
+	// With room for two transactions, the parent and child package pays more
+	// per byte than the medium transaction.
+	size := uint64(len(encoding.Marshal(parent)) + len(encoding.Marshal(child)))
+	selected = selectTransactions(txns, size, nil)
+	if len(selected) != 2 || selected[0].ID() != parent.ID() || selected[1].ID() != child.ID() {
+		t.Fatal("expected the parent and child to be selected")
+	}
+
+	// With room for three transactions, the low-fee transaction is left out.
+	size += uint64(len(encoding.Marshal(medium)))
+	selected = selectTransactions(txns, size, nil)
+	if len(selected) != 3 {
+		t.Fatal("expected three transactions to be selected, got", len(selected))
+	}
+	for _, txn := range selected {
+		if txn.ID() == low.ID() {
+			t.Fatal("lowest fee transaction was selected")
+		}
+	}
+
+	// A child is never selected without its parent.
+	size = uint64(len(encoding.Marshal(child)))
+	selected = selectTransactions(txns, size, nil)
+	for _, txn := range selected {
+		if txn.ID() == child.ID() {
+			t.Fatal("child was selected without its parent")
+		}
+	}
+}
+
+// TestSelectTransactionsPriority checks that prioritized transactions are
+// selected ahead of transactions that pay higher fees.
+func TestSelectTransactionsPriority(t *testing.T) {
+	proof := feeTxn(1, 100)
+	high := feeTxn(100, 100)
+	txns := []types.Transaction{high, proof}
+	priority := map[types.TransactionID]types.BlockHeight{proof.ID(): 10}
+
+	size := uint64(len(encoding.Marshal(proof)))
+	selected := selectTransactions(txns, size, priority)
+	if len(selected) != 1 || selected[0].ID() != proof.ID() {
+		t.Fatal("prioritized transaction was not selected")
+	}
+	selected = selectTransactions(txns, size, nil)
+	if len(selected) != 1 || selected[0].ID() != high.ID() {
+		t.Fatal("highest fee transaction was not selected")
+	}
+}
+
+// TestIntegrationPrioritizeTransactions checks that the miner stops
+// prioritizing transactions once they are confirmed or expire.
+func TestIntegrationPrioritizeTransactions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationPrioritizeTransactions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	txns, err := mt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	confirmed := txns[len(txns)-1].ID()
+	expiring := types.TransactionID{1}
+	height := mt.cs.Height()
+	mt.miner.PrioritizeTransactions([]types.TransactionID{confirmed}, height+10)
+	mt.miner.PrioritizeTransactions([]types.TransactionID{expiring}, height+1)
+
+	if _, err := mt.miner.MineBlocks(1); err != nil {
+		t.Fatal(err)
+	}
+	mt.miner.mu.Lock()
+	_, confirmedExists := mt.miner.priorityTxns[confirmed]
+	_, expiringExists := mt.miner.priorityTxns[expiring]
+	mt.miner.mu.Unlock()
+	if confirmedExists || !expiringExists {
+		t.Fatal("confirmed transaction is still prioritized, or unexpired transaction is not")
+	}
+
+	if _, err := mt.miner.MineBlocks(1); err != nil {
+		t.Fatal(err)
+	}
+	mt.miner.mu.Lock()
+	_, expiringExists = mt.miner.priorityTxns[expiring]
+	mt.miner.mu.Unlock()
+	if expiringExists {
+		t.Fatal("expired transaction is still prioritized")
+	}
+}
+
+// TestPruneTransactions checks that pruneTransactions removes the
+// transactions that a consensus change confirmed or invalidated, and keeps
+// the rest.
+func TestPruneTransactions(t *testing.T) {
+	// 'confirmed' is confirmed by the change, and 'confirmedChild' spends its
+	// output. 'doubleSpent' spends an output that the change spent, and
+	// 'invalidChild' spends its output. 'revision' revises a file contract
+	// that the change revised.
+	confirmed := feeTxn(1, 0)
+	confirmedChild := feeTxn(2, 0)
+	confirmedChild.SiacoinInputs = []types.SiacoinInput{{ParentID: confirmed.SiacoinOutputID(0)}}
+	doubleSpent := feeTxn(3, 0)
+	doubleSpent.SiacoinInputs = []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}}
+	invalidChild := feeTxn(4, 0)
+	invalidChild.SiacoinInputs = []types.SiacoinInput{{ParentID: doubleSpent.SiacoinOutputID(0)}}
+	revision := feeTxn(5, 0)
+	revision.FileContractRevisions = []types.FileContractRevision{{ParentID: types.FileContractID{2}}}
+	txns := []types.Transaction{confirmed, confirmedChild, doubleSpent, invalidChild, revision}
+
+	cc := modules.ConsensusChange{
+		AppliedBlocks: []types.Block{{Transactions: []types.Transaction{confirmed}}},
+		SiacoinOutputDiffs: []modules.SiacoinOutputDiff{
+			{Direction: modules.DiffRevert, ID: types.SiacoinOutputID{1}},
+			{Direction: modules.DiffApply, ID: confirmed.SiacoinOutputID(0)},
+		},
+		FileContractDiffs: []modules.FileContractDiff{
+			{Direction: modules.DiffRevert, ID: types.FileContractID{2}},
+			{Direction: modules.DiffApply, ID: types.FileContractID{2}},
+		},
+	}
+	valid := pruneTransactions(txns, cc)
+	if len(valid) != 2 || valid[0].ID() != confirmedChild.ID() || valid[1].ID() != revision.ID() {
+		t.Fatal("wrong transactions remain after pruning:", len(valid))
+	}
+}
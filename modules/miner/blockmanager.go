@@ -27,11 +27,13 @@ func (m *Miner) blockForWork() types.Block {
 	}
 
 	// Update the address + payouts.
-	err := m.checkAddress()
-	if err != nil {
-		m.log.Println(err)
+	if len(m.persist.PayoutSplits) == 0 {
+		err := m.checkAddress()
+		if err != nil {
+			m.log.Println(err)
+		}
 	}
-	b.MinerPayouts = []types.SiacoinOutput{{Value: b.CalculateSubsidy(m.persist.Height + 1), UnlockHash: m.persist.Address}}
+	b.MinerPayouts = m.minerPayouts(b.CalculateSubsidy(m.persist.Height + 1))
 
 	// Add an arb-data txn to the block to create a unique merkle root.
 	randBytes, _ := crypto.RandBytes(types.SpecifierLen)
@@ -63,30 +65,17 @@ func (m *Miner) newSourceBlock() {
 	m.sourceBlockTime = time.Now()
 }
 
-// HeaderForWork returns a header that is ready for nonce grinding. The miner
-// will store the header in memory for a while, depending on the constants
-// 'HeaderMemory', 'BlockMemory', and 'MaxSourceBlockAge'. On the full network,
-// it is typically safe to assume that headers will be remembered for
-// min(10 minutes, 10e3 requests).
-func (m *Miner) HeaderForWork() (types.BlockHeader, types.Target, error) {
-	if err := m.tg.Add(); err != nil {
-		return types.BlockHeader{}, types.Target{}, err
-	}
-	defer m.tg.Done()
-
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	// Return a blank header with an error if the wallet is locked.
-	if !m.wallet.Unlocked() {
-		return types.BlockHeader{}, types.Target{}, modules.ErrLockedWallet
-	}
-
-	// Check that the wallet has been initialized, and that the miner has
-	// successfully fetched an address.
-	err := m.checkAddress()
+// workBlock returns a block that is ready for nonce grinding, and remembers
+// the header of the block so that it can be submitted with SubmitHeader. The
+// miner will store the header in memory for a while, depending on the
+// constants 'HeaderMemory', 'BlockMemory', and 'MaxSourceBlockAge'.
+func (m *Miner) workBlock() (types.Block, error) {
+	// Return a blank block with an error if the miner has nowhere to pay the
+	// block subsidy, which happens if no payout splits are set and the wallet
+	// is locked or cannot provide an address.
+	err := m.checkPayouts()
 	if err != nil {
-		return types.BlockHeader{}, types.Target{}, err
+		return types.Block{}, err
 	}
 
 	// If too much time has elapsed since the last source block, get a new one.
@@ -103,7 +92,7 @@ func (m *Miner) HeaderForWork() (types.BlockHeader, types.Target, error) {
 	var arbData [crypto.EntropySize]byte
 	_, err = rand.Read(arbData[:])
 	if err != nil {
-		return types.BlockHeader{}, types.Target{}, err
+		return types.Block{}, err
 	}
 	copy(m.sourceBlock.Transactions[0].ArbitraryData[0], arbData[:])
 	header := m.sourceBlock.Header()
@@ -120,8 +109,54 @@ func (m *Miner) HeaderForWork() (types.BlockHeader, types.Target, error) {
 		m.memProgress = 0
 	}
 
-	// Return the header and target.
-	return header, m.persist.Target, nil
+	// The source block is modified by later calls, so the returned block
+	// needs its own copy of the transactions and the arbitrary data.
+	b := *m.sourceBlock
+	b.Transactions = make([]types.Transaction, len(m.sourceBlock.Transactions))
+	copy(b.Transactions, m.sourceBlock.Transactions)
+	b.Transactions[0].ArbitraryData = [][]byte{arbData[:]}
+	return b, nil
+}
+
+// HeaderForWork returns a header that is ready for nonce grinding. The miner
+// will store the header in memory for a while, depending on the constants
+// 'HeaderMemory', 'BlockMemory', and 'MaxSourceBlockAge'. On the full network,
+// it is typically safe to assume that headers will be remembered for
+// min(10 minutes, 10e3 requests).
+func (m *Miner) HeaderForWork() (types.BlockHeader, types.Target, error) {
+	if err := m.tg.Add(); err != nil {
+		return types.BlockHeader{}, types.Target{}, err
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.workBlock()
+	if err != nil {
+		return types.BlockHeader{}, types.Target{}, err
+	}
+	return b.Header(), m.blockTarget(b.Timestamp), nil
+}
+
+// BlockTemplate returns a block that is ready for nonce grinding, along with
+// its target. The header of the block is remembered in the same way as the
+// headers returned by HeaderForWork, so a solved header can be submitted with
+// SubmitHeader without resubmitting the transactions.
+func (m *Miner) BlockTemplate() (types.Block, types.Target, error) {
+	if err := m.tg.Add(); err != nil {
+		return types.Block{}, types.Target{}, err
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, err := m.workBlock()
+	if err != nil {
+		return types.Block{}, types.Target{}, err
+	}
+	return b, m.blockTarget(b.Timestamp), nil
 }
 
 // managedSubmitBlock takes a solved block and submits it to the blockchain.
@@ -148,9 +183,24 @@ func (m *Miner) managedSubmitBlock(b types.Block) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Grab a new address for the miner. Call may fail if the wallet is locked
-	// or if the wallet addresses have been exhausted.
+	// Notify the subscribers. The consensus set has already updated the
+	// miner, so the height of the miner is the height of the block.
+	fb := modules.FoundBlock{
+		ID:     b.ID(),
+		Height: m.persist.Height,
+	}
+	for _, mp := range b.MinerPayouts {
+		fb.Payout = fb.Payout.Add(mp.Value)
+	}
+	m.updateSubscribers(fb)
+
+	// Grab a new address for the miner, unless the subsidy is paid to the
+	// payout splits. Call may fail if the wallet is locked or if the wallet
+	// addresses have been exhausted.
 	m.persist.BlocksFound = append(m.persist.BlocksFound, b.ID())
+	if len(m.persist.PayoutSplits) != 0 {
+		return m.saveSync()
+	}
 	var uc types.UnlockConditions
 	uc, err = m.wallet.NextAddress()
 	if err != nil {
@@ -212,3 +262,22 @@ func (m *Miner) SubmitHeader(bh types.BlockHeader) error {
 	}
 	return nil
 }
+
+// SubmitBlock accepts a block that was assembled outside of the miner. Unlike
+// blocks built from the headers of the miner, the block does not pay the
+// wallet, so it is not counted as a block mined by the miner, and an invalid
+// block does not indicate a problem with the transaction pool.
+func (m *Miner) SubmitBlock(b types.Block) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+
+	err := m.cs.AcceptBlock(b)
+	if err != nil {
+		m.log.Println("WARN: an externally mined block was rejected:", err)
+		return err
+	}
+	m.log.Println("Accepted an externally mined block:", b.ID())
+	return nil
+}
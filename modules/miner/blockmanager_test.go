@@ -222,3 +222,92 @@ func TestIntegrationHeaderRequestOverflow(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+// TestIntegrationBlockTemplate checks that a block template can be solved by
+// grinding its header, and that the solved header can be submitted.
+func TestIntegrationBlockTemplate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationBlockTemplate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	// The template builds on the current block and pays the miner.
+	b, target, err := mt.miner.BlockTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ParentID != mt.cs.CurrentBlock().ID() {
+		t.Fatal("block template does not build on the current block")
+	}
+	if len(b.MinerPayouts) == 0 {
+		t.Fatal("block template has no miner payouts")
+	}
+
+	// Templates are unique, so that external miners do not overlap.
+	b2, _, err := mt.miner.BlockTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.MerkleRoot() == b2.MerkleRoot() {
+		t.Fatal("block templates share a merkle root")
+	}
+
+	// Solve the header of the first template and submit it.
+	startHeight := mt.cs.Height()
+	solvedHeader := solveHeader(b.Header(), target)
+	err = mt.miner.SubmitHeader(solvedHeader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.cs.Height() != startHeight+1 {
+		t.Fatal("submitting the solved template did not extend the blockchain")
+	}
+	b.Nonce = solvedHeader.Nonce
+	if mt.cs.CurrentBlock().ID() != b.ID() {
+		t.Fatal("the solved block does not match the template")
+	}
+}
+
+// TestIntegrationSubmitBlock checks that externally mined blocks are added
+// to the consensus set, and that unsolved blocks are rejected.
+func TestIntegrationSubmitBlock(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationSubmitBlock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	b, target, err := mt.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An unsolved block is rejected.
+	unsolved := b
+	for id := unsolved.ID(); bytes.Compare(target[:], id[:]) >= 0; id = unsolved.ID() {
+		unsolved.Nonce[0]++
+	}
+	if err := mt.miner.SubmitBlock(unsolved); err != modules.ErrBlockUnsolved {
+		t.Fatal("expected ErrBlockUnsolved, got", err)
+	}
+
+	// A solved block extends the blockchain.
+	startHeight := mt.cs.Height()
+	solved, ok := mt.miner.SolveBlock(b, target)
+	if !ok {
+		t.Fatal("could not solve block")
+	}
+	if err := mt.miner.SubmitBlock(solved); err != nil {
+		t.Fatal(err)
+	}
+	if mt.cs.Height() != startHeight+1 || mt.cs.CurrentBlock().ID() != solved.ID() {
+		t.Fatal("submitted block was not added to the blockchain")
+	}
+}
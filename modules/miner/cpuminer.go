@@ -1,9 +1,18 @@
 package miner
 
 import (
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+var (
+	errInvalidCPULimit = errors.New("cpu limit must be between 1 and 100 percent")
+	errInvalidThreads  = errors.New("cpu miner needs at least one thread")
 )
 
 // threadedMine starts a gothread that does CPU mining. threadedMine is the
@@ -24,8 +33,8 @@ func (m *Miner) threadedMine() {
 	m.mu.Unlock()
 
 	// Solve blocks repeatedly, keeping track of how fast hashing is
-	// occurring.
-	cycleStart := time.Now()
+	// occurring. Each round, every thread grinds its own range of nonces on
+	// the same block.
 	for {
 		m.mu.Lock()
 
@@ -48,11 +57,13 @@ func (m *Miner) threadedMine() {
 
 		// Prepare the work and release the miner lock.
 		bfw := m.blockForWork()
-		target := m.persist.Target
+		target := m.blockTarget(bfw.Timestamp)
+		threads := m.threads
 		m.mu.Unlock()
 
 		// Solve the block.
-		b, solved := solveBlock(bfw, target)
+		roundStart := time.Now()
+		b, solved := solveBlockParallel(bfw, target, threads)
 		if solved {
 			err := m.managedSubmitBlock(b)
 			if err != nil {
@@ -60,18 +71,75 @@ func (m *Miner) threadedMine() {
 			}
 		}
 
+		// Pause to stay within the cpu limit. The pause is included in the
+		// hashrate, so that the hashrate reflects the throttling.
+		m.managedThrottle(time.Since(roundStart))
+
 		// Update the hashrate. If the block was solved, the full set of
 		// iterations was not completed, so the hashrate should not be updated.
 		m.mu.Lock()
 		if !solved {
-			nanosecondsElapsed := 1 + time.Since(cycleStart).Nanoseconds() // Add 1 to prevent divide by zero errors.
-			cycleStart = time.Now()                                        // Reset the cycle counter as soon as the previous value is measured.
-			m.hashRate = 1e9 * solveAttempts / nanosecondsElapsed
+			nanosecondsElapsed := 1 + time.Since(roundStart).Nanoseconds() // Add 1 to prevent divide by zero errors.
+			m.hashRate = 1e9 * int64(threads) * solveAttempts / nanosecondsElapsed
 		}
 		m.mu.Unlock()
 	}
 }
 
+// solveBlockParallel tries to solve a block using 'threads' goroutines, each
+// of which tries solveAttempts nonces from its own range. A bool is returned
+// indicating whether the block was successfully solved.
+func solveBlockParallel(b types.Block, target types.Target, threads int) (types.Block, bool) {
+	solved := solveHeaderParallel(b.Header(), target, threads, 0)
+	if len(solved) == 0 {
+		return b, false
+	}
+	b.Nonce = solved[0].Nonce
+	return b, true
+}
+
+// solveHeaderParallel tries to solve a header using 'threads' goroutines.
+// Thread i tries solveAttempts nonces starting from 'start +
+// i*solveAttempts'. Each thread stops at the first solution it finds, and the
+// solutions of all threads are returned.
+func solveHeaderParallel(h types.BlockHeader, target types.Target, threads int, start uint64) []types.BlockHeader {
+	solved := make(chan types.BlockHeader, threads)
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func(start uint64) {
+			defer wg.Done()
+			if sh, ok := solveHeaderRange(h, target, start, solveAttempts); ok {
+				solved <- sh
+			}
+		}(start + uint64(i)*solveAttempts)
+	}
+	wg.Wait()
+	close(solved)
+
+	var headers []types.BlockHeader
+	for sh := range solved {
+		headers = append(headers, sh)
+	}
+	return headers
+}
+
+// managedThrottle pauses after a round of grinding that took 'elapsed', for
+// long enough that the threads of the miner are busy for at most cpuLimit
+// percent of the time.
+func (m *Miner) managedThrottle(elapsed time.Duration) {
+	m.mu.RLock()
+	limit := m.cpuLimit
+	m.mu.RUnlock()
+	if limit >= 100 {
+		return
+	}
+	select {
+	case <-time.After(elapsed * time.Duration(100-limit) / time.Duration(limit)):
+	case <-m.tg.StopChan():
+	}
+}
+
 // CPUHashrate returns an estimated cpu hashrate.
 func (m *Miner) CPUHashrate() int {
 	if err := m.tg.Add(); err != nil {
@@ -96,8 +164,9 @@ func (m *Miner) CPUMining() bool {
 	return m.miningOn
 }
 
-// StartCPUMining will start a single threaded cpu miner. If the miner is
-// already running, nothing will happen.
+// StartCPUMining will start the cpu miner, which mines using the number of
+// threads set by SetCPUThreads. If the miner is already running, nothing will
+// happen.
 func (m *Miner) StartCPUMining() {
 	if err := m.tg.Add(); err != nil {
 		build.Critical(err)
@@ -106,6 +175,7 @@ func (m *Miner) StartCPUMining() {
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.stopPoolMining()
 	m.miningOn = true
 	go m.threadedMine()
 }
@@ -123,3 +193,84 @@ func (m *Miner) StopCPUMining() {
 	m.hashRate = 0
 	m.miningOn = false
 }
+
+// CPUThreads returns the number of threads that the cpu miner uses.
+func (m *Miner) CPUThreads() int {
+	if err := m.tg.Add(); err != nil {
+		build.Critical(err)
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.threads
+}
+
+// SetCPUThreads sets the number of threads that the cpu miner uses. If the
+// miner is running, the change takes effect once the current round of work is
+// done.
+func (m *Miner) SetCPUThreads(threads int) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+
+	if threads < 1 {
+		return errInvalidThreads
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.threads = threads
+	return nil
+}
+
+// Status returns the state of the cpu miner and the number of blocks that
+// have been found.
+func (m *Miner) Status() modules.MinerStatus {
+	if err := m.tg.Add(); err != nil {
+		build.Critical(err)
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	good, stale := m.blocksMined()
+	return modules.MinerStatus{
+		CPUMining:        m.miningOn,
+		CPUThreads:       m.threads,
+		CPULimit:         m.cpuLimit,
+		CPUHashrate:      int(m.hashRate),
+		BlocksMined:      good,
+		StaleBlocksMined: stale,
+	}
+}
+
+// CPULimit returns the percentage of cpu time that each thread of the miner
+// may use.
+func (m *Miner) CPULimit() int {
+	if err := m.tg.Add(); err != nil {
+		build.Critical(err)
+	}
+	defer m.tg.Done()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.cpuLimit
+}
+
+// SetCPULimit sets the percentage of cpu time that each thread of the miner
+// may use. A change takes effect after the current round of grinding.
+func (m *Miner) SetCPULimit(limit int) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+
+	if limit < 1 || limit > 100 {
+		return errInvalidCPULimit
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cpuLimit = limit
+	return nil
+}
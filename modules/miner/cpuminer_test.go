@@ -0,0 +1,139 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestSolveBlockRange checks that solveBlockRange only tries nonces in its
+// range.
+func TestSolveBlockRange(t *testing.T) {
+	b := types.Block{Timestamp: types.CurrentTimestamp()}
+
+	// Every nonce solves the block for the largest target.
+	var easiest types.Target
+	for i := range easiest {
+		easiest[i] = 0xff
+	}
+	solved, ok := solveBlockRange(b, easiest, 1000, 10)
+	if !ok {
+		t.Fatal("block was not solved for the largest target")
+	}
+	if nonce := binary.LittleEndian.Uint64(solved.Nonce[:]); nonce != 1000 {
+		t.Fatal("expected the first nonce of the range, got", nonce)
+	}
+
+	// A block solved for the root target meets it.
+	solved, ok = solveBlockRange(b, types.RootTarget, 0, 1000)
+	if !ok {
+		t.Fatal("block was not solved for the root target")
+	}
+	if id := solved.ID(); bytes.Compare(types.RootTarget[:], id[:]) < 0 {
+		t.Fatal("solved block does not meet the target")
+	}
+
+	// No nonce solves the block for an impossible target.
+	if _, ok := solveBlockRange(b, types.Target{}, 0, 100); ok {
+		t.Fatal("block was solved for an impossible target")
+	}
+}
+
+// TestCPUThreads checks that the cpu miner mines with the number of threads
+// set by SetCPUThreads.
+func TestCPUThreads(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestCPUThreads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	if mt.miner.CPUThreads() < 1 {
+		t.Fatal("cpu miner defaults to no threads")
+	}
+	if err := mt.miner.SetCPUThreads(0); err != errInvalidThreads {
+		t.Fatal("expected errInvalidThreads, got", err)
+	}
+	if err := mt.miner.SetCPUThreads(2); err != nil {
+		t.Fatal(err)
+	}
+	if mt.miner.CPUThreads() != 2 {
+		t.Fatal("wrong number of threads:", mt.miner.CPUThreads())
+	}
+
+	// The miner finds blocks using multiple threads.
+	startHeight := mt.cs.Height()
+	mt.miner.StartCPUMining()
+	defer mt.miner.StopCPUMining()
+	for i := 0; mt.cs.Height() < startHeight+2; i++ {
+		if i == 100 {
+			t.Fatal("cpu miner did not find any blocks")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// The status reflects the mining.
+	status := mt.miner.Status()
+	if !status.CPUMining || status.CPUThreads != 2 || status.BlocksMined < 2 {
+		t.Fatal("wrong miner status:", status)
+	}
+}
+
+// TestCPULimit checks that the cpu limit can be set, and that the miner
+// pauses between rounds to stay within it.
+func TestCPULimit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestCPULimit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	if mt.miner.CPULimit() != 100 {
+		t.Fatal("cpu limit should default to 100, got", mt.miner.CPULimit())
+	}
+	for _, limit := range []int{0, 101} {
+		if err := mt.miner.SetCPULimit(limit); err != errInvalidCPULimit {
+			t.Fatalf("expected errInvalidCPULimit for %v, got %v", limit, err)
+		}
+	}
+
+	// Without a limit, there is no pause.
+	start := time.Now()
+	mt.miner.managedThrottle(time.Second)
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("miner paused without a cpu limit")
+	}
+
+	// At 25%, a round is followed by a pause three times as long.
+	if err := mt.miner.SetCPULimit(25); err != nil {
+		t.Fatal(err)
+	}
+	if mt.miner.Status().CPULimit != 25 {
+		t.Fatal("status does not report the cpu limit")
+	}
+	start = time.Now()
+	mt.miner.managedThrottle(30 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Fatal("miner did not pause for long enough:", elapsed)
+	}
+
+	// The throttled miner still finds blocks.
+	startHeight := mt.cs.Height()
+	mt.miner.StartCPUMining()
+	defer mt.miner.StopCPUMining()
+	for i := 0; mt.cs.Height() < startHeight+1; i++ {
+		if i == 100 {
+			t.Fatal("throttled cpu miner did not find any blocks")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -3,6 +3,7 @@ package miner
 import (
 	"errors"
 	"fmt"
+	"runtime"
 	"sync"
 	"time"
 
@@ -67,6 +68,32 @@ var (
 		}
 		panic("unrecognized build.Release")
 	}()
+
+	// MinSourceBlockAge is the minimum amount of time that must elapse
+	// before a source block is replaced because transactions with higher fees
+	// have entered the transaction pool. New blocks always replace the source
+	// block immediately.
+	MinSourceBlockAge = func() time.Duration {
+		if build.Release == "dev" {
+			return 1 * time.Second
+		}
+		if build.Release == "standard" {
+			return 5 * time.Second
+		}
+		if build.Release == "testing" {
+			return 0
+		}
+		panic("unrecognized build.Release")
+	}()
+)
+
+const (
+	// sourceBlockFeeIncrease is the factor by which the fees available in the
+	// transaction pool must exceed the fees of the source block before the
+	// source block is replaced, expressed as a divisor of the fees of the
+	// source block. A value of 10 means that the fees must increase by more
+	// than 10%.
+	sourceBlockFeeIncrease = 10
 )
 
 // Miner struct contains all variables the miner needs
@@ -94,11 +121,29 @@ type Miner struct {
 	sourceBlock     *types.Block                                   // The block from which new headers for mining are created.
 	sourceBlockTime time.Time                                      // How long headers have been using the same block (different from 'recent block').
 	memProgress     int                                            // The index of the most recent header used in headerMem.
+	parentTimestamp types.Timestamp                                // The timestamp of the parent of the unsolved block, zero if unknown.
 
 	// CPUMiner variables.
 	miningOn bool  // indicates if the miner is supposed to be running
 	mining   bool  // indicates if the miner is actually running
 	hashRate int64 // indicates hashes per second
+	threads  int   // the number of threads that the miner grinds with
+	cpuLimit int   // the percentage of cpu time that each thread may use
+
+	// Pool mining variables. The pool client runs in its own goroutine until
+	// poolStop is closed.
+	poolStatus modules.PoolStatus
+	poolJob    *poolJob     // the most recent job sent by the pool
+	poolTarget types.Target // the target that shares must meet
+	poolStop   chan struct{}
+
+	// subscribers are notified each time the miner finds a block.
+	subscribers []modules.MinerSubscriber
+
+	// priorityTxns are the transactions that are included in blocks
+	// regardless of their fees, along with the height after which they are
+	// no longer prioritized.
+	priorityTxns map[types.TransactionID]types.BlockHeight
 
 	// Utils
 	log        *persist.Logger
@@ -170,6 +215,11 @@ func New(cs modules.ConsensusSet, tpool modules.TransactionPool, w modules.Walle
 		arbDataMem: make(map[types.BlockHeader][crypto.EntropySize]byte),
 		headerMem:  make([]types.BlockHeader, HeaderMemory),
 
+		priorityTxns: make(map[types.TransactionID]types.BlockHeight),
+
+		threads:  runtime.NumCPU(),
+		cpuLimit: 100,
+
 		persistDir: persistDir,
 	}
 
@@ -194,6 +244,12 @@ func New(cs modules.ConsensusSet, tpool modules.TransactionPool, w modules.Walle
 		m.cs.Unsubscribe(m)
 	})
 
+	// The consensus set only reports the parent of the unsolved block if it
+	// changed while the miner was offline.
+	if cb := m.cs.CurrentBlock(); cb.ID() == m.persist.UnsolvedBlock.ParentID {
+		m.parentTimestamp = cb.Timestamp
+	}
+
 	m.tpool.TransactionPoolSubscribe(m)
 	m.tg.OnStop(func() {
 		m.tpool.Unsubscribe(m)
@@ -230,6 +286,16 @@ func (m *Miner) Close() error {
 	return build.JoinErrors(errs, "; ")
 }
 
+// blockTarget returns the target that a block with the given timestamp must
+// meet. On networks with a minimum difficulty gap, a block found long enough
+// after its parent only needs to meet the root target.
+func (m *Miner) blockTarget(timestamp types.Timestamp) types.Target {
+	if types.MinDifficultyGap != 0 && m.parentTimestamp != 0 && timestamp > m.parentTimestamp+types.MinDifficultyGap {
+		return types.RootTarget
+	}
+	return m.persist.Target
+}
+
 // checkAddress checks that the miner has an address, fetching an address from
 // the wallet if not.
 func (m *Miner) checkAddress() error {
@@ -254,7 +320,12 @@ func (m *Miner) BlocksMined() (goodBlocks, staleBlocks int) {
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	return m.blocksMined()
+}
 
+// blocksMined counts the good blocks and stale blocks that have been mined
+// by the miner.
+func (m *Miner) blocksMined() (goodBlocks, staleBlocks int) {
 	for _, blockID := range m.persist.BlocksFound {
 		if m.cs.InCurrentPath(blockID) {
 			goodBlocks++
@@ -0,0 +1,112 @@
+package miner
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// maxPayoutSplits is the largest number of addresses that the block
+	// subsidy can be split between.
+	maxPayoutSplits = 16
+)
+
+var (
+	errPayoutPercentage = errors.New("payout split percentages must add up to 100")
+	errTooManySplits    = errors.New("too many payout splits")
+	errZeroPercentage   = errors.New("payout split must have a percentage of at least 1")
+)
+
+// validatePayoutSplits returns an error if the payout splits cannot be used
+// to pay out a block subsidy.
+func validatePayoutSplits(splits []modules.MinerPayoutSplit) error {
+	if len(splits) == 0 {
+		return nil
+	}
+	if len(splits) > maxPayoutSplits {
+		return errTooManySplits
+	}
+	var total uint64
+	for _, split := range splits {
+		if split.Percentage == 0 {
+			return errZeroPercentage
+		}
+		total += split.Percentage
+		if total > 100 {
+			return errPayoutPercentage
+		}
+	}
+	if total != 100 {
+		return errPayoutPercentage
+	}
+	return nil
+}
+
+// minerPayouts returns the miner payouts that pay out a block subsidy. If
+// payout splits are set, the subsidy is split between them, with any
+// rounding error paid to the last split. Otherwise the subsidy is paid to the
+// wallet address of the miner.
+func (m *Miner) minerPayouts(subsidy types.Currency) []types.SiacoinOutput {
+	if len(m.persist.PayoutSplits) == 0 {
+		return []types.SiacoinOutput{{Value: subsidy, UnlockHash: m.persist.Address}}
+	}
+	payouts := make([]types.SiacoinOutput, len(m.persist.PayoutSplits))
+	remaining := subsidy
+	for i, split := range m.persist.PayoutSplits {
+		value := remaining
+		if i != len(m.persist.PayoutSplits)-1 {
+			value = subsidy.Mul64(split.Percentage).Div64(100)
+			remaining = remaining.Sub(value)
+		}
+		payouts[i] = types.SiacoinOutput{Value: value, UnlockHash: split.UnlockHash}
+	}
+	return payouts
+}
+
+// checkPayouts checks that the miner has somewhere to pay the block subsidy.
+// If payout splits are set, the wallet is not needed. Otherwise, the wallet
+// must be unlocked so that the miner can fetch an address.
+func (m *Miner) checkPayouts() error {
+	if len(m.persist.PayoutSplits) != 0 {
+		return nil
+	}
+	if !m.wallet.Unlocked() {
+		return modules.ErrLockedWallet
+	}
+	return m.checkAddress()
+}
+
+// PayoutSplits returns the addresses that the block subsidy is split between.
+func (m *Miner) PayoutSplits() []modules.MinerPayoutSplit {
+	if err := m.tg.Add(); err != nil {
+		build.Critical(err)
+	}
+	defer m.tg.Done()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]modules.MinerPayoutSplit(nil), m.persist.PayoutSplits...)
+}
+
+// SetPayoutSplits sets the addresses that the block subsidy is split between.
+// The source block is replaced so that new work pays out to the new
+// addresses.
+func (m *Miner) SetPayoutSplits(splits []modules.MinerPayoutSplit) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+
+	if err := validatePayoutSplits(splits); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.persist.PayoutSplits = append([]modules.MinerPayoutSplit(nil), splits...)
+	m.newSourceBlock()
+	return m.saveSync()
+}
@@ -0,0 +1,114 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestValidatePayoutSplits probes the validatePayoutSplits function.
+func TestValidatePayoutSplits(t *testing.T) {
+	tests := []struct {
+		percentages []uint64
+		err         error
+	}{
+		{nil, nil},
+		{[]uint64{100}, nil},
+		{[]uint64{60, 30, 10}, nil},
+		{[]uint64{50, 49}, errPayoutPercentage},
+		{[]uint64{50, 51}, errPayoutPercentage},
+		{[]uint64{100, 0}, errZeroPercentage},
+		{make([]uint64, maxPayoutSplits+1), errTooManySplits},
+	}
+	for _, test := range tests {
+		var splits []modules.MinerPayoutSplit
+		for _, p := range test.percentages {
+			splits = append(splits, modules.MinerPayoutSplit{Percentage: p})
+		}
+		if err := validatePayoutSplits(splits); err != test.err {
+			t.Errorf("validatePayoutSplits(%v): expected %v, got %v", test.percentages, test.err, err)
+		}
+	}
+}
+
+// TestMinerPayouts checks that the subsidy is split between the payout
+// splits, and that the payouts add up to the subsidy.
+func TestMinerPayouts(t *testing.T) {
+	m := new(Miner)
+	m.persist.Address = types.UnlockHash{1}
+	subsidy := types.NewCurrency64(1001)
+
+	// Without splits, the subsidy is paid to the wallet address.
+	payouts := m.minerPayouts(subsidy)
+	if len(payouts) != 1 || payouts[0].UnlockHash != m.persist.Address || payouts[0].Value.Cmp(subsidy) != 0 {
+		t.Fatal("wrong payouts without splits:", payouts)
+	}
+
+	// With splits, the rounding error is paid to the last split.
+	m.persist.PayoutSplits = []modules.MinerPayoutSplit{
+		{UnlockHash: types.UnlockHash{2}, Percentage: 70},
+		{UnlockHash: types.UnlockHash{3}, Percentage: 30},
+	}
+	payouts = m.minerPayouts(subsidy)
+	if len(payouts) != 2 {
+		t.Fatal("wrong number of payouts:", len(payouts))
+	}
+	if payouts[0].UnlockHash != (types.UnlockHash{2}) || payouts[0].Value.Cmp(types.NewCurrency64(700)) != 0 {
+		t.Error("wrong first payout:", payouts[0])
+	}
+	if payouts[1].UnlockHash != (types.UnlockHash{3}) || payouts[1].Value.Cmp(types.NewCurrency64(301)) != 0 {
+		t.Error("wrong second payout:", payouts[1])
+	}
+}
+
+// TestIntegrationPayoutSplits checks that blocks found by the miner pay the
+// payout splits, and that the wallet is not needed to mine with them.
+func TestIntegrationPayoutSplits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationPayoutSplits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	splits := []modules.MinerPayoutSplit{
+		{UnlockHash: types.UnlockHash{1}, Percentage: 25},
+		{UnlockHash: types.UnlockHash{2}, Percentage: 75},
+	}
+	if err := mt.miner.SetPayoutSplits(splits[:1]); err != errPayoutPercentage {
+		t.Fatal("expected errPayoutPercentage, got", err)
+	}
+	if err := mt.miner.SetPayoutSplits(splits); err != nil {
+		t.Fatal(err)
+	}
+	if len(mt.miner.PayoutSplits()) != 2 {
+		t.Fatal("payout splits were not set")
+	}
+
+	// Mine a block with the wallet locked.
+	if err := mt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	b, target, err := mt.miner.BlockTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mt.miner.SubmitHeader(solveHeader(b.Header(), target)); err != nil {
+		t.Fatal(err)
+	}
+	payouts := mt.cs.CurrentBlock().MinerPayouts
+	if len(payouts) != 2 || payouts[0].UnlockHash != splits[0].UnlockHash || payouts[1].UnlockHash != splits[1].UnlockHash {
+		t.Fatal("block does not pay the payout splits:", payouts)
+	}
+
+	// Removing the splits requires the wallet again.
+	if err := mt.miner.SetPayoutSplits(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := mt.miner.BlockTemplate(); err != modules.ErrLockedWallet {
+		t.Fatal("expected ErrLockedWallet, got", err)
+	}
+}
@@ -30,6 +30,7 @@ type (
 		Address       types.UnlockHash
 		BlocksFound   []types.BlockID
 		UnsolvedBlock types.Block
+		PayoutSplits  []modules.MinerPayoutSplit
 	}
 )
 
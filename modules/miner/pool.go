@@ -0,0 +1,414 @@
+package miner
+
+// pool.go implements a client for stratum-like mining pools. The client and
+// the pool exchange newline-delimited JSON messages. The client subscribes
+// with mining.subscribe, registers its payout address with mining.authorize,
+// and may suggest a share difficulty with mining.suggest_difficulty. The pool
+// sets the share difficulty with mining.set_difficulty, and sends jobs with
+// mining.notify. A job is a block header to grind on, hex encoded, and the
+// pool is responsible for turning the shares that meet the network target
+// into blocks. Shares are submitted with mining.submit, which carries the
+// payout address, the job id, and the hex encoded nonce.
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// The ids of the requests made during the handshake. Shares are submitted
+	// with ids starting from stratumFirstSubmitID.
+	stratumSubscribeID   = 1
+	stratumAuthorizeID   = 2
+	stratumSuggestID     = 3
+	stratumFirstSubmitID = 4
+
+	// poolIdleInterval is how long the pool client waits before checking for
+	// work again when it has no job or no share difficulty.
+	poolIdleInterval = 100 * time.Millisecond
+)
+
+var (
+	errNoPoolPayout     = errors.New("pool mining requires a payout address")
+	errPoolBadJob       = errors.New("pool sent a malformed job")
+	errPoolBadDiff      = errors.New("pool sent an invalid share difficulty")
+	errPoolMining       = errors.New("miner is already mining for a pool")
+	errPoolUnauthorized = errors.New("pool rejected the payout address")
+
+	// poolDialTimeout is the timeout for connecting to a pool.
+	poolDialTimeout = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 20 * time.Second
+		case "standard":
+			return 30 * time.Second
+		case "testing":
+			return 5 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// poolReconnectInterval is how long the pool client waits before
+	// reconnecting after the connection to the pool is lost.
+	poolReconnectInterval = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 10 * time.Second
+		case "standard":
+			return 30 * time.Second
+		case "testing":
+			return 100 * time.Millisecond
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+type (
+	// A poolJob is a block header sent by the pool for grinding.
+	poolJob struct {
+		id     string
+		header types.BlockHeader
+	}
+
+	// A stratumRequest is a message sent by the client to the pool.
+	stratumRequest struct {
+		ID     uint64        `json:"id"`
+		Method string        `json:"method"`
+		Params []interface{} `json:"params"`
+	}
+
+	// A stratumMessage is a message sent by the pool to the client. Responses
+	// to requests carry an id, while notifications carry a method.
+	stratumMessage struct {
+		ID     *uint64           `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+		Result json.RawMessage   `json:"result"`
+		Error  json.RawMessage   `json:"error"`
+	}
+
+	// A poolSession is a single connection to a pool.
+	poolSession struct {
+		conn   net.Conn
+		enc    *json.Encoder
+		nextID uint64
+		payout types.UnlockHash
+		mu     sync.Mutex
+	}
+)
+
+// succeeded returns true if the message is a response that reports success.
+func (msg stratumMessage) succeeded() bool {
+	if len(msg.Error) != 0 && string(msg.Error) != "null" {
+		return false
+	}
+	var result bool
+	return json.Unmarshal(msg.Result, &result) == nil && result
+}
+
+// shareTarget returns the target that shares must meet for a share
+// difficulty.
+func shareTarget(difficulty uint64) types.Target {
+	return types.IntToTarget(new(big.Int).Div(types.RootDepth.Int(), new(big.Int).SetUint64(difficulty)))
+}
+
+// send sends a request to the pool.
+func (ps *poolSession) send(id uint64, method string, params ...interface{}) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return ps.enc.Encode(stratumRequest{ID: id, Method: method, Params: params})
+}
+
+// submit submits a share to the pool.
+func (ps *poolSession) submit(jobID string, nonce types.BlockNonce) error {
+	ps.mu.Lock()
+	id := ps.nextID
+	ps.nextID++
+	ps.mu.Unlock()
+	return ps.send(id, "mining.submit", ps.payout.String(), jobID, hex.EncodeToString(nonce[:]))
+}
+
+// handlePoolMessage processes a message sent by the pool. Messages that
+// arrive after pool mining has been stopped are ignored, so that they do not
+// affect a later session.
+func (m *Miner) handlePoolMessage(msg stratumMessage, stop <-chan struct{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	select {
+	case <-stop:
+		return nil
+	default:
+	}
+
+	switch {
+	case msg.Method == "mining.notify":
+		var id, headerHex string
+		if len(msg.Params) < 2 || json.Unmarshal(msg.Params[0], &id) != nil || json.Unmarshal(msg.Params[1], &headerHex) != nil {
+			return errPoolBadJob
+		}
+		headerBytes, err := hex.DecodeString(headerHex)
+		if err != nil || len(headerBytes) != types.BlockHeaderSize {
+			return errPoolBadJob
+		}
+		var header types.BlockHeader
+		if err := encoding.Unmarshal(headerBytes, &header); err != nil {
+			return errPoolBadJob
+		}
+		m.poolJob = &poolJob{id: id, header: header}
+
+	case msg.Method == "mining.set_difficulty":
+		var difficulty uint64
+		if len(msg.Params) < 1 || json.Unmarshal(msg.Params[0], &difficulty) != nil || difficulty == 0 {
+			return errPoolBadDiff
+		}
+		m.poolStatus.Difficulty = difficulty
+		m.poolTarget = shareTarget(difficulty)
+
+	case msg.ID != nil && *msg.ID == stratumAuthorizeID:
+		if !msg.succeeded() {
+			return errPoolUnauthorized
+		}
+		m.poolStatus.LastError = ""
+
+	case msg.ID != nil && *msg.ID >= stratumFirstSubmitID:
+		if msg.succeeded() {
+			m.poolStatus.SharesAccepted++
+		} else {
+			m.poolStatus.SharesRejected++
+		}
+	}
+	return nil
+}
+
+// threadedGrindPoolJobs grinds on the most recent job sent by the pool,
+// submitting the shares that meet the share target, until 'done' is closed.
+func (m *Miner) threadedGrindPoolJobs(ps *poolSession, done <-chan struct{}, stop <-chan struct{}) {
+	var current *poolJob
+	var nonce uint64
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		m.mu.RLock()
+		job, target, threads := m.poolJob, m.poolTarget, m.threads
+		m.mu.RUnlock()
+		if job == nil || target == (types.Target{}) {
+			select {
+			case <-done:
+				return
+			case <-time.After(poolIdleInterval):
+			}
+			continue
+		}
+		if job != current {
+			current = job
+			nonce = 0
+		}
+
+		roundStart := time.Now()
+		shares := solveHeaderParallel(job.header, target, threads, nonce)
+		nonce += uint64(threads) * solveAttempts
+		for _, share := range shares {
+			if err := ps.submit(job.id, share.Nonce); err != nil {
+				// The read loop will notice that the connection failed.
+				return
+			}
+		}
+		m.managedThrottle(time.Since(roundStart))
+
+		nanosecondsElapsed := 1 + time.Since(roundStart).Nanoseconds() // Add 1 to prevent divide by zero errors.
+		m.mu.Lock()
+		select {
+		case <-stop:
+		default:
+			m.poolStatus.Hashrate = int(1e9 * int64(threads) * solveAttempts / nanosecondsElapsed)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// managedPoolSession connects to a pool and mines for it until the connection
+// fails or 'stop' is closed.
+func (m *Miner) managedPoolSession(pool modules.NetAddress, payout types.UnlockHash, difficulty uint64, stop <-chan struct{}) error {
+	conn, err := modules.Dial(pool, poolDialTimeout)
+	if err != nil {
+		return err
+	}
+
+	// Close the connection when pool mining is stopped or the miner shuts
+	// down, which unblocks the read loop.
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+		case <-m.tg.StopChan():
+		case <-done:
+		}
+		conn.Close()
+	}()
+
+	m.mu.Lock()
+	m.poolStatus.Connected = true
+	m.mu.Unlock()
+
+	ps := &poolSession{
+		conn:   conn,
+		enc:    json.NewEncoder(conn),
+		nextID: stratumFirstSubmitID,
+		payout: payout,
+	}
+	if err := ps.send(stratumSubscribeID, "mining.subscribe", "sia/"+build.Version); err != nil {
+		return err
+	}
+	if err := ps.send(stratumAuthorizeID, "mining.authorize", payout.String(), ""); err != nil {
+		return err
+	}
+	if difficulty != 0 {
+		if err := ps.send(stratumSuggestID, "mining.suggest_difficulty", difficulty); err != nil {
+			return err
+		}
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.threadedGrindPoolJobs(ps, done, stop)
+	}()
+
+	dec := json.NewDecoder(conn)
+	for {
+		var msg stratumMessage
+		if err := dec.Decode(&msg); err != nil {
+			return err
+		}
+		if err := m.handlePoolMessage(msg, stop); err != nil {
+			return err
+		}
+	}
+}
+
+// threadedPoolMine mines for a pool, reconnecting whenever the connection is
+// lost, until 'stop' is closed.
+func (m *Miner) threadedPoolMine(pool modules.NetAddress, payout types.UnlockHash, difficulty uint64, stop <-chan struct{}) {
+	if err := m.tg.Add(); err != nil {
+		return
+	}
+	defer m.tg.Done()
+
+	for {
+		err := m.managedPoolSession(pool, payout, difficulty, stop)
+		select {
+		case <-stop:
+			return
+		case <-m.tg.StopChan():
+			return
+		default:
+		}
+
+		m.log.Println("WARN: lost connection to mining pool:", err)
+		m.mu.Lock()
+		m.poolStatus.Connected = false
+		m.poolStatus.Hashrate = 0
+		m.poolStatus.LastError = err.Error()
+		m.poolJob = nil
+		m.mu.Unlock()
+
+		select {
+		case <-stop:
+			return
+		case <-m.tg.StopChan():
+			return
+		case <-time.After(poolReconnectInterval):
+		}
+	}
+}
+
+// stopPoolMining stops the pool client, if it is running.
+func (m *Miner) stopPoolMining() {
+	if m.poolStop != nil {
+		close(m.poolStop)
+		m.poolStop = nil
+	}
+	m.poolStatus.PoolMining = false
+	m.poolStatus.Connected = false
+	m.poolStatus.Hashrate = 0
+	m.poolJob = nil
+}
+
+// StartPoolMining connects to a pool and starts contributing shares to it.
+// The cpu miner is stopped, as both grind with the same threads.
+func (m *Miner) StartPoolMining(pool modules.NetAddress, payout types.UnlockHash, difficulty uint64) error {
+	if err := m.tg.Add(); err != nil {
+		return err
+	}
+	defer m.tg.Done()
+
+	if _, _, err := net.SplitHostPort(string(pool)); err != nil {
+		return err
+	}
+	if payout == (types.UnlockHash{}) {
+		return errNoPoolPayout
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.poolStop != nil {
+		return errPoolMining
+	}
+	m.hashRate = 0
+	m.miningOn = false
+
+	m.poolStop = make(chan struct{})
+	m.poolStatus = modules.PoolStatus{
+		PoolMining:    true,
+		Pool:          pool,
+		PayoutAddress: payout,
+		Difficulty:    difficulty,
+	}
+	m.poolTarget = types.Target{}
+	m.poolJob = nil
+	go m.threadedPoolMine(pool, payout, difficulty, m.poolStop)
+	return nil
+}
+
+// StopPoolMining disconnects from the pool.
+func (m *Miner) StopPoolMining() {
+	if err := m.tg.Add(); err != nil {
+		build.Critical(err)
+	}
+	defer m.tg.Done()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopPoolMining()
+}
+
+// PoolStatus returns the state of the pool client.
+func (m *Miner) PoolStatus() modules.PoolStatus {
+	if err := m.tg.Add(); err != nil {
+		build.Critical(err)
+	}
+	defer m.tg.Done()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.poolStatus
+}
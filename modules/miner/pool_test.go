@@ -0,0 +1,204 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// A testPool is a minimal stratum-like pool that accepts a single connection,
+// sends one job, and checks the shares submitted against the share target.
+type testPool struct {
+	listener   net.Listener
+	authorize  bool
+	difficulty uint64
+	header     types.BlockHeader
+
+	shares chan types.BlockHeader
+	payout chan string
+}
+
+// newTestPool creates a testPool listening on a random local port.
+func newTestPool(authorize bool, difficulty uint64, t *testing.T) *testPool {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp := &testPool{
+		listener:   l,
+		authorize:  authorize,
+		difficulty: difficulty,
+		header:     types.BlockHeader{Timestamp: types.CurrentTimestamp()},
+		shares:     make(chan types.BlockHeader, 100),
+		payout:     make(chan string, 1),
+	}
+	go tp.serve()
+	return tp
+}
+
+// address returns the address of the testPool.
+func (tp *testPool) address() modules.NetAddress {
+	return modules.NetAddress(tp.listener.Addr().String())
+}
+
+// serve handles a single client.
+func (tp *testPool) serve() {
+	conn, err := tp.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+	target := shareTarget(tp.difficulty)
+	for {
+		var req struct {
+			ID     uint64            `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		switch req.Method {
+		case "mining.subscribe":
+			enc.Encode(map[string]interface{}{"id": req.ID, "result": "subscription", "error": nil})
+		case "mining.authorize":
+			var payout string
+			json.Unmarshal(req.Params[0], &payout)
+			tp.payout <- payout
+			enc.Encode(map[string]interface{}{"id": req.ID, "result": tp.authorize, "error": nil})
+			if !tp.authorize {
+				return
+			}
+			enc.Encode(map[string]interface{}{"id": nil, "method": "mining.set_difficulty", "params": []interface{}{tp.difficulty}})
+			enc.Encode(map[string]interface{}{"id": nil, "method": "mining.notify", "params": []interface{}{"job1", hex.EncodeToString(encoding.Marshal(tp.header)), true}})
+		case "mining.submit":
+			var jobID, nonceHex string
+			json.Unmarshal(req.Params[1], &jobID)
+			json.Unmarshal(req.Params[2], &nonceHex)
+			share := tp.header
+			nonce, _ := hex.DecodeString(nonceHex)
+			copy(share.Nonce[:], nonce)
+			id := crypto.HashObject(share)
+			valid := jobID == "job1" && bytes.Compare(target[:], id[:]) >= 0
+			enc.Encode(map[string]interface{}{"id": req.ID, "result": valid, "error": nil})
+			tp.shares <- share
+		}
+	}
+}
+
+// TestShareTarget checks that share targets get smaller as the share
+// difficulty increases.
+func TestShareTarget(t *testing.T) {
+	if shareTarget(1) != types.RootDepth {
+		t.Fatal("difficulty 1 should allow every hash")
+	}
+	if shareTarget(2).Cmp(shareTarget(1)) >= 0 || shareTarget(1000).Cmp(shareTarget(2)) >= 0 {
+		t.Fatal("share targets do not decrease as the difficulty increases")
+	}
+}
+
+// TestIntegrationPoolMining checks that the miner submits valid shares to a
+// pool, and that the pool client can be stopped.
+func TestIntegrationPoolMining(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationPoolMining")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+	tp := newTestPool(true, 1000, t)
+	defer tp.listener.Close()
+
+	payout := types.UnlockHash{1, 2, 3}
+	if err := mt.miner.StartPoolMining(tp.address(), types.UnlockHash{}, 0); err != errNoPoolPayout {
+		t.Fatal("expected errNoPoolPayout, got", err)
+	}
+	mt.miner.StartCPUMining()
+	if err := mt.miner.StartPoolMining(tp.address(), payout, 0); err != nil {
+		t.Fatal(err)
+	}
+	if mt.miner.CPUMining() {
+		t.Fatal("starting pool mining did not stop the cpu miner")
+	}
+	if err := mt.miner.StartPoolMining(tp.address(), payout, 0); err != errPoolMining {
+		t.Fatal("expected errPoolMining, got", err)
+	}
+
+	// The pool receives the payout address and valid shares.
+	select {
+	case p := <-tp.payout:
+		if p != payout.String() {
+			t.Fatal("pool received the wrong payout address:", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("pool did not receive the payout address")
+	}
+	target := shareTarget(1000)
+	for i := 0; i < 3; i++ {
+		select {
+		case share := <-tp.shares:
+			if id := crypto.HashObject(share); bytes.Compare(target[:], id[:]) < 0 {
+				t.Fatal("miner submitted a share that does not meet the share target")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("pool did not receive any shares")
+		}
+	}
+	var status modules.PoolStatus
+	for i := 0; i < 50; i++ {
+		status = mt.miner.PoolStatus()
+		if status.SharesAccepted >= 3 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !status.PoolMining || !status.Connected || status.Difficulty != 1000 || status.SharesAccepted < 3 || status.SharesRejected != 0 {
+		t.Fatal("wrong pool status:", status)
+	}
+
+	// Starting the cpu miner stops pool mining.
+	mt.miner.StartCPUMining()
+	defer mt.miner.StopCPUMining()
+	if status := mt.miner.PoolStatus(); status.PoolMining || status.Connected {
+		t.Fatal("starting the cpu miner did not stop pool mining:", status)
+	}
+}
+
+// TestIntegrationPoolUnauthorized checks that the pool client reports a pool
+// that rejects its payout address.
+func TestIntegrationPoolUnauthorized(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationPoolUnauthorized")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+	tp := newTestPool(false, 1000, t)
+	defer tp.listener.Close()
+
+	if err := mt.miner.StartPoolMining(tp.address(), types.UnlockHash{1}, 0); err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.StopPoolMining()
+	for i := 0; i < 50; i++ {
+		if mt.miner.PoolStatus().LastError == errPoolUnauthorized.Error() {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatal("pool client did not report the rejected payout address:", mt.miner.PoolStatus())
+}
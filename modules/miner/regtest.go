@@ -0,0 +1,76 @@
+package miner
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	siasync "github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// maxMineBlocks is the largest number of blocks that can be mined with a
+	// single call to MineBlocks.
+	maxMineBlocks = 1000
+)
+
+var (
+	errMineBlocksCount    = errors.New("number of blocks to mine must be between 1 and 1000")
+	errMineBlocksDisabled = errors.New("blocks can only be mined on demand on the regtest network, or in dev and testing builds")
+)
+
+// mineBlocksEnabled returns true if blocks can be mined on demand. On-demand
+// mining grinds until a block is found, which is only practical at the
+// trivial difficulty of the regtest network and of dev and testing builds.
+func mineBlocksEnabled() bool {
+	return build.Release != "standard" || types.CurrentNetwork().Name == "regtest"
+}
+
+// MineBlocks mines 'n' blocks on top of the current block, including the
+// transactions in the transaction pool, and returns the ids of the blocks.
+func (m *Miner) MineBlocks(n int) ([]types.BlockID, error) {
+	if err := m.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer m.tg.Done()
+
+	if !mineBlocksEnabled() {
+		return nil, errMineBlocksDisabled
+	}
+	if n < 1 || n > maxMineBlocks {
+		return nil, errMineBlocksCount
+	}
+
+	var ids []types.BlockID
+	for i := 0; i < n; i++ {
+		m.mu.Lock()
+		err := m.checkPayouts()
+		bfw := m.blockForWork()
+		target := m.blockTarget(bfw.Timestamp)
+		threads := m.threads
+		m.mu.Unlock()
+		if err != nil {
+			return ids, err
+		}
+
+		// Grind until the block is solved.
+		header := bfw.Header()
+		for start := uint64(0); ; start += uint64(threads) * solveAttempts {
+			select {
+			case <-m.tg.StopChan():
+				return ids, siasync.ErrStopped
+			default:
+			}
+			if solved := solveHeaderParallel(header, target, threads, start); len(solved) != 0 {
+				bfw.Nonce = solved[0].Nonce
+				break
+			}
+		}
+
+		if err := m.managedSubmitBlock(bfw); err != nil {
+			return ids, err
+		}
+		ids = append(ids, bfw.ID())
+	}
+	return ids, nil
+}
@@ -0,0 +1,50 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestIntegrationMineBlocks checks that MineBlocks extends the blockchain by
+// the requested number of blocks.
+func TestIntegrationMineBlocks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationMineBlocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	if _, err := mt.miner.MineBlocks(0); err != errMineBlocksCount {
+		t.Fatal("expected errMineBlocksCount, got", err)
+	}
+	if _, err := mt.miner.MineBlocks(maxMineBlocks + 1); err != errMineBlocksCount {
+		t.Fatal("expected errMineBlocksCount, got", err)
+	}
+
+	// Mine blocks that include a transaction from the pool.
+	_, err = mt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	startHeight := mt.cs.Height()
+	ids, err := mt.miner.MineBlocks(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 5 || mt.cs.Height() != startHeight+5 {
+		t.Fatal("wrong number of blocks mined:", len(ids), mt.cs.Height()-startHeight)
+	}
+	if mt.cs.CurrentBlock().ID() != ids[4] {
+		t.Fatal("the last block mined is not the current block")
+	}
+	if len(mt.tpool.TransactionList()) != 0 {
+		t.Fatal("the transaction pool was not mined")
+	}
+	if good, _ := mt.miner.BlocksMined(); good < 5 {
+		t.Fatal("mined blocks were not recorded:", good)
+	}
+}
@@ -0,0 +1,35 @@
+package miner
+
+import (
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// updateSubscribers sends a found block to all subscribers.
+func (m *Miner) updateSubscribers(fb modules.FoundBlock) {
+	for _, subscriber := range m.subscribers {
+		subscriber.ReceiveFoundBlock(fb)
+	}
+}
+
+// MinerSubscribe adds a subscriber to the miner. Subscribers will receive
+// every block found by the miner from now on.
+func (m *Miner) MinerSubscribe(subscriber modules.MinerSubscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, subscriber)
+}
+
+// Unsubscribe removes a subscriber from the miner. If the subscriber is not
+// in m.subscribers, Unsubscribe does nothing. If the subscriber occurs more
+// than once in m.subscribers, only the earliest occurrence is removed.
+func (m *Miner) Unsubscribe(subscriber modules.MinerSubscriber) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.subscribers {
+		if m.subscribers[i] == subscriber {
+			m.subscribers = append(m.subscribers[0:i], m.subscribers[i+1:]...)
+			break
+		}
+	}
+}
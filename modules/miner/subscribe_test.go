@@ -0,0 +1,61 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// foundBlockSubscriber records the blocks found by the miner.
+type foundBlockSubscriber struct {
+	blocks []modules.FoundBlock
+}
+
+// ReceiveFoundBlock records a found block.
+func (fbs *foundBlockSubscriber) ReceiveFoundBlock(fb modules.FoundBlock) {
+	fbs.blocks = append(fbs.blocks, fb)
+}
+
+// TestIntegrationMinerSubscribe checks that subscribers are notified of the
+// blocks found by the miner until they unsubscribe.
+func TestIntegrationMinerSubscribe(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationMinerSubscribe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	fbs := new(foundBlockSubscriber)
+	mt.miner.MinerSubscribe(fbs)
+	ids, err := mt.miner.MineBlocks(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fbs.blocks) != 2 {
+		t.Fatal("expected 2 found blocks, got", len(fbs.blocks))
+	}
+	for i, fb := range fbs.blocks {
+		if fb.ID != ids[i] {
+			t.Fatal("found block has the wrong id")
+		}
+		if fb.Height != mt.cs.Height()-types.BlockHeight(len(ids)-1-i) {
+			t.Fatal("found block has the wrong height:", fb.Height)
+		}
+		if fb.Payout.Cmp(types.CalculateCoinbase(fb.Height)) < 0 {
+			t.Fatal("found block payout is less than the block subsidy:", fb.Payout)
+		}
+	}
+
+	// Unsubscribed subscribers are not notified.
+	mt.miner.Unsubscribe(fbs)
+	if _, err := mt.miner.MineBlocks(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(fbs.blocks) != 2 {
+		t.Fatal("unsubscribed subscriber was notified")
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
-	"unsafe"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
@@ -16,7 +15,8 @@ import (
 
 const (
 	// solveAttempts is the number of times that SolveBlock will try to solve a
-	// block before giving up.
+	// block before giving up. It is also the number of nonces that each
+	// thread of the cpu miner tries in a round.
 	solveAttempts = 16e3
 )
 
@@ -24,24 +24,39 @@ const (
 // target. A bool is returned indicating whether the block was successfully
 // solved.
 func solveBlock(b types.Block, target types.Target) (types.Block, bool) {
+	return solveBlockRange(b, target, 0, solveAttempts)
+}
+
+// solveBlockRange tries to solve a block for the target using the nonces
+// from 'start' to 'start+attempts'. A bool is returned indicating whether the
+// block was successfully solved.
+func solveBlockRange(b types.Block, target types.Target, start uint64, attempts uint64) (types.Block, bool) {
+	h, solved := solveHeaderRange(b.Header(), target, start, attempts)
+	if solved {
+		b.Nonce = h.Nonce
+	}
+	return b, solved
+}
+
+// solveHeaderRange tries to solve a header for the target using the nonces
+// from 'start' to 'start+attempts'. A bool is returned indicating whether the
+// header was successfully solved.
+func solveHeaderRange(h types.BlockHeader, target types.Target, start uint64, attempts uint64) (types.BlockHeader, bool) {
 	// Assemble the header.
-	merkleRoot := b.MerkleRoot()
-	header := make([]byte, 80)
-	copy(header, b.ParentID[:])
-	binary.LittleEndian.PutUint64(header[40:48], uint64(b.Timestamp))
-	copy(header[48:], merkleRoot[:])
-
-	var nonce uint64
-	for i := 0; i < solveAttempts; i++ {
+	header := make([]byte, types.BlockHeaderSize)
+	copy(header, h.ParentID[:])
+	binary.LittleEndian.PutUint64(header[40:48], uint64(h.Timestamp))
+	copy(header[48:], h.MerkleRoot[:])
+
+	for nonce := start; nonce < start+attempts; nonce++ {
+		binary.LittleEndian.PutUint64(header[32:40], nonce)
 		id := crypto.HashBytes(header)
 		if bytes.Compare(target[:], id[:]) >= 0 {
-			copy(b.Nonce[:], header[32:40])
-			return b, true
+			copy(h.Nonce[:], header[32:40])
+			return h, true
 		}
-		*(*uint64)(unsafe.Pointer(&header[32])) = nonce
-		nonce++
 	}
-	return b, false
+	return h, false
 }
 
 // BlockForWork returns a block that is ready for nonce grinding, along with
@@ -61,7 +76,7 @@ func (m *Miner) BlockForWork() (b types.Block, t types.Target, err error) {
 	}
 
 	b = m.blockForWork()
-	return b, m.persist.Target, nil
+	return b, m.blockTarget(b.Timestamp), nil
 }
 
 // AddBlock adds a block to the consensus set.
@@ -95,7 +110,7 @@ func (m *Miner) FindBlock() (types.Block, error) {
 
 		// Get a block for work.
 		bfw = m.blockForWork()
-		target = m.persist.Target
+		target = m.blockTarget(bfw.Timestamp)
 		return nil
 	}()
 	if err != nil {
@@ -1,7 +1,8 @@
 package miner
 
 import (
-	"github.com/NebulousLabs/Sia/encoding"
+	"time"
+
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -45,9 +46,17 @@ func (m *Miner) ProcessConsensusChange(cc modules.ConsensusChange) {
 		}
 	}
 
+	m.prunePriorityTransactions(cc.AppliedBlocks)
+
+	// Remove the transactions that the change confirmed or invalidated, so
+	// that the unsolved block stays valid until the transaction pool sends
+	// its updated set.
+	m.persist.UnsolvedBlock.Transactions = pruneTransactions(m.persist.UnsolvedBlock.Transactions, cc)
+
 	// Update the unsolved block.
 	var exists1, exists2 bool
 	m.persist.UnsolvedBlock.ParentID = cc.AppliedBlocks[len(cc.AppliedBlocks)-1].ID()
+	m.parentTimestamp = cc.AppliedBlocks[len(cc.AppliedBlocks)-1].Timestamp
 	m.persist.Target, exists1 = m.cs.ChildTarget(m.persist.UnsolvedBlock.ParentID)
 	m.persist.UnsolvedBlock.Timestamp, exists2 = m.cs.MinimumValidChildTimestamp(m.persist.UnsolvedBlock.ParentID)
 	if !exists1 {
@@ -68,7 +77,8 @@ func (m *Miner) ProcessConsensusChange(cc modules.ConsensusChange) {
 }
 
 // ReceiveUpdatedUnconfirmedTransactions will replace the current unconfirmed
-// set of transactions with the input transactions.
+// set of transactions with the input transactions, selecting those that pay
+// the most in fees when they do not all fit in a block.
 func (m *Miner) ReceiveUpdatedUnconfirmedTransactions(unconfirmedTransactions []types.Transaction, _ modules.ConsensusChange) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -80,15 +90,29 @@ func (m *Miner) ReceiveUpdatedUnconfirmedTransactions(unconfirmedTransactions []
 		return
 	}
 
-	// Add transactions to the block until the block size limit is reached.
-	// Transactions are assumed to be in a sensible order.
-	var i int
-	remainingSize := int(types.BlockSizeLimit - 5e3)
-	for i = range unconfirmedTransactions {
-		remainingSize -= len(encoding.Marshal(unconfirmedTransactions[i]))
-		if remainingSize < 0 {
-			break
+	// Fill the block with the transactions that pay the most in fees per
+	// byte, up to the block size limit.
+	m.persist.UnsolvedBlock.Transactions = selectTransactions(unconfirmedTransactions, types.MaxTransactionSize, m.priorityTxns)
+
+	// Replace the source block if the new transactions pay significantly
+	// more in fees, so that external miners are not left grinding on a block
+	// that leaves fees in the transaction pool.
+	if m.sourceBlock != nil && time.Since(m.sourceBlockTime) >= MinSourceBlockAge {
+		sourceFees := transactionFees(m.sourceBlock.Transactions)
+		threshold := sourceFees.Add(sourceFees.Div64(sourceBlockFeeIncrease))
+		if transactionFees(m.persist.UnsolvedBlock.Transactions).Cmp(threshold) > 0 {
+			m.newSourceBlock()
+		}
+	}
+}
+
+// transactionFees returns the sum of the miner fees of a set of transactions.
+func transactionFees(txns []types.Transaction) types.Currency {
+	fees := types.ZeroCurrency
+	for _, txn := range txns {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
 		}
 	}
-	m.persist.UnsolvedBlock.Transactions = unconfirmedTransactions[:i+1]
+	return fees
 }
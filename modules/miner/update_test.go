@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 // TestIntegrationBlockHeightReorg checks that the miner has the correct block
@@ -125,3 +126,66 @@ func TestMinerHeightForcefulMismatch(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestUnconfirmedTransactionsSizeLimit checks that the miner only adds
+// transactions to the unsolved block while they fit in a block.
+func TestUnconfirmedTransactionsSizeLimit(t *testing.T) {
+	m := new(Miner)
+	txn := types.Transaction{
+		ArbitraryData: [][]byte{make([]byte, types.MaxTransactionSize/4)},
+	}
+	txns := []types.Transaction{txn, txn, txn, txn}
+
+	// Four transactions do not fit in a block because of the encoding
+	// overhead, so only the first three should be used.
+	m.ReceiveUpdatedUnconfirmedTransactions(txns, modules.ConsensusChange{})
+	if len(m.persist.UnsolvedBlock.Transactions) != 3 {
+		t.Fatal("expecting 3 transactions in the unsolved block, got", len(m.persist.UnsolvedBlock.Transactions))
+	}
+
+	// All transactions are used if they fit.
+	m.ReceiveUpdatedUnconfirmedTransactions(txns[:2], modules.ConsensusChange{})
+	if len(m.persist.UnsolvedBlock.Transactions) != 2 {
+		t.Fatal("expecting 2 transactions in the unsolved block, got", len(m.persist.UnsolvedBlock.Transactions))
+	}
+}
+
+// TestIntegrationSourceBlockFees checks that the source block is replaced
+// when transactions with higher fees enter the transaction pool.
+func TestIntegrationSourceBlockFees(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	mt, err := createMinerTester("TestIntegrationSourceBlockFees")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mt.miner.Close()
+
+	// Get a template, so that the next template would normally reuse the
+	// same source block.
+	b, _, err := mt.miner.BlockTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !transactionFees(b.Transactions).IsZero() {
+		t.Fatal("block template has fees before any transactions were sent")
+	}
+
+	// Send a transaction with a miner fee. The next template should include
+	// it.
+	_, err = mt.wallet.SendSiacoins(types.SiacoinPrecision, types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _, err = mt.miner.BlockTemplate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transactionFees(b.Transactions).IsZero() {
+		t.Fatal("block template was not refreshed after a transaction with fees entered the pool")
+	}
+	if b.MinerPayouts[0].Value.Cmp(b.CalculateSubsidy(mt.cs.Height()+1)) != 0 {
+		t.Fatal("block template does not pay out the fees to the miner")
+	}
+}
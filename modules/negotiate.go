@@ -163,6 +163,17 @@ var (
 	// RPCSettings is the specifier for requesting settings from the host.
 	RPCSettings = types.Specifier{'S', 'e', 't', 't', 'i', 'n', 'g', 's', 2}
 
+	// RPCQuerySettings is the specifier for requesting the settings of a host
+	// outside of a negotiation. Unlike RPCSettings, it does not advance the
+	// revision number of the settings, so anyone may call it to check the
+	// prices and capacity of a host.
+	RPCQuerySettings = types.Specifier{'Q', 'u', 'e', 'r', 'y', 'S', 'e', 't', 't', 'i', 'n', 'g', 's'}
+
+	// RPCSession is the specifier for establishing an encrypted session with
+	// the host. The specifier of the RPC being called follows the handshake,
+	// and is sent over the encrypted session.
+	RPCSession = types.Specifier{'S', 'e', 's', 's', 'i', 'o', 'n'}
+
 	// SectorSize defines how large a sector should be in bytes. The sector
 	// size needs to be a power of two to be compatible with package
 	// merkletree. 4MB has been chosen for the live network because large
@@ -45,6 +45,36 @@ func (na NetAddress) Port() string {
 	return port
 }
 
+// ResolveIP returns the NetAddress with its host resolved to an IP address.
+// NetAddresses whose host is already an IP address are returned unchanged. If
+// a hostname resolves to several addresses, an IPv4 address is preferred, as
+// IPv4 addresses are reachable from more of the network. IPv6 addresses are
+// enclosed in square brackets.
+func (na NetAddress) ResolveIP() (NetAddress, error) {
+	host, port, err := net.SplitHostPort(string(na))
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(host) != nil {
+		return na, nil
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return "", err
+	}
+	if len(ips) == 0 {
+		return "", errors.New("hostname did not resolve to any addresses")
+	}
+	ip := ips[0]
+	for _, candidate := range ips {
+		if candidate.To4() != nil {
+			ip = candidate
+			break
+		}
+	}
+	return NetAddress(net.JoinHostPort(ip.String(), port)), nil
+}
+
 // IsLoopback returns true for IP addresses that are on the same machine.
 func (na NetAddress) IsLoopback() bool {
 	host, _, err := net.SplitHostPort(string(na))
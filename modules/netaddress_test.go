@@ -194,3 +194,33 @@ func TestIsValid(t *testing.T) {
 		}
 	}
 }
+
+// TestResolveIP tests that ResolveIP resolves hostnames to IP addresses and
+// leaves IP addresses unchanged.
+func TestResolveIP(t *testing.T) {
+	t.Parallel()
+
+	testSet := []struct {
+		query NetAddress
+		want  NetAddress
+	}{
+		{"127.0.0.1:9981", "127.0.0.1:9981"},
+		{"[::1]:9981", "[::1]:9981"},
+		{"[2001:db8::1]:9981", "[2001:db8::1]:9981"},
+		{"localhost:9981", "127.0.0.1:9981"},
+	}
+	for _, test := range testSet {
+		got, err := test.query.ResolveIP()
+		if err != nil {
+			t.Errorf("ResolveIP failed for %q: %v", test.query, err)
+		} else if got != test.want {
+			t.Errorf("ResolveIP(%q): expected %q, got %q", test.query, test.want, got)
+		}
+	}
+
+	for _, addr := range []NetAddress{"garbage", "unresolvable.invalid:9981"} {
+		if _, err := addr.ResolveIP(); err == nil {
+			t.Errorf("ResolveIP succeeded for %q", addr)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package modules
+
+import (
+	"errors"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// Network alerts are messages from the developers to every node on the
+// network, such as a warning that an upgrade is required before a certain
+// height. A network alert is signed with one of the NetworkAlertKeys, and is
+// relayed by the gateways that accept it until it expires. Network alerts are
+// only displayed to the operator; they have no effect on consensus, or on any
+// other behavior of the node.
+const (
+	// MaxNetworkAlertMessageLength is the length of the longest network alert
+	// message that is accepted.
+	MaxNetworkAlertMessageLength = 1024
+
+	// MaxNetworkAlertSize is the largest encoded NetworkAlert.
+	MaxNetworkAlertSize = MaxNetworkAlertMessageLength + 256
+)
+
+var (
+	// NetworkAlertTestingKey signs the network alerts of dev and testing
+	// builds. Its entropy is public, so anyone can sign alerts on test
+	// networks.
+	NetworkAlertTestingKey, networkAlertTestingPublicKey = crypto.GenerateKeyPairDeterministic(crypto.HashObject("Sia network alert testing key"))
+
+	// NetworkAlertKeys are the keys that network alerts must be signed with.
+	// No key has been published for the standard network yet, so standard
+	// builds do not accept any network alerts.
+	NetworkAlertKeys = func() []crypto.PublicKey {
+		switch build.Release {
+		case "dev":
+			return []crypto.PublicKey{networkAlertTestingPublicKey}
+		case "standard":
+			return nil
+		case "testing":
+			return []crypto.PublicKey{networkAlertTestingPublicKey}
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	// ErrNetworkAlertExpired is returned when a network alert has expired.
+	ErrNetworkAlertExpired = errors.New("network alert has expired")
+
+	errNetworkAlertSeverity = errors.New("network alert has an unknown severity")
+	errNetworkAlertTooLong  = errors.New("network alert message is too long")
+)
+
+// A NetworkAlert is a signed message from the developers.
+type NetworkAlert struct {
+	Severity AlertSeverity
+	Message  string
+
+	// Expiry is the time after which the alert is no longer displayed or
+	// relayed.
+	Expiry types.Timestamp
+
+	Signature crypto.Signature
+}
+
+// ID returns the hash of the signed fields of the alert, which uniquely
+// identifies it.
+func (na NetworkAlert) ID() crypto.Hash {
+	return crypto.HashAll(na.Severity, na.Message, na.Expiry)
+}
+
+// SignNetworkAlert signs a network alert with the given key.
+func SignNetworkAlert(na NetworkAlert, sk crypto.SecretKey) (NetworkAlert, error) {
+	sig, err := crypto.SignHash(na.ID(), sk)
+	if err != nil {
+		return NetworkAlert{}, err
+	}
+	na.Signature = sig
+	return na, nil
+}
+
+// VerifyNetworkAlert returns an error if the alert is malformed, has expired,
+// or was not signed with one of the NetworkAlertKeys.
+func VerifyNetworkAlert(na NetworkAlert) error {
+	switch na.Severity {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+	default:
+		return errNetworkAlertSeverity
+	}
+	if len(na.Message) > MaxNetworkAlertMessageLength {
+		return errNetworkAlertTooLong
+	}
+	if na.Expiry <= types.CurrentTimestamp() {
+		return ErrNetworkAlertExpired
+	}
+	for _, pk := range NetworkAlertKeys {
+		if crypto.VerifyHash(na.ID(), pk, na.Signature) == nil {
+			return nil
+		}
+	}
+	return crypto.ErrInvalidSignature
+}
@@ -0,0 +1,175 @@
+package modules
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// SOCKS5 protocol constants, as defined in RFC 1928.
+const (
+	socksVersion        = 5
+	socksMethodNoAuth   = 0
+	socksCmdConnect     = 1
+	socksAddrTypeIPv4   = 1
+	socksAddrTypeName   = 3
+	socksAddrTypeIPv6   = 4
+	socksReplySucceeded = 0
+)
+
+var (
+	errSOCKSAuth    = errors.New("SOCKS5 proxy requires authentication")
+	errSOCKSVersion = errors.New("proxy is not a SOCKS5 proxy")
+
+	// socksReplyErrors are the errors that correspond to the reply codes of
+	// a SOCKS5 proxy.
+	socksReplyErrors = []string{
+		1: "general SOCKS server failure",
+		2: "connection not allowed by ruleset",
+		3: "network unreachable",
+		4: "host unreachable",
+		5: "connection refused",
+		6: "TTL expired",
+		7: "command not supported",
+		8: "address type not supported",
+	}
+)
+
+var (
+	// proxyAddr is the address of the SOCKS5 proxy that outbound connections
+	// are made through. If proxyAddr is empty, connections are made directly.
+	proxyAddr NetAddress
+	proxyMu   sync.RWMutex
+)
+
+// SetProxy sets the address of a SOCKS5 proxy, such as Tor, that all
+// outbound peer and host connections made with Dial will go through. An empty
+// address disables the proxy.
+func SetProxy(addr NetAddress) error {
+	if addr != "" {
+		if err := addr.IsValid(); err != nil {
+			return err
+		}
+	}
+	proxyMu.Lock()
+	proxyAddr = addr
+	proxyMu.Unlock()
+	return nil
+}
+
+// Proxy returns the address of the SOCKS5 proxy that outbound connections go
+// through, or the empty string if no proxy is in use.
+func Proxy() NetAddress {
+	proxyMu.RLock()
+	defer proxyMu.RUnlock()
+	return proxyAddr
+}
+
+// Dial connects to 'addr', through the SOCKS5 proxy if one has been set. When
+// a proxy is used, hostnames are resolved by the proxy, so that no DNS
+// requests leak outside of it and .onion addresses can be reached over Tor.
+func Dial(addr NetAddress, timeout time.Duration) (net.Conn, error) {
+	proxy := Proxy()
+	if proxy == "" {
+		return net.DialTimeout("tcp", string(addr), timeout)
+	}
+
+	conn, err := net.DialTimeout("tcp", string(proxy), timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	if err := socksConnect(conn, addr); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// socksConnect performs a SOCKS5 handshake over 'conn', asking the proxy to
+// connect to 'addr'. Only the no-authentication method is supported.
+func socksConnect(conn net.Conn, addr NetAddress) error {
+	host, portStr, err := net.SplitHostPort(string(addr))
+	if err != nil {
+		return err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return err
+	}
+
+	// Negotiate the authentication method.
+	if _, err := conn.Write([]byte{socksVersion, 1, socksMethodNoAuth}); err != nil {
+		return err
+	}
+	var method [2]byte
+	if _, err := io.ReadFull(conn, method[:]); err != nil {
+		return err
+	}
+	if method[0] != socksVersion {
+		return errSOCKSVersion
+	}
+	if method[1] != socksMethodNoAuth {
+		return errSOCKSAuth
+	}
+
+	// Send the connect request.
+	req := []byte{socksVersion, socksCmdConnect, 0}
+	if ip := net.ParseIP(host); ip == nil {
+		if len(host) > 255 {
+			return errors.New("hostname is too long for a SOCKS5 request")
+		}
+		req = append(req, socksAddrTypeName, byte(len(host)))
+		req = append(req, host...)
+	} else if ip4 := ip.To4(); ip4 != nil {
+		req = append(req, socksAddrTypeIPv4)
+		req = append(req, ip4...)
+	} else {
+		req = append(req, socksAddrTypeIPv6)
+		req = append(req, ip.To16()...)
+	}
+	var portBytes [2]byte
+	binary.BigEndian.PutUint16(portBytes[:], uint16(port))
+	req = append(req, portBytes[:]...)
+	if _, err := conn.Write(req); err != nil {
+		return err
+	}
+
+	// Read the reply. The bound address in the reply is not used, but must
+	// be read so that it isn't mistaken for data from the remote host.
+	var reply [4]byte
+	if _, err := io.ReadFull(conn, reply[:]); err != nil {
+		return err
+	}
+	if reply[0] != socksVersion {
+		return errSOCKSVersion
+	}
+	if reply[1] != socksReplySucceeded {
+		if int(reply[1]) < len(socksReplyErrors) && socksReplyErrors[reply[1]] != "" {
+			return errors.New("SOCKS5 proxy: " + socksReplyErrors[reply[1]])
+		}
+		return errors.New("SOCKS5 proxy: unknown error " + strconv.Itoa(int(reply[1])))
+	}
+	var boundLen int
+	switch reply[3] {
+	case socksAddrTypeIPv4:
+		boundLen = net.IPv4len
+	case socksAddrTypeIPv6:
+		boundLen = net.IPv6len
+	case socksAddrTypeName:
+		var nameLen [1]byte
+		if _, err := io.ReadFull(conn, nameLen[:]); err != nil {
+			return err
+		}
+		boundLen = int(nameLen[0])
+	default:
+		return errors.New("SOCKS5 proxy sent an unknown address type")
+	}
+	_, err = io.ReadFull(conn, make([]byte, boundLen+2))
+	return err
+}
@@ -0,0 +1,162 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// serveSOCKS runs a minimal SOCKS5 proxy on 'l' that supports the
+// no-authentication method and the connect command. If 'reply' is non-zero,
+// the proxy refuses every request with that reply code.
+func serveSOCKS(l net.Listener, reply byte) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			var greeting [3]byte
+			if _, err := io.ReadFull(conn, greeting[:]); err != nil {
+				return
+			}
+			conn.Write([]byte{socksVersion, socksMethodNoAuth})
+
+			var req [4]byte
+			if _, err := io.ReadFull(conn, req[:]); err != nil {
+				return
+			}
+			var host string
+			switch req[3] {
+			case socksAddrTypeIPv4, socksAddrTypeIPv6:
+				ip := make(net.IP, net.IPv4len)
+				if req[3] == socksAddrTypeIPv6 {
+					ip = make(net.IP, net.IPv6len)
+				}
+				if _, err := io.ReadFull(conn, ip); err != nil {
+					return
+				}
+				host = ip.String()
+			case socksAddrTypeName:
+				var nameLen [1]byte
+				if _, err := io.ReadFull(conn, nameLen[:]); err != nil {
+					return
+				}
+				name := make([]byte, nameLen[0])
+				if _, err := io.ReadFull(conn, name); err != nil {
+					return
+				}
+				host = string(name)
+			}
+			var port [2]byte
+			if _, err := io.ReadFull(conn, port[:]); err != nil {
+				return
+			}
+			addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:]))))
+
+			if reply != socksReplySucceeded {
+				conn.Write([]byte{socksVersion, reply, 0, socksAddrTypeIPv4, 0, 0, 0, 0, 0, 0})
+				return
+			}
+			remote, err := net.Dial("tcp", addr)
+			if err != nil {
+				conn.Write([]byte{socksVersion, 5, 0, socksAddrTypeIPv4, 0, 0, 0, 0, 0, 0})
+				return
+			}
+			defer remote.Close()
+			conn.Write([]byte{socksVersion, socksReplySucceeded, 0, socksAddrTypeIPv4, 127, 0, 0, 1, 0, 0})
+			go io.Copy(remote, conn)
+			io.Copy(conn, remote)
+		}(conn)
+	}
+}
+
+// TestDialProxy checks that Dial connects through a SOCKS5 proxy once one has
+// been set, and that errors reported by the proxy are returned.
+func TestDialProxy(t *testing.T) {
+	// An echo server to connect to.
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer echo.Close()
+	go func() {
+		for {
+			conn, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	_, echoPort, _ := net.SplitHostPort(echo.Addr().String())
+
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Close()
+	go serveSOCKS(proxy, socksReplySucceeded)
+
+	if err := SetProxy("garbage"); err == nil {
+		t.Fatal("SetProxy accepted an invalid address")
+	}
+	if err := SetProxy(NetAddress(proxy.Addr().String())); err != nil {
+		t.Fatal(err)
+	}
+	defer SetProxy("")
+	if Proxy() != NetAddress(proxy.Addr().String()) {
+		t.Fatal("wrong proxy address:", Proxy())
+	}
+
+	// Hostnames are passed to the proxy to resolve.
+	for _, addr := range []string{echo.Addr().String(), net.JoinHostPort("localhost", echoPort)} {
+		conn, err := Dial(NetAddress(addr), 5*time.Second)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := []byte("hello through the proxy")
+		if _, err := conn.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, len(msg))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf, msg) {
+			t.Fatal("data was corrupted by the proxy")
+		}
+		conn.Close()
+	}
+
+	// A proxy that refuses the connection.
+	refusing, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer refusing.Close()
+	go serveSOCKS(refusing, 2)
+	if err := SetProxy(NetAddress(refusing.Addr().String())); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Dial(NetAddress(echo.Addr().String()), 5*time.Second)
+	if err == nil || err.Error() != "SOCKS5 proxy: connection not allowed by ruleset" {
+		t.Fatal("expected the proxy's error, got", err)
+	}
+
+	// Without a proxy, connections are made directly.
+	SetProxy("")
+	conn, err := Dial(NetAddress(echo.Addr().String()), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+}
@@ -0,0 +1,148 @@
+package modules
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// rateLimitChunkSize is the largest amount of data that a rate limited
+	// connection reads or writes at once. Splitting large transfers into
+	// chunks lets connections that share a RateLimit take turns, instead of
+	// one large transfer blocking the others until it completes.
+	rateLimitChunkSize = 1 << 14
+)
+
+// A RateLimit is a token bucket that limits the number of bytes per second
+// that are read from and written to the connections that use it. A RateLimit
+// can be shared by many connections to place a cap on their combined
+// bandwidth. A nil RateLimit, or one with a rate of zero, does not limit.
+type RateLimit struct {
+	// rate is the number of bytes per second that the bucket refills by, and
+	// the maximum number of tokens that the bucket holds.
+	rate   int64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// NewRateLimit returns a RateLimit that allows 'bytesPerSecond' bytes per
+// second, with bursts of up to one second's worth of data.
+func NewRateLimit(bytesPerSecond int64) *RateLimit {
+	return &RateLimit{
+		rate:   bytesPerSecond,
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+// Rate returns the number of bytes per second allowed by the RateLimit.
+func (rl *RateLimit) Rate() int64 {
+	if rl == nil {
+		return 0
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	return rl.rate
+}
+
+// SetRate changes the number of bytes per second allowed by the RateLimit.
+// A rate of zero removes the limit.
+func (rl *RateLimit) SetRate(bytesPerSecond int64) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.rate = bytesPerSecond
+	if rl.tokens > float64(bytesPerSecond) {
+		rl.tokens = float64(bytesPerSecond)
+	}
+}
+
+// wait takes 'n' tokens from the bucket, blocking until the bucket has
+// refilled enough to cover them. The tokens are taken immediately, so
+// concurrent callers queue up behind each other.
+func (rl *RateLimit) wait(n int) {
+	if rl == nil {
+		return
+	}
+	rl.mu.Lock()
+	if rl.rate <= 0 {
+		rl.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	rl.tokens += now.Sub(rl.last).Seconds() * float64(rl.rate)
+	if rl.tokens > float64(rl.rate) {
+		rl.tokens = float64(rl.rate)
+	}
+	rl.last = now
+	rl.tokens -= float64(n)
+	var delay time.Duration
+	if rl.tokens < 0 {
+		delay = time.Duration(-rl.tokens / float64(rl.rate) * float64(time.Second))
+	}
+	rl.mu.Unlock()
+	time.Sleep(delay)
+}
+
+// rateLimitedConn is a net.Conn whose reads and writes are limited by a set
+// of RateLimits.
+type rateLimitedConn struct {
+	net.Conn
+	limits []*RateLimit
+}
+
+// Read reads data from the connection, waiting afterwards until the amount
+// read is allowed by every RateLimit of the connection.
+func (rlc *rateLimitedConn) Read(p []byte) (int, error) {
+	if len(p) > rateLimitChunkSize {
+		p = p[:rateLimitChunkSize]
+	}
+	n, err := rlc.Conn.Read(p)
+	for _, rl := range rlc.limits {
+		rl.wait(n)
+	}
+	return n, err
+}
+
+// Write writes data to the connection in chunks, waiting before each chunk
+// until it is allowed by every RateLimit of the connection.
+func (rlc *rateLimitedConn) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > rateLimitChunkSize {
+			chunk = chunk[:rateLimitChunkSize]
+		}
+		for _, rl := range rlc.limits {
+			rl.wait(len(chunk))
+		}
+		n, err := rlc.Conn.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// NewRateLimitedConn wraps a connection so that its reads and writes are
+// limited by each of the provided RateLimits. Typically a connection is
+// limited by a RateLimit of its own and by a RateLimit that is shared with
+// other connections. Nil RateLimits are ignored.
+func NewRateLimitedConn(conn net.Conn, limits ...*RateLimit) net.Conn {
+	var nonNil []*RateLimit
+	for _, rl := range limits {
+		if rl != nil {
+			nonNil = append(nonNil, rl)
+		}
+	}
+	if len(nonNil) == 0 {
+		return conn
+	}
+	return &rateLimitedConn{
+		Conn:   conn,
+		limits: nonNil,
+	}
+}
@@ -0,0 +1,99 @@
+package modules
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRateLimit checks that a RateLimit allows an initial burst and then
+// limits the rate at which tokens are taken.
+func TestRateLimit(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rl := NewRateLimit(1000)
+
+	// The first second's worth of data is allowed immediately.
+	start := time.Now()
+	rl.wait(1000)
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatal("initial burst was delayed")
+	}
+
+	// Further data is limited to the rate.
+	start = time.Now()
+	rl.wait(200)
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatal("rate limit was not enforced:", elapsed)
+	}
+
+	// Removing the limit takes effect immediately.
+	rl.SetRate(0)
+	start = time.Now()
+	rl.wait(1e9)
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatal("wait was delayed after the limit was removed")
+	}
+	if rl.Rate() != 0 {
+		t.Fatal("wrong rate:", rl.Rate())
+	}
+
+	// A nil RateLimit does not limit.
+	var nilLimit *RateLimit
+	nilLimit.wait(1e9)
+	if nilLimit.Rate() != 0 {
+		t.Fatal("nil RateLimit has a rate")
+	}
+}
+
+// TestRateLimitedConn checks that data sent over a rate limited connection
+// arrives intact, and that a shared RateLimit caps the combined bandwidth of
+// several connections.
+func TestRateLimitedConn(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Without limits, the connection is not wrapped.
+	c1, c2 := net.Pipe()
+	if NewRateLimitedConn(c1, nil) != c1 {
+		t.Fatal("connection without limits was wrapped")
+	}
+	c1.Close()
+	c2.Close()
+
+	// Send data larger than a chunk over two connections that share a
+	// limit. The first second's worth of data is sent immediately, so the
+	// transfers should take at least another second.
+	const rate = 64e3
+	shared := NewRateLimit(rate)
+	data := bytes.Repeat([]byte{1, 2, 3, 4}, rate/4)
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		c1, c2 := net.Pipe()
+		conn := NewRateLimitedConn(c1, NewRateLimit(rate), shared)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			conn.Write(data)
+		}()
+		recv := make([]byte, len(data))
+		if _, err := io.ReadFull(c2, recv); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(recv, data) {
+			t.Fatal("data was corrupted by the rate limited conn")
+		}
+		c2.Close()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Fatal("shared rate limit was not enforced:", elapsed)
+	}
+}
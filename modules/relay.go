@@ -0,0 +1,64 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// The relay protocol lets hosts that cannot accept incoming connections, such
+// as hosts behind a strict NAT, be reached through a publicly reachable relay.
+//
+// The host opens a control connection to the relay and calls
+// RPCRelayRegister. The relay listens on a new port for the host and responds
+// with the port, and the host announces the relay's address with that port.
+// When a renter connects to the port, the relay sends a RelayRequest over the
+// control connection. The host then opens a new connection to the relay and
+// calls RPCRelayJoin with the token of the request, after which the relay
+// forwards all data between the renter and the host. The relay cannot read
+// the data of renters that use encrypted sessions.
+const (
+	// RelayDir is the name of the directory that is used to store the
+	// relay's persistent data.
+	RelayDir = "relay"
+
+	// MaxRelayPortLength is the largest encoded port that is accepted from a
+	// relay. Ports have at most 5 digits, plus an 8 byte length prefix.
+	MaxRelayPortLength = 13
+
+	// MaxRelayRequestSize is the largest encoded RelayRequest.
+	MaxRelayRequestSize = 256
+)
+
+var (
+	// RPCRelayRegister is the specifier for registering a host with a relay.
+	RPCRelayRegister = types.Specifier{'R', 'e', 'l', 'a', 'y', 'R', 'e', 'g', 'i', 's', 't', 'e', 'r'}
+
+	// RPCRelayJoin is the specifier for joining a connection that is
+	// waiting at the relay.
+	RPCRelayJoin = types.Specifier{'R', 'e', 'l', 'a', 'y', 'J', 'o', 'i', 'n'}
+
+	// RelayKeepaliveInterval is how often the relay sends an empty
+	// RelayRequest over an idle control connection, so that NATs keep the
+	// connection open and the host can tell when the relay has gone away.
+	RelayKeepaliveInterval = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 30 * time.Second
+		case "standard":
+			return 1 * time.Minute
+		case "testing":
+			return 500 * time.Millisecond
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+)
+
+// A RelayRequest is sent by a relay over a host's control connection when a
+// renter connects. A request with an empty Token is a keepalive.
+type RelayRequest struct {
+	Token      [16]byte
+	RemoteAddr NetAddress
+}
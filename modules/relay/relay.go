@@ -0,0 +1,382 @@
+// Package relay implements a relay that forwards the connections of renters to
+// hosts that cannot accept incoming connections, such as hosts behind a strict
+// NAT. See modules/relay.go for a description of the protocol.
+package relay
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
+	siasync "github.com/NebulousLabs/Sia/sync"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// logFile is the name of the relay's log file.
+	logFile = modules.RelayDir + ".log"
+
+	// maxRelayedHosts is the number of hosts that can be registered with the
+	// relay at once.
+	maxRelayedHosts = 100
+
+	// maxPendingConns is the number of renter connections that can wait for
+	// the same host to join them.
+	maxPendingConns = 32
+)
+
+var (
+	// joinTimeout is how long a renter connection waits for the host to join
+	// it before it is closed.
+	joinTimeout = func() time.Duration {
+		switch build.Release {
+		case "dev":
+			return 20 * time.Second
+		case "standard":
+			return 30 * time.Second
+		case "testing":
+			return 2 * time.Second
+		default:
+			panic("unrecognized build.Release")
+		}
+	}()
+
+	errTooManyHosts = errors.New("relay is serving too many hosts")
+)
+
+// A pendingConn is a renter connection that is waiting for its host to join
+// it.
+type pendingConn struct {
+	conn  net.Conn
+	host  *relayedHost
+	timer *time.Timer
+}
+
+// A relayedHost is a host that is registered with the relay.
+type relayedHost struct {
+	listener net.Listener
+	requests chan modules.RelayRequest
+	pending  int // number of pendingConns of the host
+
+	// closed is closed once the control connection of the host is gone, and
+	// requests are no longer read.
+	closed chan struct{}
+}
+
+// A Relay forwards the connections of renters to hosts that cannot accept
+// incoming connections.
+type Relay struct {
+	listener net.Listener
+	hosts    map[*relayedHost]struct{}
+	pending  map[[16]byte]*pendingConn
+
+	// blocklist contains the IP ranges of renters that are not relayed. It
+	// may be shared with other modules.
+	blocklist *modules.Blocklist
+
+	log *persist.Logger
+	mu  sync.Mutex
+	tg  siasync.ThreadGroup
+}
+
+// New returns a Relay that accepts host registrations on 'addr'.
+func New(addr string, persistDir string) (*Relay, error) {
+	if err := os.MkdirAll(persistDir, 0700); err != nil {
+		return nil, err
+	}
+	r := &Relay{
+		hosts:   make(map[*relayedHost]struct{}),
+		pending: make(map[[16]byte]*pendingConn),
+	}
+	var err error
+	r.log, err = persist.NewFileLogger(filepath.Join(persistDir, logFile))
+	if err != nil {
+		return nil, err
+	}
+	r.listener, err = net.Listen("tcp", addr)
+	if err != nil {
+		r.log.Close()
+		return nil, err
+	}
+
+	closeChan := make(chan struct{})
+	go r.threadedListen(r.listener, closeChan)
+	r.tg.OnStop(func() {
+		r.listener.Close()
+		<-closeChan
+	})
+	r.log.Println("INFO: relay listening on", r.listener.Addr())
+	return r, nil
+}
+
+// Address returns the address that the relay accepts host registrations on.
+func (r *Relay) Address() modules.NetAddress {
+	return modules.NetAddress(r.listener.Addr().String())
+}
+
+// SetBlocklist sets the IP ranges of renters that are not relayed. The
+// Blocklist may be shared with other modules. A nil Blocklist allows every
+// address.
+func (r *Relay) SetBlocklist(bl *modules.Blocklist) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.blocklist = bl
+}
+
+// Close closes the relay, disconnecting all hosts and renters.
+func (r *Relay) Close() error {
+	if err := r.tg.Stop(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	for token, pc := range r.pending {
+		pc.timer.Stop()
+		pc.conn.Close()
+		delete(r.pending, token)
+	}
+	r.mu.Unlock()
+	return r.log.Close()
+}
+
+// threadedListen accepts connections from hosts.
+func (r *Relay) threadedListen(l net.Listener, closeChan chan struct{}) {
+	defer close(closeChan)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go r.threadedHandleConn(conn)
+	}
+}
+
+// threadedHandleConn reads the RPC called by a host and handles it.
+func (r *Relay) threadedHandleConn(conn net.Conn) {
+	if r.tg.Add() != nil {
+		conn.Close()
+		return
+	}
+	defer r.tg.Done()
+
+	if err := conn.SetDeadline(time.Now().Add(modules.NegotiateSettingsTime)); err != nil {
+		conn.Close()
+		return
+	}
+	var id types.Specifier
+	if err := encoding.ReadObject(conn, &id, 16); err != nil {
+		conn.Close()
+		return
+	}
+	switch id {
+	case modules.RPCRelayRegister:
+		err := r.managedRegister(conn)
+		if err != nil {
+			r.log.Debugf("WARN: relaying for host %v failed: %v", conn.RemoteAddr(), err)
+		}
+		conn.Close()
+	case modules.RPCRelayJoin:
+		r.managedJoin(conn)
+	default:
+		r.log.Debugf("WARN: host %v called unknown RPC %v", conn.RemoteAddr(), id)
+		conn.Close()
+	}
+}
+
+// managedRegister registers a host, listening for renters on a new port
+// until the host's control connection closes or the relay is stopped.
+func (r *Relay) managedRegister(control net.Conn) error {
+	r.mu.Lock()
+	full := len(r.hosts) >= maxRelayedHosts
+	r.mu.Unlock()
+	if full {
+		return modules.WriteNegotiationRejection(control, errTooManyHosts)
+	}
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return modules.WriteNegotiationRejection(control, errors.New("relay could not listen: "+err.Error()))
+	}
+	defer l.Close()
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	if err != nil {
+		return err
+	}
+	if err := modules.WriteNegotiationAcceptance(control); err != nil {
+		return err
+	}
+	if err := encoding.WriteObject(control, port); err != nil {
+		return err
+	}
+
+	host := &relayedHost{
+		listener: l,
+		requests: make(chan modules.RelayRequest, maxPendingConns),
+		closed:   make(chan struct{}),
+	}
+	r.mu.Lock()
+	r.hosts[host] = struct{}{}
+	r.mu.Unlock()
+	defer func() {
+		close(host.closed)
+		r.mu.Lock()
+		delete(r.hosts, host)
+		r.mu.Unlock()
+	}()
+	r.log.Printf("INFO: relaying renter connections on port %v for host %v", port, control.RemoteAddr())
+	go r.threadedAcceptRenters(host)
+
+	// The host does not send anything over the control connection, so a
+	// read only returns once the connection is closed.
+	if err := control.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(ioutil.Discard, control)
+		close(closed)
+	}()
+
+	for {
+		var req modules.RelayRequest
+		select {
+		case req = <-host.requests:
+		case <-time.After(modules.RelayKeepaliveInterval):
+		case <-closed:
+			return nil
+		case <-r.tg.StopChan():
+			return nil
+		}
+		if err := control.SetWriteDeadline(time.Now().Add(modules.RelayKeepaliveInterval)); err != nil {
+			return err
+		}
+		if err := encoding.WriteObject(control, req); err != nil {
+			return err
+		}
+	}
+}
+
+// threadedAcceptRenters accepts renter connections on the port of a host,
+// asking the host to join each connection.
+func (r *Relay) threadedAcceptRenters(host *relayedHost) {
+	for {
+		conn, err := host.listener.Accept()
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		blocklist := r.blocklist
+		r.mu.Unlock()
+		if blocklist.IsBlocked(modules.NetAddress(conn.RemoteAddr().String())) {
+			conn.Close()
+			continue
+		}
+		var token [16]byte
+		b, err := crypto.RandBytes(len(token))
+		if err != nil {
+			conn.Close()
+			continue
+		}
+		copy(token[:], b)
+
+		r.mu.Lock()
+		if host.pending >= maxPendingConns {
+			r.mu.Unlock()
+			conn.Close()
+			continue
+		}
+		host.pending++
+		r.pending[token] = &pendingConn{
+			conn:  conn,
+			host:  host,
+			timer: time.AfterFunc(joinTimeout, func() { r.managedExpire(token) }),
+		}
+		r.mu.Unlock()
+
+		// The host stops reading requests when its control connection
+		// closes, in which case the renter connection is dropped.
+		req := modules.RelayRequest{
+			Token:      token,
+			RemoteAddr: modules.NetAddress(conn.RemoteAddr().String()),
+		}
+		select {
+		case host.requests <- req:
+		case <-host.closed:
+			r.managedExpire(token)
+			return
+		case <-r.tg.StopChan():
+			r.managedExpire(token)
+			return
+		}
+	}
+}
+
+// takePending removes a pending connection, returning nil if there is no
+// connection with the given token.
+func (r *Relay) takePending(token [16]byte) *pendingConn {
+	pc, exists := r.pending[token]
+	if !exists {
+		return nil
+	}
+	delete(r.pending, token)
+	pc.host.pending--
+	pc.timer.Stop()
+	return pc
+}
+
+// managedExpire closes a renter connection that the host did not join in
+// time.
+func (r *Relay) managedExpire(token [16]byte) {
+	r.mu.Lock()
+	pc := r.takePending(token)
+	r.mu.Unlock()
+	if pc != nil {
+		pc.conn.Close()
+	}
+}
+
+// managedJoin joins a connection from a host to the renter connection whose
+// token the host sends, and forwards data between the two until either side
+// closes its connection.
+func (r *Relay) managedJoin(hostConn net.Conn) {
+	defer hostConn.Close()
+	var token [16]byte
+	if err := encoding.ReadObject(hostConn, &token, 16); err != nil {
+		return
+	}
+	r.mu.Lock()
+	pc := r.takePending(token)
+	r.mu.Unlock()
+	if pc == nil {
+		return
+	}
+	renterConn := pc.conn
+	defer renterConn.Close()
+	if err := hostConn.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	// Forward data until either side closes its connection, or the relay is
+	// stopped.
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(renterConn, hostConn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(hostConn, renterConn)
+		done <- struct{}{}
+	}()
+	select {
+	case <-done:
+	case <-r.tg.StopChan():
+	}
+}
@@ -0,0 +1,183 @@
+package relay
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+)
+
+// newTestRelay returns a Relay listening on localhost.
+func newTestRelay(name string, t *testing.T) *Relay {
+	r, err := New("localhost:0", build.TempDir(modules.RelayDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// register registers a host with the relay, returning the control connection
+// and the address that the relay listens on for the host.
+func register(r *Relay, t *testing.T) (net.Conn, string) {
+	control, err := net.Dial("tcp", string(r.Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encoding.WriteObject(control, modules.RPCRelayRegister); err != nil {
+		t.Fatal(err)
+	}
+	if err := modules.ReadNegotiationAcceptance(control); err != nil {
+		t.Fatal(err)
+	}
+	var port string
+	if err := encoding.ReadObject(control, &port, modules.MaxRelayPortLength); err != nil {
+		t.Fatal(err)
+	}
+	return control, net.JoinHostPort("localhost", port)
+}
+
+// readRequest reads the next request that is not a keepalive from a control
+// connection.
+func readRequest(control net.Conn, t *testing.T) modules.RelayRequest {
+	for {
+		var req modules.RelayRequest
+		if err := encoding.ReadObject(control, &req, modules.MaxRelayRequestSize); err != nil {
+			t.Fatal(err)
+		}
+		if req.Token != ([16]byte{}) {
+			return req
+		}
+	}
+}
+
+// join joins a renter connection that is waiting at the relay.
+func join(r *Relay, token [16]byte, t *testing.T) net.Conn {
+	conn, err := net.Dial("tcp", string(r.Address()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := encoding.WriteObject(conn, modules.RPCRelayJoin); err != nil {
+		t.Fatal(err)
+	}
+	if err := encoding.WriteObject(conn, token); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// TestRelayForwarding checks that the relay forwards data between a renter and
+// a host that joined the renter's connection.
+func TestRelayForwarding(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	r := newTestRelay("TestRelayForwarding", t)
+	defer r.Close()
+	control, addr := register(r, t)
+	defer control.Close()
+
+	renter, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer renter.Close()
+	req := readRequest(control, t)
+	if req.RemoteAddr != modules.NetAddress(renter.LocalAddr().String()) {
+		t.Fatal("request has the wrong remote address:", req.RemoteAddr, renter.LocalAddr())
+	}
+	host := join(r, req.Token, t)
+	defer host.Close()
+
+	// Data is forwarded in both directions.
+	if _, err := renter.Write([]byte("foo")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 3)
+	if _, err := io.ReadFull(host, buf); err != nil || !bytes.Equal(buf, []byte("foo")) {
+		t.Fatal("host did not receive the renter's data:", err, buf)
+	}
+	if _, err := host.Write([]byte("bar")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(renter, buf); err != nil || !bytes.Equal(buf, []byte("bar")) {
+		t.Fatal("renter did not receive the host's data:", err, buf)
+	}
+
+	// Closing the host's connection closes the renter's connection.
+	host.Close()
+	renter.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := renter.Read(buf); err != io.EOF {
+		t.Fatal("expected EOF, got", err)
+	}
+
+	// A token cannot be used twice.
+	reused := join(r, req.Token, t)
+	defer reused.Close()
+	reused.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := reused.Read(buf); err != io.EOF {
+		t.Fatal("expected EOF, got", err)
+	}
+}
+
+// TestRelayJoinTimeout checks that renter connections that the host does not
+// join are closed, and that the relay stops listening for a host once its
+// control connection closes.
+func TestRelayJoinTimeout(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	r := newTestRelay("TestRelayJoinTimeout", t)
+	defer r.Close()
+	control, addr := register(r, t)
+
+	renter, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer renter.Close()
+	readRequest(control, t)
+	renter.SetReadDeadline(time.Now().Add(2 * joinTimeout))
+	if _, err := renter.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatal("expected EOF, got", err)
+	}
+
+	control.Close()
+	for i := 0; i < 50; i++ {
+		r.mu.Lock()
+		n := len(r.hosts)
+		r.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Fatal("relay still listens for a host that disconnected")
+	}
+}
+
+// TestRelayKeepalive checks that the relay sends keepalives over an idle
+// control connection.
+func TestRelayKeepalive(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	r := newTestRelay("TestRelayKeepalive", t)
+	defer r.Close()
+	control, _ := register(r, t)
+	defer control.Close()
+
+	control.SetReadDeadline(time.Now().Add(3 * modules.RelayKeepaliveInterval))
+	var req modules.RelayRequest
+	if err := encoding.ReadObject(control, &req, modules.MaxRelayRequestSize); err != nil {
+		t.Fatal(err)
+	}
+	if req.Token != ([16]byte{}) {
+		t.Fatal("expected a keepalive, got a request")
+	}
+}
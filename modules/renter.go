@@ -135,6 +135,11 @@ type Renter interface {
 	// AllHosts returns the full list of hosts known to the renter.
 	AllHosts() []HostDBEntry
 
+	// BackupMetadata writes a copy of the renter's file metadata and
+	// contracts into a directory, laid out as in the renter's persist
+	// directory.
+	BackupMetadata(dir string) error
+
 	// Close closes the Renter.
 	Close() error
 
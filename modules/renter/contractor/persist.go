@@ -66,6 +66,14 @@ func (c *Contractor) saveSync() error {
 	return c.persist.saveSync(c.persistData())
 }
 
+// BackupMetadata writes a copy of the Contractor persistence data into 'dir',
+// under the name of its persist file.
+func (c *Contractor) BackupMetadata(dir string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return newPersist(dir).saveSync(c.persistData())
+}
+
 // saveRevision returns a function that saves a revision. It is used by the
 // Editor and Downloader types to prevent desynchronizing with their host.
 func (c *Contractor) saveRevision(id types.FileContractID) func(types.FileContractRevision, []crypto.Hash) error {
@@ -31,11 +31,12 @@ type (
 	}
 )
 
-// stdDialer implements the dialer interface via net.DialTimeout.
+// stdDialer implements the dialer interface via modules.Dial, which uses the
+// SOCKS5 proxy if one has been set.
 type stdDialer struct{}
 
 func (d stdDialer) DialTimeout(addr modules.NetAddress, timeout time.Duration) (net.Conn, error) {
-	return net.DialTimeout("tcp", string(addr), timeout)
+	return modules.Dial(addr, timeout)
 }
 
 // stdSleeper implements the sleeper interface via time.Sleep.
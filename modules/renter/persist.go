@@ -154,15 +154,20 @@ func (f *file) UnmarshalSia(r io.Reader) error {
 
 // saveFile saves a file to the renter directory.
 func (r *Renter) saveFile(f *file) error {
+	return saveFileIn(f, r.persistDir)
+}
+
+// saveFileIn saves a file to 'dir', at the path given by its nickname.
+func saveFileIn(f *file, dir string) error {
 	// Create directory structure specified in nickname.
-	fullPath := filepath.Join(r.persistDir, f.name+ShareExtension)
+	fullPath := filepath.Join(dir, f.name+ShareExtension)
 	err := os.MkdirAll(filepath.Dir(fullPath), 0700)
 	if err != nil {
 		return err
 	}
 
 	// Open SafeFile handle.
-	handle, err := persist.NewSafeFile(filepath.Join(r.persistDir, f.name+ShareExtension))
+	handle, err := persist.NewSafeFile(fullPath)
 	if err != nil {
 		return err
 	}
@@ -178,20 +183,43 @@ func (r *Renter) saveFile(f *file) error {
 	return handle.Commit()
 }
 
-// save stores the current renter data to disk.
-func (r *Renter) save() error {
-	data := struct {
+// persistData returns the renter data that is saved to disk.
+func (r *Renter) persistData() interface{} {
+	return struct {
 		Tracking map[string]trackedFile
 	}{r.tracking}
-	return persist.SaveFile(saveMetadata, data, filepath.Join(r.persistDir, PersistFilename))
+}
+
+// save stores the current renter data to disk.
+func (r *Renter) save() error {
+	return persist.SaveFile(saveMetadata, r.persistData(), filepath.Join(r.persistDir, PersistFilename))
 }
 
 // saveSync stores the current renter data to disk and then syncs to disk.
 func (r *Renter) saveSync() error {
-	data := struct {
-		Tracking map[string]trackedFile
-	}{r.tracking}
-	return persist.SaveFileSync(saveMetadata, data, filepath.Join(r.persistDir, PersistFilename))
+	return persist.SaveFileSync(saveMetadata, r.persistData(), filepath.Join(r.persistDir, PersistFilename))
+}
+
+// BackupMetadata writes a copy of the renter's metadata into 'dir': the
+// tracked files, a .sia file for each file, and the contracts of the
+// contractor. The renter is locked while the copy is written, so that the
+// files and contracts are consistent with each other.
+func (r *Renter) BackupMetadata(dir string) error {
+	lockID := r.mu.RLock()
+	defer r.mu.RUnlock(lockID)
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	for _, f := range r.files {
+		if err := saveFileIn(f, dir); err != nil {
+			return err
+		}
+	}
+	if err := persist.SaveFileSync(saveMetadata, r.persistData(), filepath.Join(dir, PersistFilename)); err != nil {
+		return err
+	}
+	return r.hostContractor.BackupMetadata(dir)
 }
 
 // load fetches the saved renter data from disk.
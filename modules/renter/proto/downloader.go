@@ -122,7 +122,7 @@ func NewDownloader(host modules.HostDBEntry, contract modules.RenterContract) (*
 	}
 
 	// initiate download loop
-	conn, err := net.DialTimeout("tcp", string(contract.NetAddress), 15*time.Second)
+	conn, err := dialHost(contract.NetAddress, host, modules.BandwidthDownloads)
 	if err != nil {
 		return nil, err
 	}
@@ -238,7 +238,7 @@ func NewEditor(host modules.HostDBEntry, contract modules.RenterContract, curren
 	}
 
 	// initiate revision loop
-	conn, err := net.DialTimeout("tcp", string(contract.NetAddress), 15*time.Second)
+	conn, err := dialHost(contract.NetAddress, host, modules.BandwidthUploads)
 	if err != nil {
 		return nil, err
 	}
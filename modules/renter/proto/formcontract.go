@@ -2,8 +2,6 @@ package proto
 
 import (
 	"errors"
-	"net"
-	"time"
 
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/encoding"
@@ -100,7 +98,7 @@ func FormContract(params ContractParams, txnBuilder transactionBuilder, tpool tr
 	txnSet := append(parentTxns, txn)
 
 	// initiate connection
-	conn, err := net.DialTimeout("tcp", string(host.NetAddress), 15*time.Second)
+	conn, err := dialHost(host.NetAddress, host, modules.BandwidthOther)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
@@ -15,6 +15,27 @@ import (
 // extendDeadline is a helper function for extending the connection timeout.
 func extendDeadline(conn net.Conn, d time.Duration) { _ = conn.SetDeadline(time.Now().Add(d)) }
 
+// dialHost connects to a host at 'addr'. If the host supports encrypted
+// sessions, a session is opened, which also confirms that the host controls
+// the key in 'host'. The connection's bandwidth is counted towards 'category'.
+func dialHost(addr modules.NetAddress, host modules.HostDBEntry, category modules.BandwidthCategory) (net.Conn, error) {
+	conn, err := modules.Dial(addr, 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	conn = modules.NewBandwidthConn(conn, category)
+	if build.VersionCmp(host.Version, modules.SessionVersion) < 0 {
+		return conn, nil
+	}
+	extendDeadline(conn, modules.NegotiateSettingsTime)
+	sess, err := modules.NewRenterSession(conn, host.PublicKey)
+	if err != nil {
+		_ = conn.Close()
+		return nil, errors.New("couldn't open session: " + err.Error())
+	}
+	return sess, nil
+}
+
 // startRevision is run at the beginning of each revision iteration. It reads
 // the host's settings confirms that the values are acceptable, and writes an acceptance.
 func startRevision(conn net.Conn, host modules.HostDBEntry) error {
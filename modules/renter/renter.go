@@ -42,6 +42,10 @@ type hostContractor interface {
 	// Allowance returns the current allowance
 	Allowance() modules.Allowance
 
+	// BackupMetadata writes a copy of the contractor's persistent data into
+	// a directory.
+	BackupMetadata(dir string) error
+
 	// Contract returns the latest contract formed with the specified host.
 	Contract(modules.NetAddress) (modules.RenterContract, bool)
 
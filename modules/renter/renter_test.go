@@ -178,6 +178,7 @@ type stubContractor struct{}
 
 func (stubContractor) SetAllowance(modules.Allowance) error { return nil }
 func (stubContractor) Allowance() modules.Allowance         { return modules.Allowance{} }
+func (stubContractor) BackupMetadata(string) error          { return nil }
 func (stubContractor) Contract(modules.NetAddress) (modules.RenterContract, bool) {
 	return modules.RenterContract{}, false
 }
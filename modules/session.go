@@ -0,0 +1,243 @@
+package modules
+
+// session.go implements the encrypted sessions that renters use to talk to
+// hosts. The renter opens a session by sending RPCSession, followed by an
+// ephemeral X25519 public key. The host responds with its own ephemeral key
+// and a signature of both keys, made with the key that it announced on the
+// blockchain. Signing the ephemeral keys proves to the renter that it is
+// talking to the host it expected, and both sides derive the keys of the
+// session from the shared X25519 secret. After the handshake, all data is
+// sent in frames that are encrypted and authenticated with Twofish-GCM, the
+// cipher that the crypto package already uses to encrypt files.
+//
+// The renter does not need to be authenticated during the handshake, as
+// every action that it requests from the host is authorized by a signature
+// from the renter's contract key.
+
+import (
+	"crypto/cipher"
+	"crypto/ecdh"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// SessionVersion is the first version of siad whose hosts accept
+	// RPCSession. Renters only open encrypted sessions with hosts that
+	// report this version or higher in their settings.
+	SessionVersion = "1.0.1"
+
+	// maxSessionFrameSize is the largest amount of plaintext that is sent in
+	// a single frame of an encrypted session.
+	maxSessionFrameSize = 1 << 16
+
+	// x25519KeySize is the size of an X25519 public or secret key.
+	x25519KeySize = 32
+)
+
+var (
+	// ErrBadSessionFrame is returned when a frame of an encrypted session
+	// fails to decrypt, which means that it was corrupted or tampered with.
+	ErrBadSessionFrame = errors.New("session frame could not be authenticated")
+
+	// ErrBadSessionSignature is returned when the host's signature of the
+	// session handshake is invalid.
+	ErrBadSessionSignature = errors.New("host did not sign the session handshake with its announced key")
+
+	errSessionFrameSize = errors.New("session frame is too large")
+)
+
+// sessionConn is a net.Conn that encrypts and authenticates all data sent
+// over the underlying connection. Deadlines are set on the underlying
+// connection.
+type sessionConn struct {
+	net.Conn
+
+	readAEAD  cipher.AEAD
+	writeAEAD cipher.AEAD
+
+	readNonce  uint64
+	writeNonce uint64
+
+	// readBuf contains the decrypted data of the last frame that has not yet
+	// been read.
+	readBuf []byte
+}
+
+// sessionNonce converts a frame counter into a nonce for 'aead'. Each
+// direction of the session uses its own key, so the counters can overlap.
+func sessionNonce(aead cipher.AEAD, n uint64) []byte {
+	b := make([]byte, aead.NonceSize())
+	binary.LittleEndian.PutUint64(b, n)
+	return b
+}
+
+// Read reads decrypted data from the session.
+func (sc *sessionConn) Read(p []byte) (int, error) {
+	if len(sc.readBuf) == 0 {
+		var prefix [4]byte
+		if _, err := io.ReadFull(sc.Conn, prefix[:]); err != nil {
+			return 0, err
+		}
+		size := binary.LittleEndian.Uint32(prefix[:])
+		if size > uint32(maxSessionFrameSize+sc.readAEAD.Overhead()) {
+			return 0, errSessionFrameSize
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(sc.Conn, frame); err != nil {
+			return 0, err
+		}
+		plaintext, err := sc.readAEAD.Open(frame[:0], sessionNonce(sc.readAEAD, sc.readNonce), frame, nil)
+		if err != nil {
+			return 0, ErrBadSessionFrame
+		}
+		sc.readNonce++
+		sc.readBuf = plaintext
+	}
+	n := copy(p, sc.readBuf)
+	sc.readBuf = sc.readBuf[n:]
+	return n, nil
+}
+
+// Write encrypts data and writes it to the session.
+func (sc *sessionConn) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxSessionFrameSize {
+			chunk = chunk[:maxSessionFrameSize]
+		}
+		frame := make([]byte, 4, 4+len(chunk)+sc.writeAEAD.Overhead())
+		frame = sc.writeAEAD.Seal(frame, sessionNonce(sc.writeAEAD, sc.writeNonce), chunk, nil)
+		binary.LittleEndian.PutUint32(frame, uint32(len(frame)-4))
+		if _, err := sc.Conn.Write(frame); err != nil {
+			return written, err
+		}
+		sc.writeNonce++
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+// generateEphemeralKey returns a random X25519 key pair.
+func generateEphemeralKey() (sk *ecdh.PrivateKey, pk []byte, err error) {
+	entropy, err := crypto.RandBytes(x25519KeySize)
+	if err != nil {
+		return nil, nil, err
+	}
+	sk, err = ecdh.X25519().NewPrivateKey(entropy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk, sk.PublicKey().Bytes(), nil
+}
+
+// sharedSecret returns the X25519 secret shared by 'sk' and the public key
+// 'pk' of the other side of the session.
+func sharedSecret(sk *ecdh.PrivateKey, pk []byte) ([]byte, error) {
+	theirPK, err := ecdh.X25519().NewPublicKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	return sk.ECDH(theirPK)
+}
+
+// newSessionConn derives the keys of a session from the shared secret and
+// the ephemeral keys of the renter and the host.
+func newSessionConn(conn net.Conn, shared, renterKey, hostKey []byte, isRenter bool) (*sessionConn, error) {
+	renterToHost := crypto.HashAll(shared, renterKey, hostKey, "renter")
+	hostToRenter := crypto.HashAll(shared, renterKey, hostKey, "host")
+	if !isRenter {
+		renterToHost, hostToRenter = hostToRenter, renterToHost
+	}
+	// NOTE: NewGCM only returns an error if the block size of the cipher is
+	// not 16, which is never the case for Twofish.
+	writeAEAD, err := cipher.NewGCM(crypto.TwofishKey(renterToHost).NewCipher())
+	if err != nil {
+		return nil, err
+	}
+	readAEAD, err := cipher.NewGCM(crypto.TwofishKey(hostToRenter).NewCipher())
+	if err != nil {
+		return nil, err
+	}
+	return &sessionConn{
+		Conn:      conn,
+		readAEAD:  readAEAD,
+		writeAEAD: writeAEAD,
+	}, nil
+}
+
+// NewRenterSession opens an encrypted session with a host, confirming that
+// the host controls the secret key of 'hostKey'. The RPC specifier should be
+// written to the returned connection.
+func NewRenterSession(conn net.Conn, hostKey types.SiaPublicKey) (net.Conn, error) {
+	if hostKey.Algorithm != types.SignatureEd25519 || len(hostKey.Key) != crypto.PublicKeySize {
+		return nil, errors.New("host used unsupported signature algorithm")
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], hostKey.Key)
+
+	ourSK, ourPK, err := generateEphemeralKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := encoding.WriteObject(conn, RPCSession); err != nil {
+		return nil, err
+	}
+	if err := encoding.WriteObject(conn, ourPK); err != nil {
+		return nil, err
+	}
+
+	var hostPK []byte
+	var sig crypto.Signature
+	if err := encoding.ReadObject(conn, &hostPK, 8+x25519KeySize); err != nil {
+		return nil, err
+	}
+	if err := encoding.ReadObject(conn, &sig, crypto.SignatureSize); err != nil {
+		return nil, err
+	}
+	if crypto.VerifyHash(crypto.HashAll(ourPK, hostPK), pk, sig) != nil {
+		return nil, ErrBadSessionSignature
+	}
+	shared, err := sharedSecret(ourSK, hostPK)
+	if err != nil {
+		return nil, err
+	}
+	return newSessionConn(conn, shared, ourPK, hostPK, true)
+}
+
+// NewHostSession completes the handshake of an encrypted session, after the
+// host has read RPCSession from the connection. The specifier of the RPC
+// that the renter is calling should be read from the returned connection.
+func NewHostSession(conn net.Conn, sk crypto.SecretKey) (net.Conn, error) {
+	var renterPK []byte
+	if err := encoding.ReadObject(conn, &renterPK, 8+x25519KeySize); err != nil {
+		return nil, err
+	}
+	ourSK, ourPK, err := generateEphemeralKey()
+	if err != nil {
+		return nil, err
+	}
+	shared, err := sharedSecret(ourSK, renterPK)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := crypto.SignHash(crypto.HashAll(renterPK, ourPK), sk)
+	if err != nil {
+		return nil, err
+	}
+	if err := encoding.WriteObject(conn, ourPK); err != nil {
+		return nil, err
+	}
+	if err := encoding.WriteObject(conn, sig); err != nil {
+		return nil, err
+	}
+	return newSessionConn(conn, shared, renterPK, ourPK, false)
+}
@@ -0,0 +1,111 @@
+package modules
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// tamperConn flips the last bit of every write.
+type tamperConn struct {
+	net.Conn
+}
+
+func (tc *tamperConn) Write(p []byte) (int, error) {
+	b := append([]byte(nil), p...)
+	b[len(b)-1] ^= 1
+	return tc.Conn.Write(b)
+}
+
+// openTestSession opens a session over an in-memory connection, returning the
+// host's side of the session and the error returned to the renter.
+func openTestSession(renterConn, hostConn net.Conn, hostKey types.SiaPublicKey, sk crypto.SecretKey) (net.Conn, net.Conn, error) {
+	hostChan := make(chan net.Conn, 1)
+	go func() {
+		var id types.Specifier
+		if err := encoding.ReadObject(hostConn, &id, 16); err != nil || id != RPCSession {
+			hostChan <- nil
+			return
+		}
+		sess, _ := NewHostSession(hostConn, sk)
+		hostChan <- sess
+	}()
+	renterSess, err := NewRenterSession(renterConn, hostKey)
+	return renterSess, <-hostChan, err
+}
+
+// TestSession checks that data sent over a session arrives intact, and that
+// renters detect hosts that do not control their announced key.
+func TestSession(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPairDeterministic([crypto.EntropySize]byte{1})
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: pk[:]}
+
+	renterConn, hostConn := net.Pipe()
+	renterSess, hostSess, err := openTestSession(renterConn, hostConn, hostKey, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send an RPC specifier, followed by data that spans several frames.
+	data, err := crypto.RandBytes(3*maxSessionFrameSize + 17)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		encoding.WriteObject(renterSess, RPCSettings)
+		renterSess.Write(data)
+	}()
+	var id types.Specifier
+	if err := encoding.ReadObject(hostSess, &id, 16); err != nil {
+		t.Fatal(err)
+	}
+	if id != RPCSettings {
+		t.Fatal("wrong specifier was received:", id)
+	}
+	recv := make([]byte, len(data))
+	if _, err := io.ReadFull(hostSess, recv); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(recv, data) {
+		t.Fatal("data was corrupted by the session")
+	}
+	renterConn.Close()
+	hostConn.Close()
+
+	// A host with a different key cannot open a session.
+	wrongSK, _ := crypto.GenerateKeyPairDeterministic([crypto.EntropySize]byte{2})
+	renterConn, hostConn = net.Pipe()
+	_, _, err = openTestSession(renterConn, hostConn, hostKey, wrongSK)
+	if err != ErrBadSessionSignature {
+		t.Fatal("expected ErrBadSessionSignature, got", err)
+	}
+	renterConn.Close()
+	hostConn.Close()
+}
+
+// TestSessionTampering checks that modified frames are rejected.
+func TestSessionTampering(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPairDeterministic([crypto.EntropySize]byte{1})
+	hostKey := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: pk[:]}
+
+	renterConn, hostConn := net.Pipe()
+	defer renterConn.Close()
+	defer hostConn.Close()
+	renterSess, hostSess, err := openTestSession(renterConn, hostConn, hostKey, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the frames written by the renter after the handshake.
+	renterSess.(*sessionConn).Conn = &tamperConn{Conn: renterConn}
+	go renterSess.Write([]byte("hello, host"))
+	buf := make([]byte, 64)
+	if _, err := hostSess.Read(buf); err != ErrBadSessionFrame {
+		t.Fatal("expected ErrBadSessionFrame, got", err)
+	}
+}
@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"errors"
+
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/types"
 )
@@ -11,6 +13,18 @@ const (
 	StorageManagerDir = "storagemanager"
 )
 
+var (
+	// ErrInsufficientStorageForRemoval is returned if the remaining storage
+	// folders do not have enough space remaining to support a storage folder
+	// being removed.
+	ErrInsufficientStorageForRemoval = errors.New("not enough storage remaining to support removal of disk")
+
+	// ErrInsufficientStorageForShrink is returned if the remaining storage
+	// folders do not have enough space remaining to support a storage folder
+	// being reduced in size.
+	ErrInsufficientStorageForShrink = errors.New("not enough storage remaining to support shrinking of disk")
+)
+
 type (
 	// StorageFolderMetadata contains metadata about a storage folder that is
 	// tracked by the storage folder manager.
@@ -51,6 +65,11 @@ type (
 		// gracefully handle running out of storage unexpectedly.
 		AddStorageFolder(path string, size uint64) error
 
+		// BackupMetadata writes a copy of the storage folder metadata and the
+		// sector locations into a directory, laid out as in the persist
+		// directory of the storage manager.
+		BackupMetadata(dir string) error
+
 		// The storage manager needs to be able to shut down.
 		Close() error
 
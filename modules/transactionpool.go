@@ -46,6 +46,23 @@ var (
 	// will never be used within the formal Sia protocol.
 	PrefixNonSia = types.Specifier{'N', 'o', 'n', 'S', 'i', 'a'}
 
+	// ErrLargeArbitraryData is the error that gets returned if a transaction
+	// is submitted to the transaction pool which contains arbitrary data that
+	// is larger than the limit for its prefix.
+	ErrLargeArbitraryData = errors.New("transaction contains arbitrary data that is too large for its prefix")
+
+	// ArbitraryDataSizeLimits is the registry of arbitrary data prefixes that
+	// are recognized by the IsStandard rules. Each prefix maps to the size of
+	// the largest piece of arbitrary data, prefix included, that the
+	// transaction pool will accept and relay with that prefix. Arbitrary data
+	// with any other prefix is rejected. Host announcements are small, and
+	// limiting the size of non-Sia data keeps the blockchain from being used
+	// as cheap bulk storage.
+	ArbitraryDataSizeLimits = map[types.Specifier]int{
+		PrefixHostAnnouncement: 1e3,
+		PrefixNonSia:           10e3,
+	}
+
 	// TransactionPoolDir is the name of the directory that is used to store
 	// the transaction pool's persistent data.
 	TransactionPoolDir = "transactionpool"
@@ -70,6 +87,11 @@ type TransactionPool interface {
 	// Close is necessary for clean shutdown (e.g. during testing).
 	Close() error
 
+	// Conflicted returns true if the unconfirmed transaction with the given
+	// id is known to have a competing spend that could be confirmed instead
+	// of it.
+	Conflicted(types.TransactionID) bool
+
 	// FeeEstimation returns an estimation for how high the transaction fee
 	// needs to be per byte. The minimum recommended targets getting accepted
 	// in ~3 blocks, and the maximum recommended targets getting accepted
@@ -14,19 +14,22 @@ import (
 	"github.com/NebulousLabs/bolt"
 )
 
-const (
-	// The TransactionPoolSizeLimit is first checked, and then a transaction
-	// set is added. The current transaction pool does not do any priority
-	// ordering, so the size limit is such that the transaction pool will never
-	// exceed the size of a block.
+var (
+	// TransactionPoolSizeLimit is the largest that the transaction pool can
+	// grow. When a new transaction set does not fit, the sets with the lowest
+	// fee density are evicted to make room for it. The current transaction
+	// pool does not do any other priority ordering, so the size limit is such
+	// that the transaction pool will never exceed the size of a block.
 	//
 	// TODO: Add a priority structure that will allow the transaction pool to
 	// fill up beyond the size of a single block, without being subject to
 	// manipulation.
-	//
+	TransactionPoolSizeLimit = int(types.MaxTransactionSize - modules.TransactionSetSizeLimit)
+)
+
+const (
 	// The first ~1/4 of the transaction pool can be filled for free. This is
 	// mostly to preserve compatibility with clients that do not add fees.
-	TransactionPoolSizeLimit  = 2e6 - 5e3 - modules.TransactionSetSizeLimit
 	TransactionPoolSizeForFee = 500e3
 )
 
@@ -76,12 +79,6 @@ func relatedObjectIDs(ts []types.Transaction) []ObjectID {
 // checkMinerFees checks that the total amount of transaction fees in the
 // transaction set is sufficient to earn a spot in the transaction pool.
 func (tp *TransactionPool) checkMinerFees(ts []types.Transaction) error {
-	// Transactions cannot be added after the TransactionPoolSizeLimit has been
-	// hit.
-	if tp.transactionListSize > TransactionPoolSizeLimit {
-		return errFullTransactionPool
-	}
-
 	// The first TransactionPoolSizeForFee transactions do not need fees.
 	if tp.transactionListSize > TransactionPoolSizeForFee {
 		// Currently required fees are set on a per-transaction basis. 2 coins
@@ -135,8 +132,9 @@ func (tp *TransactionPool) checkTransactionSetComposition(ts []types.Transaction
 }
 
 // handleConflicts detects whether the conflicts in the transaction pool are
-// legal children of the new transaction pool set or not.
-func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []TransactionSetID) error {
+// legal children of the new transaction pool set or not. If they are, the new
+// set is merged with the conflicts, and the id of the merged set is returned.
+func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []TransactionSetID) (TransactionSetID, error) {
 	// Create a list of all the transaction ids that compose the set of
 	// conflicts.
 	conflictMap := make(map[types.TransactionID]TransactionSetID)
@@ -157,7 +155,7 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 		dedupSet = append(dedupSet, t)
 	}
 	if len(dedupSet) == 0 {
-		return modules.ErrDuplicateTransactionSet
+		return TransactionSetID{}, modules.ErrDuplicateTransactionSet
 	}
 	// If transactions were pruned, it's possible that the set of
 	// dependencies/conflicts has also reduced. To minimize computational load
@@ -199,13 +197,21 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	// IsStandard rules (this is a new set, the rules must be rechecked).
 	err := tp.checkTransactionSetComposition(superset)
 	if err != nil {
-		return err
+		return TransactionSetID{}, err
+	}
+
+	// Check that there is room for the transaction set in the pool.
+	evictions, err := tp.setsToEvict(superset, supersetMap)
+	if err != nil {
+		return TransactionSetID{}, err
 	}
 
-	// Check that the transaction set is valid.
+	// Check that the transaction set is valid. If it is not, the new
+	// transactions may be a competing spend of the conflicts.
 	cc, err := tp.consensusSet.TryTransactionSet(superset)
 	if err != nil {
-		return modules.NewConsensusConflict(err.Error())
+		tp.markDoubleSpends(dedupSet, supersetMap)
+		return TransactionSetID{}, modules.NewConsensusConflict(err.Error())
 	}
 
 	// Remove the conflicts from the transaction pool. The diffs do not need to
@@ -216,6 +222,9 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 		delete(tp.transactionSets, conflict)
 		delete(tp.transactionSetDiffs, conflict)
 	}
+	for _, id := range evictions {
+		tp.removeTransactionSet(id)
+	}
 
 	// Add the transaction set to the pool.
 	setID := TransactionSetID(crypto.HashObject(superset))
@@ -231,14 +240,16 @@ func (tp *TransactionPool) handleConflicts(ts []types.Transaction, conflicts []T
 	}
 	tp.transactionSetDiffs[setID] = cc
 	tp.transactionListSize += len(encoding.Marshal(superset))
-	return nil
+	return setID, nil
 }
 
 // acceptTransactionSet verifies that a transaction set is allowed to be in the
-// transaction pool, and then adds it to the transaction pool.
-func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction) error {
+// transaction pool, and then adds it to the transaction pool. The id of the set
+// that the transactions were added to is returned; a transaction set that
+// depends on unconfirmed transactions is merged with their sets.
+func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction) (TransactionSetID, error) {
 	if len(ts) == 0 {
-		return errEmptySet
+		return TransactionSetID{}, errEmptySet
 	}
 
 	// Remove all transactions that have been confirmed in the transaction set.
@@ -253,18 +264,18 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction) error {
 		return nil
 	})
 	if err != nil {
-		return err
+		return TransactionSetID{}, err
 	}
 	// If no transactions remain, return a dublicate error.
 	if len(ts) == 0 {
-		return modules.ErrDuplicateTransactionSet
+		return TransactionSetID{}, modules.ErrDuplicateTransactionSet
 	}
 
 	// Check the composition of the transaction set, including fees and
 	// IsStandard rules.
 	err = tp.checkTransactionSetComposition(ts)
 	if err != nil {
-		return err
+		return TransactionSetID{}, err
 	}
 
 	// Check for conflicts with other transactions, which would indicate a
@@ -281,12 +292,20 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction) error {
 	if len(conflicts) > 0 {
 		return tp.handleConflicts(ts, conflicts)
 	}
+	evictions, err := tp.setsToEvict(ts, nil)
+	if err != nil {
+		return TransactionSetID{}, err
+	}
 	cc, err := tp.consensusSet.TryTransactionSet(ts)
 	if err != nil {
-		return modules.NewConsensusConflict(err.Error())
+		return TransactionSetID{}, modules.NewConsensusConflict(err.Error())
 	}
 
-	// Add the transaction set to the pool.
+	// Evict transaction sets with lower fees to make room, and then add the
+	// transaction set to the pool.
+	for _, id := range evictions {
+		tp.removeTransactionSet(id)
+	}
 	setID := TransactionSetID(crypto.HashObject(ts))
 	tp.transactionSets[setID] = ts
 	for _, oid := range oids {
@@ -294,7 +313,7 @@ func (tp *TransactionPool) acceptTransactionSet(ts []types.Transaction) error {
 	}
 	tp.transactionSetDiffs[setID] = cc
 	tp.transactionListSize += len(encoding.Marshal(ts))
-	return nil
+	return setID, nil
 }
 
 // AcceptTransaction adds a transaction to the unconfirmed set of
@@ -304,13 +323,16 @@ func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
 
-	err := tp.acceptTransactionSet(ts)
+	setID, err := tp.acceptTransactionSet(ts)
 	if err != nil {
 		return err
 	}
 
-	// Notify subscribers and broadcast the transaction set.
-	go tp.gateway.Broadcast("RelayTransactionSet", ts, tp.gateway.Peers())
+	// Notify subscribers and relay the transaction set. If the set was
+	// merged with the sets of its unconfirmed parents, the merged set is
+	// relayed, so that peers which are missing the parents can accept the
+	// children.
+	go tp.threadedRelayTransactionSet(tp.transactionSets[setID])
 	tp.updateSubscribersTransactions()
 	return nil
 }
@@ -319,10 +341,22 @@ func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
 // the accept is successful, the transaction will be relayed to the gateway's
 // other peers.
 func (tp *TransactionPool) relayTransactionSet(conn modules.PeerConn) error {
-	var ts []types.Transaction
-	err := encoding.ReadObject(conn, &ts, types.BlockSizeLimit)
+	ts, err := tp.readTransactionSet(conn)
 	if err != nil {
 		return err
 	}
-	return tp.AcceptTransactionSet(ts)
+	return tp.acceptRelayedSet(conn, ts)
+}
+
+// isNonstandardErr returns true if a transaction set was rejected for
+// breaking an IsStandard rule that honest nodes do not relay sets for.
+// Unrecognized arbitrary data prefixes and key types are not included, as
+// they may be relayed by nodes that have adopted a soft fork, and neither are
+// the arbitrary data size limits, which older nodes do not enforce.
+func isNonstandardErr(err error) bool {
+	switch err {
+	case errEmptySet, modules.ErrLargeTransaction, modules.ErrLargeTransactionSet:
+		return true
+	}
+	return false
 }
@@ -114,6 +114,77 @@ func TestIntegrationConflictingTransactionSets(t *testing.T) {
 	}
 }
 
+// TestIntegrationConflictedTransactions checks that transactions are marked as
+// conflicted when a valid competing spend is seen.
+func TestIntegrationConflictedTransactions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester("TestIntegrationConflictedTransactions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Create two sets that spend the same output, as in
+	// TestIntegrationConflictingTransactionSets, and an invalid third set
+	// that spends it as well.
+	fund := types.NewCurrency64(30e6)
+	txnBuilder := tpt.wallet.StartTransaction()
+	err = txnBuilder.FundSiacoins(fund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSet, err := txnBuilder.Sign(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSetDoubleSpend := make([]types.Transaction, len(txnSet))
+	copy(txnSetDoubleSpend, txnSet)
+	txnSetInvalid := make([]types.Transaction, len(txnSet))
+	copy(txnSetInvalid, txnSet)
+	txnIndex := len(txnSet) - 1
+	txnSet[txnIndex].MinerFees = append(txnSet[txnIndex].MinerFees, fund)
+	txnSetDoubleSpend[txnIndex].SiacoinOutputs = append(txnSetDoubleSpend[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund})
+	txnSetInvalid[txnIndex].SiacoinOutputs = append(txnSetInvalid[txnIndex].SiacoinOutputs, types.SiacoinOutput{Value: fund.Mul64(2)})
+	spendID := txnSet[txnIndex].ID()
+
+	err = tpt.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpt.tpool.Conflicted(spendID) {
+		t.Fatal("transaction is conflicted before a competing spend was seen")
+	}
+
+	// An invalid competing spend does not mark the transaction.
+	err = tpt.tpool.AcceptTransactionSet(txnSetInvalid)
+	if err == nil {
+		t.Fatal("invalid double spend was accepted")
+	}
+	if tpt.tpool.Conflicted(spendID) {
+		t.Fatal("transaction was marked by an invalid competing spend")
+	}
+
+	// A valid competing spend marks the transaction.
+	err = tpt.tpool.AcceptTransactionSet(txnSetDoubleSpend)
+	if err == nil {
+		t.Fatal("double spend was accepted")
+	}
+	if !tpt.tpool.Conflicted(spendID) {
+		t.Fatal("transaction was not marked as conflicted")
+	}
+	if len(txnSet) > 1 && tpt.tpool.Conflicted(txnSet[0].ID()) {
+		t.Fatal("parent of the transaction was marked as conflicted")
+	}
+
+	// The mark is removed once the transaction leaves the pool.
+	tpt.tpool.PurgeTransactionPool()
+	if tpt.tpool.Conflicted(spendID) {
+		t.Fatal("purged transaction is still conflicted")
+	}
+}
+
 // TestIntegrationCheckMinerFees probes the checkMinerFees method of the
 // transaction pool.
 func TestIntegrationCheckMinerFees(t *testing.T) {
@@ -310,6 +381,53 @@ func TestIntegrationTransactionChild(t *testing.T) {
 	}
 }
 
+// TestIntegrationChildMergedWithParent checks that a child transaction is
+// merged into the set of its unconfirmed parent, with the parent first, so
+// that the parent is mined and relayed along with the child.
+func TestIntegrationChildMergedWithParent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester("TestIntegrationChildMergedWithParent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt.Close()
+
+	// Create a parent transaction and a child that spends it.
+	fund := types.NewCurrency64(30e6)
+	txnBuilder := tpt.wallet.StartTransaction()
+	err = txnBuilder.FundSiacoins(fund)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnBuilder.AddMinerFee(fund)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txnSet) != 2 {
+		t.Fatal("test is invalid unless the transaction set has a parent and a child")
+	}
+	err = tpt.tpool.AcceptTransactionSet(txnSet[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tpt.tpool.AcceptTransactionSet(txnSet[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The pool should hold a single set, with the parent first.
+	if len(tpt.tpool.transactionSets) != 1 {
+		t.Fatal("child was not merged with its parent:", len(tpt.tpool.transactionSets))
+	}
+	txns := tpt.tpool.TransactionList()
+	if len(txns) != 2 || txns[0].ID() != txnSet[0].ID() || txns[1].ID() != txnSet[1].ID() {
+		t.Fatal("parent does not come before the child")
+	}
+}
+
 // TestIntegrationNilAccept tries submitting a nil transaction set and a 0-len
 // transaction set to the transaction pool.
 func TestIntegrationNilAccept(t *testing.T) {
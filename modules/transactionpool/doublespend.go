@@ -0,0 +1,106 @@
+package transactionpool
+
+// doublespend.go tracks unconfirmed transactions that have a competing spend.
+// The transaction pool only keeps the first spend of an output that it sees,
+// and rejects later spends of the same output. A rejected spend may still be
+// mined by a miner that saw it first, so the transactions in the pool that it
+// conflicts with are marked as conflicted. Modules can use the mark to decide
+// whether to wait for confirmations before trusting an unconfirmed
+// transaction, such as the funding of a file contract.
+
+import (
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// spentObjectIDs returns the ids of the objects that are spent by a
+// transaction.
+func spentObjectIDs(t types.Transaction) []ObjectID {
+	var oids []ObjectID
+	for _, sci := range t.SiacoinInputs {
+		oids = append(oids, ObjectID(sci.ParentID))
+	}
+	for _, fcr := range t.FileContractRevisions {
+		oids = append(oids, ObjectID(fcr.ParentID))
+	}
+	for _, sp := range t.StorageProofs {
+		oids = append(oids, ObjectID(sp.ParentID))
+	}
+	for _, sfi := range t.SiafundInputs {
+		oids = append(oids, ObjectID(sfi.ParentID))
+	}
+	return oids
+}
+
+// markDoubleSpends is called when 'ts' has been rejected for conflicting with
+// the sets in 'conflicts'. If 'ts' would be valid in place of the transactions
+// that it conflicts with, it is a competing spend, and every transaction in
+// the conflicting sets that spends an object also spent by 'ts' is marked as
+// conflicted. Invalid sets are ignored so that transactions cannot be marked
+// by spends that could never be mined.
+func (tp *TransactionPool) markDoubleSpends(ts []types.Transaction, conflicts map[TransactionSetID]struct{}) {
+	spent := make(map[ObjectID]types.TransactionID)
+	for _, txn := range ts {
+		for _, oid := range spentObjectIDs(txn) {
+			spent[oid] = txn.ID()
+		}
+	}
+
+	doubleSpends := make(map[types.TransactionID]struct{})
+	var parents []types.Transaction
+	for conflict := range conflicts {
+		for _, txn := range tp.transactionSets[conflict] {
+			txid := txn.ID()
+			doubleSpend := false
+			for _, oid := range spentObjectIDs(txn) {
+				if spender, exists := spent[oid]; exists && spender != txid {
+					doubleSpend = true
+					break
+				}
+			}
+			if doubleSpend {
+				doubleSpends[txid] = struct{}{}
+			} else {
+				parents = append(parents, txn)
+			}
+		}
+	}
+	if len(doubleSpends) == 0 {
+		return
+	}
+
+	// The competing spend may depend on unconfirmed parents in the
+	// conflicting sets, so it is validated along with the transactions that
+	// it does not conflict with.
+	_, err := tp.consensusSet.TryTransactionSet(append(parents, ts...))
+	if err != nil {
+		return
+	}
+	for txid := range doubleSpends {
+		tp.conflictedTransactions[txid] = struct{}{}
+	}
+}
+
+// pruneConflicted forgets the conflicted transactions that are no longer in
+// the transaction pool.
+func (tp *TransactionPool) pruneConflicted() {
+	inPool := make(map[types.TransactionID]struct{})
+	for _, ts := range tp.transactionSets {
+		for _, txn := range ts {
+			inPool[txn.ID()] = struct{}{}
+		}
+	}
+	for txid := range tp.conflictedTransactions {
+		if _, exists := inPool[txid]; !exists {
+			delete(tp.conflictedTransactions, txid)
+		}
+	}
+}
+
+// Conflicted returns true if the unconfirmed transaction with the given id is
+// known to have a competing spend that could be confirmed instead of it.
+func (tp *TransactionPool) Conflicted(id types.TransactionID) bool {
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	_, exists := tp.conflictedTransactions[id]
+	return exists
+}
@@ -0,0 +1,121 @@
+package transactionpool
+
+// evict.go bounds the size of the transaction pool. When a transaction set
+// does not fit in the pool, the sets with the lowest fee density (miner fees
+// per byte) are evicted to make room for it, as long as the new set pays a
+// higher fee density than every set that gets evicted. Each transaction set in
+// the pool contains all of its unconfirmed dependencies, so dependent
+// transactions are always evicted together.
+
+import (
+	"sort"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+type (
+	// feeDensity is the total amount of miner fees paid by a transaction set,
+	// along with the size of the set in bytes.
+	feeDensity struct {
+		fees types.Currency
+		size int
+	}
+
+	// evictionCandidate is a transaction set in the pool that may be evicted
+	// to make room for a new transaction set.
+	evictionCandidate struct {
+		id      TransactionSetID
+		density feeDensity
+	}
+
+	// evictionCandidates sorts transaction sets by fee density, lowest first.
+	evictionCandidates []evictionCandidate
+)
+
+func (ec evictionCandidates) Len() int      { return len(ec) }
+func (ec evictionCandidates) Swap(i, j int) { ec[i], ec[j] = ec[j], ec[i] }
+func (ec evictionCandidates) Less(i, j int) bool {
+	return ec[i].density.lessThan(ec[j].density)
+}
+
+// lessThan returns true if 'fd' pays fewer fees per byte than 'cmp'.
+func (fd feeDensity) lessThan(cmp feeDensity) bool {
+	return fd.fees.Mul64(uint64(cmp.size)).Cmp(cmp.fees.Mul64(uint64(fd.size))) < 0
+}
+
+// setFeeDensity returns the fee density of a transaction set.
+func setFeeDensity(ts []types.Transaction) feeDensity {
+	var fees types.Currency
+	for _, txn := range ts {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	return feeDensity{
+		fees: fees,
+		size: len(encoding.Marshal(ts)),
+	}
+}
+
+// setsToEvict returns the transaction sets that need to be evicted from the
+// pool to make room for 'ts'. The sets in 'replaced' are removed from the pool
+// when 'ts' is added, as 'ts' contains their transactions, so they are neither
+// counted against the size limit nor evicted. errFullTransactionPool is
+// returned if enough room can only be made by evicting sets that have the same
+// or a higher fee density than 'ts'.
+func (tp *TransactionPool) setsToEvict(ts []types.Transaction, replaced map[TransactionSetID]struct{}) ([]TransactionSetID, error) {
+	density := setFeeDensity(ts)
+	excess := tp.transactionListSize + density.size - TransactionPoolSizeLimit
+	for id := range replaced {
+		excess -= len(encoding.Marshal(tp.transactionSets[id]))
+	}
+	if excess <= 0 {
+		return nil, nil
+	}
+
+	var candidates evictionCandidates
+	for id, set := range tp.transactionSets {
+		if _, exists := replaced[id]; exists {
+			continue
+		}
+		candidates = append(candidates, evictionCandidate{
+			id:      id,
+			density: setFeeDensity(set),
+		})
+	}
+	sort.Sort(candidates)
+
+	var evictions []TransactionSetID
+	for _, candidate := range candidates {
+		if !candidate.density.lessThan(density) {
+			break
+		}
+		evictions = append(evictions, candidate.id)
+		excess -= candidate.density.size
+		if excess <= 0 {
+			return evictions, nil
+		}
+	}
+	return nil, errFullTransactionPool
+}
+
+// removeTransactionSet removes a transaction set from the pool, along with
+// the objects that it created or consumed.
+func (tp *TransactionPool) removeTransactionSet(id TransactionSetID) {
+	ts, exists := tp.transactionSets[id]
+	if !exists {
+		return
+	}
+	for _, oid := range relatedObjectIDs(ts) {
+		if tp.knownObjects[oid] == id {
+			delete(tp.knownObjects, oid)
+		}
+	}
+	for _, txn := range ts {
+		delete(tp.conflictedTransactions, txn.ID())
+	}
+	tp.transactionListSize -= len(encoding.Marshal(ts))
+	delete(tp.transactionSets, id)
+	delete(tp.transactionSetDiffs, id)
+}
@@ -0,0 +1,100 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// addTestSet adds a transaction set of roughly 'size' bytes that pays 'fee' to
+// the transaction pool, without any validation.
+func addTestSet(tp *TransactionPool, size int, fee uint64) TransactionSetID {
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID(crypto.HashObject(len(tp.transactionSets)))}},
+		MinerFees:     []types.Currency{types.NewCurrency64(fee)},
+		ArbitraryData: [][]byte{make([]byte, size)},
+	}
+	ts := []types.Transaction{txn}
+	setID := TransactionSetID(crypto.HashObject(ts))
+	tp.transactionSets[setID] = ts
+	for _, oid := range relatedObjectIDs(ts) {
+		tp.knownObjects[oid] = setID
+	}
+	tp.transactionSetDiffs[setID] = modules.ConsensusChange{}
+	tp.transactionListSize += len(encoding.Marshal(ts))
+	return setID
+}
+
+// TestSetsToEvict checks that transaction sets with the lowest fee density are
+// evicted to make room for new transaction sets.
+func TestSetsToEvict(t *testing.T) {
+	tp := &TransactionPool{
+		knownObjects:        make(map[ObjectID]TransactionSetID),
+		transactionSets:     make(map[TransactionSetID][]types.Transaction),
+		transactionSetDiffs: make(map[TransactionSetID]modules.ConsensusChange),
+	}
+
+	// Fill the pool with four sets of increasing fee density.
+	setSize := TransactionPoolSizeLimit / 4
+	low := addTestSet(tp, setSize-200, 10)
+	mid := addTestSet(tp, setSize-200, 20)
+	addTestSet(tp, setSize-200, 30)
+	addTestSet(tp, setSize-200, 40)
+
+	// A small set fits without evicting anything.
+	evictions, err := tp.setsToEvict([]types.Transaction{{}}, nil)
+	if err != nil || len(evictions) != 0 {
+		t.Fatal("expecting no evictions:", evictions, err)
+	}
+
+	// A set that does not pay more than the lowest set is rejected.
+	big := []types.Transaction{{
+		MinerFees:     []types.Currency{types.NewCurrency64(10)},
+		ArbitraryData: [][]byte{make([]byte, setSize)},
+	}}
+	_, err = tp.setsToEvict(big, nil)
+	if err != errFullTransactionPool {
+		t.Fatal("expecting errFullTransactionPool, got", err)
+	}
+
+	// A set that pays more than the two lowest sets evicts them, lowest
+	// first.
+	big[0].MinerFees[0] = types.NewCurrency64(25)
+	big[0].ArbitraryData[0] = make([]byte, setSize+100)
+	evictions, err = tp.setsToEvict(big, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evictions) != 2 || evictions[0] != low || evictions[1] != mid {
+		t.Fatal("the wrong sets were evicted:", evictions)
+	}
+
+	// Sets that are replaced by the new set are neither evicted nor counted
+	// against the size limit.
+	replaced := map[TransactionSetID]struct{}{low: {}}
+	evictions, err = tp.setsToEvict(big, replaced)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evictions) != 1 || evictions[0] != mid {
+		t.Fatal("the wrong sets were evicted when replacing a set:", evictions)
+	}
+
+	// Removing a set releases its space and objects.
+	sizeBefore := tp.transactionListSize
+	tp.removeTransactionSet(low)
+	if _, exists := tp.transactionSets[low]; exists {
+		t.Error("evicted set is still in the pool")
+	}
+	if tp.transactionListSize >= sizeBefore {
+		t.Error("evicted set is still counted against the size limit")
+	}
+	for _, setID := range tp.knownObjects {
+		if setID == low {
+			t.Error("objects of the evicted set are still known")
+		}
+	}
+}
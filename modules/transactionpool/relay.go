@@ -0,0 +1,193 @@
+package transactionpool
+
+// relay.go implements inventory-based relay of transaction sets. Instead of
+// sending every accepted transaction set to every peer, the transaction pool
+// announces the IDs of the transactions in the set with the RelayTxnInv RPC,
+// and the peer only requests the transactions that are not already in its
+// pool. Sets that overlap with sets the peer already has, such as a set that
+// was merged with its unconfirmed parents, therefore only cost the bandwidth
+// of the new transactions. On a well-connected node most peers will have
+// received the transactions from someone else first, so most of the bandwidth
+// spent on relaying duplicate transactions is saved.
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/crypto"
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// relayInventoryVersion is the first version of siad that supports the
+	// RelayTxnInv RPC. Older peers are sent transaction sets in full with the
+	// RelayTransactionSet RPC.
+	relayInventoryVersion = "1.0.1"
+)
+
+var (
+	errInventoryIncomplete = errors.New("announced transactions left the transaction pool before the set could be accepted")
+	errInventoryMismatch   = errors.New("peer sent transactions that do not match the requested IDs")
+)
+
+// readTransactionSet reads a transaction set from a peer. Sets that cannot be
+// decoded result in an *encoding.MalformedError, which the gateway reports as
+// misbehavior.
+func (tp *TransactionPool) readTransactionSet(conn modules.PeerConn) ([]types.Transaction, error) {
+	var ts []types.Transaction
+	if err := encoding.ReadObject(conn, &ts, types.BlockSizeLimit); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// acceptRelayedSet accepts a transaction set that was relayed by a peer,
+// reporting the peer if the set breaks the IsStandard rules.
+func (tp *TransactionPool) acceptRelayedSet(conn modules.PeerConn, ts []types.Transaction) error {
+	err := tp.AcceptTransactionSet(ts)
+	if isNonstandardErr(err) {
+		tp.gateway.ReportMisbehavior(conn.RPCAddr(), modules.MisbehaviorNonstandardTransaction)
+	}
+	return err
+}
+
+// poolTransactions returns the transactions in the pool that have one of the
+// given IDs.
+func (tp *TransactionPool) poolTransactions(ids []types.TransactionID) map[types.TransactionID]types.Transaction {
+	wanted := make(map[types.TransactionID]struct{}, len(ids))
+	for _, id := range ids {
+		wanted[id] = struct{}{}
+	}
+	txns := make(map[types.TransactionID]types.Transaction)
+	tp.mu.RLock()
+	defer tp.mu.RUnlock()
+	for _, ts := range tp.transactionSets {
+		for _, txn := range ts {
+			id := txn.ID()
+			if _, ok := wanted[id]; ok {
+				txns[id] = txn
+			}
+		}
+	}
+	return txns
+}
+
+// rpcRelayTransactionInv is the receiving end of the RelayTxnInv RPC. The peer
+// announces the IDs of the transactions in a set, and only the transactions
+// that are not already in the transaction pool are requested. The set is then
+// assembled from the pool and the requested transactions.
+func (tp *TransactionPool) rpcRelayTransactionInv(conn modules.PeerConn) error {
+	var ids []types.TransactionID
+	err := encoding.ReadObject(conn, &ids, types.BlockSizeLimit)
+	if err != nil {
+		return err
+	}
+	have := tp.poolTransactions(ids)
+	var missing []types.TransactionID
+	for _, id := range ids {
+		if _, ok := have[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	err = encoding.WriteObject(conn, missing)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	received, err := tp.readTransactionSet(conn)
+	if err != nil {
+		return err
+	}
+	if len(received) != len(missing) {
+		tp.gateway.ReportMisbehavior(conn.RPCAddr(), modules.MisbehaviorMalformedRPC)
+		return errInventoryMismatch
+	}
+	for i, txn := range received {
+		if txn.ID() != missing[i] {
+			tp.gateway.ReportMisbehavior(conn.RPCAddr(), modules.MisbehaviorMalformedRPC)
+			return errInventoryMismatch
+		}
+		have[missing[i]] = txn
+	}
+
+	// The transactions that were already in the pool may have been confirmed
+	// or evicted while the missing transactions were downloaded.
+	if len(missing) < len(ids) {
+		for id, txn := range tp.poolTransactions(ids) {
+			have[id] = txn
+		}
+	}
+	ts := make([]types.Transaction, 0, len(ids))
+	for _, id := range ids {
+		txn, ok := have[id]
+		if !ok {
+			return errInventoryIncomplete
+		}
+		ts = append(ts, txn)
+	}
+	return tp.acceptRelayedSet(conn, ts)
+}
+
+// announceTransactionSet returns an RPCFunc that is the calling end of the
+// RelayTxnInv RPC. Only the transactions that the peer requests are sent.
+func announceTransactionSet(ts []types.Transaction) modules.RPCFunc {
+	ids := make([]types.TransactionID, len(ts))
+	byID := make(map[types.TransactionID]types.Transaction, len(ts))
+	for i, txn := range ts {
+		ids[i] = txn.ID()
+		byID[ids[i]] = txn
+	}
+	return func(conn modules.PeerConn) error {
+		err := encoding.WriteObject(conn, ids)
+		if err != nil {
+			return err
+		}
+		var requested []types.TransactionID
+		err = encoding.ReadObject(conn, &requested, uint64(len(ids))*crypto.HashSize+8)
+		if err != nil || len(requested) == 0 {
+			return err
+		}
+		txns := make([]types.Transaction, 0, len(requested))
+		for _, id := range requested {
+			txn, ok := byID[id]
+			if !ok {
+				return errInventoryMismatch
+			}
+			txns = append(txns, txn)
+		}
+		return encoding.WriteObject(conn, txns)
+	}
+}
+
+// threadedRelayTransactionSet announces the transactions of a set to all peers
+// that support inventory-based relay, and sends the set in full to older peers.
+func (tp *TransactionPool) threadedRelayTransactionSet(ts []types.Transaction) {
+	var oldPeers []modules.Peer
+	var invPeers []modules.NetAddress
+	for _, p := range tp.gateway.Peers() {
+		if build.VersionCmp(p.Version, relayInventoryVersion) < 0 {
+			oldPeers = append(oldPeers, p)
+		} else {
+			invPeers = append(invPeers, p.NetAddress)
+		}
+	}
+	if len(oldPeers) > 0 {
+		go tp.gateway.Broadcast("RelayTransactionSet", ts, oldPeers)
+	}
+
+	announce := announceTransactionSet(ts)
+	var wg sync.WaitGroup
+	for _, addr := range invPeers {
+		wg.Add(1)
+		go func(addr modules.NetAddress) {
+			defer wg.Done()
+			// Failed announcements are not retried, as a peer that misses
+			// the set will learn of it from its other peers.
+			_ = tp.gateway.RPC(addr, "RelayTxnInv", announce)
+		}(addr)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,103 @@
+package transactionpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/encoding"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestRelayTransactionInv checks that transaction sets are relayed to peers
+// with the RelayTxnInv RPC, and that peers only request sets that they do not
+// already have.
+func TestRelayTransactionInv(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt1, err := createTpoolTester("TestRelayTransactionInv1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt1.Close()
+	tpt2, err := createTpoolTester("TestRelayTransactionInv2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tpt2.Close()
+
+	// Put both testers on the same chain by extending the chain of tpt1.
+	err = tpt2.gateway.Connect(tpt1.gateway.Address())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := tpt1.miner.FindBlock()
+	err = tpt1.cs.AcceptBlock(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50 && tpt2.cs.CurrentBlock().ID() != b.ID(); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if tpt2.cs.CurrentBlock().ID() != b.ID() {
+		t.Fatal("testers did not synchronize")
+	}
+
+	// A transaction set accepted by tpt1 should be relayed to tpt2.
+	_, err = tpt1.wallet.SendSiacoins(types.NewCurrency64(100), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50 && len(tpt2.tpool.TransactionList()) == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if len(tpt2.tpool.TransactionList()) == 0 {
+		t.Fatal("transaction set was not relayed")
+	}
+
+	// Announcing the transactions again should not cause tpt2 to request
+	// them.
+	var ids []types.TransactionID
+	for _, txn := range tpt1.tpool.TransactionList() {
+		ids = append(ids, txn.ID())
+	}
+	announce := func(ids []types.TransactionID) (requested []types.TransactionID) {
+		err := tpt1.gateway.RPC(tpt2.gateway.Address(), "RelayTxnInv", func(conn modules.PeerConn) error {
+			if err := encoding.WriteObject(conn, ids); err != nil {
+				return err
+			}
+			return encoding.ReadObject(conn, &requested, types.BlockSizeLimit)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return requested
+	}
+	if requested := announce(ids); len(requested) != 0 {
+		t.Fatal("peer requested transactions that it already has:", requested)
+	}
+
+	// A set that overlaps with the transactions of the peer should only cause
+	// the unknown transactions to be requested.
+	unknown := types.TransactionID{1}
+	requested := announce(append(ids, unknown))
+	if len(requested) != 1 || requested[0] != unknown {
+		t.Fatal("peer did not request only the unknown transaction:", requested)
+	}
+}
+
+// TestIsNonstandardErr checks that only objectively nonstandard sets are
+// reported as misbehavior, and that local relay policies are not.
+func TestIsNonstandardErr(t *testing.T) {
+	for _, err := range []error{errEmptySet, modules.ErrLargeTransaction, modules.ErrLargeTransactionSet} {
+		if !isNonstandardErr(err) {
+			t.Error("error is not reported as nonstandard:", err)
+		}
+	}
+	for _, err := range []error{nil, modules.ErrLargeArbitraryData, modules.ErrDuplicateTransactionSet} {
+		if isNonstandardErr(err) {
+			t.Error("error is reported as nonstandard:", err)
+		}
+	}
+}
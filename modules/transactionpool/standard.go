@@ -33,6 +33,12 @@ import (
 //		if they include arbitrary data which has meanings that the legacy miner
 //		doesn't understand.
 //
+// Rule: The size of arbitrary data is limited
+//		Each recognized arbitrary data prefix has a size limit. Host
+//		announcements only need a few hundred bytes, and capping the size of
+//		other arbitrary data keeps the arbitrary data field from being used to
+//		spam the blockchain.
+//
 // Rule: The transaction set size is limited.
 //		A group of dependent transactions cannot exceed 100kb to limit how
 //		quickly the transaction pool can be filled with new transactions.
@@ -92,21 +98,33 @@ func (tp *TransactionPool) IsStandardTransaction(t types.Transaction) error {
 	}
 
 	// Check that all arbitrary data is prefixed using the recognized set of
-	// prefixes. The allowed prefixes include a 'NonSia' prefix for truly
-	// arbitrary data. Blocking all other prefixes allows arbitrary data to be
-	// used to orchestrate more complicated soft forks in the future without
-	// putting older nodes at risk of violating the new rules.
-	var prefix types.Specifier
+	// prefixes, and that it does not exceed the size limit of its prefix.
+	// The allowed prefixes include a 'NonSia' prefix for truly arbitrary
+	// data. Blocking all other prefixes allows arbitrary data to be used to
+	// orchestrate more complicated soft forks in the future without putting
+	// older nodes at risk of violating the new rules.
 	for _, arb := range t.ArbitraryData {
-		// Check for a whilelisted prefix.
-		copy(prefix[:], arb)
-		if prefix == modules.PrefixHostAnnouncement ||
-			prefix == modules.PrefixNonSia {
-			continue
+		err := checkArbitraryData(arb)
+		if err != nil {
+			return err
 		}
+	}
+	return nil
+}
 
+// checkArbitraryData checks that a piece of arbitrary data has a prefix from
+// the registry of recognized prefixes, and that it is within the size limit
+// of that prefix.
+func checkArbitraryData(arb []byte) error {
+	var prefix types.Specifier
+	copy(prefix[:], arb)
+	limit, exists := modules.ArbitraryDataSizeLimits[prefix]
+	if !exists {
 		return modules.ErrInvalidArbPrefix
 	}
+	if len(arb) > limit {
+		return modules.ErrLargeArbitraryData
+	}
 	return nil
 }
 
@@ -51,3 +51,27 @@ func TestIntegrationLargeTransactions(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestCheckArbitraryData checks that arbitrary data is only accepted if it has
+// a registered prefix and is within the size limit of that prefix.
+func TestCheckArbitraryData(t *testing.T) {
+	for prefix, limit := range modules.ArbitraryDataSizeLimits {
+		arb := make([]byte, limit)
+		copy(arb, prefix[:])
+		if err := checkArbitraryData(arb); err != nil {
+			t.Error("arbitrary data at the size limit was rejected:", err)
+		}
+		arb = append(arb, 0)
+		if err := checkArbitraryData(arb); err != modules.ErrLargeArbitraryData {
+			t.Error("expecting ErrLargeArbitraryData, got", err)
+		}
+	}
+
+	unknown := types.Specifier{'U', 'n', 'k', 'n', 'o', 'w', 'n'}
+	if err := checkArbitraryData(unknown[:]); err != modules.ErrInvalidArbPrefix {
+		t.Error("expecting ErrInvalidArbPrefix, got", err)
+	}
+	if err := checkArbitraryData(nil); err != modules.ErrInvalidArbPrefix {
+		t.Error("expecting ErrInvalidArbPrefix, got", err)
+	}
+}
@@ -56,6 +56,10 @@ type (
 		transactionSets     map[TransactionSetID][]types.Transaction
 		transactionSetDiffs map[TransactionSetID]modules.ConsensusChange
 		transactionListSize int
+
+		// conflictedTransactions contains the unconfirmed transactions in the
+		// pool for which a competing spend has been seen.
+		conflictedTransactions map[types.TransactionID]struct{}
 		// TODO: Write a consistency check comparing transactionSets,
 		// transactionSetDiffs.
 		//
@@ -94,6 +98,8 @@ func New(cs modules.ConsensusSet, g modules.Gateway, persistDir string) (*Transa
 		transactionSets:     make(map[TransactionSetID][]types.Transaction),
 		transactionSetDiffs: make(map[TransactionSetID]modules.ConsensusChange),
 
+		conflictedTransactions: make(map[types.TransactionID]struct{}),
+
 		persistDir: persistDir,
 	}
 
@@ -105,12 +111,14 @@ func New(cs modules.ConsensusSet, g modules.Gateway, persistDir string) (*Transa
 
 	// Register RPCs
 	g.RegisterRPC("RelayTransactionSet", tp.relayTransactionSet)
+	g.RegisterRPC("RelayTxnInv", tp.rpcRelayTransactionInv)
 
 	return tp, nil
 }
 
 func (tp *TransactionPool) Close() error {
 	tp.gateway.UnregisterRPC("RelayTransactionSet")
+	tp.gateway.UnregisterRPC("RelayTxnInv")
 	tp.consensusSet.Unsubscribe(tp)
 	return tp.db.Close()
 }
@@ -110,6 +110,7 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 	for _, set := range unconfirmedSets {
 		tp.acceptTransactionSet(set) // Error is not checked.
 	}
+	tp.pruneConflicted()
 
 	// Inform subscribers that an update has executed.
 	tp.mu.Demote()
@@ -121,5 +122,6 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 func (tp *TransactionPool) PurgeTransactionPool() {
 	tp.mu.Lock()
 	tp.purge()
+	tp.pruneConflicted()
 	tp.mu.Unlock()
 }
@@ -278,6 +278,11 @@ type (
 		// filepath. The backup will have all seeds and keys.
 		CreateBackup(string) error
 
+		// BackupMetadata writes a backup of the wallet into a directory, laid
+		// out as in the wallet's persist directory, so that the directory can
+		// be restored as the persist directory of a new wallet.
+		BackupMetadata(dir string) error
+
 		// LoadBackup will load a backup of the wallet from the provided
 		// address. The backup wallet will be added as an auxiliary seed, not
 		// as a primary seed.
@@ -314,6 +319,11 @@ type (
 		// refund transactions.
 		ConfirmedBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, siacoinClaimBalance types.Currency)
 
+		// Height returns the height of the last block that the wallet has
+		// processed. It trails the consensus set while the wallet rescans
+		// the blockchain.
+		Height() types.BlockHeight
+
 		// UnconfirmedBalance returns the unconfirmed balance of the wallet.
 		// Outgoing funds and incoming funds are reported separately. Refund
 		// outputs are included, meaning that sending a single coin to
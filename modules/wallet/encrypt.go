@@ -9,6 +9,7 @@ import (
 	"github.com/NebulousLabs/Sia/build"
 	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/persist"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -131,8 +132,12 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 	// Subscribe to the consensus set if this is the first unlock for the
 	// wallet object.
 	if !subscribed {
-		// During rescan, print height every 3 seconds.
-		if build.Release != "testing" {
+		// During rescan, print height every 3 seconds. The progress is not
+		// printed when the logs are copied to the output of siad, because
+		// the carriage returns would garble the collected output; the
+		// rescan is logged instead.
+		w.log.Println("Rescanning consensus set")
+		if build.Release != "testing" && persist.LogOutput() == nil {
 			go func() {
 				println("Rescanning consensus set...")
 				for range time.Tick(time.Second * 3) {
@@ -156,6 +161,7 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 		w.mu.Lock()
 		w.subscribed = true
 		w.mu.Unlock()
+		w.log.Println("Finished rescanning consensus set")
 	}
 
 	w.mu.Lock()
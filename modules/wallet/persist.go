@@ -143,6 +143,15 @@ func (w *Wallet) CreateBackup(backupFilepath string) error {
 	return w.createBackup(backupFilepath)
 }
 
+// BackupMetadata writes a backup of the wallet into 'dir', under the name of
+// the wallet's settings file.
+func (w *Wallet) BackupMetadata(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return w.CreateBackup(filepath.Join(dir, settingsFile))
+}
+
 /*
 // LoadBackup loads a backup file from the provided filepath. The backup file
 // primary seed is loaded as an auxiliary seed.
@@ -218,9 +218,47 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 	defer w.tg.Done()
 	w.mu.Lock()
 	defer w.mu.Unlock()
+
+	// Find the wallet's transactions that left the blockchain during a
+	// reorg, so that they can be resubmitted to the transaction pool.
+	var reverted []types.Transaction
+	for _, txn := range cc.RevertedTransactions {
+		if _, exists := w.processedTransactionMap[txn.ID()]; exists {
+			reverted = append(reverted, txn)
+		}
+	}
+
 	w.updateConfirmedSet(cc)
 	w.revertHistory(cc)
 	w.applyHistory(cc)
+
+	if len(reverted) > 0 {
+		go w.threadedResubmitTransactions(reverted)
+	}
+}
+
+// threadedResubmitTransactions resubmits transactions that were removed from
+// the blockchain by a reorg to the transaction pool. The transactions are in
+// the order that they appeared in the blockchain, and are first submitted as a
+// single set, as they may depend on each other. If the set is rejected, for
+// example because one of the transactions conflicts with the new blockchain,
+// the transactions are resubmitted one at a time, parents before children.
+func (w *Wallet) threadedResubmitTransactions(txns []types.Transaction) {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	err := w.tpool.AcceptTransactionSet(txns)
+	if err == nil || err == modules.ErrDuplicateTransactionSet {
+		return
+	}
+	for _, txn := range txns {
+		err := w.tpool.AcceptTransactionSet([]types.Transaction{txn})
+		if err != nil && err != modules.ErrDuplicateTransactionSet {
+			w.log.Println("Unable to resubmit reverted transaction:", err)
+		}
+	}
 }
 
 // ReceiveUpdatedUnconfirmedTransactions updates the wallet's unconfirmed
@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestIntegrationResubmitRevertedTransactions checks that wallet transactions
+// which leave the blockchain during a reorg are resubmitted to the
+// transaction pool, including a child that spends the output of its parent.
+func TestIntegrationResubmitRevertedTransactions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester("TestIntegrationResubmitRevertedTransactions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Create a block without transactions that will later form a competing
+	// fork.
+	forkBlock, forkTarget, err := wt.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Send coins, which creates a parent transaction and a child that spends
+	// one of its outputs, and mine them into a block.
+	txns, err := wt.wallet.SendSiacoins(types.NewCurrency64(100), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 2 || txns[1].SiacoinInputs[0].ParentID != txns[0].SiacoinOutputID(0) {
+		t.Fatal("expected a parent and a child transaction")
+	}
+	if _, err := wt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(wt.tpool.TransactionList()) != 0 {
+		t.Fatal("transactions were not mined")
+	}
+
+	// Extend the competing fork by two blocks, causing a reorg that removes
+	// both transactions from the blockchain.
+	solvedForkBlock, _ := wt.miner.SolveBlock(forkBlock, forkTarget)
+	err = wt.cs.AcceptBlock(solvedForkBlock)
+	if err != modules.ErrNonExtendingBlock {
+		t.Fatal("expected ErrNonExtendingBlock, got", err)
+	}
+	child, _, err := wt.miner.BlockForWork()
+	if err != nil {
+		t.Fatal(err)
+	}
+	child.ParentID = solvedForkBlock.ID()
+	child.Transactions = nil
+	childTarget, ok := wt.cs.ChildTarget(solvedForkBlock.ID())
+	if !ok {
+		t.Fatal("no child target for the fork block")
+	}
+	solvedChild, _ := wt.miner.SolveBlock(child, childTarget)
+	if err := wt.cs.AcceptBlock(solvedChild); err != nil {
+		t.Fatal(err)
+	}
+	if wt.cs.CurrentBlock().ID() != solvedChild.ID() {
+		t.Fatal("the fork did not become the current chain")
+	}
+
+	// Both transactions should be back in the transaction pool.
+	inPool := func() bool {
+		pooled := make(map[types.TransactionID]struct{})
+		for _, txn := range wt.tpool.TransactionList() {
+			pooled[txn.ID()] = struct{}{}
+		}
+		for _, txn := range txns {
+			if _, exists := pooled[txn.ID()]; !exists {
+				return false
+			}
+		}
+		return true
+	}
+	for i := 0; i < 50 && !inPool(); i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if !inPool() {
+		t.Fatal("reverted transactions were not resubmitted to the transaction pool")
+	}
+}
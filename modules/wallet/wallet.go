@@ -172,6 +172,13 @@ func (w *Wallet) Close() error {
 	return build.JoinErrors(errs, "; ")
 }
 
+// Height returns the height of the last block that the wallet has processed.
+func (w *Wallet) Height() types.BlockHeight {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.consensusSetHeight
+}
+
 // AllAddresses returns all addresses that the wallet is able to spend from,
 // including unseeded addresses. Addresses are returned sorted in byte-order.
 func (w *Wallet) AllAddresses() []types.UnlockHash {
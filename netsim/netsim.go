@@ -0,0 +1,529 @@
+// Package netsim implements a simulated network for testing code that uses
+// net.Conn and net.Listener. Hosts on a simulated network listen and dial
+// like they would over TCP, but the connections are in memory, and the
+// latency, packet loss and partitions between hosts are controlled by the
+// test.
+//
+// Connections behave like TCP streams: data is delivered in order and is
+// never corrupted. Packet loss is simulated as retransmission delay, and a
+// partition holds back data until it is healed. The random decisions made
+// for packet loss are drawn from sources seeded by the Network's seed, so a
+// test that writes the same data in the same order sees the same delays.
+//
+// The gateway is currently the only module that can run on a simulated
+// network, so RPCs such as block relay can be simulated, but the host and
+// renter still use real sockets for the negotiation protocol.
+package netsim
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// firstPort is the first port assigned to listeners and dialers that
+	// do not request a specific port.
+	firstPort = 10000
+
+	// minRetransmitDelay is the smallest delay added to a segment each time
+	// it is lost.
+	minRetransmitDelay = 10 * time.Millisecond
+
+	// partitionPollInterval is how often data that is held back by a
+	// partition checks whether the partition has healed.
+	partitionPollInterval = 10 * time.Millisecond
+
+	// acceptBacklog is the number of connections that can wait to be
+	// accepted by a listener.
+	acceptBacklog = 128
+)
+
+var (
+	errAddrInUse      = errors.New("address already in use")
+	errClosed         = errors.New("use of closed network connection")
+	errConnRefused    = errors.New("connection refused")
+	errConnReset      = errors.New("connection reset by peer")
+	errInvalidLoss    = errors.New("loss rate must be in [0, 1)")
+	errNonLocalListen = errors.New("can't listen on another host's address")
+)
+
+// timeoutError is returned when a deadline or dial timeout is exceeded. Like
+// the errors of the net package, it implements net.Error.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// addr is the address of a listener or connection on a simulated network.
+type addr string
+
+func (a addr) Network() string { return "tcp" }
+func (a addr) String() string  { return string(a) }
+
+// A link identifies the connection between two hosts. Links are symmetric,
+// so the hosts are stored in sorted order.
+type link struct {
+	a, b string
+}
+
+func newLink(a, b string) link {
+	if a > b {
+		a, b = b, a
+	}
+	return link{a, b}
+}
+
+// linkConditions are the conditions of the link between two hosts.
+type linkConditions struct {
+	latency time.Duration
+	loss    float64
+}
+
+// A Network is a simulated network of hosts.
+type Network struct {
+	seed        int64
+	conditions  map[link]linkConditions
+	partitioned map[link]bool
+	listeners   map[string]*listener
+	nextPort    map[string]int
+	nextConnID  int64
+	mu          sync.Mutex
+}
+
+// NewNetwork creates a simulated network. The seed determines which segments
+// are lost on links with packet loss.
+func NewNetwork(seed int64) *Network {
+	return &Network{
+		seed:        seed,
+		conditions:  make(map[link]linkConditions),
+		partitioned: make(map[link]bool),
+		listeners:   make(map[string]*listener),
+		nextPort:    make(map[string]int),
+	}
+}
+
+// SetLatency sets the one-way latency between hosts 'a' and 'b'.
+func (n *Network) SetLatency(a, b string, latency time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c := n.conditions[newLink(a, b)]
+	c.latency = latency
+	n.conditions[newLink(a, b)] = c
+}
+
+// SetLoss sets the rate at which segments sent between hosts 'a' and 'b' are
+// lost. Each loss delays the segment by a simulated retransmission.
+func (n *Network) SetLoss(a, b string, rate float64) error {
+	if rate < 0 || rate >= 1 {
+		return errInvalidLoss
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	c := n.conditions[newLink(a, b)]
+	c.loss = rate
+	n.conditions[newLink(a, b)] = c
+	return nil
+}
+
+// Partition prevents the hosts in 'group1' from communicating with the hosts
+// in 'group2'. Dials across the partition time out, and data sent across it
+// is held back until the partition is healed.
+func (n *Network) Partition(group1, group2 []string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, a := range group1 {
+		for _, b := range group2 {
+			n.partitioned[newLink(a, b)] = true
+		}
+	}
+}
+
+// Heal removes all partitions.
+func (n *Network) Heal() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.partitioned = make(map[link]bool)
+}
+
+// isPartitioned returns true if hosts 'a' and 'b' are partitioned.
+func (n *Network) isPartitioned(a, b string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.partitioned[newLink(a, b)]
+}
+
+// linkConditions returns the conditions of the link between hosts 'a' and
+// 'b'.
+func (n *Network) linkConditions(a, b string) linkConditions {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.conditions[newLink(a, b)]
+}
+
+// allocatePort returns an unused port on 'host'. The Network's mutex must be
+// held.
+func (n *Network) allocatePort(host string) string {
+	if n.nextPort[host] == 0 {
+		n.nextPort[host] = firstPort
+	}
+	for {
+		port := strconv.Itoa(n.nextPort[host])
+		n.nextPort[host]++
+		if _, exists := n.listeners[net.JoinHostPort(host, port)]; !exists {
+			return port
+		}
+	}
+}
+
+// Host returns the host with the given IP address on the network. Hosts do
+// not need to be created; any address can be used.
+func (n *Network) Host(host string) *Host {
+	return &Host{network: n, host: host}
+}
+
+// A Host listens and dials on a simulated network.
+type Host struct {
+	network *Network
+	host    string
+}
+
+// Listen listens for connections on 'address'. The host part of the address
+// must be empty, an unspecified address, or the Host's address. If the port
+// is 0, an unused port is chosen.
+func (h *Host) Listen(address string) (net.Listener, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host != "" && host != h.host && (ip == nil || !ip.IsUnspecified()) {
+		return nil, errNonLocalListen
+	}
+
+	n := h.network
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if port == "0" {
+		port = n.allocatePort(h.host)
+	}
+	a := net.JoinHostPort(h.host, port)
+	if _, exists := n.listeners[a]; exists {
+		return nil, errAddrInUse
+	}
+	l := &listener{
+		network: n,
+		addr:    addr(a),
+		conns:   make(chan net.Conn, acceptBacklog),
+		closed:  make(chan struct{}),
+	}
+	n.listeners[a] = l
+	return l, nil
+}
+
+// Dial connects to 'address'. Establishing the connection takes one round
+// trip. Dials across a partition fail once the timeout has elapsed, or
+// immediately if there is no timeout.
+func (h *Host) Dial(address string, timeout time.Duration) (net.Conn, error) {
+	remoteHost, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	n := h.network
+	c := n.linkConditions(h.host, remoteHost)
+	if n.isPartitioned(h.host, remoteHost) || (timeout > 0 && 2*c.latency > timeout) {
+		time.Sleep(timeout)
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Addr: addr(address), Err: timeoutError{}}
+	}
+	time.Sleep(2 * c.latency)
+
+	n.mu.Lock()
+	l, exists := n.listeners[address]
+	localAddr := addr(net.JoinHostPort(h.host, n.allocatePort(h.host)))
+	connID := n.nextConnID
+	n.nextConnID++
+	n.mu.Unlock()
+	if !exists {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Addr: addr(address), Err: errConnRefused}
+	}
+
+	// Each direction of the connection draws from its own random source, so
+	// that the losses in one direction do not depend on the other.
+	toRemote := newPipe(n, h.host, remoteHost, n.seed+2*connID)
+	toLocal := newPipe(n, remoteHost, h.host, n.seed+2*connID+1)
+	local := &conn{local: localAddr, remote: addr(address), in: toLocal, out: toRemote}
+	remote := &conn{local: addr(address), remote: localAddr, in: toRemote, out: toLocal}
+	select {
+	case l.conns <- remote:
+		return local, nil
+	case <-l.closed:
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Addr: addr(address), Err: errConnRefused}
+	}
+}
+
+// listener is a net.Listener on a simulated network.
+type listener struct {
+	network   *Network
+	addr      addr
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// Accept waits for and returns the next connection to the listener.
+func (l *listener) Accept() (net.Conn, error) {
+	// Like a TCP listener, a closed listener drops its pending connections.
+	select {
+	case <-l.closed:
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Addr: l.addr, Err: errClosed}
+	default:
+	}
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, &net.OpError{Op: "accept", Net: "tcp", Addr: l.addr, Err: errClosed}
+	}
+}
+
+// Close closes the listener, freeing its address.
+func (l *listener) Close() error {
+	err := errClosed
+	l.closeOnce.Do(func() {
+		close(l.closed)
+		l.network.mu.Lock()
+		delete(l.network.listeners, string(l.addr))
+		l.network.mu.Unlock()
+		err = nil
+	})
+	return err
+}
+
+// Addr returns the listener's address.
+func (l *listener) Addr() net.Addr { return l.addr }
+
+// A segment is data that has been written to a pipe but not yet delivered.
+// A segment with fin set signals that the writer closed the connection.
+type segment struct {
+	data      []byte
+	fin       bool
+	deliverAt time.Time
+}
+
+// A pipe carries data in one direction of a connection, from host 'src' to
+// host 'dst'.
+type pipe struct {
+	network  *Network
+	src, dst string
+	rng      *rand.Rand
+
+	// pending holds the segments that have been written but not yet
+	// delivered, in order of delivery. buf holds the delivered data that has
+	// not been read.
+	pending      []segment
+	lastDelivery time.Time
+	buf          []byte
+
+	eof           bool // the writer's fin has been delivered
+	writerClosed  bool
+	readerClosed  bool
+	readDeadline  time.Time
+	writeDeadline time.Time
+
+	mu   sync.Mutex
+	cond *sync.Cond
+}
+
+func newPipe(n *Network, src, dst string, seed int64) *pipe {
+	p := &pipe{
+		network: n,
+		src:     src,
+		dst:     dst,
+		rng:     rand.New(rand.NewSource(seed)),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// send queues a segment for delivery after the link's latency, plus a
+// retransmission delay for each time that the segment is lost. Segments are
+// delivered in the order that they were sent. The pipe's mutex must be held.
+func (p *pipe) send(s segment) {
+	c := p.network.linkConditions(p.src, p.dst)
+	delay := c.latency
+	retransmitDelay := 2 * c.latency
+	if retransmitDelay < minRetransmitDelay {
+		retransmitDelay = minRetransmitDelay
+	}
+	for p.rng.Float64() < c.loss {
+		delay += retransmitDelay
+	}
+	now := time.Now()
+	s.deliverAt = now.Add(delay)
+	if s.deliverAt.Before(p.lastDelivery) {
+		s.deliverAt = p.lastDelivery
+	}
+	p.lastDelivery = s.deliverAt
+	p.pending = append(p.pending, s)
+	time.AfterFunc(s.deliverAt.Sub(now), p.deliver)
+}
+
+// deliver moves the segments that are due from pending to buf. If the hosts
+// are partitioned, delivery is retried until the partition heals.
+func (p *pipe) deliver() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.network.isPartitioned(p.src, p.dst) {
+		time.AfterFunc(partitionPollInterval, p.deliver)
+		return
+	}
+	now := time.Now()
+	for len(p.pending) > 0 && !p.pending[0].deliverAt.After(now) {
+		s := p.pending[0]
+		p.pending = p.pending[1:]
+		if s.fin {
+			p.eof = true
+		} else if !p.readerClosed {
+			p.buf = append(p.buf, s.data...)
+		}
+	}
+	p.cond.Broadcast()
+}
+
+// read reads delivered data, blocking until data is available, the writer
+// has closed the pipe, or the read deadline passes.
+func (p *pipe) read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.buf) == 0 {
+		switch {
+		case p.readerClosed:
+			return 0, errClosed
+		case p.eof:
+			return 0, io.EOF
+		case !p.readDeadline.IsZero() && !time.Now().Before(p.readDeadline):
+			return 0, timeoutError{}
+		}
+		p.cond.Wait()
+	}
+	n := copy(b, p.buf)
+	p.buf = p.buf[n:]
+	return n, nil
+}
+
+// write sends data through the pipe. Writes never block, as the simulated
+// network has unlimited buffers.
+func (p *pipe) write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case p.writerClosed:
+		return 0, errClosed
+	case p.readerClosed:
+		return 0, errConnReset
+	case !p.writeDeadline.IsZero() && !time.Now().Before(p.writeDeadline):
+		return 0, timeoutError{}
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	p.send(segment{data: append([]byte(nil), b...)})
+	return len(b), nil
+}
+
+// closeWriter sends a fin through the pipe.
+func (p *pipe) closeWriter() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.writerClosed {
+		return
+	}
+	p.writerClosed = true
+	p.send(segment{fin: true})
+}
+
+// closeReader discards the pipe's unread data and wakes up blocked readers.
+func (p *pipe) closeReader() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.readerClosed = true
+	p.buf = nil
+	p.cond.Broadcast()
+}
+
+// setReadDeadline sets the read deadline, waking up blocked readers once it
+// passes.
+func (p *pipe) setReadDeadline(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.readDeadline = t
+	p.cond.Broadcast()
+	if !t.IsZero() {
+		time.AfterFunc(t.Sub(time.Now()), func() {
+			p.mu.Lock()
+			p.cond.Broadcast()
+			p.mu.Unlock()
+		})
+	}
+}
+
+// setWriteDeadline sets the write deadline.
+func (p *pipe) setWriteDeadline(t time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.writeDeadline = t
+}
+
+// conn is a net.Conn on a simulated network.
+type conn struct {
+	local, remote addr
+	in, out       *pipe
+	closeOnce     sync.Once
+}
+
+// Read reads data from the connection.
+func (c *conn) Read(b []byte) (int, error) { return c.in.read(b) }
+
+// Write writes data to the connection.
+func (c *conn) Write(b []byte) (int, error) { return c.out.write(b) }
+
+// Close closes the connection. Data that has already been written is still
+// delivered to the remote end, followed by io.EOF.
+func (c *conn) Close() error {
+	err := errClosed
+	c.closeOnce.Do(func() {
+		c.in.closeReader()
+		c.out.closeWriter()
+		err = nil
+	})
+	return err
+}
+
+// LocalAddr returns the local address of the connection.
+func (c *conn) LocalAddr() net.Addr { return c.local }
+
+// RemoteAddr returns the remote address of the connection.
+func (c *conn) RemoteAddr() net.Addr { return c.remote }
+
+// SetDeadline sets the read and write deadlines of the connection.
+func (c *conn) SetDeadline(t time.Time) error {
+	c.in.setReadDeadline(t)
+	c.out.setWriteDeadline(t)
+	return nil
+}
+
+// SetReadDeadline sets the read deadline of the connection.
+func (c *conn) SetReadDeadline(t time.Time) error {
+	c.in.setReadDeadline(t)
+	return nil
+}
+
+// SetWriteDeadline sets the write deadline of the connection.
+func (c *conn) SetWriteDeadline(t time.Time) error {
+	c.out.setWriteDeadline(t)
+	return nil
+}
@@ -0,0 +1,251 @@
+package netsim
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// connect returns both ends of a connection from host 'a' to host 'b'.
+func connect(t *testing.T, n *Network, a, b string) (net.Conn, net.Conn) {
+	l, err := n.Host(b).Listen(":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			t.Error(err)
+		}
+		accepted <- c
+	}()
+	c, err := n.Host(a).Dial(l.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, <-accepted
+}
+
+// TestListenDial probes the addresses of listeners and connections, and the
+// errors returned by Listen and Dial.
+func TestListenDial(t *testing.T) {
+	n := NewNetwork(0)
+	h := n.Host("10.0.0.1")
+
+	l, err := h.Listen(":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Addr().String() != "10.0.0.1:10000" {
+		t.Fatal("wrong listener address:", l.Addr())
+	}
+	if _, err := h.Listen("10.0.0.1:10000"); err != errAddrInUse {
+		t.Fatal("expected errAddrInUse, got", err)
+	}
+	if _, err := h.Listen("10.0.0.2:9981"); err != errNonLocalListen {
+		t.Fatal("expected errNonLocalListen, got", err)
+	}
+	if _, err := h.Listen("0.0.0.0:9981"); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		if c.RemoteAddr().String() != "10.0.0.2:10000" {
+			t.Error("wrong remote address:", c.RemoteAddr())
+		}
+		c.Close()
+	}()
+	c, err := n.Host("10.0.0.2").Dial("10.0.0.1:10000", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.RemoteAddr().String() != "10.0.0.1:10000" {
+		t.Fatal("wrong remote address:", c.RemoteAddr())
+	}
+	c.Close()
+
+	if _, err := n.Host("10.0.0.2").Dial("10.0.0.1:1234", time.Second); err == nil {
+		t.Fatal("dialed an address without a listener")
+	}
+	l.Close()
+	if _, err := l.Accept(); err == nil {
+		t.Fatal("closed listener accepted a connection")
+	}
+	if _, err := n.Host("10.0.0.2").Dial("10.0.0.1:10000", time.Second); err == nil {
+		t.Fatal("dialed a closed listener")
+	}
+}
+
+// TestConnLatency checks that data is delivered in order after the latency
+// of the link, and that closing a connection delivers io.EOF after the data.
+func TestConnLatency(t *testing.T) {
+	n := NewNetwork(0)
+	n.SetLatency("a", "b", 50*time.Millisecond)
+	ca, cb := connect(t, n, "a", "b")
+	defer cb.Close()
+
+	start := time.Now()
+	for i := byte(0); i < 10; i++ {
+		if _, err := ca.Write([]byte{i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ca.Close()
+	if _, err := ca.Write([]byte{0}); err == nil {
+		t.Fatal("wrote to a closed connection")
+	}
+
+	data, err := readAll(cb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatal("data was delivered before the latency elapsed:", elapsed)
+	}
+	if !bytes.Equal(data, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Fatal("data was delivered out of order:", data)
+	}
+
+	// Writing to a connection whose remote end is closed fails.
+	if _, err := cb.Write([]byte{0}); err != errConnReset {
+		t.Fatal("expected errConnReset, got", err)
+	}
+}
+
+// readAll reads from a connection until io.EOF.
+func readAll(c net.Conn) ([]byte, error) {
+	var data []byte
+	buf := make([]byte, 4)
+	for {
+		n, err := c.Read(buf)
+		data = append(data, buf[:n]...)
+		if err == io.EOF {
+			return data, nil
+		} else if err != nil {
+			return data, err
+		}
+	}
+}
+
+// TestConnDeadline checks that reads time out once the read deadline passes.
+func TestConnDeadline(t *testing.T) {
+	n := NewNetwork(0)
+	ca, cb := connect(t, n, "a", "b")
+	defer ca.Close()
+	defer cb.Close()
+
+	cb.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	_, err := cb.Read(make([]byte, 1))
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatal("expected a timeout, got", err)
+	}
+
+	// Clearing the deadline allows reads to succeed again.
+	cb.SetReadDeadline(time.Time{})
+	ca.Write([]byte{1})
+	if _, err := cb.Read(make([]byte, 1)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConnLoss checks that lost segments are delayed but not corrupted, and
+// that the delays are determined by the seed.
+func TestConnLoss(t *testing.T) {
+	// delays returns the delivery delay of each of 20 segments.
+	delays := func(seed int64) []time.Duration {
+		n := NewNetwork(seed)
+		if err := n.SetLoss("a", "b", 0.5); err != nil {
+			t.Fatal(err)
+		}
+		ca, cb := connect(t, n, "a", "b")
+		defer ca.Close()
+		defer cb.Close()
+
+		var ds []time.Duration
+		out := ca.(*conn).out
+		for i := byte(0); i < 20; i++ {
+			start := time.Now()
+			ca.Write([]byte{i})
+			out.mu.Lock()
+			ds = append(ds, out.lastDelivery.Sub(start).Round(minRetransmitDelay))
+			out.mu.Unlock()
+
+			b := make([]byte, 1)
+			if _, err := io.ReadFull(cb, b); err != nil {
+				t.Fatal(err)
+			}
+			if b[0] != i {
+				t.Fatal("data was corrupted:", b[0], i)
+			}
+		}
+		return ds
+	}
+
+	d1, d2 := delays(1), delays(1)
+	var lost bool
+	for i := range d1 {
+		if d1[i] != d2[i] {
+			t.Fatal("networks with the same seed lost different segments:", d1, d2)
+		}
+		if d1[i] > 0 {
+			lost = true
+		}
+	}
+	if !lost {
+		t.Fatal("no segments were lost")
+	}
+
+	if err := NewNetwork(0).SetLoss("a", "b", 1); err != errInvalidLoss {
+		t.Fatal("expected errInvalidLoss, got", err)
+	}
+}
+
+// TestPartition checks that dials across a partition time out, that data sent
+// across it is held back until it heals, and that other links are unaffected.
+func TestPartition(t *testing.T) {
+	n := NewNetwork(0)
+	ca, cb := connect(t, n, "a", "b")
+	defer ca.Close()
+	defer cb.Close()
+
+	n.Partition([]string{"a"}, []string{"b", "c"})
+	l, err := n.Host("b").Listen(":9981")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	_, err = n.Host("a").Dial("b:9981", 50*time.Millisecond)
+	if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+		t.Fatal("expected a timeout, got", err)
+	}
+	go func() {
+		if c, err := l.Accept(); err == nil {
+			c.Close()
+		}
+	}()
+	if c, err := n.Host("d").Dial("b:9981", time.Second); err != nil {
+		t.Fatal("partition affected another host:", err)
+	} else {
+		c.Close()
+	}
+
+	ca.Write([]byte{1})
+	cb.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := cb.Read(make([]byte, 1)); err == nil {
+		t.Fatal("data was delivered across a partition")
+	}
+	n.Heal()
+	cb.SetReadDeadline(time.Now().Add(time.Second))
+	b := make([]byte, 1)
+	if _, err := cb.Read(b); err != nil || b[0] != 1 {
+		t.Fatal("data was not delivered after the partition healed:", err)
+	}
+}
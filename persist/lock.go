@@ -0,0 +1,46 @@
+package persist
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	// LockFilename is the name of the file that is locked by LockDir.
+	LockFilename = "siad.lock"
+)
+
+var (
+	// ErrDirLocked is returned by LockDir if another process holds the lock
+	// on the directory.
+	ErrDirLocked = errors.New("directory is in use by another process")
+)
+
+// A DirLock is the lock on a directory that is held by LockDir.
+type DirLock struct {
+	file *os.File
+}
+
+// LockDir locks 'dir', so that two processes cannot use the same directory
+// and corrupt each other's files. The lock is a lock on the file
+// LockFilename in 'dir', which holds the process id of the process that
+// holds the lock. The lock is released by Unlock, or by the operating system
+// when the process exits, so a crash never leaves the directory locked.
+func LockDir(dir string) (*DirLock, error) {
+	f, err := lockFile(filepath.Join(dir, LockFilename))
+	if err != nil {
+		return nil, err
+	}
+	// The process id is only informational, so an error writing it is
+	// ignored.
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return &DirLock{file: f}, nil
+}
+
+// Unlock releases the lock on the directory.
+func (dl *DirLock) Unlock() error {
+	return dl.file.Close()
+}
@@ -0,0 +1,47 @@
+package persist
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+// TestLockDir checks that a directory cannot be locked twice, and can be
+// locked again once it is unlocked.
+func TestLockDir(t *testing.T) {
+	dir := build.TempDir(persistDir, "TestLockDir")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	dl, err := LockDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, LockFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(b)) != strconv.Itoa(os.Getpid()) {
+		t.Error("lock file does not hold the process id:", string(b))
+	}
+	if _, err := LockDir(dir); err != ErrDirLocked {
+		t.Fatal("expected ErrDirLocked, got", err)
+	}
+
+	if err := dl.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	dl, err = LockDir(dir)
+	if err != nil {
+		t.Fatal("could not lock the unlocked directory:", err)
+	}
+	if err := dl.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package persist
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile opens and locks the file at 'filename', creating it if it does
+// not exist. The lock is released when the file is closed.
+func lockFile(filename string) (*os.File, error) {
+	f, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		f.Close()
+		return nil, ErrDirLocked
+	} else if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,30 @@
+//go:build windows
+// +build windows
+
+package persist
+
+import (
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is the error returned by Windows when opening a file
+// that another process has opened without sharing it.
+const errorSharingViolation syscall.Errno = 32
+
+// lockFile opens and locks the file at 'filename', creating it if it does
+// not exist. The file is opened without sharing, so that no other process can
+// open it until it is closed.
+func lockFile(filename string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(filename)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err == errorSharingViolation {
+		return nil, ErrDirLocked
+	} else if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(h), filename), nil
+}
@@ -1,15 +1,105 @@
 package persist
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/NebulousLabs/Sia/build"
 )
 
+// A LogLevel is the minimum severity of the messages that are written by
+// loggers. Critical messages are always written.
+type LogLevel int32
+
+// The log levels, in increasing order of severity. Messages written with
+// Print, Printf and Println have LevelInfo.
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var (
+	errUnknownLogLevel = errors.New("unrecognized log level")
+
+	// logLevel is the LogLevel of all loggers, and maxLogSize is the size in
+	// bytes at which log files are rotated, or zero if log files are never
+	// rotated. Both are accessed atomically.
+	logLevel   = int32(LevelInfo)
+	maxLogSize int64
+
+	// maxLogFiles is the number of rotated log files that are kept for each
+	// log file.
+	maxLogFiles = 3
+
+	// logOutput is the writer that the messages of file loggers are copied
+	// to, or nil if they are only written to their files.
+	logOutput   io.Writer
+	logOutputMu sync.Mutex
+)
+
+// ParseLogLevel returns the LogLevel with the given name, which is one of
+// "debug", "info", "warn" or "error".
+func ParseLogLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return 0, errUnknownLogLevel
+	}
+}
+
+// SetLogLevel sets the minimum severity of the messages written by all
+// loggers. The default level is LevelInfo. Debug messages are always written
+// when build.DEBUG is true.
+func SetLogLevel(level LogLevel) {
+	atomic.StoreInt32(&logLevel, int32(level))
+}
+
+// SetLogRotation sets the size in bytes at which log files are rotated. A
+// rotated log file is renamed with a numeric suffix, and only the most recent
+// rotated files are kept. A size of zero disables rotation, which is the
+// default.
+func SetLogRotation(maxSize int64) {
+	atomic.StoreInt64(&maxLogSize, maxSize)
+}
+
+// SetLogOutput copies the messages of the file loggers that are created
+// afterwards to 'w', such as os.Stdout for a service manager that collects the
+// output of siad. Messages are still written to the log files.
+func SetLogOutput(w io.Writer) {
+	logOutputMu.Lock()
+	logOutput = w
+	logOutputMu.Unlock()
+}
+
+// LogOutput returns the writer set by SetLogOutput, or nil if none is set.
+func LogOutput() io.Writer {
+	logOutputMu.Lock()
+	defer logOutputMu.Unlock()
+	return logOutput
+}
+
+// enabled returns true if messages of the given level should be written.
+func enabled(level LogLevel) bool {
+	return level >= LogLevel(atomic.LoadInt32(&logLevel))
+}
+
 // Logger is a wrapper for the standard library logger that enforces logging
 // with the Sia-standard settings. It also supports a Close method, which
 // attempts to close the underlying io.Writer.
@@ -25,30 +115,68 @@ func (l *Logger) Critical(v ...interface{}) {
 	build.Critical(v...)
 }
 
-// Debug is equivalent to Logger.Print when build.DEBUG is true. Otherwise it
-// is a no-op.
+// Debug is equivalent to Logger.Print when build.DEBUG is true or the log
+// level is LevelDebug. Otherwise it is a no-op.
 func (l *Logger) Debug(v ...interface{}) {
-	if build.DEBUG {
+	if build.DEBUG || enabled(LevelDebug) {
 		l.Output(2, fmt.Sprint(v...))
 	}
 }
 
-// Debugf is equivalent to Logger.Printf when build.DEBUG is true. Otherwise it
-// is a no-op.
+// Debugf is equivalent to Logger.Printf when build.DEBUG is true or the log
+// level is LevelDebug. Otherwise it is a no-op.
 func (l *Logger) Debugf(format string, v ...interface{}) {
-	if build.DEBUG {
+	if build.DEBUG || enabled(LevelDebug) {
 		l.Output(2, fmt.Sprintf(format, v...))
 	}
 }
 
-// Debugln is equivalent to Logger.Println when build.DEBUG is true. Otherwise
-// it is a no-op.
+// Debugln is equivalent to Logger.Println when build.DEBUG is true or the log
+// level is LevelDebug. Otherwise it is a no-op.
 func (l *Logger) Debugln(v ...interface{}) {
-	if build.DEBUG {
+	if build.DEBUG || enabled(LevelDebug) {
 		l.Output(2, fmt.Sprintln(v...))
 	}
 }
 
+// Print calls l.Output to print to the logger if the log level is at most
+// LevelInfo. Arguments are handled in the manner of fmt.Print.
+func (l *Logger) Print(v ...interface{}) {
+	if enabled(LevelInfo) {
+		l.Output(2, fmt.Sprint(v...))
+	}
+}
+
+// Printf calls l.Output to print to the logger if the log level is at most
+// LevelInfo. Arguments are handled in the manner of fmt.Printf.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	if enabled(LevelInfo) {
+		l.Output(2, fmt.Sprintf(format, v...))
+	}
+}
+
+// Println calls l.Output to print to the logger if the log level is at most
+// LevelInfo. Arguments are handled in the manner of fmt.Println.
+func (l *Logger) Println(v ...interface{}) {
+	if enabled(LevelInfo) {
+		l.Output(2, fmt.Sprintln(v...))
+	}
+}
+
+// Warnln logs a message with a WARN prefix if the log level is at most
+// LevelWarn.
+func (l *Logger) Warnln(v ...interface{}) {
+	if enabled(LevelWarn) {
+		l.Output(2, "WARN: "+fmt.Sprintln(v...))
+	}
+}
+
+// Errorln logs a message with an ERROR prefix. Errors are written at every
+// log level.
+func (l *Logger) Errorln(v ...interface{}) {
+	l.Output(2, "ERROR: "+fmt.Sprintln(v...))
+}
+
 // Close logs a shutdown message and closes the Logger's underlying io.Writer,
 // if it is also an io.Closer.
 func (l *Logger) Close() error {
@@ -62,18 +190,30 @@ func (l *Logger) Close() error {
 // NewLogger returns a logger that can be closed. Calls should not be made to
 // the logger after 'Close' has been called.
 func NewLogger(w io.Writer) *Logger {
-	l := log.New(w, "", log.Ldate|log.Ltime|log.Lmicroseconds|log.Lshortfile|log.LUTC)
-	l.Output(3, "STARTUP: Logging has started.") // Call depth is 3 because NewLogger is usually called by NewFileLogger
+	return newLogger(w, "")
+}
+
+// newLogger returns a logger that prefixes each message with 'prefix'.
+func newLogger(w io.Writer, prefix string) *Logger {
+	l := log.New(w, prefix, log.Ldate|log.Ltime|log.Lmicroseconds|log.Lshortfile|log.LUTC|log.Lmsgprefix)
+	l.Output(3, "STARTUP: Logging has started.") // Call depth is 3 because newLogger is called by NewLogger or NewFileLogger
 	return &Logger{l, w}
 }
 
 // closeableFile wraps an os.File to perform sanity checks on its Write and
 // Close methods. When the checks are enabled, calls to Write or Close will
-// panic if they are called after the file has already been closed.
+// panic if they are called after the file has already been closed. The file
+// is rotated when it grows larger than maxLogSize.
 type closeableFile struct {
 	*os.File
 	closed bool
-	mu     sync.RWMutex
+	name   string
+	size   int64
+	mu     sync.Mutex
+
+	// output receives a copy of everything written to the file, if it is
+	// not nil.
+	output io.Writer
 }
 
 // Close closes the file and sets the closed flag.
@@ -93,24 +233,64 @@ func (cf *closeableFile) Close() error {
 	return cf.File.Close()
 }
 
+// rotate renames the file, shifting the names of the previously rotated
+// files and deleting the oldest one, and opens a new file in its place.
+func (cf *closeableFile) rotate() error {
+	if err := cf.File.Close(); err != nil {
+		return err
+	}
+	for i := maxLogFiles - 1; i > 0; i-- {
+		os.Rename(cf.name+"."+strconv.Itoa(i), cf.name+"."+strconv.Itoa(i+1))
+	}
+	if err := os.Rename(cf.name, cf.name+".1"); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(cf.name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0660)
+	if err != nil {
+		return err
+	}
+	cf.File = f
+	cf.size = 0
+	return nil
+}
+
 // Write takes the input data and writes it to the file.
 func (cf *closeableFile) Write(b []byte) (int, error) {
-	cf.mu.RLock()
-	defer cf.mu.RUnlock()
+	cf.mu.Lock()
+	defer cf.mu.Unlock()
 	// Sanity check - close should not have been called yet.
 	if cf.closed {
 		build.Critical("cannot write to the file after it has been closed")
 	}
-	return cf.File.Write(b)
+	if maxSize := atomic.LoadInt64(&maxLogSize); maxSize > 0 && cf.size > 0 && cf.size+int64(len(b)) > maxSize {
+		if err := cf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := cf.File.Write(b)
+	cf.size += int64(n)
+	if cf.output != nil {
+		cf.output.Write(b)
+	}
+	return n, err
 }
 
 // NewFileLogger returns a logger that logs to logFilename. The file is opened
-// in append mode, and created if it does not exist.
+// in append mode, and created if it does not exist. Each message is prefixed
+// with the name of the file, such as "[host]" for host.log, so that the
+// messages of different modules can be told apart when their logs are
+// combined.
 func NewFileLogger(logFilename string) (*Logger, error) {
 	logFile, err := os.OpenFile(logFilename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0660)
 	if err != nil {
 		return nil, err
 	}
-	cf := &closeableFile{File: logFile}
-	return NewLogger(cf), nil
+	info, err := logFile.Stat()
+	if err != nil {
+		logFile.Close()
+		return nil, err
+	}
+	cf := &closeableFile{File: logFile, name: logFilename, size: info.Size(), output: LogOutput()}
+	base := filepath.Base(logFilename)
+	return newLogger(cf, "["+strings.TrimSuffix(base, filepath.Ext(base))+"] "), nil
 }
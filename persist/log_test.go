@@ -1,9 +1,11 @@
 package persist
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -84,3 +86,125 @@ func TestLoggerCritical(t *testing.T) {
 	}()
 	fl.Critical("a critical message")
 }
+
+// TestLoggerLevels checks that messages below the log level are not written,
+// and that file loggers prefix messages with the name of the log file.
+func TestLoggerLevels(t *testing.T) {
+	testdir := build.TempDir(persistDir, "TestLoggerLevels")
+	err := os.MkdirAll(testdir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFilename := filepath.Join(testdir, "module.log")
+	fl, err := NewFileLogger(logFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetLogLevel(LevelWarn)
+	defer SetLogLevel(LevelInfo)
+	fl.Println("INFO-MESSAGE")
+	fl.Warnln("WARN-MESSAGE")
+	fl.Errorln("ERROR-MESSAGE")
+	SetLogLevel(LevelError)
+	fl.Warnln("SUPPRESSED-WARN")
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	fileData, err := ioutil.ReadFile(logFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := string(fileData)
+	if strings.Contains(data, "INFO-MESSAGE") || strings.Contains(data, "SUPPRESSED-WARN") {
+		t.Error("messages below the log level were written")
+	}
+	if !strings.Contains(data, "[module] WARN: WARN-MESSAGE") || !strings.Contains(data, "[module] ERROR: ERROR-MESSAGE") {
+		t.Error("messages at or above the log level were not written with the module prefix:", data)
+	}
+}
+
+// TestParseLogLevel probes the ParseLogLevel function.
+func TestParseLogLevel(t *testing.T) {
+	for name, level := range map[string]LogLevel{"debug": LevelDebug, "INFO": LevelInfo, "warn": LevelWarn, "error": LevelError} {
+		if l, err := ParseLogLevel(name); err != nil || l != level {
+			t.Error("wrong level for", name, l, err)
+		}
+	}
+	if _, err := ParseLogLevel("verbose"); err != errUnknownLogLevel {
+		t.Error("expected errUnknownLogLevel, got", err)
+	}
+}
+
+// TestLoggerRotation checks that log files are rotated once they exceed the
+// maximum log size, and that only maxLogFiles rotated files are kept.
+func TestLoggerRotation(t *testing.T) {
+	testdir := build.TempDir(persistDir, "TestLoggerRotation")
+	err := os.MkdirAll(testdir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logFilename := filepath.Join(testdir, "test.log")
+	fl, err := NewFileLogger(logFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	SetLogRotation(200)
+	defer SetLogRotation(0)
+	for i := 0; i < 20; i++ {
+		fl.Println("a message that fills the log file")
+	}
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= maxLogFiles; i++ {
+		if _, err := os.Stat(logFilename + "." + strconv.Itoa(i)); err != nil {
+			t.Error("rotated log file is missing:", err)
+		}
+	}
+	if _, err := os.Stat(logFilename + "." + strconv.Itoa(maxLogFiles+1)); !os.IsNotExist(err) {
+		t.Error("too many rotated log files were kept")
+	}
+	info, err := os.Stat(logFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > 200 {
+		t.Error("log file exceeds the maximum size:", info.Size())
+	}
+}
+
+// TestLoggerOutput checks that the messages of file loggers are copied to the
+// writer set by SetLogOutput.
+func TestLoggerOutput(t *testing.T) {
+	testdir := build.TempDir(persistDir, "TestLoggerOutput")
+	err := os.MkdirAll(testdir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output bytes.Buffer
+	SetLogOutput(&output)
+	fl, err := NewFileLogger(filepath.Join(testdir, "module.log"))
+	SetLogOutput(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fl.Println("a copied message")
+	if err := fl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(filepath.Join(testdir, "module.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(contents, output.Bytes()) {
+		t.Fatalf("output does not match the log file:\n%s\n%s", output.Bytes(), contents)
+	}
+	if !strings.Contains(output.String(), "[module] ") || !strings.Contains(output.String(), "a copied message") {
+		t.Fatal("message was not copied to the output:", output.String())
+	}
+}
@@ -12,6 +12,10 @@ const (
 	// persistDir defines the folder that is used for testing the persist
 	// package.
 	persistDir = "persist"
+
+	// tempSuffix is the suffix of the temporary file that a safeFile is
+	// written to before it is committed.
+	tempSuffix = "_temp"
 )
 
 var (
@@ -56,7 +60,7 @@ func (sf *safeFile) Commit() error {
 	if err := sf.Close(); err != nil {
 		return err
 	}
-	return os.Rename(sf.finalName+"_temp", sf.finalName)
+	return os.Rename(sf.finalName+tempSuffix, sf.finalName)
 }
 
 // CommitSync syncs the file, closes it, and then renames it to the intended
@@ -72,7 +76,7 @@ func (sf *safeFile) CommitSync() error {
 // NewSafeFile returns a file that can atomically be written to disk,
 // minimizing the risk of corruption.
 func NewSafeFile(filename string) (*safeFile, error) {
-	file, err := os.Create(filename + "_temp")
+	file, err := os.Create(filename + tempSuffix)
 	if err != nil {
 		return nil, err
 	}
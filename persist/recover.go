@@ -0,0 +1,73 @@
+package persist
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RecoverDir repairs the files in 'dir' and its subdirectories that were left
+// behind by a crash while they were being saved. Files are saved by writing a
+// temporary file and renaming it, so a crash can only leave a temporary file
+// next to the last saved version of the file:
+//
+//   - If the file exists, the temporary file is an unfinished save, and is
+//     removed.
+//   - If the file does not exist and the temporary file is a complete json
+//     file, the crash happened before the rename, and the temporary file is
+//     renamed to complete the save.
+//   - Otherwise, the temporary file is an unfinished first save, and is
+//     removed.
+//
+// RecoverDir returns the names of the files that were restored and of the
+// temporary files that were removed. A relative 'dir', including the empty
+// string, is resolved against the working directory. RecoverDir must not be
+// called while the files in 'dir' are in use.
+func RecoverDir(dir string) (restored, removed []string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, tempSuffix) {
+			return nil
+		}
+		finalName := strings.TrimSuffix(path, tempSuffix)
+		if _, err := os.Stat(finalName); os.IsNotExist(err) && completeJSONFile(path) {
+			if err := os.Rename(path, finalName); err != nil {
+				return err
+			}
+			restored = append(restored, finalName)
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed = append(removed, path)
+		return nil
+	})
+	if os.IsNotExist(err) {
+		// There is nothing to recover in a directory that does not exist
+		// yet.
+		err = nil
+	}
+	return restored, removed, err
+}
+
+// completeJSONFile returns true if the file at 'path' is a complete json file
+// in the format written by Save.
+func completeJSONFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	var header, version string
+	var data json.RawMessage
+	dec := json.NewDecoder(f)
+	return dec.Decode(&header) == nil && dec.Decode(&version) == nil && dec.Decode(&data) == nil
+}
@@ -0,0 +1,90 @@
+package persist
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+// TestRecoverDir checks that RecoverDir completes the saves that were
+// interrupted before the rename, and removes the other temporary files.
+func TestRecoverDir(t *testing.T) {
+	dir := build.TempDir(persistDir, "TestRecoverDir")
+	subdir := filepath.Join(dir, "module")
+	if err := os.MkdirAll(subdir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	meta := Metadata{"TestRecoverDir", "0.1"}
+
+	// A complete save that was not renamed.
+	complete := filepath.Join(subdir, "complete.json")
+	if err := SaveFile(meta, 3, complete); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(complete, complete+tempSuffix); err != nil {
+		t.Fatal(err)
+	}
+	// An unfinished save of an existing file.
+	existing := filepath.Join(dir, "existing.json")
+	if err := SaveFile(meta, 4, existing); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(existing+tempSuffix, []byte(`"TestRecoverDir"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// An unfinished first save.
+	partial := filepath.Join(subdir, "partial.json")
+	if err := ioutil.WriteFile(partial+tempSuffix, []byte("\"TestRecoverDir\"\n\"0.1\"\n{\"a\":"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, removed, err := RecoverDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 1 || restored[0] != complete {
+		t.Error("wrong restored files:", restored)
+	}
+	if len(removed) != 2 {
+		t.Error("wrong removed files:", removed)
+	}
+	var data int
+	if err := LoadFile(meta, &data, complete); err != nil || data != 3 {
+		t.Error("complete file was not restored:", data, err)
+	}
+	if err := LoadFile(meta, &data, existing); err != nil || data != 4 {
+		t.Error("existing file was changed:", data, err)
+	}
+	for _, path := range []string{complete + tempSuffix, existing + tempSuffix, partial, partial + tempSuffix} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Error("file should not exist:", path)
+		}
+	}
+
+	// Nothing is recovered the second time, or from a missing directory.
+	if restored, removed, err := RecoverDir(dir); err != nil || len(restored) != 0 || len(removed) != 0 {
+		t.Error("unexpected second recovery:", restored, removed, err)
+	}
+	if _, _, err := RecoverDir(filepath.Join(dir, "missing")); err != nil {
+		t.Error(err)
+	}
+
+	// A relative directory is resolved against the working directory.
+	if err := ioutil.WriteFile(existing+tempSuffix, []byte(`"TestRecoverDir"`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if _, removed, err := RecoverDir(""); err != nil || len(removed) != 1 {
+		t.Error("relative directory was not recovered:", removed, err)
+	}
+}
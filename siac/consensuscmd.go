@@ -6,7 +6,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/NebulousLabs/Sia/api"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -17,22 +16,36 @@ var (
 		Long:  "Print the current state of consensus such as current block, block height, and target.",
 		Run:   wrap(consensuscmd),
 	}
+
+	consensusSnapshotCmd = &cobra.Command{
+		Use:   "snapshot [destination]",
+		Short: "Export a snapshot of the consensus set",
+		Long: `Export a snapshot of the consensus set at its current height. The snapshot
+can be used to bootstrap a new node with 'siad --snapshot'.`,
+		Run: wrap(consensussnapshotcmd),
+	}
 )
 
 // consensuscmd is the handler for the command `siac consensus`.
 // Prints the current state of consensus.
 func consensuscmd() {
-	var cg api.ConsensusGET
-	err := getAPI("/consensus", &cg)
+	cg, err := httpClient.ConsensusStatsGet()
 	if err != nil {
 		die("Could not get current consensus state:", err)
 	}
+	if jsonOutput {
+		printJSON(cg)
+		return
+	}
 	if cg.Synced {
-		fmt.Printf(`Synced: %v
-Block:  %v
-Height: %v
-Target: %v
-`, yesNo(cg.Synced), cg.CurrentBlock, cg.Height, cg.Target)
+		fmt.Printf(`Synced:     %v
+Block:      %v
+Height:     %v
+Target:     %v
+Difficulty: %v
+Block Time: %.f seconds (recent average)
+Hashrate:   %v H/s (estimated)
+`, yesNo(cg.Synced), cg.CurrentBlock, cg.Height, cg.Target, cg.Difficulty, cg.BlockInterval, cg.Hashrate)
 	} else {
 		estimatedHeight := estimatedHeightAt(time.Now())
 		estimatedProgress := float64(cg.Height) / float64(estimatedHeight) * 100
@@ -46,6 +59,24 @@ Progress (estimated): %.f%%
 	}
 }
 
+// consensussnapshotcmd is the handler for the command `siac consensus
+// snapshot [destination]`. Exports a snapshot of the consensus set.
+func consensussnapshotcmd(destination string) {
+	destination = abs(destination)
+	csp, err := httpClient.ConsensusSnapshotPost(destination)
+	if err != nil {
+		die("Could not export consensus snapshot:", err)
+	}
+	if jsonOutput {
+		printJSON(csp)
+		return
+	}
+	fmt.Printf(`Exported snapshot at height %v to %v.
+Import it on a new node with:
+	siad --snapshot %v --snapshot-id %v --snapshot-checksum %v
+`, csp.Height, destination, destination, csp.BlockID, csp.Checksum)
+}
+
 // estimatedHeightAt returns the estimated block height for the given time.
 // Block height is estimated by calculating the minutes since a known block in
 // the past and dividing by 10 minutes (the block time).
@@ -2,13 +2,22 @@ package main
 
 import (
 	"fmt"
-
-	"github.com/NebulousLabs/Sia/api"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
 var (
+	backupCmd = &cobra.Command{
+		Use:   "backup [destination]",
+		Short: "Back up the wallet, renter, and host",
+		Long: `Write a backup of the wallet seeds and keys, the renter's files and contracts,
+and the host's settings and storage obligations to a single file, encrypted
+with a password. The sector data of the host is not included. The backup can
+be restored on a new node with 'siad --restore'.`,
+		Run: wrap(backupcmd),
+	}
+
 	stopCmd = &cobra.Command{
 		Use:   "stop",
 		Short: "Stop the Sia daemon",
@@ -31,43 +40,90 @@ var (
 	}
 )
 
+// backupcmd is the handler for the command `siac backup [destination]`.
+// Writes a backup of the wallet, renter, and host.
+func backupcmd(destination string) {
+	password, err := askPassword("Backup password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	}
+	confirm, err := askPassword("Confirm backup password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	}
+	if password != confirm {
+		die("Passwords do not match.")
+	}
+	destination = abs(destination)
+	dbp, err := httpClient.DaemonBackupPost(destination, password)
+	if err != nil {
+		die("Could not create backup:", err)
+	}
+	if jsonOutput {
+		printJSON(dbp)
+		return
+	}
+	fmt.Printf(`Backed up the %v at height %v to %v.
+Restore it on a new node with:
+	siad --restore %v
+`, strings.Join(dbp.Modules, ", "), dbp.Height, destination, destination)
+}
+
 // stopcmd is the handler for the command `siac stop`.
 // Stops the daemon.
 func stopcmd() {
-	err := get("/daemon/stop")
+	err := httpClient.DaemonStopGet()
 	if err != nil {
 		die("Could not stop daemon:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Sia daemon stopped.")
 }
 
 func updatecmd() {
-	var update api.UpdateInfo
-	err := getAPI("/daemon/update", &update)
+	update, err := httpClient.DaemonUpdateGet()
 	if err != nil {
+		if jsonOutput {
+			die("Could not check for update:", err)
+		}
 		fmt.Println("Could not check for update:", err)
 		return
 	}
-	if !update.Available {
+	if !update.Available && !jsonOutput {
 		fmt.Println("Already up to date.")
 	}
 
-	err = post("/daemon/update", "")
+	err = httpClient.DaemonUpdatePost()
 	if err != nil {
+		if jsonOutput {
+			die("Could not apply update:", err)
+		}
 		fmt.Println("Could not apply update:", err)
 		return
 	}
+	if jsonOutput {
+		printJSON(update)
+		return
+	}
 	fmt.Printf("Updated to version %s! Restart siad now.\n", update.Version)
 
 }
 
 func updatecheckcmd() {
-	var update api.UpdateInfo
-	err := getAPI("/daemon/update", &update)
+	update, err := httpClient.DaemonUpdateGet()
 	if err != nil {
+		if jsonOutput {
+			die("Could not check for update:", err)
+		}
 		fmt.Println("Could not check for update:", err)
 		return
 	}
+	if jsonOutput {
+		printJSON(update)
+		return
+	}
 	if update.Available {
 		fmt.Printf("A new release (v%s) is available! Run 'siac update' to install it.\n", update.Version)
 	} else {
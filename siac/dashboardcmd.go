@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/api/client"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+const (
+	// dashboardEvents is the number of recent events shown by the dashboard.
+	dashboardEvents = 8
+
+	// dashboardWindows is the number of upcoming proof windows shown by the
+	// dashboard.
+	dashboardWindows = 5
+
+	// The escape sequences that switch to the alternate screen of the
+	// terminal and hide the cursor, clear the screen, and restore the
+	// terminal.
+	escEnterScreen = "\x1b[?1049h\x1b[?25l"
+	escClearScreen = "\x1b[H\x1b[2J"
+	escLeaveScreen = "\x1b[?25h\x1b[?1049l"
+)
+
+var (
+	dashboardCmd = &cobra.Command{
+		Use:   "dashboard",
+		Short: "Show a live dashboard of the node",
+		Long: `Show the sync progress, peers, wallet balance, contracts, upcoming proof
+windows, and recent events of the node on one screen, refreshing until 'q' or
+Ctrl-C is pressed. Pressing 'r' refreshes the dashboard immediately.`,
+		Run: wrap(dashboardcmd),
+	}
+
+	// dashboardRefresh is the interval at which the dashboard requests the
+	// state of the node. Events are shown as soon as they arrive.
+	dashboardRefresh time.Duration
+)
+
+// dashboardState is the state of the node shown by the dashboard. The error
+// of each section is set if the section could not be fetched, which is
+// usually because its module is not loaded.
+type dashboardState struct {
+	Time time.Time
+
+	Consensus    api.ConsensusGET
+	ConsensusErr error
+
+	Gateway    api.GatewayGET
+	GatewayErr error
+
+	Wallet    api.WalletGET
+	WalletErr error
+
+	Contracts    []api.RenterContract
+	ContractsErr error
+
+	Host    api.HostGET
+	HostErr error
+
+	Events []api.Event
+}
+
+// fetchDashboard requests the state of the node from the API.
+func fetchDashboard(c *client.Client) dashboardState {
+	var s dashboardState
+	s.Time = time.Now()
+	s.Consensus, s.ConsensusErr = c.ConsensusGet()
+	s.Gateway, s.GatewayErr = c.GatewayGet()
+	s.Wallet, s.WalletErr = c.WalletGet()
+	rc, err := c.RenterContractsGet("", client.Page{})
+	s.Contracts, s.ContractsErr = rc.Contracts, err
+	s.Host, s.HostErr = c.HostGet()
+	return s
+}
+
+// dashboardJSON is the state of the node printed by `siac dashboard --json`.
+// The sections that could not be fetched are left out.
+type dashboardJSON struct {
+	Time      time.Time            `json:"time"`
+	Consensus *api.ConsensusGET    `json:"consensus,omitempty"`
+	Gateway   *api.GatewayGET      `json:"gateway,omitempty"`
+	Wallet    *api.WalletGET       `json:"wallet,omitempty"`
+	Contracts []api.RenterContract `json:"contracts,omitempty"`
+	Host      *api.HostGET         `json:"host,omitempty"`
+}
+
+// newDashboardJSON returns the sections of 's' that were fetched.
+func newDashboardJSON(s dashboardState) dashboardJSON {
+	dj := dashboardJSON{Time: s.Time}
+	if s.ConsensusErr == nil {
+		dj.Consensus = &s.Consensus
+	}
+	if s.GatewayErr == nil {
+		dj.Gateway = &s.Gateway
+	}
+	if s.WalletErr == nil {
+		dj.Wallet = &s.Wallet
+	}
+	if s.ContractsErr == nil {
+		dj.Contracts = s.Contracts
+	}
+	if s.HostErr == nil {
+		dj.Host = &s.Host
+	}
+	return dj
+}
+
+// renderDashboard writes the dashboard for the state 's' to 'w'.
+func renderDashboard(w io.Writer, s dashboardState) {
+	fmt.Fprintf(w, "Sia Dashboard - %v - %v (press 'q' to quit)\n\n", addr, s.Time.Format("15:04:05"))
+
+	fmt.Fprintln(w, "Sync")
+	if s.ConsensusErr != nil {
+		fmt.Fprintln(w, "  Unavailable:", s.ConsensusErr)
+	} else if s.Consensus.Synced {
+		fmt.Fprintf(w, "  Synced at height %v\n", s.Consensus.Height)
+	} else {
+		estimatedHeight := estimatedHeightAt(s.Time)
+		progress := float64(s.Consensus.Height) / float64(estimatedHeight) * 100
+		if progress > 99 {
+			progress = 99
+		}
+		fmt.Fprintf(w, "  Syncing: height %v of about %v (%.f%%)\n", s.Consensus.Height, estimatedHeight, progress)
+	}
+
+	fmt.Fprintln(w, "\nPeers")
+	if s.GatewayErr != nil {
+		fmt.Fprintln(w, "  Unavailable:", s.GatewayErr)
+	} else {
+		var inbound int
+		for _, p := range s.Gateway.Peers {
+			if p.Inbound {
+				inbound++
+			}
+		}
+		fmt.Fprintf(w, "  %v connected (%v inbound, %v outbound)\n", len(s.Gateway.Peers), inbound, len(s.Gateway.Peers)-inbound)
+	}
+
+	fmt.Fprintln(w, "\nWallet")
+	if s.WalletErr != nil {
+		fmt.Fprintln(w, "  Unavailable:", s.WalletErr)
+	} else if !s.Wallet.Unlocked {
+		fmt.Fprintln(w, "  Locked")
+	} else {
+		incoming, outgoing := s.Wallet.UnconfirmedIncomingSiacoins, s.Wallet.UnconfirmedOutgoingSiacoins
+		var delta string
+		if incoming.Cmp(outgoing) >= 0 {
+			delta = "+" + currencyUnits(incoming.Sub(outgoing))
+		} else {
+			delta = "-" + currencyUnits(outgoing.Sub(incoming))
+		}
+		fmt.Fprintf(w, "  Confirmed: %v   Unconfirmed delta: %v   Siafunds: %v SF\n", currencyUnits(s.Wallet.ConfirmedSiacoinBalance), delta, s.Wallet.SiafundBalance)
+	}
+
+	fmt.Fprintln(w, "\nContracts")
+	if s.ContractsErr != nil {
+		fmt.Fprintln(w, "  Renter unavailable:", s.ContractsErr)
+	} else {
+		funds := types.ZeroCurrency
+		var size uint64
+		for _, c := range s.Contracts {
+			funds = funds.Add(c.RenterFunds)
+			size += c.Size
+		}
+		fmt.Fprintf(w, "  Renter: %v active, %v stored, %v remaining\n", len(s.Contracts), filesizeUnits(int64(size)), currencyUnits(funds))
+	}
+	if s.HostErr != nil {
+		fmt.Fprintln(w, "  Host unavailable:", s.HostErr)
+	} else {
+		fm := s.Host.FinancialMetrics
+		fmt.Fprintf(w, "  Host:   %v storage obligations, %v collateral locked\n", fm.ContractCount, currencyUnits(fm.LockedStorageCollateral))
+	}
+
+	fmt.Fprintln(w, "\nUpcoming proof windows")
+	windows := upcomingWindows(s.Contracts, s.Consensus.Height)
+	if len(windows) == 0 {
+		fmt.Fprintln(w, "  None")
+	}
+	for _, c := range windows {
+		fmt.Fprintf(w, "  Height %-8v (in %v blocks)  %v\n", c.EndHeight, c.EndHeight-s.Consensus.Height, c.NetAddress)
+	}
+
+	fmt.Fprintln(w, "\nRecent events")
+	if len(s.Events) == 0 {
+		fmt.Fprintln(w, "  None")
+	}
+	for i := len(s.Events) - 1; i >= 0; i-- {
+		e := s.Events[i]
+		fmt.Fprintf(w, "  %-8v %-16v %v\n", e.Height, e.Type, e.Data)
+	}
+}
+
+// upcomingWindows returns the contracts whose proof windows open after
+// 'height', soonest first.
+func upcomingWindows(contracts []api.RenterContract, height types.BlockHeight) []api.RenterContract {
+	var windows []api.RenterContract
+	for _, c := range contracts {
+		if c.EndHeight > height {
+			windows = append(windows, c)
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].EndHeight < windows[j].EndHeight
+	})
+	if len(windows) > dashboardWindows {
+		windows = windows[:dashboardWindows]
+	}
+	return windows
+}
+
+// An eventLog keeps the most recent events of the /daemon/events stream.
+type eventLog struct {
+	events []api.Event
+	mu     sync.Mutex
+}
+
+// recent returns the most recent events, oldest first.
+func (el *eventLog) recent() []api.Event {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	return append([]api.Event(nil), el.events...)
+}
+
+// add adds an event, forgetting the oldest event once dashboardEvents are
+// kept.
+func (el *eventLog) add(e api.Event) {
+	el.mu.Lock()
+	defer el.mu.Unlock()
+	el.events = append(el.events, e)
+	if len(el.events) > dashboardEvents {
+		el.events = el.events[len(el.events)-dashboardEvents:]
+	}
+}
+
+// threadedFollowEvents adds the events of the /daemon/events stream to 'el',
+// reconnecting to the stream if it fails. 'update' is signalled after each
+// event.
+func (el *eventLog) threadedFollowEvents(c *client.Client, update chan<- struct{}) {
+	for {
+		stream, err := c.DaemonEventsGet()
+		if err != nil {
+			time.Sleep(dashboardRefresh)
+			continue
+		}
+		for {
+			e, err := stream.Next()
+			if err != nil {
+				break
+			}
+			el.add(e)
+			select {
+			case update <- struct{}{}:
+			default:
+			}
+		}
+		stream.Close()
+		time.Sleep(dashboardRefresh)
+	}
+}
+
+// setRawInput switches the terminal to reading single key presses without
+// echoing them, and returns a function that restores the previous mode. If
+// the mode cannot be changed, such as when stdin is not a terminal, keys are
+// read a line at a time.
+func setRawInput() func() {
+	stty := func(args ...string) (string, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return func() {}
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return func() {}
+	}
+	return func() { stty(saved) }
+}
+
+// dashboardcmd is the handler for the command `siac dashboard`.
+// Shows a live dashboard of the node.
+func dashboardcmd() {
+	if dashboardRefresh <= 0 {
+		die("Refresh interval must be positive.")
+	}
+	if jsonOutput {
+		// The state of the node is printed once, as the dashboard cannot be
+		// refreshed in place.
+		printJSON(newDashboardJSON(fetchDashboard(httpClient)))
+		return
+	}
+
+	restore := setRawInput()
+	fmt.Print(escEnterScreen)
+	defer func() {
+		fmt.Print(escLeaveScreen)
+		restore()
+	}()
+
+	keys := make(chan byte)
+	go func() {
+		buf := make([]byte, 1)
+		for {
+			if _, err := os.Stdin.Read(buf); err != nil {
+				close(keys)
+				return
+			}
+			keys <- buf[0]
+		}
+	}()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	var events eventLog
+	update := make(chan struct{}, 1)
+	go events.threadedFollowEvents(httpClient, update)
+
+	ticker := time.NewTicker(dashboardRefresh)
+	defer ticker.Stop()
+	s := fetchDashboard(httpClient)
+	for {
+		// The screen is drawn in a single write, so that it does not
+		// flicker.
+		s.Events = events.recent()
+		buf := new(bytes.Buffer)
+		buf.WriteString(escClearScreen)
+		renderDashboard(buf, s)
+		os.Stdout.Write(buf.Bytes())
+
+		select {
+		case <-ticker.C:
+			s = fetchDashboard(httpClient)
+		case <-update:
+		case <-sigs:
+			return
+		case k, ok := <-keys:
+			if !ok {
+				// stdin was closed, so the dashboard keeps refreshing until
+				// it is interrupted.
+				keys = nil
+			} else if k == 'q' || k == 'Q' {
+				return
+			} else if k == 'r' || k == 'R' {
+				s = fetchDashboard(httpClient)
+			}
+		}
+	}
+}
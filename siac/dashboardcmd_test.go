@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+)
+
+// TestRenderDashboard checks that the dashboard shows each section of the
+// state, and the sections that could not be fetched.
+func TestRenderDashboard(t *testing.T) {
+	s := dashboardState{
+		Time:      time.Date(2017, time.January, 1, 12, 0, 0, 0, time.UTC),
+		Consensus: api.ConsensusGET{Synced: true, Height: 100},
+		Gateway: api.GatewayGET{Peers: []modules.Peer{
+			{NetAddress: "1.2.3.4:9981", Inbound: true},
+			{NetAddress: "5.6.7.8:9981"},
+			{NetAddress: "9.10.11.12:9981"},
+		}},
+		Wallet: api.WalletGET{
+			Unlocked:                    true,
+			ConfirmedSiacoinBalance:     types.SiacoinPrecision.Mul64(10),
+			UnconfirmedOutgoingSiacoins: types.SiacoinPrecision,
+		},
+		Contracts: []api.RenterContract{
+			{EndHeight: 150, NetAddress: "host1:9982", RenterFunds: types.SiacoinPrecision, Size: 1 << 20},
+			{EndHeight: 120, NetAddress: "host2:9982", RenterFunds: types.SiacoinPrecision},
+			{EndHeight: 90, NetAddress: "host3:9982"},
+		},
+		HostErr: errors.New("404 Not Found"),
+		Events: []api.Event{
+			{Type: api.EventBlock, Height: 99},
+			{Type: api.EventFundsReceived, Height: 100},
+		},
+	}
+	buf := new(bytes.Buffer)
+	renderDashboard(buf, s)
+	out := buf.String()
+
+	for _, expected := range []string{
+		"Synced at height 100",
+		"3 connected (1 inbound, 2 outbound)",
+		"Confirmed: 10 SC   Unconfirmed delta: -1 SC",
+		"Renter: 3 active",
+		"Host unavailable: 404 Not Found",
+		"Height 120      (in 20 blocks)  host2:9982",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("dashboard does not contain %q:\n%s", expected, out)
+		}
+	}
+	// Windows are listed soonest first, without the windows that have
+	// opened, and events are listed newest first.
+	if strings.Index(out, "host2") > strings.Index(out, "host1") || strings.Contains(out, "host3") {
+		t.Error("wrong proof windows:\n" + out)
+	}
+	events := out[strings.Index(out, "Recent events"):]
+	if strings.Index(events, api.EventFundsReceived) > strings.Index(events, api.EventBlock) {
+		t.Error("events are not listed newest first:\n" + out)
+	}
+}
+
+// TestEventLog checks that the event log keeps only the most recent events.
+func TestEventLog(t *testing.T) {
+	var el eventLog
+	for i := 0; i < dashboardEvents+3; i++ {
+		el.add(api.Event{Height: types.BlockHeight(i)})
+	}
+	events := el.recent()
+	if len(events) != dashboardEvents || events[0].Height != 3 || events[len(events)-1].Height != dashboardEvents+2 {
+		t.Fatal("wrong recent events:", events)
+	}
+}
+
+// TestDashboardJSON checks that the JSON output of the dashboard leaves out
+// the sections that could not be fetched.
+func TestDashboardJSON(t *testing.T) {
+	s := dashboardState{
+		Consensus:    api.ConsensusGET{Height: 100},
+		Wallet:       api.WalletGET{Unlocked: true},
+		ContractsErr: errors.New("404 Not Found"),
+		HostErr:      errors.New("404 Not Found"),
+	}
+	dj := newDashboardJSON(s)
+	if dj.Consensus == nil || dj.Consensus.Height != 100 || dj.Gateway == nil || dj.Wallet == nil || !dj.Wallet.Unlocked {
+		t.Fatal("fetched sections were left out:", dj)
+	}
+	if dj.Contracts != nil || dj.Host != nil {
+		t.Fatal("sections that could not be fetched were included:", dj)
+	}
+}
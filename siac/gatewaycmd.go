@@ -7,7 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/modules"
 )
 
 var (
@@ -50,42 +50,54 @@ var (
 // gatewayconnectcmd is the handler for the command `siac gateway add [address]`.
 // Adds a new peer to the peer list.
 func gatewayconnectcmd(addr string) {
-	err := post("/gateway/connect/"+addr, "")
+	err := httpClient.GatewayConnectPost(modules.NetAddress(addr))
 	if err != nil {
 		die("Could not add peer:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Added", addr, "to peer list.")
 }
 
 // gatewaydisconnectcmd is the handler for the command `siac gateway remove [address]`.
 // Removes a peer from the peer list.
 func gatewaydisconnectcmd(addr string) {
-	err := post("/gateway/disconnect/"+addr, "")
+	err := httpClient.GatewayDisconnectPost(modules.NetAddress(addr))
 	if err != nil {
 		die("Could not remove peer:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Removed", addr, "from peer list.")
 }
 
 // gatewayaddresscmd is the handler for the command `siac gateway address`.
 // Prints the gateway's network address.
 func gatewayaddresscmd() {
-	var info api.GatewayGET
-	err := getAPI("/gateway", &info)
+	info, err := httpClient.GatewayGet()
 	if err != nil {
 		die("Could not get gateway address:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 	fmt.Println("Address:", info.NetAddress)
 }
 
 // gatewaycmd is the handler for the command `siac gateway`.
 // Prints the gateway's network address and number of peers.
 func gatewaycmd() {
-	var info api.GatewayGET
-	err := getAPI("/gateway", &info)
+	info, err := httpClient.GatewayGet()
 	if err != nil {
 		die("Could not get gateway address:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 	fmt.Println("Address:", info.NetAddress)
 	fmt.Println("Active peers:", len(info.Peers))
 }
@@ -93,11 +105,14 @@ func gatewaycmd() {
 // gatewaylistcmd is the handler for the command `siac gateway list`.
 // Prints a list of all peers.
 func gatewaylistcmd() {
-	var info api.GatewayGET
-	err := getAPI("/gateway", &info)
+	info, err := httpClient.GatewayGet()
 	if err != nil {
 		die("Could not get peer list:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 	if len(info.Peers) == 0 {
 		fmt.Println("No peers to show.")
 		return
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"strconv"
 	"text/tabwriter"
 
 	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/crypto"
 	"github.com/NebulousLabs/Sia/modules"
 	"github.com/NebulousLabs/Sia/types"
 
@@ -118,21 +120,25 @@ sector may impact host revenue.`,
 // hostcmd is the handler for the command `siac host`.
 // Prints info about the host and its storage folders.
 func hostcmd() {
-	hg := new(api.HostGET)
-	err := getAPI("/host", hg)
+	hg, err := httpClient.HostGet()
 	if err != nil {
 		die("Could not fetch host settings:", err)
 	}
-	sg := new(api.StorageGET)
-	err = getAPI("/host/storage", sg)
+	sg, err := httpClient.HostStorageGet()
 	if err != nil {
 		die("Could not fetch storage info:", err)
 	}
+	if jsonOutput {
+		printJSON(struct {
+			Host    api.HostGET    `json:"host"`
+			Storage api.StorageGET `json:"storage"`
+		}{hg, sg})
+		return
+	}
 
 	// Determine the competitive price string.
-	ah := new(api.ActiveHosts)
 	var competitivePrice string
-	err = getAPI("/hostdb/active?numhosts=24", ah)
+	ah, err := httpClient.HostDbActiveGet(24)
 	if err != nil || len(ah.Hosts) == 0 {
 		competitivePrice = "Unavailable"
 	} else {
@@ -340,10 +346,13 @@ func hostconfigcmd(param, value string) {
 	default:
 		die("\"" + param + "\" is not a host setting")
 	}
-	err := post("/host", param+"="+value)
+	err := httpClient.HostModifySettingPost(param, value)
 	if err != nil {
 		die("Could not update host settings:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Host settings updated.")
 }
 
@@ -354,9 +363,9 @@ func hostannouncecmd(cmd *cobra.Command, args []string) {
 	var err error
 	switch len(args) {
 	case 0:
-		err = post("/host/announce", "")
+		err = httpClient.HostAnnouncePost()
 	case 1:
-		err = post("/host/announce", "netaddress="+args[0])
+		err = httpClient.HostAnnounceAddrPost(modules.NetAddress(args[0]))
 	default:
 		cmd.UsageFunc()(cmd)
 		os.Exit(exitCodeUsage)
@@ -364,13 +373,18 @@ func hostannouncecmd(cmd *cobra.Command, args []string) {
 	if err != nil {
 		die("Could not announce host:", err)
 	}
-	fmt.Println("Host announcement submitted to network.")
+	if !jsonOutput {
+		fmt.Println("Host announcement submitted to network.")
+	}
 
 	// start accepting contracts
-	err = post("/host", "acceptingcontracts=true")
+	err = httpClient.HostModifySettingPost("acceptingcontracts", true)
 	if err != nil {
 		die("Could not configure host to accept contracts:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println(`
 The host has also been configured to accept contracts.
 To revert this, run:
@@ -384,19 +398,29 @@ func hostfolderaddcmd(path, size string) {
 	if err != nil {
 		die("Could not parse size:", err)
 	}
-	err = post("/host/storage/folders/add", fmt.Sprintf("path=%s&size=%s", abs(path), size))
+	bytes, err := strconv.ParseUint(size, 10, 64)
+	if err != nil {
+		die("Could not parse size:", err)
+	}
+	err = httpClient.HostStorageFoldersAddPost(abs(path), bytes)
 	if err != nil {
 		die("Could not add folder:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Added folder", path)
 }
 
 // hostfolderremovecmd removes a folder from the host.
 func hostfolderremovecmd(path string) {
-	err := post("/host/storage/folders/remove", "path="+abs(path))
+	err := httpClient.HostStorageFoldersRemovePost(abs(path), false)
 	if err != nil {
 		die("Could not remove folder:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Removed folder", path)
 }
 
@@ -406,18 +430,32 @@ func hostfolderresizecmd(path, newsize string) {
 	if err != nil {
 		die("Could not parse size:", err)
 	}
-	err = post("/host/storage/folders/resize", fmt.Sprintf("path=%s&newsize=%s", abs(path), newsize))
+	bytes, err := strconv.ParseUint(newsize, 10, 64)
+	if err != nil {
+		die("Could not parse size:", err)
+	}
+	err = httpClient.HostStorageFoldersResizePost(abs(path), bytes)
 	if err != nil {
 		die("Could not resize folder:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Printf("Resized folder %v to %v\n", path, newsize)
 }
 
 // hostsectordeletecmd deletes a sector from the host.
 func hostsectordeletecmd(root string) {
-	err := post("/host/storage/sectors/delete/"+root, "")
+	var sectorRoot crypto.Hash
+	if err := sectorRoot.LoadString(root); err != nil {
+		die("Could not parse sector root:", err)
+	}
+	err := httpClient.HostStorageSectorsDeletePost(sectorRoot)
 	if err != nil {
 		die("Could not delete sector:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Deleted sector", root)
 }
@@ -5,7 +5,6 @@ import (
 
 	"github.com/spf13/cobra"
 
-	"github.com/NebulousLabs/Sia/api"
 	"github.com/NebulousLabs/Sia/modules"
 )
 
@@ -19,11 +18,14 @@ var (
 )
 
 func hostdbcmd() {
-	info := new(api.ActiveHosts)
-	err := getAPI("/hostdb/active", info)
+	info, err := httpClient.HostDbActiveGet(0)
 	if err != nil {
 		die("Could not fetch host list:", err)
 	}
+	if jsonOutput {
+		printJSON(info)
+		return
+	}
 	if len(info.Hosts) == 0 {
 		fmt.Println("No known active hosts")
 		return
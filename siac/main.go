@@ -2,25 +2,30 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"reflect"
+	"strings"
+	"time"
 
 	"github.com/bgentry/speakeasy"
 	"github.com/spf13/cobra"
 
 	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/api/client"
 	"github.com/NebulousLabs/Sia/build"
 )
 
 // flags
 var (
 	addr              string // override default API address
+	jsonOutput        bool   // print the responses of the API as JSON
 	initPassword      bool   // supply a custom password when creating a wallet
 	hostVerbose       bool   // display additional host info
+	minerThreads      int    // number of threads for the cpu miner
+	minerCPULimit     int    // percentage of cpu time for each miner thread
 	renterShowHistory bool   // Show download history in addition to download queue.
 	renterListVerbose bool   // Show additional info about uploaded files.
 )
@@ -32,157 +37,49 @@ const (
 	exitCodeUsage   = 64 // EX_USAGE in sysexits.h
 )
 
-// non2xx returns true for non-success HTTP status codes.
-func non2xx(code int) bool {
-	return code < 200 || code > 299
+// httpClient makes the API calls of siac. Its address is set by the --addr
+// flag before a command runs.
+var httpClient = &client.Client{
+	UserAgent:  client.DefaultUserAgent,
+	HTTPClient: &http.Client{Transport: passwordPrompter{}},
 }
 
-// decodeError returns the api.Error from a API response. This method should
-// only be called if the response's status code is non-2xx. The error returned
-// may not be of type api.Error in the event of an error unmarshalling the
-// JSON.
-func decodeError(resp *http.Response) error {
-	var apiErr api.Error
-	err := json.NewDecoder(resp.Body).Decode(&apiErr)
-	if err != nil {
-		return err
-	}
-	return apiErr
-}
+// passwordPrompter is an http.RoundTripper that prompts for the API password
+// when siad responds with 401 Unauthorized, and retries the request with it.
+// The password is sent with every later call of httpClient.
+type passwordPrompter struct{}
 
-// apiGet wraps a GET request with a status code check, such that if the GET does
-// not return 2xx, the error will be read and returned. The response body is
-// not closed.
-func apiGet(call string) (*http.Response, error) {
-	if host, port, _ := net.SplitHostPort(addr); host == "" {
-		addr = net.JoinHostPort("localhost", port)
+// RoundTrip implements http.RoundTripper.
+func (passwordPrompter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
 	}
-	resp, err := api.HttpGET("http://" + addr + call)
+	resp.Body.Close()
+	password, err := askPassword("API password: ")
 	if err != nil {
-		return nil, errors.New("no response from daemon")
-	}
-	// check error code
-	if resp.StatusCode == http.StatusUnauthorized {
-		resp.Body.Close()
-		// Prompt for password and retry request with authentication.
-		password, err := speakeasy.Ask("API password: ")
-		if err != nil {
-			return nil, err
-		}
-		resp, err = api.HttpGETAuthenticated("http://"+addr+call, password)
-		if err != nil {
-			return nil, errors.New("no response from daemon - authentication failed")
-		}
-	}
-	if resp.StatusCode == http.StatusNotFound {
-		resp.Body.Close()
-		return nil, errors.New("API call not recognized: " + call)
-	}
-	if non2xx(resp.StatusCode) {
-		err := decodeError(resp)
-		resp.Body.Close()
 		return nil, err
 	}
-	return resp, nil
-}
+	httpClient.Password = password
 
-// getAPI makes a GET API call and decodes the response. An error is returned
-// if the response status is not 2xx.
-func getAPI(call string, obj interface{}) error {
-	resp, err := apiGet(call)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNoContent {
-		return errors.New("expecting a response, but API returned status code 204 No Content")
-	}
-
-	err = json.NewDecoder(resp.Body).Decode(obj)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-// get makes an API call and discards the response. An error is returned if the
-// response status is not 2xx.
-func get(call string) error {
-	resp, err := apiGet(call)
-	if err != nil {
-		return err
-	}
-	resp.Body.Close()
-	return nil
-}
-
-// apiPost wraps a POST request with a status code check, such that if the POST
-// does not return 2xx, the error will be read and returned. The response body
-// is not closed.
-func apiPost(call, vals string) (*http.Response, error) {
-	if host, port, _ := net.SplitHostPort(addr); host == "" {
-		addr = net.JoinHostPort("localhost", port)
-	}
-
-	resp, err := api.HttpPOST("http://"+addr+call, vals)
-	if err != nil {
-		return nil, errors.New("no response from daemon")
-	}
-	// check error code
-	if resp.StatusCode == http.StatusUnauthorized {
-		resp.Body.Close()
-		// Prompt for password and retry request with authentication.
-		password, err := speakeasy.Ask("API password: ")
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
 		if err != nil {
 			return nil, err
 		}
-		resp, err = api.HttpPOSTAuthenticated("http://"+addr+call, vals, password)
-		if err != nil {
-			return nil, errors.New("no response from daemon - authentication failed")
-		}
 	}
-	if resp.StatusCode == http.StatusNotFound {
-		resp.Body.Close()
-		return nil, errors.New("API call not recognized: " + call)
-	}
-	if non2xx(resp.StatusCode) {
-		err := decodeError(resp)
-		resp.Body.Close()
-		return nil, err
-	}
-	return resp, nil
-}
-
-// postResp makes a POST API call and decodes the response. An error is
-// returned if the response status is not 2xx.
-func postResp(call, vals string, obj interface{}) error {
-	resp, err := apiPost(call, vals)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusNoContent {
-		return errors.New("expecting a response, but API returned status code 204 No Content")
-	}
-
-	err = json.NewDecoder(resp.Body).Decode(obj)
-	if err != nil {
-		return err
-	}
-	return nil
+	retry.SetBasicAuth("", password)
+	return http.DefaultTransport.RoundTrip(retry)
 }
 
-// post makes an API call and discards the response. An error is returned if
-// the response status is not 2xx.
-func post(call, vals string) error {
-	resp, err := apiPost(call, vals)
-	if err != nil {
-		return err
+// setAPIAddress points httpClient at the address given by the --addr flag,
+// which defaults to localhost if it has no host.
+func setAPIAddress() {
+	if host, port, _ := net.SplitHostPort(addr); host == "" {
+		addr = net.JoinHostPort("localhost", port)
 	}
-	resp.Body.Close()
-	return nil
+	httpClient.Address = addr
 }
 
 // wrap wraps a generic command with a check that the command has been
@@ -215,12 +112,74 @@ func wrap(fn interface{}) func(*cobra.Command, []string) {
 // die prints its arguments to stderr, then exits the program with the default
 // error code.
 func die(args ...interface{}) {
+	if jsonOutput {
+		// Scripts get the error as an api.Error, with the code of the API
+		// error that caused it, if there is one.
+		apiErr := api.Error{Message: strings.TrimSuffix(fmt.Sprintln(args...), "\n")}
+		for _, arg := range args {
+			if err, ok := arg.(api.Error); ok {
+				apiErr.Code = err.Code
+			}
+		}
+		json.NewEncoder(os.Stderr).Encode(apiErr)
+		os.Exit(exitCodeGeneral)
+	}
 	fmt.Fprintln(os.Stderr, args...)
 	os.Exit(exitCodeGeneral)
 }
 
+// printJSON prints 'v', the response of a command, as indented JSON. It is
+// used instead of the formatted output of a command when --json is set.
+func printJSON(v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		die("Could not encode response:", err)
+	}
+	fmt.Println(string(b))
+}
+
+// askPassword prompts for a password without echoing it. When --json is set,
+// the prompt is written to stderr, so that stdout only holds JSON.
+func askPassword(prompt string) (string, error) {
+	if jsonOutput {
+		return speakeasy.FAsk(os.Stderr, prompt)
+	}
+	return speakeasy.Ask(prompt)
+}
+
+// versionJSON is the output of 'siac version --json'. Daemon is left out if
+// the daemon is not running.
+type versionJSON struct {
+	Version     string             `json:"version"`
+	GitRevision string             `json:"gitrevision"`
+	Daemon      *api.DaemonVersion `json:"daemon,omitempty"`
+}
+
+// version prints the version of siac, and the version and build of the daemon
+// if it is running.
 func version() {
-	println("Sia Client v" + build.Version)
+	if jsonOutput {
+		v := versionJSON{Version: build.Version, GitRevision: build.GitRevision}
+		if dv, err := httpClient.DaemonVersionGet(); err == nil {
+			v.Daemon = &dv
+		}
+		printJSON(v)
+		return
+	}
+	fmt.Println("Sia Client v" + build.Version)
+	if build.GitRevision != "" {
+		fmt.Println("Git Revision " + build.GitRevision)
+	}
+
+	dv, err := httpClient.DaemonVersionGet()
+	if err != nil {
+		fmt.Println("Could not get the daemon version:", err)
+		return
+	}
+	fmt.Printf("Sia Daemon v%v (%v network, protocol v%v)\n", dv.Version, dv.Network, dv.ProtocolVersion)
+	if dv.GitRevision != "" {
+		fmt.Println("Git Revision " + dv.GitRevision)
+	}
 }
 
 func main() {
@@ -241,6 +200,11 @@ func main() {
 
 	root.AddCommand(stopCmd)
 
+	root.AddCommand(backupCmd)
+
+	root.AddCommand(dashboardCmd)
+	dashboardCmd.Flags().DurationVarP(&dashboardRefresh, "refresh", "r", 5*time.Second, "Interval at which the dashboard requests the state of the node")
+
 	root.AddCommand(updateCmd)
 	updateCmd.AddCommand(updateCheckCmd)
 
@@ -253,10 +217,13 @@ func main() {
 	root.AddCommand(hostdbCmd)
 
 	root.AddCommand(minerCmd)
-	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
+	minerCmd.AddCommand(minerStartCmd, minerStopCmd, minerMineCmd, minerPayoutsCmd, minerPoolCmd)
+	minerPoolCmd.AddCommand(minerPoolStartCmd, minerPoolStopCmd)
+	minerStartCmd.Flags().IntVarP(&minerThreads, "threads", "t", 0, "Number of threads to mine with (defaults to the number of cpu cores)")
+	minerStartCmd.Flags().IntVarP(&minerCPULimit, "cpulimit", "l", 0, "Percentage of cpu time that each thread may use (1-100)")
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletInitCmd,
+	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletFaucetCmd, walletInitCmd,
 		walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd,
 		walletBalanceCmd, walletTransactionsCmd, walletUnlockCmd)
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
@@ -276,11 +243,14 @@ func main() {
 	gatewayCmd.AddCommand(gatewayConnectCmd, gatewayDisconnectCmd, gatewayAddressCmd, gatewayListCmd)
 
 	root.AddCommand(consensusCmd)
+	consensusCmd.AddCommand(consensusSnapshotCmd)
 
 	// parse flags
-	root.PersistentFlags().StringVarP(&addr, "addr", "a", "localhost:9980", "which host/port to communicate with (i.e. the host/port siad is listening on)")
+	root.PersistentFlags().StringVarP(&addr, "addr", "a", client.DefaultAddress, "which host/port to communicate with (i.e. the host/port siad is listening on)")
+	root.PersistentFlags().BoolVarP(&jsonOutput, "json", "", false, "print the responses of the API as JSON instead of formatted text")
 
 	// run
+	cobra.OnInitialize(setAPIAddress)
 	if err := root.Execute(); err != nil {
 		// Since no commands return errors (all commands set Command.Run instead of
 		// Command.RunE), Command.Execute() should only return an error on an
@@ -2,8 +2,12 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
-	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/spf13/cobra"
 )
@@ -19,8 +23,56 @@ var (
 	minerStartCmd = &cobra.Command{
 		Use:   "start",
 		Short: "Start cpu mining",
-		Long:  "Start cpu mining, if the miner is already running, this command does nothing",
-		Run:   wrap(minerstartcmd),
+		Long: `Start cpu mining. If the miner is already running, only the number of threads
+and the cpu limit are changed. The cpu limit is the percentage of cpu time that
+each thread may use, e.g.:
+	siac miner start --cpulimit 50`,
+		Run: wrap(minerstartcmd),
+	}
+
+	minerMineCmd = &cobra.Command{
+		Use:   "mine [n]",
+		Short: "Mine blocks on demand",
+		Long: `Mine [n] blocks on top of the current block, including the transactions
+in the transaction pool. Only available on the regtest network and in dev
+builds, where blocks can be mined instantly.`,
+		Run: wrap(minerminecmd),
+	}
+
+	minerPayoutsCmd = &cobra.Command{
+		Use:   "payouts [address:percentage]...",
+		Short: "View or set the miner payout addresses",
+		Long: `View or set the addresses that the block subsidy is paid to.
+With no arguments, the current payout addresses are printed. The subsidy can be
+split between several addresses by percentage, e.g.:
+	siac miner payouts [address1]:60 [address2]:40
+The percentages must add up to 100. To pay the subsidy to the wallet again, run:
+	siac miner payouts wallet`,
+		Run: minerpayoutscmd,
+	}
+
+	minerPoolCmd = &cobra.Command{
+		Use:   "pool",
+		Short: "View the status of pool mining",
+		Long:  "View the status of pool mining, including the number of shares accepted by the pool.",
+		Run:   wrap(minerpoolcmd),
+	}
+
+	minerPoolStartCmd = &cobra.Command{
+		Use:   "start [pool] [payout address]",
+		Short: "Start mining for a pool",
+		Long: `Start contributing shares to a stratum-like mining pool at [pool], e.g.:
+	siac miner pool start pool.example.com:3333 [payout address]
+The payout address is registered with the pool, which pays it for the shares
+that are found. Starting pool mining stops the cpu miner.`,
+		Run: wrap(minerpoolstartcmd),
+	}
+
+	minerPoolStopCmd = &cobra.Command{
+		Use:   "stop",
+		Short: "Stop mining for a pool",
+		Long:  "Disconnect from the mining pool and stop contributing shares.",
+		Run:   wrap(minerpoolstopcmd),
 	}
 
 	minerStopCmd = &cobra.Command{
@@ -34,21 +86,27 @@ var (
 // minerstartcmd is the handler for the command `siac miner start`.
 // Starts the CPU miner.
 func minerstartcmd() {
-	err := get("/miner/start")
+	err := httpClient.MinerStartGet(minerThreads, minerCPULimit)
 	if err != nil {
 		die("Could not start miner:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("CPU Miner is now running.")
 }
 
 // minercmd is the handler for the command `siac miner`.
 // Prints the status of the miner.
 func minercmd() {
-	status := new(api.MinerGET)
-	err := getAPI("/miner", status)
+	status, err := httpClient.MinerGet()
 	if err != nil {
 		die("Could not get miner status:", err)
 	}
+	if jsonOutput {
+		printJSON(status)
+		return
+	}
 
 	miningStr := "off"
 	if status.CPUMining {
@@ -56,17 +114,161 @@ func minercmd() {
 	}
 	fmt.Printf(`Miner status:
 CPU Mining:   %s
+CPU Threads:  %d
+CPU Limit:    %d%%
 CPU Hashrate: %v KH/s
 Blocks Mined: %d (%d stale)
-`, miningStr, status.CPUHashrate/1000, status.BlocksMined, status.StaleBlocksMined)
+`, miningStr, status.CPUThreads, status.CPULimit, status.CPUHashrate/1000, status.BlocksMined, status.StaleBlocksMined)
+}
+
+// minerminecmd is the handler for the command `siac miner mine [n]`.
+// Mines blocks on demand.
+func minerminecmd(n string) {
+	count, err := strconv.Atoi(n)
+	if err != nil {
+		die("Could not parse number of blocks:", err)
+	}
+	mmb, err := httpClient.MinerMineBlocksPost(count)
+	if err != nil {
+		die("Could not mine blocks:", err)
+	}
+	if jsonOutput {
+		printJSON(mmb)
+		return
+	}
+	for _, id := range mmb.BlockIDs {
+		fmt.Println(id)
+	}
+	fmt.Printf("Mined %d blocks.\n", len(mmb.BlockIDs))
+}
+
+// minerpayoutscmd is the handler for the command `siac miner payouts`.
+// Prints or sets the payout splits of the miner.
+func minerpayoutscmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		mpg, err := httpClient.MinerPayoutsGet()
+		if err != nil {
+			die("Could not get miner payouts:", err)
+		}
+		if jsonOutput {
+			printJSON(mpg)
+			return
+		}
+		if len(mpg.Payouts) == 0 {
+			fmt.Println("Block subsidies are paid to the wallet.")
+			return
+		}
+		fmt.Println("Block subsidies are paid to:")
+		for _, split := range mpg.Payouts {
+			fmt.Printf("\t%v: %v%%\n", split.UnlockHash, split.Percentage)
+		}
+		return
+	}
+
+	var splits []modules.MinerPayoutSplit
+	if len(args) != 1 || args[0] != "wallet" {
+		for _, arg := range args {
+			fields := strings.Split(arg, ":")
+			if len(fields) != 2 {
+				cmd.UsageFunc()(cmd)
+				os.Exit(exitCodeUsage)
+			}
+			var split modules.MinerPayoutSplit
+			if err := split.UnlockHash.LoadString(fields[0]); err != nil {
+				die("Could not parse address:", err)
+			}
+			if _, err := fmt.Sscan(fields[1], &split.Percentage); err != nil {
+				die("Could not parse percentage:", err)
+			}
+			splits = append(splits, split)
+		}
+	}
+	err := httpClient.MinerPayoutsPost(splits)
+	if err != nil {
+		die("Could not set miner payouts:", err)
+	}
+	if jsonOutput {
+		return
+	}
+	if len(splits) == 0 {
+		fmt.Println("Block subsidies will be paid to the wallet.")
+	} else {
+		fmt.Println("Miner payouts updated.")
+	}
+}
+
+// minerpoolcmd is the handler for the command `siac miner pool`.
+// Prints the status of pool mining.
+func minerpoolcmd() {
+	status, err := httpClient.MinerPoolGet()
+	if err != nil {
+		die("Could not get pool status:", err)
+	}
+	if jsonOutput {
+		printJSON(status)
+		return
+	}
+	if !status.PoolMining {
+		fmt.Println("Not mining for a pool.")
+		return
+	}
+
+	connectedStr := "no"
+	if status.Connected {
+		connectedStr = "yes"
+	}
+	fmt.Printf(`Pool status:
+Pool:            %v
+Connected:       %s
+Payout Address:  %v
+Difficulty:      %d
+Hashrate:        %v KH/s
+Shares Accepted: %d (%d rejected)
+`, status.Pool, connectedStr, status.PayoutAddress, status.Difficulty, status.Hashrate/1000, status.SharesAccepted, status.SharesRejected)
+	if status.LastError != "" {
+		fmt.Println("Last Error:     ", status.LastError)
+	}
+}
+
+// minerpoolstartcmd is the handler for the command `siac miner pool start`.
+// Starts mining for a pool.
+func minerpoolstartcmd(pool, payout string) {
+	var payoutAddr types.UnlockHash
+	if err := payoutAddr.LoadString(payout); err != nil {
+		die("Could not parse payout address:", err)
+	}
+	err := httpClient.MinerPoolStartPost(modules.NetAddress(pool), payoutAddr, 0)
+	if err != nil {
+		die("Could not start pool mining:", err)
+	}
+	if jsonOutput {
+		return
+	}
+	fmt.Println("Now mining for", pool)
+}
+
+// minerpoolstopcmd is the handler for the command `siac miner pool stop`.
+// Stops mining for a pool.
+func minerpoolstopcmd() {
+	err := httpClient.MinerPoolStopPost()
+	if err != nil {
+		die("Could not stop pool mining:", err)
+	}
+	if jsonOutput {
+		return
+	}
+	fmt.Println("Stopped pool mining.")
 }
 
 // minerstopcmd is the handler for the command `siac miner stop`.
 // Stops the CPU miner.
 func minerstopcmd() {
-	err := get("/miner/stop")
+	err := httpClient.MinerStopGet()
 	if err != nil {
 		die("Could not stop miner:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Stopped mining.")
 }
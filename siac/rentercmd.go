@@ -10,7 +10,9 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/api/client"
 	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 var (
@@ -114,11 +116,21 @@ func abs(path string) string {
 // rentercmd displays the renter's financial metrics and lists the files it is
 // tracking.
 func rentercmd() {
-	var rg api.RenterGET
-	err := getAPI("/renter", &rg)
+	rg, err := httpClient.RenterGet()
 	if err != nil {
 		die("Could not get renter info:", err)
 	}
+	if jsonOutput {
+		rf, err := httpClient.RenterFilesGet("", client.Page{})
+		if err != nil {
+			die("Could not get file list:", err)
+		}
+		printJSON(struct {
+			Renter api.RenterGET   `json:"renter"`
+			Files  api.RenterFiles `json:"files"`
+		}{rg, rf})
+		return
+	}
 	fm := rg.FinancialMetrics
 	unspent := fm.ContractSpending.Sub(fm.DownloadSpending).Sub(fm.StorageSpending).Sub(fm.UploadSpending)
 	fmt.Printf(`Renter info:
@@ -139,8 +151,7 @@ func rentercmd() {
 // renteruploadscmd is the handler for the command `siac renter uploads`.
 // Lists files currently uploading.
 func renteruploadscmd() {
-	var rf api.RenterFiles
-	err := getAPI("/renter/files", &rf)
+	rf, err := httpClient.RenterFilesGet("", client.Page{})
 	if err != nil {
 		die("Could not get upload queue:", err)
 	}
@@ -157,6 +168,10 @@ func renteruploadscmd() {
 			filteredFiles = append(filteredFiles, fi)
 		}
 	}
+	if jsonOutput {
+		printJSON(api.RenterFiles{Files: filteredFiles})
+		return
+	}
 	if len(filteredFiles) == 0 {
 		fmt.Println("No files are uploading.")
 		return
@@ -171,11 +186,25 @@ func renteruploadscmd() {
 // Lists files currently downloading, and optionally previously downloaded
 // files if the -H or --history flag is specified.
 func renterdownloadscmd() {
-	var queue api.RenterDownloadQueue
-	err := getAPI("/renter/downloads", &queue)
+	queue, err := httpClient.RenterDownloadsGet()
 	if err != nil {
 		die("Could not get download queue:", err)
 	}
+	if jsonOutput {
+		// Without --history, only the files that are downloading are
+		// printed, as they are without --json.
+		if !renterShowHistory {
+			var downloading []modules.DownloadInfo
+			for _, file := range queue.Downloads {
+				if file.Received != file.Filesize {
+					downloading = append(downloading, file)
+				}
+			}
+			queue.Downloads = downloading
+		}
+		printJSON(queue)
+		return
+	}
 	// Filter out files that have been downloaded.
 	var downloading []modules.DownloadInfo
 	for _, file := range queue.Downloads {
@@ -214,11 +243,14 @@ func renterdownloadscmd() {
 
 // renterallowancecmd displays the current allowance.
 func renterallowancecmd() {
-	var rg api.RenterGET
-	err := getAPI("/renter", &rg)
+	rg, err := httpClient.RenterGet()
 	if err != nil {
 		die("Could not get allowance:", err)
 	}
+	if jsonOutput {
+		printJSON(rg.Settings.Allowance)
+		return
+	}
 	allowance := rg.Settings.Allowance
 
 	// convert to SC
@@ -238,10 +270,21 @@ func rentersetallowancecmd(amount, period string) {
 	if err != nil {
 		die("Could not parse period")
 	}
-	err = post("/renter", fmt.Sprintf("funds=%s&period=%s", hastings, blocks))
+	var funds types.Currency
+	if _, err := fmt.Sscan(hastings, &funds); err != nil {
+		die("Could not parse amount:", err)
+	}
+	var blockHeight types.BlockHeight
+	if _, err := fmt.Sscan(blocks, &blockHeight); err != nil {
+		die("Could not parse period:", err)
+	}
+	err = httpClient.RenterPost(funds, blockHeight)
 	if err != nil {
 		die("Could not set allowance:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Allowance updated.")
 }
 
@@ -262,11 +305,14 @@ func (s byValue) Less(i, j int) bool {
 // rentercontractscmd is the handler for the comand `siac renter contracts`.
 // It lists the Renter's contracts.
 func rentercontractscmd() {
-	var rc api.RenterContracts
-	err := getAPI("/renter/contracts", &rc)
+	rc, err := httpClient.RenterContractsGet("", client.Page{})
 	if err != nil {
 		die("Could not get contracts:", err)
 	}
+	if jsonOutput {
+		printJSON(rc)
+		return
+	}
 	if len(rc.Contracts) == 0 {
 		fmt.Println("No contracts have been formed.")
 		return
@@ -289,20 +335,26 @@ func rentercontractscmd() {
 // renterfilesdeletecmd is the handler for the command `siac renter delete [path]`.
 // Removes the specified path from the Sia network.
 func renterfilesdeletecmd(path string) {
-	err := post("/renter/delete/"+path, "")
+	err := httpClient.RenterDeletePost(path)
 	if err != nil {
 		die("Could not delete file:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Deleted", path)
 }
 
 // renterfilesdownloadcmd is the handler for the comand `siac renter download [path] [destination]`.
 // Downloads a path from the Sia network to the local specified destination.
 func renterfilesdownloadcmd(path, destination string) {
-	err := get("/renter/download/" + path + "?destination=" + abs(destination))
+	err := httpClient.RenterDownloadGet(path, abs(destination))
 	if err != nil {
 		die("Could not download file:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Printf("Downloaded '%s' to %s.\n", path, abs(destination))
 }
 
@@ -317,11 +369,14 @@ func (s bySiaPath) Less(i, j int) bool { return s[i].SiaPath < s[j].SiaPath }
 // renterfileslistcmd is the handler for the command `siac renter list`.
 // Lists files known to the renter on the network.
 func renterfileslistcmd() {
-	var rf api.RenterFiles
-	err := getAPI("/renter/files", &rf)
+	rf, err := httpClient.RenterFilesGet("", client.Page{})
 	if err != nil {
 		die("Could not get file list:", err)
 	}
+	if jsonOutput {
+		printJSON(rf)
+		return
+	}
 	if len(rf.Files) == 0 {
 		fmt.Println("No files have been uploaded.")
 		return
@@ -359,19 +414,25 @@ func renterfileslistcmd() {
 // renterfilesrenamecmd is the handler for the command `siac renter rename [path] [newpath]`.
 // Renames a file on the Sia network.
 func renterfilesrenamecmd(path, newpath string) {
-	err := post("/renter/rename/"+path, "newsiapath="+newpath)
+	err := httpClient.RenterRenamePost(path, newpath)
 	if err != nil {
 		die("Could not rename file:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Printf("Renamed %s to %s\n", path, newpath)
 }
 
 // renterfilesuploadcmd is the handler for the command `siac renter upload [source] [path]`.
 // Uploads the [source] file to [path] on the Sia network.
 func renterfilesuploadcmd(source, path string) {
-	err := post("/renter/upload/"+path, "source="+abs(source))
+	err := httpClient.RenterUploadPost(abs(source), path)
 	if err != nil {
 		die("Could not upload file:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Printf("Uploaded '%s' as %s.\n", abs(source), path)
 }
@@ -3,11 +3,13 @@ package main
 import (
 	"fmt"
 	"math/big"
+	"os"
+	"strings"
 
-	"github.com/bgentry/speakeasy"
+	"github.com/NebulousLabs/entropy-mnemonics"
 	"github.com/spf13/cobra"
 
-	"github.com/NebulousLabs/Sia/api"
+	"github.com/NebulousLabs/Sia/api/client"
 	"github.com/NebulousLabs/Sia/types"
 )
 
@@ -45,6 +47,15 @@ The smallest unit of siacoins is the hasting. One siacoin is 10^24 hastings. Oth
 		Run:   wrap(walletaddressescmd),
 	}
 
+	walletFaucetCmd = &cobra.Command{
+		Use:   "faucet [url]",
+		Short: "Request coins from a testnet faucet",
+		Long: `Request coins from a faucet on a test network, and wait until they are confirmed.
+If no url is given, the faucet that siad was started with (--faucet-url) is used.
+The wallet must be unlocked. Faucets are not available on mainnet.`,
+		Run: walletfaucetcmd,
+	}
+
 	walletInitCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize and encrypt a new wallet",
@@ -148,21 +159,53 @@ Run 'wallet send --help' to see a list of available units.`,
 // walletaddresscmd fetches a new address from the wallet that will be able to
 // receive coins.
 func walletaddresscmd() {
-	addr := new(api.WalletAddressGET)
-	err := getAPI("/wallet/address", addr)
+	addr, err := httpClient.WalletAddressGet()
 	if err != nil {
 		die("Could not generate new address:", err)
 	}
+	if jsonOutput {
+		printJSON(addr)
+		return
+	}
 	fmt.Printf("Created new address: %s\n", addr.Address)
 }
 
+// walletfaucetcmd requests coins from a faucet and waits until they are
+// confirmed.
+func walletfaucetcmd(cmd *cobra.Command, args []string) {
+	var faucetURL string
+	switch len(args) {
+	case 0:
+	case 1:
+		faucetURL = args[0]
+	default:
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	if !jsonOutput {
+		fmt.Println("Requesting coins from the faucet. Waiting for them to be confirmed, which may take several minutes...")
+	}
+	wfp, err := httpClient.WalletFaucetPost(faucetURL)
+	if err != nil {
+		die("Could not get coins from the faucet:", err)
+	}
+	if jsonOutput {
+		printJSON(wfp)
+		return
+	}
+	fmt.Printf("Received %v at %v, confirmed at height %v\n", currencyUnits(wfp.Amount), wfp.Address, wfp.ConfirmationHeight)
+}
+
 // walletaddressescmd fetches the list of addresses that the wallet knows.
 func walletaddressescmd() {
-	addrs := new(api.WalletAddressesGET)
-	err := getAPI("/wallet/addresses", addrs)
+	addrs, err := httpClient.WalletAddressesGet()
 	if err != nil {
 		die("Failed to fetch addresses:", err)
 	}
+	if jsonOutput {
+		printJSON(addrs)
+		return
+	}
 	for _, addr := range addrs.Addresses {
 		fmt.Println(addr)
 	}
@@ -170,19 +213,22 @@ func walletaddressescmd() {
 
 // walletinitcmd encrypts the wallet with the given password
 func walletinitcmd() {
-	var er api.WalletInitPOST
-	qs := fmt.Sprintf("dictionary=%s", "english")
+	var password string
 	if initPassword {
-		password, err := speakeasy.Ask("Wallet password: ")
+		var err error
+		password, err = askPassword("Wallet password: ")
 		if err != nil {
 			die("Reading password failed:", err)
 		}
-		qs += fmt.Sprintf("&encryptionpassword=%s", password)
 	}
-	err := postResp("/wallet/init", qs, &er)
+	er, err := httpClient.WalletInitPost(password, mnemonics.English)
 	if err != nil {
 		die("Error when encrypting wallet:", err)
 	}
+	if jsonOutput {
+		printJSON(er)
+		return
+	}
 	fmt.Printf("Recovery seed:\n%s\n\n", er.PrimarySeed)
 	if initPassword {
 		fmt.Printf("Wallet encrypted with given password\n")
@@ -193,53 +239,59 @@ func walletinitcmd() {
 
 // walletload033xcmd loads a v0.3.3.x wallet into the current wallet.
 func walletload033xcmd(source string) {
-	password, err := speakeasy.Ask("Wallet password: ")
+	password, err := askPassword("Wallet password: ")
 	if err != nil {
 		die("Reading password failed:", err)
 	}
-	qs := fmt.Sprintf("source=%s&encryptionpassword=%s", abs(source), password)
-	err = post("/wallet/033x", qs)
+	err = httpClient.Wallet033xPost(abs(source), password)
 	if err != nil {
 		die("Loading wallet failed:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Wallet loading successful.")
 }
 
 // walletloadseedcmd adds a seed to the wallet's list of seeds
 func walletloadseedcmd() {
-	password, err := speakeasy.Ask("Wallet password: ")
+	password, err := askPassword("Wallet password: ")
 	if err != nil {
 		die("Reading password failed:", err)
 	}
-	seed, err := speakeasy.Ask("New Seed: ")
+	seed, err := askPassword("New Seed: ")
 	if err != nil {
 		die("Reading seed failed:", err)
 	}
-	qs := fmt.Sprintf("encryptionpassword=%s&seed=%s&dictionary=%s", password, seed, "english")
-	err = post("/wallet/seed", qs)
+	err = httpClient.WalletSeedPost(seed, password, mnemonics.English)
 	if err != nil {
 		die("Could not add seed:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Added Key")
 }
 
 // walletloadsiagcmd loads a siag key set into the wallet.
 func walletloadsiagcmd(keyfiles string) {
-	password, err := speakeasy.Ask("Wallet password: ")
+	password, err := askPassword("Wallet password: ")
 	if err != nil {
 		die("Reading password failed:", err)
 	}
-	qs := fmt.Sprintf("keyfiles=%s&encryptionpassword=%s", keyfiles, password)
-	err = post("/wallet/siagkey", qs)
+	err = httpClient.WalletSiagkeyPost(strings.Split(keyfiles, ","), password)
 	if err != nil {
 		die("Loading siag key failed:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Wallet loading successful.")
 }
 
 // walletlockcmd locks the wallet
 func walletlockcmd() {
-	err := post("/wallet/lock", "")
+	err := httpClient.WalletLockPost()
 	if err != nil {
 		die("Could not lock wallet:", err)
 	}
@@ -247,11 +299,14 @@ func walletlockcmd() {
 
 // walletseedcmd returns the current seed {
 func walletseedscmd() {
-	var seedInfo api.WalletSeedsGET
-	err := getAPI("/wallet/seeds", &seedInfo)
+	seedInfo, err := httpClient.WalletSeedsGet(mnemonics.English)
 	if err != nil {
 		die("Error retrieving the current seed:", err)
 	}
+	if jsonOutput {
+		printJSON(seedInfo)
+		return
+	}
 	fmt.Printf("Primary Seed: %s\n"+
 		"Addresses Remaining %d\n"+
 		"All Seeds:\n", seedInfo.PrimarySeed, seedInfo.AddressesRemaining)
@@ -266,29 +321,56 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if err != nil {
 		die("Could not parse amount:", err)
 	}
-	err = post("/wallet/siacoins", fmt.Sprintf("amount=%s&destination=%s", hastings, dest))
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Could not parse amount:", err)
+	}
+	var destAddr types.UnlockHash
+	if err := destAddr.LoadString(dest); err != nil {
+		die("Could not parse destination address:", err)
+	}
+	wsp, err := httpClient.WalletSiacoinsPost(value, destAddr)
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
+	if jsonOutput {
+		printJSON(wsp)
+		return
+	}
 	fmt.Printf("Sent %s hastings to %s\n", hastings, dest)
 }
 
 // walletsendsiafundscmd sends siafunds to a destination address.
 func walletsendsiafundscmd(amount, dest string) {
-	err := post("/wallet/siafunds", fmt.Sprintf("amount=%s&destination=%s", amount, dest))
+	var value types.Currency
+	if _, err := fmt.Sscan(amount, &value); err != nil {
+		die("Could not parse amount:", err)
+	}
+	var destAddr types.UnlockHash
+	if err := destAddr.LoadString(dest); err != nil {
+		die("Could not parse destination address:", err)
+	}
+	wsp, err := httpClient.WalletSiafundsPost(value, destAddr)
 	if err != nil {
 		die("Could not send siafunds:", err)
 	}
+	if jsonOutput {
+		printJSON(wsp)
+		return
+	}
 	fmt.Printf("Sent %s siafunds to %s\n", amount, dest)
 }
 
 // walletbalancecmd retrieves and displays information about the wallet.
 func walletbalancecmd() {
-	status := new(api.WalletGET)
-	err := getAPI("/wallet", status)
+	status, err := httpClient.WalletGet()
 	if err != nil {
 		die("Could not get wallet status:", err)
 	}
+	if jsonOutput {
+		printJSON(status)
+		return
+	}
 	encStatus := "Unencrypted"
 	if status.Encrypted {
 		encStatus = "Encrypted"
@@ -323,11 +405,14 @@ Siafund Claims:      %v H
 // wallettransactionscmd lists all of the transactions related to the wallet,
 // providing a net flow of siacoins and siafunds for each.
 func wallettransactionscmd() {
-	wtg := new(api.WalletTransactionsGET)
-	err := getAPI("/wallet/transactions?startheight=0&endheight=10000000", wtg)
+	wtg, err := httpClient.WalletTransactionsGet(0, 10000000, client.Page{})
 	if err != nil {
 		die("Could not fetch transaction history:", err)
 	}
+	if jsonOutput {
+		printJSON(wtg)
+		return
+	}
 
 	fmt.Println("    [height]                                                   [transaction id]    [net siacoins]   [net siafunds]")
 	txns := append(wtg.ConfirmedTransactions, wtg.UnconfirmedTransactions...)
@@ -381,15 +466,19 @@ func wallettransactionscmd() {
 
 // walletunlockcmd unlocks a saved wallet
 func walletunlockcmd() {
-	password, err := speakeasy.Ask("Wallet password: ")
+	password, err := askPassword("Wallet password: ")
 	if err != nil {
 		die("Reading password failed:", err)
 	}
-	fmt.Println("Unlocking the wallet. This may take several minutes...")
-	qs := fmt.Sprintf("encryptionpassword=%s&dictonary=%s", password, "english")
-	err = post("/wallet/unlock", qs)
+	if !jsonOutput {
+		fmt.Println("Unlocking the wallet. This may take several minutes...")
+	}
+	err = httpClient.WalletUnlockPost(password)
 	if err != nil {
 		die("Could not unlock wallet:", err)
 	}
+	if jsonOutput {
+		return
+	}
 	fmt.Println("Wallet unlocked")
 }
@@ -0,0 +1,210 @@
+package main
+
+// config.go loads the configuration of siad from a config file and from
+// environment variables. Every option of siad can be set with a command-line
+// flag, an environment variable or a line of the config file, in decreasing
+// order of priority. Options that are not set anywhere keep their defaults.
+//
+// The environment variable of an option is its flag name in upper case,
+// with dashes replaced by underscores and prefixed by SIAD_, such as
+// SIAD_API_ADDR for --api-addr.
+//
+// The config file contains one option per line in the form 'name = value',
+// where the name is the flag name of the option. Blank lines and lines
+// starting with '#' are ignored. Options starting with 'host.' set the
+// internal settings of the host, using the names of the settings in the host
+// API. For example:
+//
+//	api-addr = localhost:9980
+//	max-bandwidth = 1000000
+//	host.acceptingcontracts = true
+//	host.minstorageprice = 100000000000
+//
+// The host settings are reloaded from the config file when siad receives
+// SIGHUP.
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/NebulousLabs/Sia/modules"
+
+	"github.com/spf13/pflag"
+)
+
+const (
+	// defaultConfigFile is the name of the config file that is loaded from
+	// the sia directory if no config file is specified.
+	defaultConfigFile = "siad.conf"
+
+	// envPrefix is the prefix of the environment variables that set the
+	// options of siad.
+	envPrefix = "SIAD_"
+
+	// hostSettingPrefix is the prefix of the config file options that set
+	// the internal settings of the host.
+	hostSettingPrefix = "host."
+)
+
+var (
+	errUnknownHostSetting = errors.New("unrecognized host setting")
+)
+
+// envName returns the name of the environment variable that sets the option
+// with the given flag name.
+func envName(flag string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(flag, "-", "_", -1))
+}
+
+// parseConfigFile reads the options of a config file.
+func parseConfigFile(r io.Reader) (map[string]string, error) {
+	options := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		split := strings.SplitN(text, "=", 2)
+		if len(split) != 2 || strings.TrimSpace(split[0]) == "" {
+			return nil, fmt.Errorf("line %v: expected 'name = value'", line)
+		}
+		options[strings.TrimSpace(split[0])] = strings.TrimSpace(split[1])
+	}
+	return options, scanner.Err()
+}
+
+// applyOptions sets each flag that was not set on the command line to the
+// value of its environment variable, or else to its value in 'options'. The
+// host settings in 'options' are returned separately, keyed by the name of
+// the setting.
+func applyOptions(fs *pflag.FlagSet, options map[string]string, getenv func(string) string) (map[string]string, error) {
+	hostSettings := make(map[string]string)
+	for name, value := range options {
+		if strings.HasPrefix(name, hostSettingPrefix) {
+			hostSettings[strings.TrimPrefix(name, hostSettingPrefix)] = value
+		} else if fs.Lookup(name) == nil {
+			return nil, errors.New("unrecognized option " + name)
+		}
+	}
+
+	var err error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+		value, set := getenv(envName(f.Name)), true
+		if value == "" {
+			value, set = options[f.Name]
+		}
+		if set {
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value for %v: %v", f.Name, setErr)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hostSettings, nil
+}
+
+// applyHostSettings returns 'settings' with the settings in 'values'
+// replaced. The values are decoded as they would be by the host API, so
+// currencies and block heights are plain integers.
+func applyHostSettings(settings modules.HostInternalSettings, values map[string]string) (modules.HostInternalSettings, error) {
+	var fields map[string]json.RawMessage
+	b, _ := json.Marshal(settings)
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return modules.HostInternalSettings{}, err
+	}
+	for name, value := range values {
+		if _, exists := fields[name]; !exists {
+			return modules.HostInternalSettings{}, fmt.Errorf("%v: %v", errUnknownHostSetting, name)
+		}
+		// Values that are not valid JSON, such as net addresses, are
+		// treated as strings.
+		if json.Valid([]byte(value)) {
+			fields[name] = json.RawMessage(value)
+		} else {
+			fields[name], _ = json.Marshal(value)
+		}
+	}
+	b, _ = json.Marshal(fields)
+	var newSettings modules.HostInternalSettings
+	if err := json.Unmarshal(b, &newSettings); err != nil {
+		return modules.HostInternalSettings{}, fmt.Errorf("invalid host setting: %v", err)
+	}
+	return newSettings, nil
+}
+
+// readConfigFile reads the options of the config file at 'path'. A missing
+// file has no options, unless it is 'required'.
+func readConfigFile(path string, required bool) (map[string]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) && !required {
+		return make(map[string]string), nil
+	} else if err != nil {
+		return nil, errors.New("unable to open config file: " + err.Error())
+	}
+	defer f.Close()
+	options, err := parseConfigFile(f)
+	if err != nil {
+		return nil, errors.New("unable to parse config file " + path + ": " + err.Error())
+	}
+	return options, nil
+}
+
+// reloadHostSettings returns 'settings' with the host settings of the config
+// file at 'path' applied. Settings that have been removed from the config
+// file keep their values in 'settings', and the other options of the config
+// file only take effect when siad is restarted.
+func reloadHostSettings(settings modules.HostInternalSettings, path string) (modules.HostInternalSettings, error) {
+	options, err := readConfigFile(path, true)
+	if err != nil {
+		return modules.HostInternalSettings{}, err
+	}
+	values := make(map[string]string)
+	for name, value := range options {
+		if strings.HasPrefix(name, hostSettingPrefix) {
+			values[strings.TrimPrefix(name, hostSettingPrefix)] = value
+		}
+	}
+	return applyHostSettings(settings, values)
+}
+
+// loadConfig applies the environment variables and the config file to the
+// flags of siad that were not set on the command line. If no config file is
+// specified, siad.conf is loaded from the sia directory if it exists.
+func loadConfig(fs *pflag.FlagSet, config *Config) error {
+	path := config.Siad.ConfigFile
+	if env := os.Getenv(envName("config-file")); env != "" && !fs.Changed("config-file") {
+		path = env
+	}
+	required := path != ""
+	if !required {
+		siaDir := config.Siad.SiaDir
+		if env := os.Getenv(envName("sia-directory")); env != "" && !fs.Changed("sia-directory") {
+			siaDir = env
+		}
+		path = filepath.Join(siaDir, defaultConfigFile)
+	}
+
+	config.ConfigPath = path
+
+	options, err := readConfigFile(path, required)
+	if err != nil {
+		return err
+	}
+	config.HostSettings, err = applyOptions(fs, options, os.Getenv)
+	if err != nil {
+		return errors.New("invalid configuration: " + err.Error())
+	}
+	return nil
+}
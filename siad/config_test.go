@@ -0,0 +1,140 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/types"
+
+	"github.com/spf13/pflag"
+)
+
+// TestParseConfigFile probes the parseConfigFile function.
+func TestParseConfigFile(t *testing.T) {
+	file := `
+# comment
+api-addr = localhost:9990
+  max-bandwidth=1000
+host.acceptingcontracts = true
+`
+	options, err := parseConfigFile(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(options) != 3 || options["api-addr"] != "localhost:9990" || options["max-bandwidth"] != "1000" || options["host.acceptingcontracts"] != "true" {
+		t.Fatal("wrong options:", options)
+	}
+
+	for _, invalid := range []string{"api-addr", "= value"} {
+		if _, err := parseConfigFile(strings.NewReader(invalid)); err == nil {
+			t.Error("expected an error for", invalid)
+		}
+	}
+}
+
+// TestApplyOptions checks that flags take priority over environment
+// variables, which take priority over the config file.
+func TestApplyOptions(t *testing.T) {
+	var apiAddr, rpcAddr, hostAddr string
+	var bandwidth int64
+	fs := pflag.NewFlagSet("siad", pflag.ContinueOnError)
+	fs.StringVar(&apiAddr, "api-addr", "localhost:9980", "")
+	fs.StringVar(&rpcAddr, "rpc-addr", ":9981", "")
+	fs.StringVar(&hostAddr, "host-addr", ":9982", "")
+	fs.Int64Var(&bandwidth, "max-bandwidth", 0, "")
+	if err := fs.Parse([]string{"--api-addr", "localhost:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"SIAD_API_ADDR": "localhost:2",
+		"SIAD_RPC_ADDR": ":3",
+	}
+	options := map[string]string{
+		"api-addr":                "localhost:4",
+		"rpc-addr":                ":5",
+		"max-bandwidth":           "1000",
+		"host.acceptingcontracts": "true",
+	}
+	hostSettings, err := applyOptions(fs, options, func(name string) string { return env[name] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if apiAddr != "localhost:1" || rpcAddr != ":3" || bandwidth != 1000 || hostAddr != ":9982" {
+		t.Fatal("options applied with the wrong priority:", apiAddr, rpcAddr, bandwidth, hostAddr)
+	}
+	if len(hostSettings) != 1 || hostSettings["acceptingcontracts"] != "true" {
+		t.Fatal("wrong host settings:", hostSettings)
+	}
+
+	// Unknown options and invalid values are rejected.
+	if _, err := applyOptions(fs, map[string]string{"unknown": "1"}, func(string) string { return "" }); err == nil {
+		t.Error("expected an error for an unknown option")
+	}
+	fs = pflag.NewFlagSet("siad", pflag.ContinueOnError)
+	fs.Int64Var(&bandwidth, "max-bandwidth", 0, "")
+	if _, err := applyOptions(fs, map[string]string{"max-bandwidth": "lots"}, func(string) string { return "" }); err == nil {
+		t.Error("expected an error for an invalid value")
+	}
+}
+
+// TestApplyHostSettings probes the applyHostSettings function.
+func TestApplyHostSettings(t *testing.T) {
+	settings := modules.HostInternalSettings{MaxDuration: 10}
+	settings, err := applyHostSettings(settings, map[string]string{
+		"acceptingcontracts": "true",
+		"minstorageprice":    "1000",
+		"netaddress":         "example.com:9982",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.AcceptingContracts || settings.MinStoragePrice.Cmp(types.NewCurrency64(1000)) != 0 || settings.NetAddress != "example.com:9982" || settings.MaxDuration != 10 {
+		t.Fatal("host settings were not applied:", settings)
+	}
+
+	if _, err := applyHostSettings(settings, map[string]string{"unknown": "1"}); err == nil {
+		t.Error("expected an error for an unknown setting")
+	}
+	if _, err := applyHostSettings(settings, map[string]string{"maxduration": "-1"}); err == nil {
+		t.Error("expected an error for an invalid setting")
+	}
+}
+
+// TestReloadHostSettings checks that reloadHostSettings applies the host
+// settings of the config file, and only those.
+func TestReloadHostSettings(t *testing.T) {
+	dir := build.TempDir("siad", "TestReloadHostSettings")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, defaultConfigFile)
+	contents := "api-addr = localhost:1\nhost.acceptingcontracts = true\nhost.windowsize = 20\n"
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings := modules.HostInternalSettings{MaxDuration: 10, WindowSize: 5}
+	settings, err := reloadHostSettings(settings, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.AcceptingContracts || settings.WindowSize != 20 || settings.MaxDuration != 10 {
+		t.Fatal("host settings were not reloaded:", settings)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("host.unknown = 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloadHostSettings(settings, path); err == nil {
+		t.Error("expected an error for an unknown setting")
+	}
+	if _, err := reloadHostSettings(settings, filepath.Join(dir, "missing.conf")); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
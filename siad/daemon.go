@@ -3,9 +3,14 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/NebulousLabs/Sia/api"
@@ -16,11 +21,15 @@ import (
 	"github.com/NebulousLabs/Sia/modules/explorer"
 	"github.com/NebulousLabs/Sia/modules/gateway"
 	"github.com/NebulousLabs/Sia/modules/host"
+	"github.com/NebulousLabs/Sia/modules/lightclient"
 	"github.com/NebulousLabs/Sia/modules/miner"
+	"github.com/NebulousLabs/Sia/modules/relay"
 	"github.com/NebulousLabs/Sia/modules/renter"
 	"github.com/NebulousLabs/Sia/modules/transactionpool"
 	"github.com/NebulousLabs/Sia/modules/wallet"
+	"github.com/NebulousLabs/Sia/persist"
 	"github.com/NebulousLabs/Sia/profile"
+	"github.com/NebulousLabs/Sia/types"
 
 	"github.com/bgentry/speakeasy"
 	"github.com/spf13/cobra"
@@ -29,12 +38,22 @@ import (
 // verifyAPISecurity checks that the security values are consistent with a
 // sane, secure system.
 func verifyAPISecurity(config Config) error {
+	// Allowing every origin lets any website call the API from a browser, so
+	// it requires an API password.
+	for _, origin := range strings.Split(config.Siad.APICORSOrigins, ",") {
+		if strings.TrimSpace(origin) == "*" && !config.Siad.AuthenticateAPI {
+			return errors.New("cannot allow every origin with --api-cors-origins without setting an api password")
+		}
+	}
+
 	// Make sure that only the loopback address is allowed unless the
 	// --disable-api-security flag has been used.
 	if !config.Siad.AllowAPIBind {
-		addr := modules.NetAddress(config.Siad.APIaddr)
-		if !addr.IsLoopback() && addr.Host() != "" {
-			return errors.New("you must pass --disable-api-security to bind Siad to a non-localhost address")
+		for _, apiAddr := range strings.Split(config.Siad.APIaddr, ",") {
+			addr := modules.NetAddress(apiAddr)
+			if !addr.IsLoopback() && addr.Host() != "" {
+				return errors.New("you must pass --disable-api-security to bind Siad to a non-localhost address")
+			}
 		}
 		return nil
 	}
@@ -57,12 +76,22 @@ func processNetAddr(addr string) string {
 	return addr
 }
 
+// processNetAddrs applies processNetAddr to each address in a comma-separated
+// list of addresses.
+func processNetAddrs(addrs string) string {
+	split := strings.Split(addrs, ",")
+	for i := range split {
+		split[i] = processNetAddr(strings.TrimSpace(split[i]))
+	}
+	return strings.Join(split, ",")
+}
+
 // processModules makes the modules string lowercase to make checking if a
 // module in the string easier, and returns an error if the string contains an
 // invalid module character.
 func processModules(modules string) (string, error) {
 	modules = strings.ToLower(modules)
-	validModules := "cghmrtwe"
+	validModules := "cghlmrtwe"
 	invalidModules := modules
 	for _, m := range validModules {
 		invalidModules = strings.Replace(invalidModules, string(m), "", 1)
@@ -73,16 +102,183 @@ func processModules(modules string) (string, error) {
 	return modules, nil
 }
 
+// panicFlushTimeout is the time that siad waits for the modules to save their
+// state after a panic.
+const panicFlushTimeout = time.Minute
+
+var (
+	// moduleNames are the names of the modules, by their letter in the
+	// --modules flag.
+	moduleNames = map[rune]string{
+		'c': "consensus set",
+		'e': "explorer",
+		'g': "gateway",
+		'h': "host",
+		'l': "light client",
+		'm': "miner",
+		'r': "renter",
+		't': "transaction pool",
+		'w': "wallet",
+	}
+
+	// moduleDependencies are the modules that each module needs in order to
+	// run, by their letter in the --modules flag.
+	moduleDependencies = map[rune]string{
+		'c': "g",
+		'e': "c",
+		'l': "g",
+		't': "cg",
+		'w': "ct",
+		'm': "ctw",
+		'h': "ctw",
+		'r': "ctw",
+	}
+)
+
+// checkModuleDependencies returns an error if any of the modules in 'modules'
+// needs a module that is not in 'modules', so that an incomplete set of
+// modules is reported before any of them are loaded.
+func checkModuleDependencies(modules string) error {
+	var errs []error
+	for _, m := range modules {
+		var missing []string
+		for _, dep := range moduleDependencies[m] {
+			if !strings.ContainsRune(modules, dep) {
+				missing = append(missing, fmt.Sprintf("%v (%c)", moduleNames[dep], dep))
+			}
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("the %v (%c) requires the %v", moduleNames[m], m, strings.Join(missing, ", ")))
+		}
+	}
+	return build.JoinErrors(errs, ", and ")
+}
+
+// parseCheckpoints parses a comma-separated list of checkpoints of the form
+// 'height:id'.
+func parseCheckpoints(s string) (map[types.BlockHeight]types.BlockID, error) {
+	checkpoints := make(map[types.BlockHeight]types.BlockID)
+	if s == "" {
+		return checkpoints, nil
+	}
+	for _, checkpoint := range strings.Split(s, ",") {
+		split := strings.SplitN(strings.TrimSpace(checkpoint), ":", 2)
+		if len(split) != 2 {
+			return nil, fmt.Errorf("invalid checkpoint %q: expected height:id", checkpoint)
+		}
+		var height types.BlockHeight
+		if _, err := fmt.Sscan(split[0], &height); err != nil {
+			return nil, fmt.Errorf("invalid checkpoint height %q: %v", split[0], err)
+		}
+		var id crypto.Hash
+		if err := id.LoadString(split[1]); err != nil {
+			return nil, fmt.Errorf("invalid checkpoint id %q: %v", split[1], err)
+		}
+		checkpoints[height] = types.BlockID(id)
+	}
+	return checkpoints, nil
+}
+
+// selectNetwork sets the network parameters to those of the network named by
+// the --network flag. Networks other than the default network keep their data
+// in a separate directory, and do not use the bootstrap peers.
+func selectNetwork(config Config) (Config, error) {
+	network, err := types.NetworkByName(config.Siad.Network)
+	if err != nil {
+		return config, errors.New("unable to select network " + config.Siad.Network + ": " + err.Error())
+	}
+	types.SetNetwork(network)
+	if network.Name != types.DefaultNetwork().Name {
+		config.Siad.SiaDir = filepath.Join(config.Siad.SiaDir, network.Name)
+		config.Siad.NoBootstrap = true
+		fmt.Println("Using network", network.Name)
+	}
+	return config, nil
+}
+
+// importSnapshot installs the consensus snapshot at 'filename' in the consensus
+// directory, unless a consensus database already exists.
+func importSnapshot(filename, trustedID, trustedChecksum, consensusDir string) error {
+	if _, err := os.Stat(filepath.Join(consensusDir, consensus.DatabaseFilename)); err == nil {
+		fmt.Println("Consensus database already exists, ignoring --snapshot")
+		return nil
+	}
+	var id crypto.Hash
+	err := id.LoadString(trustedID)
+	if err != nil {
+		return errors.New("--snapshot requires a valid --snapshot-id: " + err.Error())
+	}
+	var checksum crypto.Hash
+	err = checksum.LoadString(trustedChecksum)
+	if err != nil {
+		return errors.New("--snapshot requires a valid --snapshot-checksum: " + err.Error())
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = consensus.ImportSnapshot(f, consensusDir, types.BlockID(id), checksum)
+	if err != nil {
+		return errors.New("unable to import consensus snapshot: " + err.Error())
+	}
+	return nil
+}
+
+// restoreBackup writes the files of the backup at 'filename' to the sia
+// directory. The password of the backup is read from backupPasswordEnv, or
+// prompted for.
+func restoreBackup(filename, siaDir string) error {
+	password := os.Getenv(backupPasswordEnv)
+	if password == "" {
+		var err error
+		password, err = speakeasy.Ask("Enter backup password: ")
+		if err != nil {
+			return err
+		}
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	manifest, err := modules.ReadBackup(f, siaDir, modules.BackupKey(password))
+	if err != nil {
+		return errors.New("unable to restore backup: " + err.Error())
+	}
+	fmt.Printf("Restored the %v from a backup made at height %v\n", strings.Join(manifest.Modules, ", "), manifest.Height)
+	return nil
+}
+
 // processConfig checks the configuration values and performs cleanup on
 // incorrect-but-allowed values.
 func processConfig(config Config) (Config, error) {
 	var err1 error
-	config.Siad.APIaddr = processNetAddr(config.Siad.APIaddr)
-	config.Siad.RPCaddr = processNetAddr(config.Siad.RPCaddr)
-	config.Siad.HostAddr = processNetAddr(config.Siad.HostAddr)
+	config.Siad.APIaddr = processNetAddrs(config.Siad.APIaddr)
+	config.Siad.RPCaddr = processNetAddrs(config.Siad.RPCaddr)
+	config.Siad.HostAddr = processNetAddrs(config.Siad.HostAddr)
 	config.Siad.Modules, err1 = processModules(config.Siad.Modules)
+	if err1 == nil {
+		err1 = checkModuleDependencies(config.Siad.Modules)
+	}
 	err2 := verifyAPISecurity(config)
-	err := build.JoinErrors([]error{err1, err2}, ", and ")
+	var err3 error
+	if config.Siad.LogLevel != "" {
+		if _, err := persist.ParseLogLevel(config.Siad.LogLevel); err != nil {
+			err3 = errors.New("invalid --log-level " + config.Siad.LogLevel)
+		}
+	}
+	var err4 error
+	if config.Siad.APIRateLimit < 0 {
+		err4 = errors.New("--api-rate-limit cannot be negative")
+	}
+	var err5 error
+	if config.Siad.FaucetURL != "" {
+		if u, err := url.Parse(config.Siad.FaucetURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			err5 = errors.New("--faucet-url must be an http or https url")
+		}
+	}
+	err := build.JoinErrors([]error{err1, err2, err3, err4, err5}, ", and ")
 	if err != nil {
 		return Config{}, err
 	}
@@ -93,9 +289,12 @@ func processConfig(config Config) (Config, error) {
 func startDaemon(config Config) (err error) {
 	// Prompt user for API password.
 	if config.Siad.AuthenticateAPI {
-		config.APIPassword, err = speakeasy.Ask("Enter API password: ")
-		if err != nil {
-			return err
+		config.APIPassword = os.Getenv(apiPasswordEnv)
+		if config.APIPassword == "" {
+			config.APIPassword, err = speakeasy.Ask("Enter API password: ")
+			if err != nil {
+				return err
+			}
 		}
 		if config.APIPassword == "" {
 			return errors.New("password cannot be blank")
@@ -108,29 +307,162 @@ func startDaemon(config Config) (err error) {
 		return err
 	}
 
+	config, err = selectNetwork(config)
+	if err != nil {
+		return err
+	}
+
+	// The logging settings apply to the logs of all modules.
+	if config.Siad.LogLevel != "" {
+		level, _ := persist.ParseLogLevel(config.Siad.LogLevel)
+		persist.SetLogLevel(level)
+	}
+	persist.SetLogRotation(config.Siad.MaxLogSize)
+	if config.Siad.LogStdout {
+		persist.SetLogOutput(os.Stdout)
+	}
+
+	// Lock the sia directory, so that a second siad cannot corrupt the files
+	// of the modules by using the same directory.
+	config.Siad.SiaDir, err = filepath.Abs(config.Siad.SiaDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(config.Siad.SiaDir, 0700); err != nil {
+		return err
+	}
+	dirLock, err := persist.LockDir(config.Siad.SiaDir)
+	if err == persist.ErrDirLocked {
+		return errors.New("another siad is already using " + config.Siad.SiaDir)
+	} else if err != nil {
+		return errors.New("unable to lock " + config.Siad.SiaDir + ": " + err.Error())
+	}
+	defer dirLock.Unlock()
+
+	// Write the pid file for the service manager.
+	if config.Siad.PidFile != "" {
+		if err := writePidFile(config.Siad.PidFile); err != nil {
+			return errors.New("unable to write pid file: " + err.Error())
+		}
+		defer removePidFile(config.Siad.PidFile)
+	}
+
+	// Repair the files that a crash left partially written, before the
+	// modules load them.
+	restored, removed, err := persist.RecoverDir(config.Siad.SiaDir)
+	if err != nil {
+		return errors.New("unable to check " + config.Siad.SiaDir + " for partially written files: " + err.Error())
+	}
+	for _, filename := range restored {
+		fmt.Println("Completed the interrupted save of", filename)
+	}
+	for _, filename := range removed {
+		fmt.Println("Removed the partially written file", filename)
+	}
+
+	// Restore the backup before any module loads its files, so that every
+	// module starts from the restored metadata.
+	if config.Siad.Restore != "" {
+		if err := restoreBackup(config.Siad.Restore, config.Siad.SiaDir); err != nil {
+			return err
+		}
+	}
+
+	checkpoints, err := parseCheckpoints(config.Siad.Checkpoints)
+	if err != nil {
+		return err
+	}
+
 	// Print a startup message.
 	fmt.Println("Loading...")
 	loadStart := time.Now()
 
+	// Outbound connections made by all modules go through the proxy.
+	if config.Siad.Proxy != "" {
+		if err := modules.SetProxy(modules.NetAddress(config.Siad.Proxy)); err != nil {
+			return errors.New("invalid proxy address: " + err.Error())
+		}
+	}
+
+	// The blocklist is shared by the gateway, the host, and the relay.
+	blocklist := modules.NewBlocklist()
+	if config.Siad.Blocklist != "" {
+		for _, r := range strings.Split(config.Siad.Blocklist, ",") {
+			if err := blocklist.Block(strings.TrimSpace(r)); err != nil {
+				return errors.New("invalid blocklist entry " + r + ": " + err.Error())
+			}
+		}
+	}
+
+	// The bandwidth limit is shared by the gateway and the host.
+	var rateLimit *modules.RateLimit
+	if config.Siad.MaxBandwidth > 0 {
+		rateLimit = modules.NewRateLimit(config.Siad.MaxBandwidth)
+	}
+
 	// Create all of the modules.
 	i := 0
 	var g modules.Gateway
 	if strings.Contains(config.Siad.Modules, "g") {
 		i++
 		fmt.Printf("(%d/%d) Loading gateway...\n", i, len(config.Siad.Modules))
-		g, err = gateway.New(config.Siad.RPCaddr, filepath.Join(config.Siad.SiaDir, modules.GatewayDir))
+		rpcAddrs := strings.Split(config.Siad.RPCaddr, ",")
+		gw, err := gateway.New(rpcAddrs[0], filepath.Join(config.Siad.SiaDir, modules.GatewayDir))
 		if err != nil {
 			return err
 		}
+		for _, addr := range rpcAddrs[1:] {
+			if err := gw.Listen(addr); err != nil {
+				return errors.New("unable to listen on " + addr + ": " + err.Error())
+			}
+		}
+		gw.SetRateLimits(rateLimit, config.Siad.MaxConnBandwidth)
+		gw.SetBlocklist(blocklist)
+		if err := gw.SetPeerLimits(config.Siad.MaxInboundPeers, config.Siad.MaxOutboundPeers); err != nil {
+			return err
+		}
+		if err := gw.SetRPCTimeout(config.Siad.RPCTimeout); err != nil {
+			return err
+		}
+		gw.SetCompression(config.Siad.Compress)
+		if config.Siad.LANDiscovery {
+			if err := gw.EnableLANDiscovery(); err != nil {
+				return errors.New("unable to enable LAN discovery: " + err.Error())
+			}
+		}
+		if !config.Siad.NoBootstrap && config.Siad.DNSSeeds != "" {
+			// Resolving the seeds may take a while, and the nodes are only
+			// needed once the gateway looks for new peers.
+			go gw.ResolveDNSSeeds(strings.Split(config.Siad.DNSSeeds, ","))
+		}
+		if config.Siad.PublicAddr != "" {
+			if err := gw.SetPublicAddress(modules.NetAddress(config.Siad.PublicAddr)); err != nil {
+				return errors.New("invalid public address: " + err.Error())
+			}
+		}
+		g = gw
 	}
 	var cs modules.ConsensusSet
 	if strings.Contains(config.Siad.Modules, "c") {
 		i++
 		fmt.Printf("(%d/%d) Loading consensus...\n", i, len(config.Siad.Modules))
-		cs, err = consensus.New(g, filepath.Join(config.Siad.SiaDir, modules.ConsensusDir))
+		if config.Siad.Snapshot != "" {
+			err = importSnapshot(config.Siad.Snapshot, config.Siad.SnapshotID, config.Siad.SnapshotChecksum, filepath.Join(config.Siad.SiaDir, modules.ConsensusDir))
+			if err != nil {
+				return err
+			}
+		}
+		c, err := consensus.New(g, filepath.Join(config.Siad.SiaDir, modules.ConsensusDir))
 		if err != nil {
 			return err
 		}
+		c.SetPruning(config.Siad.Prune)
+		for height, id := range checkpoints {
+			if err := c.AddCheckpoint(height, id); err != nil {
+				return fmt.Errorf("unable to add checkpoint at height %v: %v", height, err)
+			}
+		}
+		cs = c
 	}
 	var e modules.Explorer
 	if strings.Contains(config.Siad.Modules, "e") {
@@ -141,6 +473,15 @@ func startDaemon(config Config) (err error) {
 			return err
 		}
 	}
+	var lc modules.LightClient
+	if strings.Contains(config.Siad.Modules, "l") {
+		i++
+		fmt.Printf("(%d/%d) Loading light client...\n", i, len(config.Siad.Modules))
+		lc, err = lightclient.New(g, filepath.Join(config.Siad.SiaDir, modules.LightClientDir))
+		if err != nil {
+			return err
+		}
+	}
 	var tpool modules.TransactionPool
 	if strings.Contains(config.Siad.Modules, "t") {
 		i++
@@ -172,10 +513,46 @@ func startDaemon(config Config) (err error) {
 	if strings.Contains(config.Siad.Modules, "h") {
 		i++
 		fmt.Printf("(%d/%d) Loading host...\n", i, len(config.Siad.Modules))
-		h, err = host.New(cs, tpool, w, config.Siad.HostAddr, filepath.Join(config.Siad.SiaDir, modules.HostDir))
+		hostAddrs := strings.Split(config.Siad.HostAddr, ",")
+		hst, err := host.New(cs, tpool, w, hostAddrs[0], filepath.Join(config.Siad.SiaDir, modules.HostDir))
 		if err != nil {
 			return err
 		}
+		for _, addr := range hostAddrs[1:] {
+			if err := hst.Listen(addr); err != nil {
+				return errors.New("unable to listen on " + addr + ": " + err.Error())
+			}
+		}
+		hst.SetRateLimits(rateLimit, config.Siad.MaxConnBandwidth)
+		hst.SetBlocklist(blocklist)
+		if config.Siad.HostRelay != "" {
+			if err := hst.UseRelay(modules.NetAddress(config.Siad.HostRelay)); err != nil {
+				return errors.New("unable to use relay: " + err.Error())
+			}
+		}
+		if len(config.HostSettings) != 0 {
+			settings, err := applyHostSettings(hst.InternalSettings(), config.HostSettings)
+			if err != nil {
+				return err
+			}
+			if err := hst.SetInternalSettings(settings); err != nil {
+				return errors.New("unable to apply host settings: " + err.Error())
+			}
+		}
+		// A host that is also mining includes its own storage proofs in the
+		// blocks that it mines.
+		if m != nil {
+			hst.SetTransactionPrioritizer(m)
+		}
+		h = hst
+	}
+	if config.Siad.RelayAddr != "" {
+		rl, err := relay.New(config.Siad.RelayAddr, filepath.Join(config.Siad.SiaDir, modules.RelayDir))
+		if err != nil {
+			return errors.New("unable to start relay: " + err.Error())
+		}
+		rl.SetBlocklist(blocklist)
+		defer rl.Close()
 	}
 	var r modules.Renter
 	if strings.Contains(config.Siad.Modules, "r") {
@@ -186,14 +563,16 @@ func startDaemon(config Config) (err error) {
 			return err
 		}
 	}
+	apiAddrs := strings.Split(config.Siad.APIaddr, ",")
 	srv, err := api.NewServer(
-		config.Siad.APIaddr,
+		apiAddrs[0],
 		config.Siad.RequiredUserAgent,
 		config.APIPassword,
 		cs,
 		e,
 		g,
 		h,
+		lc,
 		m,
 		r,
 		tpool,
@@ -202,6 +581,42 @@ func startDaemon(config Config) (err error) {
 	if err != nil {
 		return err
 	}
+	for _, addr := range apiAddrs[1:] {
+		if err := srv.Listen(addr); err != nil {
+			return errors.New("unable to listen on " + addr + ": " + err.Error())
+		}
+	}
+	if config.Siad.DebugAPI {
+		srv.EnableDebug()
+	}
+	if config.Siad.APICORSOrigins != "" {
+		var origins []string
+		for _, origin := range strings.Split(config.Siad.APICORSOrigins, ",") {
+			origins = append(origins, strings.TrimSpace(origin))
+		}
+		srv.SetCORSOrigins(origins)
+	}
+	if err := srv.SetRateLimit(config.Siad.APIRateLimit, time.Minute); err != nil {
+		return errors.New("invalid --api-rate-limit: " + err.Error())
+	}
+	if config.Siad.AuditLog != "" {
+		auditLog := config.Siad.AuditLog
+		if !filepath.IsAbs(auditLog) {
+			auditLog = filepath.Join(config.Siad.SiaDir, auditLog)
+		}
+		if err := srv.SetAuditLog(auditLog); err != nil {
+			return errors.New("unable to open the audit log: " + err.Error())
+		}
+	}
+	if config.Siad.FaucetURL != "" {
+		srv.SetFaucetURL(config.Siad.FaucetURL)
+	}
+	defer flushOnPanic(srv)
+
+	// Reload the host settings from the config file on SIGHUP.
+	if h != nil {
+		defer reloadOnSignal(h, config.ConfigPath)()
+	}
 
 	// Bootstrap to the network.
 	if !config.Siad.NoBootstrap && g != nil {
@@ -218,6 +633,9 @@ func startDaemon(config Config) (err error) {
 	// Print a 'startup complete' message.
 	startupTime := time.Since(loadStart)
 	fmt.Println("Finished loading in", startupTime.Seconds(), "seconds")
+	if err := sdNotify("READY=1\nSTATUS=Finished loading"); err != nil {
+		fmt.Println("Unable to notify the service manager:", err)
+	}
 
 	// Start serving api requests.
 	err = srv.Serve()
@@ -227,8 +645,77 @@ func startDaemon(config Config) (err error) {
 	return nil
 }
 
+// reloadOnSignal reloads the host settings from the config file at 'path'
+// each time siad receives SIGHUP, so that the host can be reconfigured without
+// a restart. The returned function stops the reloading.
+func reloadOnSignal(h modules.Host, path string) func() {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-sigChan:
+			case <-stop:
+				return
+			}
+			settings, err := reloadHostSettings(h.InternalSettings(), path)
+			if err == nil {
+				err = h.SetInternalSettings(settings)
+			}
+			if err != nil {
+				fmt.Println("Unable to reload the host settings:", err)
+				continue
+			}
+			fmt.Println("Reloaded the host settings from", path)
+		}
+	}()
+	return func() {
+		signal.Stop(sigChan)
+		close(stop)
+	}
+}
+
+// flushOnPanic is deferred by startDaemon. If startDaemon panics after the
+// modules have been loaded, flushOnPanic closes the API server and the modules
+// before the panic continues, so that the modules save their state, such as
+// the host's storage obligations and the wallet's settings. A panic can leave
+// a module locked, so closing is given panicFlushTimeout to finish.
+//
+// Only panics on the goroutine of startDaemon are recovered. A panic in one of
+// the threads of a module still terminates siad without saving; panics in API
+// handlers are recovered by net/http, and do not terminate siad. Files that
+// were being saved when siad terminated are repaired by persist.RecoverDir at
+// the next startup.
+func flushOnPanic(srv io.Closer) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	fmt.Println("siad panicked, saving state before exiting...")
+	closed := make(chan error, 1)
+	go func() {
+		closed <- srv.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err != nil {
+			fmt.Println("Unable to save state:", err)
+		}
+	case <-time.After(panicFlushTimeout):
+		fmt.Println("Timed out saving state")
+	}
+	panic(r)
+}
+
 // startDaemonCmd is a passthrough function for startDaemon.
 func startDaemonCmd(cmd *cobra.Command, _ []string) {
+	// Apply the environment variables and the config file to the options
+	// that were not set on the command line.
+	if err := loadConfig(cmd.Flags(), &globalConfig); err != nil {
+		die(err)
+	}
+
 	// Create the profiling directory if profiling is enabled.
 	if globalConfig.Siad.Profile {
 		go profile.StartContinuousProfile(globalConfig.Siad.ProfileDir)
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/NebulousLabs/Sia/types"
 )
 
 // TestUnitProcessNetAddr probes the 'processNetAddr' function.
@@ -21,6 +26,71 @@ func TestUnitProcessNetAddr(t *testing.T) {
 	}
 }
 
+// TestUnitParseCheckpoints probes the 'parseCheckpoints' function.
+func TestUnitParseCheckpoints(t *testing.T) {
+	id := types.BlockID{1, 2, 3}
+	checkpoints, err := parseCheckpoints(fmt.Sprintf("10:%v, 20:%v", id, types.BlockID{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != 2 || checkpoints[10] != id || checkpoints[20] != (types.BlockID{}) {
+		t.Error("wrong checkpoints:", checkpoints)
+	}
+	if checkpoints, err := parseCheckpoints(""); err != nil || len(checkpoints) != 0 {
+		t.Error("expected no checkpoints:", checkpoints, err)
+	}
+	for _, s := range []string{"10", "x:" + id.String(), "10:abc", fmt.Sprintf("10:%v,", id)} {
+		if _, err := parseCheckpoints(s); err == nil {
+			t.Errorf("expected an error for %q", s)
+		}
+	}
+}
+
+// TestUnitSelectNetwork probes the 'selectNetwork' function.
+func TestUnitSelectNetwork(t *testing.T) {
+	defer types.SetNetwork(types.DefaultNetwork())
+
+	// The default network leaves the config untouched.
+	var config Config
+	config.Siad.SiaDir = "sia"
+	config.Siad.Network = types.DefaultNetwork().Name
+	selected, err := selectNetwork(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selected.Siad.SiaDir != "sia" || selected.Siad.NoBootstrap || types.GenesisID != types.DefaultNetwork().GenesisBlock().ID() {
+		t.Error("default network changed the config or the genesis block")
+	}
+
+	// Other networks replace the genesis block, use their own directory, and
+	// do not bootstrap.
+	config.Siad.Network = "regtest"
+	selected, err = selectNetwork(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selected.Siad.SiaDir != filepath.Join("sia", "regtest") || !selected.Siad.NoBootstrap {
+		t.Error("wrong config for regtest:", selected.Siad.SiaDir, selected.Siad.NoBootstrap)
+	}
+	if types.CurrentNetwork().Name != "regtest" || types.GenesisID != types.RegtestNetwork().GenesisBlock().ID() {
+		t.Error("regtest network was not selected")
+	}
+
+	// Selecting the default network again restores its parameters.
+	config.Siad.Network = types.DefaultNetwork().Name
+	if _, err := selectNetwork(config); err != nil {
+		t.Fatal(err)
+	}
+	if types.CurrentNetwork().Name != types.DefaultNetwork().Name || types.GenesisID != types.DefaultNetwork().GenesisBlock().ID() {
+		t.Error("default network was not restored")
+	}
+
+	config.Siad.Network = "unknown"
+	if _, err := selectNetwork(config); err == nil {
+		t.Error("expected an error for an unknown network")
+	}
+}
+
 // TestUnitProcessModules tests that processModules correctly processes modules
 // passed to the -M / --modules flag.
 func TestUnitProcessModules(t *testing.T) {
@@ -68,6 +138,26 @@ func TestUnitProcessModules(t *testing.T) {
 	}
 }
 
+// TestUnitCheckModuleDependencies checks that checkModuleDependencies rejects
+// modules that are loaded without the modules they need.
+func TestUnitCheckModuleDependencies(t *testing.T) {
+	for _, valid := range []string{"", "g", "gc", "gce", "gctwh", "gctwm", "gctwr", "cghmrtwe"} {
+		if err := checkModuleDependencies(valid); err != nil {
+			t.Errorf("modules %q were rejected: %v", valid, err)
+		}
+	}
+	for _, invalid := range []string{"c", "ge", "gcth", "gctm", "ctwr", "h"} {
+		if err := checkModuleDependencies(invalid); err == nil {
+			t.Errorf("modules %q were accepted", invalid)
+		}
+	}
+
+	err := checkModuleDependencies("gcth")
+	if err == nil || !strings.Contains(err.Error(), "the host (h) requires the wallet (w)") {
+		t.Error("wrong error for a host without a wallet:", err)
+	}
+}
+
 // TestUnitProcessConfig probes the 'processConfig' function.
 func TestUnitProcessConfig(t *testing.T) {
 	// Test valid configs.
@@ -78,10 +168,12 @@ func TestUnitProcessConfig(t *testing.T) {
 		inputs: [][]string{
 			{"9980", "9981", "9982", "cghmrtwe"},
 			{":9980", ":9981", ":9982", "CGHMRTWE"},
+			{"9980,localhost:9990", "9981, 10.0.0.1:9991", ":9982,9992", "cghmrtwe"},
 		},
 		expectedOutputs: [][]string{
 			{":9980", ":9981", ":9982", "cghmrtwe"},
 			{":9980", ":9981", ":9982", "cghmrtwe"},
+			{":9980,localhost:9990", ":9981,10.0.0.1:9991", ":9982,:9992", "cghmrtwe"},
 		},
 	}
 	var config Config
@@ -111,6 +203,17 @@ func TestUnitProcessConfig(t *testing.T) {
 	if err == nil {
 		t.Error("processModules didn't error on invalid module:", invalidModule)
 	}
+	config.Siad.Modules = "ch"
+	_, err = processConfig(config)
+	if err == nil {
+		t.Error("processConfig didn't error on missing module dependencies")
+	}
+	config.Siad.Modules = "cg"
+	config.Siad.LogLevel = "verbose"
+	_, err = processConfig(config)
+	if err == nil {
+		t.Error("processConfig didn't error on invalid log level")
+	}
 }
 
 // TestVerifyAPISecurity checks that the verifyAPISecurity function is
@@ -142,6 +245,15 @@ func TestVerifyAPISecurity(t *testing.T) {
 		t.Error("public + securityOn was accepted")
 	}
 
+	// Check that a public hostname is rejected when it is one of several
+	// addresses.
+	var securityOnList Config
+	securityOnList.Siad.APIaddr = "127.0.0.1:9980,sia.tech:9980"
+	err = verifyAPISecurity(securityOnList)
+	if err == nil {
+		t.Error("public address in a list + securityOn was accepted")
+	}
+
 	// Check that a public hostname is rejected when security is disabled and
 	// there is no api password.
 	var securityOffPublic Config
@@ -162,4 +274,57 @@ func TestVerifyAPISecurity(t *testing.T) {
 	if err != nil {
 		t.Error("public + securityOff with authentication was rejected:", err)
 	}
+
+	// Check that every origin is only allowed with an api password, and that
+	// specific origins are allowed without one.
+	var anyOrigin Config
+	anyOrigin.Siad.APIaddr = "localhost:9980"
+	anyOrigin.Siad.APICORSOrigins = "http://localhost:8080, *"
+	if err := verifyAPISecurity(anyOrigin); err == nil {
+		t.Error("every origin was allowed without authentication")
+	}
+	anyOrigin.Siad.AuthenticateAPI = true
+	if err := verifyAPISecurity(anyOrigin); err != nil {
+		t.Error("every origin with authentication was rejected:", err)
+	}
+	anyOrigin.Siad.AuthenticateAPI = false
+	anyOrigin.Siad.APICORSOrigins = "http://localhost:8080"
+	if err := verifyAPISecurity(anyOrigin); err != nil {
+		t.Error("a specific origin was rejected:", err)
+	}
+}
+
+// closeCounter is an io.Closer that counts the calls to Close.
+type closeCounter int
+
+// Close implements io.Closer.
+func (cc *closeCounter) Close() error {
+	*cc++
+	return nil
+}
+
+// TestUnitFlushOnPanic checks that flushOnPanic closes the server on a panic
+// and lets the panic continue, and does nothing otherwise.
+func TestUnitFlushOnPanic(t *testing.T) {
+	var cc closeCounter
+	func() {
+		defer flushOnPanic(&cc)
+	}()
+	if cc != 0 {
+		t.Fatal("server was closed without a panic")
+	}
+
+	r := func() (r interface{}) {
+		defer func() {
+			r = recover()
+		}()
+		defer flushOnPanic(&cc)
+		panic("test panic")
+	}()
+	if r != "test panic" {
+		t.Fatal("panic did not continue:", r)
+	}
+	if cc != 1 {
+		t.Fatal("server was not closed after a panic")
+	}
 }
@@ -3,10 +3,15 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/NebulousLabs/Sia/build"
+	"github.com/NebulousLabs/Sia/modules"
+	"github.com/NebulousLabs/Sia/modules/gateway"
+	"github.com/NebulousLabs/Sia/types"
 )
 
 var (
@@ -25,26 +30,69 @@ const (
 // The Config struct contains all configurable variables for siad. It is
 // compatible with gcfg.
 type Config struct {
-	// The APIPassword is input by the user after the daemon starts up, if the
-	// --authenticate-api flag is set.
+	// The APIPassword is input by the user after the daemon starts up, or
+	// read from SIAD_API_PASSWORD, if the --authenticate-api flag is set.
 	APIPassword string
 
+	// HostSettings are the internal settings of the host that are set by the
+	// config file, keyed by the names of the settings in the host API.
+	HostSettings map[string]string
+
+	// ConfigPath is the config file that the options were loaded from. It is
+	// read again when the host settings are reloaded.
+	ConfigPath string
+
 	// The Siad variables are referenced directly by cobra, and are set
 	// according to the flags.
 	Siad struct {
-		APIaddr      string
-		RPCaddr      string
-		HostAddr     string
-		AllowAPIBind bool
+		APIaddr        string
+		RPCaddr        string
+		HostAddr       string
+		AllowAPIBind   bool
+		APICORSOrigins string
+		APIRateLimit   int
+		AuditLog       string
 
 		Modules           string
+		Network           string
+		FaucetURL         string
 		NoBootstrap       bool
+		Prune             bool
+		Checkpoints       string
+		Snapshot          string
+		SnapshotID        string
+		SnapshotChecksum  string
+		Restore           string
 		RequiredUserAgent string
 		AuthenticateAPI   bool
 
+		MaxBandwidth     int64
+		MaxConnBandwidth int64
+
+		Proxy      string
+		PublicAddr string
+		Blocklist  string
+
+		MaxInboundPeers  int
+		MaxOutboundPeers int
+		RPCTimeout       time.Duration
+		Compress         bool
+		LANDiscovery     bool
+		DNSSeeds         string
+
+		RelayAddr string
+		HostRelay string
+
+		LogLevel   string
+		MaxLogSize int64
+		LogStdout  bool
+		PidFile    string
+
 		Profile    bool
 		ProfileDir string
+		DebugAPI   bool
 		SiaDir     string
+		ConfigFile string
 	}
 }
 
@@ -67,6 +115,10 @@ func versionCmd(*cobra.Command, []string) {
 	default:
 		fmt.Println("Sia Daemon v" + build.Version + "-???")
 	}
+	if build.GitRevision != "" {
+		fmt.Println("Git Revision " + build.GitRevision)
+	}
+	fmt.Println("Protocol Version " + build.ProtocolVersion)
 }
 
 // modulesCmd is a cobra command that prints help info about modules.
@@ -79,6 +131,10 @@ modules are run. The default modules are:
 	gateway, consensus set, host, miner, renter, transaction pool, wallet
 This is equivalent to:
 	siad -M cghmrtw
+The modules that each module requires are listed below, and siad will not
+start if a module is enabled without them. For example, a dedicated host that does not
+rent or mine only needs:
+	siad -M gctwh
 Below is a list of all the modules available.
 
 Gateway (g):
@@ -127,7 +183,14 @@ Explorer (e):
 	the blockchain.
 	The explorer requires the consenus set.
 	Example:
-		siad -M gce`)
+		siad -M gce
+Light Client (l):
+	The light client tracks only the headers of the blockchain, along with
+	proofs of the transactions of the addresses and file contracts that it
+	is told to track, instead of the full blockchain.
+	The light client requires the gateway.
+	Example:
+		siad -M gl`)
 }
 
 // main establishes a set of commands and flags using the cobra package.
@@ -155,19 +218,50 @@ func main() {
 
 	// Set default values, which have the lowest priority.
 	root.Flags().StringVarP(&globalConfig.Siad.RequiredUserAgent, "agent", "", "Sia-Agent", "required substring for the user agent")
-	root.Flags().StringVarP(&globalConfig.Siad.HostAddr, "host-addr", "", ":9982", "which port the host listens on")
+	root.Flags().StringVarP(&globalConfig.Siad.HostAddr, "host-addr", "", ":9982", "which port the host listens on (a comma-separated list to listen on several addresses, the first of which is announced)")
 	root.Flags().StringVarP(&globalConfig.Siad.ProfileDir, "profile-directory", "", "profiles", "location of the profiling directory")
-	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:9980", "which host:port the API server listens on")
+	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:9980", "which host:port the API server listens on (a comma-separated list to listen on several addresses)")
 	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
+	root.Flags().StringVarP(&globalConfig.Siad.ConfigFile, "config-file", "", "", "location of the config file (defaults to "+defaultConfigFile+" in the sia directory)")
+	root.Flags().StringVarP(&globalConfig.Siad.FaucetURL, "faucet-url", "", "", "faucet that 'siac wallet faucet' requests testnet coins from when no faucet is given")
+	root.Flags().StringVarP(&globalConfig.Siad.Network, "network", "", types.DefaultNetwork().Name, "which network to join: "+types.DefaultNetwork().Name+", testnet, or regtest")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
 	root.Flags().BoolVarP(&globalConfig.Siad.Profile, "profile", "", false, "enable profiling")
-	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
+	root.Flags().BoolVarP(&globalConfig.Siad.DebugAPI, "debug-api", "", false, "serve profiles and runtime statistics under /debug on the API")
+	root.Flags().StringVarP(&globalConfig.Siad.LogLevel, "log-level", "", "info", "minimum severity of the messages written to the module logs: debug, info, warn, or error")
+	root.Flags().Int64VarP(&globalConfig.Siad.MaxLogSize, "max-log-size", "", 0, "size in bytes at which log files are rotated (0 to never rotate)")
+	root.Flags().BoolVarP(&globalConfig.Siad.LogStdout, "log-stdout", "", false, "also write the module logs to stdout, for service managers that collect the output of siad")
+	root.Flags().StringVarP(&globalConfig.Siad.PidFile, "pidfile", "", "", "write the process id of siad to this file while it runs")
+	root.Flags().Int64VarP(&globalConfig.Siad.MaxBandwidth, "max-bandwidth", "", 0, "limit on the combined bandwidth of all peer and renter connections, in bytes per second (0 for no limit)")
+	root.Flags().Int64VarP(&globalConfig.Siad.MaxConnBandwidth, "max-conn-bandwidth", "", 0, "limit on the bandwidth of each peer and renter connection, in bytes per second (0 for no limit)")
+	root.Flags().StringVarP(&globalConfig.Siad.Proxy, "proxy", "", "", "SOCKS5 proxy, such as Tor, to make all outbound peer and host connections through")
+	root.Flags().StringVarP(&globalConfig.Siad.PublicAddr, "public-addr", "", "", "address advertised to peers instead of the discovered external IP, such as a Tor onion address")
+	root.Flags().StringVarP(&globalConfig.Siad.Blocklist, "blocklist", "", "", "comma-separated list of IP addresses and CIDR ranges that are not allowed to connect as peers or renters")
+	root.Flags().IntVarP(&globalConfig.Siad.MaxInboundPeers, "max-inbound-peers", "", 120, "maximum number of peers that can connect to the gateway (0 to reject inbound connections)")
+	root.Flags().IntVarP(&globalConfig.Siad.MaxOutboundPeers, "max-outbound-peers", "", modules.WellConnectedThreshold, "number of peers that the gateway connects to")
+	root.Flags().DurationVarP(&globalConfig.Siad.RPCTimeout, "rpc-timeout", "", gateway.DefaultRPCTimeout, "deadline for each peer RPC, after which a stalled peer is abandoned")
+	root.Flags().BoolVarP(&globalConfig.Siad.Compress, "compress", "", false, "compress block sync traffic with peers that also enable compression")
+	root.Flags().StringVarP(&globalConfig.Siad.DNSSeeds, "dns-seeds", "", strings.Join(modules.DNSSeeds, ","), "comma-separated list of DNS seeds to find peers with when bootstrapping")
+	root.Flags().BoolVarP(&globalConfig.Siad.LANDiscovery, "lan-discovery", "", false, "discover and connect to other nodes on the local network that also enable LAN discovery")
+	root.Flags().StringVarP(&globalConfig.Siad.RelayAddr, "relay-addr", "", "", "run a relay on this address that forwards renter connections to hosts behind NATs (disabled if empty)")
+	root.Flags().StringVarP(&globalConfig.Siad.HostRelay, "host-relay", "", "", "address of a relay that renters reach the host through, for hosts that cannot accept incoming connections")
+	root.Flags().BoolVarP(&globalConfig.Siad.Prune, "prune", "", false, "discard the transactions of old blocks to save disk space")
+	root.Flags().StringVarP(&globalConfig.Siad.Checkpoints, "checkpoints", "", "", "comma-separated list of trusted blocks as height:id, which blocks must match and whose ancestors skip signature checks during sync")
+	root.Flags().StringVarP(&globalConfig.Siad.Snapshot, "snapshot", "", "", "bootstrap the consensus set from a snapshot file")
+	root.Flags().StringVarP(&globalConfig.Siad.SnapshotID, "snapshot-id", "", "", "trusted id of the block that the snapshot ends at")
+	root.Flags().StringVarP(&globalConfig.Siad.SnapshotChecksum, "snapshot-checksum", "", "", "trusted checksum of the consensus state at the block that the snapshot ends at")
+	root.Flags().StringVarP(&globalConfig.Siad.Restore, "restore", "", "", "restore the wallet, renter, and host from a backup made by 'siac backup' before loading the modules")
+	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on (a comma-separated list to listen on several addresses, the first of which is advertised to peers)")
 	root.Flags().StringVarP(&globalConfig.Siad.Modules, "modules", "M", "cghmrtw", "enabled modules, see 'siad modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", false, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
+	root.Flags().StringVarP(&globalConfig.Siad.APICORSOrigins, "api-cors-origins", "", "", "comma-separated list of origins that browser-based UIs may call the API from, such as http://localhost:8080 (* for any origin without credentials, which requires --authenticate-api)")
+	root.Flags().IntVarP(&globalConfig.Siad.APIRateLimit, "api-rate-limit", "", 0, "number of expensive API calls, such as uploads and wallet rescans, that each client can make per minute (0 for no limit)")
+	root.Flags().StringVarP(&globalConfig.Siad.AuditLog, "audit-log", "", "", "append a record of each API call that changes the state of the node to this file (relative to the sia directory)")
 
-	// Parse cmdline flags, overwriting both the default values and the config
-	// file values.
+	// Parse cmdline flags, overwriting the default values. The environment
+	// variables and the config file are applied to the flags that are not set
+	// by startDaemonCmd.
 	if err := root.Execute(); err != nil {
 		// Since no commands return errors (all commands set Command.Run instead of
 		// Command.RunE), Command.Execute() should only return an error on an
@@ -0,0 +1,68 @@
+package main
+
+// service.go helps service managers such as systemd to supervise siad. siad
+// always runs in the foreground; a supervisor that expects a daemon to fork
+// should be configured to treat siad as a simple or notify service.
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// apiPasswordEnv is the environment variable that holds the API
+	// password when --authenticate-api is set, so that siad can be started
+	// without a terminal to enter the password in.
+	apiPasswordEnv = envPrefix + "API_PASSWORD"
+
+	// backupPasswordEnv is the environment variable that holds the password
+	// of the backup restored with --restore.
+	backupPasswordEnv = envPrefix + "BACKUP_PASSWORD"
+
+	// notifySocketEnv is the environment variable through which systemd
+	// passes the socket that sdNotify writes to.
+	notifySocketEnv = "NOTIFY_SOCKET"
+)
+
+// writePidFile writes the process id of siad to 'filename'.
+func writePidFile(filename string) error {
+	return ioutil.WriteFile(filename, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+}
+
+// removePidFile removes the pid file written by writePidFile, unless it has
+// since been replaced by another process.
+func removePidFile(filename string) error {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(b)) != strconv.Itoa(os.Getpid()) {
+		return errors.New("pid file belongs to another process")
+	}
+	return os.Remove(filename)
+}
+
+// sdNotify sends 'state', such as "READY=1", to the service manager using the
+// sd_notify protocol. It does nothing if siad was not started by a service
+// manager that expects notifications.
+func sdNotify(state string) error {
+	socket := os.Getenv(notifySocketEnv)
+	if socket == "" {
+		return nil
+	}
+	// A leading '@' denotes a socket in the abstract namespace.
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+// TestPidFile checks that the pid file holds the pid of siad, and that it is
+// only removed by the process that wrote it.
+func TestPidFile(t *testing.T) {
+	dir := build.TempDir("siad", "TestPidFile")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	pidFile := filepath.Join(dir, "siad.pid")
+	if err := writePidFile(pidFile); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != strconv.Itoa(os.Getpid())+"\n" {
+		t.Fatal("wrong pid file contents:", string(b))
+	}
+	if err := removePidFile(pidFile); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(pidFile); !os.IsNotExist(err) {
+		t.Fatal("pid file was not removed")
+	}
+
+	// A pid file written by another process is left alone.
+	if err := ioutil.WriteFile(pidFile, []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := removePidFile(pidFile); err == nil {
+		t.Fatal("removed the pid file of another process")
+	}
+}
+
+// TestSdNotify checks that sdNotify sends its state to the socket in
+// NOTIFY_SOCKET, and does nothing when it is not set.
+func TestSdNotify(t *testing.T) {
+	defer os.Setenv(notifySocketEnv, os.Getenv(notifySocketEnv))
+	os.Unsetenv(notifySocketEnv)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := build.TempDir("siad", "TestSdNotify")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Skip("unix sockets are not supported:", err)
+	}
+	defer conn.Close()
+	os.Setenv(notifySocketEnv, socket)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 64)
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf[:n]) != "READY=1" {
+		t.Fatal("wrong notification:", string(buf[:n]))
+	}
+}
@@ -45,35 +45,33 @@ type (
 		MerkleRoot crypto.Hash `json:"merkleroot"`
 	}
 
+	// A TransactionProof is a Merkle proof that a transaction is part of a
+	// block. The proof can be verified using only the header of the block,
+	// which allows light clients to confirm transactions without downloading
+	// full blocks.
+	TransactionProof struct {
+		BlockID     BlockID       `json:"blockid"`
+		Transaction Transaction   `json:"transaction"`
+		ProofIndex  uint64        `json:"proofindex"`
+		NumLeaves   uint64        `json:"numleaves"`
+		HashSet     []crypto.Hash `json:"hashset"`
+	}
+
 	BlockHeight uint64
 	BlockID     crypto.Hash
 	BlockNonce  [8]byte
 )
 
-// CalculateCoinbase calculates the coinbase for a given height. The coinbase
-// equation is:
-//
-//     coinbase := max(InitialCoinbase - height, MinimumCoinbase) * SiacoinPrecision
+// CalculateCoinbase calculates the coinbase for a given height on the current
+// network. See NetworkParams.Coinbase for the coinbase equation.
 func CalculateCoinbase(height BlockHeight) Currency {
-	base := InitialCoinbase - uint64(height)
-	if uint64(height) > InitialCoinbase || base < MinimumCoinbase {
-		base = MinimumCoinbase
-	}
-	return NewCurrency64(base).Mul(SiacoinPrecision)
+	return CurrentNetwork().Coinbase(height)
 }
 
 // CalculateNumSiacoins calculates the number of siacoins in circulation at a
-// given height.
+// given height on the current network.
 func CalculateNumSiacoins(height BlockHeight) Currency {
-	deflationBlocks := BlockHeight(InitialCoinbase - MinimumCoinbase)
-	avgDeflationSiacoins := CalculateCoinbase(0).Add(CalculateCoinbase(height)).Div(NewCurrency64(2))
-	if height <= deflationBlocks {
-		deflationSiacoins := avgDeflationSiacoins.Mul(NewCurrency64(uint64(height + 1)))
-		return deflationSiacoins
-	}
-	deflationSiacoins := avgDeflationSiacoins.Mul(NewCurrency64(uint64(deflationBlocks + 1)))
-	trailingSiacoins := NewCurrency64(uint64(height - deflationBlocks)).Mul(CalculateCoinbase(height))
-	return deflationSiacoins.Add(trailingSiacoins)
+	return CurrentNetwork().NumSiacoins(height)
 }
 
 // ID returns the ID of a Block, which is calculated by hashing the header.
@@ -124,6 +122,42 @@ func (b Block) MerkleRoot() crypto.Hash {
 	return tree.Root()
 }
 
+// TransactionProof returns a Merkle proof that the transaction at index 'i'
+// is a part of the block.
+func (b Block) TransactionProof(i int) TransactionProof {
+	tree := crypto.NewTree()
+	proofIndex := uint64(len(b.MinerPayouts) + i)
+	tree.SetIndex(proofIndex)
+	for _, payout := range b.MinerPayouts {
+		tree.PushObject(payout)
+	}
+	for _, txn := range b.Transactions {
+		tree.PushObject(txn)
+	}
+	_, proof, _, numLeaves := tree.Prove()
+
+	hashSet := make([]crypto.Hash, len(proof)-1)
+	for j, p := range proof[1:] {
+		copy(hashSet[j][:], p)
+	}
+	return TransactionProof{
+		BlockID:     b.ID(),
+		Transaction: b.Transactions[i],
+		ProofIndex:  proofIndex,
+		NumLeaves:   numLeaves,
+		HashSet:     hashSet,
+	}
+}
+
+// Verify returns true if the proof shows that the transaction is part of the
+// block with the given header.
+func (tp TransactionProof) Verify(h BlockHeader) bool {
+	if h.ID() != tp.BlockID {
+		return false
+	}
+	return crypto.VerifySegment(encoding.Marshal(tp.Transaction), tp.HashSet, tp.NumLeaves, tp.ProofIndex, h.MerkleRoot)
+}
+
 // MinerPayoutID returns the ID of the miner payout at the given index, which
 // is calculated by hashing the concatenation of the BlockID and the payout
 // index.
@@ -252,3 +252,35 @@ func TestBlockEncoding(t *testing.T) {
 		t.Fatal("block changed after encode/decode:", b, decB)
 	}
 }
+
+// TestBlockTransactionProof checks that transaction proofs can be verified
+// against the header of the block they were created from.
+func TestBlockTransactionProof(t *testing.T) {
+	b := Block{
+		MinerPayouts: []SiacoinOutput{{Value: NewCurrency64(1)}, {Value: NewCurrency64(2)}},
+		Transactions: []Transaction{
+			{ArbitraryData: [][]byte{{1}}},
+			{ArbitraryData: [][]byte{{2}}},
+			{ArbitraryData: [][]byte{{3}}},
+		},
+	}
+	h := b.Header()
+	for i := range b.Transactions {
+		tp := b.TransactionProof(i)
+		if !tp.Verify(h) {
+			t.Error("valid transaction proof was rejected:", i)
+		}
+	}
+
+	// Proofs for a different transaction or block should fail.
+	tp := b.TransactionProof(1)
+	tp.Transaction = b.Transactions[0]
+	if tp.Verify(h) {
+		t.Error("proof with the wrong transaction was accepted")
+	}
+	tp = b.TransactionProof(1)
+	b.Nonce[0]++
+	if tp.Verify(b.Header()) {
+		t.Error("proof was accepted for the wrong block")
+	}
+}
@@ -13,7 +13,14 @@ import (
 )
 
 var (
-	BlockSizeLimit   = uint64(2e6)
+	BlockSizeLimit = uint64(2e6)
+
+	// MaxTransactionSize is the size of the largest transaction that can fit
+	// in a block. 5kb of every block is reserved for the header and the miner
+	// payouts, so the transactions of a block cannot exceed this size in
+	// total either.
+	MaxTransactionSize = BlockSizeLimit - 5e3
+
 	RootDepth        = Target{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255}
 	BlockFrequency   BlockHeight
 	MaturityDelay    BlockHeight
@@ -27,6 +34,13 @@ var (
 	FutureThreshold        Timestamp
 	ExtremeFutureThreshold Timestamp
 
+	// MinDifficultyGap is the number of seconds after which a block may be
+	// mined at the root target instead of the target of its parent, and the
+	// target may never become easier than the root target. Zero disables the
+	// rule, which is only enabled on test networks, so that they do not stall
+	// when hashpower leaves.
+	MinDifficultyGap Timestamp
+
 	SiafundCount     = NewCurrency64(10000)
 	SiafundPortion   = big.NewRat(39, 1000)
 	SiacoinPrecision = NewCurrency(new(big.Int).Exp(big.NewInt(10), big.NewInt(24), nil))
@@ -0,0 +1,197 @@
+package types
+
+// network.go contains the parameters that distinguish one Sia network from
+// another. The parameters of the network selected by build.Release are set in
+// constants.go. Other networks, such as a public testnet or a local regression
+// testing network, can be selected at startup with SetNetwork, which allows
+// multiple networks to be run from the same binary.
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/NebulousLabs/Sia/build"
+)
+
+var (
+	errUnknownNetwork = errors.New("unrecognized network name")
+
+	// defaultNetwork contains the parameters set by constants.go, and
+	// currentNetwork contains the parameters of the network that is in use.
+	// currentNetwork is cached so that hot paths such as CalculateCoinbase do
+	// not need to assemble the parameters on every call.
+	defaultNetwork NetworkParams
+	currentNetwork NetworkParams
+)
+
+// NetworkParams contains the genesis block and the consensus parameters of a
+// network.
+type NetworkParams struct {
+	// Name identifies the network.
+	Name string
+
+	BlockFrequency         BlockHeight
+	MaturityDelay          BlockHeight
+	GenesisTimestamp       Timestamp
+	RootTarget             Target
+	TargetWindow           BlockHeight
+	MaxAdjustmentUp        *big.Rat
+	MaxAdjustmentDown      *big.Rat
+	FutureThreshold        Timestamp
+	ExtremeFutureThreshold Timestamp
+	MinDifficultyGap       Timestamp
+
+	// The block subsidy starts at InitialCoinbase siacoins and decreases by
+	// one siacoin per block until it reaches MinimumCoinbase siacoins.
+	InitialCoinbase uint64
+	MinimumCoinbase uint64
+
+	GenesisSiafundAllocation []SiafundOutput
+}
+
+// init records the parameters of the network selected by build.Release. It
+// runs after the init function of constants.go, as files are initialized in
+// the order that they are presented to the compiler.
+func init() {
+	defaultNetwork = NetworkParams{
+		BlockFrequency:           BlockFrequency,
+		MaturityDelay:            MaturityDelay,
+		GenesisTimestamp:         GenesisTimestamp,
+		RootTarget:               RootTarget,
+		TargetWindow:             TargetWindow,
+		MaxAdjustmentUp:          MaxAdjustmentUp,
+		MaxAdjustmentDown:        MaxAdjustmentDown,
+		FutureThreshold:          FutureThreshold,
+		ExtremeFutureThreshold:   ExtremeFutureThreshold,
+		MinDifficultyGap:         MinDifficultyGap,
+		InitialCoinbase:          InitialCoinbase,
+		MinimumCoinbase:          MinimumCoinbase,
+		GenesisSiafundAllocation: GenesisSiafundAllocation,
+	}
+	switch build.Release {
+	case "standard":
+		defaultNetwork.Name = "mainnet"
+	default:
+		defaultNetwork.Name = build.Release
+	}
+	currentNetwork = defaultNetwork
+}
+
+// DefaultNetwork returns the parameters of the network selected by
+// build.Release.
+func DefaultNetwork() NetworkParams {
+	return defaultNetwork
+}
+
+// TestnetNetwork returns the parameters of a public test network. The testnet
+// follows the rules of the default network, but has its own genesis block and
+// a lower initial difficulty. Blocks found more than four block intervals
+// after their parent may be mined at the initial difficulty, so that the
+// testnet does not stall when hashpower leaves.
+func TestnetNetwork() NetworkParams {
+	p := defaultNetwork
+	p.Name = "testnet"
+	p.GenesisTimestamp = defaultNetwork.GenesisTimestamp + 1
+	p.RootTarget = RatToTarget(new(big.Rat).Mul(defaultNetwork.RootTarget.Rat(), big.NewRat(1<<10, 1)))
+	p.MinDifficultyGap = 4 * Timestamp(defaultNetwork.BlockFrequency)
+	return p
+}
+
+// RegtestNetwork returns the parameters of a local regression testing
+// network. Blocks can be mined instantly, and the difficulty barely adjusts.
+func RegtestNetwork() NetworkParams {
+	p := defaultNetwork
+	p.Name = "regtest"
+	p.GenesisTimestamp = defaultNetwork.GenesisTimestamp + 2
+	p.BlockFrequency = 1
+	p.MaturityDelay = 3
+	p.RootTarget = Target{128}
+	p.TargetWindow = 200
+	p.MaxAdjustmentUp = big.NewRat(10001, 10000)
+	p.MaxAdjustmentDown = big.NewRat(9999, 10000)
+	return p
+}
+
+// NetworkByName returns the parameters of the network with the given name.
+func NetworkByName(name string) (NetworkParams, error) {
+	switch name {
+	case defaultNetwork.Name:
+		return DefaultNetwork(), nil
+	case "testnet":
+		return TestnetNetwork(), nil
+	case "regtest":
+		return RegtestNetwork(), nil
+	default:
+		return NetworkParams{}, errUnknownNetwork
+	}
+}
+
+// CurrentNetwork returns the parameters of the network that is currently in
+// use.
+func CurrentNetwork() NetworkParams {
+	return currentNetwork
+}
+
+// Coinbase returns the block subsidy of the network at the given height. The
+// coinbase equation is:
+//
+//	coinbase := max(InitialCoinbase - height, MinimumCoinbase) * SiacoinPrecision
+func (p NetworkParams) Coinbase(height BlockHeight) Currency {
+	base := p.InitialCoinbase - uint64(height)
+	if uint64(height) > p.InitialCoinbase || base < p.MinimumCoinbase {
+		base = p.MinimumCoinbase
+	}
+	return NewCurrency64(base).Mul(SiacoinPrecision)
+}
+
+// NumSiacoins returns the number of siacoins that the block subsidies of the
+// network have created up to and including the given height.
+func (p NetworkParams) NumSiacoins(height BlockHeight) Currency {
+	// If the subsidy never decreases, every block has the same subsidy.
+	if p.MinimumCoinbase >= p.InitialCoinbase {
+		return p.Coinbase(0).Mul64(uint64(height + 1))
+	}
+
+	deflationBlocks := BlockHeight(p.InitialCoinbase - p.MinimumCoinbase)
+	avgDeflationSiacoins := p.Coinbase(0).Add(p.Coinbase(height)).Div(NewCurrency64(2))
+	if height <= deflationBlocks {
+		deflationSiacoins := avgDeflationSiacoins.Mul(NewCurrency64(uint64(height + 1)))
+		return deflationSiacoins
+	}
+	deflationSiacoins := avgDeflationSiacoins.Mul(NewCurrency64(uint64(deflationBlocks + 1)))
+	trailingSiacoins := NewCurrency64(uint64(height - deflationBlocks)).Mul(p.Coinbase(height))
+	return deflationSiacoins.Add(trailingSiacoins)
+}
+
+// GenesisBlock returns the genesis block of the network.
+func (p NetworkParams) GenesisBlock() Block {
+	return Block{
+		Timestamp: p.GenesisTimestamp,
+		Transactions: []Transaction{
+			{SiafundOutputs: p.GenesisSiafundAllocation},
+		},
+	}
+}
+
+// SetNetwork replaces the network parameters, including the genesis block.
+// SetNetwork must be called at startup, before any modules are created, as
+// the parameters are read without synchronization.
+func SetNetwork(p NetworkParams) {
+	BlockFrequency = p.BlockFrequency
+	MaturityDelay = p.MaturityDelay
+	GenesisTimestamp = p.GenesisTimestamp
+	RootTarget = p.RootTarget
+	TargetWindow = p.TargetWindow
+	MaxAdjustmentUp = p.MaxAdjustmentUp
+	MaxAdjustmentDown = p.MaxAdjustmentDown
+	FutureThreshold = p.FutureThreshold
+	ExtremeFutureThreshold = p.ExtremeFutureThreshold
+	MinDifficultyGap = p.MinDifficultyGap
+	InitialCoinbase = p.InitialCoinbase
+	MinimumCoinbase = p.MinimumCoinbase
+	GenesisSiafundAllocation = p.GenesisSiafundAllocation
+
+	GenesisBlock = p.GenesisBlock()
+	GenesisID = GenesisBlock.ID()
+	currentNetwork = p
+}
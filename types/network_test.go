@@ -0,0 +1,70 @@
+package types
+
+import (
+	"testing"
+)
+
+// TestSetNetwork checks that SetNetwork replaces the network parameters and
+// the genesis block.
+func TestSetNetwork(t *testing.T) {
+	// Tests in this package run sequentially, so the network can be
+	// temporarily replaced.
+	defer SetNetwork(DefaultNetwork())
+
+	if CurrentNetwork().Name != "testing" {
+		t.Fatal("wrong default network name:", CurrentNetwork().Name)
+	}
+	defaultGenesis := GenesisID
+	for _, name := range []string{"testnet", "regtest"} {
+		p, err := NetworkByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		SetNetwork(p)
+		if CurrentNetwork().Name != name {
+			t.Error("wrong network name after SetNetwork:", CurrentNetwork().Name)
+		}
+		if GenesisID == defaultGenesis || GenesisID != GenesisBlock.ID() {
+			t.Error("genesis block was not replaced for", name)
+		}
+		if RootTarget != p.RootTarget || MaturityDelay != p.MaturityDelay {
+			t.Error("parameters were not replaced for", name)
+		}
+	}
+
+	SetNetwork(DefaultNetwork())
+	if GenesisID != defaultGenesis {
+		t.Error("genesis block was not restored")
+	}
+	if _, err := NetworkByName("unknown"); err != errUnknownNetwork {
+		t.Error("expected errUnknownNetwork, got", err)
+	}
+}
+
+// TestNetworkSubsidySchedule checks the block subsidy and siacoin supply of
+// networks with custom subsidy schedules.
+func TestNetworkSubsidySchedule(t *testing.T) {
+	p := NetworkParams{InitialCoinbase: 100, MinimumCoinbase: 90}
+	if p.Coinbase(5).Cmp(NewCurrency64(95).Mul(SiacoinPrecision)) != 0 {
+		t.Error("wrong coinbase during the deflation period:", p.Coinbase(5))
+	}
+	if p.Coinbase(50).Cmp(NewCurrency64(90).Mul(SiacoinPrecision)) != 0 {
+		t.Error("wrong coinbase after the deflation period:", p.Coinbase(50))
+	}
+
+	// A schedule where the minimum is not below the initial subsidy has a
+	// constant subsidy.
+	flat := NetworkParams{InitialCoinbase: 10, MinimumCoinbase: 20}
+	for _, p := range []NetworkParams{p, flat} {
+		total := ZeroCurrency
+		for height := BlockHeight(0); height < 30; height++ {
+			total = total.Add(p.Coinbase(height))
+			if total.Cmp(p.NumSiacoins(height)) != 0 {
+				t.Fatal("supply miscalculation at height", height, total, p.NumSiacoins(height))
+			}
+		}
+	}
+	if flat.Coinbase(0).Cmp(flat.Coinbase(100)) != 0 {
+		t.Error("subsidy of a flat schedule changed")
+	}
+}
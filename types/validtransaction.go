@@ -111,9 +111,7 @@ func (t Transaction) correctFileContractRevisions(currentHeight BlockHeight) err
 // Currently there is no limitation on transaction size other than it must fit
 // in a block.
 func (t Transaction) fitsInABlock() error {
-	// Check that the transaction will fit inside of a block, leaving 5kb for
-	// overhead.
-	if uint64(len(encoding.Marshal(t))) > BlockSizeLimit-5e3 {
+	if uint64(len(encoding.Marshal(t))) > MaxTransactionSize {
 		return ErrTransactionTooLarge
 	}
 	return nil
@@ -266,6 +264,18 @@ func (t Transaction) validUnlockConditions(currentHeight BlockHeight) (err error
 // transaction. StandaloneValid will not check that all outputs being spent are
 // legal outputs, as it has no confirmed or unconfirmed set to look at.
 func (t Transaction) StandaloneValid(currentHeight BlockHeight) (err error) {
+	err = t.StandaloneValidUnsigned(currentHeight)
+	if err != nil {
+		return
+	}
+	return t.validSignatures(currentHeight)
+}
+
+// StandaloneValidUnsigned performs all of the checks of StandaloneValid
+// except for the signature checks. It should only be used on transactions
+// that are already known to be valid, such as transactions in blocks beneath
+// a trusted checkpoint.
+func (t Transaction) StandaloneValidUnsigned(currentHeight BlockHeight) (err error) {
 	err = t.fitsInABlock()
 	if err != nil {
 		return
@@ -290,13 +300,5 @@ func (t Transaction) StandaloneValid(currentHeight BlockHeight) (err error) {
 	if err != nil {
 		return
 	}
-	err = t.validUnlockConditions(currentHeight)
-	if err != nil {
-		return
-	}
-	err = t.validSignatures(currentHeight)
-	if err != nil {
-		return
-	}
-	return
+	return t.validUnlockConditions(currentHeight)
 }